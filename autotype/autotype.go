@@ -0,0 +1,104 @@
+package autotype
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultSequence is typed for accounts without a custom sequence
+const DefaultSequence = "{USERNAME}{TAB}{PASSWORD}{ENTER}"
+
+// special keys which can be used in a sequence
+const (
+	KeyTab   = "TAB"
+	KeyEnter = "ENTER"
+	KeySpace = "SPACE"
+)
+
+var (
+	ErrInvalidSequence = fmt.Errorf("invalid auto-type sequence")
+	ErrNoBackend       = fmt.Errorf("no keyboard emulation available (install xdotool or wtype)")
+)
+
+// Keystroke is either literal text or a special key
+type Keystroke struct {
+	Text string
+	Key  string
+}
+
+// Fields maps placeholder names (e.g. USERNAME) to their value
+type Fields map[string]string
+
+// Parse resolves the placeholders of a sequence like {USERNAME}{TAB}{PASSWORD}{ENTER}
+// into keystrokes. Placeholders are either fields or special keys; any other
+// text is typed as is
+func Parse(sequence string, fields Fields) ([]Keystroke, error) {
+	var keystrokes []Keystroke
+	for len(sequence) > 0 {
+		open := strings.IndexRune(sequence, '{')
+		if open < 0 {
+			keystrokes = append(keystrokes, Keystroke{Text: sequence})
+			break
+		}
+		if open > 0 {
+			keystrokes = append(keystrokes, Keystroke{Text: sequence[:open]})
+		}
+		end := strings.IndexRune(sequence[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: missing closing brace", ErrInvalidSequence)
+		}
+		name := strings.ToUpper(sequence[open+1 : open+end])
+		switch name {
+		case KeyTab, KeyEnter, KeySpace:
+			keystrokes = append(keystrokes, Keystroke{Key: name})
+		default:
+			value, ok := fields[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown placeholder {%s}", ErrInvalidSequence, name)
+			}
+			keystrokes = append(keystrokes, Keystroke{Text: value})
+		}
+		sequence = sequence[open+end+1:]
+	}
+	return keystrokes, nil
+}
+
+// Backend emulates a keyboard
+type Backend interface {
+	// SwitchWindow focuses the previously active window
+	SwitchWindow(ctx context.Context) error
+	// Type types literal text
+	Type(ctx context.Context, text string) error
+	// Press presses a special key
+	Press(ctx context.Context, key string) error
+}
+
+// Run types the keystrokes using the backend. If switchWindow is set the
+// previously active window is focused first. The delay is waited before typing
+// to let the target window become ready
+func Run(ctx context.Context, backend Backend, keystrokes []Keystroke, switchWindow bool, delay time.Duration) error {
+	if switchWindow {
+		if err := backend.SwitchWindow(ctx); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for _, k := range keystrokes {
+		var err error
+		if k.Key != "" {
+			err = backend.Press(ctx, k.Key)
+		} else if k.Text != "" {
+			err = backend.Type(ctx, k.Text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package autotype
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	fields := Fields{"USERNAME": "sherlock", "PASSWORD": "221b"}
+	tt := []struct {
+		sequence string
+		expect   []Keystroke
+		err      error
+	}{
+		{
+			sequence: DefaultSequence,
+			expect: []Keystroke{
+				{Text: "sherlock"}, {Key: KeyTab}, {Text: "221b"}, {Key: KeyEnter},
+			},
+		},
+		{
+			sequence: "login {username}{space}now",
+			expect: []Keystroke{
+				{Text: "login "}, {Text: "sherlock"}, {Key: KeySpace}, {Text: "now"},
+			},
+		},
+		{
+			sequence: "{PASSWORD",
+			err:      ErrInvalidSequence,
+		},
+		{
+			sequence: "{TOTP}",
+			err:      ErrInvalidSequence,
+		},
+	}
+	for _, tc := range tt {
+		keystrokes, err := Parse(tc.sequence, fields)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("autotype.Parse(%s): want: %v, have: %v", tc.sequence, tc.err, err)
+		}
+		if err == nil && !reflect.DeepEqual(keystrokes, tc.expect) {
+			t.Fatalf("autotype.Parse(%s): want: %v, have: %v", tc.sequence, tc.expect, keystrokes)
+		}
+	}
+}
+
+// recorder records the calls of the backend
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) SwitchWindow(ctx context.Context) error {
+	r.calls = append(r.calls, "switch")
+	return nil
+}
+
+func (r *recorder) Type(ctx context.Context, text string) error {
+	r.calls = append(r.calls, "type:"+text)
+	return nil
+}
+
+func (r *recorder) Press(ctx context.Context, key string) error {
+	r.calls = append(r.calls, "press:"+key)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	var r recorder
+	keystrokes := []Keystroke{{Text: "sherlock"}, {Key: KeyTab}, {Text: ""}, {Key: KeyEnter}}
+	if err := Run(context.Background(), &r, keystrokes, true, 0); err != nil {
+		t.Fatalf("autotype.Run: want: nil, have: %v", err)
+	}
+	expect := []string{"switch", "type:sherlock", "press:TAB", "press:ENTER"}
+	if !reflect.DeepEqual(r.calls, expect) {
+		t.Fatalf("autotype.Run: want: %v, have: %v", expect, r.calls)
+	}
+}
@@ -0,0 +1,93 @@
+package autotype
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Detect picks the keyboard emulation of the platform
+func Detect() (Backend, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return appleScript{}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := exec.LookPath("wtype"); err == nil {
+				return wtype{}, nil
+			}
+		}
+		if _, err := exec.LookPath("xdotool"); err == nil {
+			return xdotool{}, nil
+		}
+	}
+	return nil, ErrNoBackend
+}
+
+// run executes the command passing stdin to it. Secrets are only ever
+// passed through stdin so they do not show up in the process list
+func run(ctx context.Context, stdin string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// xdotool emulates the keyboard on X11
+type xdotool struct{}
+
+var xdotoolKeys = map[string]string{KeyTab: "Tab", KeyEnter: "Return", KeySpace: "space"}
+
+func (xdotool) SwitchWindow(ctx context.Context) error {
+	return run(ctx, "", "xdotool", "key", "--clearmodifiers", "alt+Tab")
+}
+
+func (xdotool) Type(ctx context.Context, text string) error {
+	return run(ctx, text, "xdotool", "type", "--clearmodifiers", "--file", "-")
+}
+
+func (xdotool) Press(ctx context.Context, key string) error {
+	return run(ctx, "", "xdotool", "key", "--clearmodifiers", xdotoolKeys[key])
+}
+
+// wtype emulates the keyboard on wayland compositors supporting the virtual keyboard protocol
+type wtype struct{}
+
+var wtypeKeys = map[string]string{KeyTab: "Tab", KeyEnter: "Return", KeySpace: "space"}
+
+func (wtype) SwitchWindow(ctx context.Context) error {
+	return run(ctx, "", "wtype", "-M", "alt", "-k", "Tab", "-m", "alt")
+}
+
+func (wtype) Type(ctx context.Context, text string) error {
+	return run(ctx, text, "wtype", "-")
+}
+
+func (wtype) Press(ctx context.Context, key string) error {
+	return run(ctx, "", "wtype", "-k", wtypeKeys[key])
+}
+
+// appleScript emulates the keyboard on macOS using System Events. The script
+// is passed through stdin to keep the typed text out of the process list
+type appleScript struct{}
+
+// key codes of the special keys on macOS
+var appleKeyCodes = map[string]int{KeyTab: 48, KeyEnter: 36, KeySpace: 49}
+
+func (appleScript) SwitchWindow(ctx context.Context) error {
+	return run(ctx, `tell application "System Events" to keystroke tab using command down`, "osascript", "-")
+}
+
+func (appleScript) Type(ctx context.Context, text string) error {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+	return run(ctx, fmt.Sprintf(`tell application "System Events" to keystroke "%s"`, escaped), "osascript", "-")
+}
+
+func (appleScript) Press(ctx context.Context, key string) error {
+	return run(ctx, fmt.Sprintf(`tell application "System Events" to key code %d`, appleKeyCodes[key]), "osascript", "-")
+}
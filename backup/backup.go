@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	snapshotPrefix = "sherlock-"
+	snapshotSuffix = ".tar.gz"
+	// sealedSuffix is appended to the names of sealed (encrypted) snapshots
+	sealedSuffix = ".sealed"
+	// snapshotLayout is the UTC time format of snapshot names so names sort by time
+	snapshotLayout = "20060102T150405Z"
+)
+
+var ErrNoTarget = fmt.Errorf("no backup target configured (use sherlock backup config --target or --remote)")
+
+// Target stores snapshots
+type Target interface {
+	// Put stores a snapshot under the name
+	Put(ctx context.Context, name string, r io.Reader) error
+	// List returns the names of all stored snapshots
+	List(ctx context.Context) ([]string, error)
+	// Get reads a stored snapshot
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Remove deletes a stored snapshot
+	Remove(ctx context.Context, name string) error
+}
+
+// Policy defines how many snapshots are kept. The newest snapshot of each of
+// the last KeepDaily days, KeepWeekly weeks and KeepMonthly months is kept
+type Policy struct {
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+// SnapshotName names a snapshot taken at the point in time
+// => sherlock-20211015T150405Z.tar.gz or sherlock-20211015T150405Z.tar.gz.sealed
+func SnapshotName(t time.Time, sealed bool) string {
+	name := snapshotPrefix + t.UTC().Format(snapshotLayout) + snapshotSuffix
+	if sealed {
+		name += sealedSuffix
+	}
+	return name
+}
+
+// ParseSnapshotName returns when a snapshot was taken. Names not created by
+// SnapshotName are reported as not ok
+func ParseSnapshotName(name string) (time.Time, bool) {
+	name = strings.TrimSuffix(name, sealedSuffix)
+	if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(snapshotLayout, strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Run stores the snapshot written by snapshot under the name in the target and
+// prunes the target according to the policy. It returns the names of the
+// removed snapshots
+func Run(ctx context.Context, target Target, policy Policy, name string, snapshot func(io.Writer) error) ([]string, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(snapshot(pw))
+	}()
+	if err := target.Put(ctx, name, pr); err != nil {
+		pr.CloseWithError(err)
+		return nil, err
+	}
+	return Prune(ctx, target, policy)
+}
+
+// Prune removes all snapshots of the target not kept by the policy. The
+// newest snapshot is always kept. Other files in the target are ignored
+func Prune(ctx context.Context, target Target, policy Policy) ([]string, error) {
+	names, err := target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, name := range Expired(names, policy) {
+		if err := target.Remove(ctx, name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// Expired returns the snapshot names not kept by the policy
+func Expired(names []string, policy Policy) []string {
+	type snapshot struct {
+		name string
+		at   time.Time
+	}
+	var snapshots []snapshot
+	for _, name := range names {
+		if at, ok := ParseSnapshotName(name); ok {
+			snapshots = append(snapshots, snapshot{name: name, at: at})
+		}
+	}
+	// newest first
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].at.After(snapshots[j].at)
+	})
+
+	keep := make(map[string]bool)
+	if len(snapshots) > 0 {
+		keep[snapshots[0].name] = true
+	}
+	for _, bucket := range []struct {
+		n   int
+		key func(time.Time) string
+	}{
+		{n: policy.KeepDaily, key: func(t time.Time) string { return t.Format("2006-01-02") }},
+		{n: policy.KeepWeekly, key: func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{n: policy.KeepMonthly, key: func(t time.Time) string { return t.Format("2006-01") }},
+	} {
+		seen := make(map[string]bool)
+		for _, s := range snapshots {
+			if len(seen) == bucket.n {
+				break
+			}
+			key := bucket.key(s.at)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep[s.name] = true
+		}
+	}
+
+	var expired []string
+	for _, s := range snapshots {
+		if !keep[s.name] {
+			expired = append(expired, s.name)
+		}
+	}
+	return expired
+}
+
+// DirTarget stores snapshots in a directory
+type DirTarget struct {
+	fs  afero.Fs
+	dir string
+}
+
+// NewDirTarget creates a target storing snapshots in the directory
+func NewDirTarget(fs afero.Fs, dir string) *DirTarget {
+	return &DirTarget{fs: fs, dir: dir}
+}
+
+// Put writes the snapshot to a temporary file first which is renamed once
+// complete so a target never holds a partial snapshot
+func (d DirTarget) Put(ctx context.Context, name string, r io.Reader) error {
+	if err := d.fs.MkdirAll(d.dir, 0700); err != nil {
+		return err
+	}
+	tmp := filepath.Join(d.dir, name+".tmp")
+	f, err := d.fs.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		_ = d.fs.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = d.fs.Remove(tmp)
+		return err
+	}
+	return d.fs.Rename(tmp, filepath.Join(d.dir, name))
+}
+
+func (d DirTarget) List(ctx context.Context) ([]string, error) {
+	infos, err := afero.ReadDir(d.fs, d.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		if !info.IsDir() {
+			names = append(names, info.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d DirTarget) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return d.fs.Open(filepath.Join(d.dir, name))
+}
+
+func (d DirTarget) Remove(ctx context.Context, name string) error {
+	return d.fs.Remove(filepath.Join(d.dir, name))
+}
@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestSnapshotName(t *testing.T) {
+	at := time.Date(2021, 10, 15, 15, 4, 5, 0, time.FixedZone("CEST", 2*60*60))
+	name := SnapshotName(at, false)
+	if name != "sherlock-20211015T130405Z.tar.gz" {
+		t.Fatalf("backup.SnapshotName: want: sherlock-20211015T130405Z.tar.gz, have: %s", name)
+	}
+	parsed, ok := ParseSnapshotName(name)
+	if !ok || !parsed.Equal(at) {
+		t.Fatalf("backup.ParseSnapshotName: want: %v, have: %v (%v)", at, parsed, ok)
+	}
+	if parsed, ok := ParseSnapshotName(SnapshotName(at, true)); !ok || !parsed.Equal(at) {
+		t.Fatalf("backup.ParseSnapshotName(sealed): want: %v, have: %v (%v)", at, parsed, ok)
+	}
+	for _, name := range []string{"notes.txt", "sherlock-yesterday.tar.gz"} {
+		if _, ok := ParseSnapshotName(name); ok {
+			t.Fatalf("backup.ParseSnapshotName(%s): want: not ok, have: ok", name)
+		}
+	}
+}
+
+func TestExpired(t *testing.T) {
+	// two snapshots a day for the 60 days up to Sunday, 28. Feb 2021
+	last := time.Date(2021, 2, 28, 18, 0, 0, 0, time.UTC)
+	var names []string
+	for d := 0; d < 60; d++ {
+		day := last.AddDate(0, 0, -d)
+		names = append(names, SnapshotName(day, false), SnapshotName(day.Add(-12*time.Hour), false))
+	}
+
+	expired := Expired(names, Policy{KeepDaily: 3, KeepWeekly: 2, KeepMonthly: 3})
+	expiredSet := make(map[string]bool)
+	for _, name := range expired {
+		expiredSet[name] = true
+	}
+	var kept []string
+	for _, name := range names {
+		if !expiredSet[name] {
+			kept = append(kept, name)
+		}
+	}
+	sort.Strings(kept)
+	expect := []string{
+		// newest of January and December
+		SnapshotName(time.Date(2020, 12, 31, 18, 0, 0, 0, time.UTC), false),
+		SnapshotName(time.Date(2021, 1, 31, 18, 0, 0, 0, time.UTC), false),
+		// newest of the previous week (ends Sunday 21. Feb)
+		SnapshotName(time.Date(2021, 2, 21, 18, 0, 0, 0, time.UTC), false),
+		// newest of the last three days (includes this week and month)
+		SnapshotName(time.Date(2021, 2, 26, 18, 0, 0, 0, time.UTC), false),
+		SnapshotName(time.Date(2021, 2, 27, 18, 0, 0, 0, time.UTC), false),
+		SnapshotName(last, false),
+	}
+	if !reflect.DeepEqual(kept, expect) {
+		t.Fatalf("backup.Expired: want kept: %v, have: %v", expect, kept)
+	}
+
+	// the newest snapshot is kept even without any policy
+	if expired := Expired(names[:2], Policy{}); !reflect.DeepEqual(expired, names[1:2]) {
+		t.Fatalf("backup.Expired: want: %v, have: %v", names[1:2], expired)
+	}
+}
+
+func TestRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	target := NewDirTarget(fs, "/backups")
+	ctx := context.Background()
+	if err := afero.WriteFile(fs, "/backups/README", []byte("keep me"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 10, 15, 12, 0, 0, 0, time.UTC)
+	for d := 0; d < 3; d++ {
+		name := SnapshotName(now.AddDate(0, 0, d), false)
+		_, err := Run(ctx, target, Policy{KeepDaily: 2}, name, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "snapshot %d", d)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("backup.Run: want: nil, have: %v", err)
+		}
+		b, err := afero.ReadFile(fs, "/backups/"+name)
+		if err != nil || string(b) != fmt.Sprintf("snapshot %d", d) {
+			t.Fatalf("backup.Run: want: snapshot %d, have: %s (%v)", d, b, err)
+		}
+	}
+	names, err := target.List(ctx)
+	if err != nil {
+		t.Fatalf("backup.DirTarget.List: want: nil, have: %v", err)
+	}
+	expect := []string{"README", SnapshotName(now.AddDate(0, 0, 1), false), SnapshotName(now.AddDate(0, 0, 2), false)}
+	if !reflect.DeepEqual(names, expect) {
+		t.Fatalf("backup.Run: want: %v, have: %v", expect, names)
+	}
+
+	// a failed snapshot must not leave a partial file behind
+	errSnapshot := fmt.Errorf("vault unreadable")
+	_, err = Run(ctx, target, Policy{KeepDaily: 2}, SnapshotName(now.AddDate(0, 0, 3), false), func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return errSnapshot
+	})
+	if !errors.Is(err, errSnapshot) {
+		t.Fatalf("backup.Run: want: %v, have: %v", errSnapshot, err)
+	}
+	if names, _ := target.List(ctx); !reflect.DeepEqual(names, expect) {
+		t.Fatalf("backup.Run: want: %v, have: %v", expect, names)
+	}
+}
@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+var ErrInvalidRemote = fmt.Errorf("invalid rclone remote (expected remote:path)")
+
+// RcloneTarget stores snapshots in an rclone remote (remote:path) using the rclone
+// binary, so any provider configured with rclone can be used as backup target
+type RcloneTarget struct {
+	binary string
+	remote string
+}
+
+// NewRcloneTarget creates a target for the remote like so gdrive:backups/sherlock
+func NewRcloneTarget(remote string) (*RcloneTarget, error) {
+	if err := CheckRemote(remote); err != nil {
+		return nil, err
+	}
+	return &RcloneTarget{binary: "rclone", remote: strings.TrimSuffix(remote, "/")}, nil
+}
+
+// CheckRemote validates that the remote names an rclone remote
+func CheckRemote(remote string) error {
+	i := strings.Index(remote, ":")
+	if i < 1 || strings.ContainsAny(remote[:i], `/\`) {
+		return fmt.Errorf("%w: %s", ErrInvalidRemote, remote)
+	}
+	return nil
+}
+
+// file builds the remote path of a snapshot
+func (r RcloneTarget) file(name string) string {
+	if strings.HasSuffix(r.remote, ":") {
+		return r.remote + name
+	}
+	i := strings.Index(r.remote, ":")
+	return r.remote[:i+1] + path.Join(r.remote[i+1:], name)
+}
+
+func (r RcloneTarget) run(ctx context.Context, stdin io.Reader, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("rclone %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// Put streams the snapshot to the remote. rclone uploads to a temporary
+// object first for most providers so a failed upload leaves no partial snapshot
+func (r RcloneTarget) Put(ctx context.Context, name string, snapshot io.Reader) error {
+	_, err := r.run(ctx, snapshot, "rcat", r.file(name))
+	return err
+}
+
+func (r RcloneTarget) List(ctx context.Context) ([]string, error) {
+	out, err := r.run(ctx, nil, "lsf", "--files-only", r.remote)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(out), "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (r RcloneTarget) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	out, err := r.run(ctx, nil, "cat", r.file(name))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(out)), nil
+}
+
+func (r RcloneTarget) Remove(ctx context.Context, name string) error {
+	_, err := r.run(ctx, nil, "deletefile", r.file(name))
+	return err
+}
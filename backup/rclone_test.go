@@ -0,0 +1,30 @@
+package backup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRcloneTargetFile(t *testing.T) {
+	tt := []struct {
+		remote string
+		expect string
+		err    error
+	}{
+		{remote: "gdrive:sherlock", expect: "gdrive:sherlock/snapshot"},
+		{remote: "gdrive:backups/sherlock/", expect: "gdrive:backups/sherlock/snapshot"},
+		{remote: "s3:", expect: "s3:snapshot"},
+		{remote: "/mnt/backups", err: ErrInvalidRemote},
+		{remote: ":sherlock", err: ErrInvalidRemote},
+		{remote: "./a:b", err: ErrInvalidRemote},
+	}
+	for _, tc := range tt {
+		target, err := NewRcloneTarget(tc.remote)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("backup.NewRcloneTarget(%s): want: %v, have: %v", tc.remote, tc.err, err)
+		}
+		if err == nil && target.file("snapshot") != tc.expect {
+			t.Fatalf("backup.RcloneTarget.file(%s): want: %s, have: %s", tc.remote, tc.expect, target.file("snapshot"))
+		}
+	}
+}
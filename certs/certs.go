@@ -0,0 +1,304 @@
+// Package certs manages the local certificate authority and device certificates
+// used to authenticate sherlock instances to each other with mutual TLS. The CA
+// stays on the device it was created on; every other device receives the CA
+// certificate and a device certificate issued by it
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// CAFile is the certificate of the CA every device trusts
+	CAFile = "ca.crt"
+	// CAKeyFile is the private key of the CA. Only the device which created
+	// the CA holds it
+	CAKeyFile = "ca.key"
+	// CertFile and KeyFile are the certificate and private key of the device
+	CertFile = "device.crt"
+	KeyFile  = "device.key"
+)
+
+var (
+	ErrNoCA        = fmt.Errorf("no certificate authority (use sherlock certs init)")
+	ErrCAExists    = fmt.Errorf("certificate authority already exists")
+	ErrNoIdentity  = fmt.Errorf("no device certificate (use sherlock certs init or copy one issued with sherlock certs issue)")
+	ErrInvalidCert = fmt.Errorf("certificate or key is invalid")
+)
+
+// Dir returns the default directory holding the certificates
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".sherlock", "certs")
+}
+
+// Authority issues the device certificates
+type Authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// NewAuthority creates a CA with the name valid for the duration
+func NewAuthority(name string, valid time.Duration) (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template(name, valid)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.MaxPathLenZero = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Authority{cert: cert, key: key}, nil
+}
+
+// LoadAuthority reads the CA from the directory
+func LoadAuthority(dir string) (*Authority, error) {
+	certPEM, err := ioutil.ReadFile(filepath.Join(dir, CAFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoCA
+		}
+		return nil, err
+	}
+	keyPEM, err := ioutil.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoCA
+		}
+		return nil, err
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCert, err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCert, err)
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok || !cert.IsCA {
+		return nil, fmt.Errorf("%w: %s is no sherlock CA", ErrInvalidCert, CAFile)
+	}
+	return &Authority{cert: cert, key: key}, nil
+}
+
+// Save writes the CA certificate and key to the directory. An existing CA is
+// never replaced since the devices trusting it would be locked out
+func (a *Authority) Save(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, CAKeyFile)); err == nil {
+		return ErrCAExists
+	}
+	keyPEM, err := encodeKey(a.key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, CAKeyFile), keyPEM, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, CAFile), a.CertPEM(), 0644)
+}
+
+// CertPEM returns the PEM encoded CA certificate
+func (a *Authority) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: a.cert.Raw})
+}
+
+// Issue creates a certificate for the device with the name valid for the
+// duration. It authenticates the device as client and as server since either
+// side of a sync may listen. The certificate never outlives the CA
+func (a *Authority) Issue(name string, valid time.Duration) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := template(name, valid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tmpl.NotAfter.After(a.cert.NotAfter) {
+		tmpl.NotAfter = a.cert.NotAfter
+	}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if keyPEM, err = encodeKey(key); err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+// WriteIdentity writes the CA certificate and the certificate and key of a
+// device to the directory, e.g. to copy it to the device
+func WriteIdentity(dir string, caPEM, certPEM, keyPEM []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, KeyFile), keyPEM, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, CertFile), certPEM, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, CAFile), caPEM, 0644)
+}
+
+// Identity is the certificate of a device together with the CA it trusts
+type Identity struct {
+	// Name is the common name of the device certificate
+	Name     string
+	NotAfter time.Time
+	cert     tls.Certificate
+	roots    *x509.CertPool
+}
+
+// LoadIdentity reads the device certificate, its key and the CA certificate
+// from the directory
+func LoadIdentity(dir string) (*Identity, error) {
+	read := func(name string) ([]byte, error) {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			return nil, ErrNoIdentity
+		}
+		return b, err
+	}
+	caPEM, err := read(CAFile)
+	if err != nil {
+		return nil, err
+	}
+	certPEM, err := read(CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := read(KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewIdentity(caPEM, certPEM, keyPEM)
+}
+
+// NewIdentity parses the PEM encoded CA certificate, device certificate and key
+func NewIdentity(caPEM, certPEM, keyPEM []byte) (*Identity, error) {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("%w: %s holds no certificate", ErrInvalidCert, CAFile)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCert, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCert, err)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCert, err)
+	}
+	return &Identity{Name: leaf.Subject.CommonName, NotAfter: leaf.NotAfter, cert: pair, roots: roots}, nil
+}
+
+// ServerConfig accepts only clients presenting a certificate issued by the CA
+func (id *Identity) ServerConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{id.cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    id.roots,
+		MinVersion:   tls.VersionTLS13,
+	}
+}
+
+// ClientConfig accepts only servers presenting a certificate issued by the CA.
+// Peers are reached by address and not by a name their certificate could
+// hold, so the certificate chain is verified without a host name
+func (id *Identity) ClientConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{id.cert},
+		MinVersion:   tls.VersionTLS13,
+		// the chain is verified by VerifyPeerCertificate instead
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+			if len(raw) == 0 {
+				return fmt.Errorf("peer presented no certificate")
+			}
+			leaf, err := x509.ParseCertificate(raw[0])
+			if err != nil {
+				return err
+			}
+			intermediates := x509.NewCertPool()
+			for _, r := range raw[1:] {
+				c, err := x509.ParseCertificate(r)
+				if err != nil {
+					return err
+				}
+				intermediates.AddCert(c)
+			}
+			_, err = leaf.Verify(x509.VerifyOptions{
+				Roots:         id.roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			return err
+		},
+	}
+}
+
+// PeerName returns the common name of the certificate the peer of the
+// completed handshake presented
+func PeerName(conn *tls.Conn) string {
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return state.PeerCertificates[0].Subject.CommonName
+}
+
+// template is the certificate template for the name valid from now on
+func template(name string, valid time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"sherlock"}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(valid),
+	}, nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
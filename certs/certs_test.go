@@ -0,0 +1,139 @@
+package certs
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// identity issues a device certificate of the CA
+func identity(t *testing.T, ca *Authority, name string) *Identity {
+	certPEM, keyPEM, err := ca.Issue(name, time.Hour)
+	if err != nil {
+		t.Fatalf("certs.Issue: want: nil, have: %v", err)
+	}
+	id, err := NewIdentity(ca.CertPEM(), certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("certs.NewIdentity: want: nil, have: %v", err)
+	}
+	return id
+}
+
+// handshake connects a client and a server over a pipe and returns the
+// name the server sees and the errors of both sides
+func handshake(client, server *Identity) (string, error, error) {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+	done := make(chan error, 1)
+	var name string
+	go func() {
+		conn := tls.Server(s, server.ServerConfig())
+		err := conn.Handshake()
+		if err == nil {
+			name = PeerName(conn)
+		}
+		// unblock the client if the server rejected it
+		s.Close()
+		done <- err
+	}()
+	clientErr := tls.Client(c, client.ClientConfig()).Handshake()
+	if clientErr == nil {
+		// the server verifies the client certificate after the client finished
+		_, _ = c.Read(make([]byte, 1))
+	}
+	serverErr := <-done
+	return name, clientErr, serverErr
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, err := NewAuthority("sherlock CA", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("certs.NewAuthority: want: nil, have: %v", err)
+	}
+	laptop := identity(t, ca, "laptop")
+	desktop := identity(t, ca, "desktop")
+	if laptop.Name != "laptop" || laptop.NotAfter.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("certs.NewIdentity: want: laptop valid for 1h, have: %s %v", laptop.Name, laptop.NotAfter)
+	}
+
+	name, clientErr, serverErr := handshake(laptop, desktop)
+	if clientErr != nil || serverErr != nil || name != "laptop" {
+		t.Fatalf("handshake: want: <nil> <nil> laptop, have: %v %v %q", clientErr, serverErr, name)
+	}
+
+	other, err := NewAuthority("other CA", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("certs.NewAuthority: want: nil, have: %v", err)
+	}
+	stranger := identity(t, other, "stranger")
+	if _, _, serverErr := handshake(stranger, desktop); serverErr == nil {
+		t.Fatalf("handshake: want: client of other CA rejected, have: accepted")
+	}
+	if _, clientErr, _ := handshake(laptop, stranger); clientErr == nil {
+		t.Fatalf("handshake: want: server of other CA rejected, have: accepted")
+	}
+
+	if _, err := NewIdentity(ca.CertPEM(), nil, nil); !errors.Is(err, ErrInvalidCert) {
+		t.Fatalf("certs.NewIdentity: want: %v, have: %v", ErrInvalidCert, err)
+	}
+	certPEM, keyPEM, err := other.Issue("stranger", time.Hour)
+	if err != nil {
+		t.Fatalf("certs.Issue: want: nil, have: %v", err)
+	}
+	if _, err := NewIdentity(ca.CertPEM(), certPEM, keyPEM); !errors.Is(err, ErrInvalidCert) {
+		t.Fatalf("certs.NewIdentity: want: %v (issued by other CA), have: %v", ErrInvalidCert, err)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-certs")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := LoadAuthority(dir); err != ErrNoCA {
+		t.Fatalf("certs.LoadAuthority: want: %v, have: %v", ErrNoCA, err)
+	}
+	if _, err := LoadIdentity(dir); err != ErrNoIdentity {
+		t.Fatalf("certs.LoadIdentity: want: %v, have: %v", ErrNoIdentity, err)
+	}
+	ca, err := NewAuthority("sherlock CA", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("certs.NewAuthority: want: nil, have: %v", err)
+	}
+	if err := ca.Save(dir); err != nil {
+		t.Fatalf("certs.Save: want: nil, have: %v", err)
+	}
+	if err := ca.Save(dir); err != ErrCAExists {
+		t.Fatalf("certs.Save: want: %v, have: %v", ErrCAExists, err)
+	}
+	loaded, err := LoadAuthority(dir)
+	if err != nil {
+		t.Fatalf("certs.LoadAuthority: want: nil, have: %v", err)
+	}
+	certPEM, keyPEM, err := loaded.Issue("laptop", 48*time.Hour)
+	if err != nil {
+		t.Fatalf("certs.Issue: want: nil, have: %v", err)
+	}
+	if err := WriteIdentity(dir, loaded.CertPEM(), certPEM, keyPEM); err != nil {
+		t.Fatalf("certs.WriteIdentity: want: nil, have: %v", err)
+	}
+	id, err := LoadIdentity(dir)
+	if err != nil {
+		t.Fatalf("certs.LoadIdentity: want: nil, have: %v", err)
+	}
+	// a device certificate never outlives its CA
+	if id.Name != "laptop" || id.NotAfter.After(time.Now().Add(24*time.Hour)) {
+		t.Fatalf("certs.LoadIdentity: want: laptop valid for at most 24h, have: %s %v", id.Name, id.NotAfter)
+	}
+	info, err := os.Stat(dir + "/" + KeyFile)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("device key: want: 0600, have: %v %v", info, err)
+	}
+}
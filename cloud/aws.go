@@ -0,0 +1,105 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AWS services secrets can be stored in
+const (
+	AWSSecretsManager = "secretsmanager"
+	AWSParameterStore = "ssm"
+)
+
+// AWS stores passwords in the Secrets Manager or as SecureString parameter
+// in the SSM Parameter Store using the aws CLI
+type AWS struct {
+	// Service is either AWSSecretsManager or AWSParameterStore
+	Service string
+	// Prefix of the secret names
+	Prefix string
+	// Profile and Region of the aws CLI; empty for the CLI defaults
+	Profile string
+	Region  string
+	run     runner
+}
+
+// NewAWS returns an AWS store for the service
+func NewAWS(service, prefix, profile, region string) (*AWS, error) {
+	if service != AWSSecretsManager && service != AWSParameterStore {
+		return nil, fmt.Errorf("%w: %q (use %s or %s)", ErrUnknownService, service, AWSSecretsManager, AWSParameterStore)
+	}
+	return &AWS{
+		Service: service,
+		Prefix:  prefix,
+		Profile: profile,
+		Region:  region,
+		run:     execRunner,
+	}, nil
+}
+
+// Name maps an account to {prefix}{group}/{account}. SSM parameter names
+// within a hierarchy must start with a slash which is added if missing
+func (a *AWS) Name(gid, account string) string {
+	prefix := a.Prefix
+	if a.Service == AWSParameterStore && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return SecretName(prefix, gid, account)
+}
+
+// Get returns the value of the secret or an ErrNotFound
+func (a *AWS) Get(ctx context.Context, name string) (string, error) {
+	operation, query := "get-secret-value", "SecretString"
+	req := map[string]interface{}{"SecretId": name}
+	if a.Service == AWSParameterStore {
+		operation, query = "get-parameter", "Parameter.Value"
+		req = map[string]interface{}{"Name": name, "WithDecryption": true}
+	}
+	out, err := a.call(ctx, operation, req, "--query", query)
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") || strings.Contains(err.Error(), "ParameterNotFound") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	var value string
+	if err := json.Unmarshal(out, &value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Put writes the value of the secret. create tells if the secret is new
+func (a *AWS) Put(ctx context.Context, name, value string, create bool) error {
+	var err error
+	switch {
+	case a.Service == AWSParameterStore:
+		_, err = a.call(ctx, "put-parameter", map[string]interface{}{
+			"Name": name, "Value": value, "Type": "SecureString", "Overwrite": !create,
+		})
+	case create:
+		_, err = a.call(ctx, "create-secret", map[string]interface{}{"Name": name, "SecretString": value})
+	default:
+		_, err = a.call(ctx, "put-secret-value", map[string]interface{}{"SecretId": name, "SecretString": value})
+	}
+	return err
+}
+
+// call runs an aws CLI operation passing the request as JSON on stdin
+func (a *AWS) call(ctx context.Context, operation string, req map[string]interface{}, args ...string) ([]byte, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	args = append([]string{a.Service, operation, "--cli-input-json", "file:///dev/stdin", "--output", "json"}, args...)
+	if a.Profile != "" {
+		args = append(args, "--profile", a.Profile)
+	}
+	if a.Region != "" {
+		args = append(args, "--region", a.Region)
+	}
+	return a.run(ctx, input, "aws", args...)
+}
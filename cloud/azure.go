@@ -0,0 +1,56 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Azure stores passwords as secrets of an Azure Key Vault using the az CLI
+type Azure struct {
+	// Vault is the name of the key vault
+	Vault string
+	// Prefix of the secret names
+	Prefix string
+	run    runner
+}
+
+// NewAzure returns an Azure store for the key vault
+func NewAzure(vault, prefix string) (*Azure, error) {
+	if vault == "" {
+		return nil, fmt.Errorf("%w: the name of the key vault is required", ErrMissingOption)
+	}
+	return &Azure{Vault: vault, Prefix: prefix, run: execRunner}, nil
+}
+
+// Name maps an account to {prefix}{group}--{account} since key vault
+// secret names allow only letters, digits and dashes
+func (a *Azure) Name(gid, account string) string {
+	return flatName(a.Prefix, gid, account)
+}
+
+// Get returns the value of the secret or an ErrNotFound
+func (a *Azure) Get(ctx context.Context, name string) (string, error) {
+	out, err := a.run(ctx, nil, "az", "keyvault", "secret", "show",
+		"--vault-name", a.Vault, "--name", name, "--query", "value", "--output", "json")
+	if err != nil {
+		if strings.Contains(err.Error(), "SecretNotFound") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	var value string
+	if err := json.Unmarshal(out, &value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Put writes the value as new version of the secret. The secret is created
+// if it does not exist
+func (a *Azure) Put(ctx context.Context, name, value string, create bool) error {
+	_, err := a.run(ctx, []byte(value), "az", "keyvault", "secret", "set",
+		"--vault-name", a.Vault, "--name", name, "--file", "/dev/stdin", "--encoding", "utf-8", "--output", "none")
+	return err
+}
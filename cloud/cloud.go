@@ -0,0 +1,163 @@
+// Package cloud stores account passwords in the secret stores of cloud
+// providers using their CLIs and the credentials configured for them
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	ErrNotFound       = fmt.Errorf("secret not found")
+	ErrUnknownService = fmt.Errorf("unknown secret service")
+	ErrMissingOption  = fmt.Errorf("missing provider option")
+)
+
+// Provider is a remote secret store accounts are pushed to and pulled from
+type Provider interface {
+	// Name maps an account to the name of its secret
+	Name(gid, account string) string
+	// Get returns the value of the secret or an ErrNotFound
+	Get(ctx context.Context, name string) (string, error)
+	// Put writes the value of the secret. create tells if the secret is new
+	Put(ctx context.Context, name, value string, create bool) error
+}
+
+// Entry is an account selected for a sync
+type Entry struct {
+	// Query (group@account) of the account
+	Query string
+	// Name of the secret as mapped by the Provider
+	Name  string
+	Value string
+}
+
+// Change is an Entry to be written. For a push Value is the local value,
+// for a pull the remote one
+type Change struct {
+	Entry
+	Create bool
+}
+
+// Tracker is told about every secret read or written by a sync, e.g. to report
+// the progress. A nil Tracker is ignored
+type Tracker interface {
+	Start(name string)
+	Done(name string, err error)
+}
+
+// track calls fn for the named secret reporting it to the tracker
+func track(t Tracker, name string, fn func() error) error {
+	if t == nil {
+		return fn()
+	}
+	t.Start(name)
+	err := fn()
+	t.Done(name, err)
+	return err
+}
+
+// PlanPush returns the entries whose secret is missing or differs from the
+// local value
+func PlanPush(ctx context.Context, p Provider, entries []Entry, t Tracker) ([]Change, error) {
+	var changes []Change
+	for _, e := range entries {
+		var remote string
+		var create bool
+		err := track(t, e.Name, func() (err error) {
+			remote, err = p.Get(ctx, e.Name)
+			if create = errors.Is(err, ErrNotFound); create {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !create && remote == e.Value {
+			continue
+		}
+		changes = append(changes, Change{Entry: e, Create: create})
+	}
+	return changes, nil
+}
+
+// Push writes the planned changes
+func Push(ctx context.Context, p Provider, changes []Change, t Tracker) error {
+	for _, c := range changes {
+		if err := track(t, c.Name, func() error {
+			return p.Put(ctx, c.Name, c.Value, c.Create)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanPull returns the entries with a secret differing from the local
+// value. Entries without a secret are skipped
+func PlanPull(ctx context.Context, p Provider, entries []Entry, t Tracker) ([]Change, error) {
+	var changes []Change
+	for _, e := range entries {
+		var remote string
+		var missing bool
+		err := track(t, e.Name, func() (err error) {
+			remote, err = p.Get(ctx, e.Name)
+			if missing = errors.Is(err, ErrNotFound); missing {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if missing || remote == e.Value {
+			continue
+		}
+		e.Value = remote
+		changes = append(changes, Change{Entry: e})
+	}
+	return changes, nil
+}
+
+// runner runs a CLI passing stdin and returns its output. Secret values are
+// only ever passed on stdin so they do not show up in the process list
+type runner func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error)
+
+// execRunner runs the CLI as child process. The output on stderr is part
+// of the returned error
+func execRunner(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v: %s", name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// SecretName maps an account to a hierarchical secret name
+// => {prefix}{group}/{account}
+func SecretName(prefix, gid, account string) string {
+	return prefix + gid + "/" + account
+}
+
+// flatName maps an account to a secret name for stores allowing only
+// letters, digits and dashes => {prefix}{group}--{account}. Other
+// characters are replaced with a dash
+func flatName(prefix, gid, account string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, prefix+gid+"--"+account)
+}
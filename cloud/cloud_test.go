@@ -0,0 +1,261 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// call is a recorded CLI invocation
+type call struct {
+	args  string
+	stdin map[string]interface{}
+}
+
+// fakeRunner records the calls and answers them with the output of the
+// longest matching command prefix; an output starting with "error:" is returned as error.
+// Stdin is recorded as JSON object if possible else as {"raw": stdin}
+func fakeRunner(calls *[]call, outputs map[string]string) runner {
+	return func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+		c := call{args: name + " " + strings.Join(args, " ")}
+		if stdin != nil {
+			if err := json.Unmarshal(stdin, &c.stdin); err != nil {
+				c.stdin = map[string]interface{}{"raw": string(stdin)}
+			}
+		}
+		*calls = append(*calls, c)
+		var match string
+		for prefix := range outputs {
+			if strings.HasPrefix(c.args, prefix) && len(prefix) > len(match) {
+				match = prefix
+			}
+		}
+		out := outputs[match]
+		if strings.HasPrefix(out, "error:") {
+			return nil, fmt.Errorf(strings.TrimPrefix(out, "error:"))
+		}
+		return []byte(out), nil
+	}
+}
+
+// memProvider is a Provider keeping the secrets in a map
+type memProvider map[string]string
+
+func (m memProvider) Name(gid, account string) string { return SecretName("", gid, account) }
+
+func (m memProvider) Get(ctx context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (m memProvider) Put(ctx context.Context, name, value string, create bool) error {
+	if _, ok := m[name]; ok == create {
+		return fmt.Errorf("put %s: create==%v but exists==%v", name, create, ok)
+	}
+	m[name] = value
+	return nil
+}
+
+// recordTracker records the calls of a Tracker
+type recordTracker []string
+
+func (r *recordTracker) Start(name string) { *r = append(*r, "start "+name) }
+
+func (r *recordTracker) Done(name string, err error) { *r = append(*r, "done "+name) }
+
+func TestNames(t *testing.T) {
+	ssm, _ := NewAWS(AWSParameterStore, "sherlock/", "", "")
+	sm, _ := NewAWS(AWSSecretsManager, "sherlock/", "", "")
+	azure, _ := NewAzure("vault", "sherlock-")
+	gcp, _ := NewGCP("", "sherlock-")
+	tt := []struct {
+		p    Provider
+		name string
+	}{
+		{p: ssm, name: "/sherlock/work/db_admin"},
+		{p: sm, name: "sherlock/work/db_admin"},
+		{p: azure, name: "sherlock-work--db-admin"},
+		{p: gcp, name: "sherlock-work--db-admin"},
+	}
+	for _, tc := range tt {
+		if have := tc.p.Name("work", "db_admin"); have != tc.name {
+			t.Fatalf("cloud.Provider.Name: want: %s, have: %s", tc.name, have)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+	remote := memProvider{"work/db": "221b", "work/api": "old-token"}
+	entries := []Entry{
+		{Query: "work@db", Name: "work/db", Value: "221b"},
+		{Query: "work@api", Name: "work/api", Value: "new-token"},
+		{Query: "work@vpn", Name: "work/vpn", Value: "moriarty"},
+	}
+
+	push, err := PlanPush(ctx, remote, entries, nil)
+	if err != nil {
+		t.Fatalf("cloud.PlanPush: want: nil, have: %v", err)
+	}
+	wantPush := []Change{{Entry: entries[1]}, {Entry: entries[2], Create: true}}
+	if !reflect.DeepEqual(push, wantPush) {
+		t.Fatalf("cloud.PlanPush: want: %v, have: %v", wantPush, push)
+	}
+
+	pull, err := PlanPull(ctx, remote, entries, nil)
+	if err != nil {
+		t.Fatalf("cloud.PlanPull: want: nil, have: %v", err)
+	}
+	wantPull := []Change{{Entry: Entry{Query: "work@api", Name: "work/api", Value: "old-token"}}}
+	if !reflect.DeepEqual(pull, wantPull) {
+		t.Fatalf("cloud.PlanPull: want: %v, have: %v", wantPull, pull)
+	}
+
+	var tracked recordTracker
+	if err := Push(ctx, remote, push, &tracked); err != nil {
+		t.Fatalf("cloud.Push: want: nil, have: %v", err)
+	}
+	if want := []string{"start work/api", "done work/api", "start work/vpn", "done work/vpn"}; !reflect.DeepEqual([]string(tracked), want) {
+		t.Fatalf("cloud.Push: tracked: want: %v, have: %v", want, tracked)
+	}
+	if push, _ := PlanPush(ctx, remote, entries, nil); len(push) != 0 {
+		t.Fatalf("cloud.PlanPush: want: no changes after push, have: %v", push)
+	}
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	var calls []call
+	a, err := NewAWS(AWSSecretsManager, "sherlock/", "dev", "eu-central-1")
+	if err != nil {
+		t.Fatalf("cloud.NewAWS: want: nil, have: %v", err)
+	}
+	a.run = fakeRunner(&calls, map[string]string{"aws secretsmanager get-secret-value": `"221b"`})
+
+	value, err := a.Get(context.Background(), "sherlock/work/db")
+	if err != nil || value != "221b" {
+		t.Fatalf("cloud.AWS.Get: want: 221b, have: %q (%v)", value, err)
+	}
+	if err := a.Put(context.Background(), "sherlock/work/db", "221c", true); err != nil {
+		t.Fatalf("cloud.AWS.Put: want: nil, have: %v", err)
+	}
+	expect := []call{
+		{
+			args:  "aws secretsmanager get-secret-value --cli-input-json file:///dev/stdin --output json --query SecretString --profile dev --region eu-central-1",
+			stdin: map[string]interface{}{"SecretId": "sherlock/work/db"},
+		},
+		{
+			args:  "aws secretsmanager create-secret --cli-input-json file:///dev/stdin --output json --profile dev --region eu-central-1",
+			stdin: map[string]interface{}{"Name": "sherlock/work/db", "SecretString": "221c"},
+		},
+	}
+	if !reflect.DeepEqual(calls, expect) {
+		t.Fatalf("cloud.AWS: want: %v, have: %v", expect, calls)
+	}
+}
+
+func TestAWSParameterStore(t *testing.T) {
+	var calls []call
+	a, err := NewAWS(AWSParameterStore, "sherlock/", "", "")
+	if err != nil {
+		t.Fatalf("cloud.NewAWS: want: nil, have: %v", err)
+	}
+	a.run = fakeRunner(&calls, map[string]string{"aws ssm get-parameter": "error:An error occurred (ParameterNotFound)"})
+
+	if _, err := a.Get(context.Background(), "/sherlock/work/db"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cloud.AWS.Get: want: %v, have: %v", ErrNotFound, err)
+	}
+	if err := a.Put(context.Background(), "/sherlock/work/db", "221c", false); err != nil {
+		t.Fatalf("cloud.AWS.Put: want: nil, have: %v", err)
+	}
+	put := calls[1]
+	if put.args != "aws ssm put-parameter --cli-input-json file:///dev/stdin --output json" {
+		t.Fatalf("cloud.AWS.Put: want: put-parameter, have: %s", put.args)
+	}
+	want := map[string]interface{}{"Name": "/sherlock/work/db", "Value": "221c", "Type": "SecureString", "Overwrite": true}
+	if !reflect.DeepEqual(put.stdin, want) {
+		t.Fatalf("cloud.AWS.Put: want: %v, have: %v", want, put.stdin)
+	}
+}
+
+func TestNewAWSUnknownService(t *testing.T) {
+	if _, err := NewAWS("s3", "", "", ""); !errors.Is(err, ErrUnknownService) {
+		t.Fatalf("cloud.NewAWS: want: %v, have: %v", ErrUnknownService, err)
+	}
+}
+
+func TestAzure(t *testing.T) {
+	if _, err := NewAzure("", "sherlock-"); !errors.Is(err, ErrMissingOption) {
+		t.Fatalf("cloud.NewAzure: want: %v, have: %v", ErrMissingOption, err)
+	}
+	var calls []call
+	a, err := NewAzure("home", "sherlock-")
+	if err != nil {
+		t.Fatalf("cloud.NewAzure: want: nil, have: %v", err)
+	}
+	a.run = fakeRunner(&calls, map[string]string{
+		"az keyvault secret show --vault-name home --name sherlock-work--db": `"221b"`,
+		"az keyvault secret show": "error:(SecretNotFound) A secret with (name/id) was not found",
+	})
+	ctx := context.Background()
+	if value, err := a.Get(ctx, "sherlock-work--db"); err != nil || value != "221b" {
+		t.Fatalf("cloud.Azure.Get: want: 221b, have: %q (%v)", value, err)
+	}
+	if _, err := a.Get(ctx, "sherlock-work--vpn"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cloud.Azure.Get: want: %v, have: %v", ErrNotFound, err)
+	}
+	if err := a.Put(ctx, "sherlock-work--vpn", "moriarty", true); err != nil {
+		t.Fatalf("cloud.Azure.Put: want: nil, have: %v", err)
+	}
+	want := call{
+		args:  "az keyvault secret set --vault-name home --name sherlock-work--vpn --file /dev/stdin --encoding utf-8 --output none",
+		stdin: map[string]interface{}{"raw": "moriarty"},
+	}
+	if put := calls[2]; !reflect.DeepEqual(put, want) {
+		t.Fatalf("cloud.Azure.Put: want: %v, have: %v", want, put)
+	}
+}
+
+func TestGCP(t *testing.T) {
+	var calls []call
+	g, err := NewGCP("homelab", "sherlock-")
+	if err != nil {
+		t.Fatalf("cloud.NewGCP: want: nil, have: %v", err)
+	}
+	g.run = fakeRunner(&calls, map[string]string{
+		"gcloud secrets versions access latest --secret sherlock-work--db": "221b",
+		"gcloud secrets versions access":                                   "error:NOT_FOUND: Secret [sherlock-work--vpn] not found",
+	})
+	ctx := context.Background()
+	if value, err := g.Get(ctx, "sherlock-work--db"); err != nil || value != "221b" {
+		t.Fatalf("cloud.GCP.Get: want: 221b, have: %q (%v)", value, err)
+	}
+	if _, err := g.Get(ctx, "sherlock-work--vpn"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cloud.GCP.Get: want: %v, have: %v", ErrNotFound, err)
+	}
+	if err := g.Put(ctx, "sherlock-work--vpn", "moriarty", true); err != nil {
+		t.Fatalf("cloud.GCP.Put: want: nil, have: %v", err)
+	}
+	if err := g.Put(ctx, "sherlock-work--db", "221c", false); err != nil {
+		t.Fatalf("cloud.GCP.Put: want: nil, have: %v", err)
+	}
+	want := []call{
+		{
+			args:  "gcloud secrets create sherlock-work--vpn --data-file - --replication-policy automatic --project homelab",
+			stdin: map[string]interface{}{"raw": "moriarty"},
+		},
+		{
+			args:  "gcloud secrets versions add sherlock-work--db --data-file - --project homelab",
+			stdin: map[string]interface{}{"raw": "221c"},
+		},
+	}
+	if !reflect.DeepEqual(calls[2:], want) {
+		t.Fatalf("cloud.GCP.Put: want: %v, have: %v", want, calls[2:])
+	}
+}
@@ -0,0 +1,58 @@
+package cloud
+
+import (
+	"context"
+	"strings"
+)
+
+// GCP stores passwords in the Google Secret Manager using the gcloud CLI
+type GCP struct {
+	// Project is the project of the secrets; empty for the gcloud default
+	Project string
+	// Prefix of the secret names
+	Prefix string
+	run    runner
+}
+
+// NewGCP returns a GCP store for the project
+func NewGCP(project, prefix string) (*GCP, error) {
+	return &GCP{Project: project, Prefix: prefix, run: execRunner}, nil
+}
+
+// Name maps an account to {prefix}{group}--{account} since secret ids
+// allow no slashes
+func (g *GCP) Name(gid, account string) string {
+	return flatName(g.Prefix, gid, account)
+}
+
+// Get returns the latest version of the secret or an ErrNotFound
+func (g *GCP) Get(ctx context.Context, name string) (string, error) {
+	out, err := g.gcloud(ctx, nil, "versions", "access", "latest", "--secret", name)
+	if err != nil {
+		if strings.Contains(err.Error(), "NOT_FOUND") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Put creates the secret or adds the value as new version
+func (g *GCP) Put(ctx context.Context, name, value string, create bool) error {
+	var err error
+	if create {
+		_, err = g.gcloud(ctx, []byte(value), "create", name, "--data-file", "-", "--replication-policy", "automatic")
+	} else {
+		_, err = g.gcloud(ctx, []byte(value), "versions", "add", name, "--data-file", "-")
+	}
+	return err
+}
+
+// gcloud runs a gcloud secrets command
+func (g *GCP) gcloud(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	args = append([]string{"secrets"}, args...)
+	if g.Project != "" {
+		args = append(args, "--project", g.Project)
+	}
+	return g.run(ctx, stdin, "gcloud", args...)
+}
@@ -2,20 +2,29 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/KonstantinGasser/sherlock/internal"
 	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// defaultGenerateLength is the length of auto-generated passwords if not set
+	defaultGenerateLength = 32
+	// minGenerateLength is the shortest length allowed for auto-generated passwords
+	minGenerateLength = 10
+)
+
 func cmdAdd(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
 	add := &cobra.Command{
 		Use:   "add",
 		Short: "add an group or account to sherlock",
 		Long:  "add either a new group to sherlock or an account to a sherlock-group",
-		Run: func(cmd *cobra.Command, args []string) {
-			_ = cmd.Help()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
 		},
 	}
 	add.AddCommand(cmdAddGroup(ctx, sherlock))
@@ -35,21 +44,16 @@ func cmdAddGroup(ctx context.Context, sherlock *internal.Sherlock) *cobra.Comman
 		Short: "add a group to sherlock",
 		Long:  "add a new group for accounts to sherlock",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) <= 0 {
-				terminal.Error("group name not set (sherlock add group [group-name])")
-				return
-			}
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
-			if err := sherlock.SetupGroup(args[0], groupKey, opts.insecure); err != nil {
-				terminal.Error(err.Error())
-				return
+			if err := sherlock.SetupGroup(ctx, args[0], groupKey, opts.insecure); err != nil {
+				return err
 			}
 			terminal.Success("group %q added to sherlock", args[0])
+			return nil
 		},
 	}
 	addGroup.Flags().BoolVarP(&opts.insecure, "insecure", "i", false, "allow insecure group password")
@@ -61,6 +65,15 @@ type addAccountOptions struct {
 	tag      string
 	insecure bool
 	gen      string
+	generate bool
+	length   int
+	preset   string
+	copy     bool
+	username string
+	url      string
+	note     string
+	// pronounceable generates a syllable-based password
+	pronounceable bool
 }
 
 func cmdAddAccount(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
@@ -70,75 +83,110 @@ func cmdAddAccount(ctx context.Context, sherlock *internal.Sherlock) *cobra.Comm
 		Short: "add an account to a sherlock group",
 		Long:  "add a new account to a sherlock group",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) <= 0 {
-				terminal.Error("account name not set (sherlock add account [account-name])")
-				return
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
 			// check if the group exists
 			gid, _, err := internal.SplitQuery(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
+			}
+			if err := sherlock.GroupExists(ctx, gid); err == nil {
+				return sherlock.GroupNotFound(ctx, gid)
+			}
+			// --gen is deprecated in favour of --generate --length
+			if opts.gen != "" {
+				if opts.length, err = strconv.Atoi(opts.gen); err != nil {
+					return fmt.Errorf("%w: invalid length number for auto generated password", internal.ErrInvalidInput)
+				}
+				opts.generate = true
+			}
+			// a preset or --pronounceable implies an auto-generated password
+			if opts.preset != "" || opts.pronounceable {
+				opts.generate = true
 			}
-			if err := sherlock.GroupExists(gid); err == nil {
-				terminal.Error("group does not exist")
-				return
+			if opts.generate && opts.length < minGenerateLength {
+				return fmt.Errorf("%w: length for auto generated password must be at least %d", internal.ErrInvalidInput, minGenerateLength)
 			}
 
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 
 			// validate the password/key
-			err = sherlock.CheckGroupKey(ctx, args[0], groupKey)
-			if err != nil {
-				terminal.Error(err.Error())
-				return
+			if err := sherlock.CheckGroupKey(ctx, args[0], groupKey); err != nil {
+				return err
 			}
 
 			// figure out password: either auto gen password or read from stdin
 			var password string
-			if opts.gen != "" { // generate password
-				passwdLen, err := strconv.Atoi(opts.gen)
-				if err != nil || passwdLen < 10 {
-					terminal.Error("invalid length number for auto generated password (must be number grater then 10")
-					return
+			preset := internal.Preset{Length: opts.length, Pronounceable: opts.pronounceable}
+			if opts.preset != "" {
+				if preset, err = lookupPreset(ctx, sherlock, opts.preset); err != nil {
+					return err
 				}
-				password, err = internal.AutoGeneratePassword(passwdLen)
+				preset.Pronounceable = preset.Pronounceable || opts.pronounceable
+				// the strength of a preset password is up to the site rules and has
+				// been checked when the preset was set
+				opts.insecure = true
+			}
+			if opts.generate {
+				password, err = internal.GeneratePassword(preset)
 				if err != nil {
-					terminal.Error(err.Error())
-					return
+					return err
 				}
-				terminal.Info("generated password : %s", password)
 			} else {
 				password, err = terminal.ReadPassword("(%s) password: ", args[0])
 				if err != nil {
-					terminal.Error(err.Error())
-					return
+					return err
 				}
 			}
 			// create/store new Account
-			account, err := internal.NewAccount(args[0], password, opts.tag, opts.insecure)
+			account, err := internal.NewAccount(args[0], password, opts.tag, opts.insecure,
+				internal.WithUsername(opts.username),
+				internal.WithURL(opts.url),
+				internal.WithNote(opts.note),
+				internal.WithPreset(opts.preset),
+			)
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAddAccount(account)); err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			terminal.Success("account %q successfully added to %q", account.Name, args[0])
+
+			// the generated password is only revealed once the account is stored
+			if opts.generate {
+				if preset.Pronounceable {
+					terminal.Info("entropy of the generated password: %.0f bits", preset.Entropy())
+				}
+				if opts.copy {
+					if err := clipboard.WriteAll(password); err != nil {
+						return err
+					}
+					terminal.Info("generated password copied to clipboard")
+					return nil
+				}
+				terminal.Info("generated password : %s", password)
+			}
+			return nil
 		},
 	}
 
 	addGroup.Flags().StringVarP(&opts.tag, "tag", "t", "", "optional tag for this account")
 	addGroup.Flags().BoolVarP(&opts.insecure, "insecure", "i", false, "allow insecure group password")
+	addGroup.Flags().StringVarP(&opts.username, "username", "u", "", "optional username for this account")
+	addGroup.Flags().StringVar(&opts.url, "url", "", "optional url for this account")
+	addGroup.Flags().StringVar(&opts.note, "note", "", "optional note for this account")
+	addGroup.Flags().BoolVarP(&opts.generate, "generate", "g", false, "auto-generate a secure password instead of entering one")
+	addGroup.Flags().IntVarP(&opts.length, "length", "l", defaultGenerateLength, "length of the auto-generated password")
+	addGroup.Flags().StringVar(&opts.preset, "preset", "", "auto-generate the password with a generator preset (see sherlock preset) and keep it for the account")
+	addGroup.Flags().BoolVar(&opts.pronounceable, "pronounceable", false, "auto-generate a syllable-based password which is easier to type on TVs and consoles")
+	addGroup.Flags().BoolVarP(&opts.copy, "copy", "c", false, "copy the auto-generated password to the clipboard instead of printing it")
 
 	// I set this to string to make input validation checking easier if the input data is not a valid number
 	addGroup.Flags().StringVarP(&opts.gen, "gen", "e", "", "length for auto-generate secure password. Create your own password when not set")
+	_ = addGroup.Flags().MarkDeprecated("gen", "use --generate --length instead")
 
 	return addGroup
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/autotype"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/spf13/cobra"
+)
+
+type autotypeOptions struct {
+	delay    time.Duration
+	noSwitch bool
+}
+
+func cmdAutotype(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts autotypeOptions
+	autotypeCmd := &cobra.Command{
+		Use:   "autotype",
+		Short: "type an accounts credentials into the previously active window",
+		Long: "autotype focuses the previously active window and types the accounts auto-type sequence " +
+			"(default: " + autotype.DefaultSequence + ") using xdotool or wtype on Linux and System Events on macOS",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.delay < 0 {
+				return fmt.Errorf("%w: delay must not be negative", internal.ErrInvalidInput)
+			}
+			backend, err := autotype.Detect()
+			if err != nil {
+				return err
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			keystrokes, err := account.AutoTypeSequence(time.Now())
+			if err != nil {
+				return err
+			}
+			return autotype.Run(ctx, backend, keystrokes, !opts.noSwitch, opts.delay)
+		},
+	}
+	autotypeCmd.Flags().DurationVarP(&opts.delay, "delay", "d", 500*time.Millisecond, "time to wait for the target window before typing")
+	autotypeCmd.Flags().BoolVar(&opts.noSwitch, "no-switch", false, "type into the window focused after the delay instead of switching windows")
+
+	return autotypeCmd
+}
@@ -0,0 +1,437 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/backup"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func cmdBackup(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "back up all group vaults",
+		Long: "backup writes snapshots of all (still encrypted) group vaults to the configured target and prunes old " +
+			"snapshots. No group key is required so backup run can be scheduled with cron or a systemd timer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	backupCmd.AddCommand(cmdBackupRun(ctx, sherlock))
+	backupCmd.AddCommand(cmdBackupConfig(ctx, sherlock))
+	backupCmd.AddCommand(cmdBackupList(ctx, sherlock))
+	backupCmd.AddCommand(cmdBackupKeygen(ctx, sherlock))
+	backupCmd.AddCommand(cmdBackupRestore(ctx, sherlock))
+	return backupCmd
+}
+
+type backupOptions struct {
+	target       string
+	remote       string
+	keepDaily    int
+	keepWeekly   int
+	keepMonthly  int
+	passphrase   bool
+	recipient    string
+	noEncryption bool
+}
+
+// flags registers the backup flags with the configured values as defaults
+func (opts *backupOptions) flags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&opts.target, "target", "t", "", "directory to store snapshots in")
+	cmd.Flags().StringVarP(&opts.remote, "remote", "r", "", "rclone remote (remote:path) to store snapshots in instead of a directory")
+	cmd.Flags().IntVar(&opts.keepDaily, "keep-daily", 0, "number of daily snapshots to keep")
+	cmd.Flags().IntVar(&opts.keepWeekly, "keep-weekly", 0, "number of weekly snapshots to keep")
+	cmd.Flags().IntVar(&opts.keepMonthly, "keep-monthly", 0, "number of monthly snapshots to keep")
+	cmd.Flags().BoolVar(&opts.passphrase, "passphrase", false, "seal snapshots with a backup passphrase (SHERLOCK_BACKUP_KEY or prompt)")
+	cmd.Flags().StringVar(&opts.recipient, "recipient", "", "seal snapshots for the recipient created by backup keygen")
+	cmd.Flags().BoolVar(&opts.noEncryption, "no-encryption", false, "do not seal snapshots (vaults stay encrypted with their group keys)")
+}
+
+// apply overrides the config with the flags set on the command
+func (opts backupOptions) apply(cmd *cobra.Command, config *internal.BackupConfig) error {
+	if cmd.Flags().Changed("target") && cmd.Flags().Changed("remote") {
+		return fmt.Errorf("%w: use either --target or --remote", internal.ErrInvalidInput)
+	}
+	if cmd.Flags().Changed("target") {
+		config.Target = opts.target
+		config.Remote = ""
+	}
+	if cmd.Flags().Changed("remote") {
+		if err := backup.CheckRemote(opts.remote); err != nil {
+			return err
+		}
+		config.Remote = opts.remote
+		config.Target = ""
+	}
+	for _, keep := range []struct {
+		flag  string
+		value int
+		set   *int
+	}{
+		{flag: "keep-daily", value: opts.keepDaily, set: &config.KeepDaily},
+		{flag: "keep-weekly", value: opts.keepWeekly, set: &config.KeepWeekly},
+		{flag: "keep-monthly", value: opts.keepMonthly, set: &config.KeepMonthly},
+	} {
+		if !cmd.Flags().Changed(keep.flag) {
+			continue
+		}
+		if keep.value < 0 {
+			return fmt.Errorf("%w: --%s must not be negative", internal.ErrInvalidInput, keep.flag)
+		}
+		*keep.set = keep.value
+	}
+	switch {
+	case opts.passphrase && opts.recipient != "", opts.noEncryption && (opts.passphrase || opts.recipient != ""):
+		return fmt.Errorf("%w: use only one of --passphrase, --recipient and --no-encryption", internal.ErrInvalidInput)
+	case opts.passphrase:
+		config.Encryption = internal.BackupEncryptionPassphrase
+	case opts.recipient != "":
+		if err := security.CheckRecipient(opts.recipient); err != nil {
+			return err
+		}
+		config.Encryption = internal.BackupEncryptionRecipient
+		config.Recipient = strings.TrimSpace(opts.recipient)
+	case opts.noEncryption:
+		config.Encryption = ""
+		config.Recipient = ""
+	}
+	return nil
+}
+
+func backupTarget(config internal.BackupConfig) (backup.Target, error) {
+	if config.Remote != "" {
+		return backup.NewRcloneTarget(config.Remote)
+	}
+	if config.Target == "" {
+		return nil, backup.ErrNoTarget
+	}
+	return backup.NewDirTarget(afero.NewOsFs(), config.Target), nil
+}
+
+// backupLocation describes where snapshots are stored
+func backupLocation(config internal.BackupConfig) string {
+	if config.Remote != "" {
+		return config.Remote
+	}
+	if config.Target != "" {
+		return config.Target
+	}
+	return "(not set)"
+}
+
+// backupSnapshot returns the function writing the snapshot sealed as configured
+func backupSnapshot(ctx context.Context, sherlock *internal.Sherlock, config internal.BackupConfig) (func(io.Writer) error, error) {
+	var seal func([]byte) ([]byte, error)
+	switch config.Encryption {
+	case "":
+		return func(w io.Writer) error {
+			return sherlock.Snapshot(ctx, w)
+		}, nil
+	case internal.BackupEncryptionRecipient:
+		seal = func(b []byte) ([]byte, error) {
+			return security.SealRecipient(b, config.Recipient)
+		}
+	case internal.BackupEncryptionPassphrase:
+		passphrase, err := readBackupKey("backup passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if passphrase == "" {
+			return nil, fmt.Errorf("%w: backup passphrase must not be empty", internal.ErrInvalidInput)
+		}
+		seal = func(b []byte) ([]byte, error) {
+			return security.SealPassphrase(b, passphrase)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown backup encryption %q", internal.ErrInvalidInput, config.Encryption)
+	}
+	return func(w io.Writer) error {
+		var buf bytes.Buffer
+		if err := sherlock.Snapshot(ctx, &buf); err != nil {
+			return err
+		}
+		sealed, err := seal(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(sealed)
+		return err
+	}, nil
+}
+
+func cmdBackupRun(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts backupOptions
+	run := &cobra.Command{
+		Use:   "run",
+		Short: "write a snapshot of all vaults and prune old snapshots",
+		Long:  "run writes a snapshot of all vaults to the target and removes snapshots not kept by the retention policy. Flags override the configured values for this run",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			if err := opts.apply(cmd, &config.Backup); err != nil {
+				return err
+			}
+			target, err := backupTarget(config.Backup)
+			if err != nil {
+				return err
+			}
+			snapshot, err := backupSnapshot(ctx, sherlock, config.Backup)
+			if err != nil {
+				return err
+			}
+			name := backup.SnapshotName(time.Now(), config.Backup.Encryption != "")
+			removed, err := backup.Run(ctx, target, backup.Policy{
+				KeepDaily:   config.Backup.KeepDaily,
+				KeepWeekly:  config.Backup.KeepWeekly,
+				KeepMonthly: config.Backup.KeepMonthly,
+			}, name, snapshot)
+			if err != nil {
+				return err
+			}
+			terminal.Success("snapshot %s written to %s", name, backupLocation(config.Backup))
+			for _, name := range removed {
+				terminal.Info("pruned %s", name)
+			}
+			return nil
+		},
+	}
+	opts.flags(run)
+	return run
+}
+
+func cmdBackupConfig(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts backupOptions
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "show or change the backup target, encryption and retention policy",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().NFlag() > 0 {
+				if err := opts.apply(cmd, &config.Backup); err != nil {
+					return err
+				}
+				if err := sherlock.SaveConfig(ctx, config); err != nil {
+					return err
+				}
+				terminal.Success("backup config updated")
+			}
+			encryption := config.Backup.Encryption
+			switch encryption {
+			case "":
+				encryption = "none (group keys only)"
+			case internal.BackupEncryptionRecipient:
+				encryption += " " + config.Backup.Recipient
+			}
+			terminal.Info("target       : %s", backupLocation(config.Backup))
+			terminal.Info("encryption   : %s", encryption)
+			terminal.Info("keep daily   : %d", config.Backup.KeepDaily)
+			terminal.Info("keep weekly  : %d", config.Backup.KeepWeekly)
+			terminal.Info("keep monthly : %d", config.Backup.KeepMonthly)
+			return nil
+		},
+	}
+	opts.flags(config)
+	return config
+}
+
+func cmdBackupList(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the snapshots stored in the backup target",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			target, err := backupTarget(config.Backup)
+			if err != nil {
+				return err
+			}
+			names, err := target.List(ctx)
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, name := range names {
+				if at, ok := backup.ParseSnapshotName(name); ok {
+					rows = append(rows, []string{name, at.Local().Format(eventTimeLayout)})
+				}
+			}
+			terminal.ToTable([]string{"Snapshot", "Taken"}, rows)
+			return nil
+		},
+	}
+}
+
+type backupKeygenOptions struct {
+	out string
+}
+
+func cmdBackupKeygen(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts backupKeygenOptions
+	keygen := &cobra.Command{
+		Use:   "keygen",
+		Short: "create a key pair to seal snapshots for",
+		Long: "keygen creates an identity and its recipient and configures backups to be sealed for the recipient. " +
+			"Only the identity can open the snapshots - store it offline (e.g. with a recovery officer). " +
+			"backup run then no longer needs any secret",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			identity, recipient, err := security.GenerateIdentity()
+			if err != nil {
+				return err
+			}
+			if opts.out != "" {
+				f, err := os.OpenFile(opts.out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+				if err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintln(f, identity); err != nil {
+					f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+				terminal.Success("identity written to %s", opts.out)
+			} else {
+				terminal.Warning("store the identity offline, it is not kept by sherlock")
+				terminal.Info("identity  : %s", identity)
+			}
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			config.Backup.Encryption = internal.BackupEncryptionRecipient
+			config.Backup.Recipient = recipient
+			if err := sherlock.SaveConfig(ctx, config); err != nil {
+				return err
+			}
+			terminal.Info("recipient : %s", recipient)
+			terminal.Success("backups are sealed for the recipient from now on")
+			return nil
+		},
+	}
+	keygen.Flags().StringVar(&opts.out, "out", "", "write the identity to a new file instead of printing it")
+	return keygen
+}
+
+type backupRestoreOptions struct {
+	groups   []string
+	identity string
+	force    bool
+}
+
+func cmdBackupRestore(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts backupRestoreOptions
+	restore := &cobra.Command{
+		Use:   "restore <snapshot>",
+		Short: "restore group vaults from a snapshot",
+		Long: "restore writes the group vaults of a snapshot (a file or the name of a snapshot in the backup target) back " +
+			"to sherlock. Existing groups are only replaced with --force. Sealed snapshots are opened with the backup " +
+			"passphrase or identity (SHERLOCK_BACKUP_KEY, --identity or prompt)",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := readSnapshotFile(ctx, sherlock, args[0])
+			if err != nil {
+				return err
+			}
+			if security.IsSealed(b) {
+				secret := envBackupKey
+				if opts.identity != "" {
+					identity, err := ioutil.ReadFile(opts.identity)
+					if err != nil {
+						return err
+					}
+					secret = string(identity)
+				}
+				if secret == "" {
+					if secret, err = terminal.ReadPassword("backup passphrase or identity: "); err != nil {
+						return err
+					}
+				}
+				if b, err = security.OpenSealed(b, secret); err != nil {
+					return err
+				}
+			}
+			vaults, err := internal.ReadSnapshot(bytes.NewReader(b))
+			if err != nil {
+				return err
+			}
+			gids := opts.groups
+			if len(gids) == 0 {
+				for gid := range vaults {
+					gids = append(gids, gid)
+				}
+				sort.Strings(gids)
+			}
+			var failed int
+			for _, gid := range gids {
+				vault, ok := vaults[gid]
+				if !ok {
+					terminal.Warning("%s: %s", gid, internal.ErrNoSuchGroup)
+					failed++
+					continue
+				}
+				if err := sherlock.RestoreGroup(ctx, gid, vault, opts.force); err != nil {
+					terminal.Warning("%s: %s", gid, err)
+					failed++
+					continue
+				}
+				terminal.Success("%s: restored", gid)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d groups not restored", failed, len(gids))
+			}
+			return nil
+		},
+	}
+	restore.Flags().StringSliceVarP(&opts.groups, "group", "g", nil, "restore only the group (repeatable)")
+	restore.Flags().StringVar(&opts.identity, "identity", "", "file holding the identity to open a sealed snapshot")
+	restore.Flags().BoolVarP(&opts.force, "force", "f", false, "replace existing groups")
+	return restore
+}
+
+// readSnapshotFile reads a snapshot from a file or, if no such file exists,
+// from the configured backup target by its name
+func readSnapshotFile(ctx context.Context, sherlock *internal.Sherlock, snapshot string) ([]byte, error) {
+	b, err := ioutil.ReadFile(snapshot)
+	if err == nil || !os.IsNotExist(err) {
+		return b, err
+	}
+	config, cfgErr := sherlock.Config(ctx)
+	if cfgErr != nil || (config.Backup.Target == "" && config.Backup.Remote == "") {
+		return nil, err
+	}
+	target, cfgErr := backupTarget(config.Backup)
+	if cfgErr != nil {
+		return nil, err
+	}
+	r, err := target.Get(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/storage"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+var ErrNotCached = fmt.Errorf("the storage backend is not cached (only remote backends are)")
+
+func cmdCache(ctx context.Context) *cobra.Command {
+	cache := &cobra.Command{
+		Use:   "cache",
+		Short: "inspect the local cache of a remote storage backend",
+		Long:  "vaults read from a remote storage backend are cached (still encrypted) so they can be read offline. Writes always go to the remote and fail if the vault was changed there since it was read",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cache.AddCommand(cmdCacheStatus(ctx))
+	cache.AddCommand(cmdCacheClear())
+
+	return cache
+}
+
+// cachedConfig loads the storage config failing if the backend is not cached
+func cachedConfig() (storage.Config, error) {
+	config, err := storage.LoadConfig()
+	if err != nil {
+		return storage.Config{}, err
+	}
+	if !config.Cached() {
+		return storage.Config{}, ErrNotCached
+	}
+	return config, nil
+}
+
+func cmdCacheStatus(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "compare the cached vaults with the remote",
+		Long:  "list the cached vaults and whether they are current, stale (changed on the remote), removed from the remote or cannot be compared because the remote is offline",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := cachedConfig()
+			if err != nil {
+				return err
+			}
+			remote, err := storage.OpenRemote(config)
+			if err != nil {
+				return err
+			}
+			entries, err := storage.CacheStatus(ctx, config, remote)
+			if err != nil {
+				return err
+			}
+			terminal.Info("cache of the %s backend: %s", config.Backend, storage.CachePath(config))
+			rows := make([][]string, 0, len(entries))
+			for _, e := range entries {
+				rows = append(rows, []string{e.Group, formatSize(e.Size), e.Cached.Local().Format(eventTimeLayout), e.Revision[:12], e.State})
+			}
+			terminal.ToTable([]string{"Group", "Size", "Cached", "Revision", "State"}, rows)
+			return nil
+		},
+	}
+}
+
+func cmdCacheClear() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "remove the cached vaults",
+		Long:  "remove the cached vaults of the remote storage backend. They are cached again when read",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := cachedConfig()
+			if err != nil {
+				return err
+			}
+			if err := storage.ClearCache(config); err != nil {
+				return err
+			}
+			terminal.Success("cache %s cleared", storage.CachePath(config))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/certs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// caValidity is how long a CA created with certs init is valid
+const caValidity = 10 * 365 * 24 * time.Hour
+
+type certsOptions struct {
+	dir  string
+	name string
+	out  string
+	days int
+}
+
+func cmdCerts() *cobra.Command {
+	var opts certsOptions
+
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "manage the certificates authenticating devices to each other",
+		Long: "create a local certificate authority and issue device certificates so devices syncing with sherlock sync peer " +
+			"authenticate each other with mutual TLS, which sync peer requires. The CA stays on the device it was created on",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.dir, "dir", certs.Dir(), "directory holding the certificates")
+	cmd.AddCommand(cmdCertsInit(&opts))
+	cmd.AddCommand(cmdCertsIssue(&opts))
+	cmd.AddCommand(cmdCertsShow(&opts))
+
+	return cmd
+}
+
+func cmdCertsInit(opts *certsOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "create a certificate authority and the certificate of this device",
+		Long: "create a certificate authority valid for 10 years and issue the certificate of this device with it. The CA key is " +
+			"written to ca.key next to the certificates and never leaves this device; an existing CA is never replaced",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			valid, err := certValidity(opts.days)
+			if err != nil {
+				return err
+			}
+			name, err := deviceName(opts.name)
+			if err != nil {
+				return err
+			}
+			ca, err := certs.NewAuthority(name+" sherlock CA", caValidity)
+			if err != nil {
+				return err
+			}
+			if err := ca.Save(opts.dir); err != nil {
+				return err
+			}
+			certPEM, keyPEM, err := ca.Issue(name, valid)
+			if err != nil {
+				return err
+			}
+			if err := certs.WriteIdentity(opts.dir, ca.CertPEM(), certPEM, keyPEM); err != nil {
+				return err
+			}
+			terminal.Success("certificate authority and certificate of %s written to %s", name, opts.dir)
+			terminal.Info("issue certificates for other devices with: sherlock certs issue <device> --out <dir>")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.name, "name", "", "name of this device (default: host name)")
+	cmd.Flags().IntVar(&opts.days, "days", 365, "number of days the device certificate is valid")
+
+	return cmd
+}
+
+func cmdCertsIssue(opts *certsOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "issue <device>",
+		Short: "issue the certificate of another device",
+		Long: "issue a certificate for another device with the CA of this device. The CA certificate, the device certificate and its " +
+			"key are written to --out; copy the directory to ~/.sherlock/certs on the device. The key is not encrypted, move it " +
+			"over a trusted channel and remove the copy => sherlock certs issue desktop --out /media/usb/desktop",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.out == "" {
+				return fmt.Errorf("%w: --out is required", internal.ErrInvalidInput)
+			}
+			if abs, err := filepath.Abs(opts.out); err == nil && abs == filepath.Clean(opts.dir) {
+				return fmt.Errorf("%w: --out must not be the certificate directory of this device", internal.ErrInvalidInput)
+			}
+			valid, err := certValidity(opts.days)
+			if err != nil {
+				return err
+			}
+			ca, err := certs.LoadAuthority(opts.dir)
+			if err != nil {
+				return err
+			}
+			certPEM, keyPEM, err := ca.Issue(args[0], valid)
+			if err != nil {
+				return err
+			}
+			if err := certs.WriteIdentity(opts.out, ca.CertPEM(), certPEM, keyPEM); err != nil {
+				return err
+			}
+			terminal.Success("certificate of %s written to %s", args[0], opts.out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.out, "out", "", "directory the certificate of the device is written to")
+	cmd.Flags().IntVar(&opts.days, "days", 365, "number of days the certificate is valid")
+
+	return cmd
+}
+
+func cmdCertsShow(opts *certsOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "show the certificate of this device",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := certs.LoadIdentity(opts.dir)
+			if err != nil {
+				return err
+			}
+			_, err = certs.LoadAuthority(opts.dir)
+			hasCA := err == nil
+			if err != nil && err != certs.ErrNoCA {
+				return err
+			}
+			terminal.ToTable([]string{"Device", "Expires", "CA on this device"}, [][]string{
+				{id.Name, id.NotAfter.Local().Format(eventTimeLayout), fmt.Sprintf("%t", hasCA)},
+			})
+			if time.Until(id.NotAfter) < 30*24*time.Hour {
+				terminal.Warning("the certificate expires soon; issue a new one with sherlock certs issue")
+			}
+			return nil
+		},
+	}
+}
+
+// certValidity converts the --days flag
+func certValidity(days int) (time.Duration, error) {
+	if days <= 0 {
+		return 0, fmt.Errorf("%w: --days must be a positive number", internal.ErrInvalidInput)
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// deviceName returns the name or the host name if none is given
+func deviceName(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	return os.Hostname()
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/cloud"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// kinds of change of a cloud secret
+const (
+	cloudCreated = "created"
+	cloudChanged = "changed"
+)
+
+type cloudOptions struct {
+	all      bool
+	tag      string
+	prefix   string
+	dryRun   bool
+	insecure bool
+}
+
+// newProvider creates the cloud.Provider of a command once its flags are parsed
+type newProvider func(prefix string) (cloud.Provider, error)
+
+func cmdAWS(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var service, profile, region string
+	aws := &cobra.Command{
+		Use:   "aws",
+		Short: "sync passwords with AWS Secrets Manager or SSM Parameter Store",
+		Long: "push passwords to or pull them from AWS Secrets Manager (--service secretsmanager) or the SSM Parameter " +
+			"Store (--service ssm) using the aws CLI and its configured credentials. Accounts are mapped to " +
+			"{prefix}{group}/{account} and can be selected by --tag",
+	}
+	aws.PersistentFlags().StringVar(&service, "service", cloud.AWSSecretsManager, "AWS service to use (secretsmanager|ssm)")
+	aws.PersistentFlags().StringVar(&profile, "profile", "", "aws CLI profile")
+	aws.PersistentFlags().StringVar(&region, "region", "", "aws region")
+
+	return cmdCloud(ctx, sherlock, aws, "sherlock/", func(prefix string) (cloud.Provider, error) {
+		return cloud.NewAWS(service, prefix, profile, region)
+	})
+}
+
+func cmdAzure(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var vault string
+	azure := &cobra.Command{
+		Use:   "azure",
+		Short: "sync passwords with an Azure Key Vault",
+		Long: "push passwords to or pull them from an Azure Key Vault using the az CLI and its configured " +
+			"credentials. Accounts are mapped to {prefix}{group}--{account} and can be selected by --tag",
+	}
+	azure.PersistentFlags().StringVar(&vault, "vault", "", "name of the key vault")
+
+	return cmdCloud(ctx, sherlock, azure, "sherlock-", func(prefix string) (cloud.Provider, error) {
+		return cloud.NewAzure(vault, prefix)
+	})
+}
+
+func cmdGCP(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var project string
+	gcp := &cobra.Command{
+		Use:   "gcp",
+		Short: "sync passwords with Google Secret Manager",
+		Long: "push passwords to or pull them from Google Secret Manager using the gcloud CLI and its configured " +
+			"credentials. Accounts are mapped to {prefix}{group}--{account} and can be selected by --tag",
+	}
+	gcp.PersistentFlags().StringVar(&project, "project", "", "project of the secrets (default gcloud project)")
+
+	return cmdCloud(ctx, sherlock, gcp, "sherlock-", func(prefix string) (cloud.Provider, error) {
+		return cloud.NewGCP(project, prefix)
+	})
+}
+
+// cmdCloud adds the push and pull commands and the shared selection flags
+// to the command of a cloud provider
+func cmdCloud(ctx context.Context, sherlock *internal.Sherlock, provider *cobra.Command, defaultPrefix string, newProvider newProvider) *cobra.Command {
+	var opts cloudOptions
+	provider.RunE = func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	}
+	provider.PersistentFlags().BoolVarP(&opts.all, "all", "a", false, "sync all registered groups")
+	provider.PersistentFlags().StringVarP(&opts.tag, "tag", "t", "", "only sync accounts with the tag")
+	provider.PersistentFlags().StringVar(&opts.prefix, "prefix", defaultPrefix, "prefix of the secret names")
+	provider.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, "only print the changes")
+
+	push := &cobra.Command{
+		Use:   "push",
+		Short: "write passwords to the cloud",
+		Long:  "create or update the secrets of the selected accounts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := newProvider(opts.prefix)
+			if err != nil {
+				return err
+			}
+			entries, _, err := selectEntries(ctx, sherlock, p, args, opts, false)
+			if err != nil {
+				return err
+			}
+			progress := newProgress(cmd, "checking secrets", len(entries))
+			changes, err := cloud.PlanPush(ctx, p, entries, progress)
+			progress.Finish()
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				terminal.Info("all secrets are up to date")
+				return nil
+			}
+			printCloudChanges(changes)
+			if opts.dryRun {
+				return nil
+			}
+			progress = newProgress(cmd, "pushing secrets", len(changes))
+			err = cloud.Push(ctx, p, changes, progress)
+			progress.Finish()
+			if err != nil {
+				return err
+			}
+			terminal.Success("%d secrets pushed", len(changes))
+			return nil
+		},
+	}
+
+	pull := &cobra.Command{
+		Use:   "pull",
+		Short: "update passwords from the cloud",
+		Long:  "update the passwords of the selected accounts with the values of their secrets. Accounts without a secret are left untouched",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := newProvider(opts.prefix)
+			if err != nil {
+				return err
+			}
+			entries, keys, err := selectEntries(ctx, sherlock, p, args, opts, true)
+			if err != nil {
+				return err
+			}
+			progress := newProgress(cmd, "checking secrets", len(entries))
+			changes, err := cloud.PlanPull(ctx, p, entries, progress)
+			progress.Finish()
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				terminal.Info("all passwords are up to date")
+				return nil
+			}
+			printCloudChanges(changes)
+			if opts.dryRun {
+				return nil
+			}
+			progress = newProgress(cmd, "updating passwords", len(changes))
+			for _, c := range changes {
+				progress.Start(c.Query)
+				err := sherlock.UpdateState(ctx, c.Query, keys[c.Query], internal.OptAccPassword(c.Value, opts.insecure))
+				progress.Done(c.Query, err)
+				if err != nil {
+					progress.Finish()
+					return fmt.Errorf("%s: %w", c.Query, err)
+				}
+			}
+			progress.Finish()
+			terminal.Success("%d passwords updated", len(changes))
+			return nil
+		},
+	}
+	pull.Flags().BoolVar(&opts.insecure, "insecure", false, "accept pulled passwords failing the password policy")
+
+	provider.AddCommand(push)
+	provider.AddCommand(pull)
+
+	return provider
+}
+
+// selectEntries loads the groups (see pickGroups) and maps their accounts
+// with the tag (all accounts if no tag is given) to cloud.Entries holding the
+// password. Links are followed and accounts which are still time-locked are
+// skipped; a pull skips all linked and time-locked accounts. The group keys
+// are returned by query to write pulled passwords
+func selectEntries(ctx context.Context, sherlock *internal.Sherlock, p cloud.Provider, args []string, opts cloudOptions, pull bool) ([]cloud.Entry, map[string]string, error) {
+	gids, err := pickGroups(ctx, sherlock, args, opts.all)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := internal.FilterByTag(opts.tag)
+	var entries []cloud.Entry
+	keys := make(map[string]string)
+	for _, gid := range gids {
+		groupKey, err := readGroupKey(gid)
+		if err != nil {
+			return nil, nil, err
+		}
+		group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", gid, err)
+		}
+		for _, account := range group.Accounts {
+			if !filter(account) {
+				continue
+			}
+			query := gid + "@" + account.Name
+			// pulling would replace the link or the time lock with the value of the secret
+			if pull && (account.Linked() || account.TimeLock != nil) {
+				terminal.Warning("%s is skipped: linked and time-locked accounts are not pulled", query)
+				continue
+			}
+			password, err := sherlock.AccountPassword(ctx, *account, readGroupKey)
+			if errors.Is(err, internal.ErrTimeLocked) {
+				terminal.Warning("%s is skipped: %v", query, err)
+				continue
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", query, err)
+			}
+			entries = append(entries, cloud.Entry{Query: query, Name: p.Name(gid, account.Name), Value: password})
+			keys[query] = groupKey
+		}
+	}
+	return entries, keys, nil
+}
+
+func printCloudChanges(changes []cloud.Change) {
+	rows := make([][]string, 0, len(changes))
+	for _, c := range changes {
+		change := cloudChanged
+		if c.Create {
+			change = cloudCreated
+		}
+		rows = append(rows, []string{c.Query, c.Name, change})
+	}
+	terminal.ToTable([]string{"Account", "Secret", "Change"}, rows)
+}
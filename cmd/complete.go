@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/spf13/cobra"
+)
+
+// queryArgs are the commands (by command path) whose first argument is a query
+// like group@account
+var queryArgs = map[string]bool{
+	"sherlock autotype":        true,
+	"sherlock del account":     true,
+	"sherlock fav add":         true,
+	"sherlock fav rm":          true,
+	"sherlock gen pin":         true,
+	"sherlock get":             true,
+	"sherlock k8s credential":  true,
+	"sherlock launch":          true,
+	"sherlock link":            true,
+	"sherlock log":             true,
+	"sherlock otp":             true,
+	"sherlock otp export":      true,
+	"sherlock otp import":      true,
+	"sherlock pipe":            true,
+	"sherlock qr":              true,
+	"sherlock recovery set":    true,
+	"sherlock recovery status": true,
+	"sherlock recovery use":    true,
+	"sherlock rotate":          true,
+	"sherlock show":            true,
+	"sherlock share link":      true,
+	"sherlock timelock open":   true,
+	"sherlock timelock set":    true,
+	"sherlock tmux send":       true,
+	"sherlock unlink":          true,
+	"sherlock update autotype": true,
+	"sherlock update name":     true,
+	"sherlock update note":     true,
+	"sherlock update otp":      true,
+	"sherlock update password": true,
+	"sherlock update preset":   true,
+	"sherlock update tag":      true,
+	"sherlock update url":      true,
+	"sherlock update username": true,
+}
+
+// groupArgs are the commands (by command path) whose first argument is a group
+var groupArgs = map[string]bool{
+	"sherlock del group":        true,
+	"sherlock diff":             true,
+	"sherlock list":             true,
+	"sherlock lock":             true,
+	"sherlock unlock":           true,
+	"sherlock transfer send":    true,
+	"sherlock transfer receive": true,
+	"sherlock undo":             true,
+	"sherlock keyring add":      true,
+	"sherlock keyring rm":       true,
+	"sherlock group require":    true,
+	"sherlock group archive":    true,
+	"sherlock group unarchive":  true,
+	"sherlock group hint":       true,
+	"sherlock group index":      true,
+}
+
+// registerCompletions adds the dynamic completion of groups and accounts to the
+// commands taking a query or group argument and to every --group flag
+func registerCompletions(ctx context.Context, sherlock *internal.Sherlock, root *cobra.Command) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		switch {
+		case queryArgs[cmd.CommandPath()]:
+			cmd.ValidArgsFunction = completeFirst(completeQueries(ctx, sherlock))
+		case groupArgs[cmd.CommandPath()]:
+			cmd.ValidArgsFunction = completeFirst(completeGroups(ctx, sherlock))
+		}
+		if cmd.LocalFlags().Lookup("group") != nil {
+			_ = cmd.RegisterFlagCompletionFunc("group", completeGroups(ctx, sherlock))
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+}
+
+// completion is the signature of cobra's dynamic completion functions
+type completion func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeFirst only completes the first argument
+func completeFirst(complete completion) completion {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+// completeGroups completes the names of the registered groups
+func completeGroups(ctx context.Context, sherlock *internal.Sherlock) completion {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		groups, err := sherlock.ActiveGroups(ctx)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return groups, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeQueries completes group@ and, once a group is typed, the accounts of the
+// group. Accounts are only completed for groups whose key is set as SHERLOCK_KEY_<GROUP>
+// since a completion must never prompt for a key
+func completeQueries(ctx context.Context, sherlock *internal.Sherlock) completion {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if !strings.Contains(toComplete, "@") {
+			groups, err := sherlock.ActiveGroups(ctx)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			queries := make([]string, len(groups))
+			for i, gid := range groups {
+				queries[i] = gid + "@"
+			}
+			return queries, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+		}
+		gid := toComplete[:strings.Index(toComplete, "@")]
+		groupKey, ok := envGroupKeys[internal.GroupKeyEnv(gid)]
+		if !ok {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return group.Queries(), cobra.ShellCompDirectiveNoFileComp
+	}
+}
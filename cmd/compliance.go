@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type complianceExportOptions struct {
+	all    bool
+	maxAge int
+	out    string
+}
+
+type complianceVerifyOptions struct {
+	fingerprint string
+}
+
+func cmdCompliance(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	compliance := &cobra.Command{
+		Use:   "compliance",
+		Short: "export and verify signed compliance reports",
+		Long: "a compliance report states how the groups comply with the password policy: key derivation of the vaults, vault " +
+			"signatures, weak and outdated passwords. It holds no secrets or account names and is signed with the device key so " +
+			"auditors can verify it without access to any vault",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	compliance.AddCommand(cmdComplianceExport(ctx, sherlock))
+	compliance.AddCommand(cmdComplianceVerify())
+
+	return compliance
+}
+
+func cmdComplianceExport(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts complianceExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export [groups]",
+		Short: "write a signed compliance report",
+		Long: "check the groups (default group if none) against the password policy and write the signed report to <out>.json " +
+			"and a human-readable copy to <out>.txt. Vault signing must be enabled (sherlock sign enable)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.maxAge <= 0 {
+				return fmt.Errorf("%w: --max-age must be a positive number of days", internal.ErrInvalidInput)
+			}
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			groups, err := loadGroups(ctx, sherlock, gids)
+			if err != nil {
+				return err
+			}
+			report, err := sherlock.Compliance(ctx, time.Now(), time.Duration(opts.maxAge)*24*time.Hour, groups...)
+			if err != nil {
+				return err
+			}
+			signed, err := sherlock.SignReport(ctx, report)
+			if err != nil {
+				return err
+			}
+
+			out := opts.out
+			if out == "" {
+				out = "compliance-" + report.Generated.Format("2006-01-02")
+			}
+			out = strings.TrimSuffix(out, ".json")
+			var jsonReport, textReport bytes.Buffer
+			if err := internal.WriteReportJSON(&jsonReport, signed); err != nil {
+				return err
+			}
+			if err := internal.WriteReportText(&textReport, report, signed); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(out+".json", jsonReport.Bytes(), 0600); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(out+".txt", textReport.Bytes(), 0600); err != nil {
+				return err
+			}
+			terminal.Success("compliance report of %d group(s) written to %s.json and %s.txt", len(report.Groups), out, out)
+			terminal.Info("signing key %s", signed.Fingerprint())
+			if !report.Compliant {
+				for _, g := range report.Groups {
+					if len(g.Findings) > 0 {
+						terminal.Warning("%s: %s", g.GID, strings.Join(g.Findings, ", "))
+					}
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.all, "all", "a", false, "report on all registered groups")
+	cmd.Flags().IntVar(&opts.maxAge, "max-age", 90, "age in days passwords are accepted to have")
+	cmd.Flags().StringVar(&opts.out, "out", "", "file name of the report without extension (default compliance-<date>)")
+	return cmd
+}
+
+func cmdComplianceVerify() *cobra.Command {
+	var opts complianceVerifyOptions
+
+	cmd := &cobra.Command{
+		Use:   "verify <report.json>",
+		Short: "verify the signature of a compliance report",
+		Long: "verify that the compliance report has not been modified since it was signed. No vault or group key is needed; " +
+			"compare the printed signing key with the one of the device or pass it with --fingerprint",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			report, signed, err := internal.VerifyReport(b)
+			if err != nil {
+				return err
+			}
+			if opts.fingerprint != "" && !strings.EqualFold(opts.fingerprint, signed.Fingerprint()) {
+				return fmt.Errorf("%w: signed with %s", internal.ErrInvalidReport, signed.Fingerprint())
+			}
+			terminal.Success("signature of the report generated %s is valid", report.Generated.Local().Format(time.RFC3339))
+			terminal.Info("signing key %s", signed.Fingerprint())
+			if report.Compliant {
+				terminal.Info("all %d group(s) are compliant", len(report.Groups))
+				return nil
+			}
+			for _, g := range report.Groups {
+				if len(g.Findings) > 0 {
+					terminal.Warning("%s: %s", g.GID, strings.Join(g.Findings, ", "))
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.fingerprint, "fingerprint", "", "fingerprint of the signing key the report must be signed with")
+	return cmd
+}
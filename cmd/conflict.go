@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+)
+
+// conflictDateLayout formats the timestamps shown in conflict prompts
+const conflictDateLayout = "2006-01-02 15:04"
+
+// conflictChoices maps the answers of a conflict prompt to the strategies
+var conflictChoices = map[string]string{
+	"s": internal.ConflictSkip,
+	"o": internal.ConflictOverwrite,
+	"r": internal.ConflictRename,
+}
+
+// conflictResolver returns the Resolver for the --on-conflict strategy
+func conflictResolver(gid, strategy string) (internal.Resolver, error) {
+	if err := internal.ValidConflict(strategy); err != nil {
+		return nil, err
+	}
+	if strategy != internal.ConflictPrompt {
+		return internal.ResolveWith(strategy), nil
+	}
+	return promptConflict(gid), nil
+}
+
+// promptConflict returns a Resolver showing the metadata of both accounts and
+// asking how to resolve the conflict. An upper case answer applies to all
+// remaining conflicts
+func promptConflict(gid string) internal.Resolver {
+	var all string
+	return func(existing, imported *internal.Account) (string, error) {
+		if all != "" {
+			return all, nil
+		}
+		password := "same"
+		if existing.Password != imported.Password {
+			password = "differs"
+		}
+		terminal.ToTable([]string{"Field", "Existing", "Imported"}, [][]string{
+			{"username", existing.Username, imported.Username},
+			{"url", existing.URL, imported.URL},
+			{"tag", existing.Tag, imported.Tag},
+			{"password", password, password},
+			{"note", fmt.Sprintf("%d chars", len(existing.Note)), fmt.Sprintf("%d chars", len(imported.Note))},
+			{"created", existing.CreatedOn.Format(conflictDateLayout), imported.CreatedOn.Format(conflictDateLayout)},
+			{"updated", existing.UpdatedOn.Format(conflictDateLayout), imported.UpdatedOn.Format(conflictDateLayout)},
+		})
+		strategy, forAll, err := askConflict(gid+"@"+existing.Name, "[s]kip, [o]verwrite, [r]ename (upper case for all): ", conflictChoices)
+		if err != nil {
+			return "", err
+		}
+		if forAll {
+			all = strategy
+		}
+		return strategy, nil
+	}
+}
+
+// askConflict prompts until one of the choices is answered and returns its
+// strategy and whether the answer was upper case
+func askConflict(label, prompt string, choices map[string]string) (string, bool, error) {
+	for {
+		answer, err := terminal.ReadLine("(%s) already exists - %s", label, prompt)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: no answer for conflicting %s", internal.ErrInvalidInput, label)
+		}
+		answer = strings.TrimSpace(answer)
+		lower := strings.ToLower(answer)
+		if strategy, ok := choices[lower]; ok {
+			return strategy, answer != lower, nil
+		}
+		terminal.Warning("unknown answer %q", answer)
+	}
+}
+
+// printImported summarizes the outcome of an import
+func printImported(gid string, results []internal.Imported) {
+	var added, overwritten, renamed, skipped int
+	for _, r := range results {
+		switch r.Resolution {
+		case internal.ConflictOverwrite:
+			overwritten++
+		case internal.ConflictRename:
+			renamed++
+			terminal.Info("%s@%s imported as %s@%s", gid, r.Name, gid, r.As)
+		case internal.ConflictSkip:
+			skipped++
+		default:
+			added++
+		}
+	}
+	terminal.Success("%d accounts imported into %s (%d added, %d overwritten, %d renamed, %d skipped)",
+		added+overwritten+renamed, gid, added, overwritten, renamed, skipped)
+}
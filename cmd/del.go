@@ -13,8 +13,8 @@ func cmdDel(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
 		Use:   "del",
 		Short: "delete a group or account from sherlock",
 		Long:  "delete a group or account from sherlock",
-		Run: func(cmd *cobra.Command, args []string) {
-			_ = cmd.Help()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
 		},
 	}
 	del.AddCommand(cmdDelAccount(ctx, sherlock))
@@ -34,20 +34,14 @@ func cmdDelGroup(ctx context.Context, sherlock *internal.Sherlock) *cobra.Comman
 		Short: "delete a group",
 		Long:  "delete a group from sherlock (irreversible, all mapped accounts will be deleted as well)",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) <= 0 {
-				terminal.Error("group key required")
-				return
-			}
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
-			group, err := sherlock.LoadGroup(args[0], groupKey)
+			group, err := sherlock.LoadGroup(ctx, args[0], groupKey)
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			if !opts.force {
 				// show verbose output of all account which will be deleted
@@ -58,14 +52,14 @@ func cmdDelGroup(ctx context.Context, sherlock *internal.Sherlock) *cobra.Comman
 					terminal.TableWithCellMerge(0),
 				)
 				if yes := terminal.YesNo("delete group with [y/N]: "); !yes {
-					return
+					return nil
 				}
 			}
 			if err := sherlock.DeleteGroup(ctx, args[0]); err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			terminal.Success("group %q successfully deleted!", args[0])
+			return nil
 		},
 	}
 	group.Flags().BoolVarP(&opts.force, "force", "f", false, "bypass confirmation dialog")
@@ -81,31 +75,25 @@ func cmdDelAccount(ctx context.Context, sherlock *internal.Sherlock) *cobra.Comm
 	del := &cobra.Command{
 		Use:   "account",
 		Short: "delete an account from a group",
-		Long:  "delete an account from a group",
+		Long:  "delete an account from a group. The account deleted last can be restored with sherlock undo",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) <= 0 {
-				terminal.Error("account key required (group@account)")
-				return
-			}
-
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			if !opts.force {
 				confirm := terminal.YesNo("delete account [y/N]: ")
 				if !confirm {
-					return
+					return nil
 				}
 			}
 
-			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccDelete()); err != nil {
-				terminal.Error(err.Error())
-				return
+			if _, err := sherlock.DeleteAccount(ctx, args[0], groupKey); err != nil {
+				return err
 			}
-			terminal.Success("account %q successfully deleted", args[0])
+			terminal.Success("account %q successfully deleted (use sherlock undo to restore it)", args[0])
+			return nil
 		},
 	}
 	del.Flags().BoolVarP(&opts.force, "force", "f", false, "will bypass [y/N] prompt")
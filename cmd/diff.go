@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdDiff(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	diff := &cobra.Command{
+		Use:   "diff <group> <vault> [<vault>]",
+		Short: "show which accounts changed between two versions of a group",
+		Long: "diff compares a copy of a group vault (e.g. a backup) with the current vault of the group or two copies " +
+			"with each other and lists added, removed and changed accounts. Values are never shown, only the names of changed fields",
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gid := args[0]
+			groupKey, err := readGroupKey(gid)
+			if err != nil {
+				return err
+			}
+			from, err := decryptVaultFile(args[1], groupKey)
+			if err != nil {
+				return err
+			}
+			var to *internal.Group
+			if len(args) == 3 {
+				to, err = decryptVaultFile(args[2], groupKey)
+			} else {
+				to, err = sherlock.LoadGroup(ctx, gid, groupKey)
+			}
+			if err != nil {
+				return err
+			}
+			changes := internal.DiffGroups(from, to)
+			if len(changes) == 0 {
+				terminal.Info("no changes")
+				return nil
+			}
+			rows := make([][]string, len(changes))
+			for i, c := range changes {
+				rows[i] = []string{c.Account, c.Change, strings.Join(c.Fields, ", ")}
+			}
+			terminal.ToTable([]string{"Account", "Change", "Fields"}, rows)
+			return nil
+		},
+	}
+	return diff
+}
+
+// decryptVaultFile reads and decrypts a vault file stored outside of sherlock
+func decryptVaultFile(path, groupKey string) (*internal.Group, error) {
+	vault, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	group, err := internal.DecryptGroup(vault, groupKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return group, nil
+}
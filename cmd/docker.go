@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/compose"
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type dockerSecretsOptions struct {
+	compose string
+	envDir  string
+	replace bool
+	dryRun  bool
+}
+
+func cmdDocker(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	docker := &cobra.Command{
+		Use:   "docker",
+		Short: "provide accounts to docker",
+		Long:  "provide account values to docker containers without writing them to disk",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	docker.AddCommand(cmdDockerSecrets(ctx, sherlock))
+
+	return docker
+}
+
+func cmdDockerSecrets(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts dockerSecretsOptions
+	secrets := &cobra.Command{
+		Use:   "secrets",
+		Short: "create docker secrets and env files from a compose file",
+		Long: "resolve the sherlock://group@account[#field] references of a compose file. External secrets with an " +
+			"x-sherlock field are created with docker secret create, service environment variables are written to " +
+			"<service>.env files in a memory backed (tmpfs) directory",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := ioutil.ReadFile(opts.compose)
+			if err != nil {
+				return err
+			}
+			file, err := compose.Parse(b)
+			if err != nil {
+				return err
+			}
+			secretRefs, err := file.SecretRefs()
+			if err != nil {
+				return err
+			}
+			envRefs := file.EnvRefs()
+			if len(secretRefs) == 0 && len(envRefs) == 0 {
+				terminal.Info("%s has no sherlock references", opts.compose)
+				return nil
+			}
+
+			resolver := newRefResolver(sherlock)
+			secretValues := make([]string, len(secretRefs))
+			for i, ref := range secretRefs {
+				if secretValues[i], err = resolver.resolve(ctx, ref.Value); err != nil {
+					return fmt.Errorf("secret %s: %w", ref.Name, err)
+				}
+			}
+			envFiles := make(map[string][]string)
+			var services []string
+			for _, ref := range envRefs {
+				value, err := resolver.resolve(ctx, ref.Value)
+				if err != nil {
+					return fmt.Errorf("%s %s: %w", ref.Service, ref.Name, err)
+				}
+				if strings.ContainsAny(value, "\r\n") {
+					return fmt.Errorf("%w: %s %s: multi-line values cannot be passed in an env file", internal.ErrInvalidInput, ref.Service, ref.Name)
+				}
+				if _, ok := envFiles[ref.Service]; !ok {
+					services = append(services, ref.Service)
+				}
+				envFiles[ref.Service] = append(envFiles[ref.Service], ref.Name+"="+value)
+			}
+
+			if opts.dryRun {
+				for _, ref := range secretRefs {
+					terminal.Info("secret %s <- %s", ref.Name, ref.Value)
+				}
+				for _, ref := range envRefs {
+					terminal.Info("%s.env %s <- %s", ref.Service, ref.Name, ref.Value)
+				}
+				return nil
+			}
+
+			var dir string
+			if len(services) > 0 {
+				if dir, err = envFileDir(opts.envDir); err != nil {
+					return err
+				}
+			}
+			if len(secretRefs) > 0 {
+				progress := newProgress(cmd, "creating docker secrets", len(secretRefs))
+				for i, ref := range secretRefs {
+					progress.Start(ref.Name)
+					err := createDockerSecret(ctx, ref.Name, secretValues[i], opts.replace)
+					progress.Done(ref.Name, err)
+					if err != nil {
+						progress.Finish()
+						return err
+					}
+				}
+				progress.Finish()
+				terminal.Success("%d docker secrets created", len(secretRefs))
+			}
+			for _, service := range services {
+				path := filepath.Join(dir, service+".env")
+				if err := writeEnvFile(path, envFiles[service]); err != nil {
+					return err
+				}
+				terminal.Success("env file for %s written to %s (add it as env_file of the service)", service, path)
+			}
+			return nil
+		},
+	}
+	secrets.Flags().StringVar(&opts.compose, "compose", "compose.yaml", "compose file to resolve")
+	secrets.Flags().StringVar(&opts.envDir, "env-dir", "", "tmpfs directory for env files (default $XDG_RUNTIME_DIR/sherlock or /dev/shm/sherlock)")
+	secrets.Flags().BoolVar(&opts.replace, "replace", false, "remove existing docker secrets before creating them")
+	secrets.Flags().BoolVar(&opts.dryRun, "dry-run", false, "resolve the references but only print what would be created")
+
+	return secrets
+}
+
+// createDockerSecret creates the docker secret passing the value on stdin
+// so it never shows up in the process list or on disk
+func createDockerSecret(ctx context.Context, name, value string, replace bool) error {
+	if replace {
+		_ = exec.CommandContext(ctx, "docker", "secret", "rm", name).Run()
+	}
+	cmd := exec.CommandContext(ctx, "docker", "secret", "create", name, "-")
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker secret create %s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// envFileDir creates the directory for env files and makes sure it is
+// memory backed so no plaintext value is written to disk
+func envFileDir(dir string) (string, error) {
+	if dir == "" {
+		root := os.Getenv("XDG_RUNTIME_DIR")
+		if root == "" {
+			root = "/dev/shm"
+		}
+		dir = filepath.Join(root, "sherlock")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	if err := fs.RequireTmpfs(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// writeEnvFile writes the KEY=value lines readable only by the user
+func writeEnvFile(path string, lines []string) error {
+	content := strings.Join(lines, "\n") + "\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completion scripts by shell and the file they are written to
+var completionScripts = map[string]struct {
+	file string
+	gen  func(root *cobra.Command, path string) error
+}{
+	"bash":       {file: "sherlock.bash", gen: (*cobra.Command).GenBashCompletionFile},
+	"zsh":        {file: "_sherlock", gen: (*cobra.Command).GenZshCompletionFile},
+	"fish":       {file: "sherlock.fish", gen: func(root *cobra.Command, path string) error { return root.GenFishCompletionFile(path, true) }},
+	"powershell": {file: "sherlock.ps1", gen: (*cobra.Command).GenPowerShellCompletionFileWithDesc},
+}
+
+type genDocsOptions struct {
+	man         bool
+	completions []string
+	dir         string
+}
+
+func cmdGenDocs() *cobra.Command {
+	var opts genDocsOptions
+
+	genDocs := &cobra.Command{
+		Use:   "gen-docs",
+		Short: "generate man pages and shell completion scripts",
+		Long:  "generate a man page for every command (into {dir}/man1) and completion scripts for bash, zsh, fish and powershell (into {dir}/completions) for packaging. The completion scripts complete groups and, for groups with a key set as SHERLOCK_KEY_<GROUP>, accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.man && len(opts.completions) == 0 {
+				return fmt.Errorf("%w: use --man and/or --completions", internal.ErrInvalidInput)
+			}
+			for _, shell := range opts.completions {
+				if _, ok := completionScripts[shell]; !ok {
+					return fmt.Errorf("%w: unknown shell %q (use bash, zsh, fish or powershell)", internal.ErrInvalidInput, shell)
+				}
+			}
+			root := cmd.Root()
+			if opts.man {
+				dir := filepath.Join(opts.dir, "man1")
+				n, err := genManPages(root, dir)
+				if err != nil {
+					return err
+				}
+				terminal.Success("%d man pages written to %s", n, dir)
+			}
+			if len(opts.completions) == 0 {
+				return nil
+			}
+			dir := filepath.Join(opts.dir, "completions")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			for _, shell := range opts.completions {
+				script := completionScripts[shell]
+				if err := script.gen(root, filepath.Join(dir, script.file)); err != nil {
+					return err
+				}
+				terminal.Success("%s completion written to %s", shell, filepath.Join(dir, script.file))
+			}
+			return nil
+		},
+	}
+	genDocs.Flags().BoolVar(&opts.man, "man", false, "generate man pages")
+	genDocs.Flags().StringSliceVar(&opts.completions, "completions", nil, "generate completion scripts for the shells (bash,zsh,fish,powershell)")
+	genDocs.Flags().StringVar(&opts.dir, "dir", "docs", "directory the docs are written to")
+
+	return genDocs
+}
+
+// genManPages writes a man page for the command and every visible sub-command
+// into dir and returns the number of pages written
+func genManPages(cmd *cobra.Command, dir string) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	var n int
+	var walk func(cmd *cobra.Command) error
+	walk = func(cmd *cobra.Command) error {
+		if !cmd.IsAvailableCommand() && cmd.HasParent() {
+			return nil
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, manName(cmd)+".1"), manPage(cmd), 0644); err != nil {
+			return err
+		}
+		n++
+		for _, sub := range cmd.Commands() {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return n, walk(cmd)
+}
+
+// manName is the name of a command's man page like sherlock-add-account
+func manName(cmd *cobra.Command) string {
+	return strings.Replace(cmd.CommandPath(), " ", "-", -1)
+}
+
+// manPage renders the man page of a command in roff
+func manPage(cmd *cobra.Command) []byte {
+	var b bytes.Buffer
+	name := manName(cmd)
+	fmt.Fprintf(&b, ".TH %q \"1\" %q \"sherlock %s\" \"sherlock manual\"\n", strings.ToUpper(name), manDate().Format("Jan 2006"), Version)
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", roffEscape(name), roffEscape(cmd.Short))
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n\\fB%s\\fP\n", roffEscape(cmd.UseLine()))
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	fmt.Fprintf(&b, ".SH DESCRIPTION\n%s\n", roffEscape(description))
+	writeManFlags(&b, "OPTIONS", cmd.NonInheritedFlags())
+	writeManFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags())
+
+	var related []string
+	if cmd.HasParent() {
+		related = append(related, manName(cmd.Parent()))
+	}
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			related = append(related, manName(sub))
+		}
+	}
+	if len(related) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, r := range related {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "\\fB%s\\fP(1)", roffEscape(r))
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// writeManFlags writes the visible flags as a section of tagged paragraphs
+func writeManFlags(b *bytes.Buffer, section string, flags *pflag.FlagSet) {
+	if !flags.HasAvailableFlags() {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", section)
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		b.WriteString(".TP\n")
+		if f.Shorthand != "" {
+			fmt.Fprintf(b, "\\fB\\-%s\\fP, ", f.Shorthand)
+		}
+		fmt.Fprintf(b, "\\fB\\-\\-%s\\fP", roffEscape(f.Name))
+		if f.Value.Type() != "bool" {
+			fmt.Fprintf(b, " \\fI%s\\fP", f.Value.Type())
+		}
+		b.WriteString("\n" + roffEscape(f.Usage))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "[]" {
+			fmt.Fprintf(b, " (default %s)", roffEscape(f.DefValue))
+		}
+		b.WriteString("\n")
+	})
+}
+
+// roffEscape escapes backslashes, hyphens and control characters at the start of a line
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// manDate is the date of the man pages. SOURCE_DATE_EPOCH overrides it for
+// reproducible builds
+func manDate() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/qr"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type emergencyKitOptions struct {
+	out      string
+	insecure bool
+}
+
+func cmdEmergencyKit(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts emergencyKitOptions
+	kit := &cobra.Command{
+		Use:   "emergency-kit",
+		Short: "create a printable emergency kit",
+		Long:  "create a printable document listing the vault locations and groups with room for group key hints. Accounts passed as group@account are added as QR code and text, encrypted with a separate recovery passphrase",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := sherlock.ReadRegisteredGroups(ctx)
+			if err != nil {
+				return err
+			}
+			kit := internal.Kit{Created: time.Now()}
+			for _, gid := range gids {
+				kit.Vaults = append(kit.Vaults, internal.KitVault{Group: gid, Path: fs.VaultPath(gid)})
+			}
+			if len(args) > 0 {
+				var secrets []internal.KitSecret
+				for _, query := range args {
+					groupKey, err := readGroupKey(query)
+					if err != nil {
+						return err
+					}
+					account, err := getAccount(ctx, sherlock, query, groupKey)
+					if err != nil {
+						return fmt.Errorf("%s: %w", query, err)
+					}
+					secrets = append(secrets, internal.NewKitSecret(query, account))
+				}
+				passphrase, err := readRecoveryPassphrase(opts.insecure)
+				if err != nil {
+					return err
+				}
+				if kit.Payload, err = internal.SealKit(secrets, passphrase); err != nil {
+					return err
+				}
+				kit.Secrets = len(secrets)
+			}
+
+			var w io.Writer = os.Stdout
+			if opts.out != "" {
+				f, err := os.OpenFile(opts.out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			if err := kit.WriteText(w); err != nil {
+				return err
+			}
+			if opts.out != "" {
+				terminal.Success("emergency kit written to %s (print it and delete the file)", opts.out)
+			}
+			return nil
+		},
+	}
+	kit.Flags().StringVar(&opts.out, "out", "", "write the kit to a file instead of stdout")
+	kit.Flags().BoolVar(&opts.insecure, "insecure", false, "allow a weak recovery passphrase")
+
+	kit.AddCommand(cmdEmergencyKitOpen())
+
+	return kit
+}
+
+// readRecoveryPassphrase prompts for the recovery passphrase of an emergency
+// kit twice to rule out typos
+func readRecoveryPassphrase(insecure bool) (string, error) {
+	passphrase, err := terminal.ReadPassword("recovery passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if !insecure {
+		if err := security.PasswordStrength(passphrase); err != nil {
+			return "", err
+		}
+	}
+	repeated, err := terminal.ReadPassword("repeat recovery passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != repeated {
+		return "", fmt.Errorf("%w: recovery passphrases do not match", internal.ErrInvalidInput)
+	}
+	return passphrase, nil
+}
+
+type emergencyKitOpenOptions struct {
+	qrImage string
+}
+
+func cmdEmergencyKitOpen() *cobra.Command {
+	var opts emergencyKitOpenOptions
+	open := &cobra.Command{
+		Use:   "open",
+		Short: "decrypt the secrets of an emergency kit",
+		Long:  "decrypt the secrets of an emergency kit. Type in the printed text (finish with an empty line) or pass a photo of the QR code with --qr",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var payload string
+			if opts.qrImage != "" {
+				var err error
+				if payload, err = qr.Decode(opts.qrImage); err != nil {
+					return err
+				}
+			} else {
+				lines, err := terminal.ReadLines("enter the emergency kit text (finish with an empty line):")
+				if err != nil {
+					return err
+				}
+				payload = strings.Join(lines, "")
+			}
+			passphrase, err := terminal.ReadPassword("recovery passphrase: ")
+			if err != nil {
+				return err
+			}
+			secrets, err := internal.OpenKit(payload, passphrase)
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(secrets))
+			for _, s := range secrets {
+				rows = append(rows, []string{s.Account, s.Username, s.Password, s.URL})
+			}
+			terminal.ToTable([]string{"Account", "Username", "Password", "URL"}, rows)
+			return nil
+		},
+	}
+	open.Flags().StringVar(&opts.qrImage, "qr", "", "image file of the QR code (png, jpeg or gif)")
+
+	return open
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/storage"
+	"github.com/spf13/cobra"
+)
+
+// useEphemeral swaps the storage for an in-memory vault which is gone when sherlock
+// exits. Except for "sherlock setup" the default group is set up right away with the
+// key from SHERLOCK_KEY_DEFAULT or a prompted key so any command can run on it
+func useEphemeral(ctx context.Context, sherlock *internal.Sherlock, cmd *cobra.Command) error {
+	fileSystem, err := storage.New(storage.Memory, nil)
+	if err != nil {
+		return err
+	}
+	sherlock.UseFileSystem(fileSystem)
+	if cmd.CommandPath() == "sherlock setup" {
+		return nil
+	}
+	groupKey, err := readGroupKey("default")
+	if err != nil {
+		return err
+	}
+	// the vault only lives as long as the process, so the command is not
+	// prompted for the key a second time
+	envGroupKeys[internal.GroupKeyEnv("default")] = groupKey
+	return sherlock.Setup(ctx, groupKey)
+}
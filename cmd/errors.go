@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/KonstantinGasser/sherlock/autotype"
+	"github.com/KonstantinGasser/sherlock/backup"
+	"github.com/KonstantinGasser/sherlock/certs"
+	"github.com/KonstantinGasser/sherlock/cloud"
+	"github.com/KonstantinGasser/sherlock/compose"
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/k8s"
+	"github.com/KonstantinGasser/sherlock/kdbx"
+	"github.com/KonstantinGasser/sherlock/peer"
+	"github.com/KonstantinGasser/sherlock/picker"
+	"github.com/KonstantinGasser/sherlock/plugin"
+	"github.com/KonstantinGasser/sherlock/qr"
+	"github.com/KonstantinGasser/sherlock/registry"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/selfupdate"
+	"github.com/KonstantinGasser/sherlock/storage"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/KonstantinGasser/sherlock/webhook"
+)
+
+// exit statuses returned by the CLI. The values are part of the public
+// interface of sherlock and must not change
+const (
+	ExitOK            = 0
+	ExitFailure       = 1
+	ExitWrongKey      = 2
+	ExitNoSuchGroup   = 3
+	ExitNoSuchAccount = 4
+	ExitNotSetup      = 5
+	ExitInvalidInput  = 6
+	ExitExists        = 7
+	ExitTampered      = 8
+	ExitReadOnly      = 9
+	// ExitInterrupted follows the shell convention of 128 + SIGINT
+	ExitInterrupted = 130
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// exitCodes maps known errors to their exit status and a stable error code name
+var exitCodes = []struct {
+	err  error
+	exit int
+	code string
+}{
+	{err: internal.ErrWrongKey, exit: ExitWrongKey, code: "wrong_key"},
+	{err: internal.ErrNoSuchGroup, exit: ExitNoSuchGroup, code: "no_such_group"},
+	{err: fs.ErrNoSuchGroup, exit: ExitNoSuchGroup, code: "no_such_group"},
+	{err: internal.ErrNoSuchAccount, exit: ExitNoSuchAccount, code: "no_such_account"},
+	{err: internal.ErrNotSetup, exit: ExitNotSetup, code: "not_setup"},
+	{err: internal.ErrInvalidInput, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidQuery, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrMissingValues, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidAccountName, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidGroupName, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidAlias, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoSuchAlias, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrAmbiguousName, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNamePattern, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoSuchField, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrHOTPField, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidOTPType, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidOTPSecret, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidOTPAlgorithm, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidOTPDigits, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidOTPPeriod, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidOTPURI, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrUnknownSortKey, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrUnknownFilterField, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: autotype.ErrInvalidSequence, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: qr.ErrInvalidWiFiSecurity, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: qr.ErrInvalidFrame, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: kdbx.ErrEmptyPassphrase, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: qr.ErrIncompleteFrames, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: picker.ErrUnknownMenu, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: backup.ErrNoTarget, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: backup.ErrInvalidRemote, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidReference, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: compose.ErrInvalidFile, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: plugin.ErrNotFound, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: cloud.ErrUnknownService, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: cloud.ErrMissingOption, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: k8s.ErrInvalidManifest, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: k8s.ErrInvalidExecInfo, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: k8s.ErrInvalidCredential, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: registry.ErrUnknownRegistry, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: registry.ErrInvalidToken, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: registry.ErrInvalidURL, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: fs.ErrNoTmpfs, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrUnknownHook, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrUnknownConflict, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoSuchHook, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoWebhook, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: webhook.ErrInsecureURL, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoRecoveryCodes, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNothingToUndo, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoSuchPreset, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidPreset, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrWeakPreset, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidPINLength, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrRequiredField, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidAccountLink, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNotArchived, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidHint, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrHintsDisabled, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrTimeLocked, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidTimeLock, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrClockBehind, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNotTimeLocked, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoIndex, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrNoKeyring, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidKit, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidSnapshot, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: security.ErrInvalidRecipient, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: security.ErrInvalidIdentity, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: security.ErrOpenSealed, exit: ExitWrongKey, code: "wrong_key"},
+	{err: peer.ErrPairing, exit: ExitWrongKey, code: "wrong_key"},
+	{err: errPeerCertificate, exit: ExitWrongKey, code: "wrong_key"},
+	{err: security.ErrUnsupportedKDF, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: storage.ErrInvalidConfig, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: storage.ErrUnknownBackend, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: storage.ErrNoSuchWorkspace, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: ErrNotCached, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: selfupdate.ErrInvalidKey, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: selfupdate.ErrNoReleaseKey, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: certs.ErrNoCA, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: certs.ErrNoIdentity, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: certs.ErrInvalidCert, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrAccountExists, exit: ExitExists, code: "exists"},
+	{err: fs.ErrGroupExists, exit: ExitExists, code: "exists"},
+	{err: storage.ErrWorkspaceExists, exit: ExitExists, code: "exists"},
+	{err: internal.ErrKeyringExists, exit: ExitExists, code: "exists"},
+	{err: internal.ErrNameCollision, exit: ExitExists, code: "exists"},
+	{err: internal.ErrKeyEnvName, exit: ExitExists, code: "exists"},
+	{err: internal.ErrAlreadySetup, exit: ExitExists, code: "exists"},
+	{err: internal.ErrGroupExists, exit: ExitExists, code: "exists"},
+	{err: internal.ErrSigningEnabled, exit: ExitExists, code: "exists"},
+	{err: certs.ErrCAExists, exit: ExitExists, code: "exists"},
+	{err: internal.ErrSigningDisabled, exit: ExitInvalidInput, code: "invalid_input"},
+	{err: internal.ErrInvalidReport, exit: ExitTampered, code: "tampered"},
+	{err: internal.ErrIndexTampered, exit: ExitTampered, code: "tampered"},
+	{err: ErrStorageConfigured, exit: ExitExists, code: "exists"},
+	{err: errTampered, exit: ExitTampered, code: "tampered"},
+	{err: selfupdate.ErrBadSignature, exit: ExitTampered, code: "tampered"},
+	{err: selfupdate.ErrInvalidSignature, exit: ExitTampered, code: "tampered"},
+	{err: internal.ErrReadOnlyGroup, exit: ExitReadOnly, code: "read_only"},
+	{err: context.Canceled, exit: ExitInterrupted, code: "interrupted"},
+}
+
+var errTampered = fmt.Errorf("vault signature verification failed")
+
+var errWipeIncomplete = fmt.Errorf("wipe incomplete")
+
+// commandExit passes the exit status of a command run by sherlock through
+type commandExit struct {
+	status int
+}
+
+func (e commandExit) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.status)
+}
+
+// errorEnvelope is the json representation of an error written
+// with --output json
+type errorEnvelope struct {
+	Error struct {
+		Exit    int    `json:"exit"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// exitCode resolves the exit status and error code name of an error
+func exitCode(err error) (int, string) {
+	var exit commandExit
+	if errors.As(err, &exit) {
+		return exit.status, "command_failed"
+	}
+	for _, c := range exitCodes {
+		if errors.Is(err, c.err) {
+			return c.exit, c.code
+		}
+	}
+	return ExitFailure, "failure"
+}
+
+// reportError writes the error to the cli in the requested output format
+// and returns the exit status for it
+func reportError(err error, output string) int {
+	exit, code := exitCode(err)
+	if output != outputJSON {
+		terminal.Error("%s", err)
+		return exit
+	}
+	var envelope errorEnvelope
+	envelope.Error.Exit = exit
+	envelope.Error.Code = code
+	envelope.Error.Message = err.Error()
+	if err := json.NewEncoder(os.Stdout).Encode(envelope); err != nil {
+		terminal.Error("%s", err)
+	}
+	return exit
+}
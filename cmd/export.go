@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/kdbx"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportJSON = "json"
+	exportCSV  = "csv"
+	// exportKDBX is a full, encrypted export KeePass compatible (mobile) apps open
+	exportKDBX = "kdbx"
+)
+
+type exportOptions struct {
+	redact   bool
+	format   string
+	all      bool
+	out      string
+	insecure bool
+}
+
+func cmdExport(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts exportOptions
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "export an inventory of accounts",
+		Long: "export writes the group, name, tag, url and timestamps of every account of the given groups (default group " +
+			"if none) as json or csv. Passwords, usernames, notes and otp secrets are stripped (--redact). --format kdbx instead " +
+			"writes all accounts including their secrets to a KeePass database (KDBX 3.1) encrypted with a new passphrase which " +
+			"KeePass compatible apps such as KeePassDX, KeePassium or Strongbox open on phones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch opts.format {
+			case exportKDBX:
+				if opts.redact {
+					return fmt.Errorf("%w: a kdbx export holds the secrets, drop --redact", internal.ErrInvalidInput)
+				}
+				if opts.out == "" {
+					return fmt.Errorf("%w: a kdbx export needs --out", internal.ErrInvalidInput)
+				}
+			case exportJSON, exportCSV:
+				if !opts.redact {
+					return fmt.Errorf("%w: only metadata exports are supported as %s, use --redact", internal.ErrInvalidInput, opts.format)
+				}
+			default:
+				return fmt.Errorf("%w: unknown export format %q (use %s, %s or %s)", internal.ErrInvalidInput, opts.format, exportJSON, exportCSV, exportKDBX)
+			}
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			if opts.format == exportKDBX {
+				return exportDatabase(ctx, sherlock, gids, opts)
+			}
+			var items []internal.InventoryItem
+			for _, gid := range gids {
+				groupKey, err := readGroupKey(gid)
+				if err != nil {
+					return err
+				}
+				group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+				if err != nil {
+					return fmt.Errorf("%s: %w", gid, err)
+				}
+				items = append(items, group.Inventory()...)
+			}
+
+			var w io.Writer = os.Stdout
+			if opts.out != "" {
+				f, err := os.OpenFile(opts.out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			if opts.format == exportCSV {
+				err = internal.WriteInventoryCSV(w, items)
+			} else {
+				err = internal.WriteInventoryJSON(w, items)
+			}
+			if err != nil {
+				return err
+			}
+			if opts.out != "" {
+				terminal.Success("%d accounts exported to %s", len(items), opts.out)
+			}
+			return nil
+		},
+	}
+	export.Flags().BoolVar(&opts.redact, "redact", false, "strip all secrets and export metadata only")
+	export.Flags().StringVarP(&opts.format, "format", "f", exportJSON, "export format (json|csv|kdbx)")
+	export.Flags().BoolVarP(&opts.all, "all", "a", false, "export all registered groups")
+	export.Flags().StringVar(&opts.out, "out", "", "write the export to a file instead of stdout")
+	export.Flags().BoolVar(&opts.insecure, "insecure", false, "allow a weak passphrase for the kdbx database")
+
+	return export
+}
+
+// exportDatabase writes all accounts of the groups to a KeePass database with
+// one KeePass group per group
+func exportDatabase(ctx context.Context, sherlock *internal.Sherlock, gids []string, opts exportOptions) error {
+	db := kdbx.Database{Name: "sherlock"}
+	var count int
+	for _, gid := range gids {
+		groupKey, err := readGroupKey(gid)
+		if err != nil {
+			return err
+		}
+		group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+		if err != nil {
+			return fmt.Errorf("%s: %w", gid, err)
+		}
+		entries := make([]kdbx.Entry, len(group.Accounts))
+		for i, a := range group.Accounts {
+			// the database holds no links or time locks, so the password is exported
+			// as it is used; an account which is still time-locked cannot be exported
+			password, err := sherlock.AccountPassword(ctx, *a, readGroupKey)
+			if err != nil {
+				return fmt.Errorf("%s@%s: %w", gid, a.Name, err)
+			}
+			entries[i] = kdbx.Entry{
+				Title:    a.Name,
+				UserName: a.Username,
+				Password: password,
+				URL:      a.URL,
+				Notes:    a.Note,
+				Tags:     a.Tag,
+				Created:  a.CreatedOn,
+				Modified: a.UpdatedOn,
+			}
+			if a.OTP != nil {
+				entries[i].OTP = a.OTP.URI(gid, a.Name)
+			}
+		}
+		db.Groups = append(db.Groups, kdbx.Group{Name: gid, Entries: entries})
+		count += len(entries)
+	}
+
+	passphrase, err := terminal.ReadPassword("database passphrase: ")
+	if err != nil {
+		return err
+	}
+	if !opts.insecure {
+		if err := security.PasswordStrength(passphrase); err != nil {
+			return err
+		}
+	}
+	repeated, err := terminal.ReadPassword("repeat database passphrase: ")
+	if err != nil {
+		return err
+	}
+	if passphrase != repeated {
+		return fmt.Errorf("%w: database passphrases do not match", internal.ErrInvalidInput)
+	}
+
+	f, err := os.OpenFile(opts.out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := kdbx.Write(f, db, passphrase); err != nil {
+		return err
+	}
+	terminal.Success("%d accounts exported to %s, open it with any KeePass compatible app", count, opts.out)
+	return nil
+}
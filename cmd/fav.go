@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdFav(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	fav := &cobra.Command{
+		Use:   "fav",
+		Short: "pin favorite accounts",
+		Long:  "favorite accounts are marked with a star and listed first by list --fav, pick and menu",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	fav.AddCommand(cmdFavSet(ctx, sherlock, "add", "pin an account as favorite", true))
+	fav.AddCommand(cmdFavSet(ctx, sherlock, "rm", "unpin a favorite account", false))
+
+	return fav
+}
+
+func cmdFavSet(ctx context.Context, sherlock *internal.Sherlock, use, short string, favorite bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  short + " (group@account)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccFavorite(favorite)); err != nil {
+				return err
+			}
+			if favorite {
+				terminal.Success("account %q pinned as favorite", args[0])
+				return nil
+			}
+			terminal.Success("account %q unpinned", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// defaultPINDigits is the number of digits of generated PINs if not set
+const defaultPINDigits = 6
+
+func cmdGen(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	gen := &cobra.Command{
+		Use:   "gen",
+		Short: "generate secrets",
+		Long:  "generate secrets which do not fit the password generator of add account",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	gen.AddCommand(cmdGenPIN(ctx, sherlock))
+
+	return gen
+}
+
+type genPINOptions struct {
+	digits int
+	tag    string
+	update bool
+	copy   bool
+}
+
+func cmdGenPIN(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts genPINOptions
+	pin := &cobra.Command{
+		Use:   "pin [<group@account>]",
+		Short: "generate a numeric PIN",
+		Long: "generate a uniformly random numeric PIN. Trivial PINs like 123456, 0000 or 121212 are never generated. " +
+			"With an account the PIN is stored as its password (a new account or, with --update, an existing one) => sherlock gen pin --digits 6 bank@card",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pin, err := internal.GeneratePIN(opts.digits)
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				if err := storePIN(ctx, sherlock, args[0], pin, opts); err != nil {
+					return err
+				}
+			}
+			if opts.copy {
+				if err := clipboard.WriteAll(pin); err != nil {
+					return err
+				}
+				terminal.Info("generated PIN copied to clipboard")
+				return nil
+			}
+			terminal.Info("generated PIN : %s", pin)
+			return nil
+		},
+	}
+	pin.Flags().IntVarP(&opts.digits, "digits", "d", defaultPINDigits, "number of digits of the PIN")
+	pin.Flags().StringVarP(&opts.tag, "tag", "t", "", "optional tag for a new account")
+	pin.Flags().BoolVar(&opts.update, "update", false, "replace the password of an existing account with the PIN")
+	pin.Flags().BoolVarP(&opts.copy, "copy", "c", false, "copy the PIN to the clipboard instead of printing it")
+
+	return pin
+}
+
+// storePIN stores the PIN as password of a new or, with --update, an existing
+// account. PINs never pass the password policy so it is not applied
+func storePIN(ctx context.Context, sherlock *internal.Sherlock, query, pin string, opts genPINOptions) error {
+	groupKey, err := readGroupKey(query)
+	if err != nil {
+		return err
+	}
+	if opts.update {
+		if err := sherlock.UpdateState(ctx, query, groupKey, internal.OptAccPassword(pin, true)); err != nil {
+			return err
+		}
+		terminal.Success("PIN of %q updated", query)
+		return nil
+	}
+	account, err := internal.NewAccount(query, pin, opts.tag, true)
+	if err != nil {
+		return err
+	}
+	if err := sherlock.UpdateState(ctx, query, groupKey, internal.OptAddAccount(account)); err != nil {
+		return err
+	}
+	terminal.Success("account %q with the PIN added", query)
+	return nil
+}
@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/notify"
 	"github.com/KonstantinGasser/sherlock/terminal"
 	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
@@ -11,6 +15,8 @@ import (
 
 type getOptions struct {
 	verbose bool
+	field   string
+	clear   time.Duration
 }
 
 func cmdGet(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
@@ -18,26 +24,70 @@ func cmdGet(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
 	get := &cobra.Command{
 		Use:   "get",
 		Short: "get retrieves a stored password from a group",
-		Long:  "with the get command you can query an accounts password from a specific group",
+		Long:  "with the get command you can query an accounts password (or any other field using --field) from a specific group",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
-			account, err := sherlock.GetAccount(args[0], groupKey)
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
+			}
+			value, err := sherlock.AccountField(ctx, args[0], groupKey, *account, opts.field)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, opts.field)
 			}
 			if opts.verbose {
-				terminal.Info(account.Password)
+				terminal.Info(value)
+			}
+			if err := clipboard.WriteAll(value); err != nil {
+				return err
+			}
+			if opts.clear > 0 {
+				terminal.Info("clipboard is cleared in %v", opts.clear)
+				return clearClipboard(ctx, value, opts.clear)
 			}
-			clipboard.WriteAll(account.Password)
+			return nil
 		},
 	}
 	get.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "print plain password to cli")
+	get.Flags().StringVarP(&opts.field, "field", "f", internal.FieldPassword, "account field to copy (password|username|url|totp|note)")
+	get.Flags().DurationVar(&opts.clear, "clear", 0, "clear the clipboard after the duration (e.g. 30s) and show a desktop notification")
 
 	return get
 }
+
+// clearClipboard waits for the duration and clears the clipboard if it still
+// holds the value. Clearing is announced with a desktop notification so it
+// does not go unnoticed
+func clearClipboard(ctx context.Context, value string, after time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(after):
+	}
+	current, err := clipboard.ReadAll()
+	if err != nil {
+		return err
+	}
+	if current != value {
+		terminal.Info("clipboard changed in the meantime and is left untouched")
+		return nil
+	}
+	if err := clipboard.WriteAll(""); err != nil {
+		return err
+	}
+	terminal.Success("clipboard cleared")
+	sendNotification(ctx, "clipboard cleared", fmt.Sprintf("the copied value has been removed after %v", after))
+	return nil
+}
+
+// sendNotification shows a desktop notification. Failures are reported but
+// never fail the command; platforms without notifications are skipped
+func sendNotification(ctx context.Context, title, message string) {
+	if err := notify.Send(ctx, title, message); err != nil && !errors.Is(err, notify.ErrUnsupported) {
+		terminal.Warning("notification: %v", err)
+	}
+}
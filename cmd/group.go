@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// aliasArgs are the commands (by command path) besides queryArgs and groupArgs
+// whose first argument is a query or group
+var aliasArgs = map[string]bool{
+	"sherlock add account": true,
+}
+
+func cmdGroup(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	group := &cobra.Command{
+		Use:   "group",
+		Short: "manage group settings",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	group.AddCommand(cmdGroupAlias(ctx, sherlock))
+	group.AddCommand(cmdGroupNames(ctx, sherlock))
+	group.AddCommand(cmdGroupRequire(ctx, sherlock))
+	group.AddCommand(cmdGroupArchive(ctx, sherlock))
+	group.AddCommand(cmdGroupUnarchive(ctx, sherlock))
+	group.AddCommand(cmdGroupHint(ctx, sherlock))
+	group.AddCommand(cmdGroupIndex(ctx, sherlock))
+
+	return group
+}
+
+type groupAliasOptions struct {
+	remove bool
+}
+
+func cmdGroupAlias(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts groupAliasOptions
+	alias := &cobra.Command{
+		Use:   "alias [<alias> <group>]",
+		Short: "define short names for groups",
+		Long: "an alias is a short name of a group (e.g. w for work-infrastructure) usable wherever a group or group@account " +
+			"query is expected. Without arguments the aliases are listed; --rm <alias> removes an alias",
+		Args: cobra.RangeArgs(0, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case opts.remove && len(args) == 1:
+				if err := sherlock.RemoveAlias(ctx, args[0]); err != nil {
+					return err
+				}
+				terminal.Success("alias %s removed", args[0])
+				return nil
+			case len(args) == 2 && !opts.remove:
+				if err := sherlock.SetAlias(ctx, args[0], args[1]); err != nil {
+					return err
+				}
+				terminal.Success("%s is now an alias of %s", args[0], args[1])
+				return nil
+			case len(args) == 0 && !opts.remove:
+				config, err := sherlock.Config(ctx)
+				if err != nil {
+					return err
+				}
+				aliases := make([]string, 0, len(config.Aliases))
+				for alias := range config.Aliases {
+					aliases = append(aliases, alias)
+				}
+				sort.Strings(aliases)
+				rows := make([][]string, len(aliases))
+				for i, alias := range aliases {
+					rows[i] = []string{alias, config.Aliases[alias]}
+				}
+				terminal.ToTable([]string{"Alias", "Group"}, rows)
+				return nil
+			}
+			return cmd.Usage()
+		},
+	}
+	alias.Flags().BoolVar(&opts.remove, "rm", false, "remove the alias")
+
+	return alias
+}
+
+type groupNamesOptions struct {
+	fold    bool
+	exact   bool
+	pattern string
+}
+
+func cmdGroupNames(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts groupNamesOptions
+	names := &cobra.Command{
+		Use:   "names",
+		Short: "configure how group and account names are matched and validated",
+		Long: "with --fold group and account names are matched case-insensitive and Unicode normalized (NFC), so github finds GitHub " +
+			"and names differing only that way cannot be created anymore. An exact match always wins. --exact restores exact matching. " +
+			"Names never contain whitespace, control characters, @ or path separators; --pattern sets a regular expression new names " +
+			"have to match as well (an empty pattern removes it). Without a flag the current settings are shown",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.fold && opts.exact {
+				return fmt.Errorf("%w: use either --fold or --exact", internal.ErrInvalidInput)
+			}
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			setPattern := cmd.Flags().Changed("pattern")
+			if !opts.fold && !opts.exact && !setPattern {
+				mode := "exact"
+				if config.FoldNames {
+					mode = "fold (case-insensitive, NFC normalized)"
+				}
+				terminal.Info("names are matched: %s", mode)
+				if config.NamePattern != "" {
+					terminal.Info("new names have to match: %s", config.NamePattern)
+				}
+				return nil
+			}
+			if setPattern {
+				if err := sherlock.NamePattern(opts.pattern); err != nil {
+					return err
+				}
+				config.NamePattern = opts.pattern
+				if opts.pattern == "" {
+					terminal.Success("name pattern removed")
+				} else {
+					terminal.Success("new names have to match %s", opts.pattern)
+				}
+			}
+			if !opts.fold && !opts.exact {
+				return sherlock.SaveConfig(ctx, config)
+			}
+			if opts.fold {
+				collisions, err := sherlock.GroupNameCollisions(ctx)
+				if err != nil {
+					return err
+				}
+				for _, names := range collisions {
+					terminal.Warning("groups %s only differ in case; use the exact names or rename them", strings.Join(names, ", "))
+				}
+			}
+			config.FoldNames = opts.fold
+			if err := sherlock.SaveConfig(ctx, config); err != nil {
+				return err
+			}
+			if opts.fold {
+				terminal.Success("names are matched case-insensitive and normalized")
+			} else {
+				terminal.Success("names are matched exactly")
+			}
+			return nil
+		},
+	}
+	names.Flags().BoolVar(&opts.fold, "fold", false, "match names case-insensitive and NFC normalized")
+	names.Flags().BoolVar(&opts.exact, "exact", false, "match names exactly")
+	names.Flags().StringVar(&opts.pattern, "pattern", "", "regular expression new group and account names have to match")
+
+	return names
+}
+
+type groupRequireOptions struct {
+	none bool
+}
+
+func cmdGroupRequire(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts groupRequireOptions
+	require := &cobra.Command{
+		Use:   "require <group> [<field>...]",
+		Short: "require account fields in a group",
+		Long: "set the fields (username, url, note, tag, otp) every account of the group must have. Adding or updating an account " +
+			"missing one fails; existing accounts are listed to be completed. The requirement is stored in the group and shared with it. " +
+			"Without fields the required fields are shown; --none removes the requirement => sherlock group require work username url",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 && !opts.none {
+				group, err := sherlock.LoadGroup(ctx, args[0], groupKey)
+				if err != nil {
+					return err
+				}
+				if len(group.Required) == 0 {
+					terminal.Info("group %s requires no fields", args[0])
+					return nil
+				}
+				terminal.Info("group %s requires: %s", args[0], strings.Join(group.Required, ", "))
+				return nil
+			}
+			if opts.none && len(args) > 1 {
+				return fmt.Errorf("%w: use either fields or --none", internal.ErrInvalidInput)
+			}
+			incomplete, err := sherlock.SetRequired(ctx, args[0], groupKey, args[1:])
+			if err != nil {
+				return err
+			}
+			if opts.none {
+				terminal.Success("group %s requires no fields", args[0])
+				return nil
+			}
+			terminal.Success("group %s requires: %s", args[0], strings.Join(args[1:], ", "))
+			if len(incomplete) > 0 {
+				terminal.Warning("accounts missing a required field: %s", strings.Join(incomplete, ", "))
+			}
+			return nil
+		},
+	}
+	require.Flags().BoolVar(&opts.none, "none", false, "remove the required fields")
+
+	return require
+}
+
+func cmdGroupArchive(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "archive <group>",
+		Short: "move a group out of daily use",
+		Long: "archive a retired group: it is left out of listings, pickers and completions but stays encrypted and can still be " +
+			"used by its name, backed up and synced. List archived groups with sherlock list groups --archived and bring one back with sherlock group unarchive",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.ArchiveGroup(ctx, args[0]); err != nil {
+				return err
+			}
+			terminal.Success("group %s archived", args[0])
+			return nil
+		},
+	}
+}
+
+func cmdGroupUnarchive(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unarchive <group>",
+		Short: "bring an archived group back into daily use",
+		Long:  "move the group out of the archive so it is listed, picked and completed again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.UnarchiveGroup(ctx, args[0]); err != nil {
+				return err
+			}
+			terminal.Success("group %s unarchived", args[0])
+			return nil
+		},
+	}
+}
+
+// resolveGroupNames applies the name settings of the config: it replaces group
+// aliases (and with folded names the case of a group) in the query or group
+// argument and the --group flag of the command before any group key is looked up
+func resolveGroupNames(ctx context.Context, sherlock *internal.Sherlock, cmd *cobra.Command, args []string) error {
+	config, err := sherlock.Config(ctx)
+	if err != nil {
+		return err
+	}
+	sherlock.FoldNames(config.FoldNames)
+	if err := sherlock.NamePattern(config.NamePattern); err != nil {
+		return err
+	}
+	if len(config.Aliases) == 0 && !config.FoldNames {
+		return nil
+	}
+	path := cmd.CommandPath()
+	if len(args) > 0 && (queryArgs[path] || groupArgs[path] || aliasArgs[path]) {
+		if args[0], err = sherlock.ResolveQuery(ctx, config, args[0]); err != nil {
+			return err
+		}
+	}
+	flag := cmd.LocalFlags().Lookup("group")
+	if flag == nil || !flag.Changed {
+		return nil
+	}
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		gids := slice.GetSlice()
+		for i := range gids {
+			if gids[i], err = sherlock.ResolveGroup(ctx, config, gids[i]); err != nil {
+				return err
+			}
+		}
+		return slice.Replace(gids)
+	}
+	gid, err := sherlock.ResolveGroup(ctx, config, flag.Value.String())
+	if err != nil {
+		return err
+	}
+	return flag.Value.Set(gid)
+}
+
+type groupHintOptions struct {
+	rm  bool
+	on  bool
+	off bool
+}
+
+func cmdGroupHint(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts groupHintOptions
+	hint := &cobra.Command{
+		Use:   "hint [<group> [<hint>]]",
+		Short: "store a reminder of a group key",
+		Long: "store a hint reminding you which passphrase the group uses. The hint is shown when prompting for the group key " +
+			"after two failed unlocks in a row. It is stored in plain text in the config and must never contain the key itself. " +
+			"Without a hint the command reports whether the group has one; --rm removes it. --off turns hints off and removes " +
+			"all stored hints, --on turns them on again => sherlock group hint work \"the one from the onboarding mail\"",
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.on || opts.off {
+				if opts.on && opts.off {
+					return fmt.Errorf("%w: use either --on or --off", internal.ErrInvalidInput)
+				}
+				if len(args) > 0 || opts.rm {
+					return fmt.Errorf("%w: --on and --off apply to all groups", internal.ErrInvalidInput)
+				}
+				if err := sherlock.EnableHints(ctx, opts.on); err != nil {
+					return err
+				}
+				if opts.on {
+					terminal.Success("passphrase hints turned on")
+				} else {
+					terminal.Success("passphrase hints turned off and removed")
+				}
+				return nil
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("%w: hint requires a group", internal.ErrInvalidInput)
+			}
+			switch {
+			case opts.rm:
+				if len(args) > 1 {
+					return fmt.Errorf("%w: use either a hint or --rm", internal.ErrInvalidInput)
+				}
+				if err := sherlock.RemoveHint(ctx, args[0]); err != nil {
+					return err
+				}
+				terminal.Success("hint of group %s removed", args[0])
+			case len(args) == 1:
+				config, err := sherlock.Config(ctx)
+				if err != nil {
+					return err
+				}
+				switch _, ok := config.Hints[args[0]]; {
+				case config.NoHints:
+					terminal.Info("passphrase hints are turned off")
+				case ok:
+					terminal.Info("group %s has a hint, it is shown after two failed unlocks", args[0])
+				default:
+					terminal.Info("group %s has no hint", args[0])
+				}
+			default:
+				groupKey, err := readGroupKey(args[0])
+				if err != nil {
+					return err
+				}
+				if err := sherlock.SetHint(ctx, args[0], groupKey, args[1]); err != nil {
+					return err
+				}
+				terminal.Success("hint of group %s stored", args[0])
+			}
+			return nil
+		},
+	}
+	hint.Flags().BoolVar(&opts.rm, "rm", false, "remove the hint of the group")
+	hint.Flags().BoolVar(&opts.on, "on", false, "turn passphrase hints on")
+	hint.Flags().BoolVar(&opts.off, "off", false, "turn passphrase hints off and remove all stored hints")
+
+	return hint
+}
+
+type groupIndexOptions struct {
+	off bool
+}
+
+func cmdGroupIndex(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts groupIndexOptions
+	index := &cobra.Command{
+		Use:   "index <group>",
+		Short: "keep account names, tags and timestamps in plain text to list the group without unlocking it",
+		Long: "store the names, tags, favorite marks and timestamps of the accounts in plain text next to the encrypted vault so " +
+			"sherlock list and sherlock search --index work without the group key. Passwords, usernames, urls, notes and otp " +
+			"secrets stay encrypted. Anyone with access to the vault file can read the index; it is authenticated with the group " +
+			"key whenever the group is unlocked and by the vault signature if signing is enabled. Running it again rebuilds an " +
+			"index modified outside of sherlock, --off removes the index => sherlock group index work",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.SetPlainIndex(ctx, args[0], groupKey, !opts.off); err != nil {
+				return err
+			}
+			if opts.off {
+				terminal.Success("plain text index of group %s removed", args[0])
+				return nil
+			}
+			terminal.Success("group %s keeps a plain text index and can be listed without its group key", args[0])
+			return nil
+		},
+	}
+	index.Flags().BoolVar(&opts.off, "off", false, "remove the plain text index")
+
+	return index
+}
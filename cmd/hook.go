@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// hookTimeout limits how long a single hook may run
+const hookTimeout = 30 * time.Second
+
+func cmdHook(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	hook := &cobra.Command{
+		Use:   "hook",
+		Short: "run shell commands before or after changes are written",
+		Long: "hooks are shell commands run before (pre-write) or after (post-write) a group is written. They receive " +
+			"the group, the operation and the account as $1, $2 and $3. A failing pre-write hook aborts the write, " +
+			"a failing post-write hook is reported",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	hook.AddCommand(cmdHookAdd(ctx, sherlock))
+	hook.AddCommand(cmdHookList(ctx, sherlock))
+	hook.AddCommand(cmdHookRemove(ctx, sherlock))
+
+	return hook
+}
+
+func cmdHookAdd(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add",
+		Short: "add a hook",
+		Long:  "add a shell command as pre-write or post-write hook => sherlock hook add post-write 'cd ~/.sherlock && git add -A && git commit -qm \"$2 $1\"'",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.AddHook(ctx, args[0], args[1]); err != nil {
+				return err
+			}
+			terminal.Success("%s hook added", args[0])
+			return nil
+		},
+	}
+}
+
+func cmdHookList(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the hooks",
+		Long:  "list the hooks in the order they are run",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hooks, err := sherlock.Hooks(ctx)
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, kind := range []struct {
+				name     string
+				commands []string
+			}{
+				{name: internal.HookPreWrite, commands: hooks.PreWrite},
+				{name: internal.HookPostWrite, commands: hooks.PostWrite},
+			} {
+				for i, command := range kind.commands {
+					rows = append(rows, []string{kind.name, strconv.Itoa(i + 1), command})
+				}
+			}
+			if len(rows) == 0 {
+				terminal.Info("no hooks configured")
+				return nil
+			}
+			terminal.ToTable([]string{"Hook", "#", "Command"}, rows, terminal.TableWithCellMerge(0))
+			return nil
+		},
+	}
+}
+
+func cmdHookRemove(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove",
+		Short: "remove a hook",
+		Long:  "remove a hook by its kind and number as shown by hook list => sherlock hook remove post-write 1",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("%w: hook number %q", internal.ErrInvalidInput, args[1])
+			}
+			if err := sherlock.RemoveHook(ctx, args[0], n); err != nil {
+				return err
+			}
+			terminal.Success("%s hook #%d removed", args[0], n)
+			return nil
+		},
+	}
+}
+
+// preWriteHooks returns the Check running the pre-write hooks. The first
+// failing hook aborts the write
+func preWriteHooks(sherlock *internal.Sherlock) internal.Check {
+	return func(ctx context.Context, events []internal.ChangeEvent) error {
+		hooks, err := sherlock.Hooks(ctx)
+		if err != nil {
+			return err
+		}
+		return runHooks(ctx, hooks.PreWrite, events)
+	}
+}
+
+// postWriteHooks returns the Observer running the post-write hooks. A
+// failing hook is reported but does not fail the command since the change
+// has already been written
+func postWriteHooks(sherlock *internal.Sherlock) internal.Observer {
+	return func(ctx context.Context, events []internal.ChangeEvent) {
+		hooks, err := sherlock.Hooks(ctx)
+		if err != nil {
+			terminal.Warning("post-write hook: %v", err)
+			return
+		}
+		if err := runHooks(ctx, hooks.PostWrite, events); err != nil {
+			terminal.Warning("post-write hook: %v", err)
+		}
+	}
+}
+
+// runHooks runs every command for every event with sh -c. The group,
+// operation and account are passed as $1, $2 and $3 as well as in
+// SHERLOCK_HOOK_* variables. The output of hooks goes to stderr so it
+// does not mix with the output of sherlock
+func runHooks(ctx context.Context, commands []string, events []internal.ChangeEvent) error {
+	for _, command := range commands {
+		for _, e := range events {
+			hookCtx, cancel := context.WithTimeout(ctx, hookTimeout)
+			cmd := exec.CommandContext(hookCtx, "sh", "-c", command, "sherlock-hook", e.Group, e.Operation, e.Account)
+			cmd.Env = append(os.Environ(),
+				"SHERLOCK_HOOK_GROUP="+e.Group,
+				"SHERLOCK_HOOK_OPERATION="+e.Operation,
+				"SHERLOCK_HOOK_ACCOUNT="+e.Account,
+			)
+			cmd.Stdout = os.Stderr
+			cmd.Stderr = os.Stderr
+			err := cmd.Run()
+			cancel()
+			if err != nil {
+				return fmt.Errorf("%q: %v", command, err)
+			}
+		}
+	}
+	return nil
+}
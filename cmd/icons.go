@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/icon"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// iconFetchTimeout limits the time to fetch a single favicon
+const iconFetchTimeout = 10 * time.Second
+
+func cmdIcons(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	icons := &cobra.Command{
+		Use:   "icons",
+		Short: "fetch and cache favicons of account urls",
+		Long: "icons are never fetched implicitly. Use icons fetch to download the favicons of account urls " +
+			"into the local cache and icons clear to remove them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	icons.AddCommand(cmdIconsFetch(ctx, sherlock))
+	icons.AddCommand(cmdIconsClear(ctx, sherlock))
+	return icons
+}
+
+type iconsFetchOptions struct {
+	all     bool
+	refresh bool
+}
+
+func cmdIconsFetch(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts iconsFetchOptions
+	fetch := &cobra.Command{
+		Use:   "fetch",
+		Short: "fetch the favicons of the account urls of the given groups (default group if none)",
+		Long:  "fetch downloads https://{host}/favicon.ico for every account url. Icons already cached are skipped unless --refresh is set",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			client := &http.Client{Timeout: iconFetchTimeout}
+			for _, gid := range gids {
+				groupKey, err := readGroupKey(gid)
+				if err != nil {
+					return err
+				}
+				group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+				if err != nil {
+					return err
+				}
+				for _, host := range group.Hosts() {
+					if _, err := sherlock.CachedIcon(ctx, host); err == nil && !opts.refresh {
+						continue
+					} else if err != nil && !os.IsNotExist(err) {
+						return err
+					}
+					b, err := icon.Fetch(ctx, client, host)
+					if err != nil {
+						terminal.Warning("%s: %s", host, err)
+						continue
+					}
+					if err := sherlock.CacheIcon(ctx, host, b); err != nil {
+						return err
+					}
+					terminal.Success("%s: icon cached", host)
+				}
+			}
+			return nil
+		},
+	}
+	fetch.Flags().BoolVarP(&opts.all, "all", "a", false, "fetch icons for all registered groups")
+	fetch.Flags().BoolVarP(&opts.refresh, "refresh", "r", false, "fetch icons even if already cached")
+
+	return fetch
+}
+
+func cmdIconsClear(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "remove all cached icons",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.ClearIcons(ctx); err != nil {
+				return err
+			}
+			terminal.Success("icon cache cleared")
+			return nil
+		},
+	}
+}
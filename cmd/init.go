@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/storage"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+var ErrStorageConfigured = fmt.Errorf("a storage backend is already configured (use --force to replace it)")
+
+type initOptions struct {
+	from  string
+	force bool
+}
+
+func cmdInit(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts initOptions
+
+	initialize := &cobra.Command{
+		Use:   "init",
+		Short: "set up sherlock on a new device from existing remote vaults",
+		Long:  "configure the storage backend for a remote (s3://bucket/prefix, git+ssh://host/repo.git, webdav://host/path, sftp://user@host/path, ...) and pull the encrypted vaults found there. sftp:// and ssh:// remotes are served over SSH, any other remote by the storage plugin named by its scheme. The vaults stay encrypted; no group key is needed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.from == "" {
+				return fmt.Errorf("%w: --from is required", internal.ErrInvalidInput)
+			}
+			// init configures the default workspace only
+			if w, err := storage.LoadWorkspaces(); err == nil && w.Active() != storage.DefaultWorkspace {
+				return fmt.Errorf("%w: workspace %s is in use; add the remote as workspace with sherlock workspace add <name> --from %s", internal.ErrInvalidInput, w.Active(), opts.from)
+			}
+			config, err := storage.FromURL(opts.from)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(storage.ConfigPath()); err == nil && !opts.force {
+				return ErrStorageConfigured
+			}
+			fileSystem, err := storage.OpenConfig(config)
+			if err != nil {
+				return err
+			}
+			remote := internal.NewSherlock(fileSystem)
+			if err := remote.IsSetUp(ctx); err != nil {
+				return fmt.Errorf("%w: no sherlock vaults found at %s", err, opts.from)
+			}
+			infos, err := remote.GroupInfos(ctx)
+			if err != nil {
+				return err
+			}
+			if err := storage.WriteConfig(config); err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(infos))
+			for _, info := range infos {
+				accounts := "-"
+				if info.Known {
+					accounts = strconv.Itoa(info.Accounts)
+				}
+				rows = append(rows, []string{info.GID, accounts, formatSize(info.Size)})
+			}
+			terminal.ToTable([]string{"Group", "Accounts", "Size"}, rows)
+			if sherlock.IsSetUp(ctx) == nil {
+				terminal.Warning("the vaults stored on this device before are no longer used (remove %s to use them again)", storage.ConfigPath())
+			}
+			terminal.Success("%d groups pulled from %s; sherlock now uses %s", len(infos), opts.from, storage.ConfigPath())
+			return nil
+		},
+	}
+	initialize.Flags().StringVar(&opts.from, "from", "", "url of the remote holding the vaults")
+	initialize.Flags().BoolVar(&opts.force, "force", false, "replace an existing storage config")
+
+	return initialize
+}
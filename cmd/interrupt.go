@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context cancelled on SIGINT or SIGTERM so running
+// operations stop without leaving a vault half written and deferred cleanups
+// (e.g. masking a revealed secret) run before the command returns. sherlock
+// only exits without waiting for the command on a second signal, e.g. if it
+// is blocked reading input
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+		fmt.Fprintln(os.Stderr, "stopping... interrupt again to exit immediately")
+		<-signals
+		os.Exit(ExitInterrupted)
+	}()
+	return ctx
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/k8s"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type k8sSyncOptions struct {
+	manifest    string
+	kubeContext string
+	dryRun      bool
+}
+
+type k8sCredentialOptions struct {
+	field string
+	cert  string
+	key   string
+	ttl   time.Duration
+}
+
+func cmdK8s(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	k := &cobra.Command{
+		Use:   "k8s",
+		Short: "sync accounts to Kubernetes Secrets and authenticate kubectl",
+		Long: "create and update Kubernetes Secrets from sherlock accounts using kubectl and the current kubeconfig, " +
+			"and provide the credentials of kubeconfig users as a client-go credential plugin",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	k.AddCommand(cmdK8sSync(ctx, sherlock))
+	k.AddCommand(cmdK8sCredential(ctx, sherlock))
+
+	return k
+}
+
+func cmdK8sSync(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts k8sSyncOptions
+	sync := &cobra.Command{
+		Use:   "sync",
+		Short: "create or update the Secrets of a manifest",
+		Long: "resolve the sherlock://group@account[#field] references of a manifest and create or update the " +
+			"Kubernetes Secrets. The changed keys of every Secret are printed (never their values); " +
+			"with --dry-run nothing is written",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := ioutil.ReadFile(opts.manifest)
+			if err != nil {
+				return err
+			}
+			manifest, err := k8s.ParseManifest(b)
+			if err != nil {
+				return err
+			}
+			resolver := newRefResolver(sherlock)
+			type pending struct {
+				secret k8s.Secret
+				data   map[string]string
+				exists bool
+			}
+			// resolve all references first since resolving may prompt for group keys
+			desired := make([]map[string]string, len(manifest.Secrets))
+			for i, secret := range manifest.Secrets {
+				desired[i] = make(map[string]string, len(secret.Data))
+				for key, ref := range secret.Data {
+					if desired[i][key], err = resolver.resolve(ctx, ref); err != nil {
+						return fmt.Errorf("%s/%s %s: %w", secret.Namespace, secret.Name, key, err)
+					}
+				}
+			}
+			var syncs []pending
+			var rows [][]string
+			progress := newProgress(cmd, "reading secrets", len(manifest.Secrets))
+			for i, secret := range manifest.Secrets {
+				id := secret.Namespace + "/" + secret.Name
+				progress.Start(id)
+				current, exists, err := k8sSecretData(ctx, opts.kubeContext, secret.Namespace, secret.Name)
+				progress.Done(id, err)
+				if err != nil {
+					progress.Finish()
+					return err
+				}
+				changes := k8s.Diff(current, desired[i])
+				if len(changes) == 0 {
+					continue
+				}
+				for _, c := range changes {
+					rows = append(rows, []string{id, c.Key, c.Change})
+				}
+				syncs = append(syncs, pending{secret: secret, data: desired[i], exists: exists})
+			}
+			progress.Finish()
+			if len(syncs) == 0 {
+				terminal.Info("all secrets are up to date")
+				return nil
+			}
+			terminal.ToTable([]string{"Secret", "Key", "Change"}, rows, terminal.TableWithCellMerge(0))
+			if opts.dryRun {
+				return nil
+			}
+			progress = newProgress(cmd, "writing secrets", len(syncs))
+			for _, p := range syncs {
+				id := p.secret.Namespace + "/" + p.secret.Name
+				obj, err := k8s.Object(p.secret.Name, p.secret.Namespace, p.data)
+				if err != nil {
+					return err
+				}
+				verb := "create"
+				if p.exists {
+					verb = "replace"
+				}
+				progress.Start(id)
+				_, err = kubectl(ctx, opts.kubeContext, obj, verb, "-f", "-")
+				progress.Done(id+" "+verb+"d", err)
+				if err != nil {
+					progress.Finish()
+					return err
+				}
+			}
+			progress.Finish()
+			terminal.Success("%d secrets written", len(syncs))
+			return nil
+		},
+	}
+	sync.Flags().StringVar(&opts.manifest, "manifest", "", "manifest mapping Secrets to sherlock references")
+	sync.Flags().StringVar(&opts.kubeContext, "context", "", "kubeconfig context to use (default current context)")
+	sync.Flags().BoolVar(&opts.dryRun, "dry-run", false, "only print the changes")
+	_ = sync.MarkFlagRequired("manifest")
+
+	return sync
+}
+
+func cmdK8sCredential(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts k8sCredentialOptions
+	credential := &cobra.Command{
+		Use:   "credential [group@account]",
+		Short: "print an ExecCredential for a kubeconfig user",
+		Long: "act as client-go credential plugin: print an ExecCredential with the password (or --field) of the account as " +
+			"bearer token and/or the client certificate and key referenced with --cert and --key. The apiVersion requested " +
+			"by kubectl in " + k8s.ExecInfoEnv + " is answered; messages and prompts go to stderr. Group keys are read from " +
+			"SHERLOCK_KEY_<GROUP>, the keyring or a prompt if kubectl runs the plugin interactively",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			terminal.MessagesToStderr()
+			info, err := k8s.ParseExecInfo(os.Getenv(k8s.ExecInfoEnv))
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 && opts.cert == "" {
+				return fmt.Errorf("%w: an account or --cert and --key are required", internal.ErrInvalidInput)
+			}
+			if (opts.cert == "") != (opts.key == "") {
+				return fmt.Errorf("%w: --cert and --key are required together", internal.ErrInvalidInput)
+			}
+			if opts.ttl < 0 {
+				return fmt.Errorf("%w: --ttl must not be negative", internal.ErrInvalidInput)
+			}
+			var c k8s.Credential
+			resolver := newRefResolver(sherlock)
+			resolve := func(ref *string, value string) {
+				if err == nil && value != "" {
+					*ref, err = resolver.resolve(ctx, value)
+				}
+			}
+			if len(args) == 1 {
+				resolve(&c.Token, internal.ReferenceScheme+args[0]+"#"+opts.field)
+			}
+			resolve(&c.ClientCert, opts.cert)
+			resolve(&c.ClientKey, opts.key)
+			if err != nil {
+				if !info.Interactive {
+					return fmt.Errorf("%w (kubectl runs the plugin without a terminal: set interactiveMode: IfAvailable, "+
+						"SHERLOCK_KEY_<GROUP> or use the keyring)", err)
+				}
+				return err
+			}
+			if opts.ttl > 0 {
+				c.Expires = time.Now().Add(opts.ttl)
+			}
+			b, err := k8s.ExecCredential(info, c)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(os.Stdout, string(b))
+			return err
+		},
+	}
+	credential.Flags().StringVarP(&opts.field, "field", "f", internal.FieldPassword, "account field used as token (password|username|url|note)")
+	credential.Flags().StringVar(&opts.cert, "cert", "", "sherlock:// reference to the PEM encoded client certificate")
+	credential.Flags().StringVar(&opts.key, "key", "", "sherlock:// reference to the PEM encoded client key")
+	credential.Flags().DurationVar(&opts.ttl, "ttl", 0, "let kubectl request the credential again after the duration (default: never)")
+
+	return credential
+}
+
+// k8sSecretData reads the data of a Secret. A missing Secret is no error
+// but reported as not existing
+func k8sSecretData(ctx context.Context, kubeContext, namespace, name string) (map[string]string, bool, error) {
+	out, err := kubectl(ctx, kubeContext, nil, "get", "secret", name, "--namespace", namespace, "--output", "json", "--ignore-not-found")
+	if err != nil {
+		return nil, false, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return map[string]string{}, false, nil
+	}
+	data, err := k8s.DecodeData(out)
+	if err != nil {
+		return nil, false, fmt.Errorf("secret %s/%s: %v", namespace, name, err)
+	}
+	return data, true, nil
+}
+
+// kubectl runs kubectl passing stdin (Secret objects are never written to
+// disk) and returns its output
+func kubectl(ctx context.Context, kubeContext string, stdin []byte, args ...string) ([]byte, error) {
+	verb := args[0]
+	if kubeContext != "" {
+		args = append([]string{"--context", kubeContext}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl %s: %v: %s", verb, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
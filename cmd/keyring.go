@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdKeyring(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	keyring := &cobra.Command{
+		Use:   "keyring",
+		Short: "unlock all groups with one master passphrase",
+		Long: "the keyring stores group keys sealed with a master passphrase. Groups in the keyring are unlocked with the " +
+			"master passphrase (prompted once per command or read from " + masterKeyEnv + ") instead of their group key. " +
+			"The group keys stay valid and can still be shared",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	keyring.AddCommand(cmdKeyringInit(ctx, sherlock))
+	keyring.AddCommand(cmdKeyringAdd(ctx, sherlock))
+	keyring.AddCommand(cmdKeyringRm(ctx, sherlock))
+	keyring.AddCommand(cmdKeyringList(ctx, sherlock))
+
+	return keyring
+}
+
+type keyringInitOptions struct {
+	insecure bool
+}
+
+func cmdKeyringInit(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts keyringInitOptions
+	init := &cobra.Command{
+		Use:   "init",
+		Short: "create the keyring",
+		Long:  "create an empty keyring sealed with a new master passphrase. Add groups with sherlock keyring add",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase, err := terminal.ReadPassword("master passphrase: ")
+			if err != nil {
+				return err
+			}
+			if !opts.insecure {
+				if err := security.PasswordStrength(passphrase); err != nil {
+					return err
+				}
+			}
+			repeated, err := terminal.ReadPassword("repeat master passphrase: ")
+			if err != nil {
+				return err
+			}
+			if passphrase != repeated {
+				return fmt.Errorf("%w: master passphrases do not match", internal.ErrInvalidInput)
+			}
+			if _, err := sherlock.CreateKeyring(ctx, passphrase); err != nil {
+				return err
+			}
+			terminal.Success("keyring created (add groups with sherlock keyring add <group>)")
+			return nil
+		},
+	}
+	init.Flags().BoolVar(&opts.insecure, "insecure", false, "allow a weak master passphrase")
+
+	return init
+}
+
+func cmdKeyringAdd(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <group>...",
+		Short: "store group keys in the keyring",
+		Long:  "store the keys of the groups in the keyring. Each group key is checked before it is stored; add a group again after its key changed",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyring, err := openKeyring(ctx, sherlock)
+			if err != nil {
+				return err
+			}
+			for _, gid := range args {
+				// the group key is read without the keyring so a changed key can replace the stored one
+				groupKey, ok := envGroupKeys[internal.GroupKeyEnv(gid)]
+				if !ok {
+					if groupKey, err = terminal.ReadPassword("(%s) password: ", gid); err != nil {
+						return err
+					}
+				}
+				sherlock.Unlocking(gid)
+				if err := sherlock.AddToKeyring(ctx, keyring, gid, groupKey); err != nil {
+					return err
+				}
+				terminal.Success("group %s added to the keyring", gid)
+			}
+			return nil
+		},
+	}
+}
+
+func cmdKeyringRm(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <group>",
+		Short: "remove a group key from the keyring",
+		Long:  "remove the key of the group from the keyring. The group is unlocked with its group key again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyring, err := openKeyring(ctx, sherlock)
+			if err != nil {
+				return err
+			}
+			if err := sherlock.RemoveFromKeyring(ctx, keyring, args[0]); err != nil {
+				return err
+			}
+			terminal.Success("group %s removed from the keyring", args[0])
+			return nil
+		},
+	}
+}
+
+func cmdKeyringList(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the groups in the keyring",
+		Long:  "list the groups whose keys are stored in the keyring. The keyring is not unlocked for this",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := sherlock.KeyringGroups(ctx)
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, len(gids))
+			for i, gid := range gids {
+				rows[i] = []string{gid}
+			}
+			terminal.ToTable([]string{"Group"}, rows)
+			return nil
+		},
+	}
+}
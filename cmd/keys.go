@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+)
+
+// backupKeyEnv is the environment variable holding the backup passphrase or identity
+const backupKeyEnv = "SHERLOCK_BACKUP_KEY"
+
+// masterKeyEnv is the environment variable holding the master passphrase of the keyring
+const masterKeyEnv = "SHERLOCK_MASTER_KEY"
+
+// envGroupKeys holds the group keys read from the environment mapped
+// by the name of their environment variable
+var envGroupKeys = map[string]string{}
+
+// envBackupKey holds the backup passphrase or identity read from the environment
+var envBackupKey string
+
+// envMasterKey holds the master passphrase of the keyring read from the environment
+var envMasterKey string
+
+// session holds the keyring for the run of a command. It is unlocked with the
+// master passphrase the first time a key of one of its groups is required.
+// Passphrase hints are looked up with the sherlock of the session
+var session struct {
+	ctx      context.Context
+	sherlock *internal.Sherlock
+	groups   map[string]bool
+	keyring  *internal.Keyring
+}
+
+// loadEnvGroupKeys reads all SHERLOCK_KEY_<GROUP> variables (and SHERLOCK_BACKUP_KEY, SHERLOCK_MASTER_KEY)
+// once and removes them from the process environment so they are not passed on to child processes
+func loadEnvGroupKeys() {
+	if key, ok := os.LookupEnv(backupKeyEnv); ok {
+		envBackupKey = key
+		_ = os.Unsetenv(backupKeyEnv)
+	}
+	if key, ok := os.LookupEnv(masterKeyEnv); ok {
+		envMasterKey = key
+		_ = os.Unsetenv(masterKeyEnv)
+	}
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, internal.GroupKeyEnvPrefix) {
+			continue
+		}
+		set := strings.SplitN(env, "=", 2)
+		if len(set) != 2 {
+			continue
+		}
+		envGroupKeys[set[0]] = set[1]
+		_ = os.Unsetenv(set[0])
+	}
+}
+
+// readGroupKey resolves the group key for a query (group@account) or a group name.
+// Keys are resolved in the following order:
+//  1. SHERLOCK_KEY_<GROUP> environment variable
+//  2. the keyring (unlocked once with the master passphrase)
+//  3. interactive prompt, showing the passphrase hint after failed unlocks
+//
+// Every key read is one unlock of the group logged in the unlock log
+func readGroupKey(query string) (string, error) {
+	gid := query
+	if strings.Contains(query, "@") {
+		var err error
+		if gid, _, err = internal.SplitQuery(query); err != nil {
+			return "", err
+		}
+	}
+	announceUnlock(gid)
+	if key, ok := envGroupKeys[internal.GroupKeyEnv(gid)]; ok {
+		return key, nil
+	}
+	if key, ok, err := keyringGroupKey(gid); err != nil || ok {
+		return key, err
+	}
+	if hint, ok := groupHint(gid); ok {
+		terminal.Info("(%s) hint: %s", gid, hint)
+	}
+	return terminal.ReadPassword("(%s) password: ", query)
+}
+
+// announceUnlock logs the next load of the group as an unlock by the user
+func announceUnlock(gid string) {
+	if session.sherlock != nil {
+		session.sherlock.Unlocking(gid)
+	}
+}
+
+// groupHint returns the passphrase hint of the group if it is to be shown
+func groupHint(gid string) (string, bool) {
+	if session.sherlock == nil {
+		return "", false
+	}
+	config, err := session.sherlock.Config(session.ctx)
+	if err != nil {
+		return "", false
+	}
+	return config.Hint(gid)
+}
+
+// useKeyring makes the groups of the keyring (if one exists) resolvable by readGroupKey
+func useKeyring(ctx context.Context, sherlock *internal.Sherlock) error {
+	session.ctx, session.sherlock = ctx, sherlock
+	gids, err := sherlock.KeyringGroups(ctx)
+	if err != nil {
+		if err == internal.ErrNoKeyring {
+			return nil
+		}
+		return err
+	}
+	session.groups = make(map[string]bool, len(gids))
+	for _, gid := range gids {
+		session.groups[gid] = true
+	}
+	return nil
+}
+
+// keyringGroupKey returns the key of the group from the keyring unlocking it on first use
+func keyringGroupKey(gid string) (string, bool, error) {
+	if !session.groups[gid] {
+		return "", false, nil
+	}
+	if session.keyring == nil {
+		keyring, err := openKeyring(session.ctx, session.sherlock)
+		if err != nil {
+			return "", false, err
+		}
+		session.keyring = keyring
+	}
+	key, ok := session.keyring.Key(gid)
+	return key, ok, nil
+}
+
+// openKeyring unlocks the keyring with SHERLOCK_MASTER_KEY or the prompted master passphrase
+func openKeyring(ctx context.Context, sherlock *internal.Sherlock) (*internal.Keyring, error) {
+	passphrase := envMasterKey
+	if passphrase == "" {
+		var err error
+		if passphrase, err = terminal.ReadPassword("keyring passphrase: "); err != nil {
+			return nil, err
+		}
+	}
+	return sherlock.OpenKeyring(ctx, passphrase)
+}
+
+// readBackupKey resolves the backup passphrase (or identity) from SHERLOCK_BACKUP_KEY
+// or an interactive prompt
+func readBackupKey(format string, a ...interface{}) (string, error) {
+	if envBackupKey != "" {
+		return envBackupKey, nil
+	}
+	return terminal.ReadPassword(format, a...)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+type launchOptions struct {
+	noOpen bool
+}
+
+func cmdLaunch(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts launchOptions
+	launch := &cobra.Command{
+		Use:   "launch",
+		Short: "open the url of an account and copy its credentials step by step",
+		Long:  "copy the username of an account and open its url in the browser. After pressing enter the clipboard is swapped to the password",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			rawURL, err := account.Field(internal.FieldURL)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, internal.FieldURL)
+			}
+			target, err := launchURL(rawURL)
+			if err != nil {
+				return err
+			}
+			username, err := account.Field(internal.FieldUsername)
+			hasUsername := err == nil
+			if hasUsername {
+				if err := clipboard.WriteAll(username); err != nil {
+					return err
+				}
+				terminal.Info("username of %q copied to clipboard", args[0])
+			}
+			if opts.noOpen {
+				terminal.Info("open %s", target)
+			} else {
+				if err := openURL(ctx, target); err != nil {
+					return err
+				}
+				terminal.Info("opened %s", target)
+			}
+			if hasUsername {
+				if _, err := terminal.ReadLine("press enter to copy the password "); err != nil {
+					return err
+				}
+			}
+			if err := clipboard.WriteAll(account.Password); err != nil {
+				return err
+			}
+			terminal.Success("password of %q copied to clipboard", args[0])
+			return nil
+		},
+	}
+	launch.Flags().BoolVar(&opts.noOpen, "no-open", false, "only print the url instead of opening it")
+
+	return launch
+}
+
+// launchURL validates the url of an account before it is opened. Urls without
+// a scheme are opened with https, schemes other than http(s) are refused
+func launchURL(raw string) (string, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%w: account url %q cannot be opened", internal.ErrInvalidInput, raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: only http and https urls are opened, not %q", internal.ErrInvalidInput, u.Scheme)
+	}
+	return u.String(), nil
+}
+
+// openURL opens the url with the default browser of the platform
+func openURL(ctx context.Context, target string) error {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "windows":
+		name, args = "rundll32", []string{"url.dll,FileProtocolHandler"}
+	default:
+		name = "xdg-open"
+	}
+	cmd := exec.CommandContext(ctx, name, append(args, target)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
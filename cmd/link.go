@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdLink(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "link <group@account> <group@account>",
+		Short: "let an account use the password of another account",
+		Long: "link the first account to the second so it uses the password of the second instead of a copy, e.g. accounts signing in " +
+			"with work@sso. The own password of the first account is removed; get, show and the other commands follow the link and " +
+			"rotating either account rotates the target. Setting a new password or sherlock unlink removes the link",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			targetKey, err := readGroupKey(args[1])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.LinkAccount(ctx, args[0], groupKey, args[1], targetKey); err != nil {
+				return err
+			}
+			terminal.Success("%s uses the password of %s", args[0], args[1])
+			return nil
+		},
+	}
+}
+
+func cmdUnlink(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unlink <group@account>",
+		Short: "give a linked account its own password",
+		Long:  "remove the link of the account set with sherlock link. The account keeps a copy of the current password of the target",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			targetKey := groupKey
+			if account.Linked() {
+				if targetKey, err = readGroupKey(account.Link); err != nil {
+					return err
+				}
+			}
+			if err := sherlock.UnlinkAccount(ctx, args[0], groupKey, targetKey); err != nil {
+				return err
+			}
+			terminal.Success("%s has its own password again", args[0])
+			return nil
+		},
+	}
+}
+
+// getAccount returns the account of the query. The password of a linked
+// account is the one of its target, whose group key is read if needed. The
+// password of an expired time lock is opened
+func getAccount(ctx context.Context, sherlock *internal.Sherlock, query, groupKey string) (*internal.Account, error) {
+	account, err := sherlock.GetAccount(ctx, query, groupKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := account.OpenTimeLock(time.Now()); err != nil {
+		return nil, err
+	}
+	if !account.Linked() {
+		return account, nil
+	}
+	targetKey, err := readGroupKey(account.Link)
+	if err != nil {
+		return nil, err
+	}
+	if err := sherlock.FollowLink(ctx, account, targetKey); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
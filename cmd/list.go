@@ -2,15 +2,64 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/KonstantinGasser/sherlock/internal"
 	"github.com/KonstantinGasser/sherlock/terminal"
 	"github.com/spf13/cobra"
 )
 
+// filterDateLayout is the layout of the date filters of the list command
+const filterDateLayout = "2006-01-02"
+
 type listOptions struct {
-	filterByTag string
-	all         bool
+	filterByTag   string
+	all           bool
+	fav           bool
+	group         string
+	createdAfter  string
+	createdBefore string
+	updatedAfter  string
+	updatedBefore string
+	has           []string
+	sort          string
+	desc          bool
+}
+
+// filters builds the account filters from the options
+func (opts listOptions) filters() ([]func(*internal.Account) bool, error) {
+	filters := []func(*internal.Account) bool{
+		internal.FilterByTag(opts.filterByTag),
+	}
+	for _, date := range []struct {
+		flag   string
+		value  string
+		filter func(time.Time) func(*internal.Account) bool
+	}{
+		{flag: "created-after", value: opts.createdAfter, filter: internal.FilterCreatedAfter},
+		{flag: "created-before", value: opts.createdBefore, filter: internal.FilterCreatedBefore},
+		{flag: "updated-after", value: opts.updatedAfter, filter: internal.FilterUpdatedAfter},
+		{flag: "updated-before", value: opts.updatedBefore, filter: internal.FilterUpdatedBefore},
+	} {
+		if date.value == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(filterDateLayout, date.value, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: --%s expects a date like 2024-01-31", internal.ErrInvalidInput, date.flag)
+		}
+		filters = append(filters, date.filter(t))
+	}
+	for _, field := range opts.has {
+		filter, err := internal.FilterHas(field)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
 }
 
 func cmdList(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
@@ -19,45 +68,139 @@ func cmdList(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
 	list := &cobra.Command{
 		Use:   "list",
 		Short: "list all accounts mapped to a given group",
-		Long:  "with the list command you can inspect all accounts mapped to a given group",
-		Args:  cobra.MaximumNArgs(2),
-		Run: func(cmd *cobra.Command, args []string) {
+		Long: "with the list command you can inspect all accounts mapped to a given group. Groups with a plain text index " +
+			"(see sherlock group index) are listed without their group key unless --has is used",
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var gid = "default"
 			if opts.all {
-				groupList, err := sherlock.ReadRegisteredGroups()
+				groupList, err := sherlock.ActiveGroups(ctx)
 				if err != nil {
-					terminal.Error(err.Error())
-					return
+					return err
 				}
 				terminal.Info("Registered Groups : ")
 				for _, group := range groupList {
 					terminal.Info(group)
 				}
-				return
+				return nil
 			} else if len(args) > 0 {
 				gid = args[0]
 			}
-			groupKey, err := terminal.ReadPassword("(%s) password: ", gid)
+			if opts.group != "" {
+				if len(args) > 0 && args[0] != opts.group {
+					return fmt.Errorf("%w: group given as argument and with --group", internal.ErrInvalidInput)
+				}
+				gid = opts.group
+			}
+			filters, err := opts.filters()
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
-			group, err := sherlock.LoadGroup(gid, groupKey)
+			group, err := loadListedGroup(ctx, sherlock, gid, len(opts.has) > 0)
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
+			}
+			if opts.sort != "" {
+				if err := group.Sort(opts.sort, opts.desc); err != nil {
+					return err
+				}
+			}
+			if opts.fav {
+				group.PinFavorites()
 			}
 			terminal.ToTable(
 				[]string{"Group", "Account", "#Tag", "Created On", "Updated On"},
-				group.Table(
-					internal.FilterByTag(opts.filterByTag),
-				),
+				group.Table(filters...),
 				terminal.TableWithCellMerge(0),
 			)
+			return nil
 		},
 	}
 	list.Flags().StringVarP(&opts.filterByTag, "tag", "t", "", "filter accounts by tag name")
-	list.Flags().BoolVarP(&opts.all, "all", "a", false, "show all registered groups")
+	list.Flags().BoolVarP(&opts.all, "all", "a", false, "show all registered groups which are not archived")
+	list.Flags().BoolVar(&opts.fav, "fav", false, "show favorite accounts first")
+	list.Flags().StringVarP(&opts.group, "group", "g", "", "group to list (same as the argument)")
+	list.Flags().StringVar(&opts.createdAfter, "created-after", "", "only accounts created on or after the date (YYYY-MM-DD)")
+	list.Flags().StringVar(&opts.createdBefore, "created-before", "", "only accounts created before the date (YYYY-MM-DD)")
+	list.Flags().StringVar(&opts.updatedAfter, "updated-after", "", "only accounts updated on or after the date (YYYY-MM-DD)")
+	list.Flags().StringVar(&opts.updatedBefore, "updated-before", "", "only accounts updated before the date (YYYY-MM-DD)")
+	list.Flags().StringSliceVar(&opts.has, "has", nil, "only accounts with a value for the field (username, url, note, tag, otp, autotype)")
+	list.Flags().StringVar(&opts.sort, "sort", "", "sort accounts by name, created or updated")
+	list.Flags().BoolVar(&opts.desc, "desc", false, "sort in descending order")
+
+	list.AddCommand(cmdListGroups(ctx, sherlock))
 
 	return list
 }
+
+// loadListedGroup reads the group from its plain text index if it has one.
+// Otherwise or if fields outside of the index are needed the group is unlocked
+func loadListedGroup(ctx context.Context, sherlock *internal.Sherlock, gid string, secretFields bool) (*internal.Group, error) {
+	if !secretFields {
+		group, err := sherlock.GroupIndex(ctx, gid)
+		if err != internal.ErrNoIndex {
+			return group, err
+		}
+	}
+	groupKey, err := readGroupKey(gid)
+	if err != nil {
+		return nil, err
+	}
+	return sherlock.LoadGroup(ctx, gid, groupKey)
+}
+
+type listGroupsOptions struct {
+	archived bool
+}
+
+func cmdListGroups(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts listGroupsOptions
+	groups := &cobra.Command{
+		Use:   "groups",
+		Short: "list all groups with their number of accounts, size and last modification",
+		Long: "the group details are read from the vault headers and do not require any group key. Groups not written since the details were introduced show a \"-\". " +
+			"Archived groups are only listed with --archived",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			infos, err := sherlock.GroupInfos(ctx)
+			if err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(infos))
+			for _, info := range infos {
+				if config.Archived(info.GID) != opts.archived {
+					continue
+				}
+				accounts, modified := "-", "-"
+				if info.Known {
+					accounts = strconv.Itoa(info.Accounts)
+					modified = info.Modified.Local().Format(eventTimeLayout)
+				}
+				rows = append(rows, []string{info.GID, accounts, formatSize(info.Size), modified})
+			}
+			terminal.ToTable([]string{"Group", "Accounts", "Size", "Modified"}, rows)
+			return nil
+		},
+	}
+	groups.Flags().BoolVar(&opts.archived, "archived", false, "list the archived groups instead")
+
+	return groups
+}
+
+// formatSize formats a size in bytes using binary units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
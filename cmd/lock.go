@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdLock(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: "mark a group read-only",
+		Long:  "mark a group read-only (e.g. an archive). Changing, deleting or replacing the group is refused until it is unlocked again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.SetReadOnly(ctx, args[0], groupKey, true); err != nil {
+				return err
+			}
+			terminal.Success("group %q is now read-only", args[0])
+			return nil
+		},
+	}
+}
+
+func cmdUnlock(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unlock",
+		Short: "allow changes to a read-only group",
+		Long:  "remove the read-only mark of a group set with sherlock lock",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.SetReadOnly(ctx, args[0], groupKey, false); err != nil {
+				return err
+			}
+			terminal.Success("group %q can be changed again", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// eventTimeLayout formats the time of history events
+const eventTimeLayout = "2006-01-02 15:04:05"
+
+func cmdLog(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	log := &cobra.Command{
+		Use:   "log",
+		Short: "list the change history of an account",
+		Long: "log lists when an account was created, renamed and which fields changed. " +
+			"Values of changed fields are never shown",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, e := range account.History() {
+				rows = append(rows, []string{e.At.Local().Format(eventTimeLayout), e.Kind, e.Field, e.Detail})
+			}
+			terminal.ToTable([]string{"Time", "Event", "Field", "Detail"}, rows)
+			return nil
+		},
+	}
+	return log
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/autotype"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/picker"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// menuPrompt is shown by the menu when selecting an account
+const menuPrompt = "sherlock"
+
+type menuOptions struct {
+	backend  string
+	all      bool
+	autotype bool
+	delay    time.Duration
+}
+
+func cmdMenu(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts menuOptions
+	menu := &cobra.Command{
+		Use:   "menu",
+		Short: "select an account in rofi, dmenu or wofi and copy its password",
+		Long: "menu lists the accounts of the given groups (default group if none) in a desktop launcher and copies the " +
+			"password of the selected account or types its auto-type sequence with --autotype. Group keys not provided by " +
+			"SHERLOCK_KEY_<GROUP> are read using the launcher (rofi and wofi) or the terminal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			launcher, err := picker.LookupMenu(opts.backend)
+			if err != nil {
+				return err
+			}
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			queries, keys, err := loadQueries(ctx, sherlock, gids, func(gid string) (string, error) {
+				if key, ok := envGroupKeys[internal.GroupKeyEnv(gid)]; ok {
+					announceUnlock(gid)
+					return key, nil
+				}
+				if launcher.CanReadPassword() {
+					announceUnlock(gid)
+					return launcher.ReadPassword(ctx, gid+" password")
+				}
+				return readGroupKey(gid)
+			})
+			if err != nil {
+				return err
+			}
+			selected, err := launcher.Select(ctx, menuPrompt, queries)
+			if err != nil {
+				return err
+			}
+			gid, _, err := internal.SplitQuery(selected)
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, selected, keys[gid])
+			if err != nil {
+				return err
+			}
+			if opts.autotype {
+				backend, err := autotype.Detect()
+				if err != nil {
+					return err
+				}
+				keystrokes, err := account.AutoTypeSequence(time.Now())
+				if err != nil {
+					return err
+				}
+				// the launcher closed and focus is back on the previous window
+				return autotype.Run(ctx, backend, keystrokes, false, opts.delay)
+			}
+			if err := clipboard.WriteAll(account.Password); err != nil {
+				return err
+			}
+			terminal.Success("password of %q copied to clipboard", selected)
+			return nil
+		},
+	}
+	menu.Flags().StringVarP(&opts.backend, "backend", "b", "rofi", "launcher to select the account with (rofi|dmenu|wofi)")
+	menu.Flags().BoolVarP(&opts.all, "all", "a", false, "list accounts of all registered groups")
+	menu.Flags().BoolVarP(&opts.autotype, "autotype", "t", false, "type the auto-type sequence instead of copying the password")
+	menu.Flags().DurationVarP(&opts.delay, "delay", "d", 300*time.Millisecond, "time to wait for the launcher to close before typing")
+
+	return menu
+}
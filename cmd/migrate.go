@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdMigrate(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate existing vaults",
+		Long:  "migrate existing vaults to harden or upgrade an installation",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	migrate.AddCommand(cmdMigrateKDF(ctx, sherlock))
+	migrate.AddCommand(cmdMigrateReencrypt(ctx, sherlock))
+
+	return migrate
+}
+
+type migrateKDFOptions struct {
+	to          string
+	allowWeaker bool
+}
+
+func cmdMigrateKDF(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts migrateKDFOptions
+	kdf := &cobra.Command{
+		Use:   "kdf",
+		Short: "re-derive the group keys with a different key derivation",
+		Long: "walk all groups, re-derive each group key with the chosen key derivation and its current parameters and rewrite " +
+			"the vaults not using them yet. Migrating to the weaker " + security.KDFSHA256 + " requires --allow-weaker",
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !security.IsKDF(opts.to) {
+				return fmt.Errorf("%w %q (use %s or %s)", security.ErrUnsupportedKDF, opts.to, security.KDFArgon2id, security.KDFSHA256)
+			}
+			if opts.to == security.KDFSHA256 && !opts.allowWeaker {
+				return fmt.Errorf("%w: %s is weaker than %s; use --allow-weaker to migrate to it anyway", internal.ErrInvalidInput, opts.to, security.DefaultKDF)
+			}
+			groups, err := sherlock.ReadRegisteredGroups(ctx)
+			if err != nil {
+				return err
+			}
+			var failed int
+			for _, gid := range groups {
+				// an argon2id vault with weaker parameters is migrated as well
+				matches, err := sherlock.GroupMatches(ctx, gid, opts.to)
+				if err != nil {
+					terminal.Error("(%s) %s", gid, err.Error())
+					failed++
+					continue
+				}
+				if matches {
+					terminal.Info("(%s) already uses %s", gid, opts.to)
+					continue
+				}
+				if err := migrateGroupKDF(ctx, sherlock, gid, opts.to); err != nil {
+					terminal.Error("(%s) %s", gid, err.Error())
+					failed++
+					continue
+				}
+				terminal.Success("(%s) migrated to %s", gid, opts.to)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d group(s) could not be migrated", failed, len(groups))
+			}
+			return nil
+		},
+	}
+	kdf.Flags().StringVar(&opts.to, "to", security.KDFArgon2id, "key derivation to migrate to")
+	kdf.Flags().BoolVar(&opts.allowWeaker, "allow-weaker", false, "allow migrating to a weaker key derivation")
+
+	return kdf
+}
+
+func migrateGroupKDF(ctx context.Context, sherlock *internal.Sherlock, gid, kdf string) error {
+	groupKey, err := readGroupKey(gid)
+	if err != nil {
+		return err
+	}
+	return sherlock.MigrateKDF(ctx, gid, groupKey, kdf)
+}
+
+type migrateReencryptOptions struct {
+	restart  bool
+	outdated bool
+}
+
+func cmdMigrateReencrypt(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts migrateReencryptOptions
+	reencrypt := &cobra.Command{
+		Use:   "re-encrypt",
+		Short: "re-encrypt every group with the current encryption settings",
+		Long: "walk all groups (archived ones included) and re-encrypt each vault with the current vault format and key derivation " +
+			"(" + security.DefaultKDF + " with its current parameters). Every new vault is decrypted and compared before it replaces the old one. " +
+			"An interrupted or partly failed run resumes where it stopped when run again; --restart starts over",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groups, err := sherlock.ReadRegisteredGroups(ctx)
+			if err != nil {
+				return err
+			}
+			var done []string
+			if !opts.restart {
+				if done, err = sherlock.Reencrypted(ctx); err != nil {
+					return err
+				}
+			}
+			if len(done) > 0 {
+				terminal.Info("resuming: %d group(s) already re-encrypted (use --restart to start over)", len(done))
+			}
+			skip := make(map[string]bool, len(done))
+			for _, gid := range done {
+				skip[gid] = true
+			}
+			var failed int
+			for _, gid := range groups {
+				if skip[gid] {
+					continue
+				}
+				if opts.outdated {
+					current, err := sherlock.GroupCurrent(ctx, gid)
+					if err != nil {
+						terminal.Error("(%s) %s", gid, err.Error())
+						failed++
+						continue
+					}
+					if current {
+						terminal.Info("(%s) already current", gid)
+						continue
+					}
+				}
+				groupKey, err := readGroupKey(gid)
+				if err == nil {
+					err = sherlock.Reencrypt(ctx, gid, groupKey)
+				}
+				if err != nil {
+					// an interrupt stops the run; the progress so far is kept
+					if ctx.Err() != nil {
+						return err
+					}
+					terminal.Error("(%s) %s", gid, err.Error())
+					failed++
+					continue
+				}
+				done = append(done, gid)
+				if err := sherlock.SetReencrypted(ctx, done); err != nil {
+					return err
+				}
+				terminal.Success("(%s) re-encrypted", gid)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d group(s) could not be re-encrypted; run sherlock migrate re-encrypt again to resume", failed, len(groups))
+			}
+			return sherlock.SetReencrypted(ctx, nil)
+		},
+	}
+	reencrypt.Flags().BoolVar(&opts.restart, "restart", false, "discard the progress of an interrupted run and start over")
+	reencrypt.Flags().BoolVar(&opts.outdated, "outdated", false, "only re-encrypt vaults not using the current settings")
+
+	return reencrypt
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/backup"
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/storage"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// nukePhrase has to be passed with --confirm-phrase and typed again before
+// anything is wiped
+const nukePhrase = "wipe this device"
+
+type nukeOptions struct {
+	phrase string
+	remote bool
+}
+
+// nukePlan lists everything sherlock nuke wipes. It is collected before
+// anything is wiped since the local files describe where the rest is
+type nukePlan struct {
+	// local are the files and directories overwritten and removed
+	local []string
+	// snapshots are the backup snapshots in local backup directories
+	snapshots []string
+	// remotes are the remote workspaces wiped with --remote
+	remotes map[string]storage.Config
+	// rclone are the rclone backup remotes emptied with --remote
+	rclone []string
+}
+
+func cmdNuke(ctx context.Context) *cobra.Command {
+	var opts nukeOptions
+
+	cmd := &cobra.Command{
+		Use:   "nuke",
+		Short: "wipe all vaults, caches and backups from this device",
+		Long: "nuke overwrites and removes every local vault of all workspaces, the caches of remote workspaces, the config, keys, certificates, " +
+			"keyring and the backup snapshots in local backup directories, and clears the clipboard, e.g. before a border crossing " +
+			"or after losing a device. With --remote the vaults of remote workspaces and rclone backups are deleted as well. " +
+			"What is wiped is listed and the phrase has to be typed again before anything is touched. There is no undo. " +
+			"Overwriting cannot reach copies solid state drives or journaling filesystems keep of old content",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.phrase != nukePhrase {
+				return fmt.Errorf("%w: --confirm-phrase must be %q", internal.ErrInvalidInput, nukePhrase)
+			}
+			plan, err := planNuke(ctx, opts.remote)
+			if err != nil {
+				return err
+			}
+			terminal.Warning("the following will be wiped for good:")
+			for _, p := range append(plan.local, plan.snapshots...) {
+				terminal.Warning("  %s", p)
+			}
+			for name, config := range plan.remotes {
+				terminal.Warning("  workspace %s: all vaults on %s", name, workspaceLocation(config))
+			}
+			for _, remote := range plan.rclone {
+				terminal.Warning("  backups on %s", remote)
+			}
+			typed, err := terminal.ReadLine("type %q to wipe: ", nukePhrase)
+			if err != nil || strings.TrimSpace(typed) != nukePhrase {
+				terminal.Info("nothing has been wiped")
+				return nil
+			}
+			return runNuke(ctx, plan)
+		},
+	}
+	cmd.Flags().StringVar(&opts.phrase, "confirm-phrase", "", fmt.Sprintf("must be %q", nukePhrase))
+	cmd.Flags().BoolVar(&opts.remote, "remote", false, "also delete the vaults of remote workspaces and rclone backups")
+	_ = cmd.MarkFlagRequired("confirm-phrase")
+
+	return cmd
+}
+
+// planNuke collects the local files, backups and remotes of all workspaces.
+// Workspaces which cannot be read are reported and skipped
+func planNuke(ctx context.Context, remote bool) (nukePlan, error) {
+	plan := nukePlan{
+		local:   []string{filepath.Dir(storage.ConfigPath())},
+		remotes: make(map[string]storage.Config),
+	}
+	w, err := storage.LoadWorkspaces()
+	if err != nil {
+		return plan, err
+	}
+	for _, name := range w.Names() {
+		config, ok := w.Workspaces[name]
+		if !ok {
+			if config, err = storage.LoadDefaultConfig(); err != nil {
+				return plan, err
+			}
+		}
+		plan.local = append(plan.local, storage.LocalPaths(config)...)
+		if remote && config.Remote() {
+			plan.remotes[name] = config
+		}
+
+		backend, err := storage.OpenConfig(config)
+		if err != nil {
+			terminal.Warning("workspace %s: backups cannot be looked up: %v", name, err)
+			continue
+		}
+		settings, err := internal.NewSherlock(backend).Config(ctx)
+		if err != nil {
+			terminal.Warning("workspace %s: backups cannot be looked up: %v", name, err)
+			continue
+		}
+		if settings.Backup.Target != "" {
+			names, err := backup.NewDirTarget(afero.NewOsFs(), settings.Backup.Target).List(ctx)
+			if err != nil {
+				terminal.Warning("workspace %s: backups in %s cannot be listed: %v", name, settings.Backup.Target, err)
+			}
+			for _, n := range names {
+				// the directory may hold files which are not sherlock's
+				if _, ok := backup.ParseSnapshotName(n); ok {
+					plan.snapshots = append(plan.snapshots, filepath.Join(settings.Backup.Target, n))
+				}
+			}
+		}
+		if remote && settings.Backup.Remote != "" {
+			plan.rclone = append(plan.rclone, settings.Backup.Remote)
+		}
+	}
+	return plan, nil
+}
+
+// runNuke wipes everything of the plan. Remotes go first since the local files
+// are not needed to reach them. A failure does not stop the rest from being wiped
+func runNuke(ctx context.Context, plan nukePlan) error {
+	var failed int
+	for name, config := range plan.remotes {
+		backend, err := storage.OpenRemote(config)
+		if err == nil {
+			var groups []string
+			if groups, err = internal.NewSherlock(backend).Wipe(ctx); err == nil {
+				terminal.Success("workspace %s: %d group(s) deleted on %s", name, len(groups), workspaceLocation(config))
+				continue
+			}
+		}
+		failed++
+		terminal.Error("workspace %s: %v", name, err)
+	}
+	for _, remote := range plan.rclone {
+		if err := emptyRclone(ctx, remote); err != nil {
+			failed++
+			terminal.Error("backups on %s: %v", remote, err)
+			continue
+		}
+		terminal.Success("backups on %s deleted", remote)
+	}
+	var files int
+	for _, p := range append(plan.snapshots, plan.local...) {
+		n, err := fs.Wipe(p)
+		files += n
+		if err != nil {
+			failed++
+			terminal.Error("%s: %v", p, err)
+		}
+	}
+	terminal.Success("%d file(s) overwritten and removed", files)
+	if err := clipboard.WriteAll(""); err == nil {
+		terminal.Success("clipboard cleared")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d location(s) could not be wiped", errWipeIncomplete, failed)
+	}
+	return nil
+}
+
+// emptyRclone removes all snapshots of the rclone remote. Other files are kept
+func emptyRclone(ctx context.Context, remote string) error {
+	target, err := backup.NewRcloneTarget(remote)
+	if err != nil {
+		return err
+	}
+	names, err := target.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if _, ok := backup.ParseSnapshotName(n); !ok {
+			continue
+		}
+		if err := target.Remove(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
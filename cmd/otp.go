@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/qr"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+type otpOptions struct {
+	verbose bool
+}
+
+func cmdOTP(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts otpOptions
+	otp := &cobra.Command{
+		Use:     "otp",
+		Aliases: []string{"totp"},
+		Short:   "generate a one-time password for an account",
+		Long:    "generate a time-based (TOTP) or counter-based (HOTP) one-time password and copy it to the clipboard. For HOTP the counter is incremented with every generated password",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			code, valid, err := sherlock.GenerateOTP(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			if opts.verbose {
+				terminal.Info(code)
+			}
+			if valid > 0 {
+				terminal.Info("one-time password valid for %s", valid)
+			}
+			return clipboard.WriteAll(code)
+		},
+	}
+	otp.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "print one-time password to cli")
+	otp.AddCommand(cmdOTPImport(ctx, sherlock))
+	otp.AddCommand(cmdOTPExport(ctx, sherlock))
+
+	return otp
+}
+
+type otpImportOptions struct {
+	qrImage    string
+	onConflict string
+}
+
+func cmdOTPImport(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts otpImportOptions
+	imp := &cobra.Command{
+		Use:   "import",
+		Short: "import an otpauth:// uri for an account",
+		Long:  "set the one-time password secret and parameters (algorithm, digits, period) of an account from an otpauth:// uri or a QR code image",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			var uri string
+			if opts.qrImage != "" {
+				if uri, err = qr.Decode(opts.qrImage); err != nil {
+					return err
+				}
+			} else {
+				// the uri contains the secret and is therefore read like a password
+				if uri, err = terminal.ReadPassword("(%s) otpauth uri: ", args[0]); err != nil {
+					return err
+				}
+			}
+			otp, err := internal.ParseOTPURI(uri)
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			if account.OTP != nil {
+				strategy, err := resolveOTPConflict(args[0], account.OTP, otp, opts.onConflict)
+				if err != nil {
+					return err
+				}
+				if strategy == internal.ConflictSkip {
+					terminal.Info("account already has an otp secret - skipped")
+					return nil
+				}
+			}
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccOTP(otp)); err != nil {
+				return err
+			}
+			terminal.Info("account otp secret imported")
+			return nil
+		},
+	}
+	imp.Flags().StringVar(&opts.qrImage, "qr", "", "read the otpauth:// uri from a QR code image (png, jpeg or gif)")
+	imp.Flags().StringVar(&opts.onConflict, "on-conflict", internal.ConflictPrompt, "how to resolve an existing otp secret (skip|overwrite|prompt)")
+
+	return imp
+}
+
+// resolveOTPConflict decides whether the otp secret of an account is replaced
+// by an imported one. Renaming does not apply to otp secrets
+func resolveOTPConflict(query string, existing, imported *internal.OTP, strategy string) (string, error) {
+	if err := internal.ValidConflict(strategy); err != nil {
+		return "", err
+	}
+	switch strategy {
+	case internal.ConflictRename:
+		return "", fmt.Errorf("%w: otp secrets cannot be renamed (use skip, overwrite or prompt)", internal.ErrInvalidInput)
+	case internal.ConflictPrompt:
+		params := func(o *internal.OTP) string {
+			algorithm := o.Algorithm
+			if algorithm == "" {
+				algorithm = "SHA1"
+			}
+			return fmt.Sprintf("%s %s, %d digits, %ds", o.Type, algorithm, o.Digits, o.Period)
+		}
+		same := "differs"
+		if existing.Secret == imported.Secret {
+			same = "same"
+		}
+		terminal.ToTable([]string{"Field", "Existing", "Imported"}, [][]string{
+			{"parameters", params(existing), params(imported)},
+			{"secret", same, same},
+		})
+		strategy, _, err := askConflict(query+" otp", "[s]kip, [o]verwrite: ", map[string]string{
+			"s": internal.ConflictSkip,
+			"o": internal.ConflictOverwrite,
+		})
+		return strategy, err
+	}
+	return strategy, nil
+}
+
+type otpExportOptions struct {
+	uri bool
+}
+
+func cmdOTPExport(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts otpExportOptions
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "export the otp secret of an account as QR code",
+		Long:  "render the otpauth:// uri of an account as QR code in the terminal to enroll an authenticator app",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			if account.OTP == nil {
+				return internal.ErrNoOTP
+			}
+			label := account.Username
+			if label == "" {
+				label = account.Name
+			}
+			uri := account.OTP.URI(account.Name, label)
+			if opts.uri {
+				fmt.Println(uri)
+				return nil
+			}
+			return qr.Render(os.Stdout, uri)
+		},
+	}
+	export.Flags().BoolVar(&opts.uri, "uri", false, "print the otpauth:// uri instead of the QR code")
+
+	return export
+}
+
+type updateOTPOptions struct {
+	kind      string
+	counter   uint64
+	algorithm string
+	digits    int
+	period    int
+	steam     bool
+}
+
+func cmdUpdateAccOTP(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts updateOTPOptions
+	otp := &cobra.Command{
+		Use:   "otp",
+		Short: "set account one-time password secret",
+		Long:  "allows to set/update the base32 encoded secret used to generate one-time passwords for an account",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			secret, err := terminal.ReadPassword("(%s) otp secret: ", args[0])
+			if err != nil {
+				return err
+			}
+			otpOpts := []internal.OTPOption{
+				internal.OTPCounter(opts.counter),
+				internal.OTPAlgorithm(opts.algorithm),
+				internal.OTPDigits(opts.digits),
+				internal.OTPPeriod(opts.period),
+			}
+			if opts.steam {
+				otpOpts = append(otpOpts, internal.OTPSteam())
+			}
+			otp, err := internal.NewOTP(opts.kind, secret, otpOpts...)
+			if err != nil {
+				return err
+			}
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccOTP(otp)); err != nil {
+				return err
+			}
+			terminal.Info("account otp secret updated")
+			return nil
+		},
+	}
+	otp.Flags().StringVarP(&opts.kind, "type", "t", internal.OTPTypeTOTP, "one-time password algorithm (totp|hotp)")
+	otp.Flags().Uint64Var(&opts.counter, "counter", 0, "initial counter for hotp")
+	otp.Flags().StringVar(&opts.algorithm, "algorithm", internal.OTPAlgorithmSHA1, "hmac algorithm (SHA1|SHA256|SHA512)")
+	otp.Flags().IntVar(&opts.digits, "digits", 6, "number of digits (6-8)")
+	otp.Flags().IntVar(&opts.period, "period", 30, "seconds a totp is valid")
+	otp.Flags().BoolVar(&opts.steam, "steam", false, "generate Steam Guard style passwords")
+
+	return otp
+}
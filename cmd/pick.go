@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/picker"
+	"github.com/KonstantinGasser/sherlock/plugin"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+type pickOptions struct {
+	all    bool
+	print  bool
+	field  string
+	plugin string
+}
+
+func cmdPick(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts pickOptions
+	pick := &cobra.Command{
+		Use:   "pick",
+		Short: "pick an account with a fuzzy finder and copy its password",
+		Long: "pick lists the accounts of the given groups (default group if none) in fzf or, if fzf is not installed, " +
+			"a built-in fuzzy finder and copies the password (or any other field using --field) of the selected account",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			queries, keys, err := loadQueries(ctx, sherlock, gids, readGroupKey)
+			if err != nil {
+				return err
+			}
+			var selected string
+			if opts.plugin != "" {
+				p, err := plugin.Lookup(os.Getenv("PATH"), opts.plugin)
+				if err != nil {
+					return err
+				}
+				if selected, err = p.Pick(ctx, "sherlock> ", queries); err != nil {
+					return err
+				}
+				if selected == "" {
+					return picker.ErrNoSelection
+				}
+			} else if path, err := exec.LookPath("fzf"); err == nil {
+				selected, err = picker.External(ctx, path, []string{"--prompt", "sherlock> "}, queries)
+				if err != nil {
+					return err
+				}
+			} else {
+				if selected, err = picker.Prompt(queries, os.Stdin, os.Stderr); err != nil {
+					return err
+				}
+			}
+			gid, _, err := internal.SplitQuery(selected)
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, selected, keys[gid])
+			if err != nil {
+				return err
+			}
+			value, err := sherlock.AccountField(ctx, selected, keys[gid], *account, opts.field)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, opts.field)
+			}
+			if opts.print {
+				fmt.Println(value)
+				return nil
+			}
+			if err := clipboard.WriteAll(value); err != nil {
+				return err
+			}
+			terminal.Success("%s of %q copied to clipboard", opts.field, selected)
+			return nil
+		},
+	}
+	pick.Flags().BoolVarP(&opts.all, "all", "a", false, "pick from all registered groups")
+	pick.Flags().BoolVarP(&opts.print, "print", "p", false, "print the value instead of copying it")
+	pick.Flags().StringVarP(&opts.field, "field", "f", internal.FieldPassword, "account field to copy (password|username|url|totp|note)")
+	pick.Flags().StringVar(&opts.plugin, "plugin", "", "select with a picker plugin instead of fzf")
+
+	return pick
+}
+
+// pickGroups resolves the groups (or their aliases) to pick accounts from
+func pickGroups(ctx context.Context, sherlock *internal.Sherlock, args []string, all bool) ([]string, error) {
+	if all {
+		return sherlock.ActiveGroups(ctx)
+	}
+	if len(args) > 0 {
+		config, err := sherlock.Config(ctx)
+		if err != nil {
+			return nil, err
+		}
+		gids := make([]string, len(args))
+		for i, arg := range args {
+			if gids[i], err = sherlock.ResolveGroup(ctx, config, arg); err != nil {
+				return nil, err
+			}
+		}
+		return gids, nil
+	}
+	return []string{"default"}, nil
+}
+
+// loadQueries loads the groups and returns the queries (group@account) of all
+// their accounts, favorites first, together with the group keys read for them using readKey
+func loadQueries(ctx context.Context, sherlock *internal.Sherlock, gids []string, readKey func(string) (string, error)) ([]string, map[string]string, error) {
+	var groups []*internal.Group
+	keys := make(map[string]string, len(gids))
+	for _, gid := range gids {
+		groupKey, err := readKey(gid)
+		if err != nil {
+			return nil, nil, err
+		}
+		group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", gid, err)
+		}
+		keys[gid] = groupKey
+		groups = append(groups, group)
+	}
+	return internal.Queries(groups...), keys, nil
+}
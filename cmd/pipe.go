@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// defaultPipeStdin is written to the command with --stdin-only if no
+// --stdin is given, e.g. for tools prompting for a password
+const defaultPipeStdin = "{password}\n"
+
+type pipeOptions struct {
+	stdin     string
+	stdinOnly bool
+}
+
+func cmdPipe(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts pipeOptions
+	pipe := &cobra.Command{
+		Use:   "pipe <group@account> -- <command> [args...]",
+		Short: "run a command with account values in its arguments or stdin",
+		Long: "run a command and replace the placeholders {password}, {username}, {url}, {note} and {totp} (TOTP only) in its " +
+			"arguments with the fields of the account. With --stdin the expanded text is written to the stdin of the command instead of reading " +
+			"the terminal. Arguments are visible to every user of the machine (e.g. in /proc/<pid>/cmdline and ps); " +
+			"--stdin-only refuses placeholders in the arguments and passes the values through stdin only " +
+			"=> sherlock pipe --stdin-only work@db -- psql -h db.internal -W",
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 1 {
+				return fmt.Errorf("%w: separate the command with -- (sherlock pipe group@account -- command)", internal.ErrInvalidInput)
+			}
+			command := args[1:]
+			var placeholders bool
+			for _, arg := range command {
+				placeholders = placeholders || internal.HasFields(arg)
+			}
+			if opts.stdinOnly {
+				if placeholders {
+					return fmt.Errorf("%w: --stdin-only passes values through stdin only, remove the placeholders from the arguments", internal.ErrInvalidInput)
+				}
+				if !cmd.Flags().Changed("stdin") {
+					opts.stdin = defaultPipeStdin
+				}
+			}
+			if !placeholders && opts.stdin == "" {
+				return fmt.Errorf("%w: neither the arguments nor --stdin hold a placeholder", internal.ErrInvalidInput)
+			}
+
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			argv := make([]string, len(command))
+			for i, arg := range command {
+				if argv[i], err = account.Expand(arg); err != nil {
+					return err
+				}
+			}
+			var stdin io.Reader = os.Stdin
+			if opts.stdin != "" {
+				text, err := account.Expand(unescapePipeStdin(opts.stdin))
+				if err != nil {
+					return err
+				}
+				stdin = strings.NewReader(text)
+			}
+			if placeholders {
+				terminal.Warning("account values in arguments are visible to other processes; prefer --stdin-only")
+			}
+			return runPiped(ctx, argv, stdin)
+		},
+	}
+	pipe.Flags().StringVar(&opts.stdin, "stdin", "", `text written to the stdin of the command, e.g. "{password}\n"`)
+	pipe.Flags().BoolVar(&opts.stdinOnly, "stdin-only", false, `pass account values through stdin only (default stdin "{password}\n")`)
+
+	return pipe
+}
+
+// runPiped runs the command with the terminal as stdout and stderr. The
+// command line is never printed since it may hold account values
+func runPiped(ctx context.Context, argv []string, stdin io.Reader) error {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() > 0 {
+			return commandExit{status: exit.ExitCode()}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// unescapePipeStdin turns the escapes \n and \t typed on the command line
+// into new lines and tabs
+func unescapePipeStdin(text string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(text)
+}
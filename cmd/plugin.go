@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/cloud"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/plugin"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type pluginImportOptions struct {
+	group      string
+	insecure   bool
+	onConflict string
+}
+
+func cmdPlugin(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	p := &cobra.Command{
+		Use:   "plugin",
+		Short: "use sherlock-<name> plugins found on the PATH",
+		Long: "plugins are executables named sherlock-<name> on the PATH. They are run as sherlock <name> [args] " +
+			"and can serve as importer (plugin import), picker (pick --plugin) or sync target (plugin sync)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	p.AddCommand(cmdPluginList())
+	p.AddCommand(cmdPluginImport(ctx, sherlock))
+	p.AddCommand(cmdPluginSync(ctx, sherlock))
+
+	return p
+}
+
+func cmdPluginList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the plugins found on the PATH",
+		Long:  "list the sherlock-<name> executables found on the PATH. Of plugins with the same name the first one on the PATH is used",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins := plugin.Discover(os.Getenv("PATH"))
+			if len(plugins) == 0 {
+				terminal.Info("no plugins found on the PATH")
+				return nil
+			}
+			rows := make([][]string, 0, len(plugins))
+			for _, p := range plugins {
+				rows = append(rows, []string{p.Name, p.Path})
+			}
+			terminal.ToTable([]string{"Plugin", "Path"}, rows)
+			return nil
+		},
+	}
+}
+
+func cmdPluginImport(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts pluginImportOptions
+	imp := &cobra.Command{
+		Use:   "import",
+		Short: "import accounts with an importer plugin",
+		Long:  "import the accounts returned by a plugin into a group. Arguments after -- are passed to the plugin => sherlock plugin import csv -- --file export.csv",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := plugin.Lookup(os.Getenv("PATH"), args[0])
+			if err != nil {
+				return err
+			}
+			progress := newProgress(cmd, "importing with "+p.Name, 1)
+			progress.Start(p.Name)
+			imported, err := p.Import(ctx, args[1:])
+			progress.Done(p.Name, err)
+			progress.Finish()
+			if err != nil {
+				return err
+			}
+			if len(imported) == 0 {
+				terminal.Info("%s returned no accounts", p.Name)
+				return nil
+			}
+			resolve, err := conflictResolver(opts.group, opts.onConflict)
+			if err != nil {
+				return err
+			}
+			groupKey, err := readGroupKey(opts.group)
+			if err != nil {
+				return err
+			}
+			accounts := make([]*internal.Account, 0, len(imported))
+			for _, a := range imported {
+				query := opts.group + "@" + a.Name
+				account, err := internal.NewAccount(query, a.Password, a.Tag, opts.insecure,
+					internal.WithUsername(a.Username), internal.WithURL(a.URL), internal.WithNote(a.Note))
+				if err != nil {
+					return fmt.Errorf("%s: %w", query, err)
+				}
+				accounts = append(accounts, account)
+			}
+			results, err := sherlock.ImportAccounts(ctx, opts.group, groupKey, accounts, resolve)
+			if err != nil {
+				return err
+			}
+			printImported(opts.group, results)
+			return nil
+		},
+	}
+	imp.Flags().StringVarP(&opts.group, "group", "g", "default", "group to import the accounts into")
+	imp.Flags().BoolVar(&opts.insecure, "insecure", false, "accept passwords failing the password policy")
+	imp.Flags().StringVar(&opts.onConflict, "on-conflict", internal.ConflictPrompt, "how to resolve accounts which already exist (skip|overwrite|rename|prompt)")
+
+	return imp
+}
+
+func cmdPluginSync(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var name string
+	sync := &cobra.Command{
+		Use:   "sync",
+		Short: "sync passwords with a sync target plugin",
+		Long: "push passwords to or pull them from a plugin answering get and put requests. Accounts are mapped to " +
+			"{prefix}{group}/{account} and can be selected by --tag",
+	}
+	sync.PersistentFlags().StringVar(&name, "plugin", "", "name of the sync target plugin")
+
+	return cmdCloud(ctx, sherlock, sync, "", func(prefix string) (cloud.Provider, error) {
+		if name == "" {
+			return nil, fmt.Errorf("%w: --plugin is required", internal.ErrInvalidInput)
+		}
+		p, err := plugin.Lookup(os.Getenv("PATH"), name)
+		if err != nil {
+			return nil, err
+		}
+		return &plugin.Provider{Plugin: p, Prefix: prefix}, nil
+	})
+}
+
+// runPlugin runs the plugin sherlock-<name> if the first argument is no
+// sherlock command. It reports whether a plugin was run
+func runPlugin(ctx context.Context, root *cobra.Command, args []string) (int, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return ExitOK, false
+	}
+	if cmd, _, err := root.Find(args); err == nil && cmd != root {
+		return ExitOK, false
+	}
+	p, err := plugin.Lookup(os.Getenv("PATH"), args[0])
+	if err != nil {
+		return ExitOK, false
+	}
+	code, err := p.Run(ctx, args[1:], os.Stdout, os.Stderr)
+	if err != nil {
+		return reportError(err, outputText), true
+	}
+	return code, true
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdPreset(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	preset := &cobra.Command{
+		Use:   "preset",
+		Short: "manage password generator presets for site rules",
+		Long: "a preset stores the password rules of a site (e.g. legacy-bank: at most 12 characters, no symbols). " +
+			"Accounts added with --preset keep it so generating a new password (update password --generate, rotate) follows the rules again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	preset.AddCommand(cmdPresetList(ctx, sherlock))
+	preset.AddCommand(cmdPresetSet(ctx, sherlock))
+	preset.AddCommand(cmdPresetRm(ctx, sherlock))
+
+	return preset
+}
+
+func cmdPresetList(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the generator presets",
+		Long:  "list the generator presets with the entropy of the passwords they generate",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0, len(config.Presets))
+			for name := range config.Presets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			rows := make([][]string, len(names))
+			for i, name := range names {
+				p := config.Presets[name]
+				symbols := "default"
+				switch {
+				case p.NoSymbols:
+					symbols = "none"
+				case p.Symbols != "":
+					symbols = p.Symbols
+				}
+				mode := "random"
+				if p.Pronounceable {
+					mode = "pronounceable"
+				}
+				rows[i] = []string{name, mode, strconv.Itoa(p.Length), symbols, fmt.Sprintf("%.0f bits", p.Entropy())}
+			}
+			terminal.ToTable([]string{"Preset", "Mode", "Length", "Symbols", "Entropy"}, rows)
+			return nil
+		},
+	}
+}
+
+type presetSetOptions struct {
+	length    int
+	noSymbols bool
+	symbols   string
+	insecure  bool
+	// pronounceable generates syllable-based passwords
+	pronounceable bool
+}
+
+func cmdPresetSet(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts presetSetOptions
+	set := &cobra.Command{
+		Use:   "set <name>",
+		Short: "create or change a generator preset",
+		Long: "create or change the generator preset => sherlock preset set legacy-bank --length 12 --no-symbols. " +
+			"Presets generating passwords weaker than the password policy are refused unless --insecure is set",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p := internal.Preset{Length: opts.length, NoSymbols: opts.noSymbols, Symbols: opts.symbols, Pronounceable: opts.pronounceable}
+			if err := sherlock.SetPreset(ctx, args[0], p, opts.insecure); err != nil {
+				return err
+			}
+			terminal.Success("preset %s set (%.0f bits of entropy)", args[0], p.Entropy())
+			return nil
+		},
+	}
+	set.Flags().IntVarP(&opts.length, "length", "l", defaultGenerateLength, "length of the generated passwords")
+	set.Flags().BoolVar(&opts.noSymbols, "no-symbols", false, "generate passwords without symbols")
+	set.Flags().StringVar(&opts.symbols, "symbols", "", "only use these symbols instead of the default ones")
+	set.Flags().BoolVar(&opts.pronounceable, "pronounceable", false, "generate syllable-based passwords which are easier to type")
+	set.Flags().BoolVarP(&opts.insecure, "insecure", "i", false, "allow a preset weaker than the password policy")
+
+	return set
+}
+
+func cmdPresetRm(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>",
+		Short: "remove a generator preset",
+		Long:  "remove the generator preset. Accounts using it fall back to the default generator",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.RemovePreset(ctx, args[0]); err != nil {
+				return err
+			}
+			terminal.Success("preset %s removed", args[0])
+			return nil
+		},
+	}
+}
+
+// lookupPreset returns the named preset
+func lookupPreset(ctx context.Context, sherlock *internal.Sherlock, name string) (internal.Preset, error) {
+	config, err := sherlock.Config(ctx)
+	if err != nil {
+		return internal.Preset{}, err
+	}
+	return config.Preset(name)
+}
+
+// generateFor generates a new password for the account with its preset unless
+// the length is set explicitly. Pronounceable switches the generator to
+// pronounceable passwords. It returns the preset the password was generated
+// with and whether it is the one of the account
+func generateFor(ctx context.Context, sherlock *internal.Sherlock, account *internal.Account, length int, lengthSet, pronounceable bool) (string, internal.Preset, bool, error) {
+	p := internal.Preset{Length: length, Pronounceable: pronounceable}
+	var fromAccount bool
+	if !lengthSet {
+		config, err := sherlock.Config(ctx)
+		if err != nil {
+			return "", p, false, err
+		}
+		if preset, ok := config.AccountPreset(account); ok {
+			p, fromAccount = preset, true
+			p.Pronounceable = p.Pronounceable || pronounceable
+		} else if account.Preset != "" {
+			terminal.Warning("preset %s of the account no longer exists, using the default generator", account.Preset)
+		}
+	}
+	password, err := internal.GeneratePassword(p)
+	return password, p, fromAccount, err
+}
+
+// optAccPreset sets the preset of an account after checking it exists. An
+// empty name removes the preset from the account
+func optAccPreset(ctx context.Context, sherlock *internal.Sherlock, name string) internal.StateOption {
+	return func(g *internal.Group, acc string) error {
+		if name != "" {
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			if _, err := config.Preset(name); err != nil {
+				return err
+			}
+		}
+		return internal.OptAccPreset(name)(g, acc)
+	}
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// newProgress starts reporting the progress of an operation over total items.
+// With --output json progress events are written instead of a progress bar
+func newProgress(cmd *cobra.Command, operation string, total int) *terminal.Progress {
+	output, _ := cmd.Flags().GetString("output")
+	return terminal.NewProgress(operation, total, output == outputJSON)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/qr"
+	"github.com/spf13/cobra"
+)
+
+// wifiTag is the tag of accounts holding the password of a WiFi network
+const wifiTag = "wifi"
+
+func cmdQR(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	qrCmd := &cobra.Command{
+		Use:   "qr",
+		Short: "render account data as QR code",
+		Long:  "render account data as QR code in the terminal to scan it with a phone",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	qrCmd.AddCommand(cmdQRWiFi(ctx, sherlock))
+
+	return qrCmd
+}
+
+type qrWiFiOptions struct {
+	ssid     string
+	security string
+	hidden   bool
+}
+
+func cmdQRWiFi(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts qrWiFiOptions
+	wifi := &cobra.Command{
+		Use:   "wifi",
+		Short: "render a QR code to join a WiFi network",
+		Long:  "render the WiFi network of an account tagged wifi as QR code phones can scan to join it. The account name is used as SSID unless --ssid is given",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			security := strings.ToUpper(opts.security)
+			if strings.EqualFold(opts.security, qr.WiFiNoPass) {
+				security = qr.WiFiNoPass
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			if account.Tag != wifiTag {
+				return fmt.Errorf("%w: account is not tagged %q (use sherlock update tag)", internal.ErrInvalidInput, wifiTag)
+			}
+			ssid := opts.ssid
+			if ssid == "" {
+				ssid = account.Name
+			}
+			payload, err := qr.WiFi(ssid, account.Password, security, opts.hidden)
+			if err != nil {
+				return err
+			}
+			return qr.Render(os.Stdout, payload)
+		},
+	}
+	wifi.Flags().StringVar(&opts.ssid, "ssid", "", "network name if it differs from the account name")
+	wifi.Flags().StringVar(&opts.security, "security", qr.WiFiWPA, "network security (WPA|WEP|nopass)")
+	wifi.Flags().BoolVar(&opts.hidden, "hidden", false, "the network does not broadcast its SSID")
+
+	return wifi
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdRecovery(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	recovery := &cobra.Command{
+		Use:   "recovery",
+		Short: "store and use one-time recovery codes",
+		Long:  "store the one-time recovery (backup) codes of an account and hand them out one by one, keeping track of the used codes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	recovery.AddCommand(cmdRecoverySet(ctx, sherlock))
+	recovery.AddCommand(cmdRecoveryUse(ctx, sherlock))
+	recovery.AddCommand(cmdRecoveryStatus(ctx, sherlock))
+
+	return recovery
+}
+
+func cmdRecoverySet(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set",
+		Short: "replace the recovery codes of an account",
+		Long:  "replace the recovery codes of an account. Codes are separated by white space, commas or new lines; no codes remove the recovery codes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			lines, err := terminal.ReadLines("(%s) recovery codes (finish with an empty line):", args[0])
+			if err != nil {
+				return err
+			}
+			codes := internal.ParseRecoveryCodes(strings.Join(lines, "\n"))
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccRecoveryCodes(codes)); err != nil {
+				return err
+			}
+			terminal.Success("%d recovery codes stored", len(codes))
+			return nil
+		},
+	}
+}
+
+func cmdRecoveryUse(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use",
+		Short: "reveal the next unused recovery code",
+		Long:  "reveal the next unused recovery code of an account and mark it as used",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			code, left, err := sherlock.UseRecoveryCode(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			fmt.Println(code)
+			if left == 0 {
+				terminal.Warning("that was the last recovery code, generate new ones and store them with sherlock recovery set")
+				return nil
+			}
+			terminal.Info("%d recovery codes left", left)
+			return nil
+		},
+	}
+}
+
+func cmdRecoveryStatus(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "show how many recovery codes are left",
+		Long:  "show the number of unused recovery codes and when the used ones have been handed out",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			if len(account.RecoveryCodes) == 0 {
+				terminal.Info("%s has no recovery codes", args[0])
+				return nil
+			}
+			rows := make([][]string, 0, len(account.RecoveryCodes))
+			for i, c := range account.RecoveryCodes {
+				used := "-"
+				if c.UsedOn != nil {
+					used = c.UsedOn.Local().Format(eventTimeLayout)
+				}
+				rows = append(rows, []string{fmt.Sprint(i + 1), used})
+			}
+			terminal.ToTable([]string{"#", "Used On"}, rows)
+			terminal.Info("%d of %d recovery codes left", account.RecoveryCodesLeft(), len(account.RecoveryCodes))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+)
+
+// refResolver resolves sherlock:// references. Every group is loaded (and
+// its key asked for) only once no matter how often it is referenced
+type refResolver struct {
+	sherlock *internal.Sherlock
+	groups   map[string]*internal.Group
+}
+
+func newRefResolver(sherlock *internal.Sherlock) *refResolver {
+	return &refResolver{
+		sherlock: sherlock,
+		groups:   make(map[string]*internal.Group),
+	}
+}
+
+// resolve returns the account value the reference points to
+func (r *refResolver) resolve(ctx context.Context, value string) (string, error) {
+	ref, err := internal.ParseReference(value)
+	if err != nil {
+		return "", err
+	}
+	group, ok := r.groups[ref.GID]
+	if !ok {
+		groupKey, err := readGroupKey(ref.GID)
+		if err != nil {
+			return "", err
+		}
+		if group, err = r.sherlock.LoadGroup(ctx, ref.GID, groupKey); err != nil {
+			return "", fmt.Errorf("%s: %w", ref.GID, err)
+		}
+		r.groups[ref.GID] = group
+	}
+	return r.sherlock.ResolveReference(ctx, *group, ref, readGroupKey)
+}
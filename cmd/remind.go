@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type remindOptions struct {
+	all    bool
+	maxAge int
+	notify bool
+}
+
+func cmdRemind(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts remindOptions
+	remind := &cobra.Command{
+		Use:   "remind",
+		Short: "list passwords due for a change",
+		Long:  "list the accounts with a password older than --max-age days. With --notify a desktop notification is shown, which is handy when running remind from cron or a login script",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.maxAge <= 0 {
+				return fmt.Errorf("%w: --max-age must be a positive number of days", internal.ErrInvalidInput)
+			}
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			var groups []*internal.Group
+			for _, gid := range gids {
+				groupKey, err := readGroupKey(gid)
+				if err != nil {
+					return err
+				}
+				group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+				if err != nil {
+					return fmt.Errorf("%s: %w", gid, err)
+				}
+				groups = append(groups, group)
+			}
+			now := time.Now()
+			reminders := internal.Reminders(now, time.Duration(opts.maxAge)*24*time.Hour, groups...)
+			if len(reminders) == 0 {
+				terminal.Success("all passwords are younger than %d days", opts.maxAge)
+				return nil
+			}
+			rows := make([][]string, 0, len(reminders))
+			for _, r := range reminders {
+				days := int(r.Age(now).Hours() / 24)
+				rows = append(rows, []string{r.GID, r.Account, r.ChangedOn.Format(eventTimeLayout), fmt.Sprintf("%d days", days)})
+			}
+			terminal.ToTable([]string{"Group", "Account", "Password Changed", "Age"}, rows)
+			terminal.Warning("%d passwords are older than %d days", len(reminders), opts.maxAge)
+			if opts.notify {
+				sendNotification(ctx, "passwords expired",
+					fmt.Sprintf("%d passwords are older than %d days (run sherlock remind)", len(reminders), opts.maxAge))
+			}
+			return nil
+		},
+	}
+	remind.Flags().BoolVarP(&opts.all, "all", "a", false, "check all registered groups")
+	remind.Flags().IntVar(&opts.maxAge, "max-age", 90, "maximum accepted password age in days")
+	remind.Flags().BoolVar(&opts.notify, "notify", false, "show a desktop notification if passwords are due")
+
+	return remind
+}
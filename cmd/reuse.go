@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type reuseOptions struct {
+	dot string
+}
+
+func cmdReuse(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts reuseOptions
+	reuse := &cobra.Command{
+		Use:   "reuse [<group>...]",
+		Short: "find accounts sharing a password",
+		Long: "compare the passwords of the accounts of the groups (all groups if none is given) by their hash and list " +
+			"the clusters of accounts sharing a password, reuse across groups first. Nothing leaves the device; " +
+			"--dot writes the clusters as Graphviz graph (dot -Tsvg reuse.dot > reuse.svg)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := pickGroups(ctx, sherlock, args, len(args) == 0)
+			if err != nil {
+				return err
+			}
+			groups, err := loadGroups(ctx, sherlock, gids)
+			if err != nil {
+				return err
+			}
+			clusters := internal.FindReuse(groups...)
+			if opts.dot != "" {
+				f, err := os.OpenFile(opts.dot, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if err := internal.WriteReuseDOT(f, clusters); err != nil {
+					return err
+				}
+			}
+			if len(clusters) == 0 {
+				terminal.Success("no password is shared by two accounts of %d groups", len(groups))
+				return nil
+			}
+
+			var accounts, crossGroup int
+			rows := make([][]string, 0, len(clusters))
+			for i, c := range clusters {
+				accounts += len(c.Accounts)
+				scope := "1 group"
+				if c.CrossGroup() {
+					crossGroup++
+					scope = strconv.Itoa(c.Groups) + " groups"
+				}
+				rows = append(rows, []string{strconv.Itoa(i + 1), scope, strconv.Itoa(len(c.Accounts)), strings.Join(c.Accounts, "\n")})
+			}
+			terminal.Warning("reused passwords: %d shared by %d accounts (%d across groups)", len(clusters), accounts, crossGroup)
+			terminal.ToTable([]string{"#", "Reused In", "Accounts", "Group@Account"}, rows)
+			if opts.dot != "" {
+				terminal.Info("graph written to %s", opts.dot)
+			}
+			return nil
+		},
+	}
+	reuse.Flags().StringVar(&opts.dot, "dot", "", "write the clusters as Graphviz DOT graph to the file")
+
+	return reuse
+}
@@ -2,18 +2,54 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/KonstantinGasser/sherlock/internal"
 	"github.com/spf13/cobra"
 )
 
-const (
-	skippSetupFor = "setup"
-)
+// skippSetupFor are the commands (by command path) which can run before sherlock
+// is set-up. A new device can be initialized from a remote or a peer, a snapshot restored,
+// an emergency kit opened, the cache inspected, workspaces switched, compliance reports verified,
+// the device wiped, device certificates managed and sherlock itself updated.
+// Completions must not fail or print warnings on an unverified vault
+var skippSetupFor = map[string]bool{
+	"sherlock setup":                              true,
+	"sherlock init":                               true,
+	"sherlock sync peer":                          true,
+	"sherlock backup restore":                     true,
+	"sherlock emergency-kit open":                 true,
+	"sherlock self-update":                        true,
+	"sherlock gen-docs":                           true,
+	"sherlock cache status":                       true,
+	"sherlock cache clear":                        true,
+	"sherlock workspace list":                     true,
+	"sherlock workspace use":                      true,
+	"sherlock workspace add":                      true,
+	"sherlock compliance verify":                  true,
+	"sherlock nuke":                               true,
+	"sherlock certs init":                         true,
+	"sherlock certs issue":                        true,
+	"sherlock certs show":                         true,
+	"sherlock " + cobra.ShellCompRequestCmd:       true,
+	"sherlock " + cobra.ShellCompNoDescRequestCmd: true,
+}
+
+type rootOptions struct {
+	output    string
+	ephemeral bool
+}
 
-func RootCmd(sherlock *internal.Sherlock) *cobra.Command {
+func RootCmd(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts rootOptions
 
-	ctx := context.Background()
+	loadEnvGroupKeys()
+	sherlock.BeforeWrite(preWriteHooks(sherlock))
+	sherlock.Observe(postWriteHooks(sherlock))
+	sherlock.Observe(notifyWebhook(sherlock))
+	// vault signatures (if enabled) are verified when a group is loaded
+	sherlock.OnTampered(warnTampered)
 
 	root := &cobra.Command{
 		Use:           "sherlock",
@@ -24,22 +60,108 @@ func RootCmd(sherlock *internal.Sherlock) *cobra.Command {
 		// ensure that sherlock is properly set-up. This means that the default group
 		// exists and that it holds an encrypted .vault file. "sherlock setup" is excluded from this check
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if cmd.Use == skippSetupFor {
+			if opts.output != outputText && opts.output != outputJSON {
+				return fmt.Errorf("%w: unknown output format %q (use %s or %s)", internal.ErrInvalidInput, opts.output, outputText, outputJSON)
+			}
+			if opts.ephemeral {
+				if err := useEphemeral(ctx, sherlock, cmd); err != nil {
+					return err
+				}
+			}
+			if skippSetupFor[cmd.CommandPath()] {
 				return nil
 			}
-			return sherlock.IsSetUp()
+			if err := sherlock.IsSetUp(ctx); err != nil {
+				return err
+			}
+			if err := useKeyring(ctx, sherlock); err != nil {
+				return err
+			}
+			return resolveGroupNames(ctx, sherlock, cmd, args)
 		},
-		Run: func(cmd *cobra.Command, args []string) {
-			_ = cmd.Help()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
 		},
 	}
+	root.PersistentFlags().StringVarP(&opts.output, "output", "o", outputText, "output format of errors and progress (text|json)")
+	root.PersistentFlags().BoolVar(&opts.ephemeral, "ephemeral", false, "keep vaults in memory only; nothing is written to disk")
 
 	root.AddCommand(cmdSetup(ctx, sherlock))
+	root.AddCommand(cmdInit(ctx, sherlock))
 	root.AddCommand(cmdAdd(ctx, sherlock))
 	root.AddCommand(cmdDel(ctx, sherlock))
+	root.AddCommand(cmdUndo(ctx, sherlock))
+	root.AddCommand(cmdGroup(ctx, sherlock))
+	root.AddCommand(cmdKeyring(ctx, sherlock))
 	root.AddCommand(cmdList(ctx, sherlock))
 	root.AddCommand(cmdGet(ctx, sherlock))
+	root.AddCommand(cmdFav(ctx, sherlock))
+	root.AddCommand(cmdShow(ctx, sherlock))
+	root.AddCommand(cmdLaunch(ctx, sherlock))
+	root.AddCommand(cmdPipe(ctx, sherlock))
+	root.AddCommand(cmdToken(ctx, sherlock))
+	root.AddCommand(cmdSearch(ctx, sherlock))
+	root.AddCommand(cmdPick(ctx, sherlock))
+	root.AddCommand(cmdMenu(ctx, sherlock))
+	root.AddCommand(cmdTmux(ctx, sherlock))
+	root.AddCommand(cmdIcons(ctx, sherlock))
+	root.AddCommand(cmdDiff(ctx, sherlock))
+	root.AddCommand(cmdLog(ctx, sherlock))
+	root.AddCommand(cmdStats(ctx, sherlock))
+	root.AddCommand(cmdReuse(ctx, sherlock))
+	root.AddCommand(cmdRemind(ctx, sherlock))
+	root.AddCommand(cmdBackup(ctx, sherlock))
+	root.AddCommand(cmdWebhook(ctx, sherlock))
+	root.AddCommand(cmdHook(ctx, sherlock))
+	root.AddCommand(cmdDocker(ctx, sherlock))
+	root.AddCommand(cmdK8s(ctx, sherlock))
+	root.AddCommand(cmdAWS(ctx, sherlock))
+	root.AddCommand(cmdAzure(ctx, sherlock))
+	root.AddCommand(cmdGCP(ctx, sherlock))
+	root.AddCommand(cmdPlugin(ctx, sherlock))
+	root.AddCommand(cmdEmergencyKit(ctx, sherlock))
+	root.AddCommand(cmdExport(ctx, sherlock))
+	root.AddCommand(cmdOTP(ctx, sherlock))
+	root.AddCommand(cmdQR(ctx, sherlock))
+	root.AddCommand(cmdRecovery(ctx, sherlock))
+	root.AddCommand(cmdAutotype(ctx, sherlock))
 	root.AddCommand(cmdUpdate(ctx, sherlock))
+	root.AddCommand(cmdSign(ctx, sherlock))
+	root.AddCommand(cmdMigrate(ctx, sherlock))
+	root.AddCommand(cmdLock(ctx, sherlock))
+	root.AddCommand(cmdUnlock(ctx, sherlock))
 	root.AddCommand(cmdVersion())
+	root.AddCommand(cmdSelfUpdate(ctx))
+	root.AddCommand(cmdGenDocs())
+	root.AddCommand(cmdCache(ctx))
+	root.AddCommand(cmdSync(ctx, sherlock))
+	root.AddCommand(cmdTransfer(ctx, sherlock))
+	root.AddCommand(cmdShare(ctx, sherlock))
+	root.AddCommand(cmdRotate(ctx, sherlock))
+	root.AddCommand(cmdPreset(ctx, sherlock))
+	root.AddCommand(cmdGen(ctx, sherlock))
+	root.AddCommand(cmdLink(ctx, sherlock))
+	root.AddCommand(cmdUnlink(ctx, sherlock))
+	root.AddCommand(cmdTimeLock(ctx, sherlock))
+	root.AddCommand(cmdCompliance(ctx, sherlock))
+	root.AddCommand(cmdSecurity(ctx, sherlock))
+	root.AddCommand(cmdNuke(ctx))
+	root.AddCommand(cmdCerts())
+	root.AddCommand(cmdWorkspace())
+	registerCompletions(ctx, sherlock, root)
 	return root
 }
+
+// Execute runs the sherlock CLI and returns the exit status of the executed command
+func Execute(sherlock *internal.Sherlock) int {
+	ctx := interruptContext()
+	root := RootCmd(ctx, sherlock)
+	if code, ok := runPlugin(ctx, root, os.Args[1:]); ok {
+		return code
+	}
+	if err := root.Execute(); err != nil {
+		output, _ := root.PersistentFlags().GetString("output")
+		return reportError(err, output)
+	}
+	return ExitOK
+}
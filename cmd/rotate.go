@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/plugin"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type rotateOptions struct {
+	provider string
+	length   int
+}
+
+func cmdRotate(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts rotateOptions
+	rotate := &cobra.Command{
+		Use:   "rotate",
+		Short: "change a credential at its service with a rotation plugin",
+		Long: "rotate an account (group@account) end-to-end: the rotation provider plugin sherlock-<provider> changes the " +
+			"credential at the service and sherlock stores the new value. The plugin gets the current password and a " +
+			"generated one it may set. Arguments after -- are passed to the plugin => sherlock rotate --provider github work@token -- --scopes repo",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.provider == "" {
+				return fmt.Errorf("%w: --provider is required", internal.ErrInvalidInput)
+			}
+			if opts.length < minGenerateLength {
+				return fmt.Errorf("%w: length for auto generated password must be at least %d", internal.ErrInvalidInput, minGenerateLength)
+			}
+			p, err := plugin.Lookup(os.Getenv("PATH"), opts.provider)
+			if err != nil {
+				return err
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			// a linked account has no password of its own; the target is rotated
+			if account.Linked() {
+				terminal.Info("%s uses the password of %s which is rotated", args[0], account.Link)
+				args[0] = account.Link
+				if groupKey, err = readGroupKey(args[0]); err != nil {
+					return err
+				}
+				if account, err = sherlock.GetAccount(ctx, args[0], groupKey); err != nil {
+					return err
+				}
+			}
+			// the plugin needs the current password to change it at the service
+			if err := account.OpenTimeLock(time.Now()); err != nil {
+				return err
+			}
+			generated, _, _, err := generateFor(ctx, sherlock, account, opts.length, cmd.Flags().Changed("length"), false)
+			if err != nil {
+				return err
+			}
+
+			progress := newProgress(cmd, "rotating with "+p.Name, 1)
+			progress.Start(args[0])
+			password, err := p.Rotate(ctx, args[1:], plugin.RotateRequest{
+				Account:   args[0],
+				Username:  account.Username,
+				URL:       account.URL,
+				Password:  account.Password,
+				Generated: generated,
+			})
+			progress.Done(args[0], err)
+			progress.Finish()
+			if err != nil {
+				return err
+			}
+			// the credential has been changed at the service and must not get lost. Its
+			// strength is up to the service so the password policy is not applied
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccPassword(password, true)); err != nil {
+				terminal.Warning("%s changed the credential but it could not be stored; store it manually with sherlock update password %s:\n\n\t%s\n", p.Name, args[0], password)
+				return err
+			}
+			terminal.Success("%s rotated with %s", args[0], p.Name)
+			return nil
+		},
+	}
+	rotate.Flags().StringVar(&opts.provider, "provider", "", "name of the rotation provider plugin (sherlock-<provider>)")
+	rotate.Flags().IntVar(&opts.length, "length", defaultGenerateLength, "length of the generated password offered to the provider (overrides the preset of the account)")
+
+	return rotate
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type searchOptions struct {
+	all   bool
+	index bool
+}
+
+func cmdSearch(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts searchOptions
+
+	search := &cobra.Command{
+		Use:   "search",
+		Short: "search accounts by name, tag, username, url and note",
+		Long: "search the accounts of one or more groups for a term. The groups are decrypted one after another, matches are shown with the surrounding text. Passwords are never searched. " +
+			"With --index only the names and tags of groups with a plain text index are searched, without any group key",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := pickGroups(ctx, sherlock, args[1:], opts.all)
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			for _, gid := range gids {
+				var group *internal.Group
+				if opts.index {
+					if group, err = sherlock.GroupIndex(ctx, gid); err == internal.ErrNoIndex {
+						terminal.Warning("%s: no plain text index, group skipped", gid)
+						continue
+					}
+				} else {
+					var groupKey string
+					if groupKey, err = readGroupKey(gid); err != nil {
+						return err
+					}
+					group, err = sherlock.LoadGroup(ctx, gid, groupKey)
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", gid, err)
+				}
+				for _, r := range group.Search(args[0]) {
+					rows = append(rows, []string{r.Query, r.Field, r.Snippet})
+				}
+			}
+			if len(rows) == 0 {
+				terminal.Info("no account matches %q", args[0])
+				return nil
+			}
+			terminal.ToTable([]string{"Account", "Field", "Match"}, rows, terminal.TableWithCellMerge(0))
+			return nil
+		},
+	}
+	search.Flags().BoolVarP(&opts.all, "all", "a", false, "search all registered groups")
+	search.Flags().BoolVar(&opts.index, "index", false, "only search names and tags in the plain text index of the groups without unlocking them")
+
+	return search
+}
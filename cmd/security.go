@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type securityLogOptions struct {
+	group  string
+	failed bool
+	limit  int
+}
+
+func cmdSecurity(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	security := &cobra.Command{
+		Use:   "security",
+		Short: "inspect security relevant events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	security.AddCommand(cmdSecurityLog(ctx, sherlock))
+
+	return security
+}
+
+func cmdSecurityLog(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts securityLogOptions
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "list recent attempts to unlock groups",
+		Long: "list the latest successful and failed attempts to unlock a group with the host and terminal they were made from, " +
+			"so someone else trying your vault stands out. The group keys are never logged",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.limit <= 0 {
+				return fmt.Errorf("%w: --limit must be a positive number", internal.ErrInvalidInput)
+			}
+			attempts, err := sherlock.UnlockLog(ctx)
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			var failed int
+			for _, a := range attempts {
+				if len(rows) == opts.limit {
+					break
+				}
+				if opts.group != "" && a.Group != opts.group || opts.failed && a.OK {
+					continue
+				}
+				result := "unlocked"
+				if !a.OK {
+					result, failed = "wrong key", failed+1
+				}
+				rows = append(rows, []string{a.At.Local().Format(eventTimeLayout), a.Group, result, a.Host, a.TTY})
+			}
+			if len(rows) == 0 {
+				terminal.Info("no unlock attempts logged")
+				return nil
+			}
+			terminal.ToTable([]string{"Time", "Group", "Result", "Host", "TTY"}, rows)
+			if failed > 0 {
+				terminal.Warning("%d of the listed attempts failed", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&opts.group, "group", "g", "", "only list attempts to unlock the group")
+	cmd.Flags().BoolVar(&opts.failed, "failed", false, "only list failed attempts")
+	cmd.Flags().IntVarP(&opts.limit, "limit", "n", 20, "number of attempts to list")
+
+	return cmd
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/selfupdate"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// ReleaseKey is the minisign public key release binaries are signed with. It is
+// set when building a release:
+// -ldflags "-X github.com/KonstantinGasser/sherlock/cmd.ReleaseKey=RW..."
+var ReleaseKey = ""
+
+// selfUpdateTimeout limits checking the feed and downloading the binary
+const selfUpdateTimeout = 5 * time.Minute
+
+type selfUpdateOptions struct {
+	check bool
+	force bool
+	feed  string
+}
+
+func cmdSelfUpdate(ctx context.Context) *cobra.Command {
+	var opts selfUpdateOptions
+
+	selfUpdate := &cobra.Command{
+		Use:   "self-update",
+		Short: "update sherlock to the latest release",
+		Long:  "download the latest release binary for this platform, verify its minisign signature with the release key and replace the running executable. With --check sherlock only reports whether an update is available",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(ctx, selfUpdateTimeout)
+			defer cancel()
+			client := &http.Client{}
+
+			release, err := selfupdate.Latest(ctx, client, opts.feed)
+			if err != nil {
+				return err
+			}
+			newer := selfupdate.Newer(Version, release.Version)
+			if opts.check {
+				if newer {
+					terminal.Info("sherlock %s is available (installed: %s). Update with: sherlock self-update", release.Version, Version)
+					return nil
+				}
+				terminal.Success("sherlock %s is up to date", Version)
+				return nil
+			}
+			if !newer && !opts.force {
+				terminal.Success("sherlock %s is up to date", Version)
+				return nil
+			}
+			// the key is checked before anything is downloaded so a build without
+			// a release key can still --check for updates
+			if ReleaseKey == "" {
+				return selfupdate.ErrNoReleaseKey
+			}
+			key, err := selfupdate.ParsePublicKey(ReleaseKey)
+			if err != nil {
+				return err
+			}
+			executable, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			if executable, err = filepath.EvalSymlinks(executable); err != nil {
+				return err
+			}
+			binary, err := release.Download(ctx, client, key)
+			if err != nil {
+				return err
+			}
+			if err := selfupdate.Replace(executable, binary); err != nil {
+				return err
+			}
+			terminal.Success("sherlock updated from %s to %s (%s)", Version, release.Version, executable)
+			return nil
+		},
+	}
+	selfUpdate.Flags().BoolVar(&opts.check, "check", false, "only report whether an update is available")
+	selfUpdate.Flags().BoolVar(&opts.force, "force", false, "install the latest release even if it is not newer")
+	selfUpdate.Flags().StringVar(&opts.feed, "feed", selfupdate.FeedURL, "url of the release feed (for mirrors)")
+
+	return selfUpdate
+}
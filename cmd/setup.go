@@ -13,23 +13,24 @@ func cmdSetup(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
 		Use:   "setup",
 		Short: "setup allows to initially set-up a main password for your vault",
 		Long:  "to encrypt and decrypt your vault you will need to set-up a main password",
-		Run: func(cmd *cobra.Command, args []string) {
-			if err := sherlock.IsSetUp(); err == nil {
-				terminal.Error("sherlock is already set-up")
-				return
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.IsSetUp(ctx); err == nil {
+				return internal.ErrAlreadySetup
 			}
 			terminal.Success("sherlock has a default group for accounts not mapped to any group.\nPlease provide a group password for the default group.")
 
-			groupKey, err := terminal.ReadPassword("(default) group password: ")
-			if err != nil {
-				terminal.Error(err.Error())
-				return
+			groupKey, ok := envGroupKeys[internal.GroupKeyEnv("default")]
+			if !ok {
+				var err error
+				if groupKey, err = terminal.ReadPassword("(default) group password: "); err != nil {
+					return err
+				}
 			}
-			if err := sherlock.Setup(groupKey); err != nil {
-				terminal.Error(err.Error())
-				return
+			if err := sherlock.Setup(ctx, groupKey); err != nil {
+				return err
 			}
 			terminal.Banner()
+			return nil
 		},
 	}
 }
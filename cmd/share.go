@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/share"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdShare(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	shareCmd := &cobra.Command{
+		Use:   "share",
+		Short: "hand a credential to someone on the local network",
+		Long:  "hand a credential to someone on the local network without sending it through chat or email",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	shareCmd.AddCommand(cmdShareLink(ctx, sherlock))
+
+	return shareCmd
+}
+
+type shareLinkOptions struct {
+	expires time.Duration
+	views   int
+	host    string
+	port    int
+}
+
+func cmdShareLink(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts shareLinkOptions
+	link := &cobra.Command{
+		Use:   "link",
+		Short: "serve an account over a one-time HTTPS link",
+		Long: "serve the username, password and url of an account (group@account) over HTTPS under a random link until it " +
+			"has been revealed --views times or --expires has passed. The certificate is self-signed; the recipient should " +
+			"compare its fingerprint before accepting it. The link is served while the command runs",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			host := opts.host
+			if host == "" {
+				ips := share.LocalIPs()
+				if len(ips) == 0 {
+					return fmt.Errorf("%w: no network address found (use --host)", internal.ErrInvalidInput)
+				}
+				host = ips[0]
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			l, err := share.NewLink(share.Secret{
+				Name:     args[0],
+				Username: account.Username,
+				Password: account.Password,
+				URL:      account.URL,
+			}, opts.views, opts.expires, append(share.LocalIPs(), host))
+			if err != nil {
+				return fmt.Errorf("%w: %v", internal.ErrInvalidInput, err)
+			}
+			ln, err := net.Listen("tcp", ":"+strconv.Itoa(opts.port))
+			if err != nil {
+				return err
+			}
+			defer ln.Close()
+			l.OnView = func(left int) {
+				terminal.Info("%s revealed (%d views left)", args[0], left)
+			}
+
+			addr := net.JoinHostPort(host, strconv.Itoa(ln.Addr().(*net.TCPAddr).Port))
+			terminal.Info("share this link (valid until %s):\n\n\thttps://%s%s\n", l.Expires().Format("15:04:05"), addr, l.Path())
+			terminal.Info("certificate fingerprint (SHA-256):\n\n\t%s\n", l.Fingerprint())
+
+			viewed, err := l.Serve(ctx, ln)
+			if err != nil {
+				return err
+			}
+			if viewed < opts.views {
+				terminal.Warning("link expired after %d of %d views", viewed, opts.views)
+				return nil
+			}
+			terminal.Success("link used up and closed")
+			return nil
+		},
+	}
+	link.Flags().DurationVar(&opts.expires, "expires", 10*time.Minute, "time until the link expires")
+	link.Flags().IntVar(&opts.views, "views", 1, "number of times the credential can be revealed")
+	link.Flags().StringVar(&opts.host, "host", "", "address of this device in the link (default: first network address)")
+	link.Flags().IntVar(&opts.port, "port", 0, "port to serve the link on (default: random)")
+
+	return link
+}
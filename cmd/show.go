@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type showOptions struct {
+	duration time.Duration
+}
+
+func cmdShow(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts showOptions
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "show reveals an accounts password for a few seconds",
+		Long:  "show displays the password masked and reveals it on keypress for a few seconds before masking it again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.duration <= 0 {
+				return fmt.Errorf("%w: reveal duration must be positive", internal.ErrInvalidInput)
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			return terminal.Reveal(ctx, args[0], account.Password, opts.duration)
+		},
+	}
+	show.Flags().DurationVarP(&opts.duration, "duration", "d", 5*time.Second, "how long the password is revealed")
+
+	return show
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdSign(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	sign := &cobra.Command{
+		Use:   "sign",
+		Short: "sign vaults to detect tampering",
+		Long:  "sign every encrypted vault with a device key and verify the signatures to detect vaults modified outside of sherlock",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	sign.AddCommand(cmdSignEnable(ctx, sherlock))
+	sign.AddCommand(cmdSignVerify(ctx, sherlock))
+
+	return sign
+}
+
+func cmdSignEnable(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable",
+		Short: "create a device key and sign all vaults",
+		Long:  "create a device key used to sign all existing and future vault writes",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.EnableSigning(ctx); err != nil {
+				return err
+			}
+			terminal.Success("vault signing enabled")
+			return nil
+		},
+	}
+}
+
+func cmdSignVerify(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "verify the signatures of all vaults",
+		Long:  "verify the signatures of all vaults and report the groups which have been modified or rolled back outside of sherlock",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tampered, err := sherlock.VerifyVaults(ctx)
+			if err != nil {
+				return err
+			}
+			for _, gid := range tampered {
+				warnTampered(gid)
+			}
+			if len(tampered) > 0 {
+				return fmt.Errorf("%w: %d vault(s) affected", errTampered, len(tampered))
+			}
+			terminal.Success("all vault signatures are valid")
+			return nil
+		},
+	}
+}
+
+// warnTampered prints a warning for a group which vault signature does not match
+func warnTampered(gid string) {
+	terminal.Warning("WARNING: vault of group %q was modified, replaced or rolled back outside of sherlock!", gid)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type statsOptions struct {
+	all bool
+}
+
+func cmdStats(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts statsOptions
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "summarize accounts, password ages and tags",
+		Long:  "summarize the accounts of one or more groups: accounts per group, weak passwords, password age distribution and tag usage",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gids, err := pickGroups(ctx, sherlock, args, opts.all)
+			if err != nil {
+				return err
+			}
+			groups, err := loadGroups(ctx, sherlock, gids)
+			if err != nil {
+				return err
+			}
+			s := internal.CollectStats(time.Now(), groups...)
+
+			terminal.Info("%d accounts in %d groups, %d with a weak password", s.Accounts, len(s.Groups), s.Weak)
+			rows := make([][]string, 0, len(s.Groups))
+			for _, g := range s.Groups {
+				rows = append(rows, []string{g.GID, strconv.Itoa(g.Accounts), strconv.Itoa(g.Weak)})
+			}
+			terminal.ToTable([]string{"Group", "Accounts", "Weak"}, rows)
+
+			counts := make([]int, len(s.Ages))
+			rows = make([][]string, 0, len(s.Ages))
+			for i, a := range s.Ages {
+				counts[i] = a.Count
+				rows = append(rows, []string{a.Label, strconv.Itoa(a.Count)})
+			}
+			terminal.Info("password age %s", terminal.Sparkline(counts))
+			terminal.ToTable([]string{"Password Age", "Accounts"}, rows)
+
+			if len(s.Tags) > 0 {
+				rows = make([][]string, 0, len(s.Tags))
+				for _, t := range s.Tags {
+					rows = append(rows, []string{"#" + t.Tag, strconv.Itoa(t.Count)})
+				}
+				terminal.ToTable([]string{"#Tag", "Accounts"}, rows)
+			}
+			return nil
+		},
+	}
+	stats.Flags().BoolVarP(&opts.all, "all", "a", false, "summarize all registered groups")
+
+	return stats
+}
+
+// loadGroups reads the keys of the groups and loads them
+func loadGroups(ctx context.Context, sherlock *internal.Sherlock, gids []string) ([]*internal.Group, error) {
+	groups := make([]*internal.Group, 0, len(gids))
+	for _, gid := range gids {
+		groupKey, err := readGroupKey(gid)
+		if err != nil {
+			return nil, err
+		}
+		group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", gid, err)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/certs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/peer"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// discoveryWait is how long answers of peers on the local network are collected
+const discoveryWait = 3 * time.Second
+
+var ErrNoPeer = fmt.Errorf("no sherlock peer found on the local network")
+
+var errPeerCertificate = fmt.Errorf("peer certificate rejected")
+
+type syncPeerOptions struct {
+	code    string
+	peer    string
+	addr    string
+	newest  bool
+	timeout time.Duration
+	mtls    bool
+	certs   string
+	// identity authenticates this device with mutual TLS
+	identity *certs.Identity
+}
+
+func cmdSync(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	sync := &cobra.Command{
+		Use:   "sync",
+		Short: "sync the vaults with other devices",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	sync.AddCommand(cmdSyncPeer(ctx, sherlock))
+
+	return sync
+}
+
+func cmdSyncPeer(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts syncPeerOptions
+
+	syncPeer := &cobra.Command{
+		Use:   "peer",
+		Short: "sync the vaults with another device on the local network",
+		Long: "sync the (still encrypted) group vaults with another sherlock instance on the local network without any remote storage. " +
+			"Run sherlock sync peer on one device; it waits for a peer and shows a one-time code. Run sherlock sync peer --code <code> " +
+			"on the other device which finds the first one via mDNS. A group changed on one device only since the last sync is taken " +
+			"from that device, groups only one device holds are copied. Groups changed on both devices are skipped unless --newest " +
+			"keeps the more recently modified vault. Both devices authenticate each other with certificates issued by the same " +
+			"CA (see sherlock certs) and the connection is encrypted with TLS; the code only selects the waiting peer",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+			defer cancel()
+
+			dir := opts.certs
+			if dir == "" {
+				dir = certs.Dir()
+			}
+			id, err := certs.LoadIdentity(dir)
+			if err != nil {
+				return err
+			}
+			if time.Now().After(id.NotAfter) {
+				return fmt.Errorf("%w: the certificate of this device expired on %s", certs.ErrInvalidCert, id.NotAfter.Local().Format(eventTimeLayout))
+			}
+			opts.identity = id
+
+			config, err := sherlock.Config(ctx)
+			if err != nil {
+				return err
+			}
+			local, err := localVaults(ctx, sherlock, config.Sync.Revisions)
+			if err != nil {
+				return err
+			}
+			var result *peer.Result
+			if opts.code == "" {
+				result, err = acceptPeer(ctx, local, opts)
+			} else {
+				result, err = connectPeer(ctx, local, opts)
+			}
+			if err != nil {
+				return err
+			}
+			for _, v := range result.Received {
+				if err := sherlock.RestoreGroup(ctx, v.Group, v.Data, true); err != nil {
+					return fmt.Errorf("could not store %s: %w", v.Group, err)
+				}
+			}
+			if config.Sync.Revisions == nil {
+				config.Sync.Revisions = make(map[string]string)
+			}
+			for gid, revision := range result.Revisions {
+				config.Sync.Revisions[gid] = revision
+			}
+			if err := sherlock.SaveConfig(ctx, config); err != nil {
+				return err
+			}
+			printSync(result)
+			return nil
+		},
+	}
+	syncPeer.Flags().StringVar(&opts.code, "code", "", "code shown by the waiting peer")
+	syncPeer.Flags().StringVar(&opts.peer, "peer", "", "name of the peer if more than one is waiting")
+	syncPeer.Flags().StringVar(&opts.addr, "addr", "", "connect to the peer at host:port instead of discovering it")
+	syncPeer.Flags().BoolVar(&opts.newest, "newest", false, "resolve groups changed on both devices by the newer vault")
+	syncPeer.Flags().DurationVar(&opts.timeout, "timeout", 2*time.Minute, "time to wait for the peer and the sync")
+	syncPeer.Flags().BoolVar(&opts.mtls, "mtls", false, "authenticate the peer with mutual TLS using the certificates in ~/.sherlock/certs")
+	_ = syncPeer.Flags().MarkDeprecated("mtls", "mutual TLS is always used")
+	syncPeer.Flags().StringVar(&opts.certs, "certs", "", "directory holding the certificates of this device (default ~/.sherlock/certs)")
+
+	return syncPeer
+}
+
+// localVaults reads the vaults of all groups. A device not set up yet has none
+func localVaults(ctx context.Context, sherlock *internal.Sherlock, bases map[string]string) ([]peer.Vault, error) {
+	if sherlock.IsSetUp(ctx) != nil {
+		return nil, nil
+	}
+	infos, err := sherlock.GroupInfos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vaults := make([]peer.Vault, 0, len(infos))
+	for _, info := range infos {
+		data, err := sherlock.ReadVault(ctx, info.GID)
+		if err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, peer.NewVault(info.GID, data, bases[info.GID], info.Modified))
+	}
+	return vaults, nil
+}
+
+// acceptPeer waits for a single peer to connect while advertising this device
+func acceptPeer(ctx context.Context, local []peer.Vault, opts syncPeerOptions) (*peer.Result, error) {
+	code, err := peer.NewCode()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	deadline, _ := ctx.Deadline()
+	if err := ln.(*net.TCPListener).SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	name := peer.InstanceName(hostname)
+	port := ln.Addr().(*net.TCPAddr).Port
+	go func() {
+		if err := peer.Advertise(ctx, name, port); err != nil {
+			terminal.Warning("peers cannot discover this device (%v); connect with --addr <ip>:%d", err, port)
+		}
+	}()
+	terminal.Info("waiting for a peer as %q on port %d. On the other device run:\n\n\tsherlock sync peer --code %s\n", name, port, code)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("no peer connected within %s", opts.timeout)
+		}
+		return nil, err
+	}
+	defer conn.Close()
+	// the code is valid for this connection only so it cannot be guessed
+	ln.Close()
+	_ = conn.SetDeadline(deadline)
+	tlsConn := tls.Server(conn, opts.identity.ServerConfig())
+	if err := handshakePeer(tlsConn); err != nil {
+		return nil, err
+	}
+	return peer.Accept(tlsConn, code, local, opts.newest)
+}
+
+// connectPeer connects to the waiting peer at --addr or found on the network
+func connectPeer(ctx context.Context, local []peer.Vault, opts syncPeerOptions) (*peer.Result, error) {
+	addr := opts.addr
+	if addr == "" {
+		peers, err := peer.Browse(ctx, discoveryWait)
+		if err != nil {
+			return nil, err
+		}
+		found, err := selectPeer(peers, opts.peer)
+		if err != nil {
+			return nil, err
+		}
+		terminal.Info("syncing with %s (%s)", found.Name, found.Addr)
+		addr = found.Addr
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	tlsConn := tls.Client(conn, opts.identity.ClientConfig())
+	if err := handshakePeer(tlsConn); err != nil {
+		return nil, err
+	}
+	return peer.Connect(tlsConn, opts.code, local, opts.newest)
+}
+
+// handshakePeer runs the TLS handshake verifying the certificate of the peer
+func handshakePeer(conn *tls.Conn) error {
+	if err := conn.Handshake(); err != nil {
+		return fmt.Errorf("%w: %v", errPeerCertificate, err)
+	}
+	terminal.Info("peer authenticated as %q", certs.PeerName(conn))
+	return nil
+}
+
+// selectPeer picks the named peer or the only one found
+func selectPeer(peers []peer.Peer, name string) (peer.Peer, error) {
+	if name != "" {
+		for _, p := range peers {
+			if strings.EqualFold(p.Name, name) {
+				return p, nil
+			}
+		}
+		return peer.Peer{}, fmt.Errorf("%w: %q is not waiting", ErrNoPeer, name)
+	}
+	switch len(peers) {
+	case 0:
+		return peer.Peer{}, fmt.Errorf("%w (run sherlock sync peer on the other device first or use --addr)", ErrNoPeer)
+	case 1:
+		return peers[0], nil
+	}
+	names := make([]string, len(peers))
+	for i, p := range peers {
+		names[i] = p.Name
+	}
+	return peer.Peer{}, fmt.Errorf("%w: more than one peer is waiting, select one with --peer (%s)", internal.ErrInvalidInput, strings.Join(names, ", "))
+}
+
+func printSync(result *peer.Result) {
+	var rows [][]string
+	for _, state := range []struct {
+		groups []string
+		label  string
+	}{
+		{groups: result.Receive, label: "received"},
+		{groups: result.Send, label: "sent"},
+		{groups: result.InSync, label: "in sync"},
+		{groups: result.Conflicts, label: "conflict (skipped)"},
+	} {
+		for _, gid := range state.groups {
+			rows = append(rows, []string{gid, state.label})
+		}
+	}
+	terminal.ToTable([]string{"Group", "Sync"}, rows)
+	if len(result.Conflicts) > 0 {
+		terminal.Warning("%d groups changed on both devices were skipped; sync again with --newest to keep the newer vault", len(result.Conflicts))
+	}
+	terminal.Success("%d groups received, %d sent", len(result.Receive), len(result.Send))
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// timeLockLayouts are the layouts accepted by timelock set --until
+var timeLockLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"}
+
+type timeLockSetOptions struct {
+	until string
+}
+
+func cmdTimeLock(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	timelock := &cobra.Command{
+		Use:   "timelock",
+		Short: "seal a password until a point in time",
+		Long: "a time-locked password cannot be revealed before the set time, e.g. for scheduled handovers or embargoed credentials. " +
+			"The lock is enforced by sherlock, not by cryptography: anyone holding the group key and a modified client can open it early",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	timelock.AddCommand(cmdTimeLockSet(ctx, sherlock))
+	timelock.AddCommand(cmdTimeLockOpen(ctx, sherlock))
+
+	return timelock
+}
+
+func cmdTimeLockSet(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts timeLockSetOptions
+
+	cmd := &cobra.Command{
+		Use:   "set <group@account>",
+		Short: "seal the password of an account until a point in time",
+		Long: "seal the password of the account until --until. get, show and the other commands refuse to reveal it before; " +
+			"setting a new password removes the lock",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			until, err := parseTimeLock(opts.until)
+			if err != nil {
+				return err
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccTimeLock(until)); err != nil {
+				return err
+			}
+			terminal.Success("%s is time-locked until %s", args[0], until.Format(time.RFC3339))
+			terminal.Warning("the lock is enforced by sherlock: it keeps anyone from revealing the password early by accident, not an attacker holding the group key")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.until, "until", "", "time the password can be revealed (2024-01-31 09:00 in local time or RFC 3339)")
+	_ = cmd.MarkFlagRequired("until")
+	return cmd
+}
+
+func cmdTimeLockOpen(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "open <group@account>",
+		Short: "store the password of an expired time lock again",
+		Long:  "remove the expired time lock of the account and store its password as usual",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccOpenTimeLock()); err != nil {
+				return err
+			}
+			terminal.Success("%s is no longer time-locked", args[0])
+			return nil
+		},
+	}
+}
+
+// parseTimeLock parses the value of timelock set --until in local time unless
+// it has a time zone
+func parseTimeLock(value string) (time.Time, error) {
+	for _, layout := range timeLockLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: --until expects a time like 2024-01-31 09:00 or 2024-01-31T09:00:00Z", internal.ErrInvalidInput)
+}
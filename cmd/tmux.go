@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/spf13/cobra"
+)
+
+var errNoTmux = fmt.Errorf("not running inside tmux (use --target to address a pane)")
+
+func cmdTmux(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	tmux := &cobra.Command{
+		Use:   "tmux",
+		Short: "tmux integration",
+		Long:  "send account credentials to tmux panes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	tmux.AddCommand(cmdTmuxSend(ctx, sherlock))
+	return tmux
+}
+
+type tmuxSendOptions struct {
+	target string
+	field  string
+	enter  bool
+}
+
+func cmdTmuxSend(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts tmuxSendOptions
+	send := &cobra.Command{
+		Use:   "send",
+		Short: "send an accounts password to a tmux pane",
+		Long: "send types the password (or any other field using --field) of an account into the current or the --target " +
+			"tmux pane. The value is passed to tmux through a temporary paste buffer and never touches the clipboard",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.target == "" && os.Getenv("TMUX") == "" {
+				return errNoTmux
+			}
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, args[0], groupKey)
+			if err != nil {
+				return err
+			}
+			value, err := sherlock.AccountField(ctx, args[0], groupKey, *account, opts.field)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, opts.field)
+			}
+			return tmuxSend(ctx, opts.target, value, opts.enter)
+		},
+	}
+	send.Flags().StringVarP(&opts.target, "target", "t", "", "target pane (tmux target-pane syntax), defaults to the current pane")
+	send.Flags().StringVarP(&opts.field, "field", "f", internal.FieldPassword, "account field to send (password|username|url|totp|note)")
+	send.Flags().BoolVarP(&opts.enter, "enter", "e", false, "press enter after sending the value")
+
+	return send
+}
+
+// tmuxSend loads the value into a uniquely named paste buffer through stdin (so it
+// does not show up in the process list) and pastes it into the pane deleting the buffer
+func tmuxSend(ctx context.Context, target, value string, enter bool) error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	buffer := "sherlock-" + hex.EncodeToString(id)
+
+	if err := runTmux(ctx, value, "load-buffer", "-b", buffer, "-"); err != nil {
+		return err
+	}
+	paste := []string{"paste-buffer", "-d", "-b", buffer}
+	if target != "" {
+		paste = append(paste, "-t", target)
+	}
+	if err := runTmux(ctx, "", paste...); err != nil {
+		// never leave the value behind in a buffer
+		_ = runTmux(ctx, "", "delete-buffer", "-b", buffer)
+		return err
+	}
+	if !enter {
+		return nil
+	}
+	keys := []string{"send-keys"}
+	if target != "" {
+		keys = append(keys, "-t", target)
+	}
+	return runTmux(ctx, "", append(keys, "Enter")...)
+}
+
+func runTmux(ctx context.Context, stdin string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "tmux", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
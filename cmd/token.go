@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/registry"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+type tokenOptions struct {
+	url string
+	raw bool
+}
+
+func cmdToken(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts tokenOptions
+	token := &cobra.Command{
+		Use:   "token <npm|pypi|cargo> [group@account]",
+		Short: "print a package registry token in the config format of its package manager",
+		Long: "print the token of a package registry as .npmrc line (npm), pip.conf (pypi) or cargo credentials (cargo) so " +
+			"package managers read it from a process substitution instead of a dotfile. The token is the password of the " +
+			"account, default@<registry> if none is given; its url selects a private registry (the name of an alternative " +
+			"registry for cargo) and its username the pip user (default __token__). --raw prints the token only, e.g. for " +
+			"cargo's token-from-stdout credential provider. Messages and prompts go to stderr",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			terminal.MessagesToStderr()
+			name, err := registry.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+			query := "default@" + name
+			if len(args) == 2 {
+				query = args[1]
+			}
+			groupKey, err := readGroupKey(query)
+			if err != nil {
+				return err
+			}
+			account, err := getAccount(ctx, sherlock, query, groupKey)
+			if err != nil {
+				return err
+			}
+			t := registry.Token{Token: account.Password, Username: account.Username, URL: account.URL}
+			if opts.url != "" {
+				t.URL = opts.url
+			}
+			out := t.Token + "\n"
+			if !opts.raw {
+				if out, err = registry.Render(name, t); err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprint(os.Stdout, out)
+			return err
+		},
+	}
+	token.Flags().StringVar(&opts.url, "url", "", "registry url (cargo: name of the alternative registry) instead of the url of the account")
+	token.Flags().BoolVar(&opts.raw, "raw", false, "print the token only")
+
+	return token
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/peer"
+	"github.com/KonstantinGasser/sherlock/qr"
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+// defaultFrameSize is the number of payload characters per QR code. Larger
+// frames need fewer codes but may not fit the terminal
+const defaultFrameSize = 300
+
+func cmdTransfer(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	transfer := &cobra.Command{
+		Use:   "transfer",
+		Short: "move accounts between devices with QR codes",
+		Long: "transfer moves the accounts of a group to another device without any network connection. The sender shows them " +
+			"as a sequence of QR codes (or text frames) encrypted with a one-time code which has to be told to the receiver separately. " +
+			"The code is the only protection of the frames: anyone who photographs them and learns the code can read the accounts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	transfer.AddCommand(cmdTransferSend(ctx, sherlock))
+	transfer.AddCommand(cmdTransferReceive(ctx, sherlock))
+
+	return transfer
+}
+
+type transferSendOptions struct {
+	accounts  []string
+	text      bool
+	frameSize int
+}
+
+func cmdTransferSend(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts transferSendOptions
+	send := &cobra.Command{
+		Use:   "send <group>",
+		Short: "show the accounts of a group as encrypted QR codes",
+		Long: "encrypt the accounts of the group (or the ones selected with --account) with a new one-time code of about 100 bits " +
+			"and show them as a sequence of QR codes, one at a time. The accounts keep their history, otp secrets and recovery codes. " +
+			"--text prints the frames as text lines instead, e.g. to paste them on the other device",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.frameSize < 1 {
+				return fmt.Errorf("%w: --frame-size must be positive", internal.ErrInvalidInput)
+			}
+			gid := args[0]
+			groupKey, err := readGroupKey(gid)
+			if err != nil {
+				return err
+			}
+			group, err := sherlock.LoadGroup(ctx, gid, groupKey)
+			if err != nil {
+				return err
+			}
+			accounts, err := selectAccounts(group, opts.accounts)
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(accounts)
+			if err != nil {
+				return err
+			}
+			code, err := peer.NewOfflineCode()
+			if err != nil {
+				return err
+			}
+			sealed, err := security.SealPassphrase(b, peer.NormalizeCode(code))
+			if err != nil {
+				return err
+			}
+			frames := qr.Split(sealed, opts.frameSize)
+			terminal.Info("%d accounts of %s in %d frames. Tell the receiver the code %s (never show it with the frames)", len(accounts), gid, len(frames), code)
+			if opts.text {
+				for _, frame := range frames {
+					fmt.Println(frame)
+				}
+				return nil
+			}
+			for i, frame := range frames {
+				fmt.Printf("\nframe %d/%d\n", i+1, len(frames))
+				if err := qr.Render(os.Stdout, frame); err != nil {
+					return err
+				}
+				if i < len(frames)-1 {
+					if _, err := terminal.ReadLine("press enter for the next frame "); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	}
+	send.Flags().StringSliceVar(&opts.accounts, "account", nil, "transfer only these accounts")
+	send.Flags().BoolVar(&opts.text, "text", false, "print the frames as text instead of QR codes")
+	send.Flags().IntVar(&opts.frameSize, "frame-size", defaultFrameSize, "characters per frame")
+
+	return send
+}
+
+// selectAccounts returns the named accounts of the group or all without names
+func selectAccounts(group *internal.Group, names []string) ([]*internal.Account, error) {
+	if len(names) == 0 {
+		return group.Accounts, nil
+	}
+	byName := make(map[string]*internal.Account, len(group.Accounts))
+	for _, a := range group.Accounts {
+		byName[a.Name] = a
+	}
+	accounts := make([]*internal.Account, 0, len(names))
+	for _, name := range names {
+		a, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", internal.ErrNoSuchAccount, name)
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+type transferReceiveOptions struct {
+	images     []string
+	onConflict string
+}
+
+func cmdTransferReceive(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	var opts transferReceiveOptions
+	receive := &cobra.Command{
+		Use:   "receive <group>",
+		Short: "import accounts shown by transfer send",
+		Long: "read the frames shown by sherlock transfer send from images of the QR codes (--image, in any order) or pasted as " +
+			"text lines, decrypt them with the one-time code and import the accounts into the group",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gid := args[0]
+			resolve, err := conflictResolver(gid, opts.onConflict)
+			if err != nil {
+				return err
+			}
+			var frames qr.Joiner
+			for _, image := range opts.images {
+				frame, err := qr.Decode(image)
+				if err != nil {
+					return fmt.Errorf("%s: %w", image, err)
+				}
+				if err := frames.Add(frame); err != nil {
+					return fmt.Errorf("%s: %w", image, err)
+				}
+			}
+			if len(opts.images) == 0 {
+				lines, err := terminal.ReadLines("paste the frames, one per line, and end with an empty line:")
+				if err != nil {
+					return err
+				}
+				for _, line := range lines {
+					if err := frames.Add(line); err != nil {
+						return err
+					}
+				}
+			}
+			sealed, err := frames.Payload()
+			if err != nil {
+				return err
+			}
+			code, err := terminal.ReadPassword("transfer code: ")
+			if err != nil {
+				return err
+			}
+			b, err := security.OpenSealed(sealed, peer.NormalizeCode(code))
+			if err != nil {
+				return err
+			}
+			var accounts []*internal.Account
+			if err := json.Unmarshal(b, &accounts); err != nil {
+				return fmt.Errorf("%w: %v", qr.ErrInvalidFrame, err)
+			}
+			groupKey, err := readGroupKey(gid)
+			if err != nil {
+				return err
+			}
+			results, err := sherlock.ImportAccounts(ctx, gid, groupKey, accounts, resolve)
+			if err != nil {
+				return err
+			}
+			printImported(gid, results)
+			return nil
+		},
+	}
+	receive.Flags().StringSliceVar(&opts.images, "image", nil, "images of the QR codes (png, jpeg or gif)")
+	receive.Flags().StringVar(&opts.onConflict, "on-conflict", internal.ConflictPrompt, "how to resolve accounts which already exist (skip|overwrite|rename|prompt)")
+
+	return receive
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdUndo(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [<group>]",
+		Short: "restore the account deleted last",
+		Long: "restore the account deleted last exactly as it was (including its history). The deleted account is kept " +
+			"encrypted in its group until the next account of the group is deleted; with a group the account deleted last " +
+			"from that group is restored",
+		Args: cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var gid string
+			if len(args) == 1 {
+				gid = args[0]
+			} else {
+				var err error
+				if gid, err = sherlock.UndoGroup(ctx); err != nil {
+					return err
+				}
+			}
+			groupKey, err := readGroupKey(gid)
+			if err != nil {
+				return err
+			}
+			account, err := sherlock.Undo(ctx, gid, groupKey)
+			if err != nil {
+				return err
+			}
+			terminal.Success("account %q restored", gid+"@"+account.Name)
+			return nil
+		},
+	}
+}
@@ -2,29 +2,45 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/KonstantinGasser/sherlock/internal"
 	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 )
 
 func cmdUpdate(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
 	update := &cobra.Command{
 		Use:   "update",
-		Short: "update an accounts password or name",
-		Long:  "update an accounts password or name",
+		Short: "update an accounts password, name or details",
+		Long:  "update an accounts password, name, username, url, note, tag, otp secret or auto-type sequence",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			_ = cmd.Help()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
 		},
 	}
 	update.AddCommand(cmdUpdateAccPassword(ctx, sherlock))
 	update.AddCommand(cmdUpdateAccName(ctx, sherlock))
+	update.AddCommand(cmdUpdateAccField(ctx, sherlock, internal.FieldUsername, internal.OptAccUsername))
+	update.AddCommand(cmdUpdateAccField(ctx, sherlock, internal.FieldURL, internal.OptAccURL))
+	update.AddCommand(cmdUpdateAccField(ctx, sherlock, internal.FieldNote, internal.OptAccNote))
+	update.AddCommand(cmdUpdateAccOTP(ctx, sherlock))
+	update.AddCommand(cmdUpdateAccField(ctx, sherlock, "autotype", internal.OptAccAutoType))
+	update.AddCommand(cmdUpdateAccField(ctx, sherlock, "tag", internal.OptsAccTag))
+	update.AddCommand(cmdUpdateAccField(ctx, sherlock, "preset", func(name string) internal.StateOption {
+		return optAccPreset(ctx, sherlock, name)
+	}))
 	return update
 }
 
 type passwordOptions struct {
 	insecure bool
+	generate bool
+	length   int
+	copy     bool
+	// pronounceable generates a syllable-based password
+	pronounceable bool
 }
 
 func cmdUpdateAccPassword(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
@@ -32,27 +48,61 @@ func cmdUpdateAccPassword(ctx context.Context, sherlock *internal.Sherlock) *cob
 	password := &cobra.Command{
 		Use:   "password",
 		Short: "change account password",
-		Long:  "allows to change/update the password of an existing account",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
-			if err != nil {
-				terminal.Error(err.Error())
-				return
+		Long: "allows to change/update the password of an existing account. With --generate a new password is generated " +
+			"with the generator preset of the account or, without one, with --length",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.pronounceable {
+				opts.generate = true
 			}
-			password, err := terminal.ReadPassword("(%s) new password: ", args[0])
+			if opts.generate && opts.length < minGenerateLength {
+				return fmt.Errorf("%w: length for auto generated password must be at least %d", internal.ErrInvalidInput, minGenerateLength)
+			}
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
+			}
+			var password string
+			var preset internal.Preset
+			if opts.generate {
+				account, err := sherlock.GetAccount(ctx, args[0], groupKey)
+				if err != nil {
+					return err
+				}
+				var fromAccount bool
+				if password, preset, fromAccount, err = generateFor(ctx, sherlock, account, opts.length, cmd.Flags().Changed("length"), opts.pronounceable); err != nil {
+					return err
+				}
+				// preset passwords follow the site rules, see add account
+				opts.insecure = opts.insecure || fromAccount
+			} else if password, err = terminal.ReadPassword("(%s) new password: ", args[0]); err != nil {
+				return err
 			}
 			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccPassword(password, opts.insecure)); err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			terminal.Info("account password updated")
+			if opts.generate {
+				if preset.Pronounceable {
+					terminal.Info("entropy of the generated password: %.0f bits", preset.Entropy())
+				}
+				if opts.copy {
+					if err := clipboard.WriteAll(password); err != nil {
+						return err
+					}
+					terminal.Info("generated password copied to clipboard")
+					return nil
+				}
+				terminal.Info("generated password : %s", password)
+			}
+			return nil
 		},
 	}
 	password.Flags().BoolVarP(&opts.insecure, "insecure", "i", false, "allow insecure password for account")
+	password.Flags().BoolVarP(&opts.generate, "generate", "g", false, "auto-generate the new password instead of entering one")
+	password.Flags().IntVarP(&opts.length, "length", "l", defaultGenerateLength, "length of the auto-generated password (overrides the preset of the account)")
+	password.Flags().BoolVar(&opts.pronounceable, "pronounceable", false, "auto-generate a syllable-based password which is easier to type")
+	password.Flags().BoolVarP(&opts.copy, "copy", "c", false, "copy the auto-generated password to the clipboard instead of printing it")
 	return password
 }
 
@@ -62,23 +112,46 @@ func cmdUpdateAccName(ctx context.Context, sherlock *internal.Sherlock) *cobra.C
 		Short: "change account name",
 		Long:  "allows to change/update the account of an existing account",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			groupKey, err := terminal.ReadPassword("(%s) password: ", args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			name, err := terminal.ReadLine("(%s) new account name: ", args[0])
 			if err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			if err := sherlock.UpdateState(ctx, args[0], groupKey, internal.OptAccName(name)); err != nil {
-				terminal.Error(err.Error())
-				return
+				return err
 			}
 			terminal.Info("account name updated")
+			return nil
 		},
 	}
 	return name
 }
+
+// cmdUpdateAccField creates the update command for a plain text account field
+func cmdUpdateAccField(ctx context.Context, sherlock *internal.Sherlock, field string, opt func(string) internal.StateOption) *cobra.Command {
+	return &cobra.Command{
+		Use:   field,
+		Short: fmt.Sprintf("change account %s", field),
+		Long:  fmt.Sprintf("allows to change/update the %s of an existing account", field),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupKey, err := readGroupKey(args[0])
+			if err != nil {
+				return err
+			}
+			value, err := terminal.ReadLine("(%s) new %s: ", args[0], field)
+			if err != nil {
+				return err
+			}
+			if err := sherlock.UpdateState(ctx, args[0], groupKey, opt(value)); err != nil {
+				return err
+			}
+			terminal.Info("account %s updated", field)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/KonstantinGasser/sherlock/webhook"
+	"github.com/spf13/cobra"
+)
+
+func cmdWebhook(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	hook := &cobra.Command{
+		Use:   "webhook",
+		Short: "notify an https endpoint about vault changes",
+		Long:  "send a signed event (group, account, operation, timestamp) to an https endpoint after every change. Events never contain secrets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	hook.AddCommand(cmdWebhookSet(ctx, sherlock))
+	hook.AddCommand(cmdWebhookRemove(ctx, sherlock))
+	hook.AddCommand(cmdWebhookTest(ctx, sherlock))
+
+	return hook
+}
+
+func cmdWebhookSet(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set",
+		Short: "set the webhook url",
+		Long:  "set the https url events are sent to. A new secret is created to sign the events; it is shown once and must be set up at the receiving end",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret, err := sherlock.SetWebhook(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			terminal.Success("events are sent to %s", args[0])
+			terminal.Info("verify the %s header (HMAC-SHA256 of the body) with the secret: %s", webhook.SignatureHeader, secret)
+			return nil
+		},
+	}
+}
+
+func cmdWebhookRemove(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove",
+		Short: "stop sending events",
+		Long:  "remove the webhook url so no more events are sent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sherlock.RemoveWebhook(ctx); err != nil {
+				return err
+			}
+			terminal.Success("webhook removed")
+			return nil
+		},
+	}
+}
+
+func cmdWebhookTest(ctx context.Context, sherlock *internal.Sherlock) *cobra.Command {
+	return &cobra.Command{
+		Use:   "test",
+		Short: "send a test event",
+		Long:  "send a test event to the webhook to check the endpoint and the signature verification",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url, secret, err := sherlock.Webhook(ctx)
+			if err != nil {
+				return err
+			}
+			event := internal.ChangeEvent{Operation: "test", Timestamp: time.Now().UTC()}
+			if err := webhook.Send(ctx, http.DefaultClient, url, secret, event); err != nil {
+				return err
+			}
+			terminal.Success("test event delivered to %s", url)
+			return nil
+		},
+	}
+}
+
+// notifyWebhook returns the Observer sending change events to the configured
+// webhook. A failed delivery is reported but does not fail the command since
+// the change has already been written
+func notifyWebhook(sherlock *internal.Sherlock) internal.Observer {
+	return func(ctx context.Context, events []internal.ChangeEvent) {
+		url, secret, err := sherlock.Webhook(ctx)
+		if err != nil {
+			if !errors.Is(err, internal.ErrNoWebhook) {
+				terminal.Warning("webhook: %v", err)
+			}
+			return
+		}
+		for _, e := range events {
+			if err := webhook.Send(ctx, http.DefaultClient, url, secret, e); err != nil {
+				terminal.Warning("webhook: %v", err)
+				return
+			}
+		}
+	}
+}
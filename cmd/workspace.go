@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/storage"
+	"github.com/KonstantinGasser/sherlock/terminal"
+	"github.com/spf13/cobra"
+)
+
+func cmdWorkspace() *cobra.Command {
+	workspace := &cobra.Command{
+		Use:   "workspace",
+		Short: "switch between vaults stored in different places",
+		Long: "a workspace is a named vault location - a local directory or a remote - with its own groups, like a kubectl context. " +
+			"The default workspace is the one set up by sherlock setup or sherlock init. SHERLOCK_WORKSPACE selects a workspace for a single command",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	workspace.AddCommand(cmdWorkspaceList())
+	workspace.AddCommand(cmdWorkspaceUse())
+	workspace.AddCommand(cmdWorkspaceAdd())
+
+	return workspace
+}
+
+func cmdWorkspaceList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the workspaces marking the current one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w, err := storage.LoadWorkspaces()
+			if err != nil {
+				return err
+			}
+			active := w.Active()
+			var rows [][]string
+			for _, name := range w.Names() {
+				config, ok := w.Workspaces[name]
+				if !ok {
+					if config, err = storage.LoadDefaultConfig(); err != nil {
+						return err
+					}
+				}
+				current := ""
+				if name == active {
+					current = "*"
+				}
+				rows = append(rows, []string{current, name, config.Backend, workspaceLocation(config)})
+			}
+			terminal.ToTable([]string{"Current", "Workspace", "Backend", "Location"}, rows)
+			return nil
+		},
+	}
+}
+
+// workspaceLocation describes where the vaults of a workspace are stored
+func workspaceLocation(config storage.Config) string {
+	switch config.Backend {
+	case storage.Local:
+		if dir := config.Options["dir"]; dir != "" {
+			return dir
+		}
+		return filepath.Dir(storage.ConfigPath())
+	case storage.SFTP:
+		return config.Options["host"] + ":" + config.Options["path"]
+	}
+	if url := config.Options["url"]; url != "" {
+		return url
+	}
+	return "-"
+}
+
+func cmdWorkspaceUse() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <workspace>",
+		Short: "make a workspace the current one",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			w, _ := storage.LoadWorkspaces()
+			return w.Names(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w, err := storage.LoadWorkspaces()
+			if err != nil {
+				return err
+			}
+			if err := w.Use(args[0]); err != nil {
+				return err
+			}
+			if err := storage.WriteWorkspaces(w); err != nil {
+				return err
+			}
+			if env := os.Getenv(storage.EnvWorkspace); env != "" && env != args[0] {
+				terminal.Warning("%s=%s still overrides the current workspace in this shell", storage.EnvWorkspace, env)
+			}
+			terminal.Success("switched to workspace %s", args[0])
+			return nil
+		},
+	}
+}
+
+type workspaceAddOptions struct {
+	dir  string
+	from string
+	use  bool
+}
+
+func cmdWorkspaceAdd() *cobra.Command {
+	var opts workspaceAddOptions
+	add := &cobra.Command{
+		Use:   "add <workspace>",
+		Short: "add a workspace with its own vaults",
+		Long: "add a workspace storing its vaults in a local directory (--dir, default ~/.sherlock/workspaces/<workspace>) or on " +
+			"a remote (--from, any url sherlock init --from accepts). Set up a new local workspace with sherlock setup after switching to it",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.dir != "" && opts.from != "" {
+				return fmt.Errorf("%w: use either --dir or --from", internal.ErrInvalidInput)
+			}
+			config := storage.Config{Backend: storage.Local, Options: map[string]string{"dir": storage.WorkspaceDir(args[0])}}
+			if opts.dir != "" {
+				dir, err := filepath.Abs(opts.dir)
+				if err != nil {
+					return err
+				}
+				config.Options["dir"] = dir
+			}
+			if opts.from != "" {
+				var err error
+				if config, err = storage.FromURL(opts.from); err != nil {
+					return err
+				}
+			}
+			w, err := storage.LoadWorkspaces()
+			if err != nil {
+				return err
+			}
+			if err := w.Add(args[0], config); err != nil {
+				return err
+			}
+			if opts.use {
+				_ = w.Use(args[0])
+			}
+			if err := storage.WriteWorkspaces(w); err != nil {
+				return err
+			}
+			terminal.Success("workspace %s added (%s)", args[0], workspaceLocation(config))
+			if !opts.use {
+				terminal.Info("switch to it with: sherlock workspace use %s", args[0])
+			}
+			return nil
+		},
+	}
+	add.Flags().StringVar(&opts.dir, "dir", "", "local directory of the vaults")
+	add.Flags().StringVar(&opts.from, "from", "", "url of a remote storing the vaults like s3://bucket/prefix or sftp://host/path")
+	add.Flags().BoolVar(&opts.use, "use", false, "switch to the workspace")
+
+	return add
+}
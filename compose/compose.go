@@ -0,0 +1,126 @@
+// Package compose finds sherlock references in docker compose files
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// prefix of values referencing a sherlock account => sherlock://group@account
+const referencePrefix = "sherlock://"
+
+var (
+	ErrInvalidFile = fmt.Errorf("invalid compose file")
+)
+
+// File is the part of a compose file which can hold references. Secrets
+// reference an account with the x-sherlock extension field:
+//
+//	secrets:
+//	  db_password:
+//	    external: true
+//	    x-sherlock: sherlock://work@db
+type File struct {
+	Services map[string]Service `yaml:"services"`
+	Secrets  map[string]Secret  `yaml:"secrets"`
+}
+
+// Service is a compose service. Only its environment is of interest
+type Service struct {
+	Environment Environment `yaml:"environment"`
+}
+
+// Secret is a top level compose secret
+type Secret struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+	Sherlock string `yaml:"x-sherlock"`
+}
+
+// Environment are the environment variables of a service. Compose allows
+// a mapping (KEY: value) as well as a list (- KEY=value)
+type Environment map[string]string
+
+// UnmarshalYAML accepts both forms of a service environment
+func (e *Environment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	env := make(map[string]string)
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, entry := range list {
+			set := strings.SplitN(entry, "=", 2)
+			if len(set) == 2 {
+				env[set[0]] = set[1]
+			}
+		}
+		*e = env
+		return nil
+	}
+	if err := unmarshal(&env); err != nil {
+		return err
+	}
+	*e = env
+	return nil
+}
+
+// Ref is a reference found in a compose file
+type Ref struct {
+	// Service of an environment variable; empty for secrets
+	Service string
+	// Name of the secret or environment variable
+	Name string
+	// Value is the sherlock:// reference
+	Value string
+}
+
+// Parse reads a compose file
+func Parse(b []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return &f, nil
+}
+
+// SecretRefs returns the references of the external secrets sorted by
+// their name. The name of a secret is its name field, if set, else its key
+func (f File) SecretRefs() ([]Ref, error) {
+	var refs []Ref
+	for key, s := range f.Secrets {
+		if s.Sherlock == "" {
+			continue
+		}
+		if !s.External {
+			return nil, fmt.Errorf("%w: secret %q with x-sherlock must be external", ErrInvalidFile, key)
+		}
+		name := key
+		if s.Name != "" {
+			name = s.Name
+		}
+		refs = append(refs, Ref{Name: name, Value: s.Sherlock})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name < refs[j].Name })
+	return refs, nil
+}
+
+// EnvRefs returns the environment variables referencing an account sorted
+// by service and variable name
+func (f File) EnvRefs() []Ref {
+	var refs []Ref
+	for service, s := range f.Services {
+		for name, value := range s.Environment {
+			if strings.HasPrefix(value, referencePrefix) {
+				refs = append(refs, Ref{Service: service, Name: name, Value: value})
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Service != refs[j].Service {
+			return refs[i].Service < refs[j].Service
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
@@ -0,0 +1,67 @@
+package compose
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const composeFile = `
+services:
+  api:
+    image: api:latest
+    environment:
+      DB_USER: sherlock://work@db#username
+      DB_PASSWORD: sherlock://work@db
+      LOG_LEVEL: debug
+  worker:
+    image: worker:latest
+    environment:
+      - QUEUE_TOKEN=sherlock://work@queue
+      - WORKERS=4
+    secrets:
+      - tls_key
+secrets:
+  tls_key:
+    external: true
+    name: api_tls_key
+    x-sherlock: sherlock://work@tls#note
+  ca:
+    file: ./ca.pem
+`
+
+func TestRefs(t *testing.T) {
+	f, err := Parse([]byte(composeFile))
+	if err != nil {
+		t.Fatalf("compose.Parse: want: nil, have: %v", err)
+	}
+	secrets, err := f.SecretRefs()
+	if err != nil {
+		t.Fatalf("compose.File.SecretRefs: want: nil, have: %v", err)
+	}
+	wantSecrets := []Ref{{Name: "api_tls_key", Value: "sherlock://work@tls#note"}}
+	if !reflect.DeepEqual(secrets, wantSecrets) {
+		t.Fatalf("compose.File.SecretRefs: want: %v, have: %v", wantSecrets, secrets)
+	}
+	wantEnv := []Ref{
+		{Service: "api", Name: "DB_PASSWORD", Value: "sherlock://work@db"},
+		{Service: "api", Name: "DB_USER", Value: "sherlock://work@db#username"},
+		{Service: "worker", Name: "QUEUE_TOKEN", Value: "sherlock://work@queue"},
+	}
+	if env := f.EnvRefs(); !reflect.DeepEqual(env, wantEnv) {
+		t.Fatalf("compose.File.EnvRefs: want: %v, have: %v", wantEnv, env)
+	}
+}
+
+func TestSecretRefsNotExternal(t *testing.T) {
+	f, err := Parse([]byte("secrets:\n  db:\n    file: ./db\n    x-sherlock: sherlock://work@db\n"))
+	if err != nil {
+		t.Fatalf("compose.Parse: want: nil, have: %v", err)
+	}
+	if _, err := f.SecretRefs(); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("compose.File.SecretRefs: want: %v, have: %v", ErrInvalidFile, err)
+	}
+	if _, err := Parse([]byte("services: [")); !errors.Is(err, ErrInvalidFile) {
+		t.Fatalf("compose.Parse: want: %v, have: %v", ErrInvalidFile, err)
+	}
+}
@@ -16,6 +16,15 @@ const (
 	groupsDir     = "groups"
 	defaultGroup  = "default"
 	vaultFileName = ".vault"
+	sigFileName   = ".vault.sig"
+	deviceKeyFile = "device.key"
+	iconsDir      = "icons"
+	configFile    = "config.json"
+	webhookFile   = "webhook.key"
+	keyringFile   = "keyring"
+	unlockLogFile = "unlock.log"
+	revisionsFile = "revisions.json"
+	tmpSuffix     = ".tmp"
 )
 
 var (
@@ -24,6 +33,8 @@ var (
 	ErrGroupExists = fmt.Errorf("group already exists")
 )
 
+// Fs stores sherlock's files on an afero.Fs. Local file operations cannot be
+// interrupted so every method checks the context before it touches a file
 type Fs struct {
 	mock afero.Fs
 }
@@ -35,13 +46,19 @@ func New(mock afero.Fs) *Fs {
 }
 
 // ReadVault reads the stored .vault file
-func (fs Fs) ReadGroupVault(group string) ([]byte, error) {
+func (fs Fs) ReadGroupVault(ctx context.Context, group string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return afero.ReadFile(fs.mock, buildVaultPath(group))
 }
 
 // InitFs creates all directories required to be setup to use
 // sherlock. If the directory exists nothing happens
-func (fs Fs) InitFs(initVault []byte) error {
+func (fs Fs) InitFs(ctx context.Context, initVault []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err := fs.mock.MkdirAll(filepath.Join(homepath(), sherlockRoot, groupsDir, defaultGroup), 0777); err != nil {
 		return err
 	}
@@ -61,7 +78,10 @@ func (fs Fs) InitFs(initVault []byte) error {
 // CreateGroup creates a new directory for a given group with its .vault file.
 // if the group already exists it will be overwritten! To check if a group exists you should use the
 // fs.GroupExists func
-func (fs Fs) CreateGroup(name string, initVault []byte) error {
+func (fs Fs) CreateGroup(ctx context.Context, name string, initVault []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err := fs.mock.MkdirAll(filepath.Join(homepath(), sherlockRoot, groupsDir, name), 0777); err != nil {
 		return err
 	}
@@ -77,7 +97,10 @@ func (fs Fs) CreateGroup(name string, initVault []byte) error {
 	return nil
 }
 
-func (fs Fs) GroupExists(name string) error {
+func (fs Fs) GroupExists(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	_, err := fs.mock.Stat(buildGroupPath(name))
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -88,7 +111,10 @@ func (fs Fs) GroupExists(name string) error {
 	return ErrGroupExists
 }
 
-func (fs Fs) VaultExists(group string) error {
+func (fs Fs) VaultExists(ctx context.Context, group string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	_, err := fs.mock.Stat(buildVaultPath(group))
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -101,14 +127,179 @@ func (fs Fs) VaultExists(group string) error {
 
 // Delete removes the passed in group directory irreversible from sherlock
 func (fs Fs) Delete(ctx context.Context, gid string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return fs.mock.RemoveAll(buildGroupPath(gid))
 }
 
+// Write replaces the group's .vault file. The data is written to a temporary
+// file first which is then renamed so a vault is never left half written
 func (fs Fs) Write(ctx context.Context, gid string, data []byte) error {
-	if err := afero.WriteFile(fs.mock, buildVaultPath(gid), data, os.ModeAppend); err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	return nil
+	tmp := buildVaultPath(gid) + tmpSuffix
+	if err := afero.WriteFile(fs.mock, tmp, data, 0600); err != nil {
+		return err
+	}
+	// a cancelled write must leave the previous vault in place
+	if err := ctx.Err(); err != nil {
+		_ = fs.mock.Remove(tmp)
+		return err
+	}
+	return fs.mock.Rename(tmp, buildVaultPath(gid))
+}
+
+// ReadDeviceKey reads the device key used to sign vaults. If signing has
+// not been enabled an os.ErrNotExist error is returned
+func (fs Fs) ReadDeviceKey(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, filepath.Join(homepath(), sherlockRoot, deviceKeyFile))
+}
+
+// WriteDeviceKey stores the device key readable only by the current user
+func (fs Fs) WriteDeviceKey(ctx context.Context, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, filepath.Join(homepath(), sherlockRoot, deviceKeyFile), key, 0600)
+}
+
+// ReadSignature reads the signature stored next to the group's .vault file
+func (fs Fs) ReadSignature(ctx context.Context, gid string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, buildSignaturePath(gid))
+}
+
+// WriteSignature replaces the signature stored next to the group's .vault file
+func (fs Fs) WriteSignature(ctx context.Context, gid string, sig []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, buildSignaturePath(gid), sig, 0600)
+}
+
+// ReadIcon reads a cached icon. If the icon is not cached an os.ErrNotExist
+// error is returned
+func (fs Fs) ReadIcon(ctx context.Context, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, buildIconPath(name))
+}
+
+// WriteIcon stores an icon in the icon cache
+func (fs Fs) WriteIcon(ctx context.Context, name string, icon []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := fs.mock.MkdirAll(buildIconPath(""), 0700); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, buildIconPath(name), icon, 0600)
+}
+
+// ClearIcons removes all cached icons
+func (fs Fs) ClearIcons(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fs.mock.RemoveAll(buildIconPath(""))
+}
+
+// ReadConfig reads the sherlock config. If no config has been written
+// an os.ErrNotExist error is returned
+func (fs Fs) ReadConfig(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, filepath.Join(homepath(), sherlockRoot, configFile))
+}
+
+// WriteConfig replaces the sherlock config
+func (fs Fs) WriteConfig(ctx context.Context, config []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, filepath.Join(homepath(), sherlockRoot, configFile), config, 0600)
+}
+
+// VaultPath returns the location of the vault file of a group
+func VaultPath(gid string) string {
+	return buildVaultPath(gid)
+}
+
+// ReadWebhookKey reads the secret webhook events are signed with. If no
+// webhook has been set an os.ErrNotExist error is returned
+func (fs Fs) ReadWebhookKey(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, filepath.Join(homepath(), sherlockRoot, webhookFile))
+}
+
+// WriteWebhookKey stores the webhook secret readable only by the current user
+func (fs Fs) WriteWebhookKey(ctx context.Context, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, filepath.Join(homepath(), sherlockRoot, webhookFile), key, 0600)
+}
+
+// ReadKeyring reads the sealed keyring. If no keyring has been created an
+// os.ErrNotExist error is returned
+func (fs Fs) ReadKeyring(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, filepath.Join(homepath(), sherlockRoot, keyringFile))
+}
+
+// WriteKeyring stores the sealed keyring readable only by the current user
+func (fs Fs) WriteKeyring(ctx context.Context, keyring []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, filepath.Join(homepath(), sherlockRoot, keyringFile), keyring, 0600)
+}
+
+// ReadUnlockLog reads the log of unlock attempts. If nothing has been logged
+// yet an os.ErrNotExist error is returned
+func (fs Fs) ReadUnlockLog(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, filepath.Join(homepath(), sherlockRoot, unlockLogFile))
+}
+
+// WriteUnlockLog stores the log of unlock attempts readable only by the current user
+func (fs Fs) WriteUnlockLog(ctx context.Context, log []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, filepath.Join(homepath(), sherlockRoot, unlockLogFile), log, 0600)
+}
+
+// ReadRevisions reads the last verified vault revisions. If no vault has been
+// signed yet an os.ErrNotExist error is returned
+func (fs Fs) ReadRevisions(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(fs.mock, filepath.Join(homepath(), sherlockRoot, revisionsFile))
+}
+
+// WriteRevisions stores the last verified vault revisions next to the device key
+func (fs Fs) WriteRevisions(ctx context.Context, revisions []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs.mock, filepath.Join(homepath(), sherlockRoot, revisionsFile), revisions, 0600)
 }
 
 func buildGroupPath(gid string) string {
@@ -121,13 +312,28 @@ func buildVaultPath(gid string) string {
 	return filepath.Join(homepath(), sherlockRoot, groupsDir, gid, vaultFileName)
 }
 
+// buildSignaturePath creates a file path like
+// => $HOME/.sherlock/groups/{group}/.vault.sig
+func buildSignaturePath(gid string) string {
+	return filepath.Join(homepath(), sherlockRoot, groupsDir, gid, sigFileName)
+}
+
+// buildIconPath creates a file path like
+// => $HOME/.sherlock/icons/{name}
+func buildIconPath(name string) string {
+	return filepath.Join(homepath(), sherlockRoot, iconsDir, name)
+}
+
 func homepath() string {
 	home, _ := os.UserHomeDir()
 	return home
 }
 
 // Read All Groups Saved
-func (fs Fs) ReadRegisteredGroups() ([]string, error) {
+func (fs Fs) ReadRegisteredGroups(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	groupList, err := afero.ReadDir(fs.mock, buildGroupPath(""))
 	if err != nil {
 		return nil, err
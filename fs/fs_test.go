@@ -3,9 +3,11 @@ package fs
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 )
@@ -22,7 +24,7 @@ func TestInitFs(t *testing.T) {
 		mock: afero.NewMemMapFs(),
 	}
 
-	err := f.InitFs(defaultInitVault)
+	err := f.InitFs(context.Background(), defaultInitVault)
 	if err != nil {
 		t.Fatalf("Fs.InitFs: want: nil, have: %v", err)
 	}
@@ -50,7 +52,7 @@ func TestCreateGroup(t *testing.T) {
 		mock: afero.NewMemMapFs(),
 	}
 
-	err := f.CreateGroup(testGroup, defaultInitVault)
+	err := f.CreateGroup(context.Background(), testGroup, defaultInitVault)
 	if err != nil {
 		t.Fatalf("fs.CreateGroup: want: nil, have: %v", err)
 	}
@@ -71,7 +73,7 @@ func TestWrite(t *testing.T) {
 	}
 
 	testGroup := "test-group"
-	err := f.CreateGroup(testGroup, defaultInitVault)
+	err := f.CreateGroup(context.Background(), testGroup, defaultInitVault)
 	if err != nil {
 		t.Fatalf("fs.CreateGroup: want: nil, have: %v", err)
 	}
@@ -91,3 +93,139 @@ func TestWrite(t *testing.T) {
 	}
 
 }
+
+func TestCancelledWrite(t *testing.T) {
+	f := Fs{
+		mock: afero.NewMemMapFs(),
+	}
+	testGroup := "test-group"
+	if err := f.CreateGroup(context.Background(), testGroup, defaultInitVault); err != nil {
+		t.Fatalf("fs.CreateGroup: want: nil, have: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.Write(ctx, testGroup, dummyWriteContent); err != context.Canceled {
+		t.Fatalf("fs.Write: want: %v, have: %v", context.Canceled, err)
+	}
+	if _, err := f.ReadGroupVault(ctx, testGroup); err != context.Canceled {
+		t.Fatalf("fs.ReadGroupVault: want: %v, have: %v", context.Canceled, err)
+	}
+	vault, err := f.ReadGroupVault(context.Background(), testGroup)
+	if err != nil {
+		t.Fatalf("fs.ReadGroupVault: want: nil, have: %v", err)
+	}
+	if !bytes.Equal(vault, defaultInitVault) {
+		t.Fatalf("fs.Write: cancelled write changed the vault: %s", vault)
+	}
+}
+
+func TestIcons(t *testing.T) {
+	f := Fs{
+		mock: afero.NewMemMapFs(),
+	}
+	if _, err := f.ReadIcon(context.Background(), "github"); !os.IsNotExist(err) {
+		t.Fatalf("fs.ReadIcon: want: %v, have: %v", os.ErrNotExist, err)
+	}
+	if err := f.WriteIcon(context.Background(), "github", dummyWriteContent); err != nil {
+		t.Fatalf("fs.WriteIcon: want: nil, have: %v", err)
+	}
+	icon, err := f.ReadIcon(context.Background(), "github")
+	if err != nil {
+		t.Fatalf("fs.ReadIcon: want: nil, have: %v", err)
+	}
+	if !bytes.Equal(icon, dummyWriteContent) {
+		t.Fatalf("fs.ReadIcon: want: %v, have: %v", dummyWriteContent, icon)
+	}
+	if err := f.ClearIcons(context.Background()); err != nil {
+		t.Fatalf("fs.ClearIcons: want: nil, have: %v", err)
+	}
+	if _, err := f.ReadIcon(context.Background(), "github"); !os.IsNotExist(err) {
+		t.Fatalf("fs.ReadIcon: want: %v, have: %v", os.ErrNotExist, err)
+	}
+}
+
+func TestOnTmpfs(t *testing.T) {
+	mounts := []byte(`/dev/sda1 / ext4 rw,relatime 0 0
+tmpfs /run tmpfs rw,nosuid,nodev 0 0
+tmpfs /run/user/1000 tmpfs rw,nosuid,nodev,mode=700 0 0
+/dev/sdb1 /run/user/1000/disk ext4 rw 0 0
+tmpfs /mnt/my\040secrets tmpfs rw 0 0
+tmpfs /dev/shm tmpfs rw,nosuid,nodev 0 0
+`)
+	tt := []struct {
+		path  string
+		tmpfs bool
+	}{
+		{path: "/home/sherlock", tmpfs: false},
+		{path: "/run/user/1000/sherlock", tmpfs: true},
+		{path: "/run/user/1000/disk/sherlock", tmpfs: false},
+		{path: "/run/user/10000", tmpfs: true},
+		{path: "/mnt/my secrets/env", tmpfs: true},
+		{path: "/dev/shmem", tmpfs: false},
+		{path: "/dev/shm", tmpfs: true},
+	}
+
+	for _, tc := range tt {
+		if have := onTmpfs(mounts, tc.path); have != tc.tmpfs {
+			t.Fatalf("fs.onTmpfs(%s): want: %v, have: %v", tc.path, tc.tmpfs, have)
+		}
+	}
+}
+
+func TestWipe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-wipe")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: want: nil, have: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, ".sherlock")
+	for _, f := range []string{"config.json", "groups/default/.vault", "groups/work/.vault", "groups/work/.vault.sig"} {
+		p := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatalf("os.MkdirAll: want: nil, have: %v", err)
+		}
+		if err := ioutil.WriteFile(p, dummyWriteContent, 0600); err != nil {
+			t.Fatalf("ioutil.WriteFile: want: nil, have: %v", err)
+		}
+	}
+
+	n, err := Wipe(root)
+	if err != nil || n != 4 {
+		t.Fatalf("fs.Wipe: want: 4 <nil>, have: %d %v", n, err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("fs.Wipe: want: %v, have: %v", os.ErrNotExist, err)
+	}
+	if n, err := Wipe(root); err != nil || n != 0 {
+		t.Fatalf("fs.Wipe(missing): want: 0 <nil>, have: %d %v", n, err)
+	}
+}
+
+func TestLockGroup(t *testing.T) {
+	f := Fs{mock: afero.NewMemMapFs()}
+	ctx := context.Background()
+	if err := f.InitFs(ctx, defaultInitVault); err != nil {
+		t.Fatalf("Fs.InitFs: want: nil, have: %v", err)
+	}
+	unlock, err := f.LockGroup(ctx, defaultGroup)
+	if err != nil {
+		t.Fatalf("Fs.LockGroup: want: nil, have: %v", err)
+	}
+	// a second process waits until the lock is released
+	waiting, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := f.LockGroup(waiting, defaultGroup); err != context.DeadlineExceeded {
+		t.Fatalf("Fs.LockGroup(locked): want: %v, have: %v", context.DeadlineExceeded, err)
+	}
+	groups, err := f.ReadRegisteredGroups(ctx)
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("Fs.ReadRegisteredGroups: want: [default] <nil>, have: %v %v", groups, err)
+	}
+	unlock()
+	unlock, err = f.LockGroup(ctx, defaultGroup)
+	if err != nil {
+		t.Fatalf("Fs.LockGroup(released): want: nil, have: %v", err)
+	}
+	unlock()
+}
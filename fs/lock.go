@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// lockSuffix names the lock directory next to the file it guards
+	lockSuffix = ".lock"
+	// lockPoll is how often a held lock is tried again
+	lockPoll = 10 * time.Millisecond
+	// lockWait is how long a lock held by another process is waited for
+	lockWait = 10 * time.Second
+	// lockStale is the age after which a lock is taken over since the process
+	// holding it must have died without releasing it
+	lockStale = time.Minute
+)
+
+var ErrGroupLocked = fmt.Errorf("group is locked by another sherlock process")
+
+// Lock takes an exclusive lock between processes by creating the directory
+// name on the file system, which either succeeds or fails atomically. It waits
+// for a lock held by another process and returns the function releasing it.
+// If the directory holding name does not exist there is nothing to guard and
+// no lock is taken
+func Lock(ctx context.Context, fsys afero.Fs, name string) (func(), error) {
+	if _, err := fsys.Stat(filepath.Dir(name)); os.IsNotExist(err) {
+		return func() {}, nil
+	}
+	deadline := time.Now().Add(lockWait)
+	for {
+		err := fsys.Mkdir(name, 0700)
+		if err == nil {
+			return func() { _ = fsys.RemoveAll(name) }, nil
+		}
+		info, statErr := fsys.Stat(name)
+		if statErr != nil && !os.IsNotExist(statErr) {
+			return nil, err
+		}
+		// file systems without modification times never take a lock over
+		if statErr == nil && !info.ModTime().IsZero() && time.Since(info.ModTime()) > lockStale {
+			_ = fsys.RemoveAll(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrGroupLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
+
+// LockGroup locks the group against changes of other sherlock processes
+func (fs Fs) LockGroup(ctx context.Context, gid string) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Lock(ctx, fs.mock, buildVaultPath(gid)+lockSuffix)
+}
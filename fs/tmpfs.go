@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// mountsFile lists the mounted filesystems of the process. It only exists
+// on linux; elsewhere no directory is considered memory backed
+const mountsFile = "/proc/self/mounts"
+
+var (
+	ErrNoTmpfs = fmt.Errorf("directory is not on a memory backed filesystem (tmpfs)")
+)
+
+// memoryFsTypes are the filesystems which never write their content to disk
+var memoryFsTypes = map[string]bool{"tmpfs": true, "ramfs": true}
+
+// mountEscaper reverts the octal escaping of white space in mount points
+var mountEscaper = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+// RequireTmpfs returns an ErrNoTmpfs if the existing directory dir is not
+// on a memory backed filesystem or if this cannot be determined
+func RequireTmpfs(dir string) error {
+	path, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+	mounts, err := ioutil.ReadFile(mountsFile)
+	if err != nil || !onTmpfs(mounts, path) {
+		return fmt.Errorf("%w: %s", ErrNoTmpfs, dir)
+	}
+	return nil
+}
+
+// onTmpfs reports whether the mount holding path (the mount point being
+// the longest prefix of path) is memory backed
+func onTmpfs(mounts []byte, path string) bool {
+	var mountPoint, fsType string
+	scanner := bufio.NewScanner(bytes.NewReader(mounts))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		point := mountEscaper.Replace(fields[1])
+		if !within(path, point) || len(point) < len(mountPoint) {
+			continue
+		}
+		mountPoint, fsType = point, fields[2]
+	}
+	return memoryFsTypes[fsType]
+}
+
+// within reports whether path is dir or below dir
+func within(path, dir string) bool {
+	if dir == "/" || path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+"/")
+}
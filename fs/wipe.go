@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Wipe overwrites every regular file at or below path with random data before
+// removing path. It returns the number of files overwritten; a missing path is
+// not an error. Solid state drives and copy-on-write or journaling filesystems
+// may keep copies of the old content the overwrite cannot reach
+func Wipe(path string) (int, error) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return 0, nil
+	}
+	var files []string
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(files)
+	for i, f := range files {
+		if err := overwrite(f); err != nil {
+			return i, err
+		}
+	}
+	return len(files), os.RemoveAll(path)
+}
+
+// overwrite replaces the content of the file with random data of the same
+// size and flushes it to disk
+func overwrite(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -8,10 +8,15 @@ require (
 	github.com/enescakir/emoji v1.0.0
 	github.com/fatih/color v1.7.0
 	github.com/m1/go-generate-password v0.0.0-20191114193340-84682ecbc3fd
+	github.com/makiuchi-d/gozxing v0.0.2
+	github.com/mattn/go-runewidth v0.0.9
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/spf13/afero v1.1.2
 	github.com/spf13/cobra v1.1.3
+	github.com/spf13/pflag v1.0.5
 	github.com/wagslane/go-password-validator v0.3.0
 	golang.org/x/crypto v0.0.0-20190605123033-f99c8df09eb5
+	golang.org/x/text v0.3.5
 	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
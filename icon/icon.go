@@ -0,0 +1,44 @@
+package icon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxSize is the largest icon accepted
+const maxSize = 256 << 10
+
+var ErrNoIcon = fmt.Errorf("no favicon found")
+
+// Fetch downloads the favicon of a host from https://{host}/favicon.ico.
+// Responses which are not an image or larger than 256KiB are rejected
+func Fetch(ctx context.Context, client *http.Client, host string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://"+host+"/favicon.ico", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrNoIcon, resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxSize {
+		return nil, fmt.Errorf("%w: icon larger than %d bytes", ErrNoIcon, maxSize)
+	}
+	if kind := http.DetectContentType(b); !strings.HasPrefix(kind, "image/") {
+		return nil, fmt.Errorf("%w: unexpected content %s", ErrNoIcon, kind)
+	}
+	return b, nil
+}
@@ -0,0 +1,49 @@
+package icon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ico is the header of a windows icon file as served for /favicon.ico
+var ico = []byte{0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x10, 0x10}
+
+// serve starts a TLS server answering /favicon.ico with the body
+func serve(body []byte) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/favicon.ico" || body == nil {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestFetch(t *testing.T) {
+	tt := []struct {
+		name string
+		body []byte
+		err  error
+	}{
+		{name: "icon", body: ico},
+		{name: "missing", body: nil, err: ErrNoIcon},
+		{name: "html", body: []byte("<html><body>not found</body></html>"), err: ErrNoIcon},
+		{name: "large", body: append(ico, bytes.Repeat([]byte{0}, maxSize)...), err: ErrNoIcon},
+	}
+	for _, tc := range tt {
+		srv := serve(tc.body)
+		b, err := Fetch(context.Background(), srv.Client(), strings.TrimPrefix(srv.URL, "https://"))
+		srv.Close()
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("icon.Fetch(%s): want: %v, have: %v", tc.name, tc.err, err)
+		}
+		if err == nil && !bytes.Equal(b, tc.body) {
+			t.Fatalf("icon.Fetch(%s): want: %v, have: %v", tc.name, tc.body, b)
+		}
+	}
+}
@@ -2,41 +2,93 @@ package internal
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/KonstantinGasser/required"
 	"github.com/KonstantinGasser/sherlock/security"
-	"github.com/m1/go-generate-password/generator"
+)
+
+const (
+	// minGeneratedLength is the shortest password which can conform to the password policy
+	minGeneratedLength = 4
+	// maxGenerateAttempts limits how often a password is re-generated to conform to the policy.
+	// Only about one in 15 passwords of minGeneratedLength conforms so the limit must be
+	// high enough to make a failure practically impossible
+	maxGenerateAttempts = 1000
 )
 
 var (
-	ErrInsecurePassword   = fmt.Errorf("provided password is insecure (use --insecure to ignore this message)")
-	ErrInvalidAccountName = fmt.Errorf("account name must be a consecutive string")
-	ErrMissingValues      = fmt.Errorf("account is missing required values")
+	ErrInvalidPasswordLength = fmt.Errorf("password length too short to generate a password")
+	ErrGeneratePassword      = fmt.Errorf("could not generate a password conforming to the password policy")
+	ErrInsecurePassword      = fmt.Errorf("provided password is insecure (use --insecure to ignore this message)")
+	ErrInvalidAccountName    = fmt.Errorf("account name must be a single word without @, / or \\ and not . or ..")
+	ErrMissingValues         = fmt.Errorf("account is missing required values")
+	ErrNoSuchField           = fmt.Errorf("unknown account field (use password, username, url, totp or note)")
+	ErrEmptyField            = fmt.Errorf("account has no value for the field")
+	ErrHOTPField             = fmt.Errorf("hotp codes advance the counter of the vault (use sherlock otp or get --field totp)")
+)
+
+// fields of an account which can be queried with Account.Field
+const (
+	FieldPassword = "password"
+	FieldUsername = "username"
+	FieldURL      = "url"
+	FieldNote     = "note"
+	// FieldTOTP is the current one-time password generated from the otp secret
+	FieldTOTP = "totp"
 )
 
 type Account struct {
-	Name      string    `json:"name" required:"yes"`
-	Password  string    `json:"password" required:"yes"`
+	Name     string `json:"name" required:"yes"`
+	Password string `json:"password" required:"yes"`
+	Username string `json:"username,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Note     string `json:"note,omitempty"`
+	OTP      *OTP   `json:"otp,omitempty"`
+	// RecoveryCodes are one-time codes handed out by Sherlock.UseRecoveryCode
+	RecoveryCodes []RecoveryCode `json:"recovery_codes,omitempty"`
+	// AutoType is the custom auto-type sequence. Empty for the default sequence
+	AutoType string `json:"autotype,omitempty"`
+	// Favorite accounts are pinned on top of listings and pickers
+	Favorite bool `json:"favorite,omitempty"`
+	// Preset is the name of the generator preset new passwords are generated with
+	Preset string `json:"preset,omitempty"`
+	// Link is the group@account query of the account whose password the
+	// account uses instead of its own, see Sherlock.LinkAccount
+	Link string `json:"link,omitempty"`
+	// TimeLock seals the password until a point in time, see OptAccTimeLock
+	TimeLock *TimeLock `json:"time_lock,omitempty"`
+	// Events is the change history of the account
+	Events    []Event   `json:"history,omitempty"`
 	Tag       string    `json:"tag"`
 	CreatedOn time.Time `json:"created_on" required:"yes"`
 	UpdatedOn time.Time `json:"updated_on"`
 }
 
 // NewAccount creates a new Account and if insecure=false checks the password strength
-// returning an err if strength security.Low
-func NewAccount(query, password, tag string, insecure bool) (*Account, error) {
+// returning an err if strength security.Low. Optional details such as the username
+// can be set using WithUsername, WithURL and WithNote
+func NewAccount(query, password, tag string, insecure bool, details ...FieldUpdate) (*Account, error) {
 	_, acc, err := SplitQuery(query)
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	a := Account{
 		Name:      acc,
 		Password:  password,
-		CreatedOn: time.Now(),
-		UpdatedOn: time.Now(),
+		CreatedOn: now,
+		UpdatedOn: now,
 		Tag:       tag,
+		Events:    []Event{{At: now, Kind: EventCreated}},
+	}
+	for _, detail := range details {
+		if err := detail(&a); err != nil {
+			return nil, err
+		}
 	}
 	if err := a.valid(); err != nil {
 		return nil, err
@@ -55,7 +107,7 @@ func (a Account) valid() error {
 	if err := required.Atomic(&a); err != nil {
 		return ErrMissingValues
 	}
-	if set := strings.Split(a.Name, " "); len(set) > 1 {
+	if !validName(a.Name) {
 		return ErrInvalidAccountName
 	}
 	return nil
@@ -72,7 +124,9 @@ func updateFieldName(name string) FieldUpdate {
 
 func updateFieldPassword(password string, insecure bool) FieldUpdate {
 	return func(a *Account) error {
-		a.Password = strings.TrimSpace(password)
+		// an own password replaces the one of the linked account or the
+		// time-locked one
+		a.Password, a.Link, a.TimeLock = strings.TrimSpace(password), "", nil
 		if insecure {
 			a.UpdatedOn = time.Now()
 			return nil
@@ -91,11 +145,116 @@ func updateFieldTag(tag string) FieldUpdate {
 	}
 }
 
+func updateFieldUsername(username string) FieldUpdate {
+	return func(a *Account) error {
+		a.Username = strings.TrimSpace(username)
+		return nil
+	}
+}
+
+func updateFieldURL(url string) FieldUpdate {
+	return func(a *Account) error {
+		a.URL = strings.TrimSpace(url)
+		return nil
+	}
+}
+
+func updateFieldNote(note string) FieldUpdate {
+	return func(a *Account) error {
+		a.Note = strings.TrimSpace(note)
+		return nil
+	}
+}
+
+// WithUsername sets the username of a new account
+func WithUsername(username string) FieldUpdate {
+	return updateFieldUsername(username)
+}
+
+// WithURL sets the url of a new account
+func WithURL(url string) FieldUpdate {
+	return updateFieldURL(url)
+}
+
+// WithNote sets the note of a new account
+func WithNote(note string) FieldUpdate {
+	return updateFieldNote(note)
+}
+
+// Field returns the value of an account field by its name. An ErrEmptyField
+// is returned if the account has no value for the field. The totp field is
+// only generated for TOTP: an HOTP code must advance the counter in the vault
+// which Sherlock.AccountField does
+func (a Account) Field(name string) (string, error) {
+	var value string
+	switch name {
+	case FieldTOTP:
+		if a.OTP == nil {
+			return "", ErrEmptyField
+		}
+		if a.OTP.Type == OTPTypeHOTP {
+			return "", ErrHOTPField
+		}
+		code, _, err := a.OTP.TOTP(time.Now())
+		return code, err
+	case FieldPassword:
+		value = a.Password
+	case FieldUsername:
+		value = a.Username
+	case FieldURL:
+		value = a.URL
+	case FieldNote:
+		value = a.Note
+	default:
+		return "", ErrNoSuchField
+	}
+	if value == "" {
+		return "", ErrEmptyField
+	}
+	return value, nil
+}
+
+// fieldPlaceholder matches the {field} placeholders expanded by Account.Expand
+var fieldPlaceholder = regexp.MustCompile(`\{(password|username|url|note|totp)\}`)
+
+// HasFields reports whether the text holds a {field} placeholder
+func HasFields(text string) bool {
+	return fieldPlaceholder.MatchString(text)
+}
+
+// Expand replaces the placeholders {password}, {username}, {url}, {note} and
+// {totp} of the text with the fields of the account. Other text is kept as is. An
+// ErrEmptyField is returned if a placeholder refers to an empty field
+func (a Account) Expand(text string) (string, error) {
+	var err error
+	expanded := fieldPlaceholder.ReplaceAllStringFunc(text, func(m string) string {
+		name := m[1 : len(m)-1]
+		value, fieldErr := a.Field(name)
+		if fieldErr != nil && err == nil {
+			err = fmt.Errorf("%w: %s", fieldErr, name)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+func updateFieldOTP(otp *OTP) FieldUpdate {
+	return func(a *Account) error {
+		a.OTP = otp
+		return nil
+	}
+}
+
 func (a *Account) update(opt FieldUpdate) error {
+	before := *a
 	if err := opt(a); err != nil {
 		return err
 	}
 	a.UpdatedOn = time.Now()
+	a.record(before)
 	return nil
 }
 
@@ -104,18 +263,27 @@ func (a Account) secure() error {
 	return security.PasswordStrength(a.Password)
 }
 
+// AutoGeneratePassword generates a random password conforming to the password
+// policy: at least one upper case, lower case, number and symbol character
 func AutoGeneratePassword(passwordLength int) (string, error) {
-	config := generator.Config{
-		Length:                     passwordLength,
-		IncludeSymbols:             true,
-		IncludeNumbers:             true,
-		IncludeLowercaseLetters:    true,
-		IncludeUppercaseLetters:    true,
-		ExcludeSimilarCharacters:   true,
-		ExcludeAmbiguousCharacters: true,
-	}
-	g, _ := generator.New(&config)
-
-	pwd, err := g.Generate()
-	return *pwd, err
+	return GeneratePassword(Preset{Length: passwordLength})
+}
+
+// conforms checks that the password has at least one upper case,
+// lower case, number and symbol character
+func conforms(password string) bool {
+	var upper, lower, number, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsNumber(r):
+			number = true
+		default:
+			symbol = true
+		}
+	}
+	return upper && lower && number && symbol
 }
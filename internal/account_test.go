@@ -1,8 +1,12 @@
 package internal
 
 import (
+	"errors"
 	"testing"
+	"time"
 	"unicode"
+
+	"github.com/KonstantinGasser/sherlock/autotype"
 )
 
 func TestNewAccount(t *testing.T) {
@@ -110,3 +114,120 @@ func TestPasswordGenerator(t *testing.T) {
 		t.Fatalf("Password Generator Error. It has to minimal : 1 uppercase, 1 lowercase, 1 symbol, 1 numeric char. got: %s", passwordRandom)
 	}
 }
+
+func TestPasswordGeneratorPolicy(t *testing.T) {
+	if _, err := AutoGeneratePassword(minGeneratedLength - 1); err != ErrInvalidPasswordLength {
+		t.Fatalf("internal.AutoGeneratePassword: want: %v, have: %v", ErrInvalidPasswordLength, err)
+	}
+	// short passwords are most likely to miss a character class
+	for i := 0; i < 50; i++ {
+		password, err := AutoGeneratePassword(minGeneratedLength)
+		if err != nil {
+			t.Fatalf("internal.AutoGeneratePassword: want: nil, have: %v", err)
+		}
+		if !conforms(password) {
+			t.Fatalf("internal.AutoGeneratePassword: password does not conform to the policy: %s", password)
+		}
+	}
+}
+
+func TestAccountField(t *testing.T) {
+	a, err := NewAccount("group@testaccount", "fsdf$35dfg0-43563sdf34", "", false,
+		WithUsername(" sherlock "),
+		WithURL("https://bakerstreet.example"),
+	)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	tt := []struct {
+		field  string
+		value  string
+		expect error
+	}{
+		{field: FieldPassword, value: "fsdf$35dfg0-43563sdf34", expect: nil},
+		{field: FieldUsername, value: "sherlock", expect: nil},
+		{field: FieldURL, value: "https://bakerstreet.example", expect: nil},
+		{field: FieldNote, value: "", expect: ErrEmptyField},
+		{field: FieldTOTP, value: "", expect: ErrEmptyField},
+		{field: "unknown", value: "", expect: ErrNoSuchField},
+	}
+	for _, tc := range tt {
+		value, err := a.Field(tc.field)
+		if err != tc.expect || value != tc.value {
+			t.Fatalf("Account.Field(%s): want: %q %v, have: %q %v", tc.field, tc.value, tc.expect, value, err)
+		}
+	}
+
+	if a.OTP, err = NewOTP(OTPTypeTOTP, rfcSecret); err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	code, _, err := a.OTP.TOTP(time.Now())
+	if err != nil {
+		t.Fatalf("OTP.TOTP: want: nil, have: %v", err)
+	}
+	// the code can roll over between both calls
+	if value, err := a.Field(FieldTOTP); err != nil || len(value) != len(code) {
+		t.Fatalf("Account.Field(totp): want: %q <nil>, have: %q %v", code, value, err)
+	}
+	if a.OTP, err = NewOTP(OTPTypeHOTP, rfcSecret); err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	if value, err := a.Field(FieldTOTP); err != ErrHOTPField {
+		t.Fatalf("Account.Field(totp): want: %v, have: %q %v", ErrHOTPField, value, err)
+	}
+}
+
+func TestAccountExpand(t *testing.T) {
+	account := Account{Name: "mysql", Password: "221b", Username: "root"}
+	tt := []struct {
+		text   string
+		value  string
+		expect error
+	}{
+		{text: "-u{username} -p{password}", value: "-uroot -p221b", expect: nil},
+		{text: "{password}\n{password}", value: "221b\n221b", expect: nil},
+		{text: "${HOME} {PASSWORD} {otp}", value: "${HOME} {PASSWORD} {otp}", expect: nil},
+		{text: "--url={url}", value: "", expect: ErrEmptyField},
+	}
+	for _, tc := range tt {
+		value, err := account.Expand(tc.text)
+		if !errors.Is(err, tc.expect) || value != tc.value {
+			t.Fatalf("Account.Expand(%q): want: %q %v, have: %q %v", tc.text, tc.value, tc.expect, value, err)
+		}
+		if HasFields(tc.text) != (tc.text != tc.value || tc.expect != nil) {
+			t.Fatalf("HasFields(%q): want: %t, have: %t", tc.text, !HasFields(tc.text), HasFields(tc.text))
+		}
+	}
+}
+
+func TestAccountAutoType(t *testing.T) {
+	account := Account{Name: "github", Password: "221b", Username: "sherlock"}
+	keystrokes, err := account.AutoTypeSequence(time.Now())
+	if err != nil {
+		t.Fatalf("account.AutoTypeSequence: want: nil, have: %v", err)
+	}
+	if len(keystrokes) != 4 || keystrokes[0].Text != "sherlock" || keystrokes[2].Text != "221b" {
+		t.Fatalf("account.AutoTypeSequence: want: default sequence, have: %v", keystrokes)
+	}
+
+	if err := account.update(updateFieldAutoType("{PASSWORD}{TOTP}{ENTER}")); err != nil {
+		t.Fatalf("account.update(autotype): want: nil, have: %v", err)
+	}
+	if _, err := account.AutoTypeSequence(time.Now()); !errors.Is(err, ErrNoOTP) {
+		t.Fatalf("account.AutoTypeSequence: want: %v, have: %v", ErrNoOTP, err)
+	}
+	if account.OTP, err = NewOTP(OTPTypeTOTP, rfcSecret); err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	keystrokes, err = account.AutoTypeSequence(time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("account.AutoTypeSequence: want: nil, have: %v", err)
+	}
+	if keystrokes[1].Text != "287082" {
+		t.Fatalf("account.AutoTypeSequence: want: TOTP 287082, have: %v", keystrokes[1])
+	}
+
+	if err := account.update(updateFieldAutoType("{PIN}")); !errors.Is(err, autotype.ErrInvalidSequence) {
+		t.Fatalf("account.update(autotype): want: %v, have: %v", autotype.ErrInvalidSequence, err)
+	}
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidAlias = fmt.Errorf("invalid group alias")
+	ErrNoSuchAlias  = fmt.Errorf("group alias not found")
+)
+
+// ResolveGroup returns the group the alias stands for. Names which are no
+// alias are returned as they are
+func (c Config) ResolveGroup(name string) string {
+	if gid, ok := c.Aliases[name]; ok {
+		return gid
+	}
+	return name
+}
+
+// ResolveQuery replaces an alias in the group of a group@account query
+func (c Config) ResolveQuery(query string) string {
+	set := strings.SplitN(query, querySplitPoint, 2)
+	if len(set) != 2 {
+		return c.ResolveGroup(query)
+	}
+	return c.ResolveGroup(set[0]) + querySplitPoint + set[1]
+}
+
+// SetAlias makes alias a short name of the group. An alias cannot shadow a
+// group and only points to a group, never to another alias
+func (sh Sherlock) SetAlias(ctx context.Context, alias, gid string) error {
+	if alias == "" || strings.ContainsAny(alias, " @") {
+		return fmt.Errorf("%w: %q must be a single word without @", ErrInvalidAlias, alias)
+	}
+	if alias == gid {
+		return fmt.Errorf("%w: %q cannot point to itself", ErrInvalidAlias, alias)
+	}
+	if err := sh.GroupExists(ctx, alias); err != nil {
+		return fmt.Errorf("%w: %q is the name of a group", ErrInvalidAlias, alias)
+	}
+	if err := sh.GroupExists(ctx, gid); err == nil {
+		return sh.GroupNotFound(ctx, gid)
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Aliases == nil {
+		config.Aliases = make(map[string]string)
+	}
+	config.Aliases[alias] = gid
+	return sh.SaveConfig(ctx, config)
+}
+
+// RemoveAlias removes the alias
+func (sh Sherlock) RemoveAlias(ctx context.Context, alias string) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Aliases[alias]; !ok {
+		return fmt.Errorf("%w: %s", ErrNoSuchAlias, alias)
+	}
+	delete(config.Aliases, alias)
+	return sh.SaveConfig(ctx, config)
+}
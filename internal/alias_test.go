@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work-infrastructure", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+
+	if err := sh.SetAlias(ctx, "w", "work-infrastructure"); err != nil {
+		t.Fatalf("sherlock.SetAlias: want: nil, have: %v", err)
+	}
+	tt := []struct {
+		alias, gid string
+		err        error
+	}{
+		{alias: "default", gid: "work-infrastructure", err: ErrInvalidAlias},
+		{alias: "w@x", gid: "work-infrastructure", err: ErrInvalidAlias},
+		{alias: "", gid: "work-infrastructure", err: ErrInvalidAlias},
+		{alias: "p", gid: "private", err: ErrNoSuchGroup},
+	}
+	for _, tc := range tt {
+		if err := sh.SetAlias(ctx, tc.alias, tc.gid); !errors.Is(err, tc.err) {
+			t.Fatalf("sherlock.SetAlias(%q, %q): want: %v, have: %v", tc.alias, tc.gid, tc.err, err)
+		}
+	}
+
+	config, err := sh.Config(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	for in, want := range map[string]string{
+		"w":            "work-infrastructure",
+		"w@github":     "work-infrastructure@github",
+		"default@w":    "default@w",
+		"work@github":  "work@github",
+		"w@mail@forum": "work-infrastructure@mail@forum",
+	} {
+		if have := config.ResolveQuery(in); have != want {
+			t.Fatalf("Config.ResolveQuery(%q): want: %q, have: %q", in, want, have)
+		}
+	}
+
+	if err := sh.RemoveAlias(ctx, "w"); err != nil {
+		t.Fatalf("sherlock.RemoveAlias: want: nil, have: %v", err)
+	}
+	if err := sh.RemoveAlias(ctx, "w"); !errors.Is(err, ErrNoSuchAlias) {
+		t.Fatalf("sherlock.RemoveAlias: want: %v, have: %v", ErrNoSuchAlias, err)
+	}
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrNotArchived = fmt.Errorf("group is not archived")
+)
+
+// Archived reports whether the group has been archived
+func (c Config) Archived(gid string) bool {
+	for _, archived := range c.Archive {
+		if archived == gid {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveGroup moves the group to the archive. Archived groups stay encrypted
+// and can be used by their name but are left out of listings, pickers and
+// completions. Backups and syncs still include them
+func (sh Sherlock) ArchiveGroup(ctx context.Context, gid string) error {
+	if _, err := sh.GroupInfo(ctx, gid); err != nil {
+		if err == ErrNoSuchGroup {
+			return sh.GroupNotFound(ctx, gid)
+		}
+		return err
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Archived(gid) {
+		return nil
+	}
+	config.Archive = append(config.Archive, gid)
+	sort.Strings(config.Archive)
+	return sh.SaveConfig(ctx, config)
+}
+
+// UnarchiveGroup moves the group out of the archive back into daily use
+func (sh Sherlock) UnarchiveGroup(ctx context.Context, gid string) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if !config.Archived(gid) {
+		return fmt.Errorf("%w: %s", ErrNotArchived, gid)
+	}
+	archive := config.Archive[:0]
+	for _, archived := range config.Archive {
+		if archived != gid {
+			archive = append(archive, archived)
+		}
+	}
+	config.Archive = archive
+	return sh.SaveConfig(ctx, config)
+}
+
+// ActiveGroups returns the registered groups which are not archived
+func (sh Sherlock) ActiveGroups(ctx context.Context) ([]string, error) {
+	return sh.archivedGroups(ctx, false)
+}
+
+// ArchivedGroups returns the registered groups which are archived
+func (sh Sherlock) ArchivedGroups(ctx context.Context) ([]string, error) {
+	return sh.archivedGroups(ctx, true)
+}
+
+func (sh Sherlock) archivedGroups(ctx context.Context, archived bool) ([]string, error) {
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(gids))
+	for _, gid := range gids {
+		if config.Archived(gid) == archived {
+			groups = append(groups, gid)
+		}
+	}
+	return groups, nil
+}
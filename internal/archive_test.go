@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestArchiveGroup(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "retired", "retired_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.ArchiveGroup(ctx, "nope"); !errors.Is(err, ErrNoSuchGroup) {
+		t.Fatalf("sherlock.ArchiveGroup: want: %v, have: %v", ErrNoSuchGroup, err)
+	}
+	if err := sh.ArchiveGroup(ctx, "retired"); err != nil {
+		t.Fatalf("sherlock.ArchiveGroup: want: nil, have: %v", err)
+	}
+	active, err := sh.ActiveGroups(ctx)
+	if err != nil || !reflect.DeepEqual(active, []string{"default"}) {
+		t.Fatalf("sherlock.ActiveGroups: want: [default], have: %v (%v)", active, err)
+	}
+	archived, err := sh.ArchivedGroups(ctx)
+	if err != nil || !reflect.DeepEqual(archived, []string{"retired"}) {
+		t.Fatalf("sherlock.ArchivedGroups: want: [retired], have: %v (%v)", archived, err)
+	}
+	// archived groups stay usable by their name
+	if _, err := sh.LoadGroup(ctx, "retired", "retired_group_key"); err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+
+	if err := sh.UnarchiveGroup(ctx, "retired"); err != nil {
+		t.Fatalf("sherlock.UnarchiveGroup: want: nil, have: %v", err)
+	}
+	if err := sh.UnarchiveGroup(ctx, "retired"); !errors.Is(err, ErrNotArchived) {
+		t.Fatalf("sherlock.UnarchiveGroup: want: %v, have: %v", ErrNotArchived, err)
+	}
+	if active, err = sh.ActiveGroups(ctx); err != nil || len(active) != 2 {
+		t.Fatalf("sherlock.ActiveGroups: want: 2 groups, have: %v (%v)", active, err)
+	}
+}
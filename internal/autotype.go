@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/autotype"
+)
+
+// placeholders of an auto-type sequence resolved from the account
+const (
+	autoTypeUsername = "USERNAME"
+	autoTypePassword = "PASSWORD"
+	autoTypeURL      = "URL"
+	autoTypeNote     = "NOTE"
+	autoTypeTOTP     = "TOTP"
+)
+
+func updateFieldAutoType(sequence string) FieldUpdate {
+	return func(a *Account) error {
+		sequence = strings.TrimSpace(sequence)
+		// validate the sequence against all known placeholders
+		if _, err := autotype.Parse(sequence, autotype.Fields{
+			autoTypeUsername: "", autoTypePassword: "", autoTypeURL: "", autoTypeNote: "", autoTypeTOTP: "",
+		}); err != nil {
+			return err
+		}
+		a.AutoType = sequence
+		return nil
+	}
+}
+
+// AutoTypeSequence resolves the auto-type sequence of the account into keystrokes.
+// Accounts without a custom sequence use autotype.DefaultSequence. The {TOTP}
+// placeholder is resolved to the one-time password valid at the point in time
+func (a Account) AutoTypeSequence(t time.Time) ([]autotype.Keystroke, error) {
+	sequence := a.AutoType
+	if sequence == "" {
+		sequence = autotype.DefaultSequence
+	}
+	fields := autotype.Fields{
+		autoTypeUsername: a.Username,
+		autoTypePassword: a.Password,
+		autoTypeURL:      a.URL,
+		autoTypeNote:     a.Note,
+	}
+	if strings.Contains(strings.ToUpper(sequence), "{"+autoTypeTOTP+"}") {
+		if a.OTP == nil || a.OTP.Type != OTPTypeTOTP {
+			return nil, ErrNoOTP
+		}
+		code, _, err := a.OTP.TOTP(t)
+		if err != nil {
+			return nil, err
+		}
+		fields[autoTypeTOTP] = code
+	}
+	return autotype.Parse(sequence, fields)
+}
@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+// signature states of a vault in a ComplianceReport
+const (
+	SignatureValid   = "valid"
+	SignatureInvalid = "invalid"
+)
+
+var (
+	ErrSigningDisabled = fmt.Errorf("vault signing is not enabled (use sherlock sign enable)")
+	ErrInvalidReport   = fmt.Errorf("compliance report is malformed or its signature does not match")
+)
+
+// ComplianceReport describes how the groups comply with the password policy.
+// It never holds any secret or account name
+type ComplianceReport struct {
+	Generated time.Time `json:"generated"`
+	// MaxPasswordAge is the age in days a password is accepted to have
+	MaxPasswordAge int `json:"max_password_age_days"`
+	// KDF is the key derivation vaults are required to use
+	KDF       string            `json:"kdf"`
+	Compliant bool              `json:"compliant"`
+	Groups    []GroupCompliance `json:"groups"`
+}
+
+// GroupCompliance describes how a group complies with the password policy.
+// Findings lists every violation, a compliant group has none
+type GroupCompliance struct {
+	GID           string     `json:"group"`
+	FormatVersion uint8      `json:"format_version"`
+	KDF           string     `json:"kdf"`
+	KDFTime       uint32     `json:"kdf_time,omitempty"`
+	KDFMemory     uint32     `json:"kdf_memory,omitempty"`
+	KDFThreads    uint8      `json:"kdf_threads,omitempty"`
+	Signature     string     `json:"signature"`
+	Accounts      int        `json:"accounts"`
+	Weak          int        `json:"weak"`
+	Outdated      int        `json:"outdated"`
+	Ages          []AgeCount `json:"password_ages"`
+	Findings      []string   `json:"findings,omitempty"`
+	Compliant     bool       `json:"compliant"`
+}
+
+// SignedReport is a ComplianceReport signed with the device key. Report is
+// the report as compact json, the signature is computed over exactly these
+// bytes so it can be verified with any ed25519 implementation
+type SignedReport struct {
+	Report    json.RawMessage `json:"report"`
+	PublicKey []byte          `json:"public_key"`
+	Signature []byte          `json:"signature"`
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the public key the report
+// was signed with
+func (s SignedReport) Fingerprint() string {
+	sum := sha256.Sum256(s.PublicKey)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// Compliance checks the groups against the password policy: vaults encrypted
+// with the current key derivation and a valid signature, no weak passwords
+// and no password older than maxAge. Password ages are relative to now
+func (sh Sherlock) Compliance(ctx context.Context, now time.Time, maxAge time.Duration, groups ...*Group) (ComplianceReport, error) {
+	gids := make([]string, len(groups))
+	for i, g := range groups {
+		gids[i] = g.GID
+	}
+	tampered, err := sh.VerifyVaults(ctx, gids...)
+	if err != nil {
+		return ComplianceReport{}, err
+	}
+	report := ComplianceReport{
+		Generated:      now.UTC(),
+		MaxPasswordAge: int(maxAge / day),
+		KDF:            security.DefaultKDF,
+		Compliant:      true,
+		Groups:         make([]GroupCompliance, 0, len(groups)),
+	}
+	for _, g := range groups {
+		vault, err := sh.readVault(ctx, g.GID)
+		if err != nil {
+			return ComplianceReport{}, err
+		}
+		h, err := security.ReadHeader(vault)
+		if err != nil {
+			return ComplianceReport{}, err
+		}
+		stats := CollectStats(now, g)
+		gc := GroupCompliance{
+			GID:           g.GID,
+			FormatVersion: h.Version,
+			KDF:           h.KDF,
+			KDFTime:       h.Time,
+			KDFMemory:     h.Memory,
+			KDFThreads:    h.Threads,
+			Signature:     SignatureValid,
+			Accounts:      len(g.Accounts),
+			Weak:          stats.Weak,
+			Ages:          stats.Ages,
+		}
+		// linked and time-locked accounts have no password of their own
+		for _, a := range g.Accounts {
+			if !a.Linked() && a.TimeLock == nil && now.Sub(a.PasswordChangedOn()) > maxAge {
+				gc.Outdated++
+			}
+		}
+		if contains(tampered, g.GID) {
+			gc.Signature = SignatureInvalid
+			gc.Findings = append(gc.Findings, "vault was modified outside of sherlock")
+		}
+		if !h.Current() {
+			gc.Findings = append(gc.Findings, "vault is not encrypted with the current key derivation")
+		}
+		if gc.Weak > 0 {
+			gc.Findings = append(gc.Findings, fmt.Sprintf("%d weak password(s)", gc.Weak))
+		}
+		if gc.Outdated > 0 {
+			gc.Findings = append(gc.Findings, fmt.Sprintf("%d password(s) older than %d days", gc.Outdated, report.MaxPasswordAge))
+		}
+		gc.Compliant = len(gc.Findings) == 0
+		report.Compliant = report.Compliant && gc.Compliant
+		report.Groups = append(report.Groups, gc)
+	}
+	return report, nil
+}
+
+// SignReport signs the report with the device key. Signing must be enabled
+func (sh Sherlock) SignReport(ctx context.Context, report ComplianceReport) (SignedReport, error) {
+	key, err := sh.fileSystem.ReadDeviceKey(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return SignedReport{}, ErrSigningDisabled
+		}
+		return SignedReport{}, err
+	}
+	b, err := json.Marshal(report)
+	if err != nil {
+		return SignedReport{}, err
+	}
+	sig, pub, err := security.SignReport(key, b)
+	if err != nil {
+		return SignedReport{}, err
+	}
+	return SignedReport{Report: b, PublicKey: pub, Signature: sig}, nil
+}
+
+// VerifyReport parses a signed report as written by WriteReportJSON and
+// verifies its signature. It needs neither a vault nor a group key
+func VerifyReport(b []byte) (ComplianceReport, SignedReport, error) {
+	var signed SignedReport
+	if err := json.Unmarshal(b, &signed); err != nil {
+		return ComplianceReport{}, SignedReport{}, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	// the report is indented in the file but signed as compact json
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, signed.Report); err != nil {
+		return ComplianceReport{}, SignedReport{}, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	if !security.VerifyReport(signed.PublicKey, compact.Bytes(), signed.Signature) {
+		return ComplianceReport{}, SignedReport{}, ErrInvalidReport
+	}
+	signed.Report = compact.Bytes()
+	var report ComplianceReport
+	if err := json.Unmarshal(signed.Report, &report); err != nil {
+		return ComplianceReport{}, SignedReport{}, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	return report, signed, nil
+}
+
+// WriteReportJSON writes the signed report as indented json
+func WriteReportJSON(w io.Writer, signed SignedReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(signed)
+}
+
+// WriteReportText writes the report in a human-readable form. The text is not
+// signed itself; it names the signature of the json report it was created with
+func WriteReportText(w io.Writer, report ComplianceReport, signed SignedReport) error {
+	status := func(ok bool) string {
+		if ok {
+			return "COMPLIANT"
+		}
+		return "NOT COMPLIANT"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "sherlock compliance report\n\n")
+	fmt.Fprintf(&b, "generated:        %s\n", report.Generated.Format(time.RFC3339))
+	fmt.Fprintf(&b, "status:           %s\n", status(report.Compliant))
+	fmt.Fprintf(&b, "policy:           %s key derivation, signed vaults, no weak passwords, passwords younger than %d days\n", report.KDF, report.MaxPasswordAge)
+	fmt.Fprintf(&b, "signing key:      %s\n", signed.Fingerprint())
+	fmt.Fprintf(&b, "signature (json): %s\n", base64.StdEncoding.EncodeToString(signed.Signature))
+	for _, g := range report.Groups {
+		fmt.Fprintf(&b, "\ngroup %s: %s\n", g.GID, status(g.Compliant))
+		fmt.Fprintf(&b, "  vault:     format v%d, %s (time=%d, memory=%d KiB, threads=%d), signature %s\n",
+			g.FormatVersion, g.KDF, g.KDFTime, g.KDFMemory, g.KDFThreads, g.Signature)
+		fmt.Fprintf(&b, "  accounts:  %d, %d weak, %d outdated\n", g.Accounts, g.Weak, g.Outdated)
+		ages := make([]string, len(g.Ages))
+		for i, a := range g.Ages {
+			ages[i] = fmt.Sprintf("%s: %d", a.Label, a.Count)
+		}
+		fmt.Fprintf(&b, "  ages:      %s\n", strings.Join(ages, ", "))
+		for _, f := range g.Findings {
+			fmt.Fprintf(&b, "  - %s\n", f)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComplianceReport(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+	for query, password := range map[string]string{"default@github": "Sup3r$ecret-pass-github", "default@wiki": "weak"} {
+		account, err := NewAccount(query, password, "", true)
+		if err != nil {
+			t.Fatalf("NewAccount: want: nil, have: %v", err)
+		}
+		if err := sh.UpdateState(ctx, query, key, OptAddAccount(account)); err != nil {
+			t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+		}
+	}
+	group, err := sh.LoadGroup(ctx, "default", key)
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+
+	now := time.Now()
+	report, err := sh.Compliance(ctx, now, 90*day, group)
+	if err != nil {
+		t.Fatalf("sherlock.Compliance: want: nil, have: %v", err)
+	}
+	if _, err := sh.SignReport(ctx, report); !errors.Is(err, ErrSigningDisabled) {
+		t.Fatalf("sherlock.SignReport: want: %v, have: %v", ErrSigningDisabled, err)
+	}
+	if report.Compliant || len(report.Groups) != 1 {
+		t.Fatalf("sherlock.Compliance: want: one non-compliant group, have: %+v", report)
+	}
+	if g := report.Groups[0]; g.Accounts != 2 || g.Weak != 1 || g.Outdated != 0 || g.Signature != SignatureValid || len(g.Findings) != 1 {
+		t.Fatalf("sherlock.Compliance: want: 2 accounts, 1 weak, valid signature, have: %+v", g)
+	}
+	report, err = sh.Compliance(ctx, now.Add(100*day), 90*day, group)
+	if err != nil {
+		t.Fatalf("sherlock.Compliance: want: nil, have: %v", err)
+	}
+	if g := report.Groups[0]; g.Outdated != 2 {
+		t.Fatalf("sherlock.Compliance(100 days later): want: 2 outdated, have: %d", g.Outdated)
+	}
+
+	if err := sh.EnableSigning(ctx); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+	signed, err := sh.SignReport(ctx, report)
+	if err != nil {
+		t.Fatalf("sherlock.SignReport: want: nil, have: %v", err)
+	}
+	var b bytes.Buffer
+	if err := WriteReportJSON(&b, signed); err != nil {
+		t.Fatalf("WriteReportJSON: want: nil, have: %v", err)
+	}
+	verified, vs, err := VerifyReport(b.Bytes())
+	if err != nil {
+		t.Fatalf("VerifyReport: want: nil, have: %v", err)
+	}
+	if vs.Fingerprint() != signed.Fingerprint() || !verified.Generated.Equal(report.Generated) || verified.Groups[0].Outdated != 2 {
+		t.Fatalf("VerifyReport: want: %+v, have: %+v", report, verified)
+	}
+
+	tampered := strings.Replace(b.String(), `"outdated": 2`, `"outdated": 0`, 1)
+	if tampered == b.String() {
+		t.Fatalf("report json: want: outdated field, have: %s", b.String())
+	}
+	if _, _, err := VerifyReport([]byte(tampered)); !errors.Is(err, ErrInvalidReport) {
+		t.Fatalf("VerifyReport(tampered): want: %v, have: %v", ErrInvalidReport, err)
+	}
+
+	var text bytes.Buffer
+	if err := WriteReportText(&text, report, signed); err != nil {
+		t.Fatalf("WriteReportText: want: nil, have: %v", err)
+	}
+	if !strings.Contains(text.String(), "NOT COMPLIANT") || !strings.Contains(text.String(), signed.Fingerprint()) {
+		t.Fatalf("WriteReportText: want: status and signing key, have: %s", text.String())
+	}
+}
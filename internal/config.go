@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+)
+
+// encryption of backup snapshots. Without encryption the vaults in a
+// snapshot are still encrypted with their group keys
+const (
+	BackupEncryptionPassphrase = "passphrase"
+	BackupEncryptionRecipient  = "recipient"
+)
+
+// default retention of backups
+const (
+	defaultKeepDaily   = 7
+	defaultKeepWeekly  = 4
+	defaultKeepMonthly = 12
+)
+
+// Config holds the settings of sherlock stored in ~/.sherlock/config.json.
+// The config never holds secrets
+type Config struct {
+	Backup  BackupConfig  `json:"backup"`
+	Webhook WebhookConfig `json:"webhook"`
+	Hooks   HooksConfig   `json:"hooks"`
+	Sync    SyncConfig    `json:"sync"`
+	// Aliases maps short names to the groups they stand for
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// FoldNames makes lookups case-insensitive and NFC normalized, see Sherlock.FoldNames
+	FoldNames bool `json:"fold_names,omitempty"`
+	// NamePattern has to match new group and account names, see Sherlock.NamePattern
+	NamePattern string `json:"name_pattern,omitempty"`
+	// Archive are the archived groups, see Sherlock.ArchiveGroup
+	Archive []string `json:"archive,omitempty"`
+	// Presets are the named password generator presets, see Preset
+	Presets map[string]Preset `json:"presets,omitempty"`
+	// Reencrypted are the groups an interrupted vault-wide re-encryption has
+	// already re-encrypted, see Sherlock.Reencrypt
+	Reencrypted []string `json:"reencrypted,omitempty"`
+	// Hints are reminders of the group keys shown after failed unlocks (never
+	// the keys themselves), see Sherlock.SetHint
+	Hints map[string]string `json:"hints,omitempty"`
+	// FailedUnlocks counts the failed unlocks in a row of groups with a hint
+	FailedUnlocks map[string]int `json:"failed_unlocks,omitempty"`
+	// NoHints turns passphrase hints off
+	NoHints bool `json:"no_hints,omitempty"`
+	// Undo is the group of the account deleted last, see Sherlock.Undo
+	Undo string `json:"undo,omitempty"`
+}
+
+// SyncConfig records the state of the last peer sync
+type SyncConfig struct {
+	// Revisions maps every group to the revision of its vault after the last sync
+	Revisions map[string]string `json:"revisions,omitempty"`
+}
+
+// BackupConfig configures where backups are stored and how many are kept
+type BackupConfig struct {
+	// Target is the directory backups are written to
+	Target string `json:"target,omitempty"`
+	// Remote is the rclone remote (remote:path) backups are written to instead of Target
+	Remote string `json:"remote,omitempty"`
+	// Encryption of snapshots on top of the group keys (passphrase or recipient)
+	Encryption string `json:"encryption,omitempty"`
+	// Recipient (public key) snapshots are sealed for
+	Recipient   string `json:"recipient,omitempty"`
+	KeepDaily   int    `json:"keep_daily"`
+	KeepWeekly  int    `json:"keep_weekly"`
+	KeepMonthly int    `json:"keep_monthly"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Backup: BackupConfig{
+			KeepDaily:   defaultKeepDaily,
+			KeepWeekly:  defaultKeepWeekly,
+			KeepMonthly: defaultKeepMonthly,
+		},
+	}
+}
+
+// Config reads the sherlock config. If no config has been written yet the
+// default config is returned
+func (sh Sherlock) Config(ctx context.Context) (Config, error) {
+	config := defaultConfig()
+	b, err := sh.fileSystem.ReadConfig(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, err
+	}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// SaveConfig replaces the sherlock config
+func (sh Sherlock) SaveConfig(ctx context.Context, config Config) error {
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return sh.fileSystem.WriteConfig(ctx, b)
+}
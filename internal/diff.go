@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+// kinds of changes between two versions of a group
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// AccountChange describes how an account differs between two versions of a group.
+// For changed accounts Fields names the changed fields; values are never included
+type AccountChange struct {
+	Account string
+	Change  string
+	Fields  []string
+}
+
+// DecryptGroup decrypts a group vault which is not stored in sherlock
+// such as a backup or a copy of a vault file
+func DecryptGroup(vault []byte, groupKey string) (*Group, error) {
+	var group Group
+	if err := security.DecryptVault(vault, groupKey, &group); err != nil {
+		return nil, decryptError(err)
+	}
+	group.Accounts = group.sorted()
+	return &group, nil
+}
+
+// DiffGroups compares two versions of a group and returns the added, removed and
+// changed accounts sorted by account name. Accounts are matched by name, a
+// renamed account shows up as removed and added
+func DiffGroups(from, to *Group) []AccountChange {
+	var changes []AccountChange
+	for _, a := range to.Accounts {
+		before, err := from.lookup(a.Name)
+		if err != nil {
+			changes = append(changes, AccountChange{Account: a.Name, Change: ChangeAdded})
+			continue
+		}
+		if fields := changedFields(before, a); len(fields) > 0 {
+			changes = append(changes, AccountChange{Account: a.Name, Change: ChangeChanged, Fields: fields})
+		}
+	}
+	for _, a := range from.Accounts {
+		if !to.exists(a.Name) {
+			changes = append(changes, AccountChange{Account: a.Name, Change: ChangeRemoved})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Account < changes[j].Account
+	})
+	return changes
+}
+
+// changedFields lists the names of the fields which differ between two versions of an account
+func changedFields(a, b *Account) []string {
+	var fields []string
+	for _, f := range []struct {
+		name     string
+		from, to interface{}
+	}{
+		{name: FieldPassword, from: a.Password, to: b.Password},
+		{name: FieldUsername, from: a.Username, to: b.Username},
+		{name: FieldURL, from: a.URL, to: b.URL},
+		{name: FieldNote, from: a.Note, to: b.Note},
+		{name: "tag", from: a.Tag, to: b.Tag},
+		{name: "otp", from: a.OTP, to: b.OTP},
+		{name: "recovery", from: a.RecoveryCodes, to: b.RecoveryCodes},
+		{name: "autotype", from: a.AutoType, to: b.AutoType},
+		{name: "preset", from: a.Preset, to: b.Preset},
+		{name: "link", from: a.Link, to: b.Link},
+		{name: "timelock", from: a.TimeLock, to: b.TimeLock},
+	} {
+		if !reflect.DeepEqual(f.from, f.to) {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
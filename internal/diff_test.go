@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiffGroups(t *testing.T) {
+	from := Group{GID: "work", Accounts: []*Account{
+		{Name: "github", Password: "221b", Username: "sherlock"},
+		{Name: "jira", Password: "baker"},
+		{Name: "slack", Password: "street"},
+	}}
+	to := Group{GID: "work", Accounts: []*Account{
+		{Name: "github", Password: "221c", Username: "sherlock", Tag: "dev"},
+		{Name: "jira", Password: "baker"},
+		{Name: "aws", Password: "moriarty"},
+	}}
+	expect := []AccountChange{
+		{Account: "aws", Change: ChangeAdded},
+		{Account: "github", Change: ChangeChanged, Fields: []string{FieldPassword, "tag"}},
+		{Account: "slack", Change: ChangeRemoved},
+	}
+	if have := DiffGroups(&from, &to); !reflect.DeepEqual(have, expect) {
+		t.Fatalf("internal.DiffGroups: want: %v, have: %v", expect, have)
+	}
+	if have := DiffGroups(&from, &from); len(have) != 0 {
+		t.Fatalf("internal.DiffGroups: want: no changes, have: %v", have)
+	}
+}
+
+func TestDecryptGroup(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(context.Background(), "default@github", groupKey, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	vault, err := sh.readVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("sherlock.readVault: want: nil, have: %v", err)
+	}
+	group, err := DecryptGroup(vault, groupKey)
+	if err != nil {
+		t.Fatalf("internal.DecryptGroup: want: nil, have: %v", err)
+	}
+	if !group.exists("github") {
+		t.Fatalf("internal.DecryptGroup: want: account github, have: %v", group.Accounts)
+	}
+	if _, err := DecryptGroup(vault, "wrong"); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("internal.DecryptGroup: want: %v, have: %v", ErrWrongKey, err)
+	}
+}
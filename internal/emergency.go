@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/qr"
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+const (
+	// kitPrefix marks the payload of an emergency kit
+	kitPrefix = "sherlock-kit-"
+	// kitLineWidth is the width the payload is wrapped at in the kit
+	kitLineWidth  = 64
+	kitTimeLayout = "2006-01-02 15:04"
+)
+
+var (
+	ErrInvalidKit = fmt.Errorf("invalid emergency kit payload (expected " + kitPrefix + "...)")
+)
+
+// KitSecret is an account copied into an emergency kit
+type KitSecret struct {
+	Account  string `json:"account"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+	URL      string `json:"url,omitempty"`
+}
+
+// NewKitSecret copies the credentials of the account found by the query
+func NewKitSecret(query string, a *Account) KitSecret {
+	return KitSecret{
+		Account:  query,
+		Username: a.Username,
+		Password: a.Password,
+		URL:      a.URL,
+	}
+}
+
+// KitVault is the location of a group vault listed in an emergency kit
+type KitVault struct {
+	Group string
+	Path  string
+}
+
+// Kit is a printable emergency kit listing the vaults and holding an
+// encrypted copy of critical secrets
+type Kit struct {
+	Created time.Time
+	Vaults  []KitVault
+	// Payload holds the secrets sealed by SealKit. Empty if no
+	// secrets are part of the kit
+	Payload string
+	Secrets int
+}
+
+// SealKit encrypts the secrets with the recovery passphrase and encodes them
+// as text which can be printed and typed in again
+func SealKit(secrets []KitSecret, passphrase string) (string, error) {
+	b, err := json.Marshal(secrets)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := security.SealPassphrase(b, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return kitPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenKit decrypts the payload of an emergency kit. White space within the
+// payload (e.g. from line breaks of the printed kit) is ignored
+func OpenKit(payload, passphrase string) ([]KitSecret, error) {
+	payload = strings.Join(strings.Fields(payload), "")
+	if !strings.HasPrefix(payload, kitPrefix) {
+		return nil, ErrInvalidKit
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(payload, kitPrefix))
+	if err != nil {
+		return nil, ErrInvalidKit
+	}
+	b, err := security.OpenSealed(sealed, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []KitSecret
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, ErrInvalidKit
+	}
+	return secrets, nil
+}
+
+// WriteText writes the kit as plain text document meant to be printed
+func (k Kit) WriteText(w io.Writer) error {
+	// render the QR code first so nothing is written if it cannot be encoded
+	var code strings.Builder
+	if k.Payload != "" {
+		if err := qr.RenderPlain(&code, k.Payload); err != nil {
+			return fmt.Errorf("cannot encode secrets as QR code (select fewer accounts): %w", err)
+		}
+	}
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "SHERLOCK EMERGENCY KIT\n")
+	fmt.Fprintf(buf, "created %s\n\n", k.Created.Local().Format(kitTimeLayout))
+	fmt.Fprintf(buf, "Keep this document in a safe place. It does not contain any group key.\n\n")
+
+	width := 0
+	for _, v := range k.Vaults {
+		if len(v.Group) > width {
+			width = len(v.Group)
+		}
+	}
+	fmt.Fprintf(buf, "VAULTS\n")
+	for _, v := range k.Vaults {
+		fmt.Fprintf(buf, "  %-*s  %s\n", width, v.Group, v.Path)
+	}
+	fmt.Fprintf(buf, "\nGROUP KEY HINTS (write down where each group key is kept)\n")
+	for _, v := range k.Vaults {
+		fmt.Fprintf(buf, "  %-*s  %s\n", width, v.Group, strings.Repeat("_", 40))
+	}
+	if k.Payload != "" {
+		fmt.Fprintf(buf, "\nCRITICAL SECRETS (%d accounts)\n", k.Secrets)
+		fmt.Fprintf(buf, "The QR code and the text below are an encrypted copy of the accounts.\n")
+		fmt.Fprintf(buf, "Open them with `sherlock emergency-kit open` and the recovery passphrase.\n\n")
+		buf.WriteString(code.String())
+		buf.WriteString("\n")
+		for payload := k.Payload; payload != ""; {
+			n := kitLineWidth
+			if n > len(payload) {
+				n = len(payload)
+			}
+			fmt.Fprintf(buf, "  %s\n", payload[:n])
+			payload = payload[n:]
+		}
+	}
+	return buf.Flush()
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+func TestSealOpenKit(t *testing.T) {
+	secrets := []KitSecret{
+		NewKitSecret("default@bank", &Account{Name: "bank", Username: "sherlock", Password: "221b-baker-street", URL: "https://bank.example.com"}),
+		NewKitSecret("work@vpn", &Account{Name: "vpn", Password: "mycroft"}),
+	}
+	payload, err := SealKit(secrets, "recovery passphrase")
+	if err != nil {
+		t.Fatalf("internal.SealKit: want: nil, have: %v", err)
+	}
+	if strings.Contains(payload, "221b-baker-street") {
+		t.Fatalf("internal.SealKit: want: encrypted payload, have: %s", payload)
+	}
+
+	// payload typed in from the printed kit with line breaks
+	wrapped := payload[:20] + "\n  " + payload[20:]
+	opened, err := OpenKit(wrapped, "recovery passphrase")
+	if err != nil {
+		t.Fatalf("internal.OpenKit: want: nil, have: %v", err)
+	}
+	if !reflect.DeepEqual(opened, secrets) {
+		t.Fatalf("internal.OpenKit: want: %v, have: %v", secrets, opened)
+	}
+	if _, err := OpenKit(payload, "wrong passphrase"); !errors.Is(err, security.ErrOpenSealed) {
+		t.Fatalf("internal.OpenKit: want: %v, have: %v", security.ErrOpenSealed, err)
+	}
+	for _, invalid := range []string{"", "sherlock-kit-!!", strings.TrimPrefix(payload, kitPrefix)} {
+		if _, err := OpenKit(invalid, "recovery passphrase"); err != ErrInvalidKit {
+			t.Fatalf("internal.OpenKit(%q): want: %v, have: %v", invalid, ErrInvalidKit, err)
+		}
+	}
+}
+
+func TestKitWriteText(t *testing.T) {
+	payload, err := SealKit([]KitSecret{{Account: "default@bank", Password: "221b-baker-street"}}, "recovery passphrase")
+	if err != nil {
+		t.Fatalf("internal.SealKit: want: nil, have: %v", err)
+	}
+	kit := Kit{
+		Created: time.Now(),
+		Vaults: []KitVault{
+			{Group: "default", Path: "/home/sherlock/.sherlock/groups/default/.vault"},
+			{Group: "work", Path: "/home/sherlock/.sherlock/groups/work/.vault"},
+		},
+		Payload: payload,
+		Secrets: 1,
+	}
+	var buf bytes.Buffer
+	if err := kit.WriteText(&buf); err != nil {
+		t.Fatalf("Kit.WriteText: want: nil, have: %v", err)
+	}
+	text := buf.String()
+	for _, want := range []string{"/home/sherlock/.sherlock/groups/work/.vault", "CRITICAL SECRETS (1 accounts)", "█"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("Kit.WriteText: want: %q in kit, have: %s", want, text)
+		}
+	}
+	// the payload printed in lines must open again
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, kitPrefix) || (len(lines) > 0 && line != "") {
+			lines = append(lines, line)
+		}
+	}
+	if _, err := OpenKit(strings.Join(lines, "\n"), "recovery passphrase"); err != nil {
+		t.Fatalf("internal.OpenKit: want: nil, have: %v", err)
+	}
+}
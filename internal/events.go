@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// operations on groups reported in a ChangeEvent. Operations on accounts
+// use ChangeAdded, ChangeRemoved and ChangeChanged
+const (
+	OpGroupCreated   = "group_created"
+	OpGroupDeleted   = "group_deleted"
+	OpGroupRestored  = "group_restored"
+	OpGroupLocked    = "group_locked"
+	OpGroupUnlocked  = "group_unlocked"
+	OpGroupMigrated  = "group_migrated"
+	OpGroupIndexed   = "group_indexed"
+	OpGroupUnindexed = "group_unindexed"
+)
+
+// ChangeEvent describes a successful change of a group. It never holds
+// any secret or account value
+type ChangeEvent struct {
+	Group     string    `json:"group"`
+	Account   string    `json:"account,omitempty"`
+	Operation string    `json:"operation"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+var (
+	ErrWriteAborted = fmt.Errorf("write aborted, nothing has been written")
+)
+
+// Observer is notified after changes have been written
+type Observer func(ctx context.Context, events []ChangeEvent)
+
+// Check is run before changes are written. An error aborts the write
+type Check func(ctx context.Context, events []ChangeEvent) error
+
+// Observe registers an Observer notified after every successful write
+func (sh *Sherlock) Observe(o Observer) {
+	sh.observers = append(sh.observers, o)
+}
+
+// BeforeWrite registers a Check run before every write
+func (sh *Sherlock) BeforeWrite(c Check) {
+	sh.checks = append(sh.checks, c)
+}
+
+// watched reports whether anyone is interested in the changes
+func (sh Sherlock) watched() bool {
+	return len(sh.observers) > 0 || len(sh.checks) > 0
+}
+
+// check runs all checks with the events of an upcoming write
+func (sh Sherlock) check(ctx context.Context, events []ChangeEvent) error {
+	for _, c := range sh.checks {
+		if err := c(ctx, events); err != nil {
+			// a check failing because the operation was cancelled reports the cancellation
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w: %v", ErrWriteAborted, err)
+		}
+	}
+	return nil
+}
+
+// emit notifies all observers about the events
+func (sh Sherlock) emit(ctx context.Context, events []ChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	for _, o := range sh.observers {
+		o(ctx, events)
+	}
+}
+
+// groupEvents returns the events of an operation on a group
+func (sh Sherlock) groupEvents(gid, op string) []ChangeEvent {
+	if !sh.watched() {
+		return nil
+	}
+	return []ChangeEvent{{Group: gid, Operation: op, Timestamp: time.Now().UTC()}}
+}
+
+// changeEvents returns the events of the accounts changed between the two
+// versions of a group. If no account changed in a visible way the account
+// the change was requested for is reported as changed
+func (sh Sherlock) changeEvents(before, after *Group, account string) []ChangeEvent {
+	if !sh.watched() {
+		return nil
+	}
+	now := time.Now().UTC()
+	var events []ChangeEvent
+	if before != nil {
+		for _, c := range DiffGroups(before, after) {
+			events = append(events, ChangeEvent{Group: after.GID, Account: c.Account, Operation: c.Change, Timestamp: now})
+		}
+	}
+	if len(events) == 0 {
+		events = append(events, ChangeEvent{Group: after.GID, Account: account, Operation: ChangeChanged, Timestamp: now})
+	}
+	return events
+}
+
+// clone returns a deep copy of the group
+func (g Group) clone() (*Group, error) {
+	b, err := g.serizalize()
+	if err != nil {
+		return nil, err
+	}
+	var c Group
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestObserve(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	var ops []string
+	sh.Observe(func(ctx context.Context, events []ChangeEvent) {
+		for _, e := range events {
+			if e.Timestamp.IsZero() {
+				t.Fatalf("Observer: want: event timestamp, have: %+v", e)
+			}
+			ops = append(ops, e.Group+"@"+e.Account+" "+e.Operation)
+		}
+	})
+
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	steps := []struct {
+		query string
+		opt   StateOption
+	}{
+		{query: "default@github", opt: OptAddAccount(account)},
+		{query: "default@github", opt: OptAccURL("https://github.com")},
+		{query: "default@github", opt: OptAccFavorite(true)},
+		{query: "default@github", opt: OptAccDelete()},
+	}
+	for _, s := range steps {
+		if err := sh.UpdateState(ctx, s.query, groupKey, s.opt); err != nil {
+			t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+		}
+	}
+	// failed changes are not reported
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAccDelete()); err == nil {
+		t.Fatalf("sherlock.UpdateState: want: %v, have: nil", ErrNoSuchAccount)
+	}
+	if err := sh.SetReadOnly(ctx, "default", groupKey, true); err != nil {
+		t.Fatalf("sherlock.SetReadOnly: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.DeleteGroup(ctx, "work"); err != nil {
+		t.Fatalf("sherlock.DeleteGroup: want: nil, have: %v", err)
+	}
+
+	want := []string{
+		"default@github added",
+		"default@github changed",
+		"default@github changed",
+		"default@github removed",
+		"default@ group_locked",
+		"work@ group_created",
+		"work@ group_deleted",
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Fatalf("Observer: want: %v, have: %v", want, ops)
+	}
+}
+
+func TestWebhookConfig(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if _, _, err := sh.Webhook(ctx); err != ErrNoWebhook {
+		t.Fatalf("sherlock.Webhook: want: %v, have: %v", ErrNoWebhook, err)
+	}
+	if _, err := sh.SetWebhook(ctx, "http://hooks.example.com"); err == nil {
+		t.Fatalf("sherlock.SetWebhook: want: error for http url, have: nil")
+	}
+	secret, err := sh.SetWebhook(ctx, "https://hooks.example.com/sherlock")
+	if err != nil {
+		t.Fatalf("sherlock.SetWebhook: want: nil, have: %v", err)
+	}
+	url, key, err := sh.Webhook(ctx)
+	if err != nil || url != "https://hooks.example.com/sherlock" || string(key) != secret {
+		t.Fatalf("sherlock.Webhook: want: url and secret, have: %s %s %v", url, key, err)
+	}
+	config, err := sh.Config(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	if config.Backup.KeepDaily != defaultKeepDaily {
+		t.Fatalf("sherlock.Config: want: backup defaults kept, have: %+v", config.Backup)
+	}
+	if err := sh.RemoveWebhook(ctx); err != nil {
+		t.Fatalf("sherlock.RemoveWebhook: want: nil, have: %v", err)
+	}
+	if _, _, err := sh.Webhook(ctx); err != ErrNoWebhook {
+		t.Fatalf("sherlock.Webhook: want: %v, have: %v", ErrNoWebhook, err)
+	}
+}
+
+func TestBeforeWrite(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	var checked, observed []string
+	refuse := false
+	sh.BeforeWrite(func(ctx context.Context, events []ChangeEvent) error {
+		for _, e := range events {
+			checked = append(checked, e.Group+"@"+e.Account+" "+e.Operation)
+		}
+		if refuse {
+			return fmt.Errorf("exit status 1")
+		}
+		return nil
+	})
+	sh.Observe(func(ctx context.Context, events []ChangeEvent) {
+		for _, e := range events {
+			observed = append(observed, e.Group+"@"+e.Account+" "+e.Operation)
+		}
+	})
+
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	refuse = true
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAccDelete()); !errors.Is(err, ErrWriteAborted) {
+		t.Fatalf("sherlock.UpdateState: want: %v, have: %v", ErrWriteAborted, err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); !errors.Is(err, ErrWriteAborted) {
+		t.Fatalf("sherlock.SetupGroup: want: %v, have: %v", ErrWriteAborted, err)
+	}
+	// the aborted changes have not been written
+	if _, err := sh.GetAccount(ctx, "default@github", groupKey); err != nil {
+		t.Fatalf("sherlock.GetAccount: want: account kept, have: %v", err)
+	}
+	if err := sh.GroupExists(ctx, "work"); err != nil {
+		t.Fatalf("sherlock.GroupExists: want: group not created, have: %v", err)
+	}
+
+	wantChecked := []string{"default@github added", "default@github removed", "work@ group_created"}
+	if !reflect.DeepEqual(checked, wantChecked) {
+		t.Fatalf("Check: want: %v, have: %v", wantChecked, checked)
+	}
+	if wantObserved := []string{"default@github added"}; !reflect.DeepEqual(observed, wantObserved) {
+		t.Fatalf("Observer: want: %v, have: %v", wantObserved, observed)
+	}
+}
+
+func TestCancelledWrite(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	// the operation is cancelled while the check runs, e.g. by Ctrl-C during a hook
+	sh.BeforeWrite(func(ctx context.Context, events []ChangeEvent) error {
+		cancel()
+		return ctx.Err()
+	})
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(cancelled, "default@github", groupKey, OptAddAccount(account)); err != context.Canceled {
+		t.Fatalf("sherlock.UpdateState: want: %v, have: %v", context.Canceled, err)
+	}
+	if _, err := sh.GetAccount(cancelled, "default@github", groupKey); err != context.Canceled {
+		t.Fatalf("sherlock.GetAccount: want: %v, have: %v", context.Canceled, err)
+	}
+	if _, err := sh.GetAccount(ctx, "default@github", groupKey); err == nil {
+		t.Fatalf("sherlock.GetAccount: want: %v, have: nil", ErrNoSuchAccount)
+	}
+}
+
+func TestHooksConfig(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.AddHook(ctx, "post-commit", "true"); !errors.Is(err, ErrUnknownHook) {
+		t.Fatalf("sherlock.AddHook: want: %v, have: %v", ErrUnknownHook, err)
+	}
+	for _, command := range []string{"git add -A", "git commit -m \"$2 $1@$3\""} {
+		if err := sh.AddHook(ctx, HookPostWrite, command); err != nil {
+			t.Fatalf("sherlock.AddHook: want: nil, have: %v", err)
+		}
+	}
+	if err := sh.AddHook(ctx, HookPreWrite, "backup.sh"); err != nil {
+		t.Fatalf("sherlock.AddHook: want: nil, have: %v", err)
+	}
+	if err := sh.RemoveHook(ctx, HookPostWrite, 3); !errors.Is(err, ErrNoSuchHook) {
+		t.Fatalf("sherlock.RemoveHook: want: %v, have: %v", ErrNoSuchHook, err)
+	}
+	if err := sh.RemoveHook(ctx, HookPostWrite, 1); err != nil {
+		t.Fatalf("sherlock.RemoveHook: want: nil, have: %v", err)
+	}
+	hooks, err := sh.Hooks(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Hooks: want: nil, have: %v", err)
+	}
+	want := HooksConfig{PreWrite: []string{"backup.sh"}, PostWrite: []string{"git commit -m \"$2 $1@$3\""}}
+	if !reflect.DeepEqual(hooks, want) {
+		t.Fatalf("sherlock.Hooks: want: %v, have: %v", want, hooks)
+	}
+}
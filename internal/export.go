@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// InventoryItem describes an account without any of its secrets
+type InventoryItem struct {
+	Group     string    `json:"group"`
+	Account   string    `json:"account"`
+	Tag       string    `json:"tag,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// Inventory lists the accounts of the group with all secrets
+// (password, username, note, otp) stripped
+func (g Group) Inventory() []InventoryItem {
+	items := make([]InventoryItem, len(g.Accounts))
+	for i, a := range g.Accounts {
+		items[i] = InventoryItem{
+			Group:     g.GID,
+			Account:   a.Name,
+			Tag:       a.Tag,
+			URL:       a.URL,
+			CreatedOn: a.CreatedOn,
+			UpdatedOn: a.UpdatedOn,
+		}
+	}
+	return items
+}
+
+// WriteInventoryJSON writes the inventory as json array
+func WriteInventoryJSON(w io.Writer, items []InventoryItem) error {
+	if items == nil {
+		items = []InventoryItem{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// WriteInventoryCSV writes the inventory as csv with a header row
+func WriteInventoryCSV(w io.Writer, items []InventoryItem) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"group", "account", "tag", "url", "created_on", "updated_on"}); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := cw.Write([]string{
+			item.Group,
+			item.Account,
+			item.Tag,
+			item.URL,
+			item.CreatedOn.UTC().Format(time.RFC3339),
+			item.UpdatedOn.UTC().Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
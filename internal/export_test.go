@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInventory(t *testing.T) {
+	created := time.Date(2021, 10, 15, 12, 0, 0, 0, time.UTC)
+	otp, err := NewOTP(OTPTypeTOTP, rfcSecret)
+	if err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	group := Group{GID: "work", Accounts: []*Account{
+		{
+			Name:      "github",
+			Password:  "221b-baker-street",
+			Username:  "sherlock",
+			URL:       "https://github.com",
+			Note:      "recovery codes in the safe",
+			OTP:       otp,
+			Tag:       "dev",
+			CreatedOn: created,
+			UpdatedOn: created,
+		},
+	}}
+
+	var jsonOut, csvOut bytes.Buffer
+	if err := WriteInventoryJSON(&jsonOut, group.Inventory()); err != nil {
+		t.Fatalf("internal.WriteInventoryJSON: want: nil, have: %v", err)
+	}
+	if err := WriteInventoryCSV(&csvOut, group.Inventory()); err != nil {
+		t.Fatalf("internal.WriteInventoryCSV: want: nil, have: %v", err)
+	}
+	for _, secret := range []string{"221b-baker-street", "sherlock", "recovery codes", rfcSecret} {
+		if strings.Contains(jsonOut.String(), secret) || strings.Contains(csvOut.String(), secret) {
+			t.Fatalf("internal.Inventory: want: no secrets, have: %q in export", secret)
+		}
+	}
+	expect := "group,account,tag,url,created_on,updated_on\nwork,github,dev,https://github.com,2021-10-15T12:00:00Z,2021-10-15T12:00:00Z\n"
+	if csvOut.String() != expect {
+		t.Fatalf("internal.WriteInventoryCSV: want: %q, have: %q", expect, csvOut.String())
+	}
+	if !strings.Contains(jsonOut.String(), `"account": "github"`) {
+		t.Fatalf("internal.WriteInventoryJSON: want: account github, have: %s", jsonOut.String())
+	}
+}
@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/KonstantinGasser/required"
 	"github.com/KonstantinGasser/sherlock/security"
@@ -11,18 +14,35 @@ import (
 
 const (
 	prettyDateLayout = "Monday, 02. January 2006"
+	// favoriteMark prefixes the name of favorite accounts in tables
+	favoriteMark = "★ "
 )
 
 var (
 	ErrAccountExists    = fmt.Errorf("account for group already exists")
 	ErrNoSuchAccount    = fmt.Errorf("account not found")
-	ErrInvalidGroupName = fmt.Errorf("group name must be a consecutive string")
+	ErrInvalidGroupName = fmt.Errorf("group name must be a single word without @, / or \\ and not . or ..")
 )
 
 // Group groups Accounts
 type Group struct {
 	GID      string     `json:"name" required:"yes"`
 	Accounts []*Account `json:"accounts"`
+	// ReadOnly groups refuse any change until they are unlocked
+	ReadOnly bool `json:"read_only,omitempty"`
+	// PlainIndex groups keep the names, tags and timestamps of their accounts
+	// in plain text in the vault header, see Sherlock.SetPlainIndex
+	PlainIndex bool `json:"plain_index,omitempty"`
+	// Undo is the account deleted last, kept encrypted with the group until it
+	// is restored or the next account is deleted
+	Undo *Account `json:"undo,omitempty"`
+	// Required are the fields accounts must have when they are added or
+	// updated, see Sherlock.SetRequired
+	Required []string `json:"required,omitempty"`
+	// names are the name settings of the config
+	names nameRules
+	// origin is set for groups loaded from a vault, see Group.unchanged
+	origin *groupOrigin
 }
 
 func NewGroup(name string) (*Group, error) {
@@ -38,6 +58,9 @@ func NewGroup(name string) (*Group, error) {
 
 // append appends an account to a group if it does not already exists
 func (g *Group) append(account *Account) error {
+	if err := g.names.checkName(account.Name, ErrInvalidAccountName); err != nil {
+		return err
+	}
 	if ok := g.exists(account.Name); ok {
 		return ErrAccountExists
 	}
@@ -45,52 +68,104 @@ func (g *Group) append(account *Account) error {
 	return nil
 }
 
+// lookup returns the account with the name. If names are folded an exact
+// match wins over a match apart from case and normalization
 func (g Group) lookup(accountName string) (*Account, error) {
 	for _, a := range g.Accounts {
 		if a.Name == accountName {
 			return a, nil
 		}
 	}
-	return nil, ErrNoSuchAccount
+	if !g.names.fold {
+		return nil, ErrNoSuchAccount
+	}
+	var found []*Account
+	for _, a := range g.Accounts {
+		if sameName(a.Name, accountName) {
+			found = append(found, a)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, ErrNoSuchAccount
+	case 1:
+		return found[0], nil
+	}
+	names := make([]string, len(found))
+	for i, a := range found {
+		names[i] = a.Name
+	}
+	return nil, fmt.Errorf("%w: account %q matches %s", ErrAmbiguousName, accountName, strings.Join(names, ", "))
 }
 
-// delete deletes a given account from the group, returns an ErrNoSuchAccount
-// if account not present
-func (g *Group) delete(account string) error {
-	var offset *int
+// delete deletes a given account from the group and keeps it as Undo, returns
+// an ErrNoSuchAccount if account not present
+func (g *Group) delete(account string) (*Account, error) {
+	found, err := g.lookup(account)
+	if err != nil {
+		return nil, err
+	}
 	for i, a := range g.Accounts {
-		if a.Name == account {
-			offset = &i
+		if a == found {
+			g.Accounts = append(g.Accounts[:i], g.Accounts[i+1:]...)
+			break
 		}
 	}
-	if offset == nil {
-		return ErrNoSuchAccount
-	}
-
-	g.Accounts = append(g.Accounts[:*offset], g.Accounts[*offset+1:]...)
-	return nil
+	g.Undo = found
+	return found, nil
 }
 
 // exists checks an account is already present in the group
-// using the account.Name as a pk
+// using the account.Name as a pk. If names are folded names differing in
+// case or normalization only are the same
 func (g Group) exists(name string) bool {
 	for _, a := range g.Accounts {
-		if name == a.Name {
+		if name == a.Name || (g.names.fold && sameName(name, a.Name)) {
 			return true
 		}
 	}
 	return false
 }
 
+// serizalize encodes the group canonically: accounts are ordered by name and
+// fields keep the order of their declaration. The same content therefore
+// always results in the same bytes
 func (g Group) serizalize() ([]byte, error) {
+	g.Accounts = g.sorted()
 	return json.Marshal(g)
 }
 
+// sorted returns the accounts ordered by name without reordering the group
+func (g Group) sorted() []*Account {
+	accounts := make([]*Account, len(g.Accounts))
+	copy(accounts, g.Accounts)
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts
+}
+
+// groupOrigin describes the vault a group has been loaded from
+type groupOrigin struct {
+	gid        string
+	key        string
+	header     security.Header
+	serialized []byte
+}
+
+// unchanged reports whether writing the group to gid with the key and kdf
+// would only re-encrypt the vault it has been loaded from
+func (g Group) unchanged(gid, groupKey, kdf string, serialized []byte) bool {
+	o := g.origin
+	return o != nil && o.gid == gid && o.key == groupKey && o.header.Meta != nil &&
+		o.header.Matches(kdf) && bytes.Equal(o.serialized, serialized)
+}
+
 func (g Group) valid() error {
 	if err := required.Atomic(&g); err != nil {
 		return ErrMissingValues
 	}
-	if set := strings.Split(g.GID, " "); len(set) != 1 {
+	if !validName(g.GID) {
 		return ErrInvalidGroupName
 	}
 	return nil
@@ -112,9 +187,19 @@ skipp:
 				continue skipp
 			}
 		}
+		name := item.Name
+		if item.Favorite {
+			name = favoriteMark + name
+		}
+		if item.Linked() {
+			name += linkMark + item.Link
+		}
+		if item.TimeLock != nil && item.TimeLock.Locked(time.Now()) {
+			name += timeLockMark + item.TimeLock.Until.Local().Format("2006-01-02 15:04")
+		}
 		accounts = append(accounts, []string{
 			g.GID,
-			item.Name,
+			name,
 			strings.Join([]string{"#", item.Tag}, ""),
 			item.CreatedOn.Format(prettyDateLayout),
 			item.UpdatedOn.Format(prettyDateLayout),
@@ -123,6 +208,36 @@ skipp:
 	return accounts
 }
 
+// Queries returns the query (group@account) of every account in the group
+// with the favorite accounts first
+func (g Group) Queries() []string {
+	return Queries(&g)
+}
+
+// Queries returns the query (group@account) of every account in the groups.
+// The favorite accounts of all groups are listed before any other account
+func Queries(groups ...*Group) []string {
+	var pinned, queries []string
+	for _, g := range groups {
+		for _, a := range g.Accounts {
+			if a.Favorite {
+				pinned = append(pinned, g.GID+querySplitPoint+a.Name)
+				continue
+			}
+			queries = append(queries, g.GID+querySplitPoint+a.Name)
+		}
+	}
+	return append(pinned, queries...)
+}
+
+// PinFavorites moves the favorite accounts to the top of the group keeping
+// the order of the accounts otherwise
+func (g *Group) PinFavorites() {
+	sort.SliceStable(g.Accounts, func(i, j int) bool {
+		return g.Accounts[i].Favorite && !g.Accounts[j].Favorite
+	})
+}
+
 func FilterByTag(tag string) func(*Account) bool {
 	return func(a *Account) bool {
 		if len(tag) == 0 {
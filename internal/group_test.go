@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"bytes"
+	"context"
+	"reflect"
 	"testing"
 )
 
@@ -105,3 +108,99 @@ func TestFilterByTag(t *testing.T) {
 		}
 	}
 }
+
+func TestGroupFavorites(t *testing.T) {
+	work := &Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "jira"},
+			{Name: "vpn", Favorite: true},
+			{Name: "wiki"},
+		},
+	}
+	private := &Group{
+		GID: "default",
+		Accounts: []*Account{
+			{Name: "bank"},
+			{Name: "mail", Favorite: true},
+		},
+	}
+
+	want := []string{"work@vpn", "default@mail", "work@jira", "work@wiki", "default@bank"}
+	if have := Queries(work, private); !reflect.DeepEqual(have, want) {
+		t.Fatalf("internal.Queries: want: %v, have: %v", want, have)
+	}
+
+	work.PinFavorites()
+	var names []string
+	for _, a := range work.Accounts {
+		names = append(names, a.Name)
+	}
+	if want := []string{"vpn", "jira", "wiki"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Group.PinFavorites: want: %v, have: %v", want, names)
+	}
+	if have := work.Table()[0][1]; have != favoriteMark+"vpn" {
+		t.Fatalf("Group.Table: want: %s, have: %s", favoriteMark+"vpn", have)
+	}
+}
+
+func TestGroupCanonical(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+	for _, name := range []string{"mail", "bank"} {
+		a, err := NewAccount("default@"+name, "fsdf$35dfg0-43563sdf34", "", false)
+		if err != nil {
+			t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+		}
+		if err := sh.UpdateState(ctx, "default@"+name, key, OptAddAccount(a)); err != nil {
+			t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+		}
+	}
+
+	group, err := sh.LoadGroup(ctx, "default", key)
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if group.Accounts[0].Name != "bank" || group.Accounts[1].Name != "mail" {
+		t.Fatalf("sherlock.LoadGroup: want: [bank mail], have: [%s %s]", group.Accounts[0].Name, group.Accounts[1].Name)
+	}
+	sorted, err := group.serizalize()
+	if err != nil {
+		t.Fatalf("group.serizalize: want: nil, have: %v", err)
+	}
+	group.Accounts[0], group.Accounts[1] = group.Accounts[1], group.Accounts[0]
+	reversed, err := group.serizalize()
+	if err != nil {
+		t.Fatalf("group.serizalize: want: nil, have: %v", err)
+	}
+	if !bytes.Equal(sorted, reversed) {
+		t.Fatalf("group.serizalize: want: same bytes in any account order, have: %s != %s", sorted, reversed)
+	}
+
+	before, err := sh.fileSystem.ReadGroupVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("fileSystem.ReadGroupVault: want: nil, have: %v", err)
+	}
+	if err := sh.WriteGroup(ctx, "default", key, group); err != nil {
+		t.Fatalf("sherlock.WriteGroup: want: nil, have: %v", err)
+	}
+	after, err := sh.fileSystem.ReadGroupVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("fileSystem.ReadGroupVault: want: nil, have: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatalf("sherlock.WriteGroup: want: unchanged vault, have: re-encrypted vault")
+	}
+
+	group.Accounts[0].Tag = "finance"
+	if err := sh.WriteGroup(ctx, "default", key, group); err != nil {
+		t.Fatalf("sherlock.WriteGroup: want: nil, have: %v", err)
+	}
+	if after, _ = sh.fileSystem.ReadGroupVault(ctx, "default"); bytes.Equal(before, after) {
+		t.Fatalf("sherlock.WriteGroup: want: changed vault, have: unchanged vault")
+	}
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// hintAfterFailures is the number of failed unlocks of a group after
+	// which its hint is shown
+	hintAfterFailures = 2
+	// maxHintLength is the maximum length of a hint in characters
+	maxHintLength = 100
+)
+
+var (
+	ErrInvalidHint   = fmt.Errorf("invalid passphrase hint")
+	ErrHintsDisabled = fmt.Errorf("passphrase hints are disabled (use sherlock group hint --on)")
+)
+
+// SetHint stores a hint reminding of the group key. The hint is kept in plain
+// text in the config and must not contain the key itself
+func (sh Sherlock) SetHint(ctx context.Context, gid, groupKey, hint string) error {
+	hint = strings.TrimSpace(hint)
+	switch {
+	case hint == "":
+		return fmt.Errorf("%w: hint is empty", ErrInvalidHint)
+	case utf8.RuneCountInString(hint) > maxHintLength:
+		return fmt.Errorf("%w: hint is longer than %d characters", ErrInvalidHint, maxHintLength)
+	case strings.Contains(strings.ToLower(hint), strings.ToLower(groupKey)):
+		return fmt.Errorf("%w: hint must not contain the group key", ErrInvalidHint)
+	}
+	if _, err := sh.LoadGroup(ctx, gid, groupKey); err != nil {
+		return err
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if config.NoHints {
+		return ErrHintsDisabled
+	}
+	if config.Hints == nil {
+		config.Hints = make(map[string]string)
+	}
+	config.Hints[gid] = hint
+	delete(config.FailedUnlocks, gid)
+	return sh.SaveConfig(ctx, config)
+}
+
+// RemoveHint removes the hint of the group
+func (sh Sherlock) RemoveHint(ctx context.Context, gid string) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	delete(config.Hints, gid)
+	delete(config.FailedUnlocks, gid)
+	return sh.SaveConfig(ctx, config)
+}
+
+// EnableHints turns passphrase hints on or off. Turning them off removes all
+// stored hints
+func (sh Sherlock) EnableHints(ctx context.Context, enabled bool) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	config.NoHints = !enabled
+	if !enabled {
+		config.Hints, config.FailedUnlocks = nil, nil
+	}
+	return sh.SaveConfig(ctx, config)
+}
+
+// Hint returns the hint of the group once unlocking it failed at least
+// hintAfterFailures times in a row
+func (c Config) Hint(gid string) (string, bool) {
+	hint, ok := c.Hints[gid]
+	if !ok || c.NoHints || c.FailedUnlocks[gid] < hintAfterFailures {
+		return "", false
+	}
+	return hint, true
+}
+
+// countUnlock counts failed unlocks of groups with a hint and resets the
+// count on success
+func (sh Sherlock) countUnlock(ctx context.Context, gid string, ok bool) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if _, hinted := config.Hints[gid]; !hinted || config.NoHints {
+		return nil
+	}
+	switch {
+	case ok && config.FailedUnlocks[gid] == 0:
+		return nil
+	case ok:
+		delete(config.FailedUnlocks, gid)
+	default:
+		if config.FailedUnlocks == nil {
+			config.FailedUnlocks = make(map[string]int)
+		}
+		config.FailedUnlocks[gid]++
+	}
+	return sh.SaveConfig(ctx, config)
+}
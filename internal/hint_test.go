@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+
+	tt := []struct {
+		name string
+		key  string
+		hint string
+		err  error
+	}{
+		{name: "empty", key: key, hint: " ", err: ErrInvalidHint},
+		{name: "holds the key", key: key, hint: "it is DEFAULT_GROUP_KEY", err: ErrInvalidHint},
+		{name: "wrong key", key: "wrong_key", hint: "the one from the mail", err: ErrWrongKey},
+		{name: "hint", key: key, hint: "the one from the mail", err: nil},
+	}
+	for _, tc := range tt {
+		if err := sh.SetHint(ctx, "default", tc.key, tc.hint); !errors.Is(err, tc.err) {
+			t.Fatalf("[%s] sherlock.SetHint: want: %v, have: %v", tc.name, tc.err, err)
+		}
+	}
+
+	hint := func() (string, bool) {
+		config, err := sh.Config(ctx)
+		if err != nil {
+			t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+		}
+		return config.Hint("default")
+	}
+	for i := 0; i < hintAfterFailures; i++ {
+		if _, ok := hint(); ok {
+			t.Fatalf("Config.Hint(%d failures): want: no hint, have: hint", i)
+		}
+		sh.Unlocking("default")
+		if _, err := sh.LoadGroup(ctx, "default", "wrong_key"); err != ErrWrongKey {
+			t.Fatalf("sherlock.LoadGroup: want: %v, have: %v", ErrWrongKey, err)
+		}
+	}
+	if h, ok := hint(); !ok || h != "the one from the mail" {
+		t.Fatalf("Config.Hint: want: %q, have: %q", "the one from the mail", h)
+	}
+	sh.Unlocking("default")
+	if _, err := sh.LoadGroup(ctx, "default", key); err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if _, ok := hint(); ok {
+		t.Fatalf("Config.Hint(after unlock): want: no hint, have: hint")
+	}
+
+	if err := sh.EnableHints(ctx, false); err != nil {
+		t.Fatalf("sherlock.EnableHints: want: nil, have: %v", err)
+	}
+	config, err := sh.Config(ctx)
+	if err != nil || len(config.Hints) != 0 || !config.NoHints {
+		t.Fatalf("sherlock.EnableHints(false): want: no hints, have: %+v (%v)", config.Hints, err)
+	}
+	if err := sh.SetHint(ctx, "default", key, "the one from the mail"); !errors.Is(err, ErrHintsDisabled) {
+		t.Fatalf("sherlock.SetHint(disabled): want: %v, have: %v", ErrHintsDisabled, err)
+	}
+}
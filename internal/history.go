@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"time"
+)
+
+// kinds of events in the history of an account
+const (
+	EventCreated = "created"
+	EventRenamed = "renamed"
+	EventChanged = "changed"
+)
+
+// maxHistory is the number of events kept per account. Older events are dropped
+const maxHistory = 100
+
+// Event is an entry in the history of an account. Values of secret fields are never recorded
+type Event struct {
+	At     time.Time `json:"at"`
+	Kind   string    `json:"kind"`
+	Field  string    `json:"field,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// History returns the events of the account oldest first. Accounts created
+// before events were recorded get their creation (and last update if any)
+// reconstructed from CreatedOn and UpdatedOn
+func (a Account) History() []Event {
+	events := a.Events
+	if len(events) > 0 && events[0].Kind == EventCreated {
+		return events
+	}
+	reconstructed := []Event{{At: a.CreatedOn, Kind: EventCreated}}
+	if len(events) == 0 && a.UpdatedOn.Sub(a.CreatedOn) > time.Second {
+		reconstructed = append(reconstructed, Event{At: a.UpdatedOn, Kind: EventChanged})
+	}
+	return append(reconstructed, events...)
+}
+
+// record appends the events for the changes made to the account since before
+func (a *Account) record(before Account) {
+	at := a.UpdatedOn
+	if a.Name != before.Name {
+		a.Events = append(a.Events, Event{At: at, Kind: EventRenamed, Detail: before.Name + " -> " + a.Name})
+	}
+	for _, field := range changedFields(&before, a) {
+		e := Event{At: at, Kind: EventChanged, Field: field}
+		if field == "tag" {
+			e.Detail = "#" + before.Tag + " -> #" + a.Tag
+		}
+		a.Events = append(a.Events, e)
+	}
+	if len(a.Events) > maxHistory {
+		a.Events = a.Events[len(a.Events)-maxHistory:]
+	}
+}
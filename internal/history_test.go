@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountHistory(t *testing.T) {
+	account, err := NewAccount("work@github", "221b", "dev", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	for _, opt := range []FieldUpdate{
+		updateFieldPassword("221c", true),
+		updateFieldName("github-bot"),
+		updateFieldTag("ops"),
+		updateFieldUsername("sherlock"),
+		updateFieldUsername("sherlock"),
+	} {
+		if err := account.update(opt); err != nil {
+			t.Fatalf("account.update: want: nil, have: %v", err)
+		}
+	}
+	expect := []Event{
+		{Kind: EventCreated},
+		{Kind: EventChanged, Field: FieldPassword},
+		{Kind: EventRenamed, Detail: "github -> github-bot"},
+		{Kind: EventChanged, Field: "tag", Detail: "#dev -> #ops"},
+		{Kind: EventChanged, Field: FieldUsername},
+	}
+	history := account.History()
+	if len(history) != len(expect) {
+		t.Fatalf("account.History: want: %d events, have: %v", len(expect), history)
+	}
+	for i, e := range history {
+		if e.Kind != expect[i].Kind || e.Field != expect[i].Field || e.Detail != expect[i].Detail {
+			t.Fatalf("account.History[%d]: want: %+v, have: %+v", i, expect[i], e)
+		}
+	}
+}
+
+func TestAccountHistoryReconstructed(t *testing.T) {
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	account := Account{Name: "github", CreatedOn: created, UpdatedOn: created.Add(24 * time.Hour)}
+	history := account.History()
+	if len(history) != 2 || history[0].Kind != EventCreated || !history[0].At.Equal(created) || history[1].Kind != EventChanged {
+		t.Fatalf("account.History: want: reconstructed created and changed event, have: %+v", history)
+	}
+
+	if err := account.update(updateFieldNote("moved to 2fa")); err != nil {
+		t.Fatalf("account.update: want: nil, have: %v", err)
+	}
+	history = account.History()
+	if len(history) != 2 || history[0].Kind != EventCreated || history[1].Field != FieldNote {
+		t.Fatalf("account.History: want: created and note changed event, have: %+v", history)
+	}
+}
+
+func TestAccountHistoryLimit(t *testing.T) {
+	account := Account{Name: "github"}
+	for i := 0; i < maxHistory+10; i++ {
+		if err := account.update(updateFieldPassword(string(rune('a'+i%2)), true)); err != nil {
+			t.Fatalf("account.update: want: nil, have: %v", err)
+		}
+	}
+	if len(account.Events) != maxHistory {
+		t.Fatalf("account.Events: want: %d, have: %d", maxHistory, len(account.Events))
+	}
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// kinds of hooks. Pre-write hooks run before a change is written and abort
+// the write if they fail, post-write hooks run after a successful write
+const (
+	HookPreWrite  = "pre-write"
+	HookPostWrite = "post-write"
+)
+
+var (
+	ErrUnknownHook = fmt.Errorf("unknown hook (use pre-write or post-write)")
+	ErrNoSuchHook  = fmt.Errorf("no such hook")
+)
+
+// HooksConfig holds the shell commands run around writes
+type HooksConfig struct {
+	PreWrite  []string `json:"pre_write,omitempty"`
+	PostWrite []string `json:"post_write,omitempty"`
+}
+
+// commands returns the commands of the kind of hook
+func (h *HooksConfig) commands(kind string) (*[]string, error) {
+	switch kind {
+	case HookPreWrite:
+		return &h.PreWrite, nil
+	case HookPostWrite:
+		return &h.PostWrite, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHook, kind)
+	}
+}
+
+// Hooks returns the configured hooks
+func (sh Sherlock) Hooks(ctx context.Context) (HooksConfig, error) {
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return HooksConfig{}, err
+	}
+	return config.Hooks, nil
+}
+
+// AddHook appends a command to the hooks of the kind
+func (sh Sherlock) AddHook(ctx context.Context, kind, command string) error {
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("%w: hook command must not be empty", ErrInvalidInput)
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	commands, err := config.Hooks.commands(kind)
+	if err != nil {
+		return err
+	}
+	*commands = append(*commands, command)
+	return sh.SaveConfig(ctx, config)
+}
+
+// RemoveHook removes the n-th (starting at 1) command of the hooks of the kind
+func (sh Sherlock) RemoveHook(ctx context.Context, kind string, n int) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	commands, err := config.Hooks.commands(kind)
+	if err != nil {
+		return err
+	}
+	if n < 1 || n > len(*commands) {
+		return fmt.Errorf("%w: %s #%d", ErrNoSuchHook, kind, n)
+	}
+	*commands = append((*commands)[:n-1], (*commands)[n:]...)
+	return sh.SaveConfig(ctx, config)
+}
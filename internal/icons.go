@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Hosts returns the distinct hosts of the account urls in the group
+func (g Group) Hosts() []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, a := range g.Accounts {
+		host := urlHost(a.URL)
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// urlHost returns the lower-cased host of an account url. Urls are often
+// stored without scheme (github.com/login) in which case https is assumed
+func urlHost(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// iconName names the cached icon of a host. The host is hashed so the cache
+// does not list the sites accounts are stored for in plain text
+func iconName(host string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(host)))
+	return hex.EncodeToString(sum[:])
+}
+
+// CachedIcon returns the cached icon of a host. If the icon is not cached an
+// os.ErrNotExist error is returned
+func (sh Sherlock) CachedIcon(ctx context.Context, host string) ([]byte, error) {
+	return sh.fileSystem.ReadIcon(ctx, iconName(host))
+}
+
+// CacheIcon stores the icon of a host in the icon cache
+func (sh Sherlock) CacheIcon(ctx context.Context, host string, icon []byte) error {
+	return sh.fileSystem.WriteIcon(ctx, iconName(host), icon)
+}
+
+// ClearIcons removes all cached icons
+func (sh Sherlock) ClearIcons(ctx context.Context) error {
+	return sh.fileSystem.ClearIcons(ctx)
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGroupHosts(t *testing.T) {
+	group := Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "github", URL: "https://GitHub.com/login"},
+			{Name: "github-bot", URL: "github.com"},
+			{Name: "jira", URL: "jira.example.com:8443/secure"},
+			{Name: "ssh"},
+		},
+	}
+	expect := []string{"github.com", "jira.example.com:8443"}
+	if have := group.Hosts(); !reflect.DeepEqual(have, expect) {
+		t.Fatalf("group.Hosts: want: %v, have: %v", expect, have)
+	}
+}
+
+func TestIconCache(t *testing.T) {
+	sh := memLock()
+	ctx := context.Background()
+	if _, err := sh.CachedIcon(ctx, "github.com"); !os.IsNotExist(err) {
+		t.Fatalf("sherlock.CachedIcon: want: %v, have: %v", os.ErrNotExist, err)
+	}
+	if err := sh.CacheIcon(ctx, "github.com", []byte("icon")); err != nil {
+		t.Fatalf("sherlock.CacheIcon: want: nil, have: %v", err)
+	}
+	if icon, err := sh.CachedIcon(ctx, "GITHUB.com"); err != nil || string(icon) != "icon" {
+		t.Fatalf("sherlock.CachedIcon: want: icon, have: %s (%v)", icon, err)
+	}
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// strategies to resolve an imported account whose name already exists in the group
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+	ConflictPrompt    = "prompt"
+)
+
+var ErrUnknownConflict = fmt.Errorf("unknown conflict strategy (use skip, overwrite, rename or prompt)")
+
+// Resolver decides how an imported account conflicting with an existing
+// account of the same name is resolved. It returns ConflictSkip,
+// ConflictOverwrite or ConflictRename
+type Resolver func(existing, imported *Account) (string, error)
+
+// ValidConflict checks the name of a conflict strategy
+func ValidConflict(strategy string) error {
+	switch strategy {
+	case ConflictSkip, ConflictOverwrite, ConflictRename, ConflictPrompt:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownConflict, strategy)
+}
+
+// ResolveWith returns a Resolver resolving every conflict with the same strategy
+func ResolveWith(strategy string) Resolver {
+	return func(existing, imported *Account) (string, error) {
+		return strategy, nil
+	}
+}
+
+// Imported is the outcome of importing a single account. Resolution is
+// empty if the account did not conflict and was added as is
+type Imported struct {
+	Name       string
+	As         string
+	Resolution string
+}
+
+// ImportAccounts adds the accounts to the group writing the group once. An account
+// conflicting with an existing account (or an account imported before) is resolved
+// by the resolver: skipped, overwritten (keeping the history, favorite and otp secret
+// of the existing account) or renamed to the next free name like github-2
+func (sh Sherlock) ImportAccounts(ctx context.Context, gid, groupKey string, accounts []*Account, resolve Resolver) ([]Imported, error) {
+	defer sh.lockGroup(gid)()
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return nil, err
+	}
+	var before *Group
+	if sh.watched() {
+		if before, err = group.clone(); err != nil {
+			return nil, err
+		}
+	}
+	imported := make([]Imported, 0, len(accounts))
+	var changed bool
+	for _, account := range accounts {
+		if err := group.names.checkName(account.Name, ErrInvalidAccountName); err != nil {
+			return nil, err
+		}
+		result := Imported{Name: account.Name, As: account.Name}
+		existing, err := group.lookup(account.Name)
+		if errors.Is(err, ErrAmbiguousName) {
+			return nil, err
+		}
+		if err != nil {
+			group.Accounts = append(group.Accounts, account)
+			imported = append(imported, result)
+			changed = true
+			continue
+		}
+		if result.Resolution, err = resolve(existing, account); err != nil {
+			return nil, err
+		}
+		switch result.Resolution {
+		case ConflictSkip:
+		case ConflictOverwrite:
+			if err := existing.update(overwriteFields(account)); err != nil {
+				return nil, err
+			}
+			changed = true
+		case ConflictRename:
+			result.As = group.freeName(account.Name)
+			account.Name = result.As
+			group.Accounts = append(group.Accounts, account)
+			changed = true
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownConflict, result.Resolution)
+		}
+		imported = append(imported, result)
+	}
+	if !changed {
+		return imported, nil
+	}
+	events := sh.changeEvents(before, group, "")
+	if err := sh.check(ctx, events); err != nil {
+		return nil, err
+	}
+	if err := sh.WriteGroup(ctx, gid, groupKey, group); err != nil {
+		return nil, err
+	}
+	sh.emit(ctx, events)
+	return imported, nil
+}
+
+// overwriteFields replaces the values of an account with the values of the imported account
+func overwriteFields(imported *Account) FieldUpdate {
+	return func(a *Account) error {
+		a.Password = imported.Password
+		a.Username = imported.Username
+		a.URL = imported.URL
+		a.Note = imported.Note
+		a.Tag = imported.Tag
+		return nil
+	}
+}
+
+// freeName returns the first name of the form {name}-{n} not used in the group
+func (g Group) freeName(name string) string {
+	for n := 2; ; n++ {
+		candidate := name + "-" + strconv.Itoa(n)
+		if !g.exists(candidate) {
+			return candidate
+		}
+	}
+}
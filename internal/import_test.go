@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImportAccounts(t *testing.T) {
+	ctx := context.Background()
+	groupKey := "default_group_key"
+
+	newAccount := func(name, password string) *Account {
+		a, err := NewAccount("default@"+name, password, "imported", true, WithUsername("watson"))
+		if err != nil {
+			t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+		}
+		return a
+	}
+
+	tt := []struct {
+		name     string
+		strategy string
+		expect   []Imported
+		password string
+		accounts []string
+	}{
+		{
+			name:     "skip keeps the existing account",
+			strategy: ConflictSkip,
+			expect:   []Imported{{Name: "github", As: "github", Resolution: ConflictSkip}, {Name: "jira", As: "jira"}},
+			password: "existing-pass",
+			accounts: []string{"github", "jira"},
+		},
+		{
+			name:     "overwrite replaces the values",
+			strategy: ConflictOverwrite,
+			expect:   []Imported{{Name: "github", As: "github", Resolution: ConflictOverwrite}, {Name: "jira", As: "jira"}},
+			password: "imported-pass",
+			accounts: []string{"github", "jira"},
+		},
+		{
+			name:     "rename adds the account under a free name",
+			strategy: ConflictRename,
+			expect:   []Imported{{Name: "github", As: "github-2", Resolution: ConflictRename}, {Name: "jira", As: "jira"}},
+			password: "existing-pass",
+			accounts: []string{"github", "github-2", "jira"},
+		},
+	}
+
+	for _, tc := range tt {
+		sh := memLock()
+		if err := sh.Setup(ctx, groupKey); err != nil {
+			t.Fatalf("[%s] sherlock.Setup: want: nil, have: %v", tc.name, err)
+		}
+		if err := sh.UpdateState(ctx, "default@github", groupKey, OptAddAccount(newAccount("github", "existing-pass"))); err != nil {
+			t.Fatalf("[%s] sherlock.UpdateState: want: nil, have: %v", tc.name, err)
+		}
+		imported, err := sh.ImportAccounts(ctx, "default", groupKey, []*Account{
+			newAccount("github", "imported-pass"),
+			newAccount("jira", "imported-pass"),
+		}, ResolveWith(tc.strategy))
+		if err != nil {
+			t.Fatalf("[%s] sherlock.ImportAccounts: want: nil, have: %v", tc.name, err)
+		}
+		if len(imported) != len(tc.expect) {
+			t.Fatalf("[%s] sherlock.ImportAccounts: want: %v, have: %v", tc.name, tc.expect, imported)
+		}
+		for i := range imported {
+			if imported[i] != tc.expect[i] {
+				t.Fatalf("[%s] sherlock.ImportAccounts: want: %v, have: %v", tc.name, tc.expect[i], imported[i])
+			}
+		}
+		group, err := sh.LoadGroup(ctx, "default", groupKey)
+		if err != nil {
+			t.Fatalf("[%s] sherlock.LoadGroup: want: nil, have: %v", tc.name, err)
+		}
+		if len(group.Accounts) != len(tc.accounts) {
+			t.Fatalf("[%s] group accounts: want: %d, have: %d", tc.name, len(tc.accounts), len(group.Accounts))
+		}
+		for i, name := range tc.accounts {
+			if group.Accounts[i].Name != name {
+				t.Fatalf("[%s] group accounts: want: %s, have: %s", tc.name, name, group.Accounts[i].Name)
+			}
+		}
+		github, _ := group.lookup("github")
+		if github.Password != tc.password {
+			t.Fatalf("[%s] github password: want: %s, have: %s", tc.name, tc.password, github.Password)
+		}
+	}
+}
+
+func TestImportAccountsResolver(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@github", "existing-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	// accounts imported twice conflict with each other
+	first, _ := NewAccount("default@jira", "first-pass", "", true)
+	second, _ := NewAccount("default@jira", "second-pass", "", true)
+	var conflicts int
+	imported, err := sh.ImportAccounts(ctx, "default", groupKey, []*Account{first, second}, func(existing, imported *Account) (string, error) {
+		conflicts++
+		if existing.Password != "first-pass" || imported.Password != "second-pass" {
+			t.Fatalf("resolver: unexpected accounts: %s, %s", existing.Password, imported.Password)
+		}
+		return ConflictSkip, nil
+	})
+	if err != nil {
+		t.Fatalf("sherlock.ImportAccounts: want: nil, have: %v", err)
+	}
+	if conflicts != 1 || len(imported) != 2 {
+		t.Fatalf("sherlock.ImportAccounts: want: 1 conflict of 2 accounts, have: %d of %d", conflicts, len(imported))
+	}
+
+	// a failing resolver aborts the import without writing
+	abort := errors.New("aborted")
+	third, _ := NewAccount("default@wiki", "third-pass", "", true)
+	dup, _ := NewAccount("default@github", "dup-pass", "", true)
+	if _, err := sh.ImportAccounts(ctx, "default", groupKey, []*Account{third, dup}, func(existing, imported *Account) (string, error) {
+		return "", abort
+	}); err != abort {
+		t.Fatalf("sherlock.ImportAccounts: want: %v, have: %v", abort, err)
+	}
+	if _, err := sh.GetAccount(ctx, "default@wiki", groupKey); err == nil {
+		t.Fatalf("sherlock.GetAccount: want: %v, have: nil", ErrNoSuchAccount)
+	}
+
+	if _, err := sh.ImportAccounts(ctx, "default", groupKey, []*Account{dup}, ResolveWith(ConflictPrompt)); !errors.Is(err, ErrUnknownConflict) {
+		t.Fatalf("sherlock.ImportAccounts: want: %v, have: %v", ErrUnknownConflict, err)
+	}
+	if err := ValidConflict("merge"); !errors.Is(err, ErrUnknownConflict) {
+		t.Fatalf("internal.ValidConflict: want: %v, have: %v", ErrUnknownConflict, err)
+	}
+}
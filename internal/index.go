@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+// maxIndexSize keeps the index and the rest of the vault header below the
+// 64 KiB a header can hold. Larger indexes are not written and the group
+// has to be unlocked to be listed
+const maxIndexSize = 60 << 10
+
+var (
+	ErrNoIndex       = fmt.Errorf("group has no plain text index (enable it with sherlock group index)")
+	ErrIndexTampered = fmt.Errorf("plain text index of the group has been modified outside of sherlock (rebuild it with sherlock group index)")
+)
+
+// IndexEntry is the plain text part of an account kept in the vault header
+// of groups with a plain text index. It never holds any secret
+type IndexEntry struct {
+	Name      string    `json:"name"`
+	Tag       string    `json:"tag,omitempty"`
+	Favorite  bool      `json:"favorite,omitempty"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// index lists the names, tags and timestamps of the accounts. It returns
+// nil if the group has no plain text index or the index is too large
+func (g Group) index() (json.RawMessage, error) {
+	if !g.PlainIndex {
+		return nil, nil
+	}
+	entries := make([]IndexEntry, len(g.Accounts))
+	for i, a := range g.sorted() {
+		entries[i] = IndexEntry{
+			Name:      a.Name,
+			Tag:       a.Tag,
+			Favorite:  a.Favorite,
+			CreatedOn: a.CreatedOn,
+			UpdatedOn: a.UpdatedOn,
+		}
+	}
+	b, err := json.Marshal(entries)
+	if err != nil || len(b) > maxIndexSize {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GroupIndex returns the group as described by the plain text index of its
+// vault without requiring the group key. The accounts only hold their name,
+// tag, favorite mark and timestamps. The index is authenticated with the group
+// key whenever the group is unlocked; without it only the vault signature can
+// prove it has not been modified, which is checked if signing is enabled
+func (sh Sherlock) GroupIndex(ctx context.Context, gid string) (*Group, error) {
+	vault, err := sh.readVault(ctx, gid)
+	if err != nil {
+		if err == ErrNoSuchGroup {
+			return nil, sh.GroupNotFound(ctx, gid)
+		}
+		return nil, err
+	}
+	h, err := security.ReadHeader(vault)
+	if err != nil {
+		return nil, err
+	}
+	if h.Meta == nil || len(h.Meta.Index) == 0 {
+		return nil, ErrNoIndex
+	}
+	if err := sh.verifyIndex(ctx, gid, vault); err != nil {
+		return nil, err
+	}
+	var entries []IndexEntry
+	if err := json.Unmarshal(h.Meta.Index, &entries); err != nil {
+		return nil, ErrIndexTampered
+	}
+	group := Group{
+		GID:        gid,
+		Accounts:   make([]*Account, len(entries)),
+		ReadOnly:   h.Meta.ReadOnly,
+		PlainIndex: true,
+		names:      sh.names,
+	}
+	for i, e := range entries {
+		group.Accounts[i] = &Account{
+			Name:      e.Name,
+			Tag:       e.Tag,
+			Favorite:  e.Favorite,
+			CreatedOn: e.CreatedOn,
+			UpdatedOn: e.UpdatedOn,
+		}
+	}
+	return &group, nil
+}
+
+// verifyIndex checks the signature of the vault if signing is enabled
+func (sh Sherlock) verifyIndex(ctx context.Context, gid string, vault []byte) error {
+	key, err := sh.fileSystem.ReadDeviceKey(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	sig, err := sh.fileSystem.ReadSignature(ctx, gid)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_, ok, err := security.VerifyVault(key, gid, vault, sig)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIndexTampered
+	}
+	return nil
+}
+
+// SetPlainIndex adds a plain text index of the account names, tags and
+// timestamps to the vault of the group or removes it. Passwords, usernames,
+// urls, notes and otp secrets stay encrypted. A modified index is rebuilt
+func (sh Sherlock) SetPlainIndex(ctx context.Context, gid, groupKey string, on bool) error {
+	defer sh.lockGroup(gid)()
+	group, err := sh.loadGroup(ctx, gid, groupKey)
+	if err != nil && err != ErrIndexTampered {
+		return err
+	}
+	if group.PlainIndex == on && err == nil {
+		return nil
+	}
+	if group.ReadOnly {
+		return ErrReadOnlyGroup
+	}
+	kdf, err := sh.GroupKDF(ctx, gid)
+	if err != nil {
+		return err
+	}
+	op := OpGroupUnindexed
+	if on {
+		op = OpGroupIndexed
+	}
+	events := sh.groupEvents(gid, op)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	group.PlainIndex = on
+	if err := sh.writeGroup(ctx, gid, groupKey, kdf, group); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
+}
+
+// decryptError maps the errors of security.DecryptVault. Any error other
+// than a modified index means the key is wrong
+func decryptError(err error) error {
+	if err == security.ErrIndexTampered {
+		return ErrIndexTampered
+	}
+	return ErrWrongKey
+}
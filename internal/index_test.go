@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+func TestPlainIndex(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+	a, err := NewAccount("default@mail", "fsdf$35dfg0-43563sdf34", "work", false)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@mail", key, OptAddAccount(a)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	if _, err := sh.GroupIndex(ctx, "default"); err != ErrNoIndex {
+		t.Fatalf("sherlock.GroupIndex: want: %v, have: %v", ErrNoIndex, err)
+	}
+	if err := sh.SetPlainIndex(ctx, "default", "wrong_key", true); err != ErrWrongKey {
+		t.Fatalf("sherlock.SetPlainIndex: want: %v, have: %v", ErrWrongKey, err)
+	}
+	if err := sh.SetPlainIndex(ctx, "default", key, true); err != nil {
+		t.Fatalf("sherlock.SetPlainIndex: want: nil, have: %v", err)
+	}
+	group, err := sh.GroupIndex(ctx, "default")
+	if err != nil {
+		t.Fatalf("sherlock.GroupIndex: want: nil, have: %v", err)
+	}
+	if len(group.Accounts) != 1 || group.Accounts[0].Name != "mail" || group.Accounts[0].Tag != "work" {
+		t.Fatalf("sherlock.GroupIndex: want: mail #work, have: %+v", group.Accounts)
+	}
+	if group.Accounts[0].Password != "" || group.Accounts[0].CreatedOn.IsZero() {
+		t.Fatalf("sherlock.GroupIndex: want: timestamps without password, have: %+v", group.Accounts[0])
+	}
+
+	vault, err := sh.fileSystem.ReadGroupVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("fileSystem.ReadGroupVault: want: nil, have: %v", err)
+	}
+	if bytes.Contains(vault, []byte("fsdf$35dfg0-43563sdf34")) {
+		t.Fatalf("vault: want: encrypted password, have: plain text")
+	}
+
+	// renaming the account in the index must be noticed on unlock
+	h, payload, err := security.DecodeVault(vault)
+	if err != nil {
+		t.Fatalf("security.DecodeVault: want: nil, have: %v", err)
+	}
+	h.Meta.Index = bytes.Replace(h.Meta.Index, []byte(`"mail"`), []byte(`"bank"`), 1)
+	tampered, err := security.EncodeVault(h, payload)
+	if err != nil {
+		t.Fatalf("security.EncodeVault: want: nil, have: %v", err)
+	}
+	if err := sh.fileSystem.Write(ctx, "default", tampered); err != nil {
+		t.Fatalf("fileSystem.Write: want: nil, have: %v", err)
+	}
+	if _, err := sh.LoadGroup(ctx, "default", key); err != ErrIndexTampered {
+		t.Fatalf("sherlock.LoadGroup: want: %v, have: %v", ErrIndexTampered, err)
+	}
+	if _, err := sh.LoadGroup(ctx, "default", "wrong_key"); err != ErrWrongKey {
+		t.Fatalf("sherlock.LoadGroup: want: %v, have: %v", ErrWrongKey, err)
+	}
+	if err := sh.SetPlainIndex(ctx, "default", key, true); err != nil {
+		t.Fatalf("sherlock.SetPlainIndex: want: nil (rebuilt), have: %v", err)
+	}
+	if group, err = sh.LoadGroup(ctx, "default", key); err != nil || group.Accounts[0].Name != "mail" {
+		t.Fatalf("sherlock.LoadGroup: want: mail <nil>, have: %v", err)
+	}
+
+	if err := sh.SetPlainIndex(ctx, "default", key, false); err != nil {
+		t.Fatalf("sherlock.SetPlainIndex: want: nil, have: %v", err)
+	}
+	if _, err := sh.GroupIndex(ctx, "default"); err != ErrNoIndex {
+		t.Fatalf("sherlock.GroupIndex: want: %v, have: %v", ErrNoIndex, err)
+	}
+}
+
+func TestPlainIndexSigned(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetPlainIndex(ctx, "default", "default_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetPlainIndex: want: nil, have: %v", err)
+	}
+	if err := sh.EnableSigning(ctx); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+	if _, err := sh.GroupIndex(ctx, "default"); err != nil {
+		t.Fatalf("sherlock.GroupIndex: want: nil, have: %v", err)
+	}
+
+	vault, err := sh.fileSystem.ReadGroupVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("fileSystem.ReadGroupVault: want: nil, have: %v", err)
+	}
+	h, payload, err := security.DecodeVault(vault)
+	if err != nil {
+		t.Fatalf("security.DecodeVault: want: nil, have: %v", err)
+	}
+	h.Meta.Index = []byte(`[{"name":"injected","created_on":"2024-01-01T00:00:00Z","updated_on":"2024-01-01T00:00:00Z"}]`)
+	tampered, err := security.EncodeVault(h, payload)
+	if err != nil {
+		t.Fatalf("security.EncodeVault: want: nil, have: %v", err)
+	}
+	if err := sh.fileSystem.Write(ctx, "default", tampered); err != nil {
+		t.Fatalf("fileSystem.Write: want: nil, have: %v", err)
+	}
+	if _, err := sh.GroupIndex(ctx, "default"); !errors.Is(err, ErrIndexTampered) {
+		t.Fatalf("sherlock.GroupIndex: want: %v, have: %v", ErrIndexTampered, err)
+	}
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+// GroupInfo describes a group by the plain text header of its vault
+// and can therefore be read without the group key
+type GroupInfo struct {
+	GID  string
+	Size int64
+	// Known is false for vaults written before sherlock kept the
+	// account count and modification time in the vault header
+	Known    bool
+	Accounts int
+	Modified time.Time
+	ReadOnly bool
+}
+
+// GroupInfos returns the GroupInfo of all registered groups
+func (sh Sherlock) GroupInfos(ctx context.Context) ([]GroupInfo, error) {
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]GroupInfo, 0, len(gids))
+	for _, gid := range gids {
+		info, err := sh.GroupInfo(ctx, gid)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// GroupInfo returns the GroupInfo of a single group
+func (sh Sherlock) GroupInfo(ctx context.Context, gid string) (GroupInfo, error) {
+	vault, err := sh.readVault(ctx, gid)
+	if err != nil {
+		return GroupInfo{}, err
+	}
+	h, err := security.ReadHeader(vault)
+	if err != nil {
+		return GroupInfo{}, err
+	}
+	info := GroupInfo{
+		GID:  gid,
+		Size: int64(len(vault)),
+	}
+	if h.Meta != nil {
+		info.Known = true
+		info.Accounts = h.Meta.Accounts
+		info.Modified = h.Meta.Modified
+		info.ReadOnly = h.Meta.ReadOnly
+	}
+	return info, nil
+}
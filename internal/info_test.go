@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGroupInfos(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	acc, err := NewAccount("work@jira", "J1ra-horse-battery-staple", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(context.Background(), "work@jira", "work_group_key", OptAddAccount(acc)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	infos, err := sh.GroupInfos(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.GroupInfos: want: nil, have: %v", err)
+	}
+	accounts := map[string]int{"default": 0, "work": 1}
+	if len(infos) != len(accounts) {
+		t.Fatalf("sherlock.GroupInfos: want: %d groups, have: %d", len(accounts), len(infos))
+	}
+	for _, info := range infos {
+		if !info.Known || info.Accounts != accounts[info.GID] {
+			t.Fatalf("sherlock.GroupInfos: want: %s with %d accounts, have: %+v", info.GID, accounts[info.GID], info)
+		}
+		if info.Size == 0 || info.Modified.IsZero() {
+			t.Fatalf("sherlock.GroupInfos: want: size and modification time, have: %+v", info)
+		}
+	}
+
+	if _, err := sh.GroupInfo(ctx, "unknown"); err != ErrNoSuchGroup {
+		t.Fatalf("sherlock.GroupInfo: want: %v, have: %v", ErrNoSuchGroup, err)
+	}
+}
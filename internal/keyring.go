@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+var (
+	ErrNoKeyring     = fmt.Errorf("no keyring (use sherlock keyring init)")
+	ErrKeyringExists = fmt.Errorf("keyring already exists")
+)
+
+// storedKeyring is the keyring as written to the file system. The group names
+// are kept in plain text (like the group directories) so it is known which
+// groups the keyring unlocks before asking for the master passphrase. The
+// group keys are sealed with the master passphrase
+type storedKeyring struct {
+	Groups []string `json:"groups"`
+	Keys   []byte   `json:"keys"`
+}
+
+// Keyring holds the group keys unlocked by the master passphrase. The group
+// keys stay valid on their own, e.g. to share a group. It is safe for
+// concurrent use
+type Keyring struct {
+	passphrase string
+	mu         sync.RWMutex
+	keys       map[string]string
+}
+
+// Key returns the group key stored for the group
+func (k *Keyring) Key(gid string) (string, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[gid]
+	return key, ok
+}
+
+// Groups returns the groups in the keyring sorted
+func (k *Keyring) Groups() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.groups()
+}
+
+func (k *Keyring) groups() []string {
+	gids := make([]string, 0, len(k.keys))
+	for gid := range k.keys {
+		gids = append(gids, gid)
+	}
+	sort.Strings(gids)
+	return gids
+}
+
+// KeyringGroups returns the groups the keyring holds keys for without
+// unlocking it. ErrNoKeyring is returned if no keyring has been created
+func (sh Sherlock) KeyringGroups(ctx context.Context) ([]string, error) {
+	stored, err := sh.readKeyring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return stored.Groups, nil
+}
+
+// CreateKeyring creates an empty keyring sealed with the master passphrase
+func (sh Sherlock) CreateKeyring(ctx context.Context, passphrase string) (*Keyring, error) {
+	defer sh.lockSettings()()
+	if _, err := sh.readKeyring(ctx); err != ErrNoKeyring {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrKeyringExists
+	}
+	k := &Keyring{passphrase: passphrase, keys: make(map[string]string)}
+	if err := sh.writeKeyring(ctx, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// OpenKeyring unlocks the keyring with the master passphrase
+func (sh Sherlock) OpenKeyring(ctx context.Context, passphrase string) (*Keyring, error) {
+	stored, err := sh.readKeyring(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := security.OpenSealed(stored.Keys, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{passphrase: passphrase, keys: make(map[string]string)}
+	if err := json.Unmarshal(b, &k.keys); err != nil {
+		return nil, fmt.Errorf("%w: keyring: %v", ErrInvalidInput, err)
+	}
+	return k, nil
+}
+
+// AddToKeyring stores the group key in the keyring. The key is checked
+// against the group first so a wrong key is never stored
+func (sh Sherlock) AddToKeyring(ctx context.Context, k *Keyring, gid, groupKey string) error {
+	if _, err := sh.LoadGroup(ctx, gid, groupKey); err != nil {
+		return err
+	}
+	defer sh.lockSettings()()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[gid] = groupKey
+	return sh.writeKeyring(ctx, k)
+}
+
+// RemoveFromKeyring removes the key of the group from the keyring
+func (sh Sherlock) RemoveFromKeyring(ctx context.Context, k *Keyring, gid string) error {
+	defer sh.lockSettings()()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[gid]; !ok {
+		return fmt.Errorf("%w: group %s is not in the keyring", ErrInvalidInput, gid)
+	}
+	delete(k.keys, gid)
+	return sh.writeKeyring(ctx, k)
+}
+
+func (sh Sherlock) readKeyring(ctx context.Context) (storedKeyring, error) {
+	var stored storedKeyring
+	b, err := sh.fileSystem.ReadKeyring(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stored, ErrNoKeyring
+		}
+		return stored, err
+	}
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return stored, fmt.Errorf("%w: keyring: %v", ErrInvalidInput, err)
+	}
+	return stored, nil
+}
+
+// writeKeyring seals and writes the keyring. The caller holds k.mu unless
+// no one else knows the keyring yet
+func (sh Sherlock) writeKeyring(ctx context.Context, k *Keyring) error {
+	b, err := json.Marshal(k.keys)
+	if err != nil {
+		return err
+	}
+	sealed, err := security.SealPassphrase(b, k.passphrase)
+	if err != nil {
+		return err
+	}
+	b, err = json.Marshal(storedKeyring{Groups: k.groups(), Keys: sealed})
+	if err != nil {
+		return err
+	}
+	return sh.fileSystem.WriteKeyring(ctx, b)
+}
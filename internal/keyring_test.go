@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if _, err := sh.KeyringGroups(ctx); err != ErrNoKeyring {
+		t.Fatalf("sherlock.KeyringGroups: want: %v, have: %v", ErrNoKeyring, err)
+	}
+	if _, err := sh.OpenKeyring(ctx, "master"); err != ErrNoKeyring {
+		t.Fatalf("sherlock.OpenKeyring: want: %v, have: %v", ErrNoKeyring, err)
+	}
+
+	k, err := sh.CreateKeyring(ctx, "master")
+	if err != nil {
+		t.Fatalf("sherlock.CreateKeyring: want: nil, have: %v", err)
+	}
+	if _, err := sh.CreateKeyring(ctx, "other"); err != ErrKeyringExists {
+		t.Fatalf("sherlock.CreateKeyring: want: %v, have: %v", ErrKeyringExists, err)
+	}
+	if err := sh.AddToKeyring(ctx, k, "work", "wrong_key"); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("sherlock.AddToKeyring(wrong key): want: %v, have: %v", ErrWrongKey, err)
+	}
+	if err := sh.AddToKeyring(ctx, k, "private", "private_group_key"); !errors.Is(err, ErrNoSuchGroup) {
+		t.Fatalf("sherlock.AddToKeyring(private): want: %v, have: %v", ErrNoSuchGroup, err)
+	}
+	for gid, key := range map[string]string{"default": "default_group_key", "work": "work_group_key"} {
+		if err := sh.AddToKeyring(ctx, k, gid, key); err != nil {
+			t.Fatalf("sherlock.AddToKeyring(%s): want: nil, have: %v", gid, err)
+		}
+	}
+
+	gids, err := sh.KeyringGroups(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.KeyringGroups: want: nil, have: %v", err)
+	}
+	if want := []string{"default", "work"}; !reflect.DeepEqual(gids, want) {
+		t.Fatalf("sherlock.KeyringGroups: want: %v, have: %v", want, gids)
+	}
+	if _, err := sh.OpenKeyring(ctx, "wrong"); !errors.Is(err, security.ErrOpenSealed) {
+		t.Fatalf("sherlock.OpenKeyring(wrong): want: %v, have: %v", security.ErrOpenSealed, err)
+	}
+	opened, err := sh.OpenKeyring(ctx, "master")
+	if err != nil {
+		t.Fatalf("sherlock.OpenKeyring: want: nil, have: %v", err)
+	}
+	// the group key unlocked by the master passphrase opens the group
+	key, ok := opened.Key("work")
+	if !ok {
+		t.Fatalf("Keyring.Key(work): want: true, have: false")
+	}
+	if _, err := sh.LoadGroup(ctx, "work", key); err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+
+	if err := sh.RemoveFromKeyring(ctx, opened, "work"); err != nil {
+		t.Fatalf("sherlock.RemoveFromKeyring: want: nil, have: %v", err)
+	}
+	if err := sh.RemoveFromKeyring(ctx, opened, "work"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("sherlock.RemoveFromKeyring: want: %v, have: %v", ErrInvalidInput, err)
+	}
+	if gids, _ := sh.KeyringGroups(ctx); !reflect.DeepEqual(gids, []string{"default"}) {
+		t.Fatalf("sherlock.KeyringGroups: want: [default], have: %v", gids)
+	}
+}
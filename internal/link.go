@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// linkMark separates the name of a linked account from its target in tables
+const linkMark = " → "
+
+var (
+	ErrInvalidAccountLink = fmt.Errorf("invalid account link")
+)
+
+// Linked reports whether the account uses the password of another account
+// instead of its own
+func (a Account) Linked() bool {
+	return a.Link != ""
+}
+
+// LinkAccount links the account of the query to the target account
+// (group@account) so it uses the password of the target instead of a copy.
+// The own password of the account is removed. Links point to accounts with a
+// password of their own so they are never chained
+func (sh Sherlock) LinkAccount(ctx context.Context, query, groupKey, target, targetKey string) error {
+	if err := sh.checkLinkTarget(ctx, query, target, targetKey); err != nil {
+		return err
+	}
+	return sh.UpdateState(ctx, query, groupKey, func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		// accounts linking to this one would end up chained
+		for _, a := range g.Accounts {
+			if a.Link == g.GID+querySplitPoint+account.Name {
+				return fmt.Errorf("%w: %s@%s is the target of %s@%s", ErrInvalidAccountLink, g.GID, account.Name, g.GID, a.Name)
+			}
+		}
+		return account.update(func(a *Account) error {
+			a.Link, a.Password = target, ""
+			return nil
+		})
+	})
+}
+
+// UnlinkAccount removes the link of the account giving it a copy of the
+// password of the target, so the account keeps working
+func (sh Sherlock) UnlinkAccount(ctx context.Context, query, groupKey, targetKey string) error {
+	account, err := sh.GetAccount(ctx, query, groupKey)
+	if err != nil {
+		return err
+	}
+	if !account.Linked() {
+		return fmt.Errorf("%w: %s is not linked", ErrInvalidAccountLink, query)
+	}
+	if err := sh.FollowLink(ctx, account, targetKey); err != nil {
+		return err
+	}
+	return sh.UpdateState(ctx, query, groupKey, func(g *Group, acc string) error {
+		a, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return a.update(func(a *Account) error {
+			a.Link, a.Password = "", account.Password
+			return nil
+		})
+	})
+}
+
+// FollowLink sets the password of a linked account to the one of its target.
+// The change is not stored. Accounts which are not linked are left as they are
+func (sh Sherlock) FollowLink(ctx context.Context, account *Account, targetKey string) error {
+	if !account.Linked() {
+		return nil
+	}
+	target, err := sh.GetAccount(ctx, account.Link, targetKey)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidAccountLink, account.Link, err)
+	}
+	if target.Linked() {
+		return fmt.Errorf("%w: %s is linked itself", ErrInvalidAccountLink, account.Link)
+	}
+	if err := target.OpenTimeLock(time.Now()); err != nil {
+		return err
+	}
+	account.Password = target.Password
+	return nil
+}
+
+// KeyFunc returns the key of a group, e.g. to follow a link into it
+type KeyFunc func(gid string) (string, error)
+
+// AccountPassword returns the password of an account of a loaded group as it is
+// handed to other tools: an expired time lock is opened (ErrTimeLocked before)
+// and a link is followed with the key of its target group returned by keys.
+// The account is not changed
+func (sh Sherlock) AccountPassword(ctx context.Context, account Account, keys KeyFunc) (string, error) {
+	if err := account.OpenTimeLock(time.Now()); err != nil {
+		return "", err
+	}
+	if account.Linked() {
+		targetKey, err := keys(account.Link)
+		if err != nil {
+			return "", err
+		}
+		if err := sh.FollowLink(ctx, &account, targetKey); err != nil {
+			return "", err
+		}
+	}
+	return account.Password, nil
+}
+
+// checkLinkTarget checks that the target exists, is not the account itself
+// and is not linked itself
+func (sh Sherlock) checkLinkTarget(ctx context.Context, query, target, targetKey string) error {
+	gid, name, err := SplitQuery(target)
+	if err != nil || gid == "" || name == "" {
+		return fmt.Errorf("%w: target must be a group@account query, have %q", ErrInvalidAccountLink, target)
+	}
+	if strings.EqualFold(query, target) {
+		return fmt.Errorf("%w: %s cannot link to itself", ErrInvalidAccountLink, query)
+	}
+	account, err := sh.GetAccount(ctx, target, targetKey)
+	if err != nil {
+		return err
+	}
+	if account.Linked() {
+		return fmt.Errorf("%w: %s is linked to %s itself (link to %s instead)", ErrInvalidAccountLink, target, account.Link, account.Link)
+	}
+	return nil
+}
+
+// FilterLinked matches accounts linking to another account
+func FilterLinked(a *Account) bool {
+	return a.Linked()
+}
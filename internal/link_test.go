@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLinkAccount(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	for _, query := range []string{"default@sso", "default@jira", "default@wiki"} {
+		account, err := NewAccount(query, "Sup3r$ecret-pass-"+query, "", true)
+		if err != nil {
+			t.Fatalf("NewAccount: want: nil, have: %v", err)
+		}
+		if err := sh.UpdateState(ctx, query, "default_group_key", OptAddAccount(account)); err != nil {
+			t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+		}
+	}
+	const key = "default_group_key"
+
+	tt := []struct {
+		name   string
+		query  string
+		target string
+		err    error
+	}{
+		{name: "self", query: "default@sso", target: "default@sso", err: ErrInvalidAccountLink},
+		{name: "no account", query: "default@jira", target: "default", err: ErrInvalidAccountLink},
+		{name: "unknown target", query: "default@jira", target: "default@nope", err: ErrNoSuchAccount},
+		{name: "link", query: "default@jira", target: "default@sso", err: nil},
+		{name: "chained", query: "default@wiki", target: "default@jira", err: ErrInvalidAccountLink},
+		{name: "target of a link", query: "default@sso", target: "default@wiki", err: ErrInvalidAccountLink},
+	}
+	for _, tc := range tt {
+		if err := sh.LinkAccount(ctx, tc.query, key, tc.target, key); !errors.Is(err, tc.err) {
+			t.Fatalf("[%s] sherlock.LinkAccount: want: %v, have: %v", tc.name, tc.err, err)
+		}
+	}
+
+	jira, err := sh.GetAccount(ctx, "default@jira", key)
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	if !jira.Linked() || jira.Password != "" {
+		t.Fatalf("sherlock.LinkAccount: want: linked account without password, have: %+v", jira)
+	}
+	if err := sh.FollowLink(ctx, jira, key); err != nil || jira.Password != "Sup3r$ecret-pass-default@sso" {
+		t.Fatalf("sherlock.FollowLink: want: password of default@sso, have: %q (%v)", jira.Password, err)
+	}
+	group, err := sh.LoadGroup(ctx, "default", key)
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if rows := group.Table(FilterLinked); len(rows) != 1 || !strings.HasSuffix(rows[0][1], linkMark+"default@sso") {
+		t.Fatalf("Group.Table: want: jira linked to default@sso, have: %v", rows)
+	}
+	// a linked account is no password reuse
+	if clusters := FindReuse(group); len(clusters) != 0 {
+		t.Fatalf("FindReuse: want: no reuse, have: %v", clusters)
+	}
+
+	if err := sh.UnlinkAccount(ctx, "default@jira", key, key); err != nil {
+		t.Fatalf("sherlock.UnlinkAccount: want: nil, have: %v", err)
+	}
+	if jira, err = sh.GetAccount(ctx, "default@jira", key); err != nil || jira.Linked() || jira.Password != "Sup3r$ecret-pass-default@sso" {
+		t.Fatalf("sherlock.UnlinkAccount: want: own copy of the password, have: %+v (%v)", jira, err)
+	}
+	if err := sh.UnlinkAccount(ctx, "default@jira", key, key); !errors.Is(err, ErrInvalidAccountLink) {
+		t.Fatalf("sherlock.UnlinkAccount: want: %v, have: %v", ErrInvalidAccountLink, err)
+	}
+
+	// setting an own password removes the link
+	if err := sh.LinkAccount(ctx, "default@wiki", key, "default@sso", key); err != nil {
+		t.Fatalf("sherlock.LinkAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@wiki", key, OptAccPassword("An0ther$ecret-pass", true)); err != nil {
+		t.Fatalf("sherlock.UpdateState(password): want: nil, have: %v", err)
+	}
+	if wiki, err := sh.GetAccount(ctx, "default@wiki", key); err != nil || wiki.Linked() {
+		t.Fatalf("OptAccPassword: want: link removed, have: %+v (%v)", wiki, err)
+	}
+}
+
+func TestAccountPasswordLinked(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+	for _, query := range []string{"default@sso", "default@jira"} {
+		account, err := NewAccount(query, "Sup3r$ecret-pass-"+query, "", true)
+		if err != nil {
+			t.Fatalf("NewAccount: want: nil, have: %v", err)
+		}
+		if err := sh.UpdateState(ctx, query, key, OptAddAccount(account)); err != nil {
+			t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+		}
+	}
+	if err := sh.LinkAccount(ctx, "default@jira", key, "default@sso", key); err != nil {
+		t.Fatalf("sherlock.LinkAccount: want: nil, have: %v", err)
+	}
+	group, err := sh.LoadGroup(ctx, "default", key)
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	keys := func(gid string) (string, error) { return key, nil }
+
+	// exports and pushes take the password of every account of a loaded group
+	for _, account := range group.Accounts {
+		password, err := sh.AccountPassword(ctx, *account, keys)
+		if err != nil || password != "Sup3r$ecret-pass-default@sso" {
+			t.Fatalf("sherlock.AccountPassword(%s): want: password of default@sso, have: %q (%v)", account.Name, password, err)
+		}
+		if account.Name == "jira" && account.Password != "" {
+			t.Fatalf("sherlock.AccountPassword: want: account unchanged, have: %+v", account)
+		}
+	}
+
+	ref := Reference{GID: "default", Account: "jira", Field: FieldPassword}
+	if _, err := group.Resolve(ref); !errors.Is(err, ErrInvalidAccountLink) {
+		t.Fatalf("Group.Resolve(linked): want: %v, have: %v", ErrInvalidAccountLink, err)
+	}
+	if value, err := sh.ResolveReference(ctx, *group, ref, keys); err != nil || value != "Sup3r$ecret-pass-default@sso" {
+		t.Fatalf("sherlock.ResolveReference(linked): want: password of default@sso, have: %q (%v)", value, err)
+	}
+}
@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"sync"
+)
+
+// locks serializes the read-modify-write cycles on the stored files so that a
+// Sherlock can be used by concurrent goroutines, e.g. an agent or an embedder
+// serving parallel requests. It is shared by all copies of a Sherlock. Locks
+// are only held within a process; a group is always locked before the settings
+type locks struct {
+	mu     sync.Mutex
+	groups map[string]*sync.Mutex
+	// settings guards the config, keyring and unlock log
+	settings sync.Mutex
+}
+
+func newLocks() *locks {
+	return &locks{groups: make(map[string]*sync.Mutex)}
+}
+
+// lockGroup blocks until no other change of the group is in progress and
+// returns the function releasing the group again
+func (sh Sherlock) lockGroup(gid string) func() {
+	sh.locks.mu.Lock()
+	mu, ok := sh.locks.groups[gid]
+	if !ok {
+		mu = &sync.Mutex{}
+		sh.locks.groups[gid] = mu
+	}
+	sh.locks.mu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// lockSettings blocks until no other change of the config, keyring or unlock
+// log is in progress and returns the function releasing them again. No group
+// may be locked while the settings are held
+func (sh Sherlock) lockSettings() func() {
+	sh.locks.settings.Lock()
+	return sh.locks.settings.Unlock
+}
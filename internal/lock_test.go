@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+	const n = 4
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			query := fmt.Sprintf("default@account%d", i)
+			a, err := NewAccount(query, "fsdf$35dfg0-43563sdf34", "", false)
+			if err != nil {
+				errs <- err
+				return
+			}
+			sh.Unlocking("default")
+			errs <- sh.UpdateState(ctx, query, key, OptAddAccount(a))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			errs <- sh.SetAlias(ctx, fmt.Sprintf("alias%d", i), "default")
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent update: want: nil, have: %v", err)
+		}
+	}
+
+	sh.Unlocking("default")
+	group, err := sh.LoadGroup(ctx, "default", key)
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if len(group.Accounts) != n {
+		t.Fatalf("sherlock.UpdateState: want: %d accounts, have: %d", n, len(group.Accounts))
+	}
+	config, err := sh.Config(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	if len(config.Aliases) != n {
+		t.Fatalf("sherlock.SetAlias: want: %d aliases, have: %v", n, config.Aliases)
+	}
+	attempts, err := sh.UnlockLog(ctx)
+	if err != nil || len(attempts) != n+1 {
+		t.Fatalf("sherlock.UnlockLog: want: %d attempts, have: %d (%v)", n+1, len(attempts), err)
+	}
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+var (
+	ErrRoundTrip = fmt.Errorf("re-encrypted vault does not decrypt to the same group")
+)
+
+// GroupKDF returns the key derivation used by the group vault
+func (sh Sherlock) GroupKDF(ctx context.Context, gid string) (string, error) {
+	bytes, err := sh.readVault(ctx, gid)
+	if err != nil {
+		return "", err
+	}
+	h, _, err := security.DecodeVault(bytes)
+	if err != nil {
+		return "", err
+	}
+	return h.KDF, nil
+}
+
+// MigrateKDF re-encrypts the group vault with a key derived by the kdf
+func (sh Sherlock) MigrateKDF(ctx context.Context, gid, groupKey, kdf string) error {
+	defer sh.lockGroup(gid)()
+	if !security.IsKDF(kdf) {
+		return security.ErrUnsupportedKDF
+	}
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return err
+	}
+	if group.ReadOnly {
+		return ErrReadOnlyGroup
+	}
+	events := sh.groupEvents(gid, OpGroupMigrated)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	if err := sh.writeGroup(ctx, gid, groupKey, kdf, group); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
+}
+
+// GroupCurrent reports whether the group vault is encrypted in the current
+// format with the default key derivation and parameters
+func (sh Sherlock) GroupCurrent(ctx context.Context, gid string) (bool, error) {
+	return sh.GroupMatches(ctx, gid, security.DefaultKDF)
+}
+
+// GroupMatches reports whether the group vault is encrypted in the current
+// format with the kdf and its current parameters. An argon2id vault with
+// weaker parameters than the current ones does not match argon2id
+func (sh Sherlock) GroupMatches(ctx context.Context, gid, kdf string) (bool, error) {
+	bytes, err := sh.readVault(ctx, gid)
+	if err != nil {
+		return false, err
+	}
+	h, err := security.ReadHeader(bytes)
+	if err != nil {
+		return false, err
+	}
+	return h.Matches(kdf), nil
+}
+
+// Reencrypt re-encrypts the group vault with the default key derivation and
+// its current parameters. The new vault is decrypted and compared to the
+// group before it replaces the old one so a faulty encryption never loses data
+func (sh Sherlock) Reencrypt(ctx context.Context, gid, groupKey string) error {
+	defer sh.lockGroup(gid)()
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return err
+	}
+	if group.ReadOnly {
+		return ErrReadOnlyGroup
+	}
+	events := sh.groupEvents(gid, OpGroupMigrated)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	encrypted, err := encryptGroup(group, groupKey, security.DefaultKDF)
+	if err != nil {
+		return err
+	}
+	want, err := group.serizalize()
+	if err != nil {
+		return err
+	}
+	// the vault is decrypted in place so a copy is checked
+	var check Group
+	if err := security.DecryptVault(append([]byte(nil), encrypted...), groupKey, &check); err != nil {
+		return fmt.Errorf("%w: %v", ErrRoundTrip, err)
+	}
+	have, err := check.serizalize()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, have) {
+		return ErrRoundTrip
+	}
+	if err := sh.writeVault(ctx, gid, encrypted); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
+}
+
+// Reencrypted returns the groups already re-encrypted by an interrupted
+// vault-wide re-encryption
+func (sh Sherlock) Reencrypted(ctx context.Context) ([]string, error) {
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.Reencrypted, nil
+}
+
+// SetReencrypted records the progress of a vault-wide re-encryption so it can
+// be resumed. No groups mark it as complete
+func (sh Sherlock) SetReencrypted(ctx context.Context, gids []string) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	config.Reencrypted = gids
+	return sh.SaveConfig(ctx, config)
+}
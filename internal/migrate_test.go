@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+func TestMigrateKDF(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.MigrateKDF(context.Background(), "default", "default_group_key", security.KDFSHA256); err != nil {
+		t.Fatalf("sherlock.MigrateKDF: want: nil, have: %v", err)
+	}
+	if kdf, err := sh.GroupKDF(ctx, "default"); err != nil || kdf != security.KDFSHA256 {
+		t.Fatalf("sherlock.GroupKDF: want: %s <nil>, have: %s %v", security.KDFSHA256, kdf, err)
+	}
+
+	// writes keep the key derivation of the vault
+	group, err := sh.LoadGroup(ctx, "default", "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if err := sh.WriteGroup(context.Background(), "default", "default_group_key", group); err != nil {
+		t.Fatalf("sherlock.WriteGroup: want: nil, have: %v", err)
+	}
+	if kdf, err := sh.GroupKDF(ctx, "default"); err != nil || kdf != security.KDFSHA256 {
+		t.Fatalf("sherlock.GroupKDF: want: %s <nil>, have: %s %v", security.KDFSHA256, kdf, err)
+	}
+
+	if err := sh.MigrateKDF(context.Background(), "default", "wrong_key", security.KDFArgon2id); err != ErrWrongKey {
+		t.Fatalf("sherlock.MigrateKDF: want: %v, have: %v", ErrWrongKey, err)
+	}
+	if err := sh.MigrateKDF(context.Background(), "default", "default_group_key", security.KDFArgon2id); err != nil {
+		t.Fatalf("sherlock.MigrateKDF: want: nil, have: %v", err)
+	}
+	if kdf, err := sh.GroupKDF(ctx, "default"); err != nil || kdf != security.KDFArgon2id {
+		t.Fatalf("sherlock.GroupKDF: want: %s <nil>, have: %s %v", security.KDFArgon2id, kdf, err)
+	}
+	if _, err := sh.LoadGroup(ctx, "default", "default_group_key"); err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if matches, err := sh.GroupMatches(ctx, "default", security.KDFArgon2id); err != nil || !matches {
+		t.Fatalf("sherlock.GroupMatches: want: true <nil>, have: %v %v", matches, err)
+	}
+
+	// argon2id with fewer passes than the current parameters needs a migration
+	vault, err := sh.fileSystem.ReadGroupVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("fs.ReadGroupVault: want: nil, have: %v", err)
+	}
+	h, payload, err := security.DecodeVault(vault)
+	if err != nil {
+		t.Fatalf("security.DecodeVault: want: nil, have: %v", err)
+	}
+	h.Time = 1
+	if vault, err = security.EncodeVault(h, payload); err != nil {
+		t.Fatalf("security.EncodeVault: want: nil, have: %v", err)
+	}
+	if err := sh.fileSystem.Write(ctx, "default", vault); err != nil {
+		t.Fatalf("fs.Write: want: nil, have: %v", err)
+	}
+	if matches, err := sh.GroupMatches(ctx, "default", security.KDFArgon2id); err != nil || matches {
+		t.Fatalf("sherlock.GroupMatches(weak argon2id): want: false <nil>, have: %v %v", matches, err)
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+	}
+	if err := sh.MigrateKDF(ctx, "default", "default_group_key", security.KDFSHA256); err != nil {
+		t.Fatalf("sherlock.MigrateKDF: want: nil, have: %v", err)
+	}
+	if current, err := sh.GroupCurrent(ctx, "default"); err != nil || current {
+		t.Fatalf("sherlock.GroupCurrent: want: false <nil>, have: %v %v", current, err)
+	}
+	if matches, err := sh.GroupMatches(ctx, "default", security.KDFSHA256); err != nil || !matches {
+		t.Fatalf("sherlock.GroupMatches(sha256): want: true <nil>, have: %v %v", matches, err)
+	}
+
+	if err := sh.Reencrypt(ctx, "default", "wrong_key"); err != ErrWrongKey {
+		t.Fatalf("sherlock.Reencrypt: want: %v, have: %v", ErrWrongKey, err)
+	}
+	if err := sh.Reencrypt(ctx, "default", "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Reencrypt: want: nil, have: %v", err)
+	}
+	if current, err := sh.GroupCurrent(ctx, "default"); err != nil || !current {
+		t.Fatalf("sherlock.GroupCurrent: want: true <nil>, have: %v %v", current, err)
+	}
+	if a, err := sh.GetAccount(ctx, "default@github", "default_group_key"); err != nil || a.Password != "Sup3r$ecret-pass" {
+		t.Fatalf("sherlock.GetAccount: want: account unchanged, have: %+v %v", a, err)
+	}
+
+	if err := sh.SetReencrypted(ctx, []string{"default"}); err != nil {
+		t.Fatalf("sherlock.SetReencrypted: want: nil, have: %v", err)
+	}
+	if done, err := sh.Reencrypted(ctx); err != nil || len(done) != 1 || done[0] != "default" {
+		t.Fatalf("sherlock.Reencrypted: want: [default], have: %v %v", done, err)
+	}
+}
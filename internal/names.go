@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrNameCollision = fmt.Errorf("name differs from an existing one only in case or unicode normalization")
+	ErrAmbiguousName = fmt.Errorf("name matches more than one")
+	ErrNamePattern   = fmt.Errorf("name does not match the name pattern")
+	ErrKeyEnvName    = fmt.Errorf("name maps to the same SHERLOCK_KEY_<GROUP> variable as an existing group")
+)
+
+// GroupKeyEnvPrefix is the prefix of environment variables holding a group key
+// like so SHERLOCK_KEY_<GROUP>
+const GroupKeyEnvPrefix = "SHERLOCK_KEY_"
+
+// nameRules are the settings of the config applied to group and account names
+type nameRules struct {
+	// fold matches names apart from case and normalization, see Sherlock.FoldNames
+	fold bool
+	// pattern has to match every new name on top of validName
+	pattern *regexp.Regexp
+}
+
+// FoldNames makes group and account lookups case-insensitive and Unicode
+// normalized (NFC) so Github finds github. Names colliding that way cannot be
+// created anymore
+func (sh *Sherlock) FoldNames(fold bool) {
+	sh.names.fold = fold
+}
+
+// NamePattern sets a regular expression new group and account names have to
+// match in addition to the default rules. An empty pattern removes it
+func (sh *Sherlock) NamePattern(pattern string) error {
+	re, err := compileNamePattern(pattern)
+	if err != nil {
+		return err
+	}
+	sh.names.pattern = re
+	return nil
+}
+
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: name pattern: %v", ErrInvalidInput, err)
+	}
+	return re, nil
+}
+
+// validName reports whether the name follows the default rules of group and
+// account names: no whitespace, control characters, @ (which splits queries)
+// or path separators and neither . nor ..
+func validName(name string) bool {
+	if name == "." || name == ".." {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '@' || r == '/' || r == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkName validates a new name against the default rules, returning invalid
+// (ErrInvalidGroupName or ErrInvalidAccountName), and the pattern
+func (r nameRules) checkName(name string, invalid error) error {
+	if !validName(name) {
+		return fmt.Errorf("%w: %q", invalid, name)
+	}
+	if r.pattern != nil && !r.pattern.MatchString(name) {
+		return fmt.Errorf("%w %s: %q", ErrNamePattern, r.pattern, name)
+	}
+	return nil
+}
+
+// sameName reports whether the names are equal apart from case and Unicode
+// normalization
+func sameName(a, b string) bool {
+	return strings.EqualFold(norm.NFC.String(a), norm.NFC.String(b))
+}
+
+// foldedMatches returns the names matching name apart from case and
+// normalization
+func foldedMatches(name string, names []string) []string {
+	var matches []string
+	for _, n := range names {
+		if sameName(name, n) {
+			matches = append(matches, n)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// recased reports whether renaming the account acc to name only changes the
+// case or normalization of its own name, which folded names allow
+func (g Group) recased(acc, name string) bool {
+	if !g.names.fold {
+		return false
+	}
+	account, err := g.lookup(acc)
+	if err != nil {
+		return false
+	}
+	existing, err := g.lookup(name)
+	return err == nil && existing == account && account.Name != name
+}
+
+// ResolveGroup returns the group a name stands for: the group of an alias or,
+// with FoldNames, the only group whose name matches apart from case and
+// normalization. Other names are returned as they are
+func (sh Sherlock) ResolveGroup(ctx context.Context, config Config, name string) (string, error) {
+	name = config.ResolveGroup(name)
+	if !sh.names.fold {
+		return name, nil
+	}
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, gid := range gids {
+		if gid == name {
+			return name, nil
+		}
+	}
+	switch matches := foldedMatches(name, gids); len(matches) {
+	case 0:
+		return name, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: group %q matches %s", ErrAmbiguousName, name, strings.Join(matches, ", "))
+	}
+}
+
+// ResolveQuery resolves the group of a group@account query like ResolveGroup
+func (sh Sherlock) ResolveQuery(ctx context.Context, config Config, query string) (string, error) {
+	set := strings.SplitN(query, querySplitPoint, 2)
+	gid, err := sh.ResolveGroup(ctx, config, set[0])
+	if err != nil || len(set) != 2 {
+		return gid, err
+	}
+	return gid + querySplitPoint + set[1], nil
+}
+
+// GroupNameCollisions returns the sets of registered groups whose names only
+// differ in case or normalization. They have to be renamed before FoldNames
+// can resolve them
+func (sh Sherlock) GroupNameCollisions(ctx context.Context) ([][]string, error) {
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var collisions [][]string
+	for _, gid := range gids {
+		if seen[gid] {
+			continue
+		}
+		if matches := foldedMatches(gid, gids); len(matches) > 1 {
+			for _, m := range matches {
+				seen[m] = true
+			}
+			collisions = append(collisions, matches)
+		}
+	}
+	return collisions, nil
+}
+
+// GroupKeyEnv builds the name of the environment variable for a group.
+// The group name is upper-cased and every character other than a letter or
+// digit is replaced with an underscore => work-infra: SHERLOCK_KEY_WORK_INFRA
+func GroupKeyEnv(gid string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, gid)
+	return GroupKeyEnvPrefix + name
+}
+
+// checkGroupName validates the name of a new group and returns ErrNameCollision
+// if FoldNames is set and a group with a colliding name exists. A name whose
+// key would be read from the variable of an existing group (work-infra and
+// work_infra) returns ErrKeyEnvName
+func (sh Sherlock) checkGroupName(ctx context.Context, name string) error {
+	if err := sh.names.checkName(name, ErrInvalidGroupName); err != nil {
+		return err
+	}
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return err
+	}
+	if sh.names.fold {
+		if matches := foldedMatches(name, gids); len(matches) > 0 {
+			return fmt.Errorf("%w: %s collides with group %s", ErrNameCollision, name, matches[0])
+		}
+	}
+	env := GroupKeyEnv(name)
+	for _, gid := range gids {
+		if gid != name && GroupKeyEnv(gid) == env {
+			return fmt.Errorf("%w: %s and group %s share %s", ErrKeyEnvName, name, gid, env)
+		}
+	}
+	return nil
+}
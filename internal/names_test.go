@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFoldNames(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "Work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	// names sharing the SHERLOCK_KEY_<GROUP> variable of a group are refused
+	for _, name := range []string{"work", "WORK"} {
+		if err := sh.SetupGroup(ctx, name, "work_group_key", true); !errors.Is(err, ErrKeyEnvName) {
+			t.Fatalf("sherlock.SetupGroup(%s): want: %v, have: %v", name, ErrKeyEnvName, err)
+		}
+	}
+	if err := sh.SetupGroup(ctx, "work-infra", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work_infra", "work_group_key", true); !errors.Is(err, ErrKeyEnvName) {
+		t.Fatalf("sherlock.SetupGroup(work_infra): want: %v, have: %v", ErrKeyEnvName, err)
+	}
+	// without folding names differing in case are distinct, e.g. groups
+	// created before they were refused and restored from a snapshot
+	vault, err := sh.ReadVault(ctx, "Work")
+	if err != nil {
+		t.Fatalf("sherlock.ReadVault: want: nil, have: %v", err)
+	}
+	if err := sh.RestoreGroup(ctx, "work", vault, false); err != nil {
+		t.Fatalf("sherlock.RestoreGroup: want: nil, have: %v", err)
+	}
+	add := func(query string) error {
+		account, err := NewAccount(query, "Sup3r$ecret-pass", "", true)
+		if err != nil {
+			return err
+		}
+		return sh.UpdateState(ctx, query, "default_group_key", OptAddAccount(account))
+	}
+	// "Café" is added decomposed (e + combining acute accent) and looked up composed
+	for _, query := range []string{"default@github", "default@Cafe\u0301"} {
+		if err := add(query); err != nil {
+			t.Fatalf("sherlock.UpdateState(add %s): want: nil, have: %v", query, err)
+		}
+	}
+	if _, err := sh.GetAccount(ctx, "default@GitHub", "default_group_key"); !errors.Is(err, ErrNoSuchAccount) {
+		t.Fatalf("sherlock.GetAccount(GitHub): want: %v, have: %v", ErrNoSuchAccount, err)
+	}
+
+	sh.FoldNames(true)
+	for _, query := range []string{"default@GitHub", "default@café"} {
+		if _, err := sh.GetAccount(ctx, query, "default_group_key"); err != nil {
+			t.Fatalf("sherlock.GetAccount(%s): want: nil, have: %v", query, err)
+		}
+	}
+	if err := add("default@GITHUB"); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("sherlock.UpdateState(add GITHUB): want: %v, have: %v", ErrAccountExists, err)
+	}
+	// an account may change the case of its own name
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccName("GitHub")); err != nil {
+		t.Fatalf("sherlock.UpdateState(rename GitHub): want: nil, have: %v", err)
+	}
+	if _, err := sh.GetAccount(ctx, "default@GitHub", "default_group_key"); err != nil {
+		t.Fatalf("sherlock.GetAccount(GitHub): want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccName("café")); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("sherlock.UpdateState(rename café): want: %v, have: %v", ErrAccountExists, err)
+	}
+
+	if err := sh.SetupGroup(ctx, "PRIVATE", "private_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "private", "private_group_key", true); !errors.Is(err, ErrNameCollision) {
+		t.Fatalf("sherlock.SetupGroup(private): want: %v, have: %v", ErrNameCollision, err)
+	}
+	var config Config
+	if gid, err := sh.ResolveGroup(ctx, config, "Private"); err != nil || gid != "PRIVATE" {
+		t.Fatalf("sherlock.ResolveGroup(Private): want: PRIVATE, have: %s (%v)", gid, err)
+	}
+	if query, err := sh.ResolveQuery(ctx, config, "private@mail"); err != nil || query != "PRIVATE@mail" {
+		t.Fatalf("sherlock.ResolveQuery(private@mail): want: PRIVATE@mail, have: %s (%v)", query, err)
+	}
+	// exact names win, colliding groups created before are ambiguous
+	if gid, _ := sh.ResolveGroup(ctx, config, "work"); gid != "work" {
+		t.Fatalf("sherlock.ResolveGroup(work): want: work, have: %s", gid)
+	}
+	if _, err := sh.ResolveGroup(ctx, config, "WORK"); !errors.Is(err, ErrAmbiguousName) {
+		t.Fatalf("sherlock.ResolveGroup(WORK): want: %v, have: %v", ErrAmbiguousName, err)
+	}
+	collisions, err := sh.GroupNameCollisions(ctx)
+	if want := [][]string{{"Work", "work"}}; err != nil || !reflect.DeepEqual(collisions, want) {
+		t.Fatalf("sherlock.GroupNameCollisions: want: %v, have: %v (%v)", want, collisions, err)
+	}
+}
+
+func TestNameRules(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"github":            true,
+		"work-infra":        true,
+		"Café":              true,
+		"mail@forum":        false,
+		"../etc":            false,
+		"a\\b":              false,
+		"..":                false,
+		"tab\tname":         false,
+		"bell\x07":          false,
+		"non\u00a0breaking": false,
+	} {
+		if validName(name) != valid {
+			t.Fatalf("validName(%q): want: %v, have: %v", name, valid, !valid)
+		}
+	}
+
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "..", "group_key", true); !errors.Is(err, ErrInvalidGroupName) {
+		t.Fatalf("sherlock.SetupGroup(..): want: %v, have: %v", ErrInvalidGroupName, err)
+	}
+	if err := sh.NamePattern("(unclosed"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("sherlock.NamePattern: want: %v, have: %v", ErrInvalidInput, err)
+	}
+	if err := sh.NamePattern(`^[a-z0-9-]+$`); err != nil {
+		t.Fatalf("sherlock.NamePattern: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "Work", "group_key", true); !errors.Is(err, ErrNamePattern) {
+		t.Fatalf("sherlock.SetupGroup(Work): want: %v, have: %v", ErrNamePattern, err)
+	}
+	account, _ := NewAccount("default@GitHub", "Sup3r$ecret-pass", "", true)
+	if err := sh.UpdateState(ctx, "default@GitHub", "default_group_key", OptAddAccount(account)); !errors.Is(err, ErrNamePattern) {
+		t.Fatalf("sherlock.UpdateState(add GitHub): want: %v, have: %v", ErrNamePattern, err)
+	}
+	account, _ = NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState(add github): want: nil, have: %v", err)
+	}
+	for name, want := range map[string]error{"git/hub": ErrInvalidAccountName, "GitHub": ErrNamePattern} {
+		if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccName(name)); !errors.Is(err, want) {
+			t.Fatalf("sherlock.UpdateState(rename %s): want: %v, have: %v", name, want, err)
+		}
+	}
+	imported := []*Account{{Name: "mail@forum", Password: "x", CreatedOn: account.CreatedOn}}
+	if _, err := sh.ImportAccounts(ctx, "default", "default_group_key", imported, nil); !errors.Is(err, ErrInvalidAccountName) {
+		t.Fatalf("sherlock.ImportAccounts(mail@forum): want: %v, have: %v", ErrInvalidAccountName, err)
+	}
+}
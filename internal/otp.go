@@ -0,0 +1,361 @@
+package internal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OTPTypeTOTP = "totp"
+	OTPTypeHOTP = "hotp"
+
+	OTPAlgorithmSHA1   = "SHA1"
+	OTPAlgorithmSHA256 = "SHA256"
+	OTPAlgorithmSHA512 = "SHA512"
+
+	// OTPEncoderSteam encodes one-time passwords as 5 characters like Steam Guard does
+	OTPEncoderSteam = "steam"
+
+	defaultOTPDigits = 6
+	defaultOTPPeriod = 30
+	steamOTPDigits   = 5
+	steamAlphabet    = "23456789BCDFGHJKMNPQRTVWXY"
+
+	otpURIScheme = "otpauth"
+)
+
+var (
+	ErrInvalidOTPType      = fmt.Errorf("otp type must be either totp or hotp")
+	ErrInvalidOTPSecret    = fmt.Errorf("otp secret must be base32 encoded")
+	ErrInvalidOTPAlgorithm = fmt.Errorf("otp algorithm must be SHA1, SHA256 or SHA512")
+	ErrInvalidOTPDigits    = fmt.Errorf("otp digits must be between 6 and 8")
+	ErrInvalidOTPPeriod    = fmt.Errorf("otp period must be a positive number of seconds")
+	ErrInvalidOTPURI       = fmt.Errorf("invalid otpauth:// uri")
+	ErrNoOTP               = fmt.Errorf("account has no otp secret (use sherlock update otp)")
+)
+
+// OTP holds the shared secret and parameters to generate one-time passwords
+// either time-based (TOTP, RFC 6238) or counter-based (HOTP, RFC 4226)
+type OTP struct {
+	Type   string `json:"type"`
+	Secret string `json:"secret"`
+	Digits int    `json:"digits"`
+	// Period in seconds a TOTP is valid
+	Period int `json:"period,omitempty"`
+	// Counter of the next HOTP to generate
+	Counter uint64 `json:"counter,omitempty"`
+	// Algorithm of the HMAC. Empty for SHA1 which was the only
+	// algorithm supported before
+	Algorithm string `json:"algorithm,omitempty"`
+	// Encoder of the password. Empty for decimal digits
+	Encoder string `json:"encoder,omitempty"`
+}
+
+// OTPOption sets a non-default parameter of an OTP
+type OTPOption func(*OTP)
+
+// OTPCounter sets the initial counter of an HOTP
+func OTPCounter(counter uint64) OTPOption {
+	return func(o *OTP) {
+		o.Counter = counter
+	}
+}
+
+// OTPAlgorithm sets the HMAC algorithm (SHA1, SHA256 or SHA512)
+func OTPAlgorithm(algorithm string) OTPOption {
+	return func(o *OTP) {
+		o.Algorithm = strings.ToUpper(algorithm)
+	}
+}
+
+// OTPDigits sets the number of digits of a password
+func OTPDigits(digits int) OTPOption {
+	return func(o *OTP) {
+		o.Digits = digits
+	}
+}
+
+// OTPPeriod sets the seconds a TOTP is valid. HOTP has no period
+func OTPPeriod(period int) OTPOption {
+	return func(o *OTP) {
+		if o.Type == OTPTypeTOTP {
+			o.Period = period
+		}
+	}
+}
+
+// OTPSteam encodes passwords like Steam Guard
+func OTPSteam() OTPOption {
+	return func(o *OTP) {
+		o.Encoder = OTPEncoderSteam
+		o.Digits = steamOTPDigits
+	}
+}
+
+// NewOTP creates an OTP for the base32 encoded secret. Spaces and padding
+// commonly used to display secrets are ignored
+func NewOTP(kind, secret string, opts ...OTPOption) (*OTP, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	otp := OTP{
+		Type:   kind,
+		Secret: secret,
+		Digits: defaultOTPDigits,
+	}
+	if kind == OTPTypeTOTP {
+		otp.Period = defaultOTPPeriod
+	}
+	for _, opt := range opts {
+		opt(&otp)
+	}
+	if otp.Algorithm == OTPAlgorithmSHA1 {
+		otp.Algorithm = ""
+	}
+	if err := otp.valid(); err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// ParseOTPURI creates an OTP from an otpauth:// uri as used by QR codes
+// to enroll authenticator apps =>
+// otpauth://totp/Issuer:account?secret=...&algorithm=SHA256&digits=8&period=60
+func ParseOTPURI(uri string) (*OTP, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != otpURIScheme {
+		return nil, ErrInvalidOTPURI
+	}
+	query := u.Query()
+	var opts []OTPOption
+	if v := query.Get("algorithm"); v != "" {
+		opts = append(opts, OTPAlgorithm(v))
+	}
+	if v := query.Get("digits"); v != "" {
+		digits, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrInvalidOTPDigits
+		}
+		opts = append(opts, OTPDigits(digits))
+	}
+	if v := query.Get("period"); v != "" {
+		period, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrInvalidOTPPeriod
+		}
+		opts = append(opts, OTPPeriod(period))
+	}
+	if v := query.Get("counter"); v != "" {
+		counter, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidOTPURI
+		}
+		opts = append(opts, OTPCounter(counter))
+	}
+	if strings.EqualFold(query.Get("encoder"), OTPEncoderSteam) {
+		opts = append(opts, OTPSteam())
+	}
+	return NewOTP(strings.ToLower(u.Host), query.Get("secret"), opts...)
+}
+
+// URI builds the otpauth:// uri of the OTP used to enroll authenticator apps.
+// The label is shown as issuer:account by most apps
+func (o OTP) URI(issuer, account string) string {
+	query := url.Values{}
+	query.Set("secret", o.Secret)
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	if o.Algorithm != "" {
+		query.Set("algorithm", o.Algorithm)
+	}
+	query.Set("digits", strconv.Itoa(o.Digits))
+	if o.Type == OTPTypeTOTP {
+		query.Set("period", strconv.Itoa(o.Period))
+	} else {
+		query.Set("counter", strconv.FormatUint(o.Counter, 10))
+	}
+	if o.Encoder != "" {
+		query.Set("encoder", o.Encoder)
+	}
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+	u := url.URL{
+		Scheme:   otpURIScheme,
+		Host:     o.Type,
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+func (o OTP) valid() error {
+	if o.Type != OTPTypeTOTP && o.Type != OTPTypeHOTP {
+		return ErrInvalidOTPType
+	}
+	if _, err := o.key(); err != nil || o.Secret == "" {
+		return ErrInvalidOTPSecret
+	}
+	if _, err := o.hash(); err != nil {
+		return err
+	}
+	if o.Encoder != OTPEncoderSteam && (o.Digits < 6 || o.Digits > 8) {
+		return ErrInvalidOTPDigits
+	}
+	if o.Type == OTPTypeTOTP && o.Period <= 0 {
+		return ErrInvalidOTPPeriod
+	}
+	return nil
+}
+
+// TOTP generates the time-based one-time password for the point in time
+// and returns how long the password is valid
+func (o OTP) TOTP(t time.Time) (string, time.Duration, error) {
+	if o.Type != OTPTypeTOTP {
+		return "", 0, ErrInvalidOTPType
+	}
+	period := int64(o.Period)
+	counter := uint64(t.Unix() / period)
+	code, err := o.generate(counter)
+	if err != nil {
+		return "", 0, err
+	}
+	valid := time.Duration(period-t.Unix()%period) * time.Second
+	return code, valid, nil
+}
+
+// HOTP generates the counter-based one-time password for the current
+// counter and increments the counter
+func (o *OTP) HOTP() (string, error) {
+	if o.Type != OTPTypeHOTP {
+		return "", ErrInvalidOTPType
+	}
+	code, err := o.generate(o.Counter)
+	if err != nil {
+		return "", err
+	}
+	o.Counter++
+	return code, nil
+}
+
+// generate computes the one-time password for a counter value as described
+// by RFC 4226 (HMAC, dynamic truncation, modulo 10^digits)
+func (o OTP) generate(counter uint64) (string, error) {
+	key, err := o.key()
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	h, err := o.hash()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(h, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	if o.Encoder == OTPEncoderSteam {
+		code := make([]byte, o.Digits)
+		for i := range code {
+			code[i] = steamAlphabet[value%uint32(len(steamAlphabet))]
+			value /= uint32(len(steamAlphabet))
+		}
+		return string(code), nil
+	}
+	mod := uint32(1)
+	for i := 0; i < o.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", o.Digits, value%mod), nil
+}
+
+func (o OTP) hash() (func() hash.Hash, error) {
+	switch o.Algorithm {
+	case "", OTPAlgorithmSHA1:
+		return sha1.New, nil
+	case OTPAlgorithmSHA256:
+		return sha256.New, nil
+	case OTPAlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, ErrInvalidOTPAlgorithm
+	}
+}
+
+func (o OTP) key() ([]byte, error) {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(o.Secret)
+}
+
+// AccountField returns the value of the field of the account queried by the
+// query like Account.Field. The totp field of an HOTP account is generated
+// with GenerateOTP so the counter is advanced in the vault under the lock
+// of the group
+func (sh Sherlock) AccountField(ctx context.Context, query, groupKey string, account Account, name string) (string, error) {
+	if name != FieldTOTP || account.OTP == nil || account.OTP.Type != OTPTypeHOTP {
+		return account.Field(name)
+	}
+	code, _, err := sh.GenerateOTP(ctx, query, groupKey)
+	return code, err
+}
+
+// GenerateOTP generates the one-time password of an account. For HOTP the
+// incremented counter is written to the vault before the password is returned.
+// The group is locked against other sherlock processes from reading the counter
+// until it is written, so processes sharing the vaults never hand out the same
+// counter. Devices syncing the vaults can still do so
+func (sh Sherlock) GenerateOTP(ctx context.Context, query, groupKey string) (string, time.Duration, error) {
+	gid, name, err := SplitQuery(query)
+	if err != nil {
+		return "", 0, err
+	}
+	defer sh.lockGroup(gid)()
+	unlock, err := sh.fileSystem.LockGroup(ctx, gid)
+	if err != nil {
+		return "", 0, err
+	}
+	defer unlock()
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return "", 0, err
+	}
+	account, err := group.lookup(name)
+	if err != nil {
+		return "", 0, group.accountNotFound(err, name)
+	}
+	if account.OTP == nil {
+		return "", 0, ErrNoOTP
+	}
+	if account.OTP.Type == OTPTypeTOTP {
+		return account.OTP.TOTP(time.Now())
+	}
+	code, err := account.OTP.HOTP()
+	if err != nil {
+		return "", 0, err
+	}
+	events := sh.changeEvents(nil, group, name)
+	if err := sh.check(ctx, events); err != nil {
+		return "", 0, err
+	}
+	if err := sh.WriteGroup(ctx, gid, groupKey, group); err != nil {
+		return "", 0, err
+	}
+	sh.emit(ctx, events)
+	return code, 0, nil
+}
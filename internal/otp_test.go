@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/spf13/afero"
+)
+
+// rfcSecret is the base32 encoded secret "12345678901234567890" used by
+// the test vectors of RFC 4226 and RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTP(t *testing.T) {
+	otp, err := NewOTP(OTPTypeHOTP, rfcSecret)
+	if err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	// RFC 4226 Appendix D
+	for i, expect := range []string{"755224", "287082", "359152", "969429", "338314"} {
+		code, err := otp.HOTP()
+		if err != nil {
+			t.Fatalf("OTP.HOTP: want: nil, have: %v", err)
+		}
+		if code != expect {
+			t.Fatalf("OTP.HOTP(%d): want: %s, have: %s", i, expect, code)
+		}
+	}
+	if otp.Counter != 5 {
+		t.Fatalf("OTP.HOTP: want: counter==5, have: counter==%d", otp.Counter)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	otp, err := NewOTP(OTPTypeTOTP, rfcSecret)
+	if err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	// RFC 6238 Appendix B (last 6 digits)
+	tt := []struct {
+		unix   int64
+		expect string
+		valid  time.Duration
+	}{
+		{unix: 59, expect: "287082", valid: 1 * time.Second},
+		{unix: 1111111109, expect: "081804", valid: 1 * time.Second},
+		{unix: 1234567890, expect: "005924", valid: 30 * time.Second},
+	}
+	for _, tc := range tt {
+		code, valid, err := otp.TOTP(time.Unix(tc.unix, 0))
+		if err != nil || code != tc.expect || valid != tc.valid {
+			t.Fatalf("OTP.TOTP(%d): want: %s %v <nil>, have: %s %v %v", tc.unix, tc.expect, tc.valid, code, valid, err)
+		}
+	}
+}
+
+func TestNewOTP(t *testing.T) {
+	tt := []struct {
+		kind   string
+		secret string
+		expect error
+	}{
+		{kind: OTPTypeTOTP, secret: "gezd gnbv gy3t qojq", expect: nil},
+		{kind: OTPTypeTOTP, secret: "GEZDGNBVGY3TQOJQ====", expect: nil},
+		{kind: "motp", secret: rfcSecret, expect: ErrInvalidOTPType},
+		{kind: OTPTypeHOTP, secret: "not-base32!", expect: ErrInvalidOTPSecret},
+		{kind: OTPTypeHOTP, secret: "", expect: ErrInvalidOTPSecret},
+	}
+	for _, tc := range tt {
+		if _, err := NewOTP(tc.kind, tc.secret); err != tc.expect {
+			t.Fatalf("internal.NewOTP(%s, %s): want: %v, have: %v", tc.kind, tc.secret, tc.expect, err)
+		}
+	}
+}
+
+func TestHOTPHasNoPeriod(t *testing.T) {
+	otp, err := NewOTP(OTPTypeHOTP, rfcSecret, OTPPeriod(30))
+	if err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	if otp.Period != 0 {
+		t.Fatalf("internal.NewOTP(hotp): want: period==0, have: period==%d", otp.Period)
+	}
+}
+
+func TestGenerateOTPConcurrentProcesses(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "sherlock-otp")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: want: nil, have: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// every sherlock stands for a process: they share the vaults on disk but
+	// not the locks held in memory
+	base := afero.NewBasePathFs(afero.NewOsFs(), dir)
+	processes := []*Sherlock{NewSherlock(fs.New(base)), NewSherlock(fs.New(base))}
+	if err := processes[0].Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@bank", "insecure", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if account.OTP, err = NewOTP(OTPTypeHOTP, rfcSecret); err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	if err := processes[0].UpdateState(ctx, "default@bank", "default_group_key", OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	const generated = 4
+	codes := make(chan string, generated)
+	errs := make(chan error, generated)
+	for i := 0; i < generated; i++ {
+		go func(sh *Sherlock) {
+			code, _, err := sh.GenerateOTP(ctx, "default@bank", "default_group_key")
+			codes <- code
+			errs <- err
+		}(processes[i%len(processes)])
+	}
+	// RFC 4226 Appendix D: every counter is handed out exactly once
+	want := map[string]bool{"755224": true, "287082": true, "359152": true, "969429": true}
+	for i := 0; i < generated; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("sherlock.GenerateOTP: want: nil, have: %v", err)
+		}
+		code := <-codes
+		if !want[code] {
+			t.Fatalf("sherlock.GenerateOTP: want: one of the first %d codes once, have: %s", generated, code)
+		}
+		delete(want, code)
+	}
+	stored, err := processes[1].GetAccount(ctx, "default@bank", "default_group_key")
+	if err != nil || stored.OTP.Counter != generated {
+		t.Fatalf("sherlock.GetAccount: want: counter==%d, have: %v %v", generated, stored, err)
+	}
+}
+
+func TestGenerateOTPPersistsCounter(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@bank", "insecure", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@bank", "default_group_key", OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	if _, _, err := sh.GenerateOTP(ctx, "default@bank", "default_group_key"); err != ErrNoOTP {
+		t.Fatalf("sherlock.GenerateOTP: want: %v, have: %v", ErrNoOTP, err)
+	}
+	otp, err := NewOTP(OTPTypeHOTP, rfcSecret)
+	if err != nil {
+		t.Fatalf("internal.NewOTP: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@bank", "default_group_key", OptAccOTP(otp)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	for _, expect := range []string{"755224", "287082"} {
+		code, _, err := sh.GenerateOTP(ctx, "default@bank", "default_group_key")
+		if err != nil || code != expect {
+			t.Fatalf("sherlock.GenerateOTP: want: %s <nil>, have: %s %v", expect, code, err)
+		}
+	}
+	// copying the totp field of an HOTP account advances the counter as well
+	stored, err := sh.GetAccount(ctx, "default@bank", "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	for _, expect := range []string{"359152", "969429"} {
+		code, err := sh.AccountField(ctx, "default@bank", "default_group_key", *stored, FieldTOTP)
+		if err != nil || code != expect {
+			t.Fatalf("sherlock.AccountField(totp): want: %s <nil>, have: %s %v", expect, code, err)
+		}
+	}
+}
+
+func TestParseOTPURI(t *testing.T) {
+	tt := []struct {
+		uri    string
+		unix   int64
+		expect string
+		err    error
+	}{
+		// RFC 6238 Appendix B
+		{
+			uri:    "otpauth://totp/ACME:sherlock?secret=" + rfcSecret + "&digits=8",
+			unix:   59,
+			expect: "94287082",
+		},
+		{
+			uri:    "otpauth://totp/ACME:sherlock?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA&algorithm=SHA256&digits=8",
+			unix:   59,
+			expect: "46119246",
+		},
+		{
+			uri:    "otpauth://totp/ACME:sherlock?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA&algorithm=SHA512&digits=8",
+			unix:   59,
+			expect: "90693936",
+		},
+		{
+			uri:    "otpauth://totp/ACME:sherlock?secret=" + rfcSecret + "&digits=8&period=60",
+			unix:   119,
+			expect: "94287082",
+		},
+		{
+			uri: "otpauth://totp/ACME:sherlock?secret=" + rfcSecret + "&algorithm=MD5",
+			err: ErrInvalidOTPAlgorithm,
+		},
+		{
+			uri: "otpauth://totp/ACME:sherlock?secret=" + rfcSecret + "&digits=10",
+			err: ErrInvalidOTPDigits,
+		},
+		{
+			uri: "https://example.com/?secret=" + rfcSecret,
+			err: ErrInvalidOTPURI,
+		},
+	}
+	for _, tc := range tt {
+		otp, err := ParseOTPURI(tc.uri)
+		if err != tc.err {
+			t.Fatalf("internal.ParseOTPURI(%s): want: %v, have: %v", tc.uri, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		code, _, err := otp.TOTP(time.Unix(tc.unix, 0))
+		if err != nil || code != tc.expect {
+			t.Fatalf("OTP.TOTP(%s): want: %s <nil>, have: %s %v", tc.uri, tc.expect, code, err)
+		}
+	}
+}
+
+func TestSteamOTP(t *testing.T) {
+	otp, err := ParseOTPURI("otpauth://totp/Steam:sherlock?secret=" + rfcSecret + "&issuer=Steam&encoder=steam")
+	if err != nil {
+		t.Fatalf("internal.ParseOTPURI: want: nil, have: %v", err)
+	}
+	code, _, err := otp.TOTP(time.Unix(59, 0))
+	if err != nil {
+		t.Fatalf("OTP.TOTP: want: nil, have: %v", err)
+	}
+	if len(code) != steamOTPDigits || strings.Trim(code, steamAlphabet) != "" {
+		t.Fatalf("OTP.TOTP: want: 5 characters of %s, have: %s", steamAlphabet, code)
+	}
+}
+
+func TestOTPURIRoundTrip(t *testing.T) {
+	tt := []*OTP{
+		{Type: OTPTypeTOTP, Secret: rfcSecret, Digits: 8, Period: 60, Algorithm: OTPAlgorithmSHA512},
+		{Type: OTPTypeHOTP, Secret: rfcSecret, Digits: 6, Counter: 42},
+		{Type: OTPTypeTOTP, Secret: rfcSecret, Digits: 5, Period: 30, Encoder: OTPEncoderSteam},
+	}
+	for _, tc := range tt {
+		uri := tc.URI("bakerstreet", "sherlock holmes")
+		otp, err := ParseOTPURI(uri)
+		if err != nil {
+			t.Fatalf("internal.ParseOTPURI(%s): want: nil, have: %v", uri, err)
+		}
+		if *otp != *tc {
+			t.Fatalf("internal.ParseOTPURI(%s): want: %+v, have: %+v", uri, *tc, *otp)
+		}
+	}
+}
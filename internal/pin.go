@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// minPINDigits is the shortest PIN generated. Shorter PINs are guessed within
+	// the retry limits of most devices
+	minPINDigits = 4
+	maxPINDigits = 32
+)
+
+var (
+	ErrInvalidPINLength = fmt.Errorf("invalid number of PIN digits")
+)
+
+// GeneratePIN generates a numeric PIN of the given digits. Every digit is
+// drawn uniformly from 0-9; trivial PINs (see trivialPIN) are rejected and
+// drawn again so the PIN is uniform among all non-trivial PINs
+func GeneratePIN(digits int) (string, error) {
+	if digits < minPINDigits || digits > maxPINDigits {
+		return "", fmt.Errorf("%w: must be between %d and %d", ErrInvalidPINLength, minPINDigits, maxPINDigits)
+	}
+	for i := 0; i < maxGenerateAttempts; i++ {
+		var b strings.Builder
+		for j := 0; j < digits; j++ {
+			d, err := randomChar("0123456789")
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(d)
+		}
+		if pin := b.String(); !trivialPIN(pin) {
+			return pin, nil
+		}
+	}
+	return "", ErrGeneratePassword
+}
+
+// trivialPIN reports whether the PIN is among the first ones tried: ascending
+// or descending sequences like 123456 or 9876 and repeated patterns like
+// 0000, 121212 or 123123
+func trivialPIN(pin string) bool {
+	ascending, descending := true, true
+	for i := 1; i < len(pin); i++ {
+		step := (int(pin[i]) - int(pin[i-1]) + 10) % 10
+		ascending = ascending && step == 1
+		descending = descending && step == 9
+	}
+	if ascending || descending {
+		return true
+	}
+	for period := 1; period <= len(pin)/2; period++ {
+		if len(pin)%period == 0 && strings.Repeat(pin[:period], len(pin)/period) == pin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGeneratePIN(t *testing.T) {
+	for _, digits := range []int{4, 6, 8} {
+		counts := make(map[rune]int)
+		for i := 0; i < 200; i++ {
+			pin, err := GeneratePIN(digits)
+			if err != nil {
+				t.Fatalf("GeneratePIN(%d): want: nil, have: %v", digits, err)
+			}
+			if len(pin) != digits || trivialPIN(pin) {
+				t.Fatalf("GeneratePIN(%d): want: non-trivial %d digits, have: %q", digits, digits, pin)
+			}
+			for _, r := range pin {
+				if r < '0' || r > '9' {
+					t.Fatalf("GeneratePIN(%d): want: only digits, have: %q", digits, pin)
+				}
+				counts[r]++
+			}
+		}
+		if len(counts) != 10 {
+			t.Fatalf("GeneratePIN(%d): want: all digits used, have: %v", digits, counts)
+		}
+	}
+	for _, digits := range []int{0, 3, 33} {
+		if _, err := GeneratePIN(digits); !errors.Is(err, ErrInvalidPINLength) {
+			t.Fatalf("GeneratePIN(%d): want: %v, have: %v", digits, ErrInvalidPINLength, err)
+		}
+	}
+}
+
+func TestTrivialPIN(t *testing.T) {
+	tt := []struct {
+		pin     string
+		trivial bool
+	}{
+		{pin: "123456", trivial: true},
+		{pin: "654321", trivial: true},
+		{pin: "890123", trivial: true},
+		{pin: "0000", trivial: true},
+		{pin: "121212", trivial: true},
+		{pin: "123123", trivial: true},
+		{pin: "12341234", trivial: true},
+		{pin: "482915", trivial: false},
+		{pin: "1213", trivial: false},
+		{pin: "12345670", trivial: false},
+	}
+	for _, tc := range tt {
+		if have := trivialPIN(tc.pin); have != tc.trivial {
+			t.Fatalf("trivialPIN(%s): want: %v, have: %v", tc.pin, tc.trivial, have)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/KonstantinGasser/sherlock/security"
+	"github.com/m1/go-generate-password/generator"
+)
+
+var (
+	ErrNoSuchPreset  = fmt.Errorf("generator preset not found (use sherlock preset list)")
+	ErrInvalidPreset = fmt.Errorf("invalid generator preset")
+	ErrWeakPreset    = fmt.Errorf("generator preset is too weak (use --insecure to ignore this message)")
+)
+
+// Preset are the rules of a site passwords are generated for, e.g. a bank
+// accepting at most 12 characters and no symbols
+type Preset struct {
+	// Length of the generated passwords
+	Length int `json:"length"`
+	// NoSymbols leaves out symbols for sites not accepting any
+	NoSymbols bool `json:"no_symbols,omitempty"`
+	// Symbols replaces the default symbols with the ones the site accepts
+	Symbols string `json:"symbols,omitempty"`
+	// Pronounceable generates syllable-based passwords which are easier to type
+	// on TVs, consoles or BIOS screens, see pronounceable
+	Pronounceable bool `json:"pronounceable,omitempty"`
+}
+
+// charset returns the characters passwords of the preset are made of. Similar
+// characters like l and 1 are left out
+func (p Preset) charset() string {
+	letters := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(generator.DefaultLetterAmbiguousSet, r) {
+			return -1
+		}
+		return r
+	}, generator.DefaultLetterSet)
+	numbers := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(generator.DefaultNumberAmbiguousSet, r) {
+			return -1
+		}
+		return r
+	}, generator.DefaultNumberSet)
+	return letters + strings.ToUpper(letters) + numbers + p.symbols()
+}
+
+// symbols returns the symbols passwords of the preset may contain
+func (p Preset) symbols() string {
+	switch {
+	case p.NoSymbols:
+		return ""
+	case p.Symbols != "":
+		return p.Symbols
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(generator.DefaultSymbolAmbiguousSet, r) {
+			return -1
+		}
+		return r
+	}, generator.DefaultSymbolSet)
+}
+
+// Entropy returns the entropy in bits of a password generated with the preset
+func (p Preset) Entropy() float64 {
+	if p.Pronounceable {
+		return p.pronounceableEntropy()
+	}
+	return float64(p.Length) * math.Log2(float64(len(p.charset())))
+}
+
+func (p Preset) valid() error {
+	if p.Length < minGeneratedLength {
+		return fmt.Errorf("%w: length must be at least %d", ErrInvalidPreset, minGeneratedLength)
+	}
+	if p.Pronounceable && p.Length < p.minPronounceable() {
+		return fmt.Errorf("%w: pronounceable passwords must be at least %d characters", ErrInvalidPreset, p.minPronounceable())
+	}
+	if p.NoSymbols && p.Symbols != "" {
+		return fmt.Errorf("%w: symbols are set but not used", ErrInvalidPreset)
+	}
+	seen := make(map[rune]bool)
+	for _, r := range p.Symbols {
+		if r > unicode.MaxASCII || !unicode.IsPunct(r) && !unicode.IsSymbol(r) || seen[r] {
+			return fmt.Errorf("%w: symbols must be distinct ASCII symbols, have %q", ErrInvalidPreset, p.Symbols)
+		}
+		seen[r] = true
+	}
+	return nil
+}
+
+// conforms checks that the password has at least one upper case, lower case,
+// number and, unless the preset leaves them out, symbol character
+func (p Preset) conforms(password string) bool {
+	if !p.NoSymbols {
+		return conforms(password)
+	}
+	var upper, lower, number bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsNumber(r):
+			number = true
+		}
+	}
+	return upper && lower && number
+}
+
+// GeneratePassword generates a random password following the preset with at
+// least one character of every character class the preset uses
+func GeneratePassword(p Preset) (string, error) {
+	if p.Length < minGeneratedLength {
+		return "", ErrInvalidPasswordLength
+	}
+	if err := p.valid(); err != nil {
+		return "", err
+	}
+	if p.Pronounceable {
+		return p.pronounceable()
+	}
+	g, err := generator.New(&generator.Config{Length: p.Length, CharacterSet: p.charset()})
+	if err != nil {
+		return "", err
+	}
+	// the generator does not guarantee every character class to be present
+	// so passwords are generated until one conforms to the policy
+	for i := 0; i < maxGenerateAttempts; i++ {
+		pwd, err := g.Generate()
+		if err != nil {
+			return "", err
+		}
+		if p.conforms(*pwd) {
+			return *pwd, nil
+		}
+	}
+	return "", ErrGeneratePassword
+}
+
+// Preset returns the preset with the name
+func (c Config) Preset(name string) (Preset, error) {
+	p, ok := c.Presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("%w: %s", ErrNoSuchPreset, name)
+	}
+	return p, nil
+}
+
+// SetPreset stores the preset under the name. Presets generating passwords
+// with less entropy than the password policy requires are refused unless
+// insecure is set
+func (sh Sherlock) SetPreset(ctx context.Context, name string, p Preset, insecure bool) error {
+	if name == "" || !validName(name) {
+		return fmt.Errorf("%w: name %q must be a single word", ErrInvalidPreset, name)
+	}
+	if err := p.valid(); err != nil {
+		return err
+	}
+	if !insecure && p.Entropy() < security.MinEntropy {
+		return fmt.Errorf("%w: %.0f bits, at least %d required", ErrWeakPreset, p.Entropy(), security.MinEntropy)
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Presets == nil {
+		config.Presets = make(map[string]Preset)
+	}
+	config.Presets[name] = p
+	return sh.SaveConfig(ctx, config)
+}
+
+// RemovePreset removes the preset. Accounts using it fall back to the
+// default generator
+func (sh Sherlock) RemovePreset(ctx context.Context, name string) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := config.Preset(name); err != nil {
+		return err
+	}
+	delete(config.Presets, name)
+	return sh.SaveConfig(ctx, config)
+}
+
+// AccountPreset returns the preset passwords of the account are generated
+// with. ok is false if the account has none or it has been removed
+func (c Config) AccountPreset(a *Account) (Preset, bool) {
+	if a.Preset == "" {
+		return Preset{}, false
+	}
+	p, err := c.Preset(a.Preset)
+	return p, err == nil
+}
+
+// WithPreset sets the generator preset of a new account
+func WithPreset(name string) FieldUpdate {
+	return updateFieldPreset(name)
+}
+
+func updateFieldPreset(name string) FieldUpdate {
+	return func(a *Account) error {
+		a.Preset = strings.TrimSpace(name)
+		return nil
+	}
+}
+
+// OptAccPreset returns a StateOption to change the generator preset of an
+// account. An empty name removes it
+func OptAccPreset(name string) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldPreset(name))
+	}
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	tt := []struct {
+		name    string
+		preset  Preset
+		symbols string
+	}{
+		{name: "default", preset: Preset{Length: 16}},
+		{name: "no symbols", preset: Preset{Length: 12, NoSymbols: true}},
+		{name: "own symbols", preset: Preset{Length: 12, Symbols: "!_"}, symbols: "!_"},
+	}
+	for _, tc := range tt {
+		for i := 0; i < 50; i++ {
+			password, err := GeneratePassword(tc.preset)
+			if err != nil {
+				t.Fatalf("[%s] GeneratePassword: want: nil, have: %v", tc.name, err)
+			}
+			if len(password) != tc.preset.Length {
+				t.Fatalf("[%s] GeneratePassword: want: length %d, have: %q", tc.name, tc.preset.Length, password)
+			}
+			if !tc.preset.conforms(password) {
+				t.Fatalf("[%s] GeneratePassword: want: conforming password, have: %q", tc.name, password)
+			}
+			for _, r := range password {
+				letterOrNumber := strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", r)
+				if tc.preset.NoSymbols && !letterOrNumber {
+					t.Fatalf("[%s] GeneratePassword: want: no symbols, have: %q", tc.name, password)
+				}
+				if tc.symbols != "" && !letterOrNumber && !strings.ContainsRune(tc.symbols, r) {
+					t.Fatalf("[%s] GeneratePassword: want: only symbols %q, have: %q", tc.name, tc.symbols, password)
+				}
+			}
+		}
+	}
+
+	if _, err := GeneratePassword(Preset{Length: 3}); err != ErrInvalidPasswordLength {
+		t.Fatalf("GeneratePassword: want: %v, have: %v", ErrInvalidPasswordLength, err)
+	}
+	if _, err := GeneratePassword(Preset{Length: 12, Symbols: "aa"}); !errors.Is(err, ErrInvalidPreset) {
+		t.Fatalf("GeneratePassword: want: %v, have: %v", ErrInvalidPreset, err)
+	}
+}
+
+func TestPresetEntropy(t *testing.T) {
+	// 52 letters without ijlo in both cases and 8 numbers without 0 and 1
+	if have := (Preset{Length: 10, NoSymbols: true}).charset(); len(have) != 52-8+8 {
+		t.Fatalf("Preset.charset: want: %d characters, have: %q", 52-8+8, have)
+	}
+	short, long := Preset{Length: 12, NoSymbols: true}, Preset{Length: 24}
+	if short.Entropy() >= long.Entropy() {
+		t.Fatalf("Preset.Entropy: want: %v < %v", short.Entropy(), long.Entropy())
+	}
+}
+
+func TestSetPreset(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	bank := Preset{Length: 12, NoSymbols: true}
+	weak := Preset{Length: 8, NoSymbols: true}
+	if err := sh.SetPreset(ctx, "pin-pad", weak, false); !errors.Is(err, ErrWeakPreset) {
+		t.Fatalf("sherlock.SetPreset: want: %v, have: %v", ErrWeakPreset, err)
+	}
+	if err := sh.SetPreset(ctx, "pin-pad", weak, true); err != nil {
+		t.Fatalf("sherlock.SetPreset: want: nil, have: %v", err)
+	}
+	if err := sh.SetPreset(ctx, "legacy-bank", bank, false); err != nil {
+		t.Fatalf("sherlock.SetPreset: want: nil, have: %v", err)
+	}
+	if err := sh.SetPreset(ctx, "two words", bank, true); !errors.Is(err, ErrInvalidPreset) {
+		t.Fatalf("sherlock.SetPreset: want: %v, have: %v", ErrInvalidPreset, err)
+	}
+	config, err := sh.Config(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	if have, err := config.Preset("legacy-bank"); err != nil || have != bank {
+		t.Fatalf("Config.Preset: want: %+v, have: %+v (%v)", bank, have, err)
+	}
+
+	account, err := NewAccount("default@bank", "Sup3r$ecret-pass", "", true, WithPreset("legacy-bank"))
+	if err != nil {
+		t.Fatalf("NewAccount: want: nil, have: %v", err)
+	}
+	if have, ok := config.AccountPreset(account); !ok || have != bank {
+		t.Fatalf("Config.AccountPreset: want: %+v, have: %+v", bank, have)
+	}
+
+	if err := sh.RemovePreset(ctx, "legacy-bank"); err != nil {
+		t.Fatalf("sherlock.RemovePreset: want: nil, have: %v", err)
+	}
+	if err := sh.RemovePreset(ctx, "legacy-bank"); !errors.Is(err, ErrNoSuchPreset) {
+		t.Fatalf("sherlock.RemovePreset: want: %v, have: %v", ErrNoSuchPreset, err)
+	}
+	if config, err = sh.Config(ctx); err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	if _, ok := config.AccountPreset(account); ok {
+		t.Fatalf("Config.AccountPreset: want: no preset after removal")
+	}
+}
+
+func TestPronounceable(t *testing.T) {
+	tt := []struct {
+		name   string
+		preset Preset
+	}{
+		{name: "even", preset: Preset{Length: 16, Pronounceable: true}},
+		{name: "odd", preset: Preset{Length: 15, Pronounceable: true}},
+		{name: "no symbols", preset: Preset{Length: 14, Pronounceable: true, NoSymbols: true}},
+		{name: "shortest", preset: Preset{Length: 5, Pronounceable: true}},
+	}
+	for _, tc := range tt {
+		for i := 0; i < 50; i++ {
+			password, err := GeneratePassword(tc.preset)
+			if err != nil {
+				t.Fatalf("[%s] GeneratePassword: want: nil, have: %v", tc.name, err)
+			}
+			if len(password) != tc.preset.Length || !tc.preset.conforms(password) {
+				t.Fatalf("[%s] GeneratePassword: want: conforming password of length %d, have: %q", tc.name, tc.preset.Length, password)
+			}
+			letters := strings.ToLower(password[:tc.preset.Length-tc.preset.pronounceableTail()])
+			for j, r := range letters {
+				alphabet := consonants
+				if j%2 == 1 {
+					alphabet = vowels
+				}
+				if !strings.ContainsRune(alphabet, r) {
+					t.Fatalf("[%s] GeneratePassword: want: syllables, have: %q", tc.name, password)
+				}
+			}
+		}
+	}
+	if _, err := GeneratePassword(Preset{Length: 4, Pronounceable: true}); !errors.Is(err, ErrInvalidPreset) {
+		t.Fatalf("GeneratePassword: want: %v, have: %v", ErrInvalidPreset, err)
+	}
+
+	// syllables carry less entropy per character than random passwords
+	random, pronounceable := Preset{Length: 16}, Preset{Length: 16, Pronounceable: true}
+	if pronounceable.Entropy() >= random.Entropy() || pronounceable.Entropy() <= 0 {
+		t.Fatalf("Preset.Entropy: want: 0 < %v < %v", pronounceable.Entropy(), random.Entropy())
+	}
+}
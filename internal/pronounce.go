@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"strings"
+)
+
+const (
+	// consonants and vowels syllables are made of. l and j are left out as
+	// they are easily confused with i and 1
+	consonants = "bcdfghkmnprstvwz"
+	vowels     = "aeiou"
+	digits     = "23456789"
+	// pronounceableDigits is the number of digits ending a pronounceable password
+	pronounceableDigits = 2
+)
+
+// pronounceable generates a password of consonant-vowel syllables like
+// "tavoKemisu" followed by two digits and, unless the preset leaves them out,
+// one symbol. The first letter of a random syllable is upper case. Every
+// position is drawn from a fixed alphabet so the entropy can be computed
+// exactly, see pronounceableEntropy
+func (p Preset) pronounceable() (string, error) {
+	letters := p.Length - p.pronounceableTail()
+	syllables := (letters + 1) / 2
+	upper, err := randomIndex(syllables)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i := 0; i < letters; i++ {
+		alphabet := consonants
+		if i%2 == 1 {
+			alphabet = vowels
+		}
+		c, err := randomChar(alphabet)
+		if err != nil {
+			return "", err
+		}
+		if i == upper*2 {
+			c = strings.ToUpper(c)
+		}
+		b.WriteString(c)
+	}
+	for i := 0; i < pronounceableDigits; i++ {
+		c, err := randomChar(digits)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(c)
+	}
+	if symbols := p.symbols(); symbols != "" {
+		c, err := randomChar(symbols)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(c)
+	}
+	return b.String(), nil
+}
+
+// pronounceableEntropy returns the entropy in bits of a pronounceable password
+// generated with the preset
+func (p Preset) pronounceableEntropy() float64 {
+	letters := p.Length - p.pronounceableTail()
+	syllables := (letters + 1) / 2
+	bits := float64(syllables)*math.Log2(float64(len(consonants))) +
+		float64(letters/2)*math.Log2(float64(len(vowels))) +
+		math.Log2(float64(syllables)) +
+		pronounceableDigits*math.Log2(float64(len(digits)))
+	if symbols := p.symbols(); symbols != "" {
+		bits += math.Log2(float64(len(symbols)))
+	}
+	return bits
+}
+
+// pronounceableTail returns the number of digits and symbols ending a
+// pronounceable password
+func (p Preset) pronounceableTail() int {
+	if p.symbols() == "" {
+		return pronounceableDigits
+	}
+	return pronounceableDigits + 1
+}
+
+// minPronounceable is the shortest pronounceable password with at least one
+// syllable so it has upper and lower case letters
+func (p Preset) minPronounceable() int {
+	return p.pronounceableTail() + 2
+}
+
+func randomChar(alphabet string) (string, error) {
+	i, err := randomIndex(len(alphabet))
+	if err != nil {
+		return "", err
+	}
+	return alphabet[i : i+1], nil
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
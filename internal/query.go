@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// keys accounts can be sorted by with Group.Sort
+const (
+	SortByName    = "name"
+	SortByCreated = "created"
+	SortByUpdated = "updated"
+)
+
+var (
+	ErrUnknownSortKey     = fmt.Errorf("unknown sort key (use name, created or updated)")
+	ErrUnknownFilterField = fmt.Errorf("unknown field (use username, url, note, tag, otp or autotype)")
+)
+
+// FilterCreatedAfter matches accounts created at or after t
+func FilterCreatedAfter(t time.Time) func(*Account) bool {
+	return func(a *Account) bool {
+		return !a.CreatedOn.Before(t)
+	}
+}
+
+// FilterCreatedBefore matches accounts created before t
+func FilterCreatedBefore(t time.Time) func(*Account) bool {
+	return func(a *Account) bool {
+		return a.CreatedOn.Before(t)
+	}
+}
+
+// FilterUpdatedAfter matches accounts updated at or after t
+func FilterUpdatedAfter(t time.Time) func(*Account) bool {
+	return func(a *Account) bool {
+		return !a.UpdatedOn.Before(t)
+	}
+}
+
+// FilterUpdatedBefore matches accounts updated before t
+func FilterUpdatedBefore(t time.Time) func(*Account) bool {
+	return func(a *Account) bool {
+		return a.UpdatedOn.Before(t)
+	}
+}
+
+// FilterHas matches accounts with a value for the field. Besides the fields
+// known to Account.Field the tag, otp and autotype of an account can be used
+func FilterHas(field string) (func(*Account) bool, error) {
+	field = strings.ToLower(field)
+	switch field {
+	case FieldUsername, FieldURL, FieldNote:
+		return func(a *Account) bool {
+			_, err := a.Field(field)
+			return err == nil
+		}, nil
+	case "tag":
+		return func(a *Account) bool { return a.Tag != "" }, nil
+	case "otp":
+		return func(a *Account) bool { return a.OTP != nil }, nil
+	case "autotype":
+		return func(a *Account) bool { return a.AutoType != "" }, nil
+	}
+	return nil, ErrUnknownFilterField
+}
+
+// Sort sorts the accounts of the group by the key. Accounts with the
+// same key keep their order
+func (g *Group) Sort(by string, desc bool) error {
+	var less func(a, b *Account) bool
+	switch by {
+	case SortByName:
+		less = func(a, b *Account) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case SortByCreated:
+		less = func(a, b *Account) bool { return a.CreatedOn.Before(b.CreatedOn) }
+	case SortByUpdated:
+		less = func(a, b *Account) bool { return a.UpdatedOn.Before(b.UpdatedOn) }
+	default:
+		return ErrUnknownSortKey
+	}
+	sort.SliceStable(g.Accounts, func(i, j int) bool {
+		if desc {
+			return less(g.Accounts[j], g.Accounts[i])
+		}
+		return less(g.Accounts[i], g.Accounts[j])
+	})
+	return nil
+}
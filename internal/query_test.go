@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	g := Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "jira", URL: "https://jira.example.com", CreatedOn: day(1), UpdatedOn: day(9)},
+			{Name: "vpn", Tag: "infra", CreatedOn: day(5), UpdatedOn: day(5)},
+			{Name: "wiki", URL: "https://wiki.example.com", Tag: "infra", CreatedOn: day(7), UpdatedOn: day(8)},
+		},
+	}
+	hasURL, err := FilterHas("URL")
+	if err != nil {
+		t.Fatalf("internal.FilterHas: want: nil, have: %v", err)
+	}
+	if _, err := FilterHas("password-hint"); err != ErrUnknownFilterField {
+		t.Fatalf("internal.FilterHas: want: %v, have: %v", ErrUnknownFilterField, err)
+	}
+
+	tt := []struct {
+		filter []func(*Account) bool
+		expect []string
+	}{
+		{
+			filter: []func(*Account) bool{FilterCreatedAfter(day(5))},
+			expect: []string{"vpn", "wiki"},
+		},
+		{
+			filter: []func(*Account) bool{FilterCreatedBefore(day(5))},
+			expect: []string{"jira"},
+		},
+		{
+			filter: []func(*Account) bool{FilterUpdatedAfter(day(8)), hasURL},
+			expect: []string{"jira", "wiki"},
+		},
+		{
+			filter: []func(*Account) bool{FilterUpdatedBefore(day(9)), hasURL, FilterByTag("infra")},
+			expect: []string{"wiki"},
+		},
+	}
+	for _, tc := range tt {
+		var names []string
+		for _, row := range g.Table(tc.filter...) {
+			names = append(names, row[1])
+		}
+		if !reflect.DeepEqual(names, tc.expect) {
+			t.Fatalf("Group.Table: want: %v, have: %v", tc.expect, names)
+		}
+	}
+}
+
+func TestGroupSort(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	g := Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "wiki", CreatedOn: day(2), UpdatedOn: day(3)},
+			{Name: "Jira", CreatedOn: day(3), UpdatedOn: day(1)},
+			{Name: "vpn", CreatedOn: day(1), UpdatedOn: day(2)},
+		},
+	}
+	tt := []struct {
+		by     string
+		desc   bool
+		expect []string
+	}{
+		{by: SortByName, expect: []string{"Jira", "vpn", "wiki"}},
+		{by: SortByCreated, expect: []string{"vpn", "wiki", "Jira"}},
+		{by: SortByUpdated, desc: true, expect: []string{"wiki", "vpn", "Jira"}},
+	}
+	for _, tc := range tt {
+		if err := g.Sort(tc.by, tc.desc); err != nil {
+			t.Fatalf("Group.Sort: want: nil, have: %v", err)
+		}
+		var names []string
+		for _, a := range g.Accounts {
+			names = append(names, a.Name)
+		}
+		if !reflect.DeepEqual(names, tc.expect) {
+			t.Fatalf("Group.Sort(%s): want: %v, have: %v", tc.by, tc.expect, names)
+		}
+	}
+	if err := g.Sort("size", false); err != ErrUnknownSortKey {
+		t.Fatalf("Group.Sort: want: %v, have: %v", ErrUnknownSortKey, err)
+	}
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrReadOnlyGroup = fmt.Errorf("group is read-only (use sherlock unlock to allow changes)")
+
+// SetReadOnly marks a group as read-only or removes the mark. While a
+// group is read-only all changes to it as well as its deletion are refused
+func (sh Sherlock) SetReadOnly(ctx context.Context, gid, groupKey string, readOnly bool) error {
+	defer sh.lockGroup(gid)()
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return err
+	}
+	if group.ReadOnly == readOnly {
+		return nil
+	}
+	kdf, err := sh.GroupKDF(ctx, gid)
+	if err != nil {
+		return err
+	}
+	op := OpGroupUnlocked
+	if readOnly {
+		op = OpGroupLocked
+	}
+	events := sh.groupEvents(gid, op)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	group.ReadOnly = readOnly
+	if err := sh.writeGroup(ctx, gid, groupKey, kdf, group); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
+}
+
+// checkWritable returns ErrReadOnlyGroup if the vault header marks the group
+// read-only. Operations replacing the whole vault use it since they do not
+// require the group key to decrypt the group
+func (sh Sherlock) checkWritable(ctx context.Context, gid string) error {
+	info, err := sh.GroupInfo(ctx, gid)
+	if err != nil {
+		return err
+	}
+	if info.ReadOnly {
+		return ErrReadOnlyGroup
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+func TestSetReadOnly(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	if err := sh.SetReadOnly(ctx, "default", "wrong_group_key", true); err != ErrWrongKey {
+		t.Fatalf("sherlock.SetReadOnly: want: %v, have: %v", ErrWrongKey, err)
+	}
+	if err := sh.SetReadOnly(ctx, "default", groupKey, true); err != nil {
+		t.Fatalf("sherlock.SetReadOnly: want: nil, have: %v", err)
+	}
+	vault, err := sh.readVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("sherlock.readVault: want: nil, have: %v", err)
+	}
+
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAccDelete()); err != ErrReadOnlyGroup {
+		t.Fatalf("sherlock.UpdateState: want: %v, have: %v", ErrReadOnlyGroup, err)
+	}
+	if err := sh.MigrateKDF(ctx, "default", groupKey, security.KDFSHA256); err != ErrReadOnlyGroup {
+		t.Fatalf("sherlock.MigrateKDF: want: %v, have: %v", ErrReadOnlyGroup, err)
+	}
+	if err := sh.DeleteGroup(ctx, "default"); err != ErrReadOnlyGroup {
+		t.Fatalf("sherlock.DeleteGroup: want: %v, have: %v", ErrReadOnlyGroup, err)
+	}
+	if err := sh.RestoreGroup(ctx, "default", vault, true); err != ErrReadOnlyGroup {
+		t.Fatalf("sherlock.RestoreGroup: want: %v, have: %v", ErrReadOnlyGroup, err)
+	}
+	if _, err := sh.GetAccount(ctx, "default@github", groupKey); err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+
+	if err := sh.SetReadOnly(ctx, "default", groupKey, false); err != nil {
+		t.Fatalf("sherlock.SetReadOnly: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAccDelete()); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoRecoveryCodes = fmt.Errorf("account has no unused recovery codes (use sherlock recovery set)")
+)
+
+// RecoveryCode is a one-time recovery (backup) code of an account
+type RecoveryCode struct {
+	Code string `json:"code"`
+	// UsedOn is set once the code has been handed out
+	UsedOn *time.Time `json:"used_on,omitempty"`
+}
+
+// ParseRecoveryCodes splits text into recovery codes. Codes are separated
+// by white space or commas, duplicates are dropped
+func ParseRecoveryCodes(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	seen := make(map[string]bool, len(fields))
+	var codes []string
+	for _, f := range fields {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		codes = append(codes, f)
+	}
+	return codes
+}
+
+// RecoveryCodesLeft returns the number of unused recovery codes
+func (a Account) RecoveryCodesLeft() int {
+	var left int
+	for _, c := range a.RecoveryCodes {
+		if c.UsedOn == nil {
+			left++
+		}
+	}
+	return left
+}
+
+func updateFieldRecoveryCodes(codes []string) FieldUpdate {
+	return func(a *Account) error {
+		if len(codes) == 0 {
+			a.RecoveryCodes = nil
+			return nil
+		}
+		a.RecoveryCodes = make([]RecoveryCode, len(codes))
+		for i, c := range codes {
+			a.RecoveryCodes[i] = RecoveryCode{Code: c}
+		}
+		return nil
+	}
+}
+
+// consumeRecoveryCode marks the next unused recovery code as used and
+// stores it in code
+func consumeRecoveryCode(code *string) FieldUpdate {
+	return func(a *Account) error {
+		for i := range a.RecoveryCodes {
+			if a.RecoveryCodes[i].UsedOn != nil {
+				continue
+			}
+			now := time.Now()
+			// copy the codes so the account before the update is not changed
+			codes := append([]RecoveryCode(nil), a.RecoveryCodes...)
+			codes[i].UsedOn = &now
+			a.RecoveryCodes = codes
+			*code = codes[i].Code
+			return nil
+		}
+		return ErrNoRecoveryCodes
+	}
+}
+
+// OptAccRecoveryCodes returns a StateOption replacing the recovery codes of an
+// account. No codes remove all recovery codes
+func OptAccRecoveryCodes(codes []string) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldRecoveryCodes(codes))
+	}
+}
+
+// UseRecoveryCode hands out the next unused recovery code of an account and
+// marks it as used. The vault is written before the code is returned so a
+// code is never handed out twice
+func (sh Sherlock) UseRecoveryCode(ctx context.Context, query, groupKey string) (string, int, error) {
+	var code string
+	var left int
+	err := sh.UpdateState(ctx, query, groupKey, func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		if err := account.update(consumeRecoveryCode(&code)); err != nil {
+			return err
+		}
+		left = account.RecoveryCodesLeft()
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return code, left, nil
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseRecoveryCodes(t *testing.T) {
+	have := ParseRecoveryCodes("1111-2222 3333-4444,\n5555-6666\r\n1111-2222\t7777")
+	want := []string{"1111-2222", "3333-4444", "5555-6666", "7777"}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("internal.ParseRecoveryCodes: want: %v, have: %v", want, have)
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	if _, _, err := sh.UseRecoveryCode(ctx, "default@github", groupKey); err != ErrNoRecoveryCodes {
+		t.Fatalf("sherlock.UseRecoveryCode: want: %v, have: %v", ErrNoRecoveryCodes, err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAccRecoveryCodes([]string{"aaaa", "bbbb"})); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	for _, want := range []struct {
+		code string
+		left int
+	}{{code: "aaaa", left: 1}, {code: "bbbb", left: 0}} {
+		code, left, err := sh.UseRecoveryCode(ctx, "default@github", groupKey)
+		if err != nil || code != want.code || left != want.left {
+			t.Fatalf("sherlock.UseRecoveryCode: want: %s %d nil, have: %s %d %v", want.code, want.left, code, left, err)
+		}
+	}
+	if _, _, err := sh.UseRecoveryCode(ctx, "default@github", groupKey); err != ErrNoRecoveryCodes {
+		t.Fatalf("sherlock.UseRecoveryCode: want: %v, have: %v", ErrNoRecoveryCodes, err)
+	}
+
+	account, err = sh.GetAccount(ctx, "default@github", groupKey)
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	var recorded int
+	for _, e := range account.History() {
+		if e.Field == "recovery" {
+			recorded++
+		}
+	}
+	// setting the codes and using each of them
+	if recorded != 3 {
+		t.Fatalf("Account.History: want: 3 recovery events, have: %d", recorded)
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReferenceScheme prefixes references to account values in files handed to
+// other tools => sherlock://group@account or sherlock://group@account#field
+const ReferenceScheme = "sherlock://"
+
+var (
+	ErrInvalidReference = fmt.Errorf("invalid reference (use sherlock://group@account[#field])")
+)
+
+// Reference points to a field of an account in a group
+type Reference struct {
+	GID     string
+	Account string
+	Field   string
+}
+
+// IsReference reports whether the value is meant as a sherlock reference
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferenceScheme)
+}
+
+// ParseReference parses a sherlock://group@account[#field] reference. Without
+// a field the reference points to the password
+func ParseReference(value string) (Reference, error) {
+	if !IsReference(value) {
+		return Reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, value)
+	}
+	query := strings.TrimPrefix(value, ReferenceScheme)
+	field := FieldPassword
+	if i := strings.Index(query, "#"); i >= 0 {
+		query, field = query[:i], query[i+1:]
+	}
+	gid, name, err := SplitQuery(query)
+	if err != nil || gid == "" || name == "" || field == "" {
+		return Reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, value)
+	}
+	return Reference{GID: gid, Account: name, Field: field}, nil
+}
+
+// Query returns the group@account query of the reference
+func (r Reference) Query() string {
+	return r.GID + querySplitPoint + r.Account
+}
+
+func (r Reference) String() string {
+	if r.Field == FieldPassword {
+		return ReferenceScheme + r.Query()
+	}
+	return ReferenceScheme + r.Query() + "#" + r.Field
+}
+
+// Resolve returns the value the reference points to. The reference must
+// point into the group. A time-locked password is returned once the lock has
+// expired (ErrTimeLocked before); links are followed by ResolveReference
+func (g Group) Resolve(ref Reference) (string, error) {
+	if ref.GID != g.GID {
+		return "", fmt.Errorf("%w: %s is not part of group %q", ErrInvalidReference, ref, g.GID)
+	}
+	account, err := g.lookup(ref.Account)
+	if err != nil {
+		return "", g.accountNotFound(err, ref.Account)
+	}
+	if ref.Field == FieldPassword {
+		if account.Linked() {
+			return "", fmt.Errorf("%w: %s is linked to %s (use Sherlock.ResolveReference)", ErrInvalidAccountLink, ref, account.Link)
+		}
+		opened := *account
+		if err := opened.OpenTimeLock(time.Now()); err != nil {
+			return "", fmt.Errorf("%s: %w", ref, err)
+		}
+		account = &opened
+	}
+	value, err := account.Field(ref.Field)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, ref)
+	}
+	return value, nil
+}
+
+// ResolveReference returns the value the reference points to like
+// Group.Resolve and follows a link of the account with the key of its target
+// group returned by keys
+func (sh Sherlock) ResolveReference(ctx context.Context, g Group, ref Reference, keys KeyFunc) (string, error) {
+	if ref.Field == FieldPassword && ref.GID == g.GID {
+		if account, err := g.lookup(ref.Account); err == nil && account.Linked() {
+			password, err := sh.AccountPassword(ctx, *account, keys)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", ref, err)
+			}
+			return password, nil
+		}
+	}
+	return g.Resolve(ref)
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tt := []struct {
+		value string
+		ref   Reference
+		err   error
+	}{
+		{value: "sherlock://work@db", ref: Reference{GID: "work", Account: "db", Field: FieldPassword}},
+		{value: "sherlock://work@db#username", ref: Reference{GID: "work", Account: "db", Field: FieldUsername}},
+		{value: "work@db", err: ErrInvalidReference},
+		{value: "sherlock://db", err: ErrInvalidReference},
+		{value: "sherlock://work@", err: ErrInvalidReference},
+		{value: "sherlock://work@db#", err: ErrInvalidReference},
+	}
+
+	for _, tc := range tt {
+		ref, err := ParseReference(tc.value)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("internal.ParseReference(%s): want: %v, have: %v", tc.value, tc.err, err)
+		}
+		if ref != tc.ref {
+			t.Fatalf("internal.ParseReference(%s): want: %v, have: %v", tc.value, tc.ref, ref)
+		}
+		if err == nil && ref.String() != tc.value {
+			t.Fatalf("internal.Reference.String: want: %s, have: %s", tc.value, ref)
+		}
+	}
+}
+
+func TestGroupResolve(t *testing.T) {
+	g := Group{GID: "work", Accounts: []*Account{{Name: "db", Password: "221b", Username: "watson"}}}
+	tt := []struct {
+		ref   Reference
+		value string
+		err   error
+	}{
+		{ref: Reference{GID: "work", Account: "db", Field: FieldPassword}, value: "221b"},
+		{ref: Reference{GID: "work", Account: "db", Field: FieldUsername}, value: "watson"},
+		{ref: Reference{GID: "work", Account: "db", Field: FieldURL}, err: ErrEmptyField},
+		{ref: Reference{GID: "work", Account: "dc", Field: FieldPassword}, err: ErrNoSuchAccount},
+		{ref: Reference{GID: "private", Account: "db", Field: FieldPassword}, err: ErrInvalidReference},
+	}
+
+	for _, tc := range tt {
+		value, err := g.Resolve(tc.ref)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("internal.Group.Resolve(%s): want: %v, have: %v", tc.ref, tc.err, err)
+		}
+		if value != tc.value {
+			t.Fatalf("internal.Group.Resolve(%s): want: %q, have: %q", tc.ref, tc.value, value)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// Reminder is an account whose password is older than the accepted age
+type Reminder struct {
+	GID       string
+	Account   string
+	ChangedOn time.Time
+}
+
+// Age returns how long the password has not been changed relative to now
+func (r Reminder) Age(now time.Time) time.Duration {
+	return now.Sub(r.ChangedOn)
+}
+
+// Reminders returns the accounts of the groups with a password not changed
+// within maxAge, oldest password first
+func Reminders(now time.Time, maxAge time.Duration, groups ...*Group) []Reminder {
+	var reminders []Reminder
+	for _, g := range groups {
+		for _, a := range g.Accounts {
+			changed := a.PasswordChangedOn()
+			if now.Sub(changed) <= maxAge {
+				continue
+			}
+			reminders = append(reminders, Reminder{GID: g.GID, Account: a.Name, ChangedOn: changed})
+		}
+	}
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].ChangedOn.Before(reminders[j].ChangedOn)
+	})
+	return reminders
+}
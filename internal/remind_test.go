@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReminders(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) time.Time { return now.Add(-time.Duration(days) * day) }
+	work := &Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "jira", CreatedOn: ago(10)},
+			{Name: "vpn", CreatedOn: ago(400),
+				Events: []Event{{At: ago(400), Kind: EventCreated}, {At: ago(45), Kind: EventChanged, Field: FieldPassword}}},
+			{Name: "wiki", CreatedOn: ago(200)},
+		},
+	}
+	private := &Group{
+		GID: "default",
+		Accounts: []*Account{
+			{Name: "bank", CreatedOn: ago(800)},
+		},
+	}
+
+	expect := []Reminder{
+		{GID: "default", Account: "bank", ChangedOn: ago(800)},
+		{GID: "work", Account: "wiki", ChangedOn: ago(200)},
+	}
+	have := Reminders(now, 90*day, work, private)
+	if !reflect.DeepEqual(have, expect) {
+		t.Fatalf("internal.Reminders: want: %v, have: %v", expect, have)
+	}
+	if age := have[1].Age(now); age != 200*day {
+		t.Fatalf("internal.Reminder.Age: want: %v, have: %v", 200*day, age)
+	}
+	if have := Reminders(now, 1000*day, work, private); len(have) != 0 {
+		t.Fatalf("internal.Reminders: want: none, have: %v", have)
+	}
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrRequiredField = fmt.Errorf("account is missing fields required by the group")
+)
+
+// requirable are the account fields a group can require besides the password
+var requirable = []string{FieldUsername, FieldURL, FieldNote, "tag", "otp"}
+
+// SetRequired sets the fields every account of the group must have when it is
+// added or updated. No fields remove the requirement. Existing accounts are
+// not changed; the ones missing a required field are returned so they can be
+// completed
+func (sh Sherlock) SetRequired(ctx context.Context, gid, groupKey string, fields []string) ([]string, error) {
+	defer sh.lockGroup(gid)()
+	required, err := normalizeRequired(fields)
+	if err != nil {
+		return nil, err
+	}
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return nil, err
+	}
+	group.Required = required
+	if err := sh.WriteGroup(ctx, gid, groupKey, group); err != nil {
+		return nil, err
+	}
+	var incomplete []string
+	for _, a := range group.Accounts {
+		if len(group.missing(a)) > 0 {
+			incomplete = append(incomplete, a.Name)
+		}
+	}
+	sort.Strings(incomplete)
+	return incomplete, nil
+}
+
+// normalizeRequired validates and sorts the fields removing duplicates
+func normalizeRequired(fields []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var required []string
+	for _, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if !contains(requirable, f) {
+			return nil, fmt.Errorf("%w: %q cannot be required (use %s)", ErrInvalidInput, f, strings.Join(requirable, ", "))
+		}
+		if !seen[f] {
+			seen[f] = true
+			required = append(required, f)
+		}
+	}
+	sort.Strings(required)
+	return required, nil
+}
+
+// missing returns the required fields the account has no value for
+func (g Group) missing(a *Account) []string {
+	var fields []string
+	for _, f := range g.Required {
+		if has, err := FilterHas(f); err == nil && !has(a) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// checkRequired returns ErrRequiredField if an account added or changed since
+// before misses a required field. Accounts which have not been touched are not
+// checked so incomplete accounts do not block other changes
+func (g Group) checkRequired(before *Group) error {
+	if len(g.Required) == 0 {
+		return nil
+	}
+	for _, a := range g.Accounts {
+		if before != nil {
+			if old, err := before.lookup(a.Name); err == nil && len(changedFields(old, a)) == 0 {
+				continue
+			}
+		}
+		if fields := g.missing(a); len(fields) > 0 {
+			return fmt.Errorf("%w: %s has no %s", ErrRequiredField, a.Name, strings.Join(fields, ", "))
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	add := func(query string, opts ...FieldUpdate) error {
+		account, err := NewAccount(query, "Sup3r$ecret-pass", "", true, opts...)
+		if err != nil {
+			return err
+		}
+		return sh.UpdateState(ctx, query, "default_group_key", OptAddAccount(account))
+	}
+	if err := add("default@legacy"); err != nil {
+		t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+	}
+
+	if _, err := sh.SetRequired(ctx, "default", "default_group_key", []string{"password"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("sherlock.SetRequired: want: %v, have: %v", ErrInvalidInput, err)
+	}
+	incomplete, err := sh.SetRequired(ctx, "default", "default_group_key", []string{"URL", "username", "url"})
+	if err != nil {
+		t.Fatalf("sherlock.SetRequired: want: nil, have: %v", err)
+	}
+	if want := []string{"legacy"}; !reflect.DeepEqual(incomplete, want) {
+		t.Fatalf("sherlock.SetRequired: want: %v, have: %v", want, incomplete)
+	}
+	group, err := sh.LoadGroup(ctx, "default", "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if want := []string{FieldURL, FieldUsername}; !reflect.DeepEqual(group.Required, want) {
+		t.Fatalf("Group.Required: want: %v, have: %v", want, group.Required)
+	}
+
+	if err := add("default@github", WithUsername("sherlock")); !errors.Is(err, ErrRequiredField) {
+		t.Fatalf("sherlock.UpdateState(add): want: %v, have: %v", ErrRequiredField, err)
+	}
+	if err := add("default@github", WithUsername("sherlock"), WithURL("https://github.com")); err != nil {
+		t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccURL("")); !errors.Is(err, ErrRequiredField) {
+		t.Fatalf("sherlock.UpdateState(url): want: %v, have: %v", ErrRequiredField, err)
+	}
+	// untouched incomplete accounts do not block changes of other accounts
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccNote("2fa on")); err != nil {
+		t.Fatalf("sherlock.UpdateState(note): want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@legacy", "default_group_key", OptAccNote("old")); !errors.Is(err, ErrRequiredField) {
+		t.Fatalf("sherlock.UpdateState(note): want: %v, have: %v", ErrRequiredField, err)
+	}
+
+	if _, err := sh.SetRequired(ctx, "default", "default_group_key", nil); err != nil {
+		t.Fatalf("sherlock.SetRequired: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@legacy", "default_group_key", OptAccNote("old")); err != nil {
+		t.Fatalf("sherlock.UpdateState(note): want: nil, have: %v", err)
+	}
+}
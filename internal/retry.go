@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures how failed FileSystem operations are retried. The delay
+// before the first retry is doubled with every further retry up to MaxDelay and
+// randomized by up to Jitter (a fraction of the delay) so clients failing at the
+// same time do not retry in lockstep
+type RetryPolicy struct {
+	// Attempts is the number of attempts including the first one
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+	Jitter   float64
+	// Retryable reports whether an error is transient. Defaults to Transient
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy retries transient errors three times within about a second
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts: 4,
+	Delay:    100 * time.Millisecond,
+	MaxDelay: 2 * time.Second,
+	Jitter:   0.2,
+}
+
+// Transient reports whether an error is temporary, e.g. a network timeout or a
+// throttled request. Cancelled operations are never transient
+func Transient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// retryFS retries the operations of a FileSystem failing with a transient error
+type retryFS struct {
+	fs     FileSystem
+	policy RetryPolicy
+	mu     *sync.Mutex
+	rand   *rand.Rand
+}
+
+// WithRetry decorates the FileSystem retrying operations failing with a transient
+// error. InitFs and CreateGroup append to a new vault and are not retried since a
+// partially applied attempt cannot be repeated safely
+func WithRetry(fs FileSystem, policy RetryPolicy) FileSystem {
+	if policy.Retryable == nil {
+		policy.Retryable = Transient
+	}
+	return retryFS{
+		fs:     fs,
+		policy: policy,
+		mu:     &sync.Mutex{},
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// do runs the operation until it succeeds, fails with a permanent error, the
+// attempts are used up or the context is done
+func (r retryFS) do(ctx context.Context, op func() error) error {
+	delay := r.policy.Delay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= r.policy.Attempts || !r.policy.Retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(r.jitter(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if delay *= 2; r.policy.MaxDelay > 0 && delay > r.policy.MaxDelay {
+			delay = r.policy.MaxDelay
+		}
+	}
+}
+
+// jitter randomizes the delay by up to the policy's jitter in either direction
+func (r retryFS) jitter(delay time.Duration) time.Duration {
+	if r.policy.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	r.mu.Lock()
+	f := r.rand.Float64()*2 - 1
+	r.mu.Unlock()
+	return delay + time.Duration(f*r.policy.Jitter*float64(delay))
+}
+
+func (r retryFS) InitFs(ctx context.Context, initVault []byte) error {
+	return r.fs.InitFs(ctx, initVault)
+}
+
+func (r retryFS) CreateGroup(ctx context.Context, name string, initVault []byte) error {
+	return r.fs.CreateGroup(ctx, name, initVault)
+}
+
+func (r retryFS) GroupExists(ctx context.Context, name string) error {
+	return r.do(ctx, func() error { return r.fs.GroupExists(ctx, name) })
+}
+
+func (r retryFS) VaultExists(ctx context.Context, group string) error {
+	return r.do(ctx, func() error { return r.fs.VaultExists(ctx, group) })
+}
+
+func (r retryFS) ReadGroupVault(ctx context.Context, group string) (vault []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		vault, err = r.fs.ReadGroupVault(ctx, group)
+		return err
+	})
+	return vault, err
+}
+
+func (r retryFS) Delete(ctx context.Context, gid string) error {
+	return r.do(ctx, func() error { return r.fs.Delete(ctx, gid) })
+}
+
+func (r retryFS) Write(ctx context.Context, gid string, data []byte) error {
+	return r.do(ctx, func() error { return r.fs.Write(ctx, gid, data) })
+}
+
+func (r retryFS) ReadRegisteredGroups(ctx context.Context) (groups []string, err error) {
+	err = r.do(ctx, func() (err error) {
+		groups, err = r.fs.ReadRegisteredGroups(ctx)
+		return err
+	})
+	return groups, err
+}
+
+func (r retryFS) ReadDeviceKey(ctx context.Context) (key []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		key, err = r.fs.ReadDeviceKey(ctx)
+		return err
+	})
+	return key, err
+}
+
+func (r retryFS) WriteDeviceKey(ctx context.Context, key []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteDeviceKey(ctx, key) })
+}
+
+func (r retryFS) ReadSignature(ctx context.Context, gid string) (sig []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		sig, err = r.fs.ReadSignature(ctx, gid)
+		return err
+	})
+	return sig, err
+}
+
+func (r retryFS) WriteSignature(ctx context.Context, gid string, sig []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteSignature(ctx, gid, sig) })
+}
+
+func (r retryFS) ReadIcon(ctx context.Context, name string) (icon []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		icon, err = r.fs.ReadIcon(ctx, name)
+		return err
+	})
+	return icon, err
+}
+
+func (r retryFS) WriteIcon(ctx context.Context, name string, icon []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteIcon(ctx, name, icon) })
+}
+
+func (r retryFS) ClearIcons(ctx context.Context) error {
+	return r.do(ctx, func() error { return r.fs.ClearIcons(ctx) })
+}
+
+func (r retryFS) ReadConfig(ctx context.Context) (config []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		config, err = r.fs.ReadConfig(ctx)
+		return err
+	})
+	return config, err
+}
+
+func (r retryFS) WriteConfig(ctx context.Context, config []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteConfig(ctx, config) })
+}
+
+func (r retryFS) ReadWebhookKey(ctx context.Context) (key []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		key, err = r.fs.ReadWebhookKey(ctx)
+		return err
+	})
+	return key, err
+}
+
+func (r retryFS) WriteWebhookKey(ctx context.Context, key []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteWebhookKey(ctx, key) })
+}
+
+func (r retryFS) ReadKeyring(ctx context.Context) (keyring []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		keyring, err = r.fs.ReadKeyring(ctx)
+		return err
+	})
+	return keyring, err
+}
+
+func (r retryFS) WriteKeyring(ctx context.Context, keyring []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteKeyring(ctx, keyring) })
+}
+
+func (r retryFS) ReadUnlockLog(ctx context.Context) (log []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		log, err = r.fs.ReadUnlockLog(ctx)
+		return err
+	})
+	return log, err
+}
+
+func (r retryFS) WriteUnlockLog(ctx context.Context, log []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteUnlockLog(ctx, log) })
+}
+
+func (r retryFS) LockGroup(ctx context.Context, gid string) (func(), error) {
+	return r.fs.LockGroup(ctx, gid)
+}
+
+func (r retryFS) ReadRevisions(ctx context.Context) (revisions []byte, err error) {
+	err = r.do(ctx, func() (err error) {
+		revisions, err = r.fs.ReadRevisions(ctx)
+		return err
+	})
+	return revisions, err
+}
+
+func (r retryFS) WriteRevisions(ctx context.Context, revisions []byte) error {
+	return r.do(ctx, func() error { return r.fs.WriteRevisions(ctx, revisions) })
+}
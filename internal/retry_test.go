@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/spf13/afero"
+)
+
+// timeoutError is a transient error like a network timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+// flakyFS fails reading vaults with err until failures are used up
+type flakyFS struct {
+	FileSystem
+	failures int
+	err      error
+	reads    int
+}
+
+func (f *flakyFS) ReadGroupVault(ctx context.Context, group string) ([]byte, error) {
+	f.reads++
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	return f.FileSystem.ReadGroupVault(ctx, group)
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := RetryPolicy{Attempts: 3, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond, Jitter: 0.5}
+
+	tt := []struct {
+		name     string
+		failures int
+		err      error
+		reads    int
+		ok       bool
+	}{
+		{name: "transient error recovers", failures: 2, err: timeoutError{}, reads: 3, ok: true},
+		{name: "attempts used up", failures: 3, err: timeoutError{}, reads: 3, ok: false},
+		{name: "permanent error not retried", failures: 1, err: os.ErrPermission, reads: 1, ok: false},
+	}
+	for _, tc := range tt {
+		flaky := &flakyFS{FileSystem: fs.New(afero.NewMemMapFs()), failures: tc.failures, err: tc.err}
+		sh := NewSherlock(WithRetry(flaky, policy))
+		if err := sh.Setup(ctx, "default_group_key"); err != nil {
+			t.Fatalf("[%s] sherlock.Setup: want: nil, have: %v", tc.name, err)
+		}
+		_, err := sh.LoadGroup(ctx, "default", "default_group_key")
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%s] sherlock.LoadGroup: want ok: %v, have: %v", tc.name, tc.ok, err)
+		}
+		if err != nil && !errors.Is(err, tc.err) {
+			t.Fatalf("[%s] sherlock.LoadGroup: want: %v, have: %v", tc.name, tc.err, err)
+		}
+		if flaky.reads != tc.reads {
+			t.Fatalf("[%s] reads: want: %d, have: %d", tc.name, tc.reads, flaky.reads)
+		}
+	}
+}
+
+func TestWithRetryCancelled(t *testing.T) {
+	flaky := &flakyFS{FileSystem: fs.New(afero.NewMemMapFs()), failures: 10, err: timeoutError{}}
+	retry := WithRetry(flaky, RetryPolicy{Attempts: 10, Delay: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := retry.ReadGroupVault(ctx, "default"); err != context.DeadlineExceeded {
+		t.Fatalf("retryFS.ReadGroupVault: want: %v, have: %v", context.DeadlineExceeded, err)
+	}
+	if flaky.reads != 1 {
+		t.Fatalf("reads: want: 1, have: %d", flaky.reads)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tt := []struct {
+		err       error
+		transient bool
+	}{
+		{err: timeoutError{}, transient: true},
+		{err: &os.PathError{Op: "read", Path: "vault", Err: timeoutError{}}, transient: true},
+		{err: os.ErrNotExist, transient: false},
+		{err: context.DeadlineExceeded, transient: false},
+		{err: nil, transient: false},
+	}
+	for _, tc := range tt {
+		if have := Transient(tc.err); have != tc.transient {
+			t.Fatalf("internal.Transient(%v): want: %v, have: %v", tc.err, tc.transient, have)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReuseCluster is a set of accounts sharing the same password. No secret is
+// part of a ReuseCluster
+type ReuseCluster struct {
+	// Accounts are the group@account queries sorted
+	Accounts []string
+	// Groups is the number of distinct groups of the accounts
+	Groups int
+}
+
+// CrossGroup reports whether the password is reused across groups which is
+// the reuse with the highest impact
+func (c ReuseCluster) CrossGroup() bool {
+	return c.Groups > 1
+}
+
+// FindReuse compares the passwords of all accounts of the groups by their
+// SHA-256 hash and returns the clusters of accounts sharing a password. Clusters
+// spanning more groups come first, then larger ones
+func FindReuse(groups ...*Group) []ReuseCluster {
+	type cluster struct {
+		accounts []string
+		groups   map[string]bool
+	}
+	byHash := make(map[[sha256.Size]byte]*cluster)
+	var order [][sha256.Size]byte
+	for _, g := range groups {
+		for _, a := range g.Accounts {
+			if a.Password == "" {
+				continue
+			}
+			sum := sha256.Sum256([]byte(a.Password))
+			c, ok := byHash[sum]
+			if !ok {
+				c = &cluster{groups: make(map[string]bool)}
+				byHash[sum] = c
+				order = append(order, sum)
+			}
+			c.accounts = append(c.accounts, g.GID+querySplitPoint+a.Name)
+			c.groups[g.GID] = true
+		}
+	}
+	var clusters []ReuseCluster
+	for _, sum := range order {
+		c := byHash[sum]
+		if len(c.accounts) < 2 {
+			continue
+		}
+		sort.Strings(c.accounts)
+		clusters = append(clusters, ReuseCluster{Accounts: c.accounts, Groups: len(c.groups)})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		if a.Groups != b.Groups {
+			return a.Groups > b.Groups
+		}
+		if len(a.Accounts) != len(b.Accounts) {
+			return len(a.Accounts) > len(b.Accounts)
+		}
+		return a.Accounts[0] < b.Accounts[0]
+	})
+	return clusters
+}
+
+// WriteReuseDOT writes the clusters as Graphviz DOT graph. Accounts are drawn
+// in a box per group and linked to a node per shared password so reuse across
+// groups stands out
+func WriteReuseDOT(w io.Writer, clusters []ReuseCluster) error {
+	byGroup := make(map[string][]string)
+	for _, c := range clusters {
+		for _, query := range c.Accounts {
+			gid := strings.SplitN(query, querySplitPoint, 2)[0]
+			byGroup[gid] = append(byGroup[gid], query)
+		}
+	}
+	gids := make([]string, 0, len(byGroup))
+	for gid := range byGroup {
+		gids = append(gids, gid)
+	}
+	sort.Strings(gids)
+
+	var b strings.Builder
+	b.WriteString("graph reuse {\n\tnode [shape=box];\n")
+	for _, gid := range gids {
+		fmt.Fprintf(&b, "\tsubgraph %s {\n\t\tlabel=%s;\n", strconv.Quote("cluster_"+gid), strconv.Quote(gid))
+		for _, query := range byGroup[gid] {
+			fmt.Fprintf(&b, "\t\t%s [label=%s];\n", strconv.Quote(query), strconv.Quote(strings.SplitN(query, querySplitPoint, 2)[1]))
+		}
+		b.WriteString("\t}\n")
+	}
+	for i, c := range clusters {
+		node := strconv.Quote("password " + strconv.Itoa(i+1))
+		color := "orange"
+		if c.CrossGroup() {
+			color = "red"
+		}
+		fmt.Fprintf(&b, "\t%s [shape=ellipse, color=%s, label=%s];\n", node, color, strconv.Quote(fmt.Sprintf("password %d (%d accounts)", i+1, len(c.Accounts))))
+		for _, query := range c.Accounts {
+			fmt.Fprintf(&b, "\t%s -- %s;\n", node, strconv.Quote(query))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
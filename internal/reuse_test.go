@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindReuse(t *testing.T) {
+	work := &Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "jira", Password: "shared-everywhere"},
+			{Name: "wiki", Password: "shared-at-work"},
+			{Name: "vpn", Password: "shared-at-work"},
+			{Name: "ci", Password: "unique"},
+		},
+	}
+	private := &Group{
+		GID: "default",
+		Accounts: []*Account{
+			{Name: "bank", Password: "shared-everywhere"},
+			{Name: "mail", Password: "also-unique"},
+		},
+	}
+
+	clusters := FindReuse(work, private)
+	want := []ReuseCluster{
+		{Accounts: []string{"default@bank", "work@jira"}, Groups: 2},
+		{Accounts: []string{"work@vpn", "work@wiki"}, Groups: 1},
+	}
+	if !reflect.DeepEqual(clusters, want) {
+		t.Fatalf("internal.FindReuse: want: %v, have: %v", want, clusters)
+	}
+	if !clusters[0].CrossGroup() || clusters[1].CrossGroup() {
+		t.Fatalf("ReuseCluster.CrossGroup: want: true, false, have: %v, %v", clusters[0].CrossGroup(), clusters[1].CrossGroup())
+	}
+
+	var dot bytes.Buffer
+	if err := WriteReuseDOT(&dot, clusters); err != nil {
+		t.Fatalf("internal.WriteReuseDOT: want: nil, have: %v", err)
+	}
+	for _, line := range []string{
+		`subgraph "cluster_work" {`,
+		`"work@jira" [label="jira"];`,
+		`"password 1" [shape=ellipse, color=red, label="password 1 (2 accounts)"];`,
+		`"password 1" -- "default@bank";`,
+		`"password 2" -- "work@wiki";`,
+	} {
+		if !strings.Contains(dot.String(), line) {
+			t.Fatalf("internal.WriteReuseDOT: want line %q in:\n%s", line, dot.String())
+		}
+	}
+	if strings.Contains(dot.String(), "shared") {
+		t.Fatalf("internal.WriteReuseDOT: password in graph:\n%s", dot.String())
+	}
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"strings"
+)
+
+// snippetContext is the number of runes shown on each side of a match
+const snippetContext = 20
+
+// SearchResult is a single match of a search term in an account field
+type SearchResult struct {
+	Query   string
+	Field   string
+	Snippet string
+}
+
+// Search matches the term case-insensitively against the name, tag, username,
+// url and note of every account. Passwords are never searched. Each matching
+// field yields a result with a snippet of the text around the first match
+func (g Group) Search(term string) []SearchResult {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return nil
+	}
+	var results []SearchResult
+	for _, a := range g.Accounts {
+		for _, f := range []struct {
+			name  string
+			value string
+		}{
+			{name: "name", value: a.Name},
+			{name: "tag", value: a.Tag},
+			{name: FieldUsername, value: a.Username},
+			{name: FieldURL, value: a.URL},
+			{name: FieldNote, value: a.Note},
+		} {
+			snippet, ok := matchSnippet(f.value, term)
+			if !ok {
+				continue
+			}
+			results = append(results, SearchResult{
+				Query:   g.GID + querySplitPoint + a.Name,
+				Field:   f.name,
+				Snippet: snippet,
+			})
+		}
+	}
+	return results
+}
+
+// matchSnippet returns the text around the first match of the lower-cased
+// term in the value. Line breaks are replaced so the snippet fits a table cell
+func matchSnippet(value, term string) (string, bool) {
+	runes := []rune(value)
+	lower := []rune(strings.ToLower(value))
+	if len(lower) != len(runes) {
+		// lower-casing changed the number of runes; match the value as is
+		lower = runes
+	}
+	at := strings.Index(string(lower), term)
+	if at < 0 {
+		return "", false
+	}
+	start := len([]rune(string(lower)[:at]))
+	end := start + len([]rune(term))
+
+	from, to := start-snippetContext, end+snippetContext
+	var prefix, suffix string
+	if from <= 0 {
+		from = 0
+	} else {
+		prefix = "…"
+	}
+	if to >= len(runes) {
+		to = len(runes)
+	} else {
+		suffix = "…"
+	}
+	snippet := prefix + string(runes[from:to]) + suffix
+	return strings.Join(strings.Fields(snippet), " "), true
+}
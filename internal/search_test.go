@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupSearch(t *testing.T) {
+	g := Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "jira", Password: "vpn-is-not-searched", URL: "https://jira.example.com"},
+			{Name: "vpn", Tag: "infra", Note: "Recovery codes are stored in the safe\nin the second drawer of the office"},
+			{Name: "wiki", Username: "alice"},
+		},
+	}
+	tt := []struct {
+		term   string
+		expect []SearchResult
+	}{
+		{
+			term: "VPN",
+			expect: []SearchResult{
+				{Query: "work@vpn", Field: "name", Snippet: "vpn"},
+			},
+		},
+		{
+			term: "drawer",
+			expect: []SearchResult{
+				{Query: "work@vpn", Field: FieldNote, Snippet: "… safe in the second drawer of the office"},
+			},
+		},
+		{
+			term: "example",
+			expect: []SearchResult{
+				{Query: "work@jira", Field: FieldURL, Snippet: "https://jira.example.com"},
+			},
+		},
+		{
+			term:   "not-searched",
+			expect: nil,
+		},
+		{
+			term:   " ",
+			expect: nil,
+		},
+	}
+	for _, tc := range tt {
+		if have := g.Search(tc.term); !reflect.DeepEqual(have, tc.expect) {
+			t.Fatalf("Group.Search(%q): want: %v, have: %v", tc.term, tc.expect, have)
+		}
+	}
+}
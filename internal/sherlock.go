@@ -3,7 +3,10 @@ package internal
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/KonstantinGasser/sherlock/security"
 )
@@ -16,9 +19,11 @@ const (
 
 var (
 	ErrNotSetup     = fmt.Errorf("sherlock needs to bee set-up first (use sherlock setup)")
+	ErrAlreadySetup = fmt.Errorf("sherlock is already set-up")
 	ErrNoSuchGroup  = fmt.Errorf("provided group cannot be found (use sherlock add group)")
 	ErrWrongKey     = fmt.Errorf("wrong group key")
 	ErrInvalidQuery = fmt.Errorf("invalid query. Query should be %q", "group@account")
+	ErrInvalidInput = fmt.Errorf("invalid input")
 )
 
 type StateOption func(g *Group, acc string) error
@@ -46,7 +51,10 @@ func OptAccPassword(password string, insecure bool) StateOption {
 // OptAccName returns a StateOption to change an account name
 func OptAccName(name string) StateOption {
 	return func(g *Group, acc string) error {
-		if ok := g.exists(name); ok {
+		if err := g.names.checkName(strings.TrimSpace(name), ErrInvalidAccountName); err != nil {
+			return err
+		}
+		if ok := g.exists(name); ok && !g.recased(acc, name) {
 			return ErrAccountExists
 		}
 		account, err := g.lookup(acc)
@@ -73,42 +81,150 @@ func OptsAccTag(tag string) StateOption {
 	}
 }
 
+// OptAccUsername returns a StateOption to change an account username
+func OptAccUsername(username string) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldUsername(username))
+	}
+}
+
+// OptAccURL returns a StateOption to change an account url
+func OptAccURL(url string) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldURL(url))
+	}
+}
+
+// OptAccNote returns a StateOption to change an account note
+func OptAccNote(note string) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldNote(note))
+	}
+}
+
+// OptAccAutoType returns a StateOption to change the auto-type sequence of an account.
+// An empty sequence resets the account to the default sequence
+func OptAccAutoType(sequence string) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldAutoType(sequence))
+	}
+}
+
+// OptAccOTP returns a StateOption to set the one-time password secret of an account
+func OptAccOTP(otp *OTP) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		return account.update(updateFieldOTP(otp))
+	}
+}
+
+// OptAccFavorite returns a StateOption to pin or unpin an account. Pinning
+// does not change the account and is therefore not recorded in its history
+func OptAccFavorite(favorite bool) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		account.Favorite = favorite
+		return nil
+	}
+}
+
 // OptAccDelete returns a StateOption deleting an account if it exists
 func OptAccDelete() StateOption {
 	return func(g *Group, acc string) error {
-		return g.delete(acc)
+		_, err := g.delete(acc)
+		return err
 	}
 }
 
 // FileSystem declares the functions sherlock requires to
-// interact with the underlying file system
+// interact with the underlying file system. Implementations must honor the
+// cancellation and deadline of the context
 type FileSystem interface {
-	InitFs(initVault []byte) error
-	CreateGroup(name string, initVault []byte) error
-	GroupExists(name string) error
-	VaultExists(group string) error
-	ReadGroupVault(group string) ([]byte, error)
+	InitFs(ctx context.Context, initVault []byte) error
+	CreateGroup(ctx context.Context, name string, initVault []byte) error
+	GroupExists(ctx context.Context, name string) error
+	VaultExists(ctx context.Context, group string) error
+	ReadGroupVault(ctx context.Context, group string) ([]byte, error)
 	Delete(ctx context.Context, gid string) error
 	Write(ctx context.Context, gid string, data []byte) error
-	ReadRegisteredGroups() ([]string, error)
+	// LockGroup locks the group against other sherlock processes and returns
+	// the function releasing the lock
+	LockGroup(ctx context.Context, gid string) (func(), error)
+	ReadRegisteredGroups(ctx context.Context) ([]string, error)
+	ReadDeviceKey(ctx context.Context) ([]byte, error)
+	WriteDeviceKey(ctx context.Context, key []byte) error
+	ReadSignature(ctx context.Context, gid string) ([]byte, error)
+	WriteSignature(ctx context.Context, gid string, sig []byte) error
+	ReadRevisions(ctx context.Context) ([]byte, error)
+	WriteRevisions(ctx context.Context, revisions []byte) error
+	ReadIcon(ctx context.Context, name string) ([]byte, error)
+	WriteIcon(ctx context.Context, name string, icon []byte) error
+	ClearIcons(ctx context.Context) error
+	ReadConfig(ctx context.Context) ([]byte, error)
+	WriteConfig(ctx context.Context, config []byte) error
+	ReadWebhookKey(ctx context.Context) ([]byte, error)
+	WriteWebhookKey(ctx context.Context, key []byte) error
+	ReadKeyring(ctx context.Context) ([]byte, error)
+	WriteKeyring(ctx context.Context, keyring []byte) error
+	ReadUnlockLog(ctx context.Context) ([]byte, error)
+	WriteUnlockLog(ctx context.Context, log []byte) error
 }
 
 type Sherlock struct {
 	fileSystem FileSystem
+	observers  []Observer
+	checks     []Check
+	names      nameRules
+	locks      *locks
+	tampered   func(gid string)
+	verified   *verified
+	unlocks    *unlocks
 }
 
-// New return new Sherlock instance
+// New return new Sherlock instance. It is safe for concurrent use once the
+// observers, checks and name rules are registered
 func NewSherlock(fs FileSystem) *Sherlock {
 	return &Sherlock{
 		fileSystem: fs,
+		locks:      newLocks(),
+		verified:   &verified{groups: make(map[string]bool)},
+		unlocks:    &unlocks{pending: make(map[string]int)},
 	}
 }
 
-func (sh Sherlock) IsSetUp() error {
-	if err := sh.fileSystem.GroupExists("default"); err == nil { // default group does not exists
+// UseFileSystem replaces the FileSystem the groups are stored in, e.g. with an
+// in-memory one for an ephemeral vault. Groups read before are not moved
+func (sh *Sherlock) UseFileSystem(fs FileSystem) {
+	sh.fileSystem = fs
+}
+
+func (sh Sherlock) IsSetUp(ctx context.Context) error {
+	if err := sh.fileSystem.GroupExists(ctx, "default"); err == nil { // default group does not exists
 		return ErrNotSetup
 	}
-	if err := sh.fileSystem.VaultExists("default"); err == nil {
+	if err := sh.fileSystem.VaultExists(ctx, "default"); err == nil {
 		return ErrNotSetup
 	}
 	return nil
@@ -117,30 +233,47 @@ func (sh Sherlock) IsSetUp() error {
 // Setup checks if a main password for the vault has already been
 // set which is required for every further command. Setup will create required directories
 // if those are missing
-func (sh *Sherlock) Setup(groupKey string) error {
-	vault, err := security.InitWithDefault(groupKey, Group{
+func (sh *Sherlock) Setup(ctx context.Context, groupKey string) error {
+	vault, err := encryptGroup(&Group{
 		GID:      "default",
 		Accounts: make([]*Account, 0),
-	})
+	}, groupKey, security.DefaultKDF)
 	if err != nil {
 		return err
 	}
 
-	if err := sh.fileSystem.InitFs(vault); err != nil {
+	if err := sh.fileSystem.InitFs(ctx, vault); err != nil {
 		return err
 	}
 	return nil
 }
 
-// DeleteGroup irreversible deletes a group from sherlock
+// DeleteGroup irreversible deletes a group from sherlock. Read-only
+// groups cannot be deleted
 func (sh *Sherlock) DeleteGroup(ctx context.Context, gid string) error {
-	return sh.fileSystem.Delete(ctx, gid)
+	defer sh.lockGroup(gid)()
+	if err := sh.checkWritable(ctx, gid); err != nil {
+		return err
+	}
+	events := sh.groupEvents(gid, OpGroupDeleted)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	if err := sh.fileSystem.Delete(ctx, gid); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
 }
 
 // SetupGroup creates the group in the file system
 // if the group does not already exists
-func (sh Sherlock) SetupGroup(name string, groupKey string, insecure bool) error {
-	if err := sh.GroupExists(name); err != nil {
+func (sh Sherlock) SetupGroup(ctx context.Context, name string, groupKey string, insecure bool) error {
+	defer sh.lockGroup(name)()
+	if err := sh.checkGroupName(ctx, name); err != nil {
+		return err
+	}
+	if err := sh.GroupExists(ctx, name); err != nil {
 		return err
 	}
 	group, err := NewGroup(name)
@@ -153,15 +286,26 @@ func (sh Sherlock) SetupGroup(name string, groupKey string, insecure bool) error
 			return err
 		}
 	}
-	vault, err := security.InitWithDefault(groupKey, group)
+	vault, err := encryptGroup(group, groupKey, security.DefaultKDF)
 	if err != nil {
 		return err
 	}
-	return sh.fileSystem.CreateGroup(name, vault)
+	events := sh.groupEvents(name, OpGroupCreated)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	if err := sh.fileSystem.CreateGroup(ctx, name, vault); err != nil {
+		return err
+	}
+	if err := sh.signVault(ctx, name, vault); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
 }
 
-func (sh Sherlock) GroupExists(name string) error {
-	return sh.fileSystem.GroupExists(name)
+func (sh Sherlock) GroupExists(ctx context.Context, name string) error {
+	return sh.fileSystem.GroupExists(ctx, name)
 }
 
 // ValidateGroupKey function validates the group's key for the requested groupID
@@ -170,13 +314,13 @@ func (sh *Sherlock) CheckGroupKey(ctx context.Context, query, groupKey string) e
 	if err != nil {
 		return err
 	}
-	bytes, err := sh.fileSystem.ReadGroupVault(gid)
+	bytes, err := sh.readVault(ctx, gid)
 	if err != nil {
 		return err
 	}
 	var group Group
 	if err := security.DecryptVault(bytes, groupKey, &group); err != nil {
-		return ErrWrongKey
+		return decryptError(err)
 	}
 	return nil
 }
@@ -184,17 +328,21 @@ func (sh *Sherlock) CheckGroupKey(ctx context.Context, query, groupKey string) e
 // GetAccount looks up the requested account
 // to locate an account the query needs to include the group
 // like so group@account
-func (sh Sherlock) GetAccount(query string, groupKey string) (*Account, error) {
+func (sh Sherlock) GetAccount(ctx context.Context, query string, groupKey string) (*Account, error) {
 	gid, name, err := SplitQuery(query)
 	if err != nil {
 		return nil, err
 	}
 
-	group, err := sh.LoadGroup(gid, groupKey)
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
 	if err != nil {
 		return nil, err
 	}
-	return group.lookup(name)
+	account, err := group.lookup(name)
+	if err != nil {
+		return nil, group.accountNotFound(err, name)
+	}
+	return account, nil
 }
 
 // UpdateState executes the passed in StateOption to perform state changes on a group
@@ -203,41 +351,166 @@ func (sh Sherlock) UpdateState(ctx context.Context, query, groupKey string, opt
 	if err != nil {
 		return err
 	}
+	defer sh.lockGroup(gid)()
 
-	group, err := sh.LoadGroup(gid, groupKey)
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
 	if err != nil {
 		return err
 	}
+	var before *Group
+	if sh.watched() || len(group.Required) > 0 {
+		if before, err = group.clone(); err != nil {
+			return err
+		}
+	}
 	if err := opt(group, name); err != nil {
+		return group.accountNotFound(err, name)
+	}
+	if err := group.checkRequired(before); err != nil {
+		return err
+	}
+	events := sh.changeEvents(before, group, name)
+	if err := sh.check(ctx, events); err != nil {
+		return err
+	}
+	if err := sh.WriteGroup(ctx, gid, groupKey, group); err != nil {
 		return err
 	}
-	return sh.WriteGroup(ctx, gid, groupKey, group)
+	sh.emit(ctx, events)
+	return nil
+}
+
+// LoadGroup loads and decrypts the group vault. If the group does not exist
+// the error suggests similar group names
+func (sh Sherlock) LoadGroup(ctx context.Context, gid string, groupKey string) (*Group, error) {
+	group, err := sh.loadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
 }
 
-// LoadGroup loads and decrypts the group vault
-func (sh Sherlock) LoadGroup(gid string, groupKey string) (*Group, error) {
-	bytes, err := sh.fileSystem.ReadGroupVault(gid)
+// loadGroup decrypts the group like LoadGroup but returns the group along
+// with ErrIndexTampered so that the index can be rebuilt
+func (sh Sherlock) loadGroup(ctx context.Context, gid string, groupKey string) (*Group, error) {
+	bytes, err := sh.readVault(ctx, gid)
 	if err != nil {
+		if err == ErrNoSuchGroup {
+			return nil, sh.GroupNotFound(ctx, gid)
+		}
+		return nil, err
+	}
+	h, err := security.ReadHeader(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := sh.verifyLoaded(ctx, gid, bytes); err != nil {
 		return nil, err
 	}
 	var group Group
 	if err := security.DecryptVault(bytes, groupKey, &group); err != nil {
-		return nil, ErrWrongKey
+		err = decryptError(err)
+		if sh.unlocking(gid) {
+			sh.recordUnlock(ctx, gid, err != ErrWrongKey)
+		}
+		if err == ErrWrongKey {
+			return nil, err
+		}
+		group.names = sh.names
+		return &group, err
+	}
+	if sh.unlocking(gid) {
+		sh.recordUnlock(ctx, gid, true)
+	}
+	group.names = sh.names
+	// vaults written before the serialization was canonical keep the
+	// accounts in the order they were added
+	group.Accounts = group.sorted()
+	serialized, err := group.serizalize()
+	if err != nil {
+		return nil, err
 	}
+	group.origin = &groupOrigin{gid: gid, key: groupKey, header: h, serialized: serialized}
 	return &group, nil
 }
 
-// WriteGroup encrypts and write the group vault
+// readVault reads the encrypted group vault returning ErrNoSuchGroup
+// if the group does not exist
+func (sh Sherlock) readVault(ctx context.Context, gid string) ([]byte, error) {
+	bytes, err := sh.fileSystem.ReadGroupVault(ctx, gid)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoSuchGroup
+		}
+		return nil, err
+	}
+	return bytes, nil
+}
+
+// WriteGroup encrypts and write the group vault. The key derivation
+// of the existing vault is kept. Read-only groups are not written
 func (sh Sherlock) WriteGroup(ctx context.Context, gid string, groupKey string, group *Group) error {
+	if group.ReadOnly {
+		return ErrReadOnlyGroup
+	}
+	kdf, err := sh.GroupKDF(ctx, gid)
+	if err != nil {
+		return err
+	}
+	return sh.writeGroup(ctx, gid, groupKey, kdf, group)
+}
+
+// writeGroup encrypts the group vault with the key derived by the kdf and writes it
+func (sh Sherlock) writeGroup(ctx context.Context, gid string, groupKey string, kdf string, group *Group) error {
 	serialized, err := group.serizalize()
 	if err != nil {
 		return err
 	}
-	encrypted, err := security.EncryptVault(serialized, groupKey)
+	// an unchanged group keeps its vault so that the encrypted file only
+	// changes with its content, e.g. for git based sync or backup dedup
+	if group.unchanged(gid, groupKey, kdf, serialized) {
+		return nil
+	}
+	encrypted, err := encryptGroup(group, groupKey, kdf)
 	if err != nil {
 		return err
 	}
-	return sh.fileSystem.Write(ctx, gid, encrypted)
+	if err := sh.writeVault(ctx, gid, encrypted); err != nil {
+		return err
+	}
+	h, err := security.ReadHeader(encrypted)
+	if err != nil {
+		return err
+	}
+	group.origin = &groupOrigin{gid: gid, key: groupKey, header: h, serialized: serialized}
+	return nil
+}
+
+// writeVault writes and signs the encrypted group vault
+func (sh Sherlock) writeVault(ctx context.Context, gid string, encrypted []byte) error {
+	if err := sh.fileSystem.Write(ctx, gid, encrypted); err != nil {
+		return err
+	}
+	return sh.signVault(ctx, gid, encrypted)
+}
+
+// encryptGroup serializes and encrypts the group. The number of accounts,
+// the time of writing and the plain text index are kept in the vault header
+func encryptGroup(group *Group, groupKey string, kdf string) ([]byte, error) {
+	serialized, err := group.serizalize()
+	if err != nil {
+		return nil, err
+	}
+	index, err := group.index()
+	if err != nil {
+		return nil, err
+	}
+	return security.EncryptVaultWithMeta(serialized, groupKey, kdf, &security.Meta{
+		Accounts: len(group.Accounts),
+		Modified: time.Now().UTC(),
+		ReadOnly: group.ReadOnly,
+		Index:    index,
+	})
 }
 
 // SplitQuery verifies that a query (for get,update command) are in the correct
@@ -250,11 +523,12 @@ func SplitQuery(query string) (string, string, error) {
 	return set[0], set[1], nil
 }
 
-// ReadRegisteredGroups loads saved groups
-func (sh Sherlock) ReadRegisteredGroups() ([]string, error) {
-	groups, err := sh.fileSystem.ReadRegisteredGroups()
+// ReadRegisteredGroups loads saved groups sorted by name
+func (sh Sherlock) ReadRegisteredGroups(ctx context.Context) ([]string, error) {
+	groups, err := sh.fileSystem.ReadRegisteredGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
+	sort.Strings(groups)
 	return groups, nil
 }
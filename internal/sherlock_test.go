@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"testing"
 
 	"github.com/KonstantinGasser/sherlock/fs"
@@ -8,20 +9,19 @@ import (
 )
 
 func memLock() *Sherlock {
-	return &Sherlock{
-		fileSystem: fs.New(afero.NewMemMapFs()),
-	}
+	return NewSherlock(fs.New(afero.NewMemMapFs()))
 }
 
 // TestSetup testis if the in-mem fs is setup (which will not be the case)
 // and then sets up sherlock
 func TestSetup(t *testing.T) {
+	ctx := context.Background()
 	sh := memLock()
-	if err := sh.IsSetUp(); err == nil {
+	if err := sh.IsSetUp(ctx); err == nil {
 		t.Fatalf("sherlock.IsSetup: want: nil (not-setup), have: %v", err)
 	}
 
-	err := sh.Setup("default_group_key")
+	err := sh.Setup(ctx, "default_group_key")
 	if err != nil {
 		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
 	}
@@ -157,9 +157,25 @@ func TestOptAccDelete(t *testing.T) {
 			toBeDeleted: "test1",
 			err:         nil,
 		},
+		{
+			g: Group{
+				GID: "test2",
+				Accounts: []*Account{
+					{
+						Name: "test1",
+					},
+					{
+						Name: "test2",
+					},
+				},
+			},
+			toBeDeleted: "test1",
+			err:         nil,
+		},
 	}
 
 	for _, tc := range tt {
+		remaining := len(tc.g.Accounts) - 1
 		err := OptAccDelete()(&tc.g, tc.toBeDeleted)
 		if err != tc.err {
 			t.Fatalf("internal.OptAccDelete: want: %v, have: %v", tc.err, err)
@@ -173,6 +189,9 @@ func TestOptAccDelete(t *testing.T) {
 			if ok := tc.g.exists(tc.toBeDeleted); ok {
 				t.Fatalf("internal.OptAccDelete: account not deleted: want:delete==%v, have:delete==%v", tc.err == nil, ok)
 			}
+			if len(tc.g.Accounts) != remaining {
+				t.Fatalf("internal.OptAccDelete: want: %d accounts left, have: %d", remaining, len(tc.g.Accounts))
+			}
 		}
 
 	}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+var (
+	ErrSigningEnabled = fmt.Errorf("vault signing is already enabled")
+)
+
+// EnableSigning creates the device key and signs all existing group vaults
+// with it. From then on every write is signed and can be verified
+// using VerifyVaults
+func (sh Sherlock) EnableSigning(ctx context.Context) error {
+	if _, err := sh.fileSystem.ReadDeviceKey(ctx); err == nil {
+		return ErrSigningEnabled
+	}
+	key, err := security.GenerateDeviceKey()
+	if err != nil {
+		return err
+	}
+	if err := sh.fileSystem.WriteDeviceKey(ctx, key); err != nil {
+		return err
+	}
+	groups, err := sh.fileSystem.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return err
+	}
+	for _, gid := range groups {
+		vault, err := sh.fileSystem.ReadGroupVault(ctx, gid)
+		if err != nil {
+			return err
+		}
+		if err := sh.signVault(ctx, gid, vault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verified remembers the groups whose vault signature has been checked so a
+// group is verified and reported once per Sherlock
+type verified struct {
+	mu     sync.Mutex
+	groups map[string]bool
+}
+
+// OnTampered registers the function called for a loaded group whose vault has
+// been modified, replaced or rolled back outside of sherlock. Only the groups
+// a command loads are verified, each at most once
+func (sh *Sherlock) OnTampered(f func(gid string)) {
+	sh.tampered = f
+}
+
+// VerifyVaults checks the signature of the vaults of the groups, of all groups
+// if none are given, and returns the groups whose vault has been modified,
+// replaced or rolled back to an older signed revision outside of sherlock. If
+// signing is not enabled no group is reported
+func (sh Sherlock) VerifyVaults(ctx context.Context, gids ...string) ([]string, error) {
+	if len(gids) == 0 {
+		var err error
+		if gids, err = sh.fileSystem.ReadRegisteredGroups(ctx); err != nil {
+			return nil, err
+		}
+	}
+	vaults := make(map[string][]byte, len(gids))
+	for _, gid := range gids {
+		vault, err := sh.readVault(ctx, gid)
+		if err != nil {
+			return nil, err
+		}
+		vaults[gid] = vault
+	}
+	return sh.verifyVaults(ctx, vaults)
+}
+
+// verifyLoaded verifies the vault of a loaded group once and reports it to
+// the function registered with OnTampered
+func (sh Sherlock) verifyLoaded(ctx context.Context, gid string, vault []byte) error {
+	if sh.tampered == nil {
+		return nil
+	}
+	sh.verified.mu.Lock()
+	done := sh.verified.groups[gid]
+	sh.verified.groups[gid] = true
+	sh.verified.mu.Unlock()
+	if done {
+		return nil
+	}
+	tampered, err := sh.verifyVaults(ctx, map[string][]byte{gid: vault})
+	if err != nil {
+		return err
+	}
+	for _, gid := range tampered {
+		sh.tampered(gid)
+	}
+	return nil
+}
+
+// verifyVaults checks the signatures of the vaults advancing the last seen
+// revision of every group
+func (sh Sherlock) verifyVaults(ctx context.Context, vaults map[string][]byte) ([]string, error) {
+	key, err := sh.fileSystem.ReadDeviceKey(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer sh.lockSettings()()
+	seen, err := sh.readRevisions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gids := make([]string, 0, len(vaults))
+	for gid := range vaults {
+		gids = append(gids, gid)
+	}
+	sort.Strings(gids)
+	var tampered []string
+	var advanced bool
+	for _, gid := range gids {
+		sig, err := sh.fileSystem.ReadSignature(ctx, gid)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		revision, ok, err := security.VerifyVault(key, gid, vaults[gid], sig)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !ok, revision < seen[gid]:
+			tampered = append(tampered, gid)
+		case revision > seen[gid]:
+			seen[gid] = revision
+			advanced = true
+		}
+	}
+	if advanced {
+		if err := sh.writeRevisions(ctx, seen); err != nil {
+			return nil, err
+		}
+	}
+	return tampered, nil
+}
+
+// signVault signs the encrypted vault with the next revision of the group if
+// signing is enabled
+func (sh Sherlock) signVault(ctx context.Context, gid string, vault []byte) error {
+	key, err := sh.fileSystem.ReadDeviceKey(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer sh.lockSettings()()
+	seen, err := sh.readRevisions(ctx)
+	if err != nil {
+		return err
+	}
+	seen[gid]++
+	sig, err := security.SignVault(key, gid, seen[gid], vault)
+	if err != nil {
+		return err
+	}
+	if err := sh.fileSystem.WriteSignature(ctx, gid, sig); err != nil {
+		return err
+	}
+	return sh.writeRevisions(ctx, seen)
+}
+
+// readRevisions returns the last signed or verified revision of every group
+func (sh Sherlock) readRevisions(ctx context.Context) (map[string]uint64, error) {
+	seen := make(map[string]uint64)
+	b, err := sh.fileSystem.ReadRevisions(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return seen, nil
+		}
+		return nil, err
+	}
+	if len(b) == 0 {
+		return seen, nil
+	}
+	if err := json.Unmarshal(b, &seen); err != nil {
+		return nil, err
+	}
+	return seen, nil
+}
+
+func (sh Sherlock) writeRevisions(ctx context.Context, seen map[string]uint64) error {
+	b, err := json.Marshal(seen)
+	if err != nil {
+		return err
+	}
+	return sh.fileSystem.WriteRevisions(ctx, b)
+}
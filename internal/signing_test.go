@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestVerifyVaults(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "test-group", "test_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+
+	// without a device key no group can be reported
+	tampered, err := sh.VerifyVaults(ctx)
+	if err != nil || len(tampered) != 0 {
+		t.Fatalf("sherlock.VerifyVaults: want: [] <nil>, have: %v %v", tampered, err)
+	}
+
+	if err := sh.EnableSigning(context.Background()); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+	if err := sh.EnableSigning(context.Background()); err != ErrSigningEnabled {
+		t.Fatalf("sherlock.EnableSigning: want: %v, have: %v", ErrSigningEnabled, err)
+	}
+	tampered, err = sh.VerifyVaults(ctx)
+	if err != nil || len(tampered) != 0 {
+		t.Fatalf("sherlock.VerifyVaults: want: [] <nil>, have: %v %v", tampered, err)
+	}
+
+	// writes through sherlock keep the signature valid
+	group, err := sh.LoadGroup(ctx, "test-group", "test_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if err := sh.WriteGroup(context.Background(), "test-group", "test_group_key", group); err != nil {
+		t.Fatalf("sherlock.WriteGroup: want: nil, have: %v", err)
+	}
+	tampered, err = sh.VerifyVaults(ctx)
+	if err != nil || len(tampered) != 0 {
+		t.Fatalf("sherlock.VerifyVaults: want: [] <nil>, have: %v %v", tampered, err)
+	}
+
+	// restoring an older vault with its valid signature must be detected
+	oldVault, err := sh.fileSystem.ReadGroupVault(ctx, "test-group")
+	if err != nil {
+		t.Fatalf("fs.ReadGroupVault: want: nil, have: %v", err)
+	}
+	oldSig, err := sh.fileSystem.ReadSignature(ctx, "test-group")
+	if err != nil {
+		t.Fatalf("fs.ReadSignature: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("test-group@test-account", "test-account-password", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "test-group@test-account", "test_group_key", OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	if err := sh.fileSystem.Write(ctx, "test-group", oldVault); err != nil {
+		t.Fatalf("fs.Write: want: nil, have: %v", err)
+	}
+	if err := sh.fileSystem.WriteSignature(ctx, "test-group", oldSig); err != nil {
+		t.Fatalf("fs.WriteSignature: want: nil, have: %v", err)
+	}
+	tampered, err = sh.VerifyVaults(ctx)
+	if err != nil || len(tampered) != 1 || tampered[0] != "test-group" {
+		t.Fatalf("sherlock.VerifyVaults(rollback): want: [test-group] <nil>, have: %v %v", tampered, err)
+	}
+
+	// replacing a vault outside of sherlock must be detected
+	if err := sh.fileSystem.Write(ctx, "test-group", []byte("replaced-vault")); err != nil {
+		t.Fatalf("fs.Write: want: nil, have: %v", err)
+	}
+	tampered, err = sh.VerifyVaults(ctx)
+	if err != nil || len(tampered) != 1 || tampered[0] != "test-group" {
+		t.Fatalf("sherlock.VerifyVaults: want: [test-group] <nil>, have: %v %v", tampered, err)
+	}
+}
+
+func TestOnTampered(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	for _, gid := range []string{"work", "private"} {
+		if err := sh.SetupGroup(ctx, gid, gid+"_group_key", true); err != nil {
+			t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+		}
+	}
+	if err := sh.EnableSigning(ctx); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+	var reported []string
+	sh.OnTampered(func(gid string) { reported = append(reported, gid) })
+
+	// only the vaults of loaded groups are verified
+	for _, gid := range []string{"work", "private"} {
+		if err := sh.fileSystem.WriteSignature(ctx, gid, []byte("invalid")); err != nil {
+			t.Fatalf("fs.WriteSignature: want: nil, have: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := sh.LoadGroup(ctx, "work", "work_group_key"); err != nil {
+			t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+		}
+	}
+	if len(reported) != 1 || reported[0] != "work" {
+		t.Fatalf("OnTampered: want: [work], have: %v", reported)
+	}
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+// snapshot entry names of a group like so {group}/.vault
+const (
+	snapshotVault     = ".vault"
+	snapshotSignature = ".vault.sig"
+)
+
+var (
+	ErrInvalidSnapshot = fmt.Errorf("invalid snapshot")
+	ErrGroupExists     = fmt.Errorf("group already exists (use --force to replace it)")
+)
+
+// Snapshot writes a gzip compressed tar archive of all group vaults (and their
+// signatures if signing is enabled) to w. The vaults stay encrypted so taking a
+// snapshot does not require any group key
+func (sh Sherlock) Snapshot(ctx context.Context, w io.Writer) error {
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	now := time.Now()
+	for _, gid := range gids {
+		vault, err := sh.readVault(ctx, gid)
+		if err != nil {
+			return err
+		}
+		if err := writeSnapshotEntry(tw, path.Join(gid, snapshotVault), vault, now); err != nil {
+			return err
+		}
+		sig, err := sh.fileSystem.ReadSignature(ctx, gid)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := writeSnapshotEntry(tw, path.Join(gid, snapshotSignature), sig, now); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeSnapshotEntry(tw *tar.Writer, name string, b []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(b)),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(b)
+	return err
+}
+
+// ReadSnapshot reads the group vaults of a snapshot written by Snapshot mapped
+// by group name. Signatures are skipped since they are only valid for the device
+// which took the snapshot
+func ReadSnapshot(r io.Reader) (map[string][]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	tr := tar.NewReader(zr)
+	vaults := make(map[string][]byte)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		gid, file := path.Split(h.Name)
+		gid = strings.TrimSuffix(gid, "/")
+		if file != snapshotVault {
+			continue
+		}
+		// group names end up in file paths and must not leave the groups directory
+		if gid == "" || gid == "." || gid == ".." || strings.ContainsAny(gid, `/\`) {
+			return nil, fmt.Errorf("%w: unexpected entry %q", ErrInvalidSnapshot, h.Name)
+		}
+		vault, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		vaults[gid] = vault
+	}
+	if len(vaults) == 0 {
+		return nil, fmt.Errorf("%w: no vaults found", ErrInvalidSnapshot)
+	}
+	return vaults, nil
+}
+
+// ReadVault returns the still encrypted vault of the group
+func (sh Sherlock) ReadVault(ctx context.Context, gid string) ([]byte, error) {
+	return sh.readVault(ctx, gid)
+}
+
+// RestoreGroup stores a vault read from a snapshot as the vault of the group. An
+// existing group is only replaced if replace is set. The restored vault is signed
+// with the device key if signing is enabled
+func (sh Sherlock) RestoreGroup(ctx context.Context, gid string, vault []byte, replace bool) error {
+	defer sh.lockGroup(gid)()
+	if _, err := security.ReadHeader(vault); err != nil {
+		return err
+	}
+	events := sh.groupEvents(gid, OpGroupRestored)
+	if err := sh.fileSystem.GroupExists(ctx, gid); err != nil {
+		if !replace {
+			return ErrGroupExists
+		}
+		if err := sh.checkWritable(ctx, gid); err != nil {
+			return err
+		}
+		if err := sh.check(ctx, events); err != nil {
+			return err
+		}
+		if err := sh.fileSystem.Write(ctx, gid, vault); err != nil {
+			return err
+		}
+	} else {
+		if err := sh.check(ctx, events); err != nil {
+			return err
+		}
+		if err := sh.fileSystem.CreateGroup(ctx, gid, vault); err != nil {
+			return err
+		}
+	}
+	if err := sh.signVault(ctx, gid, vault); err != nil {
+		return err
+	}
+	sh.emit(ctx, events)
+	return nil
+}
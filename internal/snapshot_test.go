@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.EnableSigning(context.Background()); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := sh.Snapshot(ctx, &buf); err != nil {
+		t.Fatalf("sherlock.Snapshot: want: nil, have: %v", err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: want: nil, have: %v", err)
+	}
+	tr := tar.NewReader(zr)
+	entries := make(map[string][]byte)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar.Next: want: nil, have: %v", err)
+		}
+		b, _ := ioutil.ReadAll(tr)
+		entries[h.Name] = b
+	}
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expect := []string{"default/.vault", "default/.vault.sig", "work/.vault", "work/.vault.sig"}
+	if !reflect.DeepEqual(names, expect) {
+		t.Fatalf("sherlock.Snapshot: want: %v, have: %v", expect, names)
+	}
+	if _, err := DecryptGroup(entries["work/.vault"], "work_group_key"); err != nil {
+		t.Fatalf("sherlock.Snapshot: want: decryptable vault, have: %v", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	if !reflect.DeepEqual(config, defaultConfig()) {
+		t.Fatalf("sherlock.Config: want: %v, have: %v", defaultConfig(), config)
+	}
+	config.Backup.Target = "/backups"
+	config.Backup.KeepDaily = 3
+	if err := sh.SaveConfig(context.Background(), config); err != nil {
+		t.Fatalf("sherlock.SaveConfig: want: nil, have: %v", err)
+	}
+	saved, err := sh.Config(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+	if !reflect.DeepEqual(saved, config) {
+		t.Fatalf("sherlock.Config: want: %v, have: %v", config, saved)
+	}
+}
+
+func TestRestoreGroup(t *testing.T) {
+	sh := memLock()
+	ctx := context.Background()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.EnableSigning(ctx); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := sh.Snapshot(ctx, &buf); err != nil {
+		t.Fatalf("sherlock.Snapshot: want: nil, have: %v", err)
+	}
+	vaults, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("internal.ReadSnapshot: want: nil, have: %v", err)
+	}
+	if len(vaults) != 2 {
+		t.Fatalf("internal.ReadSnapshot: want: 2 vaults, have: %d", len(vaults))
+	}
+
+	if err := sh.RestoreGroup(ctx, "work", vaults["work"], false); !errors.Is(err, ErrGroupExists) {
+		t.Fatalf("sherlock.RestoreGroup: want: %v, have: %v", ErrGroupExists, err)
+	}
+	if err := sh.RestoreGroup(ctx, "work", vaults["work"], true); err != nil {
+		t.Fatalf("sherlock.RestoreGroup(replace): want: nil, have: %v", err)
+	}
+	if err := sh.RestoreGroup(ctx, "work-restored", vaults["work"], false); err != nil {
+		t.Fatalf("sherlock.RestoreGroup(new): want: nil, have: %v", err)
+	}
+	if _, err := sh.LoadGroup(ctx, "work-restored", "work_group_key"); err != nil {
+		t.Fatalf("sherlock.LoadGroup(restored): want: nil, have: %v", err)
+	}
+	tampered, err := sh.VerifyVaults(ctx)
+	if err != nil || len(tampered) != 0 {
+		t.Fatalf("sherlock.VerifyVaults: want: no tampered vaults, have: %v (%v)", tampered, err)
+	}
+}
+
+func TestReadSnapshotRejectsPaths(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	if err := writeSnapshotEntry(tw, "../../.vault", []byte("vault"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	_ = tw.Close()
+	_ = zw.Close()
+	if _, err := ReadSnapshot(&buf); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("internal.ReadSnapshot: want: %v, have: %v", ErrInvalidSnapshot, err)
+	}
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"sort"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+const day = 24 * time.Hour
+
+// ageBuckets are the password age ranges counted by CollectStats. A zero
+// max marks the last, open ended bucket
+var ageBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{label: "< 1 month", max: 30 * day},
+	{label: "1-3 months", max: 90 * day},
+	{label: "3-6 months", max: 180 * day},
+	{label: "6-12 months", max: 365 * day},
+	{label: "> 1 year"},
+}
+
+// Stats summarizes the accounts of one or more groups. No secrets are
+// part of the Stats
+type Stats struct {
+	Accounts int
+	// Weak is the number of accounts with a password failing the
+	// password strength check
+	Weak   int
+	Groups []GroupStats
+	Ages   []AgeCount
+	Tags   []TagCount
+}
+
+// GroupStats is the number of (weak) accounts of a group
+type GroupStats struct {
+	GID      string
+	Accounts int
+	Weak     int
+}
+
+// AgeCount is the number of passwords with an age in a range
+type AgeCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// TagCount is the number of accounts using a tag
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// PasswordChangedOn returns when the password of the account has been set. For
+// accounts without a recorded password change the creation date is used
+func (a Account) PasswordChangedOn() time.Time {
+	changed := a.CreatedOn
+	for _, e := range a.Events {
+		if e.Kind == EventChanged && e.Field == FieldPassword && e.At.After(changed) {
+			changed = e.At
+		}
+	}
+	return changed
+}
+
+// CollectStats computes the Stats of the groups. Password ages are relative to now
+func CollectStats(now time.Time, groups ...*Group) Stats {
+	stats := Stats{Ages: make([]AgeCount, len(ageBuckets))}
+	for i, b := range ageBuckets {
+		stats.Ages[i].Label = b.label
+	}
+	tags := make(map[string]int)
+	for _, g := range groups {
+		gs := GroupStats{GID: g.GID, Accounts: len(g.Accounts)}
+		for _, a := range g.Accounts {
+			if a.Tag != "" {
+				tags[a.Tag]++
+			}
+			// linked accounts have no password of their own, it is counted with
+			// the target account. Time-locked passwords are sealed
+			if a.Linked() || a.TimeLock != nil {
+				continue
+			}
+			if err := security.PasswordStrength(a.Password); err != nil {
+				gs.Weak++
+			}
+			age := now.Sub(a.PasswordChangedOn())
+			for i, b := range ageBuckets {
+				if b.max == 0 || age < b.max {
+					stats.Ages[i].Count++
+					break
+				}
+			}
+		}
+		stats.Accounts += gs.Accounts
+		stats.Weak += gs.Weak
+		stats.Groups = append(stats.Groups, gs)
+	}
+	for tag, count := range tags {
+		stats.Tags = append(stats.Tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		if stats.Tags[i].Count != stats.Tags[j].Count {
+			return stats.Tags[i].Count > stats.Tags[j].Count
+		}
+		return stats.Tags[i].Tag < stats.Tags[j].Tag
+	})
+	return stats
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollectStats(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) time.Time { return now.Add(-time.Duration(days) * day) }
+	work := &Group{
+		GID: "work",
+		Accounts: []*Account{
+			{Name: "jira", Password: "weak", Tag: "dev", CreatedOn: ago(10)},
+			{Name: "vpn", Password: "J1ra-horse-battery-staple!", Tag: "infra", CreatedOn: ago(400),
+				Events: []Event{{At: ago(400), Kind: EventCreated}, {At: ago(45), Kind: EventChanged, Field: FieldPassword}}},
+			{Name: "wiki", Password: "W1ki-correct-horse-staple!", Tag: "dev", CreatedOn: ago(200)},
+		},
+	}
+	private := &Group{
+		GID: "default",
+		Accounts: []*Account{
+			{Name: "bank", Password: "123456", CreatedOn: ago(800)},
+		},
+	}
+
+	stats := CollectStats(now, work, private)
+	if stats.Accounts != 4 || stats.Weak != 2 {
+		t.Fatalf("internal.CollectStats: want: 4 accounts 2 weak, have: %d %d", stats.Accounts, stats.Weak)
+	}
+	wantGroups := []GroupStats{{GID: "work", Accounts: 3, Weak: 1}, {GID: "default", Accounts: 1, Weak: 1}}
+	if !reflect.DeepEqual(stats.Groups, wantGroups) {
+		t.Fatalf("internal.CollectStats: want: %v, have: %v", wantGroups, stats.Groups)
+	}
+	var ages []int
+	for _, a := range stats.Ages {
+		ages = append(ages, a.Count)
+	}
+	if want := []int{1, 1, 0, 1, 1}; !reflect.DeepEqual(ages, want) {
+		t.Fatalf("internal.CollectStats: want: ages %v, have: %v", want, ages)
+	}
+	wantTags := []TagCount{{Tag: "dev", Count: 2}, {Tag: "infra", Count: 1}}
+	if !reflect.DeepEqual(stats.Tags, wantTags) {
+		t.Fatalf("internal.CollectStats: want: %v, have: %v", wantTags, stats.Tags)
+	}
+}
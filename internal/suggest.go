@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions limits the number of candidates offered by didYouMean
+const maxSuggestions = 3
+
+// suggest returns the candidates closest to name by their (case-insensitive)
+// Levenshtein distance. Candidates further away than a third of the name's
+// length (but at least two edits) are not considered similar
+func suggest(name string, candidates []string) []string {
+	name = strings.ToLower(name)
+	limit := len([]rune(name)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	type match struct {
+		candidate string
+		distance  int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if d := levenshtein(name, strings.ToLower(c)); d <= limit {
+			matches = append(matches, match{candidate: c, distance: d})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+	if len(matches) > maxSuggestions {
+		matches = matches[:maxSuggestions]
+	}
+	suggestions := make([]string, len(matches))
+	for i, m := range matches {
+		suggestions[i] = m.candidate
+	}
+	return suggestions
+}
+
+// levenshtein computes the edit distance between a and b
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// didYouMean appends the suggestions to err. The returned error still
+// matches err with errors.Is
+func didYouMean(err error, suggestions []string) error {
+	if len(suggestions) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w: did you mean %s?", err, strings.Join(suggestions, ", "))
+}
+
+// GroupNotFound returns ErrNoSuchGroup suggesting registered groups similar to gid
+func (sh Sherlock) GroupNotFound(ctx context.Context, gid string) error {
+	gids, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return ErrNoSuchGroup
+	}
+	return didYouMean(ErrNoSuchGroup, suggest(gid, gids))
+}
+
+// accountNotFound adds suggestions of accounts similar to name to err if
+// it is an ErrNoSuchAccount
+func (g Group) accountNotFound(err error, name string) error {
+	if !errors.Is(err, ErrNoSuchAccount) {
+		return err
+	}
+	names := make([]string, len(g.Accounts))
+	for i, a := range g.Accounts {
+		names[i] = a.Name
+	}
+	suggestions := suggest(name, names)
+	for i, s := range suggestions {
+		suggestions[i] = g.GID + querySplitPoint + s
+	}
+	return didYouMean(err, suggestions)
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tt := []struct {
+		a, b   string
+		expect int
+	}{
+		{a: "", b: "", expect: 0},
+		{a: "github", b: "github", expect: 0},
+		{a: "githb", b: "github", expect: 1},
+		{a: "kitten", b: "sitting", expect: 3},
+		{a: "", b: "abc", expect: 3},
+		{a: "äpfel", b: "apfel", expect: 1},
+	}
+	for _, tc := range tt {
+		if have := levenshtein(tc.a, tc.b); have != tc.expect {
+			t.Fatalf("internal.levenshtein(%q, %q): want: %d, have: %d", tc.a, tc.b, tc.expect, have)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"github", "gitlab", "jira", "GitHub-Enterprise", "aws-prod", "aws-dev"}
+	tt := []struct {
+		name   string
+		expect []string
+	}{
+		{name: "githb", expect: []string{"github", "gitlab"}},
+		{name: "JIRA", expect: []string{"jira"}},
+		{name: "aws-stage", expect: []string{}},
+		{name: "aws-prd", expect: []string{"aws-prod"}},
+	}
+	for _, tc := range tt {
+		if have := suggest(tc.name, candidates); !reflect.DeepEqual(have, tc.expect) {
+			t.Fatalf("internal.suggest(%q): want: %v, have: %v", tc.name, tc.expect, have)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	groupKey := "default_group_key"
+	if err := sh.Setup(ctx, groupKey); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", groupKey, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+
+	_, err = sh.GetAccount(ctx, "default@githb", groupKey)
+	if !errors.Is(err, ErrNoSuchAccount) || !strings.Contains(err.Error(), "did you mean default@github?") {
+		t.Fatalf("sherlock.GetAccount: want: %v with suggestion, have: %v", ErrNoSuchAccount, err)
+	}
+	err = sh.UpdateState(ctx, "default@githb", groupKey, OptAccDelete())
+	if !errors.Is(err, ErrNoSuchAccount) || !strings.Contains(err.Error(), "did you mean default@github?") {
+		t.Fatalf("sherlock.UpdateState: want: %v with suggestion, have: %v", ErrNoSuchAccount, err)
+	}
+	if _, err := sh.GetAccount(ctx, "default@something", groupKey); err != ErrNoSuchAccount {
+		t.Fatalf("sherlock.GetAccount: want: %v, have: %v", ErrNoSuchAccount, err)
+	}
+
+	_, err = sh.LoadGroup(ctx, "defualt", groupKey)
+	if !errors.Is(err, ErrNoSuchGroup) || !strings.Contains(err.Error(), "did you mean default?") {
+		t.Fatalf("sherlock.LoadGroup: want: %v with suggestion, have: %v", ErrNoSuchGroup, err)
+	}
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+const (
+	// timeLockKeySize is the size of the random key sealing a time-locked secret
+	timeLockKeySize = 32
+	// timeLockMark separates the name of a time-locked account from the time
+	// it unlocks in tables
+	timeLockMark = " ⏳ "
+)
+
+var (
+	ErrTimeLocked      = fmt.Errorf("secret is time-locked")
+	ErrInvalidTimeLock = fmt.Errorf("invalid time lock")
+	ErrClockBehind     = fmt.Errorf("system clock is behind the time the lock was set (check the clock)")
+	ErrNotTimeLocked   = fmt.Errorf("account is not time-locked")
+)
+
+// TimeLock holds the password of an account sealed until a point in time. The
+// sealing key is split into two shares which sherlock refuses to combine before
+// Until. The lock is enforced by sherlock, not by cryptography: anyone with the
+// group key and a modified client can open it early
+type TimeLock struct {
+	Until time.Time `json:"until"`
+	// LockedOn is when the lock was set. A clock behind it has been turned back
+	LockedOn time.Time `json:"locked_on"`
+	Shares   [2][]byte `json:"shares"`
+	Sealed   []byte    `json:"sealed"`
+}
+
+// Locked reports whether the time lock is still closed at now
+func (l TimeLock) Locked(now time.Time) bool {
+	return now.Before(l.Until)
+}
+
+// newTimeLock seals the secret with a random key split into two shares
+func newTimeLock(secret string, until, now time.Time) (*TimeLock, error) {
+	key := make([]byte, timeLockKeySize)
+	share := make([]byte, timeLockKeySize)
+	for _, b := range [][]byte{key, share} {
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return nil, err
+		}
+	}
+	sealed, err := security.SealPassphrase([]byte(secret), base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		return nil, err
+	}
+	return &TimeLock{
+		Until:    until.UTC(),
+		LockedOn: now.UTC(),
+		Shares:   [2][]byte{share, xor(key, share)},
+		Sealed:   sealed,
+	}, nil
+}
+
+// open combines the key shares and opens the secret unless the lock is still
+// closed at now or the clock has been turned back
+func (l TimeLock) open(now time.Time) (string, error) {
+	if now.Before(l.LockedOn) {
+		return "", ErrClockBehind
+	}
+	if l.Locked(now) {
+		return "", fmt.Errorf("%w until %s (%s left)", ErrTimeLocked, l.Until.Local().Format(time.RFC3339), l.Until.Sub(now).Round(time.Minute))
+	}
+	if len(l.Shares[0]) != timeLockKeySize || len(l.Shares[1]) != timeLockKeySize {
+		return "", ErrInvalidTimeLock
+	}
+	b, err := security.OpenSealed(l.Sealed, base64.StdEncoding.EncodeToString(xor(l.Shares[0], l.Shares[1])))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTimeLock, err)
+	}
+	return string(b), nil
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// OpenTimeLock sets the password of a time-locked account to the sealed
+// secret once the lock has expired. The change is not stored. Accounts which
+// are not time-locked are left as they are
+func (a *Account) OpenTimeLock(now time.Time) error {
+	if a.TimeLock == nil {
+		return nil
+	}
+	password, err := a.TimeLock.open(now)
+	if err != nil {
+		return err
+	}
+	a.Password = password
+	return nil
+}
+
+// OptAccTimeLock returns a StateOption sealing the password of the account
+// until the time. The password is removed from the account until then
+func OptAccTimeLock(until time.Time) StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		switch {
+		case !until.After(now):
+			return fmt.Errorf("%w: %s is not in the future", ErrInvalidTimeLock, until.Format(time.RFC3339))
+		case account.TimeLock != nil:
+			return fmt.Errorf("%w: %s is time-locked already", ErrInvalidTimeLock, account.Name)
+		case account.Linked():
+			return fmt.Errorf("%w: %s uses the password of %s", ErrInvalidTimeLock, account.Name, account.Link)
+		}
+		lock, err := newTimeLock(account.Password, until, now)
+		if err != nil {
+			return err
+		}
+		return account.update(func(a *Account) error {
+			a.TimeLock, a.Password = lock, ""
+			return nil
+		})
+	}
+}
+
+// OptAccOpenTimeLock returns a StateOption storing the secret of an expired
+// time lock as the password of the account again
+func OptAccOpenTimeLock() StateOption {
+	return func(g *Group, acc string) error {
+		account, err := g.lookup(acc)
+		if err != nil {
+			return err
+		}
+		if account.TimeLock == nil {
+			return fmt.Errorf("%w: %s", ErrNotTimeLocked, account.Name)
+		}
+		password, err := account.TimeLock.open(time.Now())
+		if err != nil {
+			return err
+		}
+		return account.update(func(a *Account) error {
+			a.TimeLock, a.Password = nil, password
+			return nil
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeLock(t *testing.T) {
+	now := time.Now()
+	lock, err := newTimeLock("Sup3r$ecret-handover", now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("newTimeLock: want: nil, have: %v", err)
+	}
+
+	tt := []struct {
+		name     string
+		now      time.Time
+		password string
+		err      error
+	}{
+		{name: "before until", now: now.Add(time.Minute), err: ErrTimeLocked},
+		{name: "clock turned back", now: now.Add(-time.Minute), err: ErrClockBehind},
+		{name: "at until", now: now.Add(time.Hour), password: "Sup3r$ecret-handover"},
+		{name: "after until", now: now.Add(24 * time.Hour), password: "Sup3r$ecret-handover"},
+	}
+	for _, tc := range tt {
+		password, err := lock.open(tc.now)
+		if !errors.Is(err, tc.err) || password != tc.password {
+			t.Fatalf("[%s] TimeLock.open: want: %q (%v), have: %q (%v)", tc.name, tc.password, tc.err, password, err)
+		}
+	}
+
+	tampered := *lock
+	tampered.Shares = [2][]byte{lock.Shares[1], lock.Shares[1]}
+	if _, err := tampered.open(now.Add(time.Hour)); !errors.Is(err, ErrInvalidTimeLock) {
+		t.Fatalf("TimeLock.open(tampered): want: %v, have: %v", ErrInvalidTimeLock, err)
+	}
+}
+
+func TestOptAccTimeLock(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	const key = "default_group_key"
+	account, err := NewAccount("default@vault", "Sup3r$ecret-handover", "", true)
+	if err != nil {
+		t.Fatalf("NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+	}
+
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAccTimeLock(time.Now().Add(-time.Hour))); !errors.Is(err, ErrInvalidTimeLock) {
+		t.Fatalf("OptAccTimeLock(past): want: %v, have: %v", ErrInvalidTimeLock, err)
+	}
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAccOpenTimeLock()); !errors.Is(err, ErrNotTimeLocked) {
+		t.Fatalf("OptAccOpenTimeLock(not locked): want: %v, have: %v", ErrNotTimeLocked, err)
+	}
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAccTimeLock(time.Now().Add(time.Hour))); err != nil {
+		t.Fatalf("OptAccTimeLock: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAccTimeLock(time.Now().Add(2*time.Hour))); !errors.Is(err, ErrInvalidTimeLock) {
+		t.Fatalf("OptAccTimeLock(locked): want: %v, have: %v", ErrInvalidTimeLock, err)
+	}
+
+	locked, err := sh.GetAccount(ctx, "default@vault", key)
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	if locked.Password != "" || locked.TimeLock == nil {
+		t.Fatalf("OptAccTimeLock: want: sealed password, have: %+v", locked)
+	}
+	if err := locked.OpenTimeLock(time.Now()); !errors.Is(err, ErrTimeLocked) {
+		t.Fatalf("Account.OpenTimeLock: want: %v, have: %v", ErrTimeLocked, err)
+	}
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAccOpenTimeLock()); !errors.Is(err, ErrTimeLocked) {
+		t.Fatalf("OptAccOpenTimeLock(locked): want: %v, have: %v", ErrTimeLocked, err)
+	}
+	// exports, pushes and references must not hand out the blank password
+	keys := func(gid string) (string, error) { return key, nil }
+	if _, err := sh.AccountPassword(ctx, *locked, keys); !errors.Is(err, ErrTimeLocked) {
+		t.Fatalf("sherlock.AccountPassword(locked): want: %v, have: %v", ErrTimeLocked, err)
+	}
+	group, err := sh.LoadGroup(ctx, "default", key)
+	if err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	if _, err := group.Resolve(Reference{GID: "default", Account: "vault", Field: FieldPassword}); !errors.Is(err, ErrTimeLocked) {
+		t.Fatalf("Group.Resolve(locked): want: %v, have: %v", ErrTimeLocked, err)
+	}
+	if _, err := sh.ResolveReference(ctx, *group, Reference{GID: "default", Account: "vault", Field: FieldPassword}, keys); !errors.Is(err, ErrTimeLocked) {
+		t.Fatalf("sherlock.ResolveReference(locked): want: %v, have: %v", ErrTimeLocked, err)
+	}
+	if err := locked.OpenTimeLock(locked.TimeLock.Until); err != nil || locked.Password != "Sup3r$ecret-handover" {
+		t.Fatalf("Account.OpenTimeLock(expired): want: sealed password, have: %q (%v)", locked.Password, err)
+	}
+
+	if err := sh.UpdateState(ctx, "default@vault", key, OptAccPassword("N3w$ecret-handover-pass", true)); err != nil {
+		t.Fatalf("OptAccPassword: want: nil, have: %v", err)
+	}
+	unlocked, err := sh.GetAccount(ctx, "default@vault", key)
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	if unlocked.TimeLock != nil || unlocked.Password != "N3w$ecret-handover-pass" {
+		t.Fatalf("OptAccPassword: want: time lock removed, have: %+v", unlocked)
+	}
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	ErrNothingToUndo = fmt.Errorf("no deleted account to restore")
+)
+
+// DeleteAccount deletes the account of the group@account query and returns it.
+// The account stays in the group vault as its undo buffer and the group is
+// remembered in the config so Undo restores exactly this account
+func (sh Sherlock) DeleteAccount(ctx context.Context, query, groupKey string) (*Account, error) {
+	gid, _, err := SplitQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var deleted *Account
+	if err := sh.UpdateState(ctx, query, groupKey, func(g *Group, acc string) error {
+		var err error
+		deleted, err = g.delete(acc)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config.Undo = gid
+	return deleted, sh.SaveConfig(ctx, config)
+}
+
+// UndoGroup returns the group of the account deleted last
+func (sh Sherlock) UndoGroup(ctx context.Context) (string, error) {
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	if config.Undo == "" {
+		return "", ErrNothingToUndo
+	}
+	return config.Undo, nil
+}
+
+// Undo restores the account deleted last from the group and returns it. The
+// account is restored as it was deleted, including its history. If an
+// account with its name has been added since, ErrAccountExists is returned
+// and the deleted account is kept
+func (sh Sherlock) Undo(ctx context.Context, gid, groupKey string) (*Account, error) {
+	defer sh.lockGroup(gid)()
+	group, err := sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return nil, err
+	}
+	restored := group.Undo
+	if restored == nil {
+		return nil, fmt.Errorf("%w in group %s", ErrNothingToUndo, gid)
+	}
+	var before *Group
+	if sh.watched() {
+		if before, err = group.clone(); err != nil {
+			return nil, err
+		}
+	}
+	if group.exists(restored.Name) {
+		return nil, fmt.Errorf("%w: %s (rename it to restore the deleted account)", ErrAccountExists, restored.Name)
+	}
+	group.Accounts = append(group.Accounts, restored)
+	group.Undo = nil
+	events := sh.changeEvents(before, group, restored.Name)
+	if err := sh.check(ctx, events); err != nil {
+		return nil, err
+	}
+	if err := sh.WriteGroup(ctx, gid, groupKey, group); err != nil {
+		return nil, err
+	}
+	sh.emit(ctx, events)
+
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.Undo != gid {
+		return restored, nil
+	}
+	config.Undo = ""
+	return restored, sh.SaveConfig(ctx, config)
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if _, err := sh.UndoGroup(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("sherlock.UndoGroup: want: %v, have: %v", ErrNothingToUndo, err)
+	}
+	add := func(query, password string) error {
+		account, err := NewAccount(query, password, "", true)
+		if err != nil {
+			return err
+		}
+		return sh.UpdateState(ctx, query, "default_group_key", OptAddAccount(account))
+	}
+	if err := add("default@github", "Sup3r$ecret-pass"); err != nil {
+		t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccNote("recovery codes in the safe")); err != nil {
+		t.Fatalf("sherlock.UpdateState(note): want: nil, have: %v", err)
+	}
+	want, err := sh.GetAccount(ctx, "default@github", "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+
+	deleted, err := sh.DeleteAccount(ctx, "default@github", "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.DeleteAccount: want: nil, have: %v", err)
+	}
+	if deleted.Name != "github" {
+		t.Fatalf("sherlock.DeleteAccount: want: github, have: %s", deleted.Name)
+	}
+	if _, err := sh.GetAccount(ctx, "default@github", "default_group_key"); !errors.Is(err, ErrNoSuchAccount) {
+		t.Fatalf("sherlock.GetAccount: want: %v, have: %v", ErrNoSuchAccount, err)
+	}
+	gid, err := sh.UndoGroup(ctx)
+	if err != nil || gid != "default" {
+		t.Fatalf("sherlock.UndoGroup: want: default, have: %q (%v)", gid, err)
+	}
+	if _, err := sh.Undo(ctx, gid, "wrong_key"); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("sherlock.Undo: want: %v, have: %v", ErrWrongKey, err)
+	}
+
+	// a new account of the same name blocks the undo without losing the deleted one
+	if err := add("default@github", "An0ther$ecret-pass"); err != nil {
+		t.Fatalf("sherlock.UpdateState(add): want: nil, have: %v", err)
+	}
+	if _, err := sh.Undo(ctx, gid, "default_group_key"); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("sherlock.Undo: want: %v, have: %v", ErrAccountExists, err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", OptAccName("github-new")); err != nil {
+		t.Fatalf("sherlock.UpdateState(rename): want: nil, have: %v", err)
+	}
+
+	restored, err := sh.Undo(ctx, gid, "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.Undo: want: nil, have: %v", err)
+	}
+	have, err := sh.GetAccount(ctx, "default@github", "default_group_key")
+	if err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	if have.Name != restored.Name || have.Password != want.Password || have.Note != want.Note || len(have.Events) != len(want.Events) {
+		t.Fatalf("sherlock.Undo: want: %+v, have: %+v", want, have)
+	}
+	if _, err := sh.UndoGroup(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("sherlock.UndoGroup: want: %v, have: %v", ErrNothingToUndo, err)
+	}
+	if _, err := sh.Undo(ctx, gid, "default_group_key"); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("sherlock.Undo: want: %v, have: %v", ErrNothingToUndo, err)
+	}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxUnlockLog is the number of unlock attempts kept in the log; older
+	// attempts are dropped
+	maxUnlockLog = 500
+	// stdinLink resolves to the terminal of the process. It only exists on
+	// linux; elsewhere the terminal of an SSH session is used
+	stdinLink = "/proc/self/fd/0"
+)
+
+// UnlockAttempt is a logged attempt to unlock a group. It never holds the key
+type UnlockAttempt struct {
+	Group string    `json:"group"`
+	At    time.Time `json:"at"`
+	OK    bool      `json:"ok"`
+	Host  string    `json:"host,omitempty"`
+	TTY   string    `json:"tty,omitempty"`
+}
+
+// UnlockLog returns the logged unlock attempts, latest first
+func (sh Sherlock) UnlockLog(ctx context.Context) ([]UnlockAttempt, error) {
+	attempts, err := sh.readUnlockLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(attempts)-1; i < j; i, j = i+1, j-1 {
+		attempts[i], attempts[j] = attempts[j], attempts[i]
+	}
+	return attempts, nil
+}
+
+func (sh Sherlock) readUnlockLog(ctx context.Context) ([]UnlockAttempt, error) {
+	b, err := sh.fileSystem.ReadUnlockLog(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var attempts []UnlockAttempt
+	if err := json.Unmarshal(b, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// unlocks counts the announced unlocks of every group not yet loaded
+type unlocks struct {
+	mu      sync.Mutex
+	pending map[string]int
+}
+
+// Unlocking announces that the next load of the group unlocks it for the user,
+// e.g. with a key just entered. Only announced loads are logged in the unlock
+// log and counted for the passphrase hint; loads a command makes internally
+// are not
+func (sh Sherlock) Unlocking(gid string) {
+	sh.unlocks.mu.Lock()
+	defer sh.unlocks.mu.Unlock()
+	sh.unlocks.pending[gid]++
+}
+
+// unlocking reports whether the load of the group was announced by Unlocking
+// and consumes the announcement
+func (sh Sherlock) unlocking(gid string) bool {
+	sh.unlocks.mu.Lock()
+	defer sh.unlocks.mu.Unlock()
+	if sh.unlocks.pending[gid] == 0 {
+		return false
+	}
+	sh.unlocks.pending[gid]--
+	return true
+}
+
+// recordUnlock logs the attempt to unlock the group and counts failed unlocks
+// for its passphrase hint. It is best effort: unlocking never fails because
+// the attempt could not be stored
+func (sh Sherlock) recordUnlock(ctx context.Context, gid string, ok bool) {
+	_ = sh.logUnlock(ctx, gid, ok)
+	_ = sh.countUnlock(ctx, gid, ok)
+}
+
+// logUnlock appends the attempt to the unlock log with the host and terminal
+// it was made from
+func (sh Sherlock) logUnlock(ctx context.Context, gid string, ok bool) error {
+	defer sh.lockSettings()()
+	attempts, err := sh.readUnlockLog(ctx)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	attempts = append(attempts, UnlockAttempt{Group: gid, At: time.Now().UTC(), OK: ok, Host: host, TTY: tty()})
+	if len(attempts) > maxUnlockLog {
+		attempts = attempts[len(attempts)-maxUnlockLog:]
+	}
+	b, err := json.Marshal(attempts)
+	if err != nil {
+		return err
+	}
+	return sh.fileSystem.WriteUnlockLog(ctx, b)
+}
+
+// tty returns the terminal the process reads from or an empty string if it
+// cannot be determined, e.g. when run from a script
+func tty() string {
+	if path, err := os.Readlink(stdinLink); err == nil && strings.HasPrefix(path, "/dev/") && path != os.DevNull {
+		return path
+	}
+	return os.Getenv("SSH_TTY")
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUnlockLog(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	attempts, err := sh.UnlockLog(ctx)
+	if err != nil || len(attempts) != 0 {
+		t.Fatalf("sherlock.UnlockLog: want: [] <nil>, have: %v %v", attempts, err)
+	}
+
+	sh.Unlocking("default")
+	if _, err := sh.LoadGroup(ctx, "default", "wrong_key"); err != ErrWrongKey {
+		t.Fatalf("sherlock.LoadGroup: want: %v, have: %v", ErrWrongKey, err)
+	}
+	sh.Unlocking("default")
+	// loads after the announced one are made by the command, not the user
+	for i := 0; i < 3; i++ {
+		if _, err := sh.LoadGroup(ctx, "default", "default_group_key"); err != nil {
+			t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+		}
+	}
+	attempts, err = sh.UnlockLog(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.UnlockLog: want: nil, have: %v", err)
+	}
+	if len(attempts) != 2 || !attempts[0].OK || attempts[1].OK || attempts[0].Group != "default" {
+		t.Fatalf("sherlock.UnlockLog: want: success after failure, have: %+v", attempts)
+	}
+	if attempts[0].At.Before(attempts[1].At) {
+		t.Fatalf("sherlock.UnlockLog: want: latest first, have: %+v", attempts)
+	}
+
+	for i := 0; i < maxUnlockLog; i++ {
+		if err := sh.logUnlock(ctx, "default", true); err != nil {
+			t.Fatalf("sherlock.logUnlock: want: nil, have: %v", err)
+		}
+	}
+	attempts, err = sh.UnlockLog(ctx)
+	if err != nil || len(attempts) != maxUnlockLog {
+		t.Fatalf("sherlock.UnlockLog: want: %d attempts, have: %d (%v)", maxUnlockLog, len(attempts), err)
+	}
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/KonstantinGasser/sherlock/webhook"
+)
+
+// webhookKeyLen is the number of random bytes of a webhook secret
+const webhookKeyLen = 32
+
+var (
+	ErrNoWebhook = fmt.Errorf("no webhook configured (use sherlock webhook set)")
+)
+
+// WebhookConfig configures the HTTPS endpoint change events are sent to.
+// The secret signing the events is stored separately in webhook.key
+type WebhookConfig struct {
+	URL string `json:"url,omitempty"`
+}
+
+// SetWebhook configures the url change events are sent to and creates a new
+// secret the events are signed with. The secret is returned so it can be
+// set up at the receiving end
+func (sh Sherlock) SetWebhook(ctx context.Context, url string) (string, error) {
+	if err := webhook.CheckURL(url); err != nil {
+		return "", err
+	}
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	key := make([]byte, webhookKeyLen)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(key)
+	if err := sh.fileSystem.WriteWebhookKey(ctx, []byte(secret)); err != nil {
+		return "", err
+	}
+	config.Webhook.URL = url
+	if err := sh.SaveConfig(ctx, config); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// RemoveWebhook stops sending change events
+func (sh Sherlock) RemoveWebhook(ctx context.Context) error {
+	defer sh.lockSettings()()
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return err
+	}
+	config.Webhook.URL = ""
+	return sh.SaveConfig(ctx, config)
+}
+
+// Webhook returns the configured webhook url and its secret. ErrNoWebhook is
+// returned if no webhook is configured
+func (sh Sherlock) Webhook(ctx context.Context) (string, []byte, error) {
+	config, err := sh.Config(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if config.Webhook.URL == "" {
+		return "", nil, ErrNoWebhook
+	}
+	secret, err := sh.fileSystem.ReadWebhookKey(ctx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, ErrNoWebhook
+		}
+		return "", nil, err
+	}
+	return config.Webhook.URL, secret, nil
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"context"
+)
+
+// Wipe deletes every group and blanks the device key, keyring, webhook key,
+// config, unlock log and vault revisions. It is meant for backends the files cannot be wiped
+// of directly, e.g. a remote, and returns the deleted groups. There is no way
+// back unless a backup exists
+func (sh Sherlock) Wipe(ctx context.Context) ([]string, error) {
+	groups, err := sh.fileSystem.ReadRegisteredGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, gid := range groups {
+		unlock := sh.lockGroup(gid)
+		err := sh.fileSystem.Delete(ctx, gid)
+		unlock()
+		if err != nil {
+			return groups[:i], err
+		}
+	}
+	defer sh.lockSettings()()
+	for _, blank := range []func(context.Context, []byte) error{
+		sh.fileSystem.WriteDeviceKey,
+		sh.fileSystem.WriteKeyring,
+		sh.fileSystem.WriteWebhookKey,
+		sh.fileSystem.WriteConfig,
+		sh.fileSystem.WriteUnlockLog,
+		sh.fileSystem.WriteRevisions,
+	} {
+		if err := blank(ctx, nil); err != nil {
+			return groups, err
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWipe(t *testing.T) {
+	ctx := context.Background()
+	sh := memLock()
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.EnableSigning(ctx); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+
+	groups, err := sh.Wipe(ctx)
+	if err != nil || len(groups) != 2 {
+		t.Fatalf("sherlock.Wipe: want: 2 groups <nil>, have: %v %v", groups, err)
+	}
+	if groups, err := sh.fileSystem.ReadRegisteredGroups(ctx); err != nil || len(groups) != 0 {
+		t.Fatalf("fileSystem.ReadRegisteredGroups: want: [] <nil>, have: %v %v", groups, err)
+	}
+	if key, err := sh.fileSystem.ReadDeviceKey(ctx); err != nil || len(key) != 0 {
+		t.Fatalf("fileSystem.ReadDeviceKey: want: blank, have: %d bytes %v", len(key), err)
+	}
+	if _, err := sh.LoadGroup(ctx, "default", "default_group_key"); err == nil {
+		t.Fatalf("sherlock.LoadGroup: want: error, have: nil")
+	}
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ExecInfoEnv is the environment variable client-go passes the ExecCredential
+// request of a credential plugin in
+const ExecInfoEnv = "KUBERNETES_EXEC_INFO"
+
+// API versions of the client-go credential plugin mechanism. v1alpha1 is no
+// longer supported by client-go and not offered
+const (
+	ExecAPIV1      = "client.authentication.k8s.io/v1"
+	ExecAPIV1beta1 = "client.authentication.k8s.io/v1beta1"
+)
+
+var (
+	ErrInvalidExecInfo   = fmt.Errorf("invalid " + ExecInfoEnv)
+	ErrInvalidCredential = fmt.Errorf("invalid exec credential")
+)
+
+// ExecInfo is the ExecCredential request client-go passes to a plugin
+type ExecInfo struct {
+	APIVersion string
+	// Interactive reports whether the plugin may prompt on stdin
+	Interactive bool
+}
+
+// ParseExecInfo reads the value of KUBERNETES_EXEC_INFO. Clients not passing
+// it get a v1 credential and are treated as interactive
+func ParseExecInfo(value string) (ExecInfo, error) {
+	if value == "" {
+		return ExecInfo{APIVersion: ExecAPIV1, Interactive: true}, nil
+	}
+	var req struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Spec       struct {
+			Interactive bool `json:"interactive"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal([]byte(value), &req); err != nil {
+		return ExecInfo{}, fmt.Errorf("%w: %v", ErrInvalidExecInfo, err)
+	}
+	if req.Kind != "ExecCredential" {
+		return ExecInfo{}, fmt.Errorf("%w: unexpected kind %q", ErrInvalidExecInfo, req.Kind)
+	}
+	if req.APIVersion != ExecAPIV1 && req.APIVersion != ExecAPIV1beta1 {
+		return ExecInfo{}, fmt.Errorf("%w: unsupported apiVersion %q", ErrInvalidExecInfo, req.APIVersion)
+	}
+	return ExecInfo{APIVersion: req.APIVersion, Interactive: req.Spec.Interactive}, nil
+}
+
+// Credential authenticates a kubeconfig user with a bearer token, a PEM
+// encoded client certificate and key or both
+type Credential struct {
+	Token      string
+	ClientCert string
+	ClientKey  string
+	// Expires makes client-go run the plugin again after it, zero never expires
+	Expires time.Time
+}
+
+type execCredential struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Status     execStatus `json:"status"`
+}
+
+type execStatus struct {
+	ExpirationTimestamp   string `json:"expirationTimestamp,omitempty"`
+	Token                 string `json:"token,omitempty"`
+	ClientCertificateData string `json:"clientCertificateData,omitempty"`
+	ClientKeyData         string `json:"clientKeyData,omitempty"`
+}
+
+// ExecCredential returns the JSON of the ExecCredential answering the request
+// of client-go with the credential
+func ExecCredential(info ExecInfo, c Credential) ([]byte, error) {
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return nil, fmt.Errorf("%w: a client certificate needs its key and the other way around", ErrInvalidCredential)
+	}
+	if c.Token == "" && c.ClientCert == "" {
+		return nil, fmt.Errorf("%w: neither a token nor a client certificate", ErrInvalidCredential)
+	}
+	cred := execCredential{
+		APIVersion: info.APIVersion,
+		Kind:       "ExecCredential",
+		Status: execStatus{
+			Token:                 c.Token,
+			ClientCertificateData: c.ClientCert,
+			ClientKeyData:         c.ClientKey,
+		},
+	}
+	if !c.Expires.IsZero() {
+		cred.Status.ExpirationTimestamp = c.Expires.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(cred)
+}
@@ -0,0 +1,154 @@
+// Package k8s maps sherlock accounts to Kubernetes Secrets
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ManagedByLabel marks the Secrets written by sherlock
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	managedBy      = "sherlock"
+	defaultNS      = "default"
+)
+
+// kinds of change of a Secret key
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+var (
+	ErrInvalidManifest = fmt.Errorf("invalid secret manifest")
+)
+
+// Manifest maps Kubernetes Secrets to sherlock references:
+//
+//	secrets:
+//	  - name: db-credentials
+//	    namespace: apps
+//	    data:
+//	      username: sherlock://work@db#username
+//	      password: sherlock://work@db
+type Manifest struct {
+	Secrets []Secret `yaml:"secrets"`
+}
+
+// Secret is a Kubernetes Secret whose data keys reference accounts
+type Secret struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace"`
+	Data      map[string]string `yaml:"data"`
+}
+
+// Change is a changed key of a Secret. Values are never part of a Change
+type Change struct {
+	Key    string
+	Change string
+}
+
+// ParseManifest reads a manifest. Secrets without a namespace are put
+// into the default namespace
+func ParseManifest(b []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	seen := make(map[string]bool)
+	for i, s := range m.Secrets {
+		if s.Name == "" || len(s.Data) == 0 {
+			return nil, fmt.Errorf("%w: secret %d needs a name and data", ErrInvalidManifest, i+1)
+		}
+		if s.Namespace == "" {
+			m.Secrets[i].Namespace = defaultNS
+		}
+		id := m.Secrets[i].Namespace + "/" + s.Name
+		if seen[id] {
+			return nil, fmt.Errorf("%w: secret %s is listed twice", ErrInvalidManifest, id)
+		}
+		seen[id] = true
+	}
+	return &m, nil
+}
+
+// object is the subset of a Kubernetes Secret object sherlock reads and writes
+type object struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   metadata          `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data"`
+}
+
+type metadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Object returns the JSON of an Opaque Secret holding the data
+func Object(name, namespace string, data map[string]string) ([]byte, error) {
+	obj := object{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: metadata{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{ManagedByLabel: managedBy},
+		},
+		Type: "Opaque",
+		Data: make(map[string][]byte, len(data)),
+	}
+	for k, v := range data {
+		obj.Data[k] = []byte(v)
+	}
+	return json.Marshal(obj)
+}
+
+// DecodeData returns the decoded data of a Secret object as printed by the
+// Kubernetes API
+func DecodeData(b []byte) (map[string]string, error) {
+	var obj struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	data := make(map[string]string, len(obj.Data))
+	for k, v := range obj.Data {
+		value, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("secret key %s: %v", k, err)
+		}
+		data[k] = string(value)
+	}
+	return data, nil
+}
+
+// Diff returns the changes turning the current into the desired data
+// sorted by key
+func Diff(current, desired map[string]string) []Change {
+	var changes []Change
+	for k, v := range desired {
+		old, ok := current[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: k, Change: ChangeAdded})
+		case old != v:
+			changes = append(changes, Change{Key: k, Change: ChangeChanged})
+		}
+	}
+	for k := range current {
+		if _, ok := desired[k]; !ok {
+			changes = append(changes, Change{Key: k, Change: ChangeRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
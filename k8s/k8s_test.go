@@ -0,0 +1,110 @@
+package k8s
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte(`
+secrets:
+  - name: db-credentials
+    namespace: apps
+    data:
+      username: sherlock://work@db#username
+      password: sherlock://work@db
+  - name: api-token
+    data:
+      token: sherlock://work@api
+`))
+	if err != nil {
+		t.Fatalf("k8s.ParseManifest: want: nil, have: %v", err)
+	}
+	expect := []Secret{
+		{Name: "db-credentials", Namespace: "apps", Data: map[string]string{
+			"username": "sherlock://work@db#username",
+			"password": "sherlock://work@db",
+		}},
+		{Name: "api-token", Namespace: "default", Data: map[string]string{"token": "sherlock://work@api"}},
+	}
+	if !reflect.DeepEqual(m.Secrets, expect) {
+		t.Fatalf("k8s.ParseManifest: want: %v, have: %v", expect, m.Secrets)
+	}
+
+	invalid := []string{
+		"secrets:\n  - data:\n      token: sherlock://work@api\n",
+		"secrets:\n  - name: api\n",
+		"secrets:\n  - name: api\n    data: {a: b}\n  - name: api\n    namespace: default\n    data: {a: b}\n",
+		"secrets:\n  - name: api\n    date: {a: b}\n",
+	}
+	for _, b := range invalid {
+		if _, err := ParseManifest([]byte(b)); !errors.Is(err, ErrInvalidManifest) {
+			t.Fatalf("k8s.ParseManifest(%q): want: %v, have: %v", b, ErrInvalidManifest, err)
+		}
+	}
+}
+
+func TestObjectRoundTrip(t *testing.T) {
+	data := map[string]string{"username": "watson", "password": "221b"}
+	b, err := Object("db", "apps", data)
+	if err != nil {
+		t.Fatalf("k8s.Object: want: nil, have: %v", err)
+	}
+	decoded, err := DecodeData(b)
+	if err != nil {
+		t.Fatalf("k8s.DecodeData: want: nil, have: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, data) {
+		t.Fatalf("k8s.DecodeData: want: %v, have: %v", data, decoded)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current := map[string]string{"username": "watson", "password": "221b", "legacy": "x"}
+	desired := map[string]string{"username": "watson", "password": "221c", "token": "t"}
+	expect := []Change{
+		{Key: "legacy", Change: ChangeRemoved},
+		{Key: "password", Change: ChangeChanged},
+		{Key: "token", Change: ChangeAdded},
+	}
+	if have := Diff(current, desired); !reflect.DeepEqual(have, expect) {
+		t.Fatalf("k8s.Diff: want: %v, have: %v", expect, have)
+	}
+	if have := Diff(desired, desired); len(have) != 0 {
+		t.Fatalf("k8s.Diff: want: no changes, have: %v", have)
+	}
+}
+
+func TestExecCredential(t *testing.T) {
+	info, err := ParseExecInfo(`{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{"interactive":false}}`)
+	if err != nil || info.APIVersion != ExecAPIV1beta1 || info.Interactive {
+		t.Fatalf("k8s.ParseExecInfo: want: v1beta1 not interactive, have: %+v %v", info, err)
+	}
+	if info, err := ParseExecInfo(""); err != nil || info.APIVersion != ExecAPIV1 || !info.Interactive {
+		t.Fatalf("k8s.ParseExecInfo(\"\"): want: v1 interactive, have: %+v %v", info, err)
+	}
+	for _, value := range []string{"{", `{"kind":"Secret","apiVersion":"v1"}`, `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1alpha1"}`} {
+		if _, err := ParseExecInfo(value); !errors.Is(err, ErrInvalidExecInfo) {
+			t.Fatalf("k8s.ParseExecInfo(%q): want: %v, have: %v", value, ErrInvalidExecInfo, err)
+		}
+	}
+
+	expires := time.Date(2021, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	b, err := ExecCredential(info, Credential{Token: "221b", Expires: expires})
+	if err != nil {
+		t.Fatalf("k8s.ExecCredential: want: nil, have: %v", err)
+	}
+	expect := `{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","status":{"expirationTimestamp":"2021-03-01T11:00:00Z","token":"221b"}}`
+	if string(b) != expect {
+		t.Fatalf("k8s.ExecCredential: want: %s, have: %s", expect, b)
+	}
+
+	invalid := []Credential{{}, {ClientCert: "cert"}, {Token: "221b", ClientKey: "key"}}
+	for _, c := range invalid {
+		if _, err := ExecCredential(info, c); !errors.Is(err, ErrInvalidCredential) {
+			t.Fatalf("k8s.ExecCredential(%+v): want: %v, have: %v", c, ErrInvalidCredential, err)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/salsa20"
+)
+
+// KDBX 3.1 is the KeePass 2.x database format every KeePass compatible app
+// (KeePassXC, KeePassDX, KeePassium, Strongbox, ...) can open
+const (
+	signature1   uint32 = 0x9aa2d903
+	signature2   uint32 = 0xb54bfb67
+	versionMinor uint16 = 1
+	versionMajor uint16 = 3
+
+	// DefaultRounds is the number of AES-KDF rounds protecting the passphrase.
+	// Phones take about a second to derive the key
+	DefaultRounds uint64 = 1000000
+
+	// blockSize is the size of a block of the hashed block stream
+	blockSize = 1024 * 1024
+	// timeLayout is the format of all times in the database xml
+	timeLayout = "2006-01-02T15:04:05Z"
+)
+
+// header field ids
+const (
+	fieldEnd byte = iota
+	_
+	fieldCipherID
+	fieldCompression
+	fieldMasterSeed
+	fieldTransformSeed
+	fieldTransformRounds
+	fieldEncryptionIV
+	fieldProtectedStreamKey
+	fieldStreamStartBytes
+	fieldInnerRandomStreamID
+)
+
+const (
+	compressionGzip    uint32 = 1
+	innerStreamSalsa20 uint32 = 2
+)
+
+var (
+	// cipherAES is the uuid of AES-256-CBC
+	cipherAES = []byte{0x31, 0xc1, 0xf2, 0xe6, 0xbf, 0x71, 0x43, 0x50, 0xbe, 0x58, 0x05, 0x21, 0x6a, 0xfc, 0x5a, 0xff}
+	// salsa20Nonce is the fixed nonce of the inner random stream
+	salsa20Nonce = []byte{0xe8, 0x30, 0x09, 0x4b, 0x97, 0x20, 0x5d, 0x2a}
+)
+
+var ErrEmptyPassphrase = fmt.Errorf("database passphrase must not be empty")
+
+// Entry is an entry of the database
+type Entry struct {
+	Title    string
+	UserName string
+	Password string
+	URL      string
+	Notes    string
+	// OTP is the otpauth:// uri stored in the otp field as KeePassXC and
+	// KeePassDX read it
+	OTP      string
+	Tags     string
+	Created  time.Time
+	Modified time.Time
+}
+
+// Group is a group of entries below the root group
+type Group struct {
+	Name    string
+	Entries []Entry
+}
+
+// Database is the content of a database
+type Database struct {
+	// Name is the name of the database and its root group
+	Name   string
+	Groups []Group
+	// Rounds overrides DefaultRounds if not zero
+	Rounds uint64
+}
+
+// Write writes the database encrypted with the passphrase in the KDBX 3.1 format
+// (AES-256 with AES-KDF, gzip compressed, passwords and otp uris protected
+// by the Salsa20 inner stream)
+func Write(w io.Writer, db Database, passphrase string) error {
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+	rounds := db.Rounds
+	if rounds == 0 {
+		rounds = DefaultRounds
+	}
+	var masterSeed, transformSeed, streamKey, startBytes [32]byte
+	var iv [16]byte
+	for _, b := range [][]byte{masterSeed[:], transformSeed[:], streamKey[:], startBytes[:], iv[:]} {
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return err
+		}
+	}
+
+	var header bytes.Buffer
+	_ = binary.Write(&header, binary.LittleEndian, signature1)
+	_ = binary.Write(&header, binary.LittleEndian, signature2)
+	_ = binary.Write(&header, binary.LittleEndian, versionMinor)
+	_ = binary.Write(&header, binary.LittleEndian, versionMajor)
+	writeField(&header, fieldCipherID, cipherAES)
+	writeField(&header, fieldCompression, uint32Bytes(compressionGzip))
+	writeField(&header, fieldMasterSeed, masterSeed[:])
+	writeField(&header, fieldTransformSeed, transformSeed[:])
+	writeField(&header, fieldTransformRounds, uint64Bytes(rounds))
+	writeField(&header, fieldEncryptionIV, iv[:])
+	writeField(&header, fieldProtectedStreamKey, streamKey[:])
+	writeField(&header, fieldStreamStartBytes, startBytes[:])
+	writeField(&header, fieldInnerRandomStreamID, uint32Bytes(innerStreamSalsa20))
+	writeField(&header, fieldEnd, []byte("\r\n\r\n"))
+
+	headerHash := sha256.Sum256(header.Bytes())
+	doc, err := document(db, headerHash[:], streamKey[:])
+	if err != nil {
+		return err
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(doc); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	payload := append(startBytes[:], hashedBlocks(compressed.Bytes())...)
+	key, err := masterKey(passphrase, masterSeed[:], transformSeed[:], rounds)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	payload = pad(payload, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv[:]).CryptBlocks(payload, payload)
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err = w.Write(payload)
+	return err
+}
+
+func writeField(buf *bytes.Buffer, id byte, data []byte) {
+	buf.WriteByte(id)
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(data)))
+	buf.Write(data)
+}
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func uint64Bytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+// masterKey derives the key of the payload: the composite key (sha256 of the
+// passphrase's sha256) is transformed by rounds of AES-ECB with the transform
+// seed and hashed together with the master seed
+func masterKey(passphrase string, masterSeed, transformSeed []byte, rounds uint64) ([]byte, error) {
+	pw := sha256.Sum256([]byte(passphrase))
+	composite := sha256.Sum256(pw[:])
+	block, err := aes.NewCipher(transformSeed)
+	if err != nil {
+		return nil, err
+	}
+	transformed := composite
+	for i := uint64(0); i < rounds; i++ {
+		block.Encrypt(transformed[:16], transformed[:16])
+		block.Encrypt(transformed[16:], transformed[16:])
+	}
+	transformedKey := sha256.Sum256(transformed[:])
+	key := sha256.Sum256(append(append([]byte{}, masterSeed...), transformedKey[:]...))
+	return key[:], nil
+}
+
+// hashedBlocks splits data into blocks of index, sha256, size and data
+// terminated by an empty block with a zero hash
+func hashedBlocks(data []byte) []byte {
+	var buf bytes.Buffer
+	var index uint32
+	for len(data) > 0 {
+		n := blockSize
+		if n > len(data) {
+			n = len(data)
+		}
+		hash := sha256.Sum256(data[:n])
+		_ = binary.Write(&buf, binary.LittleEndian, index)
+		buf.Write(hash[:])
+		_ = binary.Write(&buf, binary.LittleEndian, int32(n))
+		buf.Write(data[:n])
+		data = data[n:]
+		index++
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, index)
+	buf.Write(make([]byte, sha256.Size))
+	_ = binary.Write(&buf, binary.LittleEndian, int32(0))
+	return buf.Bytes()
+}
+
+// pad applies PKCS#7 padding
+func pad(b []byte, size int) []byte {
+	n := size - len(b)%size
+	return append(b, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+type xmlFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    xmlMeta  `xml:"Meta"`
+	Root    xmlRoot  `xml:"Root"`
+}
+
+type xmlMeta struct {
+	Generator    string `xml:"Generator"`
+	HeaderHash   string `xml:"HeaderHash"`
+	DatabaseName string `xml:"DatabaseName"`
+}
+
+type xmlRoot struct {
+	Group xmlGroup `xml:"Group"`
+}
+
+type xmlGroup struct {
+	UUID    string     `xml:"UUID"`
+	Name    string     `xml:"Name"`
+	Times   xmlTimes   `xml:"Times"`
+	Entries []xmlEntry `xml:"Entry"`
+	Groups  []xmlGroup `xml:"Group"`
+}
+
+type xmlEntry struct {
+	UUID    string      `xml:"UUID"`
+	Tags    string      `xml:"Tags,omitempty"`
+	Times   xmlTimes    `xml:"Times"`
+	Strings []xmlString `xml:"String"`
+}
+
+type xmlTimes struct {
+	CreationTime         string `xml:"CreationTime"`
+	LastModificationTime string `xml:"LastModificationTime"`
+	LastAccessTime       string `xml:"LastAccessTime"`
+	Expires              string `xml:"Expires"`
+}
+
+type xmlString struct {
+	Key   string   `xml:"Key"`
+	Value xmlValue `xml:"Value"`
+}
+
+type xmlValue struct {
+	Protected string `xml:"Protected,attr,omitempty"`
+	Value     string `xml:",chardata"`
+}
+
+func times(created, modified time.Time) xmlTimes {
+	if modified.IsZero() {
+		modified = created
+	}
+	return xmlTimes{
+		CreationTime:         created.UTC().Format(timeLayout),
+		LastModificationTime: modified.UTC().Format(timeLayout),
+		LastAccessTime:       modified.UTC().Format(timeLayout),
+		Expires:              "False",
+	}
+}
+
+func newUUID() (string, error) {
+	var id [16]byte
+	if _, err := io.ReadFull(rand.Reader, id[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(id[:]), nil
+}
+
+// document builds the database xml with the protected values encrypted by
+// the inner stream
+func document(db Database, headerHash, streamKey []byte) ([]byte, error) {
+	now := time.Now()
+	rootID, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	root := xmlGroup{UUID: rootID, Name: db.Name, Times: times(now, now)}
+	var protected []*xmlValue
+	for _, g := range db.Groups {
+		id, err := newUUID()
+		if err != nil {
+			return nil, err
+		}
+		group := xmlGroup{UUID: id, Name: g.Name, Times: times(now, now)}
+		for _, e := range g.Entries {
+			id, err := newUUID()
+			if err != nil {
+				return nil, err
+			}
+			entry := xmlEntry{
+				UUID:  id,
+				Tags:  e.Tags,
+				Times: times(e.Created, e.Modified),
+				Strings: []xmlString{
+					{Key: "Title", Value: xmlValue{Value: e.Title}},
+					{Key: "UserName", Value: xmlValue{Value: e.UserName}},
+					{Key: "Password", Value: xmlValue{Protected: "True", Value: e.Password}},
+					{Key: "URL", Value: xmlValue{Value: e.URL}},
+					{Key: "Notes", Value: xmlValue{Value: e.Notes}},
+				},
+			}
+			if e.OTP != "" {
+				entry.Strings = append(entry.Strings, xmlString{Key: "otp", Value: xmlValue{Protected: "True", Value: e.OTP}})
+			}
+			group.Entries = append(group.Entries, entry)
+		}
+		root.Groups = append(root.Groups, group)
+	}
+	// the inner stream is consumed in document order
+	for g := range root.Groups {
+		for e := range root.Groups[g].Entries {
+			for s := range root.Groups[g].Entries[e].Strings {
+				if v := &root.Groups[g].Entries[e].Strings[s].Value; v.Protected != "" {
+					protected = append(protected, v)
+				}
+			}
+		}
+	}
+	protect(protected, streamKey)
+
+	file := xmlFile{
+		Meta: xmlMeta{
+			Generator:    "sherlock",
+			HeaderHash:   base64.StdEncoding.EncodeToString(headerHash),
+			DatabaseName: db.Name,
+		},
+		Root: xmlRoot{Group: root},
+	}
+	b, err := xml.MarshalIndent(file, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// protect xors the values with one continuous Salsa20 key stream and
+// replaces them by their base64 encoding
+func protect(values []*xmlValue, streamKey []byte) {
+	var size int
+	for _, v := range values {
+		size += len(v.Value)
+	}
+	stream := keyStream(streamKey, size)
+	for _, v := range values {
+		b := []byte(v.Value)
+		for i := range b {
+			b[i] ^= stream[i]
+		}
+		stream = stream[len(b):]
+		v.Value = base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+func keyStream(streamKey []byte, size int) []byte {
+	key := sha256.Sum256(streamKey)
+	stream := make([]byte, size)
+	salsa20.XORKeyStream(stream, stream, salsa20Nonce, &key)
+	return stream
+}
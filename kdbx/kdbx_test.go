@@ -0,0 +1,153 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// open decrypts a database written by Write and returns its header, xml and
+// the decrypted protected values in document order
+func open(t *testing.T, b []byte, passphrase string) ([]byte, []byte, []string) {
+	t.Helper()
+	r := bytes.NewReader(b)
+	var sig1, sig2 uint32
+	var minor, major uint16
+	for _, v := range []interface{}{&sig1, &sig2, &minor, &major} {
+		_ = binary.Read(r, binary.LittleEndian, v)
+	}
+	if sig1 != signature1 || sig2 != signature2 || major != 3 || minor != 1 {
+		t.Fatalf("kdbx header: want: KDBX 3.1, have: %x %x %d.%d", sig1, sig2, major, minor)
+	}
+	fields := make(map[byte][]byte)
+	for {
+		id, _ := r.ReadByte()
+		var size uint16
+		_ = binary.Read(r, binary.LittleEndian, &size)
+		data := make([]byte, size)
+		_, _ = r.Read(data)
+		fields[id] = data
+		if id == fieldEnd {
+			break
+		}
+	}
+	header := b[:len(b)-r.Len()]
+	if !bytes.Equal(fields[fieldCipherID], cipherAES) {
+		t.Fatalf("kdbx cipher: want: AES, have: %x", fields[fieldCipherID])
+	}
+
+	rounds := binary.LittleEndian.Uint64(fields[fieldTransformRounds])
+	key, _ := masterKey(passphrase, fields[fieldMasterSeed], fields[fieldTransformSeed], rounds)
+	block, _ := aes.NewCipher(key)
+	payload := make([]byte, r.Len())
+	_, _ = r.Read(payload)
+	cipher.NewCBCDecrypter(block, fields[fieldEncryptionIV]).CryptBlocks(payload, payload)
+	payload = payload[:len(payload)-int(payload[len(payload)-1])]
+	if !bytes.HasPrefix(payload, fields[fieldStreamStartBytes]) {
+		t.Fatalf("kdbx payload: want: stream start bytes, have: wrong key")
+	}
+
+	var compressed []byte
+	blocks := bytes.NewReader(payload[32:])
+	for {
+		var index uint32
+		var hash [32]byte
+		var size int32
+		_ = binary.Read(blocks, binary.LittleEndian, &index)
+		_, _ = blocks.Read(hash[:])
+		_ = binary.Read(blocks, binary.LittleEndian, &size)
+		if size == 0 {
+			break
+		}
+		data := make([]byte, size)
+		_, _ = blocks.Read(data)
+		if sha256.Sum256(data) != hash {
+			t.Fatalf("kdbx block %d: want: valid hash, have: mismatch", index)
+		}
+		compressed = append(compressed, data...)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("kdbx gzip: want: nil, have: %v", err)
+	}
+	doc, _ := ioutil.ReadAll(gz)
+
+	var values []string
+	var stream []byte
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Value" || len(start.Attr) == 0 {
+			continue
+		}
+		var v string
+		_ = dec.DecodeElement(&v, &start)
+		raw, _ := base64.StdEncoding.DecodeString(v)
+		if stream == nil {
+			stream = keyStream(fields[fieldProtectedStreamKey], 4096)
+		}
+		for i := range raw {
+			raw[i] ^= stream[i]
+		}
+		stream = stream[len(raw):]
+		values = append(values, string(raw))
+	}
+	return header, doc, values
+}
+
+func TestWrite(t *testing.T) {
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	db := Database{
+		Name:   "sherlock",
+		Rounds: 1000,
+		Groups: []Group{
+			{Name: "default", Entries: []Entry{
+				{Title: "github", UserName: "octocat", Password: "s3cr3t<&>", URL: "https://github.com", OTP: "otpauth://totp/github?secret=JBSWY3DPEHPK3PXP", Created: created},
+				{Title: "mail", Password: "pässwörd", Notes: "recovery: 1234", Tags: "private", Created: created, Modified: created.Add(time.Hour)},
+			}},
+			{Name: "work"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, db, "correct horse"); err != nil {
+		t.Fatalf("kdbx.Write: want: nil, have: %v", err)
+	}
+	header, doc, values := open(t, buf.Bytes(), "correct horse")
+
+	want := []string{"s3cr3t<&>", "otpauth://totp/github?secret=JBSWY3DPEHPK3PXP", "pässwörd"}
+	if len(values) != len(want) {
+		t.Fatalf("kdbx protected values: want: %q, have: %q", want, values)
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Fatalf("kdbx protected value %d: want: %q, have: %q", i, want[i], values[i])
+		}
+	}
+	if bytes.Contains(doc, []byte("s3cr3t")) {
+		t.Fatalf("kdbx xml: want: protected password, have: plain text")
+	}
+	hash := sha256.Sum256(header)
+	for _, s := range []string{"<Name>default</Name>", "<Name>work</Name>", "<Tags>private</Tags>", "octocat", "recovery: 1234",
+		"<LastModificationTime>2026-10-01T09:00:00Z</LastModificationTime>", base64.StdEncoding.EncodeToString(hash[:])} {
+		if !bytes.Contains(doc, []byte(s)) {
+			t.Fatalf("kdbx xml: want: %s, have: %s", s, doc)
+		}
+	}
+
+	if err := Write(&buf, db, ""); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Fatalf("kdbx.Write(empty passphrase): want: %v, have: %v", ErrEmptyPassphrase, err)
+	}
+}
@@ -1,18 +1,21 @@
 package main
 
 import (
+	"os"
+
 	"github.com/KonstantinGasser/sherlock/cmd"
-	"github.com/KonstantinGasser/sherlock/fs"
 	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/storage"
 	"github.com/KonstantinGasser/sherlock/terminal"
-	"github.com/spf13/afero"
 )
 
 func main() {
-	fileSystem := fs.New(afero.NewOsFs())
-	sherlock := internal.NewSherlock(fileSystem)
-
-	if err := cmd.RootCmd(sherlock).Execute(); err != nil {
+	fileSystem, err := storage.Open()
+	if err != nil {
 		terminal.Error("%s", err)
+		os.Exit(cmd.ExitFailure)
 	}
+	sherlock := internal.NewSherlock(fileSystem)
+
+	os.Exit(cmd.Execute(sherlock))
 }
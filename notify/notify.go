@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// AppName is the name notifications are shown under
+const AppName = "sherlock"
+
+var (
+	ErrUnsupported = fmt.Errorf("desktop notifications are not supported on this platform")
+)
+
+// appleScriptEscaper escapes a string for use in an AppleScript string literal
+var appleScriptEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// command returns the program and its arguments showing a notification on
+// the given platform => notify-send on linux/bsd and osascript on macOS
+func command(goos, title, message string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf(`display notification "%s" with title "%s"`,
+			appleScriptEscaper.Replace(message), appleScriptEscaper.Replace(title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=" + AppName, title, message}, nil
+	default:
+		return "", nil, ErrUnsupported
+	}
+}
+
+// Send shows a desktop notification with the platform's notification
+// service. The message must never hold a secret since notifications
+// are often kept in a history by the desktop
+func Send(ctx context.Context, title, message string) error {
+	name, args, err := command(runtime.GOOS, title, message)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tt := []struct {
+		goos string
+		name string
+		args []string
+		err  error
+	}{
+		{
+			goos: "linux",
+			name: "notify-send",
+			args: []string{"--app-name=sherlock", `clipboard "cleared"`, `it's\gone`},
+		},
+		{
+			goos: "darwin",
+			name: "osascript",
+			args: []string{"-e", `display notification "it's\\gone" with title "clipboard \"cleared\""`},
+		},
+		{
+			goos: "windows",
+			err:  ErrUnsupported,
+		},
+	}
+
+	for _, tc := range tt {
+		name, args, err := command(tc.goos, `clipboard "cleared"`, `it's\gone`)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("notify.command(%s): want: %v, have: %v", tc.goos, tc.err, err)
+		}
+		if name != tc.name || !reflect.DeepEqual(args, tc.args) {
+			t.Fatalf("notify.command(%s): want: %s %q, have: %s %q", tc.goos, tc.name, tc.args, name, args)
+		}
+	}
+}
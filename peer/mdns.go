@@ -0,0 +1,392 @@
+package peer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Service is the DNS-SD service type sherlock instances advertise
+const Service = "_sherlock._tcp.local."
+
+const (
+	mdnsPort = 5353
+	// maxLabel is the maximum length of a DNS label
+	maxLabel = 63
+	// recordTTL of the advertised records in seconds
+	recordTTL = 120
+
+	typeA   = 1
+	typePTR = 12
+	typeTXT = 16
+	typeSRV = 33
+	typeANY = 255
+	classIN = 1
+	// cacheFlush marks records only this instance answers for (RFC 6762 10.2)
+	cacheFlush = 0x8000
+	// unicastResponse is the QU bit of a question (RFC 6762 5.4)
+	unicastResponse = 0x8000
+
+	flagResponse      = 0x8000
+	flagAuthoritative = 0x0400
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: mdnsPort}
+
+var ErrMalformedMessage = fmt.Errorf("malformed mDNS message")
+
+// Peer is a sherlock instance found on the local network
+type Peer struct {
+	Name string
+	// Addr is the host:port the peer listens on
+	Addr string
+}
+
+type question struct {
+	name  string
+	qtype uint16
+	class uint16
+}
+
+// record is a resource record. Only the fields of its type are set
+type record struct {
+	name   string
+	rtype  uint16
+	class  uint16
+	target string // PTR, SRV
+	port   uint16 // SRV
+	ip     net.IP // A
+	txt    []string
+}
+
+type message struct {
+	id        uint16
+	flags     uint16
+	questions []question
+	records   []record
+}
+
+// InstanceName turns a host name into a DNS label naming the instance
+func InstanceName(hostname string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '.' || r < ' ' {
+			return '-'
+		}
+		return r
+	}, hostname)
+	if len(name) > maxLabel {
+		name = name[:maxLabel]
+	}
+	if name == "" {
+		name = "sherlock"
+	}
+	return name
+}
+
+// Advertise answers mDNS queries for the Service with the instance listening on
+// port until ctx is done
+func Advertise(ctx context.Context, name string, port int) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		reply, unicast := answer(buf[:n], InstanceName(name), port, localIPs())
+		if reply == nil {
+			continue
+		}
+		// legacy queriers (not sending from 5353) and QU questions get a unicast reply
+		dst := mdnsGroup
+		if unicast || src.Port != mdnsPort {
+			dst = src
+		}
+		_, _ = conn.WriteToUDP(reply, dst)
+	}
+}
+
+// answer returns the response to a query asking for the Service or nil. unicast
+// reports whether the query asked for a unicast response
+func answer(query []byte, name string, port int, ips []net.IP) ([]byte, bool) {
+	m, err := parseMessage(query)
+	if err != nil || m.flags&flagResponse != 0 {
+		return nil, false
+	}
+	for _, q := range m.questions {
+		qtype := q.qtype
+		if !strings.EqualFold(q.name, Service) || (qtype != typePTR && qtype != typeANY) {
+			continue
+		}
+		instance := name + "." + Service
+		host := name + ".local."
+		answers := []record{
+			{name: Service, rtype: typePTR, class: classIN, target: instance},
+			{name: instance, rtype: typeSRV, class: classIN | cacheFlush, target: host, port: uint16(port)},
+			{name: instance, rtype: typeTXT, class: classIN | cacheFlush, txt: []string{fmt.Sprintf("v=%d", protocolVersion)}},
+		}
+		for _, ip := range ips {
+			answers = append(answers, record{name: host, rtype: typeA, class: classIN | cacheFlush, ip: ip})
+		}
+		return buildMessage(m.id, flagResponse|flagAuthoritative, m.questions, answers), q.class&unicastResponse != 0
+	}
+	return nil, false
+}
+
+// Browse queries the local network for sherlock instances and collects the
+// answers for the duration of wait
+func Browse(ctx context.Context, wait time.Duration) ([]Peer, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	query := buildMessage(binary.BigEndian.Uint16(id[:]), 0, []question{{name: Service, qtype: typePTR, class: classIN}}, nil)
+	if _, err := conn.WriteToUDP(query, mdnsGroup); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]Peer)
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if e, ok := err.(net.Error); ok && e.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		for _, p := range peersOf(buf[:n], src.IP) {
+			found[p.Name] = p
+		}
+	}
+	peers := make([]Peer, 0, len(found))
+	for _, p := range found {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers, nil
+}
+
+// peersOf returns the instances announced by a response. They are addressed by
+// the source of the response which is reachable from this host
+func peersOf(response []byte, src net.IP) []Peer {
+	m, err := parseMessage(response)
+	if err != nil || m.flags&flagResponse == 0 {
+		return nil
+	}
+	ports := make(map[string]uint16)
+	for _, r := range m.records {
+		if r.rtype == typeSRV {
+			ports[strings.ToLower(r.name)] = r.port
+		}
+	}
+	var peers []Peer
+	for _, r := range m.records {
+		if r.rtype != typePTR || !strings.EqualFold(r.name, Service) {
+			continue
+		}
+		port, ok := ports[strings.ToLower(r.target)]
+		if !ok {
+			continue
+		}
+		peers = append(peers, Peer{
+			Name: strings.TrimSuffix(r.target, "."+Service),
+			Addr: net.JoinHostPort(src.String(), fmt.Sprint(port)),
+		})
+	}
+	return peers
+}
+
+// localIPs returns the IPv4 addresses of the host other than loopback
+func localIPs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			ips = append(ips, ipnet.IP.To4())
+		}
+	}
+	return ips
+}
+
+func buildMessage(id, flags uint16, questions []question, answers []record) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[0:], id)
+	binary.BigEndian.PutUint16(b[2:], flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(answers)))
+	for _, q := range questions {
+		b = appendName(b, q.name)
+		b = appendUint16(b, q.qtype)
+		b = appendUint16(b, q.class)
+	}
+	for _, r := range answers {
+		b = appendName(b, r.name)
+		b = appendUint16(b, r.rtype)
+		b = appendUint16(b, r.class)
+		b = append(b, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], recordTTL)
+
+		var data []byte
+		switch r.rtype {
+		case typePTR:
+			data = appendName(nil, r.target)
+		case typeSRV:
+			data = appendUint16(appendUint16(appendUint16(nil, 0), 0), r.port)
+			data = appendName(data, r.target)
+		case typeTXT:
+			for _, s := range r.txt {
+				data = append(append(data, byte(len(s))), s...)
+			}
+		case typeA:
+			data = append(data, r.ip.To4()...)
+		}
+		b = appendUint16(b, uint16(len(data)))
+		b = append(b, data...)
+	}
+	return b
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func parseMessage(b []byte) (message, error) {
+	var m message
+	if len(b) < 12 {
+		return m, ErrMalformedMessage
+	}
+	m.id = binary.BigEndian.Uint16(b[0:])
+	m.flags = binary.BigEndian.Uint16(b[2:])
+	qdcount := int(binary.BigEndian.Uint16(b[4:]))
+	rrcount := int(binary.BigEndian.Uint16(b[6:])) + int(binary.BigEndian.Uint16(b[8:])) + int(binary.BigEndian.Uint16(b[10:]))
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, next, err := readName(b, off)
+		if err != nil || next+4 > len(b) {
+			return m, ErrMalformedMessage
+		}
+		m.questions = append(m.questions, question{
+			name:  name,
+			qtype: binary.BigEndian.Uint16(b[next:]),
+			class: binary.BigEndian.Uint16(b[next+2:]),
+		})
+		off = next + 4
+	}
+	for i := 0; i < rrcount; i++ {
+		name, next, err := readName(b, off)
+		if err != nil || next+10 > len(b) {
+			return m, ErrMalformedMessage
+		}
+		r := record{
+			name:  name,
+			rtype: binary.BigEndian.Uint16(b[next:]),
+			class: binary.BigEndian.Uint16(b[next+2:]),
+		}
+		length := int(binary.BigEndian.Uint16(b[next+8:]))
+		data := next + 10
+		if data+length > len(b) {
+			return m, ErrMalformedMessage
+		}
+		switch r.rtype {
+		case typePTR:
+			if r.target, _, err = readName(b, data); err != nil {
+				return m, err
+			}
+		case typeSRV:
+			if length < 7 {
+				return m, ErrMalformedMessage
+			}
+			r.port = binary.BigEndian.Uint16(b[data+4:])
+			if r.target, _, err = readName(b, data+6); err != nil {
+				return m, err
+			}
+		case typeA:
+			if length == 4 {
+				r.ip = net.IP(append([]byte(nil), b[data:data+4]...))
+			}
+		}
+		m.records = append(m.records, r)
+		off = data + length
+	}
+	return m, nil
+}
+
+// readName reads the (possibly compressed) name at off and returns it with the
+// offset following it
+func readName(b []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(b) {
+			return "", 0, ErrMalformedMessage
+		}
+		length := int(b[off])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xC0 == 0xC0:
+			if off+1 >= len(b) || jumps > 16 {
+				return "", 0, ErrMalformedMessage
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3FFF)
+			jumps++
+		default:
+			if off+1+length > len(b) {
+				return "", 0, ErrMalformedMessage
+			}
+			labels = append(labels, string(b[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
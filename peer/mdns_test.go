@@ -0,0 +1,59 @@
+package peer
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestAnswer(t *testing.T) {
+	query := buildMessage(42, 0, []question{{name: Service, qtype: typePTR, class: classIN}}, nil)
+	response, unicast := answer(query, "laptop", 4242, []net.IP{net.IPv4(192, 168, 1, 20)})
+	if response == nil || unicast {
+		t.Fatalf("answer: want: multicast response, have: %v, %v", response, unicast)
+	}
+	m, err := parseMessage(response)
+	if err != nil {
+		t.Fatalf("parseMessage: want: nil, have: %v", err)
+	}
+	if m.id != 42 || len(m.records) != 4 {
+		t.Fatalf("parseMessage: want: id 42 with 4 records, have: %+v", m)
+	}
+	expect := []Peer{{Name: "laptop", Addr: "192.168.1.20:4242"}}
+	if peers := peersOf(response, net.IPv4(192, 168, 1, 20)); !reflect.DeepEqual(peers, expect) {
+		t.Fatalf("peersOf: want: %v, have: %v", expect, peers)
+	}
+
+	other := buildMessage(1, 0, []question{{name: "_http._tcp.local.", qtype: typePTR, class: classIN}}, nil)
+	if response, _ := answer(other, "laptop", 4242, nil); response != nil {
+		t.Fatalf("answer: want: no response to other services, have: %v", response)
+	}
+	qu := buildMessage(1, 0, []question{{name: Service, qtype: typePTR, class: classIN | unicastResponse}}, nil)
+	if _, unicast := answer(qu, "laptop", 4242, nil); !unicast {
+		t.Fatalf("answer: want: unicast response to QU question, have: multicast")
+	}
+}
+
+func TestReadName(t *testing.T) {
+	// "local." at 12 followed by "_sherlock._tcp" pointing to it
+	b := make([]byte, 12)
+	b = appendName(b, "local.")
+	ptr := len(b)
+	b = append(b, 9)
+	b = append(b, "_sherlock"...)
+	b = append(b, 4)
+	b = append(b, "_tcp"...)
+	b = append(b, 0xC0, 12)
+	name, next, err := readName(b, ptr)
+	if err != nil || name != Service || next != len(b) {
+		t.Fatalf("readName: want: %s %d, have: %s %d %v", Service, len(b), name, next, err)
+	}
+	// a pointer to itself must not loop forever
+	loop := append(make([]byte, 12), 0xC0, 12)
+	if _, _, err := readName(loop, 12); err == nil {
+		t.Fatalf("readName: want: error for a pointer loop, have: nil")
+	}
+	if name := InstanceName("laptop.fritz.box"); name != "laptop-fritz-box" {
+		t.Fatalf("InstanceName: want: laptop-fritz-box, have: %s", name)
+	}
+}
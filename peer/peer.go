@@ -0,0 +1,400 @@
+// Package peer syncs the encrypted group vaults of two sherlock instances on the
+// local network without any remote storage. Instances are discovered with mDNS
+// and pair with a one-time code shown by the listening instance. Every message
+// is authenticated with a key derived from the code; the vaults stay encrypted
+// with their group keys
+package peer
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	protocolVersion = 1
+	// maxFrameSize limits a single message holding the transferred vaults
+	maxFrameSize = 64 << 20
+	nonceSize    = 32
+	macSize      = sha256.Size
+	// codeLength is the number of characters of a pairing code (~50 bits)
+	codeLength = 10
+	// offlineCodeLength is the number of characters of a code which alone
+	// protects data that can be attacked offline (~99 bits)
+	offlineCodeLength = 20
+	// codeGroupSize is the number of characters between two dashes of a code
+	codeGroupSize = 5
+	// codeAlphabet leaves out characters easily confused like 0/o and 1/l
+	codeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+	roleInitiator = 'i'
+	roleResponder = 'r'
+)
+
+var (
+	ErrPairing  = fmt.Errorf("pairing failed: wrong code or the peer is no sherlock instance")
+	ErrProtocol = fmt.Errorf("peer protocol error")
+)
+
+// Vault is a still encrypted group vault
+type Vault struct {
+	Group    string `json:"group"`
+	Revision string `json:"revision"`
+	// Base is the revision of the group after its last sync with any peer.
+	// A Revision other than Base means the group changed since
+	Base     string    `json:"base,omitempty"`
+	Modified time.Time `json:"modified"`
+	Data     []byte    `json:"-"`
+}
+
+// NewVault describes the vault of a group
+func NewVault(group string, data []byte, base string, modified time.Time) Vault {
+	return Vault{Group: group, Revision: Revision(data), Base: base, Modified: modified, Data: data}
+}
+
+// Revision identifies the content of a vault
+func Revision(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Plan decides which vault of a group both peers keep
+type Plan struct {
+	Send      []string
+	Receive   []string
+	InSync    []string
+	Conflicts []string
+}
+
+// NewPlan compares the local with the remote vaults. A group only changed on
+// one side since the last sync takes the changed vault. If neither side changed
+// it (the peers synced with others), the newer vault is kept. Groups changed on
+// both sides are conflicts unless newest resolves them by the newer vault. Both
+// peers come to the same plan with local and remote swapped
+func NewPlan(local, remote []Vault, newest bool) Plan {
+	remotes := make(map[string]Vault, len(remote))
+	for _, v := range remote {
+		remotes[v.Group] = v
+	}
+	var plan Plan
+	seen := make(map[string]bool, len(local))
+	for _, l := range local {
+		seen[l.Group] = true
+		r, ok := remotes[l.Group]
+		if !ok {
+			plan.Send = append(plan.Send, l.Group)
+			continue
+		}
+		if l.Revision == r.Revision {
+			plan.InSync = append(plan.InSync, l.Group)
+			continue
+		}
+		localChanged, remoteChanged := l.Revision != l.Base, r.Revision != r.Base
+		switch {
+		case localChanged && !remoteChanged:
+			plan.Send = append(plan.Send, l.Group)
+		case remoteChanged && !localChanged:
+			plan.Receive = append(plan.Receive, l.Group)
+		case (!localChanged || newest) && l.Modified.After(r.Modified):
+			plan.Send = append(plan.Send, l.Group)
+		case (!localChanged || newest) && r.Modified.After(l.Modified):
+			plan.Receive = append(plan.Receive, l.Group)
+		default:
+			plan.Conflicts = append(plan.Conflicts, l.Group)
+		}
+	}
+	for _, r := range remote {
+		if !seen[r.Group] {
+			plan.Receive = append(plan.Receive, r.Group)
+		}
+	}
+	for _, groups := range [][]string{plan.Send, plan.Receive, plan.InSync, plan.Conflicts} {
+		sort.Strings(groups)
+	}
+	return plan
+}
+
+// Result of a sync. Received holds the vaults to store locally; Revisions the
+// revision of every group both peers hold after the sync
+type Result struct {
+	Plan
+	Received  []Vault
+	Revisions map[string]string
+}
+
+// NewCode returns a random one-time pairing code like 7kq2m-xr4td
+func NewCode() (string, error) {
+	return newCode(codeLength)
+}
+
+// NewOfflineCode returns a random one-time code like 7kq2m-xr4td-9hb3e-pw6nz.
+// It is long enough to be the only protection of data which can be attacked
+// offline, e.g. encrypted QR codes anyone may have photographed
+func NewOfflineCode() (string, error) {
+	return newCode(offlineCodeLength)
+}
+
+func newCode(length int) (string, error) {
+	max := big.NewInt(int64(len(codeAlphabet)))
+	var code strings.Builder
+	for i := 0; i < length; i++ {
+		if i > 0 && i%codeGroupSize == 0 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// NormalizeCode ignores case, spaces and dashes of a typed code. Codes are
+// normalized before they key anything so the receiver may type them any way
+func NormalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+type hello struct {
+	Version int    `json:"version"`
+	Nonce   []byte `json:"nonce"`
+}
+
+type proof struct {
+	Nonce []byte `json:"nonce,omitempty"`
+	Proof []byte `json:"proof"`
+}
+
+type manifest struct {
+	Vaults []Vault `json:"vaults"`
+	Newest bool    `json:"newest"`
+}
+
+type transfer struct {
+	Vaults map[string][]byte `json:"vaults"`
+}
+
+// session frames the messages of a connection. Once paired, every message is
+// authenticated with the session key, its direction and sequence number
+type session struct {
+	rw       io.ReadWriter
+	role     byte
+	key      []byte
+	sent     uint64
+	received uint64
+}
+
+func (s *session) mac(role byte, seq uint64, payload []byte) []byte {
+	m := hmac.New(sha256.New, s.key)
+	var header [9]byte
+	header[0] = role
+	binary.BigEndian.PutUint64(header[1:], seq)
+	m.Write(header[:])
+	m.Write(payload)
+	return m.Sum(nil)
+}
+
+func (s *session) send(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4, 4+len(payload)+macSize)
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	if s.key != nil {
+		frame = append(frame, s.mac(s.role, s.sent, payload)...)
+		s.sent++
+	}
+	_, err = s.rw.Write(frame)
+	return err
+}
+
+func (s *session) receive(v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(s.rw, size[:]); err != nil {
+		return fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrameSize {
+		return fmt.Errorf("%w: message of %d bytes too large", ErrProtocol, n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(s.rw, payload); err != nil {
+		return fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	if s.key != nil {
+		mac := make([]byte, macSize)
+		if _, err := io.ReadFull(s.rw, mac); err != nil {
+			return fmt.Errorf("%w: %v", ErrProtocol, err)
+		}
+		if !hmac.Equal(mac, s.mac(s.remoteRole(), s.received, payload)) {
+			return fmt.Errorf("%w: message not authenticated", ErrProtocol)
+		}
+		s.received++
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	return nil
+}
+
+func (s *session) remoteRole() byte {
+	if s.role == roleInitiator {
+		return roleResponder
+	}
+	return roleInitiator
+}
+
+// codeMAC proves the knowledge of the code bound to both nonces
+func codeMAC(code, label string, initiatorNonce, responderNonce []byte) []byte {
+	m := hmac.New(sha256.New, []byte(NormalizeCode(code)))
+	m.Write([]byte("sherlock-peer " + label))
+	m.Write(initiatorNonce)
+	m.Write(responderNonce)
+	return m.Sum(nil)
+}
+
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	_, err := rand.Read(nonce)
+	return nonce, err
+}
+
+// Connect pairs with the listening peer as the initiator and syncs the vaults.
+// The code is the one shown by the peer
+func Connect(rw io.ReadWriter, code string, local []Vault, newest bool) (*Result, error) {
+	s := &session{rw: rw, role: roleInitiator}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(hello{Version: protocolVersion, Nonce: nonce}); err != nil {
+		return nil, err
+	}
+	var reply proof
+	if err := s.receive(&reply); err != nil {
+		return nil, err
+	}
+	if len(reply.Nonce) != nonceSize || !hmac.Equal(reply.Proof, codeMAC(code, "responder", nonce, reply.Nonce)) {
+		return nil, ErrPairing
+	}
+	if err := s.send(proof{Proof: codeMAC(code, "initiator", nonce, reply.Nonce)}); err != nil {
+		return nil, err
+	}
+	s.key = codeMAC(code, "session", nonce, reply.Nonce)
+	return s.exchange(local, newest)
+}
+
+// Accept pairs with a connecting peer as the responder and syncs the vaults.
+// The code is the one shown to the user. A failed pairing must not be retried
+// with the same code
+func Accept(rw io.ReadWriter, code string, local []Vault, newest bool) (*Result, error) {
+	s := &session{rw: rw, role: roleResponder}
+	var h hello
+	if err := s.receive(&h); err != nil {
+		return nil, err
+	}
+	if h.Version != protocolVersion || len(h.Nonce) != nonceSize {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrProtocol, h.Version)
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(proof{Nonce: nonce, Proof: codeMAC(code, "responder", h.Nonce, nonce)}); err != nil {
+		return nil, err
+	}
+	// a peer with a wrong code hangs up instead of proving it
+	var reply proof
+	if err := s.receive(&reply); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPairing, err)
+	}
+	if !hmac.Equal(reply.Proof, codeMAC(code, "initiator", h.Nonce, nonce)) {
+		return nil, ErrPairing
+	}
+	s.key = codeMAC(code, "session", h.Nonce, nonce)
+	return s.exchange(local, newest)
+}
+
+// exchange swaps the manifests, plans the sync and transfers the vaults. The
+// initiator always sends first
+func (s *session) exchange(local []Vault, newest bool) (*Result, error) {
+	var remote manifest
+	ours := manifest{Vaults: local, Newest: newest}
+	if err := s.inTurn(ours, &remote); err != nil {
+		return nil, err
+	}
+	for _, v := range remote.Vaults {
+		if !validGroup(v.Group) {
+			return nil, fmt.Errorf("%w: invalid group %q", ErrProtocol, v.Group)
+		}
+	}
+	plan := NewPlan(local, remote.Vaults, newest || remote.Newest)
+
+	locals := make(map[string]Vault, len(local))
+	for _, v := range local {
+		locals[v.Group] = v
+	}
+	remotes := make(map[string]Vault, len(remote.Vaults))
+	for _, v := range remote.Vaults {
+		remotes[v.Group] = v
+	}
+	out := transfer{Vaults: make(map[string][]byte, len(plan.Send))}
+	for _, gid := range plan.Send {
+		out.Vaults[gid] = locals[gid].Data
+	}
+	var in transfer
+	if err := s.inTurn(out, &in); err != nil {
+		return nil, err
+	}
+	if len(in.Vaults) != len(plan.Receive) {
+		return nil, fmt.Errorf("%w: received %d vaults, expected %d", ErrProtocol, len(in.Vaults), len(plan.Receive))
+	}
+
+	result := &Result{Plan: plan, Revisions: make(map[string]string)}
+	for _, gid := range plan.Receive {
+		data, ok := in.Vaults[gid]
+		announced := remotes[gid]
+		if !ok || Revision(data) != announced.Revision {
+			return nil, fmt.Errorf("%w: vault of %q does not match its revision", ErrProtocol, gid)
+		}
+		announced.Data = data
+		result.Received = append(result.Received, announced)
+		result.Revisions[gid] = announced.Revision
+	}
+	for _, gid := range append(plan.Send, plan.InSync...) {
+		result.Revisions[gid] = locals[gid].Revision
+	}
+	return result, nil
+}
+
+// inTurn sends and receives a message; the initiator sends first so neither
+// side blocks on an unbuffered connection
+func (s *session) inTurn(out, in interface{}) error {
+	if s.role == roleInitiator {
+		if err := s.send(out); err != nil {
+			return err
+		}
+		return s.receive(in)
+	}
+	if err := s.receive(in); err != nil {
+		return err
+	}
+	return s.send(out)
+}
+
+// validGroup rejects group names which would leave the groups directory
+func validGroup(gid string) bool {
+	return gid != "" && gid != "." && gid != ".." && !strings.ContainsAny(gid, `/\`)
+}
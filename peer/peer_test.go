@@ -0,0 +1,167 @@
+package peer
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/security"
+)
+
+func vault(group, data, base string, modified time.Time) Vault {
+	v := NewVault(group, []byte(data), "", modified)
+	if base != "" {
+		v.Base = Revision([]byte(base))
+	}
+	return v
+}
+
+func TestPlan(t *testing.T) {
+	older := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	local := []Vault{
+		vault("same", "a", "a", older),
+		vault("local-only", "a", "", older),
+		vault("changed-here", "b", "a", older),
+		vault("changed-there", "a", "a", newer),
+		vault("both", "b", "a", newer),
+		vault("synced-elsewhere", "a", "a", older),
+	}
+	remote := []Vault{
+		vault("same", "a", "a", older),
+		vault("remote-only", "a", "", older),
+		vault("changed-here", "a", "a", newer),
+		vault("changed-there", "c", "a", older),
+		vault("both", "c", "a", older),
+		vault("synced-elsewhere", "b", "b", newer),
+	}
+	expect := Plan{
+		Send:      []string{"changed-here", "local-only"},
+		Receive:   []string{"changed-there", "remote-only", "synced-elsewhere"},
+		InSync:    []string{"same"},
+		Conflicts: []string{"both"},
+	}
+	if plan := NewPlan(local, remote, false); !reflect.DeepEqual(plan, expect) {
+		t.Fatalf("peer.NewPlan: want: %+v, have: %+v", expect, plan)
+	}
+	// the peer comes to the mirrored plan
+	mirrored := Plan{Send: expect.Receive, Receive: expect.Send, InSync: expect.InSync, Conflicts: expect.Conflicts}
+	if plan := NewPlan(remote, local, false); !reflect.DeepEqual(plan, mirrored) {
+		t.Fatalf("peer.NewPlan (peer): want: %+v, have: %+v", mirrored, plan)
+	}
+	if plan := NewPlan(local, remote, true); len(plan.Conflicts) != 0 || plan.Send[0] != "both" {
+		t.Fatalf("peer.NewPlan (newest): want: both sent, have: %+v", plan)
+	}
+}
+
+// pair runs Connect and Accept over an in-memory connection
+func pair(initiatorCode, responderCode string, initiator, responder []Vault) (*Result, *Result, error, error) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	type outcome struct {
+		result *Result
+		err    error
+	}
+	accepted := make(chan outcome, 1)
+	go func() {
+		result, err := Accept(b, responderCode, responder, false)
+		b.Close()
+		accepted <- outcome{result, err}
+	}()
+	result, err := Connect(a, initiatorCode, initiator, false)
+	a.Close()
+	o := <-accepted
+	return result, o.result, err, o.err
+}
+
+func TestSync(t *testing.T) {
+	code, err := NewCode()
+	if err != nil {
+		t.Fatalf("peer.NewCode: want: nil, have: %v", err)
+	}
+	now := time.Now()
+	laptop := []Vault{vault("default", "laptop-default", "", now), vault("work", "work", "", now)}
+	desktop := []Vault{vault("default", "desktop-default", "", now), vault("home", "home", "", now)}
+
+	// codes are typed case and dash insensitive
+	typed := NormalizeCode(code)
+	fromLaptop, fromDesktop, err, acceptErr := pair(typed, code, laptop, desktop)
+	if err != nil || acceptErr != nil {
+		t.Fatalf("peer.Connect/Accept: want: nil, have: %v, %v", err, acceptErr)
+	}
+	if len(fromLaptop.Received) != 1 || string(fromLaptop.Received[0].Data) != "home" {
+		t.Fatalf("peer.Connect: want: home received, have: %+v", fromLaptop.Received)
+	}
+	if len(fromDesktop.Received) != 1 || string(fromDesktop.Received[0].Data) != "work" {
+		t.Fatalf("peer.Accept: want: work received, have: %+v", fromDesktop.Received)
+	}
+	if want := []string{"default"}; !reflect.DeepEqual(fromLaptop.Conflicts, want) {
+		t.Fatalf("peer.Connect: want: conflicts %v, have: %v", want, fromLaptop.Conflicts)
+	}
+	if !reflect.DeepEqual(fromLaptop.Revisions, fromDesktop.Revisions) || len(fromLaptop.Revisions) != 2 {
+		t.Fatalf("revisions after sync: want: same for home and work, have: %v and %v", fromLaptop.Revisions, fromDesktop.Revisions)
+	}
+}
+
+func TestNewOfflineCode(t *testing.T) {
+	code, err := NewOfflineCode()
+	if err != nil {
+		t.Fatalf("peer.NewOfflineCode: want: nil, have: %v", err)
+	}
+	// 20 characters of a 31 character alphabet hold more than 80 bits
+	if len(NormalizeCode(code)) != offlineCodeLength || len(code) != offlineCodeLength+3 {
+		t.Fatalf("peer.NewOfflineCode: want: 4 groups of 5 characters, have: %s", code)
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	code, err := NewOfflineCode()
+	if err != nil {
+		t.Fatalf("peer.NewOfflineCode: want: nil, have: %v", err)
+	}
+	sealed, err := security.SealPassphrase([]byte("accounts"), NormalizeCode(code))
+	if err != nil {
+		t.Fatalf("security.SealPassphrase: want: nil, have: %v", err)
+	}
+	// the receiver may type the code without dashes, with spaces or upper case
+	for _, typed := range []string{
+		code,
+		strings.ReplaceAll(code, "-", ""),
+		strings.ReplaceAll(code, "-", " "),
+		strings.ToUpper(code),
+	} {
+		b, err := security.OpenSealed(sealed, NormalizeCode(typed))
+		if err != nil || string(b) != "accounts" {
+			t.Fatalf("security.OpenSealed(%q): want: accounts, have: %q, %v", typed, b, err)
+		}
+	}
+}
+
+func TestPairingFails(t *testing.T) {
+	code, _ := NewCode()
+	other, _ := NewCode()
+	_, _, err, acceptErr := pair(other, code, nil, []Vault{vault("default", "secret", "", time.Now())})
+	if !errors.Is(err, ErrPairing) {
+		t.Fatalf("peer.Connect: want: %v, have: %v", ErrPairing, err)
+	}
+	if !errors.Is(acceptErr, ErrPairing) {
+		t.Fatalf("peer.Accept: want: %v, have: %v", ErrPairing, acceptErr)
+	}
+}
+
+func TestTamperedMessage(t *testing.T) {
+	s := &session{role: roleInitiator, key: []byte("session-key")}
+	payload := []byte(`{"vaults":null}`)
+	mac := s.mac(roleResponder, 0, payload)
+	replayed := s.mac(roleResponder, 1, payload)
+	if reflect.DeepEqual(mac, replayed) {
+		t.Fatalf("session.mac: want: sequence bound mac, have: equal macs")
+	}
+	if reflect.DeepEqual(mac, s.mac(roleInitiator, 0, payload)) {
+		t.Fatalf("session.mac: want: direction bound mac, have: equal macs")
+	}
+}
@@ -0,0 +1,54 @@
+package picker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var ErrUnknownMenu = fmt.Errorf("unknown menu backend (use rofi, dmenu or wofi)")
+
+// Menu describes a dmenu compatible launcher reading candidates from stdin
+type Menu struct {
+	Name string
+	// args to select a candidate
+	args []string
+	// args to read a password. Empty if the menu cannot mask input
+	passwordArgs []string
+}
+
+// Menus are the supported menu backends by name
+var Menus = map[string]Menu{
+	"rofi":  {Name: "rofi", args: []string{"-dmenu", "-i", "-p"}, passwordArgs: []string{"-dmenu", "-password", "-lines", "0", "-p"}},
+	"dmenu": {Name: "dmenu", args: []string{"-i", "-p"}},
+	"wofi":  {Name: "wofi", args: []string{"--dmenu", "--insensitive", "--prompt"}, passwordArgs: []string{"--dmenu", "--password", "--lines", "1", "--prompt"}},
+}
+
+// LookupMenu returns the menu backend by its name
+func LookupMenu(name string) (Menu, error) {
+	menu, ok := Menus[strings.ToLower(name)]
+	if !ok {
+		return Menu{}, fmt.Errorf("%w: %s", ErrUnknownMenu, name)
+	}
+	return menu, nil
+}
+
+// Select lets the user select one of the candidates
+func (m Menu) Select(ctx context.Context, prompt string, candidates []string) (string, error) {
+	return External(ctx, m.Name, append(m.args, prompt), candidates)
+}
+
+// CanReadPassword reports whether the menu can read masked input
+func (m Menu) CanReadPassword() bool {
+	return len(m.passwordArgs) > 0
+}
+
+// ReadPassword reads masked input using the menu
+func (m Menu) ReadPassword(ctx context.Context, prompt string) (string, error) {
+	out, err := exec.CommandContext(ctx, m.Name, append(m.passwordArgs, prompt)...).Output()
+	if err != nil {
+		return "", ErrNoSelection
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
@@ -0,0 +1,147 @@
+package picker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxListed is the number of matches listed by the built-in finder
+const maxListed = 10
+
+var (
+	ErrNoSelection = fmt.Errorf("nothing selected")
+	ErrNoCandidate = fmt.Errorf("nothing to select from")
+)
+
+// Fuzzy ranks the candidates matching the query. A candidate matches if it
+// contains all characters of the query in order (case-insensitive). Matches
+// with consecutive characters and matches starting early rank higher
+func Fuzzy(query string, candidates []string) []string {
+	type match struct {
+		candidate string
+		score     int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if score, ok := fuzzyScore(strings.ToLower(query), strings.ToLower(c)); ok {
+			matches = append(matches, match{candidate: c, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	ranked := make([]string, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.candidate
+	}
+	return ranked
+}
+
+func fuzzyScore(query, candidate string) (int, bool) {
+	q := []rune(query)
+	if len(q) == 0 {
+		return 0, true
+	}
+	var score, at, last = 0, 0, -2
+	for i, r := range []rune(candidate) {
+		if at == len(q) {
+			break
+		}
+		if unicode.IsSpace(q[at]) {
+			at++
+			continue
+		}
+		if r != q[at] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 2
+		}
+		if at == 0 {
+			score -= i
+		}
+		last = i
+		at++
+	}
+	return score, at == len(q)
+}
+
+// External lets an external finder like fzf, rofi or dmenu select a candidate.
+// The candidates are passed line by line through stdin and the selection is
+// read from stdout
+func External(ctx context.Context, name string, args []string, candidates []string) (string, error) {
+	if len(candidates) == 0 {
+		return "", ErrNoCandidate
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(candidates, "\n") + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// finders exit non-zero if the selection is aborted or nothing matched
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return "", ErrNoSelection
+		}
+		return "", err
+	}
+	selected := strings.TrimSpace(stdout.String())
+	if selected == "" {
+		return "", ErrNoSelection
+	}
+	return selected, nil
+}
+
+// Prompt is the built-in finder used if no external finder is available.
+// It reads a query, lists the best matches numbered and reads either the
+// number of a match or a new query. A query matching a single candidate
+// selects it right away
+func Prompt(candidates []string, in io.Reader, out io.Writer) (string, error) {
+	if len(candidates) == 0 {
+		return "", ErrNoCandidate
+	}
+	r := bufio.NewReader(in)
+	var matches []string
+	fmt.Fprint(out, "search: ")
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" && err != nil {
+			return "", ErrNoSelection
+		}
+		if n, convErr := strconv.Atoi(line); convErr == nil && len(matches) > 0 {
+			if n < 1 || n > len(matches) {
+				fmt.Fprintf(out, "select 1-%d or search again: ", len(matches))
+				continue
+			}
+			return matches[n-1], nil
+		}
+		matches = Fuzzy(line, candidates)
+		switch len(matches) {
+		case 0:
+			fmt.Fprint(out, "no match, search again: ")
+			continue
+		case 1:
+			return matches[0], nil
+		}
+		if len(matches) > maxListed {
+			matches = matches[:maxListed]
+		}
+		for i, m := range matches {
+			fmt.Fprintf(out, "%2d) %s\n", i+1, m)
+		}
+		fmt.Fprint(out, "select or search again: ")
+	}
+}
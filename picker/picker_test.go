@@ -0,0 +1,74 @@
+package picker
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var candidates = []string{"default@github", "work@gitlab", "work@jira", "private@netflix"}
+
+func TestFuzzy(t *testing.T) {
+	tt := []struct {
+		query  string
+		expect []string
+	}{
+		{query: "", expect: candidates},
+		{query: "jira", expect: []string{"work@jira"}},
+		{query: "GIT", expect: []string{"work@gitlab", "default@github"}},
+		{query: "wgl", expect: []string{"work@gitlab"}},
+		{query: "work git", expect: []string{"work@gitlab"}},
+		{query: "zzz", expect: []string{}},
+	}
+	for _, tc := range tt {
+		if have := Fuzzy(tc.query, candidates); !reflect.DeepEqual(have, tc.expect) {
+			t.Fatalf("picker.Fuzzy(%q): want: %v, have: %v", tc.query, tc.expect, have)
+		}
+	}
+}
+
+func TestFuzzyRanksConsecutiveMatches(t *testing.T) {
+	have := Fuzzy("lab", []string{"a@l-a-b", "a@gitlab"})
+	if have[0] != "a@gitlab" {
+		t.Fatalf("picker.Fuzzy: want: a@gitlab first, have: %v", have)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect string
+		err    error
+	}{
+		{input: "jira\n", expect: "work@jira"},
+		{input: "git\n2\n", expect: "default@github"},
+		{input: "zzz\nnetflix\n", expect: "private@netflix"},
+		{input: "git\n9\n1\n", expect: "work@gitlab"},
+		{input: "git\n", err: ErrNoSelection},
+	}
+	for _, tc := range tt {
+		have, err := Prompt(candidates, strings.NewReader(tc.input), ioutil.Discard)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("picker.Prompt(%q): want: %v, have: %v", tc.input, tc.err, err)
+		}
+		if have != tc.expect {
+			t.Fatalf("picker.Prompt(%q): want: %s, have: %s", tc.input, tc.expect, have)
+		}
+	}
+}
+
+func TestLookupMenu(t *testing.T) {
+	for _, name := range []string{"rofi", "DMENU", "wofi"} {
+		if _, err := LookupMenu(name); err != nil {
+			t.Fatalf("picker.LookupMenu(%s): want: nil, have: %v", name, err)
+		}
+	}
+	if _, err := LookupMenu("fuzzel"); !errors.Is(err, ErrUnknownMenu) {
+		t.Fatalf("picker.LookupMenu(fuzzel): want: %v, have: %v", ErrUnknownMenu, err)
+	}
+	if menu, _ := LookupMenu("dmenu"); menu.CanReadPassword() {
+		t.Fatalf("picker.Menu(dmenu).CanReadPassword: want: false, have: true")
+	}
+}
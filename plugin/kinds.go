@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/cloud"
+)
+
+// ImportedAccount is an account returned by an importer plugin
+type ImportedAccount struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Username string `json:"username,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Note     string `json:"note,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+// ImportResult is the data of the Response to a KindImport Request
+type ImportResult struct {
+	Accounts []ImportedAccount `json:"accounts"`
+}
+
+// PickRequest is the data of a KindPick Request
+type PickRequest struct {
+	Prompt     string   `json:"prompt"`
+	Candidates []string `json:"candidates"`
+}
+
+// PickResult is the data of the Response to a KindPick Request. An empty
+// Selected means nothing was selected
+type PickResult struct {
+	Selected string `json:"selected"`
+}
+
+// SecretRequest is the data of a KindGet or KindPut Request. Value and
+// Create are only set for KindPut
+type SecretRequest struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Create bool   `json:"create,omitempty"`
+}
+
+// SecretResult is the data of the Response to a KindGet Request
+type SecretResult struct {
+	Found bool   `json:"found"`
+	Value string `json:"value"`
+}
+
+// RotateRequest is the data of a KindRotate Request. Password is the current
+// credential and Generated a new password the provider may set; providers
+// issuing their own credentials (e.g. API tokens) ignore it
+type RotateRequest struct {
+	Account   string `json:"account"`
+	Username  string `json:"username,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Password  string `json:"password"`
+	Generated string `json:"generated"`
+}
+
+// RotateResult is the data of the Response to a KindRotate Request. Password
+// is the credential now valid at the service
+type RotateResult struct {
+	Password string `json:"password"`
+}
+
+// Import calls an importer plugin
+func (p Plugin) Import(ctx context.Context, args []string) ([]ImportedAccount, error) {
+	var result ImportResult
+	if err := p.Call(ctx, KindImport, args, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Accounts, nil
+}
+
+// Pick calls a picker plugin
+func (p Plugin) Pick(ctx context.Context, prompt string, candidates []string) (string, error) {
+	var result PickResult
+	if err := p.Call(ctx, KindPick, nil, PickRequest{Prompt: prompt, Candidates: candidates}, &result); err != nil {
+		return "", err
+	}
+	return result.Selected, nil
+}
+
+// Rotate calls a rotation provider plugin and returns the new credential
+func (p Plugin) Rotate(ctx context.Context, args []string, req RotateRequest) (string, error) {
+	var result RotateResult
+	if err := p.Call(ctx, KindRotate, args, req, &result); err != nil {
+		return "", err
+	}
+	if result.Password == "" {
+		return "", fmt.Errorf("%w: %s%s: no password returned", ErrInvalidResponse, Prefix, p.Name)
+	}
+	return result.Password, nil
+}
+
+// Provider is a sync target plugin used as cloud.Provider
+type Provider struct {
+	Plugin Plugin
+	// Prefix of the secret names
+	Prefix string
+}
+
+var _ cloud.Provider = (*Provider)(nil)
+
+// Name maps an account to {prefix}{group}/{account}
+func (p *Provider) Name(gid, account string) string {
+	return cloud.SecretName(p.Prefix, gid, account)
+}
+
+// Get returns the value of the secret or a cloud.ErrNotFound
+func (p *Provider) Get(ctx context.Context, name string) (string, error) {
+	var result SecretResult
+	if err := p.Plugin.Call(ctx, KindGet, nil, SecretRequest{Name: name}, &result); err != nil {
+		return "", err
+	}
+	if !result.Found {
+		return "", cloud.ErrNotFound
+	}
+	return result.Value, nil
+}
+
+// Put writes the value of the secret
+func (p *Provider) Put(ctx context.Context, name, value string, create bool) error {
+	return p.Plugin.Call(ctx, KindPut, nil, SecretRequest{Name: name, Value: value, Create: create}, nil)
+}
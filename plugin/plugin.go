@@ -0,0 +1,173 @@
+// Package plugin runs executables named sherlock-<name> found on the PATH.
+// A plugin receives a JSON Request on stdin and, except for KindRun,
+// answers with a JSON Response on stdout
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// Prefix of plugin executables => sherlock-<name>
+	Prefix = "sherlock-"
+	// APIVersion of the Request and Response
+	APIVersion = "sherlock.plugin/v1"
+)
+
+// kinds of requests a plugin is called with
+const (
+	// KindRun runs the plugin as sherlock sub-command with the terminal attached
+	KindRun = "run"
+	// KindImport asks for accounts to import (ImportResult)
+	KindImport = "import"
+	// KindPick asks to select one of the candidates (PickRequest, PickResult)
+	KindPick = "pick"
+	// KindGet and KindPut read and write a secret of a sync target (SecretRequest, SecretResult)
+	KindGet = "get"
+	KindPut = "put"
+	// KindStorage reads or writes an object of a storage backend (StorageRequest, StorageResult)
+	KindStorage = "storage"
+	// KindRotate changes a credential at its service (RotateRequest, RotateResult)
+	KindRotate = "rotate"
+)
+
+var (
+	ErrNotFound        = fmt.Errorf("plugin not found")
+	ErrInvalidResponse = fmt.Errorf("invalid plugin response")
+	ErrFailed          = fmt.Errorf("plugin failed")
+)
+
+// Plugin is an executable sherlock-<name>
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Request is written to the stdin of a plugin
+type Request struct {
+	APIVersion string      `json:"api_version"`
+	Kind       string      `json:"kind"`
+	Args       []string    `json:"args"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// Response is read from the stdout of a plugin. A non-empty Error fails the call
+type Response struct {
+	APIVersion string          `json:"api_version"`
+	Error      string          `json:"error,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Discover returns the plugins found in the directories of path (a PATH
+// list) sorted by name. Like a shell, the first executable of a name wins
+func Discover(path string) []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(path) {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			name, ok := pluginName(info)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(dir, info.Name())})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the name of the plugin if the file is a plugin executable
+func pluginName(info os.FileInfo) (string, bool) {
+	if info.IsDir() || info.Mode()&0111 == 0 || !strings.HasPrefix(info.Name(), Prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(info.Name(), Prefix)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return name, name != ""
+}
+
+// Lookup returns the plugin with the name from the directories of path
+func Lookup(path, name string) (Plugin, error) {
+	for _, p := range Discover(path) {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Plugin{}, fmt.Errorf("%w: %s%s", ErrNotFound, Prefix, name)
+}
+
+// Run runs the plugin with the terminal attached (KindRun). Only the
+// Request is passed on stdin; the exit status of the plugin is returned
+func (p Plugin) Run(ctx context.Context, args []string, stdout, stderr io.Writer) (int, error) {
+	stdin, err := request(KindRun, args, nil)
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.CommandContext(ctx, p.Path, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			return exit.ExitCode(), nil
+		}
+		return 0, err
+	}
+	return 0, nil
+}
+
+// Call calls the plugin with a Request of the kind and decodes the data of
+// its Response into result. The stderr of the plugin is passed through
+func (p Plugin) Call(ctx context.Context, kind string, args []string, data, result interface{}) error {
+	stdin, err := request(kind, args, data)
+	if err != nil {
+		return err
+	}
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s%s: %v", ErrFailed, Prefix, p.Name, err)
+	}
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("%w: %s%s: %v", ErrInvalidResponse, Prefix, p.Name, err)
+	}
+	if resp.APIVersion != APIVersion {
+		return fmt.Errorf("%w: %s%s: unsupported api_version %q (want %s)", ErrInvalidResponse, Prefix, p.Name, resp.APIVersion, APIVersion)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%w: %s%s: %s", ErrFailed, Prefix, p.Name, resp.Error)
+	}
+	if result == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return fmt.Errorf("%w: %s%s: %v", ErrInvalidResponse, Prefix, p.Name, err)
+	}
+	return nil
+}
+
+func request(kind string, args []string, data interface{}) ([]byte, error) {
+	if args == nil {
+		args = []string{}
+	}
+	return json.Marshal(Request{APIVersion: APIVersion, Kind: kind, Args: args, Data: data})
+}
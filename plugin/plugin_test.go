@@ -0,0 +1,187 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/cloud"
+)
+
+// writePlugin writes a shell script plugin which stores its stdin next to
+// itself (<name>.request) and prints the response
+func writePlugin(t *testing.T, dir, name, response string) Plugin {
+	t.Helper()
+	path := filepath.Join(dir, Prefix+name)
+	script := "#!/bin/sh\ncat > " + path + ".request\n" + response + "\n"
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return Plugin{Name: name, Path: path}
+}
+
+func readRequest(t *testing.T, p Plugin) Request {
+	t.Helper()
+	b, err := ioutil.ReadFile(p.Path + ".request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req Request
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestDiscover(t *testing.T) {
+	first, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(first)
+	second, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(second)
+
+	csv := writePlugin(t, first, "csv", "")
+	writePlugin(t, second, "csv", "")
+	vault := writePlugin(t, second, "vault", "")
+	_ = ioutil.WriteFile(filepath.Join(first, Prefix+"notes"), nil, 0644)
+	_ = ioutil.WriteFile(filepath.Join(first, "other-tool"), nil, 0755)
+
+	expect := []Plugin{csv, vault}
+	if have := Discover(first + string(os.PathListSeparator) + second); !reflect.DeepEqual(have, expect) {
+		t.Fatalf("plugin.Discover: want: %v, have: %v", expect, have)
+	}
+	if _, err := Lookup(first, "vault"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("plugin.Lookup: want: %v, have: %v", ErrNotFound, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := writePlugin(t, dir, "csv", `echo '{"api_version":"sherlock.plugin/v1","data":{"accounts":[{"name":"github","password":"221b","tag":"dev"}]}}'`)
+
+	accounts, err := p.Import(context.Background(), []string{"--file", "export.csv"})
+	if err != nil {
+		t.Fatalf("plugin.Import: want: nil, have: %v", err)
+	}
+	expect := []ImportedAccount{{Name: "github", Password: "221b", Tag: "dev"}}
+	if !reflect.DeepEqual(accounts, expect) {
+		t.Fatalf("plugin.Import: want: %v, have: %v", expect, accounts)
+	}
+	req := readRequest(t, p)
+	if req.APIVersion != APIVersion || req.Kind != KindImport || !reflect.DeepEqual(req.Args, []string{"--file", "export.csv"}) {
+		t.Fatalf("plugin.Import: want: import request with args, have: %+v", req)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tt := []struct {
+		response string
+		err      error
+	}{
+		{response: `echo '{"api_version":"sherlock.plugin/v1","error":"no such file"}'`, err: ErrFailed},
+		{response: `echo '{"api_version":"sherlock.plugin/v0"}'`, err: ErrInvalidResponse},
+		{response: `echo 'accounts: none'`, err: ErrInvalidResponse},
+		{response: `exit 3`, err: ErrFailed},
+	}
+
+	for _, tc := range tt {
+		p := writePlugin(t, dir, "broken", tc.response)
+		if _, err := p.Import(context.Background(), nil); !errors.Is(err, tc.err) {
+			t.Fatalf("plugin.Call(%s): want: %v, have: %v", tc.response, tc.err, err)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := writePlugin(t, dir, "github", `echo '{"api_version":"sherlock.plugin/v1","data":{"password":"ghp_new"}}'`)
+
+	req := RotateRequest{Account: "work@github-token", Username: "detective", Password: "ghp_old", Generated: "g3nerated"}
+	password, err := p.Rotate(context.Background(), []string{"--scopes", "repo"}, req)
+	if err != nil || password != "ghp_new" {
+		t.Fatalf("plugin.Rotate: want: ghp_new, have: %q (%v)", password, err)
+	}
+	sent := readRequest(t, p)
+	data, _ := json.Marshal(sent.Data)
+	var have RotateRequest
+	if err := json.Unmarshal(data, &have); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Kind != KindRotate || !reflect.DeepEqual(have, req) || !reflect.DeepEqual(sent.Args, []string{"--scopes", "repo"}) {
+		t.Fatalf("plugin.Rotate: want: rotate request %+v, have: %+v", req, sent)
+	}
+
+	// a provider must return the credential now valid
+	empty := writePlugin(t, dir, "empty", `echo '{"api_version":"sherlock.plugin/v1","data":{}}'`)
+	if _, err := empty.Rotate(context.Background(), nil, req); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("plugin.Rotate: want: %v, have: %v", ErrInvalidResponse, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := writePlugin(t, dir, "hello", `echo "hello $1"; exit 4`)
+
+	var stdout bytes.Buffer
+	code, err := p.Run(context.Background(), []string{"watson"}, &stdout, ioutil.Discard)
+	if err != nil || code != 4 {
+		t.Fatalf("plugin.Run: want: exit 4, have: %d (%v)", code, err)
+	}
+	if stdout.String() != "hello watson\n" {
+		t.Fatalf("plugin.Run: want: %q, have: %q", "hello watson\n", stdout.String())
+	}
+	if req := readRequest(t, p); req.Kind != KindRun {
+		t.Fatalf("plugin.Run: want: %s request, have: %s", KindRun, req.Kind)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := &Provider{Plugin: writePlugin(t, dir, "store", `echo '{"api_version":"sherlock.plugin/v1","data":{"found":false}}'`)}
+
+	if _, err := p.Get(context.Background(), "work/db"); !errors.Is(err, cloud.ErrNotFound) {
+		t.Fatalf("plugin.Provider.Get: want: %v, have: %v", cloud.ErrNotFound, err)
+	}
+	if err := p.Put(context.Background(), "work/db", "221b", true); err != nil {
+		t.Fatalf("plugin.Provider.Put: want: nil, have: %v", err)
+	}
+	req := readRequest(t, p.Plugin)
+	b, _ := json.Marshal(req.Data)
+	var data SecretRequest
+	_ = json.Unmarshal(b, &data)
+	if req.Kind != KindPut || data != (SecretRequest{Name: "work/db", Value: "221b", Create: true}) {
+		t.Fatalf("plugin.Provider.Put: want: put request, have: %+v", req)
+	}
+}
@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"os"
+)
+
+// operations of a KindStorage Request
+const (
+	StorageGet    = "get"
+	StoragePut    = "put"
+	StorageDelete = "delete"
+	StorageList   = "list"
+)
+
+// StorageRequest is the data of a KindStorage Request. Data is only set for
+// StoragePut and encoded as base64 like every []byte in JSON
+type StorageRequest struct {
+	Op   string `json:"op"`
+	Key  string `json:"key"`
+	Data []byte `json:"data,omitempty"`
+}
+
+// StorageResult is the data of the Response to a KindStorage Request. Found
+// and Data answer StorageGet, Names answers StorageList
+type StorageResult struct {
+	Found bool     `json:"found"`
+	Data  []byte   `json:"data,omitempty"`
+	Names []string `json:"names,omitempty"`
+}
+
+// Store is a storage backend plugin storing objects by key. It implements
+// storage.ObjectStore
+type Store struct {
+	Plugin Plugin
+	// Args are passed to the plugin with every operation, e.g. the url of the remote
+	Args []string
+}
+
+// Get returns the object or an os.ErrNotExist error
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	var result StorageResult
+	if err := s.Plugin.Call(ctx, KindStorage, s.Args, StorageRequest{Op: StorageGet, Key: key}, &result); err != nil {
+		return nil, err
+	}
+	if !result.Found {
+		return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+	}
+	return result.Data, nil
+}
+
+// Put creates or replaces the object
+func (s *Store) Put(ctx context.Context, key string, data []byte) error {
+	return s.Plugin.Call(ctx, KindStorage, s.Args, StorageRequest{Op: StoragePut, Key: key, Data: data}, nil)
+}
+
+// Delete removes the object and all objects below it
+func (s *Store) Delete(ctx context.Context, key string) error {
+	return s.Plugin.Call(ctx, KindStorage, s.Args, StorageRequest{Op: StorageDelete, Key: key}, nil)
+}
+
+// List returns the names of the objects directly below the prefix
+func (s *Store) List(ctx context.Context, prefix string) ([]string, error) {
+	var result StorageResult
+	if err := s.Plugin.Call(ctx, KindStorage, s.Args, StorageRequest{Op: StorageList, Key: prefix}, &result); err != nil {
+		return nil, err
+	}
+	return result.Names, nil
+}
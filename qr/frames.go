@@ -0,0 +1,120 @@
+package qr
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FramePrefix starts the content of every frame of a split payload
+const FramePrefix = "SHERLOCK:"
+
+// idLength is the number of hex characters identifying a payload
+const idLength = 12
+
+var (
+	ErrInvalidFrame     = fmt.Errorf("invalid frame")
+	ErrIncompleteFrames = fmt.Errorf("frames missing")
+)
+
+// Split splits the payload into frames small enough to be rendered as one QR
+// code each => SHERLOCK:{id}:{n}/{total}:{base64 chunk}. The id is derived from
+// the payload and ties its frames together
+func Split(payload []byte, chunk int) []string {
+	if chunk < 1 {
+		chunk = 1
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	id := payloadID(payload)
+	total := (len(encoded) + chunk - 1) / chunk
+	if total == 0 {
+		total = 1
+	}
+	frames := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * chunk
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		frames = append(frames, fmt.Sprintf("%s%s:%d/%d:%s", FramePrefix, id, i+1, total, encoded[i*chunk:end]))
+	}
+	return frames
+}
+
+func payloadID(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])[:idLength]
+}
+
+// Joiner collects the frames of a payload in any order. A frame read twice
+// (as scanners often do) is ignored
+type Joiner struct {
+	id     string
+	total  int
+	chunks map[int]string
+}
+
+// Add adds a frame. Frames of another payload are rejected
+func (j *Joiner) Add(frame string) error {
+	parts := strings.SplitN(strings.TrimSpace(frame), ":", 4)
+	if len(parts) != 4 || parts[0]+":" != FramePrefix || len(parts[1]) != idLength {
+		return fmt.Errorf("%w: %.40q", ErrInvalidFrame, frame)
+	}
+	position := strings.SplitN(parts[2], "/", 2)
+	if len(position) != 2 {
+		return fmt.Errorf("%w: %.40q", ErrInvalidFrame, frame)
+	}
+	n, err := strconv.Atoi(position[0])
+	if err != nil {
+		return fmt.Errorf("%w: %.40q", ErrInvalidFrame, frame)
+	}
+	total, err := strconv.Atoi(position[1])
+	if err != nil || total < 1 || n < 1 || n > total {
+		return fmt.Errorf("%w: %.40q", ErrInvalidFrame, frame)
+	}
+	if j.chunks == nil {
+		j.id, j.total, j.chunks = parts[1], total, make(map[int]string, total)
+	}
+	if parts[1] != j.id || total != j.total {
+		return fmt.Errorf("%w: frame %d/%d belongs to another transfer", ErrInvalidFrame, n, total)
+	}
+	j.chunks[n] = parts[3]
+	return nil
+}
+
+// Missing returns the numbers of the frames not added yet
+func (j *Joiner) Missing() []int {
+	var missing []int
+	for n := 1; n <= j.total; n++ {
+		if _, ok := j.chunks[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	sort.Ints(missing)
+	return missing
+}
+
+// Complete reports whether all frames of the payload were added
+func (j *Joiner) Complete() bool {
+	return j.total > 0 && len(j.Missing()) == 0
+}
+
+// Payload joins the frames and verifies the result matches the payload id
+func (j *Joiner) Payload() ([]byte, error) {
+	if !j.Complete() {
+		return nil, fmt.Errorf("%w: %v of %d", ErrIncompleteFrames, j.Missing(), j.total)
+	}
+	var encoded strings.Builder
+	for n := 1; n <= j.total; n++ {
+		encoded.WriteString(j.chunks[n])
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded.String())
+	if err != nil || payloadID(payload) != j.id {
+		return nil, fmt.Errorf("%w: frames do not add up to the transfer", ErrInvalidFrame)
+	}
+	return payload, nil
+}
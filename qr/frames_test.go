@@ -0,0 +1,56 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFrames(t *testing.T) {
+	payload := bytes.Repeat([]byte("encrypted accounts "), 50)
+	frames := Split(payload, 100)
+	if len(frames) < 2 {
+		t.Fatalf("qr.Split: want: several frames, have: %d", len(frames))
+	}
+	var j Joiner
+	// frames are added in any order and scanned twice
+	for i := len(frames) - 1; i >= 0; i-- {
+		if err := j.Add(frames[i]); err != nil {
+			t.Fatalf("Joiner.Add: want: nil, have: %v", err)
+		}
+		if i == 1 {
+			if missing := j.Missing(); !reflect.DeepEqual(missing, []int{1}) {
+				t.Fatalf("Joiner.Missing: want: [1], have: %v", missing)
+			}
+			if _, err := j.Payload(); !errors.Is(err, ErrIncompleteFrames) {
+				t.Fatalf("Joiner.Payload: want: %v, have: %v", ErrIncompleteFrames, err)
+			}
+		}
+	}
+	_ = j.Add(frames[0])
+	joined, err := j.Payload()
+	if err != nil || !bytes.Equal(joined, payload) {
+		t.Fatalf("Joiner.Payload: want: payload, have: %v", err)
+	}
+
+	other := Split([]byte("other transfer"), 100)
+	if err := j.Add(other[0]); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("Joiner.Add (other transfer): want: %v, have: %v", ErrInvalidFrame, err)
+	}
+	for _, frame := range []string{"otpauth://totp/x", FramePrefix + "abc:1/1:x", strings.Replace(frames[0], "1/", "0/", 1)} {
+		var j Joiner
+		if err := j.Add(frame); !errors.Is(err, ErrInvalidFrame) {
+			t.Fatalf("Joiner.Add(%q): want: %v, have: %v", frame, ErrInvalidFrame, err)
+		}
+	}
+
+	// a modified chunk does not add up to the payload
+	var tampered Joiner
+	single := Split([]byte("short"), 100)[0]
+	_ = tampered.Add(single[:len(single)-1] + "A")
+	if _, err := tampered.Payload(); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("Joiner.Payload (tampered): want: %v, have: %v", ErrInvalidFrame, err)
+	}
+}
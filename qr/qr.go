@@ -0,0 +1,102 @@
+package qr
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	// register the image formats a QR code can be decoded from
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+const (
+	// margin is the quiet zone around the QR code in modules. The spec
+	// asks for 4 however 2 is enough for phone cameras and saves space
+	margin = 2
+
+	// black on white so the QR code scans independent of the terminal colors
+	ansiColors = "\033[30;47m"
+	ansiReset  = "\033[0m"
+)
+
+var (
+	ErrNoQRCode = fmt.Errorf("no QR code found in image")
+)
+
+// Render writes the content as QR code to w using unicode half blocks
+// so two rows of modules fit into one line of text
+func Render(w io.Writer, content string) error {
+	return render(w, content, ansiColors, ansiReset)
+}
+
+// RenderPlain writes the content as QR code like Render but without terminal
+// colors. The QR code only scans if printed dark on a light background
+func RenderPlain(w io.Writer, content string) error {
+	return render(w, content, "", "")
+}
+
+func render(w io.Writer, content, colors, reset string) error {
+	matrix, err := encode(content)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(w)
+	width, height := matrix.GetWidth(), matrix.GetHeight()
+	for y := 0; y < height; y += 2 {
+		buf.WriteString(colors)
+		for x := 0; x < width; x++ {
+			top := matrix.Get(x, y)
+			bottom := y+1 < height && matrix.Get(x, y+1)
+			switch {
+			case top && bottom:
+				buf.WriteRune('█')
+			case top:
+				buf.WriteRune('▀')
+			case bottom:
+				buf.WriteRune('▄')
+			default:
+				buf.WriteRune(' ')
+			}
+		}
+		buf.WriteString(reset)
+		buf.WriteRune('\n')
+	}
+	return buf.Flush()
+}
+
+// Decode reads the content of the QR code in an image file (png, jpeg or gif)
+func Decode(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return "", err
+	}
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, nil)
+	if err != nil {
+		return "", ErrNoQRCode
+	}
+	return result.GetText(), nil
+}
+
+// encode encodes the content in a QR code with one module per bit
+func encode(content string) (*gozxing.BitMatrix, error) {
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_MARGIN: margin,
+	}
+	return qrcode.NewQRCodeWriter().Encode(content, gozxing.BarcodeFormat_QR_CODE, 0, 0, hints)
+}
@@ -0,0 +1,105 @@
+package qr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testContent = "otpauth://totp/bakerstreet:sherlock?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestDecode renders a QR code into a png image and decodes it again
+func TestDecode(t *testing.T) {
+	matrix, err := encode(testContent)
+	if err != nil {
+		t.Fatalf("qr.encode: want: nil, have: %v", err)
+	}
+	const scale = 4
+	img := image.NewGray(image.Rect(0, 0, matrix.GetWidth()*scale, matrix.GetHeight()*scale))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			c := color.White
+			if matrix.Get(x/scale, y/scale) {
+				c = color.Black
+			}
+			img.Set(x, y, c)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "qr.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	content, err := Decode(path)
+	if err != nil {
+		t.Fatalf("qr.Decode: want: nil, have: %v", err)
+	}
+	if content != testContent {
+		t.Fatalf("qr.Decode: want: %s, have: %s", testContent, content)
+	}
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, testContent); err != nil {
+		t.Fatalf("qr.Render: want: nil, have: %v", err)
+	}
+	matrix, _ := encode(testContent)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if want := (matrix.GetHeight() + 1) / 2; len(lines) != want {
+		t.Fatalf("qr.Render: want: %d lines, have: %d", want, len(lines))
+	}
+
+	buf.Reset()
+	if err := RenderPlain(&buf, testContent); err != nil {
+		t.Fatalf("qr.RenderPlain: want: nil, have: %v", err)
+	}
+	if strings.Contains(buf.String(), "\033") {
+		t.Fatalf("qr.RenderPlain: want: no escape sequences, have: %q", buf.String())
+	}
+	if plain := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); len(plain) != len(lines) {
+		t.Fatalf("qr.RenderPlain: want: %d lines, have: %d", len(lines), len(plain))
+	}
+}
+
+func TestWiFi(t *testing.T) {
+	tt := []struct {
+		ssid, password, security string
+		hidden                   bool
+		expect                   string
+		err                      error
+	}{
+		{
+			ssid: "baker street", password: "221b", security: WiFiWPA,
+			expect: "WIFI:T:WPA;S:baker street;P:221b;;",
+		},
+		{
+			ssid: `home;net`, password: `a:b,c\d"e`, security: WiFiWEP, hidden: true,
+			expect: `WIFI:T:WEP;S:home\;net;P:a\:b\,c\\d\"e;H:true;;`,
+		},
+		{
+			ssid: "guest", password: "ignored", security: WiFiNoPass,
+			expect: "WIFI:T:nopass;S:guest;;",
+		},
+		{
+			ssid: "guest", security: "WPA3",
+			err: ErrInvalidWiFiSecurity,
+		},
+	}
+	for _, tc := range tt {
+		payload, err := WiFi(tc.ssid, tc.password, tc.security, tc.hidden)
+		if err != tc.err || payload != tc.expect {
+			t.Fatalf("qr.WiFi: want: %q %v, have: %q %v", tc.expect, tc.err, payload, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// authentication types of a WiFi network as used in the WIFI: payload
+const (
+	WiFiWPA    = "WPA"
+	WiFiWEP    = "WEP"
+	WiFiNoPass = "nopass"
+)
+
+var (
+	ErrInvalidWiFiSecurity = fmt.Errorf("unknown wifi security (use WPA, WEP or nopass)")
+)
+
+// wifiEscaper escapes the characters with a special meaning in the WIFI: payload
+var wifiEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// WiFi builds the WIFI: payload phones understand to join a network when
+// scanning its QR code => WIFI:T:{security};S:{ssid};P:{password};H:{hidden};;
+func WiFi(ssid, password, security string, hidden bool) (string, error) {
+	switch security {
+	case WiFiWPA, WiFiWEP:
+	case WiFiNoPass:
+		password = ""
+	default:
+		return "", ErrInvalidWiFiSecurity
+	}
+	var b strings.Builder
+	b.WriteString("WIFI:T:" + security + ";S:" + wifiEscaper.Replace(ssid) + ";")
+	if password != "" {
+		b.WriteString("P:" + wifiEscaper.Replace(password) + ";")
+	}
+	if hidden {
+		b.WriteString("H:true;")
+	}
+	b.WriteString(";")
+	return b.String(), nil
+}
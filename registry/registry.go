@@ -0,0 +1,131 @@
+// Package registry renders package registry tokens in the config formats of
+// npm (.npmrc), pip (pip.conf) and cargo (credentials.toml) so the tokens do
+// not have to be kept in dotfiles
+package registry
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// names of the supported registries
+const (
+	NPM   = "npm"
+	PyPI  = "pypi"
+	Cargo = "cargo"
+)
+
+// pypiTokenUser is the username PyPI expects for API tokens
+const pypiTokenUser = "__token__"
+
+var (
+	ErrUnknownRegistry = fmt.Errorf("unknown registry (use npm, pypi or cargo)")
+	ErrInvalidToken    = fmt.Errorf("token cannot be written to a config")
+	ErrInvalidURL      = fmt.Errorf("invalid registry url")
+)
+
+// defaultURLs are the public registries used if no url is given. Cargo names
+// registries instead of addressing them by url
+var defaultURLs = map[string]string{
+	NPM:  "https://registry.npmjs.org/",
+	PyPI: "https://pypi.org/simple",
+}
+
+// aliases maps the names of the package managers to their registry
+var aliases = map[string]string{
+	"pip":    PyPI,
+	"twine":  PyPI,
+	"crates": Cargo,
+}
+
+// Lookup returns the registry of the name or alias
+func Lookup(name string) (string, error) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		return alias, nil
+	}
+	switch name {
+	case NPM, PyPI, Cargo:
+		return name, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownRegistry, name)
+}
+
+// Token is the token of a registry with the account fields it is rendered with
+type Token struct {
+	Token string
+	// Username is used by pip and defaults to __token__
+	Username string
+	// URL is the registry url for npm and pip and the name of an alternative
+	// registry for cargo; empty selects the public registry
+	URL string
+}
+
+// Render returns the config of the registry holding the token:
+//
+//	npm:   //registry.npmjs.org/:_authToken=<token>
+//	pypi:  [global] index-url = https://__token__:<token>@pypi.org/simple
+//	cargo: [registry] token = "<token>"
+func Render(name string, t Token) (string, error) {
+	if t.Token == "" || strings.IndexFunc(t.Token, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("%w: it is empty or holds control characters", ErrInvalidToken)
+	}
+	switch name {
+	case NPM:
+		u, err := registryURL(name, t.URL)
+		if err != nil {
+			return "", err
+		}
+		path := u.Path
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		return fmt.Sprintf("//%s%s:_authToken=%s\n", u.Host, path, t.Token), nil
+	case PyPI:
+		u, err := registryURL(name, t.URL)
+		if err != nil {
+			return "", err
+		}
+		username := t.Username
+		if username == "" {
+			username = pypiTokenUser
+		}
+		u.User = url.UserPassword(username, t.Token)
+		return fmt.Sprintf("[global]\nindex-url = %s\n", u), nil
+	case Cargo:
+		section := "[registry]"
+		if t.URL != "" {
+			if strings.IndexFunc(t.URL, invalidCargoName) >= 0 {
+				return "", fmt.Errorf("%w: %q is no cargo registry name", ErrInvalidURL, t.URL)
+			}
+			section = fmt.Sprintf("[registries.%s]", t.URL)
+		}
+		return fmt.Sprintf("%s\ntoken = %s\n", section, tomlString(t.Token)), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownRegistry, name)
+}
+
+// registryURL parses the url of a registry or returns its default
+func registryURL(name, raw string) (*url.URL, error) {
+	if raw == "" {
+		raw = defaultURLs[name]
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidURL, raw)
+	}
+	return u, nil
+}
+
+// invalidCargoName reports the runes cargo does not allow in registry names
+func invalidCargoName(r rune) bool {
+	return !(r == '-' || r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))))
+}
+
+// tomlString quotes the value as TOML basic string. Control characters are
+// refused by Render before
+func tomlString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
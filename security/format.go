@@ -0,0 +1,156 @@
+package security
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// FormatVersion is the vault format version written by sherlock.
+	// Vaults with an older version are upgraded on read using the
+	// registered migrations
+	FormatVersion uint8 = 1
+	// legacyVersion refers to vaults written before the header was introduced
+	legacyVersion uint8 = 0
+	// magic marks a vault as versioned. Legacy vaults start with a random IV;
+	// the chance of it colliding with the magic is negligible (2^-32)
+	magic = "SHLK"
+	// headerPrefixLen => len(magic) + version (1 byte) + header length (2 bytes)
+	headerPrefixLen = len(magic) + 1 + 2
+)
+
+var (
+	ErrMalformedVault     = fmt.Errorf("vault file is malformed")
+	ErrUnsupportedVersion = fmt.Errorf("vault format version is not supported (update sherlock)")
+	ErrNoMigration        = fmt.Errorf("no migration registered for vault format version")
+	ErrHeaderTooLarge     = fmt.Errorf("vault header exceeds 64 KiB")
+)
+
+// Header holds the plain text meta data of a vault stored in front
+// of the encrypted payload
+type Header struct {
+	// Version of the vault format. Not serialized as part of the json
+	// since it is required to know how to read the header
+	Version uint8 `json:"-"`
+	// KDF names the key derivation used for the group key
+	KDF string `json:"kdf"`
+	// Salt, Time, Memory and Threads parametrize the argon2id key derivation
+	Salt    []byte `json:"salt,omitempty"`
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+	// Meta describes the vault content. It is optional since vaults
+	// written by older versions of sherlock do not carry it
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// Meta holds plain text statistics about the vault content allowing
+// to inspect a group without knowing its group key
+type Meta struct {
+	Accounts int       `json:"accounts"`
+	Modified time.Time `json:"modified"`
+	ReadOnly bool      `json:"read_only,omitempty"`
+	// Index lists the accounts without their secrets for groups which
+	// opted into a plain text index. IndexMAC authenticates it together
+	// with the encrypted payload and is checked whenever the vault is decrypted
+	Index    json.RawMessage `json:"index,omitempty"`
+	IndexMAC []byte          `json:"index_mac,omitempty"`
+}
+
+// Migration upgrades a vault by exactly one format version. The payload
+// is the still encrypted part of the vault following the header
+type Migration func(h Header, payload []byte) (Header, []byte, error)
+
+// migrations maps the version a migration upgrades from to the Migration
+var migrations = map[uint8]Migration{
+	legacyVersion: migrateLegacy,
+}
+
+// RegisterMigration registers the Migration upgrading vaults of
+// version from to version from+1
+func RegisterMigration(from uint8, m Migration) {
+	migrations[from] = m
+}
+
+// EncodeVault prefixes the payload with the header in the current format =>
+// {magic}{version}{header-len}{json-header}{payload}
+func EncodeVault(h Header, payload []byte) ([]byte, error) {
+	h.Version = FormatVersion
+	header, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > math.MaxUint16 {
+		return nil, ErrHeaderTooLarge
+	}
+	var buf bytes.Buffer
+	buf.Grow(headerPrefixLen + len(header) + len(payload))
+	buf.WriteString(magic)
+	buf.WriteByte(h.Version)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(header)))
+	buf.Write(header)
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// DecodeVault splits a vault into its header and payload. Vaults written in an
+// older format are migrated to the current FormatVersion
+func DecodeVault(vault []byte) (Header, []byte, error) {
+	h, payload, err := decodeHeader(vault)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	for h.Version < FormatVersion {
+		migrate, ok := migrations[h.Version]
+		if !ok {
+			return Header{}, nil, ErrNoMigration
+		}
+		from := h.Version
+		if h, payload, err = migrate(h, payload); err != nil {
+			return Header{}, nil, err
+		}
+		if h.Version <= from {
+			return Header{}, nil, fmt.Errorf("migration from vault format version %d did not upgrade the vault", from)
+		}
+	}
+	return h, payload, nil
+}
+
+// ReadHeader returns the header of a vault without migrating it
+func ReadHeader(vault []byte) (Header, error) {
+	h, _, err := decodeHeader(vault)
+	return h, err
+}
+
+func decodeHeader(vault []byte) (Header, []byte, error) {
+	if !bytes.HasPrefix(vault, []byte(magic)) {
+		return Header{Version: legacyVersion}, vault, nil
+	}
+	if len(vault) < headerPrefixLen {
+		return Header{}, nil, ErrMalformedVault
+	}
+	version := vault[len(magic)]
+	if version > FormatVersion {
+		return Header{}, nil, ErrUnsupportedVersion
+	}
+	size := int(binary.BigEndian.Uint16(vault[len(magic)+1 : headerPrefixLen]))
+	if len(vault) < headerPrefixLen+size {
+		return Header{}, nil, ErrMalformedVault
+	}
+	var h Header
+	if err := json.Unmarshal(vault[headerPrefixLen:headerPrefixLen+size], &h); err != nil {
+		return Header{}, nil, ErrMalformedVault
+	}
+	h.Version = version
+	return h, vault[headerPrefixLen+size:], nil
+}
+
+// migrateLegacy upgrades vaults without a header. The payload of those
+// vaults is already in the layout of version 1
+func migrateLegacy(h Header, payload []byte) (Header, []byte, error) {
+	return Header{Version: 1, KDF: KDFSHA256}, payload, nil
+}
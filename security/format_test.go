@@ -0,0 +1,118 @@
+package security
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeVault(t *testing.T) {
+	payload := []byte("encrypted-payload")
+
+	vault, err := EncodeVault(Header{KDF: KDFSHA256}, payload)
+	if err != nil {
+		t.Fatalf("security.EncodeVault: want: nil, have: %v", err)
+	}
+	h, decoded, err := DecodeVault(vault)
+	if err != nil {
+		t.Fatalf("security.DecodeVault: want: nil, have: %v", err)
+	}
+	if h.Version != FormatVersion || h.KDF != KDFSHA256 {
+		t.Fatalf("security.DecodeVault: want: %d %s, have: %d %s", FormatVersion, KDFSHA256, h.Version, h.KDF)
+	}
+	if !bytes.Equal(decoded, payload) {
+		t.Fatalf("security.DecodeVault: want: %s, have: %s", payload, decoded)
+	}
+}
+
+func TestDecodeLegacyVault(t *testing.T) {
+	legacy := []byte("iv-and-ciphertext-without-header")
+
+	h, payload, err := DecodeVault(legacy)
+	if err != nil {
+		t.Fatalf("security.DecodeVault: want: nil, have: %v", err)
+	}
+	if h.Version != FormatVersion || h.KDF != KDFSHA256 {
+		t.Fatalf("security.DecodeVault: want: %d %s, have: %d %s", FormatVersion, KDFSHA256, h.Version, h.KDF)
+	}
+	if !bytes.Equal(payload, legacy) {
+		t.Fatalf("security.DecodeVault: want: %s, have: %s", legacy, payload)
+	}
+}
+
+func TestDecodeMalformedVault(t *testing.T) {
+	tt := []struct {
+		vault  []byte
+		expect error
+	}{
+		{
+			vault:  []byte(magic),
+			expect: ErrMalformedVault,
+		},
+		{
+			vault:  append([]byte(magic), FormatVersion, 0, 10, '{'),
+			expect: ErrMalformedVault,
+		},
+		{
+			vault:  append([]byte(magic), FormatVersion+1, 0, 2, '{', '}'),
+			expect: ErrUnsupportedVersion,
+		},
+	}
+	for _, tc := range tt {
+		if _, _, err := DecodeVault(tc.vault); err != tc.expect {
+			t.Fatalf("security.DecodeVault: want: %v, have: %v", tc.expect, err)
+		}
+	}
+}
+
+func TestEncryptDecryptVault(t *testing.T) {
+	type vault struct {
+		Name string `json:"name"`
+	}
+	encrypted, err := InitWithDefault("group-key", vault{Name: "test"})
+	if err != nil {
+		t.Fatalf("security.InitWithDefault: want: nil, have: %v", err)
+	}
+	var v vault
+	if err := DecryptVault(encrypted, "group-key", &v); err != nil {
+		t.Fatalf("security.DecryptVault: want: nil, have: %v", err)
+	}
+	if v.Name != "test" {
+		t.Fatalf("security.DecryptVault: want: test, have: %s", v.Name)
+	}
+}
+
+func TestVaultMeta(t *testing.T) {
+	modified := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	encrypted, err := EncryptVaultWithMeta([]byte(`{}`), "group-key", KDFSHA256, &Meta{Accounts: 3, Modified: modified})
+	if err != nil {
+		t.Fatalf("security.EncryptVaultWithMeta: want: nil, have: %v", err)
+	}
+	h, err := ReadHeader(encrypted)
+	if err != nil {
+		t.Fatalf("security.ReadHeader: want: nil, have: %v", err)
+	}
+	if h.Meta == nil || h.Meta.Accounts != 3 || !h.Meta.Modified.Equal(modified) {
+		t.Fatalf("security.ReadHeader: want: 3 %v, have: %+v", modified, h.Meta)
+	}
+	var v map[string]interface{}
+	if err := DecryptVault(encrypted, "group-key", &v); err != nil {
+		t.Fatalf("security.DecryptVault: want: nil, have: %v", err)
+	}
+
+	encrypted, err = EncryptVault([]byte(`{}`), "group-key", KDFSHA256)
+	if err != nil {
+		t.Fatalf("security.EncryptVault: want: nil, have: %v", err)
+	}
+	if h, _ := ReadHeader(encrypted); h.Meta != nil {
+		t.Fatalf("security.ReadHeader: want: nil meta, have: %+v", h.Meta)
+	}
+}
+
+func TestEncodeVaultHeaderTooLarge(t *testing.T) {
+	index := append([]byte(`"`), bytes.Repeat([]byte("a"), 1<<16)...)
+	index = append(index, '"')
+	if _, err := EncodeVault(Header{KDF: KDFSHA256, Meta: &Meta{Index: index}}, nil); err != ErrHeaderTooLarge {
+		t.Fatalf("security.EncodeVault: want: %v, have: %v", ErrHeaderTooLarge, err)
+	}
+}
@@ -0,0 +1,182 @@
+package security
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// sealed data starts with the sealMagic followed by the seal mode
+const (
+	sealMagic = "SHLB"
+
+	sealPassphrase byte = 1
+	sealRecipient  byte = 2
+
+	nonceLen = 24
+	keyLen   = 32
+
+	// RecipientPrefix and IdentityPrefix mark the encoded public and private key of a seal key pair
+	RecipientPrefix = "sherlock-recipient-"
+	IdentityPrefix  = "sherlock-identity-"
+)
+
+var (
+	ErrNotSealed        = fmt.Errorf("data is not sealed")
+	ErrOpenSealed       = fmt.Errorf("wrong passphrase or identity for sealed data")
+	ErrInvalidRecipient = fmt.Errorf("invalid recipient (expected " + RecipientPrefix + "...)")
+	ErrInvalidIdentity  = fmt.Errorf("invalid identity (expected " + IdentityPrefix + "...)")
+)
+
+// IsSealed reports whether the data has been sealed by SealPassphrase or SealRecipient
+func IsSealed(b []byte) bool {
+	return bytes.HasPrefix(b, []byte(sealMagic))
+}
+
+// SealPassphrase encrypts and authenticates the data with a key derived from
+// the passphrase using argon2id =>
+// magic | mode | salt | nonce | secretbox
+func SealPassphrase(b []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	var nonce [nonceLen]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+	key := passphraseKey(passphrase, salt)
+
+	out := append([]byte(sealMagic), sealPassphrase)
+	out = append(out, salt...)
+	out = append(out, nonce[:]...)
+	return secretbox.Seal(out, b, &nonce, key), nil
+}
+
+// SealRecipient encrypts and authenticates the data for the recipient (public key)
+// so it can only be opened with the matching identity. No secret is required
+// to seal the data =>
+// magic | mode | ephemeral public key | nonce | box
+func SealRecipient(b []byte, recipient string) ([]byte, error) {
+	pub, err := parseKey(recipient, RecipientPrefix, ErrInvalidRecipient)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub, ephemeralPriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [nonceLen]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+	out := append([]byte(sealMagic), sealRecipient)
+	out = append(out, ephemeralPub[:]...)
+	out = append(out, nonce[:]...)
+	return box.Seal(out, b, &nonce, pub, ephemeralPriv), nil
+}
+
+// OpenSealed decrypts data sealed by SealPassphrase or SealRecipient. The
+// secret is either the passphrase or the identity matching the recipient
+func OpenSealed(b []byte, secret string) ([]byte, error) {
+	if !IsSealed(b) || len(b) < len(sealMagic)+1 {
+		return nil, ErrNotSealed
+	}
+	mode, b := b[len(sealMagic)], b[len(sealMagic)+1:]
+	var nonce [nonceLen]byte
+	switch mode {
+	case sealPassphrase:
+		if len(b) < saltLen+nonceLen {
+			return nil, ErrOpenSealed
+		}
+		salt := b[:saltLen]
+		copy(nonce[:], b[saltLen:saltLen+nonceLen])
+		opened, ok := secretbox.Open(nil, b[saltLen+nonceLen:], &nonce, passphraseKey(secret, salt))
+		if !ok {
+			return nil, ErrOpenSealed
+		}
+		return opened, nil
+	case sealRecipient:
+		priv, err := parseKey(secret, IdentityPrefix, ErrInvalidIdentity)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < keyLen+nonceLen {
+			return nil, ErrOpenSealed
+		}
+		var ephemeralPub [keyLen]byte
+		copy(ephemeralPub[:], b[:keyLen])
+		copy(nonce[:], b[keyLen:keyLen+nonceLen])
+		opened, ok := box.Open(nil, b[keyLen+nonceLen:], &nonce, &ephemeralPub, priv)
+		if !ok {
+			return nil, ErrOpenSealed
+		}
+		return opened, nil
+	default:
+		return nil, ErrNotSealed
+	}
+}
+
+// GenerateIdentity creates a key pair to seal data for. The recipient can be
+// shared and stored in plain text, the identity must be kept secret
+func GenerateIdentity() (identity string, recipient string, err error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeKey(IdentityPrefix, priv), encodeKey(RecipientPrefix, pub), nil
+}
+
+// IdentityRecipient returns the recipient matching the identity
+func IdentityRecipient(identity string) (string, error) {
+	priv, err := parseKey(identity, IdentityPrefix, ErrInvalidIdentity)
+	if err != nil {
+		return "", err
+	}
+	var pub [keyLen]byte
+	curve25519.ScalarBaseMult(&pub, priv)
+	return encodeKey(RecipientPrefix, &pub), nil
+}
+
+// CheckRecipient validates the encoding of a recipient
+func CheckRecipient(recipient string) error {
+	_, err := parseKey(recipient, RecipientPrefix, ErrInvalidRecipient)
+	return err
+}
+
+// IsIdentity reports whether the secret looks like an identity rather than a passphrase
+func IsIdentity(secret string) bool {
+	return strings.HasPrefix(strings.TrimSpace(secret), IdentityPrefix)
+}
+
+func passphraseKey(passphrase string, salt []byte) *[keyLen]byte {
+	var key [keyLen]byte
+	copy(key[:], argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, keyLen))
+	return &key
+}
+
+func encodeKey(prefix string, key *[keyLen]byte) string {
+	return prefix + base64.RawURLEncoding.EncodeToString(key[:])
+}
+
+func parseKey(s, prefix string, invalid error) (*[keyLen]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, prefix) {
+		return nil, invalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, prefix))
+	if err != nil || len(b) != keyLen {
+		return nil, invalid
+	}
+	var key [keyLen]byte
+	copy(key[:], b)
+	return &key, nil
+}
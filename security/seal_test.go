@@ -0,0 +1,72 @@
+package security
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var sealData = []byte("all vaults of sherlock")
+
+func TestSealPassphrase(t *testing.T) {
+	sealed, err := SealPassphrase(sealData, "backup-passphrase")
+	if err != nil {
+		t.Fatalf("security.SealPassphrase: want: nil, have: %v", err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, sealData) {
+		t.Fatalf("security.SealPassphrase: want: sealed data, have: %q", sealed)
+	}
+	opened, err := OpenSealed(sealed, "backup-passphrase")
+	if err != nil {
+		t.Fatalf("security.OpenSealed: want: nil, have: %v", err)
+	}
+	if !bytes.Equal(opened, sealData) {
+		t.Fatalf("security.OpenSealed: want: %s, have: %s", sealData, opened)
+	}
+	if _, err := OpenSealed(sealed, "wrong-passphrase"); !errors.Is(err, ErrOpenSealed) {
+		t.Fatalf("security.OpenSealed: want: %v, have: %v", ErrOpenSealed, err)
+	}
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := OpenSealed(sealed, "backup-passphrase"); !errors.Is(err, ErrOpenSealed) {
+		t.Fatalf("security.OpenSealed(tampered): want: %v, have: %v", ErrOpenSealed, err)
+	}
+}
+
+func TestSealRecipient(t *testing.T) {
+	identity, recipient, err := GenerateIdentity()
+	if err != nil {
+		t.Fatalf("security.GenerateIdentity: want: nil, have: %v", err)
+	}
+	if derived, err := IdentityRecipient(identity); err != nil || derived != recipient {
+		t.Fatalf("security.IdentityRecipient: want: %s, have: %s (%v)", recipient, derived, err)
+	}
+	if !IsIdentity(identity) || IsIdentity(recipient) {
+		t.Fatalf("security.IsIdentity: want: identity only, have: %v/%v", IsIdentity(identity), IsIdentity(recipient))
+	}
+
+	sealed, err := SealRecipient(sealData, recipient)
+	if err != nil {
+		t.Fatalf("security.SealRecipient: want: nil, have: %v", err)
+	}
+	opened, err := OpenSealed(sealed, identity)
+	if err != nil {
+		t.Fatalf("security.OpenSealed: want: nil, have: %v", err)
+	}
+	if !bytes.Equal(opened, sealData) {
+		t.Fatalf("security.OpenSealed: want: %s, have: %s", sealData, opened)
+	}
+
+	other, _, _ := GenerateIdentity()
+	if _, err := OpenSealed(sealed, other); !errors.Is(err, ErrOpenSealed) {
+		t.Fatalf("security.OpenSealed(other identity): want: %v, have: %v", ErrOpenSealed, err)
+	}
+	if _, err := OpenSealed(sealed, "a passphrase"); !errors.Is(err, ErrInvalidIdentity) {
+		t.Fatalf("security.OpenSealed(passphrase): want: %v, have: %v", ErrInvalidIdentity, err)
+	}
+	if _, err := SealRecipient(sealData, identity); !errors.Is(err, ErrInvalidRecipient) {
+		t.Fatalf("security.SealRecipient(identity): want: %v, have: %v", ErrInvalidRecipient, err)
+	}
+	if _, err := OpenSealed(sealData, identity); !errors.Is(err, ErrNotSealed) {
+		t.Fatalf("security.OpenSealed(plain): want: %v, have: %v", ErrNotSealed, err)
+	}
+}
@@ -3,20 +3,107 @@ package security
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 
 	passwordvalidator "github.com/wagslane/go-password-validator"
+	"golang.org/x/crypto/argon2"
 )
 
 const (
 	// minStrength is the lower limit a password has to be secure
 	minStrength = 60
+	// MinEntropy is the entropy in bits a password needs to pass PasswordStrength
+	MinEntropy = minStrength
+	// KDFSHA256 derives the AES key from the sha256 hash of the group key
+	KDFSHA256 = "sha256"
+	// KDFArgon2id derives the AES key using argon2id with a random salt
+	KDFArgon2id = "argon2id"
+	// DefaultKDF is the key derivation used for new vaults
+	DefaultKDF = KDFArgon2id
+
+	// argon2id parameters as recommended by RFC 9106 for memory constrained environments
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	saltLen       = 16
 )
 
+var (
+	ErrUnsupportedKDF = fmt.Errorf("key derivation of the vault is not supported")
+	ErrIndexTampered  = fmt.Errorf("plain text index of the vault has been modified")
+)
+
+// IsKDF reports whether sherlock supports the key derivation
+func IsKDF(kdf string) bool {
+	return kdf == KDFSHA256 || kdf == KDFArgon2id
+}
+
+// newHeader creates the header for a vault using the key derivation.
+// Every call generates a new salt
+func newHeader(kdf string) (Header, error) {
+	switch kdf {
+	case KDFSHA256:
+		return Header{KDF: KDFSHA256}, nil
+	case KDFArgon2id:
+		salt := make([]byte, saltLen)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return Header{}, err
+		}
+		return Header{
+			KDF:     KDFArgon2id,
+			Salt:    salt,
+			Time:    argon2Time,
+			Memory:  argon2Memory,
+			Threads: argon2Threads,
+		}, nil
+	default:
+		return Header{}, ErrUnsupportedKDF
+	}
+}
+
+// Current reports whether the vault of the header is written in the current
+// format with the default key derivation and its current parameters, i.e.
+// whether re-encrypting it would not change how it is protected
+func (h Header) Current() bool {
+	return h.Matches(DefaultKDF)
+}
+
+// Matches reports whether the vault of the header is written in the current
+// format with the kdf and its current parameters, i.e. whether encrypting the
+// vault again with the kdf would protect it the same way
+func (h Header) Matches(kdf string) bool {
+	if h.Version != FormatVersion || h.KDF != kdf {
+		return false
+	}
+	if kdf == KDFArgon2id {
+		return h.Time == argon2Time && h.Memory == argon2Memory && h.Threads == argon2Threads
+	}
+	return true
+}
+
+// deriveKey derives the AES key from the group key as described by the header
+func deriveKey(h Header, key string) ([]byte, error) {
+	switch h.KDF {
+	case KDFSHA256:
+		return hash(key)[:16], nil
+	case KDFArgon2id:
+		if len(h.Salt) == 0 || h.Time == 0 || h.Memory == 0 || h.Threads == 0 {
+			return nil, ErrMalformedVault
+		}
+		return argon2.IDKey([]byte(key), h.Salt, h.Time, h.Memory, h.Threads, argon2KeyLen), nil
+	default:
+		return nil, ErrUnsupportedKDF
+	}
+}
+
 func hash(key string) []byte {
 	b := sha256.Sum256([]byte(key))
 	hexB := hex.EncodeToString(b[:])
@@ -30,29 +117,33 @@ func InitWithDefault(key string, defaultVault interface{}) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	aesKey := hash(key)
-	block, err := aes.NewCipher(aesKey[:16])
+	return EncryptVault(byteVault, key, DefaultKDF)
+}
+
+// EncryptVault encrypts the data using the key derived with the kdf
+// and prefixes the result with the vault header
+func EncryptVault(b []byte, key string, kdf string) ([]byte, error) {
+	return EncryptVaultWithMeta(b, key, kdf, nil)
+}
+
+// EncryptVaultWithMeta encrypts the data like EncryptVault and stores
+// the meta data in plain text as part of the vault header. A Meta.Index
+// is authenticated with a MAC derived from the group key
+func EncryptVaultWithMeta(b []byte, key string, kdf string, meta *Meta) ([]byte, error) {
+	h, err := newHeader(kdf)
 	if err != nil {
 		return nil, err
 	}
-	encypted := make([]byte, aes.BlockSize+len(byteVault))
-	iv := encypted[:aes.BlockSize]
-
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	if meta != nil {
+		m := *meta
+		h.Meta = &m
+	}
+	aesKey, err := deriveKey(h, key)
+	if err != nil {
 		return nil, err
 	}
 
-	stream := cipher.NewCFBEncrypter(block, iv)
-	stream.XORKeyStream(encypted[aes.BlockSize:], byteVault)
-
-	return encypted, err
-}
-
-// EncryptVault encrypts the data using the key
-func EncryptVault(b []byte, key string) ([]byte, error) {
-	aeskey := hash(key)
-
-	block, err := aes.NewCipher(aeskey[:16])
+	block, err := aes.NewCipher(aesKey)
 	if err != nil {
 		return nil, err
 	}
@@ -61,37 +152,74 @@ func EncryptVault(b []byte, key string) ([]byte, error) {
 	iv := encrypted[:aes.BlockSize]
 
 	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	stream := cipher.NewCFBEncrypter(block, iv)
 
 	stream.XORKeyStream(encrypted[aes.BlockSize:], b)
 
-	return encrypted, err
+	if h.Meta != nil && len(h.Meta.Index) > 0 {
+		h.Meta.IndexMAC = indexMAC(aesKey, h.Meta.Index, encrypted)
+	}
+	return EncodeVault(h, encrypted)
 }
 
-// DecryptVault encrypts the data using the key
+// DecryptVault decrypts the data using the key. Vaults of an older
+// format version are migrated before decrypting them. ErrIndexTampered
+// is returned if the key is right but the plain text index does not match
 func DecryptVault(b []byte, key string, v interface{}) error {
-	aesKey := hash(key)
+	h, payload, err := DecodeVault(b)
+	if err != nil {
+		return err
+	}
+	if len(payload) < aes.BlockSize {
+		return ErrMalformedVault
+	}
+	aesKey, err := deriveKey(h, key)
+	if err != nil {
+		return err
+	}
 
-	block, err := aes.NewCipher(aesKey[:16])
+	// the index is checked before the payload is decrypted in place but
+	// reported after decrypting it so that a wrong key is told apart
+	indexOK := true
+	if h.Meta != nil && (len(h.Meta.Index) > 0 || len(h.Meta.IndexMAC) > 0) {
+		indexOK = hmac.Equal(h.Meta.IndexMAC, indexMAC(aesKey, h.Meta.Index, payload))
+	}
+
+	block, err := aes.NewCipher(aesKey)
 	if err != nil {
 		return err
 	}
 
-	decrypted := b[aes.BlockSize:]
+	decrypted := payload[aes.BlockSize:]
 
-	iv := b[:aes.BlockSize]
+	iv := payload[:aes.BlockSize]
 	stream := cipher.NewCFBDecrypter(block, iv)
 	stream.XORKeyStream(decrypted, decrypted)
 
 	if err := json.Unmarshal(decrypted, &v); err != nil {
 		return err
 	}
+	if !indexOK {
+		return ErrIndexTampered
+	}
 	return nil
 }
 
+// indexMAC authenticates the plain text index together with the encrypted
+// payload so that neither can be swapped without the other. The MAC key is
+// derived from the AES key and never used for encryption
+func indexMAC(aesKey, index, payload []byte) []byte {
+	macKey := sha256.Sum256(append([]byte("sherlock vault index"), aesKey...))
+	mac := hmac.New(sha256.New, macKey[:])
+	_ = binary.Write(mac, binary.BigEndian, uint64(len(index)))
+	mac.Write(index)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
 // PasswordStrength evaluates how strong the password is based on
 // the variety and diversity of the chosen characters
 func PasswordStrength(password string) error {
@@ -0,0 +1,103 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+)
+
+var (
+	ErrInvalidDeviceKey = fmt.Errorf("device key is malformed")
+)
+
+// GenerateDeviceKey creates a new ed25519 private key used to sign
+// the encrypted vault blobs written by this device
+func GenerateDeviceKey() ([]byte, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// revisionSize is the size of the revision prefixed to a vault signature
+const revisionSize = 8
+
+// revisionDomain separates the messages signed with a revision from the ones
+// signed before revisions existed => {gid}\x00{vault}
+const revisionDomain = "sherlock vault revision\x00"
+
+// SignVault signs the encrypted vault of a group at the revision with the
+// device key. The group id and the revision are part of the signed message so
+// that vaults cannot be swapped between groups and an older vault restored with
+// its signature shows a lower revision. The signature is prefixed with the
+// revision => {revision (8 bytes, big endian)}{ed25519 signature}
+func SignVault(deviceKey []byte, gid string, revision uint64, vault []byte) ([]byte, error) {
+	if len(deviceKey) != ed25519.PrivateKeySize {
+		return nil, ErrInvalidDeviceKey
+	}
+	sig := make([]byte, revisionSize, revisionSize+ed25519.SignatureSize)
+	binary.BigEndian.PutUint64(sig, revision)
+	return append(sig, ed25519.Sign(ed25519.PrivateKey(deviceKey), signedMessage(gid, revision, vault))...), nil
+}
+
+// VerifyVault reports whether the signature matches the encrypted vault
+// of the group and was created with the device key and returns the revision
+// it was signed at. Signatures written before revisions existed have revision 0
+func VerifyVault(deviceKey []byte, gid string, vault, signature []byte) (uint64, bool, error) {
+	if len(deviceKey) != ed25519.PrivateKeySize {
+		return 0, false, ErrInvalidDeviceKey
+	}
+	pub := ed25519.PrivateKey(deviceKey).Public().(ed25519.PublicKey)
+	switch len(signature) {
+	case ed25519.SignatureSize:
+		return 0, ed25519.Verify(pub, legacySignedMessage(gid, vault), signature), nil
+	case revisionSize + ed25519.SignatureSize:
+		revision := binary.BigEndian.Uint64(signature[:revisionSize])
+		return revision, ed25519.Verify(pub, signedMessage(gid, revision, vault), signature[revisionSize:]), nil
+	}
+	return 0, false, nil
+}
+
+// signedMessage builds the message signed for a vault at a revision
+// => {revisionDomain}{gid}\x00{revision}{vault}
+func signedMessage(gid string, revision uint64, vault []byte) []byte {
+	msg := make([]byte, 0, len(revisionDomain)+len(gid)+1+revisionSize+len(vault))
+	msg = append(msg, revisionDomain...)
+	msg = append(msg, gid...)
+	msg = append(msg, 0)
+	var rev [revisionSize]byte
+	binary.BigEndian.PutUint64(rev[:], revision)
+	msg = append(msg, rev[:]...)
+	return append(msg, vault...)
+}
+
+// legacySignedMessage builds the message signed for a vault before revisions
+// existed => {gid}\x00{vault}
+func legacySignedMessage(gid string, vault []byte) []byte {
+	msg := make([]byte, 0, len(gid)+1+len(vault))
+	msg = append(msg, gid...)
+	msg = append(msg, 0)
+	return append(msg, vault...)
+}
+
+// SignReport signs a json report with the device key and returns the
+// signature and the public key to verify it with. A json report never holds
+// a NUL byte so its signature cannot pass for the one of a vault
+func SignReport(deviceKey, report []byte) ([]byte, []byte, error) {
+	if len(deviceKey) != ed25519.PrivateKeySize {
+		return nil, nil, ErrInvalidDeviceKey
+	}
+	priv := ed25519.PrivateKey(deviceKey)
+	return ed25519.Sign(priv, report), priv.Public().(ed25519.PublicKey), nil
+}
+
+// VerifyReport reports whether the signature of the json report was created
+// with the private key of the public key
+func VerifyReport(publicKey, report, signature []byte) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(publicKey), report, signature)
+}
@@ -0,0 +1,91 @@
+package selfupdate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisign signature algorithms: Ed signs the message, ED signs its BLAKE2b-512 hash
+const (
+	algPure   = "Ed"
+	algHashed = "ED"
+)
+
+var (
+	ErrInvalidKey       = fmt.Errorf("invalid minisign public key")
+	ErrInvalidSignature = fmt.Errorf("invalid minisign signature")
+	ErrBadSignature     = fmt.Errorf("signature verification failed")
+)
+
+// PublicKey is a minisign public key
+type PublicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// ParsePublicKey parses the base64 encoded key of a minisign public key
+// file (its second line, starting with RW)
+func ParsePublicKey(s string) (PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != algPure {
+		return PublicKey{}, ErrInvalidKey
+	}
+	var pk PublicKey
+	copy(pk.id[:], b[2:10])
+	pk.key = ed25519.PublicKey(b[10:])
+	return pk, nil
+}
+
+// Verify checks a minisign signature file of the message: the signature of the
+// message as well as the global signature covering the trusted comment. The
+// trusted comment is returned for the caller to check what was signed
+func (pk PublicKey) Verify(message, minisig []byte) (string, error) {
+	lines := strings.Split(strings.TrimRight(string(minisig), "\r\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", ErrInvalidSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return "", ErrInvalidSignature
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", ErrInvalidSignature
+	}
+	if !bytes.Equal(sig[2:10], pk.id[:]) {
+		return "", fmt.Errorf("%w: signed with key %X, expected %X", ErrBadSignature, sig[2:10], pk.id)
+	}
+	switch string(sig[:2]) {
+	case algPure:
+	case algHashed:
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	default:
+		return "", ErrInvalidSignature
+	}
+	if !ed25519.Verify(pk.key, message, sig[10:]) {
+		return "", ErrBadSignature
+	}
+	trusted := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if !ed25519.Verify(pk.key, append(sig[10:], trusted...), global) {
+		return "", fmt.Errorf("%w: trusted comment", ErrBadSignature)
+	}
+	return trusted, nil
+}
+
+// trustedFields parses a trusted comment of key:value fields separated by
+// white space like minisign's timestamp:1556193335\tfile:sherlock_linux_amd64
+func trustedFields(trusted string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range strings.Fields(trusted) {
+		if i := strings.Index(field, ":"); i > 0 {
+			fields[field[:i]] = field[i+1:]
+		}
+	}
+	return fields
+}
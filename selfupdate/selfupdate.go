@@ -0,0 +1,214 @@
+// Package selfupdate replaces the running sherlock binary with the latest
+// release. Release binaries are only installed if their minisign signature
+// verifies with the release key sherlock was built with
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// FeedURL is the release feed of sherlock
+const FeedURL = "https://api.github.com/repos/KonstantinGasser/sherlock/releases/latest"
+
+const (
+	// maxBinarySize limits the size of a downloaded binary
+	maxBinarySize = 128 << 20
+	// maxFeedSize limits the size of the release feed and signatures
+	maxFeedSize = 1 << 20
+	// sigSuffix is appended to the asset name of a binary for its signature
+	sigSuffix = ".minisig"
+)
+
+var (
+	ErrNoAsset      = fmt.Errorf("release has no binary for this platform")
+	ErrDownload     = fmt.Errorf("download failed")
+	ErrNoReleaseKey = fmt.Errorf("sherlock was built without a release key to verify updates")
+)
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is the latest release read from the feed
+type Release struct {
+	Version string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Latest reads the latest release from the feed
+func Latest(ctx context.Context, client *http.Client, feed string) (*Release, error) {
+	b, err := get(ctx, client, feed, maxFeedSize)
+	if err != nil {
+		return nil, err
+	}
+	var release Release
+	if err := json.Unmarshal(b, &release); err != nil {
+		return nil, fmt.Errorf("%w: malformed release feed: %v", ErrDownload, err)
+	}
+	return &release, nil
+}
+
+// AssetName is the name of the release binary for a platform like sherlock_linux_amd64
+func AssetName(goos, goarch string) string {
+	name := "sherlock_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// asset returns the asset with the name
+func (r Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Download downloads the binary of the running platform and verifies its
+// signature. The trusted comment has to name the binary and the version of the
+// release so the signed binary of an older release is rejected
+func (r Release) Download(ctx context.Context, client *http.Client, key PublicKey) ([]byte, error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	binary, ok := r.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s missing in %s", ErrNoAsset, name, r.Version)
+	}
+	sig, ok := r.asset(name + sigSuffix)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not signed", ErrBadSignature, name)
+	}
+	signature, err := get(ctx, client, sig.URL, maxFeedSize)
+	if err != nil {
+		return nil, err
+	}
+	b, err := get(ctx, client, binary.URL, maxBinarySize)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := key.Verify(b, signature)
+	if err != nil {
+		return nil, err
+	}
+	// the signature of an older release is valid as well, only the trusted
+	// comment tells which binary of which release was signed
+	fields := trustedFields(trusted)
+	if fields["file"] != name || fields["version"] != r.Version {
+		return nil, fmt.Errorf("%w: signed for %s %s, expected %s %s", ErrBadSignature, fields["file"], fields["version"], name, r.Version)
+	}
+	return b, nil
+}
+
+// get downloads at most limit bytes
+func get(ctx context.Context, client *http.Client, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: %s", ErrDownload, url, resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w: %s larger than %d bytes", ErrDownload, url, limit)
+	}
+	return b, nil
+}
+
+// Newer reports whether the latest version is newer than the current one.
+// Versions are compared like v1.2.3; a current version which is no release
+// version (like dev) is considered older than any release
+func Newer(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses major, minor and patch of a version like v1.2.3
+func parseVersion(v string) ([3]int, bool) {
+	var parsed [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return parsed, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
+
+// Replace atomically replaces the executable at path with the binary. The binary
+// is written next to the executable and renamed over it so a failed update leaves
+// the executable untouched. Windows cannot replace a running executable, so there
+// the executable is moved aside to {path}.old first
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".new-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,209 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey creates a minisign key pair and returns the encoded public key
+func testKey(t *testing.T, id string) (string, ed25519.PrivateKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: want: nil, have: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(append([]byte(algPure+id), public...)), private
+}
+
+// minisign signs the message like minisign -S (hashed) or minisign -S -l (pure)
+func minisign(private ed25519.PrivateKey, id, alg string, message []byte, trusted string) []byte {
+	if alg == algHashed {
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	}
+	sig := ed25519.Sign(private, message)
+	global := ed25519.Sign(private, append(append([]byte{}, sig...), trusted...))
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append([]byte(alg+id), sig...)) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestVerify(t *testing.T) {
+	encoded, private := testKey(t, "sherlock")
+	key, err := ParsePublicKey(encoded)
+	if err != nil {
+		t.Fatalf("ParsePublicKey: want: nil, have: %v", err)
+	}
+	_, otherPrivate := testKey(t, "sherlock")
+	binary := []byte("release binary")
+
+	tt := []struct {
+		name    string
+		message []byte
+		sig     []byte
+		err     error
+	}{
+		{name: "hashed", message: binary, sig: minisign(private, "sherlock", algHashed, binary, "v1.2.0")},
+		{name: "pure", message: binary, sig: minisign(private, "sherlock", algPure, binary, "v1.2.0")},
+		{name: "modified binary", message: []byte("tampered binary"), sig: minisign(private, "sherlock", algHashed, binary, "v1.2.0"), err: ErrBadSignature},
+		{name: "other key", message: binary, sig: minisign(otherPrivate, "sherlock", algHashed, binary, "v1.2.0"), err: ErrBadSignature},
+		{name: "other key id", message: binary, sig: minisign(private, "watson12", algHashed, binary, "v1.2.0"), err: ErrBadSignature},
+		{name: "malformed", message: binary, sig: []byte("untrusted comment: nothing\n"), err: ErrInvalidSignature},
+	}
+	for _, tc := range tt {
+		if _, err := key.Verify(tc.message, tc.sig); !errors.Is(err, tc.err) {
+			t.Fatalf("[%s] PublicKey.Verify: want: %v, have: %v", tc.name, tc.err, err)
+		}
+	}
+
+	// the trusted comment is covered by the global signature
+	sig := minisign(private, "sherlock", algHashed, binary, "v1.2.0")
+	forged := []byte(string(sig[:len(sig)-len("v1.2.0\n")-89]) + "v9.9.9\n" + string(sig[len(sig)-89:]))
+	if _, err := key.Verify(binary, forged); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("PublicKey.Verify: want: %v, have: %v", ErrBadSignature, err)
+	}
+	if _, err := ParsePublicKey("RWQ-not-a-key"); err != ErrInvalidKey {
+		t.Fatalf("ParsePublicKey: want: %v, have: %v", ErrInvalidKey, err)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tt := []struct {
+		current string
+		latest  string
+		newer   bool
+	}{
+		{current: "v1.2.3", latest: "v1.2.4", newer: true},
+		{current: "v1.2.3", latest: "v1.10.0", newer: true},
+		{current: "1.2.3", latest: "v2", newer: true},
+		{current: "v1.2.3", latest: "v1.2.3"},
+		{current: "v1.3.0", latest: "v1.2.9"},
+		{current: "v1.2.3-rc1", latest: "v1.2.3"},
+		{current: "dev", latest: "v0.1.0", newer: true},
+		{current: "v1.0.0", latest: "nightly"},
+	}
+	for _, tc := range tt {
+		if newer := Newer(tc.current, tc.latest); newer != tc.newer {
+			t.Fatalf("Newer(%s, %s): want: %t, have: %t", tc.current, tc.latest, tc.newer, newer)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	encoded, private := testKey(t, "sherlock")
+	key, _ := ParsePublicKey(encoded)
+	binary := []byte("release binary")
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	trusted := "timestamp:1700000000\tfile:" + name + "\tversion:v1.2.0"
+	files := map[string][]byte{
+		"/" + name:             binary,
+		"/" + name + sigSuffix: minisign(private, "sherlock", algHashed, binary, trusted),
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			_ = json.NewEncoder(w).Encode(Release{Version: "v1.2.0", Assets: []Asset{
+				{Name: name, URL: server.URL + "/" + name},
+				{Name: name + sigSuffix, URL: server.URL + "/" + name + sigSuffix},
+				{Name: "sherlock_plan9_386", URL: server.URL + "/sherlock_plan9_386"},
+			}})
+			return
+		}
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	release, err := Latest(ctx, server.Client(), server.URL+"/latest")
+	if err != nil {
+		t.Fatalf("Latest: want: nil, have: %v", err)
+	}
+	if release.Version != "v1.2.0" {
+		t.Fatalf("Latest: want: v1.2.0, have: %s", release.Version)
+	}
+	b, err := release.Download(ctx, server.Client(), key)
+	if err != nil {
+		t.Fatalf("Release.Download: want: nil, have: %v", err)
+	}
+	if string(b) != string(binary) {
+		t.Fatalf("Release.Download: want: %q, have: %q", binary, b)
+	}
+
+	// a valid signature of another binary or an older release is rejected
+	for _, comment := range []string{
+		"timestamp:1700000000\tfile:sherlock_plan9_386\tversion:v1.2.0",
+		"timestamp:1690000000\tfile:" + name + "\tversion:v1.1.0",
+		"timestamp:1690000000\tfile:" + name,
+	} {
+		files["/"+name+sigSuffix] = minisign(private, "sherlock", algHashed, binary, comment)
+		if _, err := release.Download(ctx, server.Client(), key); !errors.Is(err, ErrBadSignature) {
+			t.Fatalf("Release.Download(%s): want: %v, have: %v", comment, ErrBadSignature, err)
+		}
+	}
+	files["/"+name+sigSuffix] = minisign(private, "sherlock", algHashed, binary, trusted)
+
+	// a binary replaced on the server is rejected
+	files["/"+name] = []byte("tampered binary")
+	if _, err := release.Download(ctx, server.Client(), key); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("Release.Download: want: %v, have: %v", ErrBadSignature, err)
+	}
+	delete(files, "/"+name+sigSuffix)
+	if _, err := release.Download(ctx, server.Client(), key); !errors.Is(err, ErrDownload) {
+		t.Fatalf("Release.Download: want: %v, have: %v", ErrDownload, err)
+	}
+	release.Assets = release.Assets[2:]
+	if _, err := release.Download(ctx, server.Client(), key); !errors.Is(err, ErrNoAsset) {
+		t.Fatalf("Release.Download: want: %v, have: %v", ErrNoAsset, err)
+	}
+	if _, err := Latest(ctx, server.Client(), server.URL+"/missing"); !errors.Is(err, ErrDownload) {
+		t.Fatalf("Latest: want: %v, have: %v", ErrDownload, err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-selfupdate")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: want: nil, have: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sherlock")
+	if err := ioutil.WriteFile(path, []byte("old binary"), 0750); err != nil {
+		t.Fatalf("ioutil.WriteFile: want: nil, have: %v", err)
+	}
+	if err := Replace(path, []byte("new binary")); err != nil {
+		t.Fatalf("Replace: want: nil, have: %v", err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if string(b) != "new binary" {
+		t.Fatalf("Replace: want: new binary, have: %q", b)
+	}
+	info, _ := os.Stat(path)
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0750|0111 {
+		t.Fatalf("Replace: want mode: %v, have: %v", os.FileMode(0751), info.Mode().Perm())
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("Replace: want: no temporary files left, have: %d files", len(entries))
+	}
+	if err := Replace(filepath.Join(dir, "missing"), []byte("new binary")); !os.IsNotExist(err) {
+		t.Fatalf("Replace: want: not exist error, have: %v", err)
+	}
+}
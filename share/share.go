@@ -0,0 +1,263 @@
+// Package share hands a single secret to someone on the local network. The
+// secret is served over HTTPS with a self-signed certificate under a random
+// token and only revealed a limited number of times before the link expires.
+// Opening the link does not reveal the secret; it has to be requested with a
+// button so link previews of chat apps cannot use up a view
+package share
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenSize is the number of random bytes of a link token
+const tokenSize = 24
+
+var (
+	ErrInvalidLink = fmt.Errorf("invalid share link")
+)
+
+// Secret is what a link reveals
+type Secret struct {
+	Name     string
+	Username string
+	Password string
+	URL      string
+}
+
+// Link serves a secret until it has been viewed Views times or expires
+type Link struct {
+	token   string
+	secret  Secret
+	views   int
+	expires time.Time
+	cert    tls.Certificate
+
+	mu     sync.Mutex
+	viewed int
+	done   chan struct{}
+	// OnView is called after every view with the number of views left
+	OnView func(left int)
+}
+
+// NewLink creates a link to the secret valid for the duration and number of
+// views. The certificate of the link is issued for the hosts (IPs or names)
+func NewLink(secret Secret, views int, valid time.Duration, hosts []string) (*Link, error) {
+	if views < 1 || valid <= 0 {
+		return nil, fmt.Errorf("%w: views and expiry must be positive", ErrInvalidLink)
+	}
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(valid)
+	cert, err := selfSigned(hosts, expires)
+	if err != nil {
+		return nil, err
+	}
+	return &Link{
+		token:   base64.RawURLEncoding.EncodeToString(token),
+		secret:  secret,
+		views:   views,
+		expires: expires,
+		cert:    cert,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Path returns the path of the link like so /{token}
+func (l *Link) Path() string {
+	return "/" + l.token
+}
+
+// Expires returns when the link expires
+func (l *Link) Expires() time.Time {
+	return l.expires
+}
+
+// Certificate returns the DER encoded certificate the link is served with
+func (l *Link) Certificate() []byte {
+	return l.cert.Certificate[0]
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate like browsers
+// show it so the recipient can verify it
+func (l *Link) Fingerprint() string {
+	sum := sha256.Sum256(l.Certificate())
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// Serve serves the link on the listener until all views are used, the link
+// expires or the context is done. It returns the number of views
+func (l *Link) Serve(ctx context.Context, ln net.Listener) (int, error) {
+	srv := &http.Server{
+		Handler:           l,
+		TLSConfig:         &tls.Config{Certificates: []tls.Certificate{l.cert}, MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ServeTLS(ln, "", "")
+	}()
+	timer := time.NewTimer(time.Until(l.expires))
+	defer timer.Stop()
+	var err error
+	select {
+	case <-l.done:
+	case <-timer.C:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case err = <-errs:
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdown)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.viewed, err
+}
+
+// ServeHTTP shows a page to reveal the secret on GET and reveals it on POST.
+// Any other path than the one of the link is not found
+func (l *Link) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if subtle.ConstantTimeCompare([]byte(r.URL.Path), []byte(l.Path())) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !l.available() {
+			http.Error(w, "this link has expired", http.StatusGone)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = revealPage.Execute(w, l.secret.Name)
+	case http.MethodPost:
+		left, ok := l.view()
+		if !ok {
+			http.Error(w, "this link has expired", http.StatusGone)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = secretPage.Execute(w, l.secret)
+		if l.OnView != nil {
+			l.OnView(left)
+		}
+		if left == 0 {
+			close(l.done)
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// available reports whether the link has views left and has not expired
+func (l *Link) available() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.viewed < l.views && time.Now().Before(l.expires)
+}
+
+// view uses up a view returning the views left
+func (l *Link) view() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.viewed >= l.views || !time.Now().Before(l.expires) {
+		return 0, false
+	}
+	l.viewed++
+	return l.views - l.viewed, true
+}
+
+// selfSigned creates an ECDSA certificate for the hosts valid until expires
+func selfSigned(hosts []string, expires time.Time) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "sherlock share"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     expires,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// LocalIPs returns the IPv4 addresses of the host other than loopback
+func LocalIPs() []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []string
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			ips = append(ips, ipnet.IP.To4().String())
+		}
+	}
+	return ips
+}
+
+var revealPage = template.Must(template.New("reveal").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>sherlock share</title></head>
+<body style="font-family:sans-serif;max-width:32em;margin:3em auto">
+<p>A credential for <b>{{.}}</b> has been shared with you. It can only be revealed a limited number of times.</p>
+<form method="post"><button type="submit">reveal</button></form>
+</body></html>
+`))
+
+var secretPage = template.Must(template.New("secret").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>sherlock share</title></head>
+<body style="font-family:sans-serif;max-width:32em;margin:3em auto">
+<h3>{{.Name}}</h3>
+<table>
+{{if .Username}}<tr><td>username</td><td><code>{{.Username}}</code></td></tr>{{end}}
+<tr><td>password</td><td><code>{{.Password}}</code></td></tr>
+{{if .URL}}<tr><td>url</td><td><code>{{.URL}}</code></td></tr>{{end}}
+</table>
+<p>Copy it now; this page cannot be reloaded.</p>
+</body></html>
+`))
@@ -0,0 +1,114 @@
+package share
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve starts serving the link on localhost and returns a client trusting
+// only the link certificate
+func serve(t *testing.T, link *Link) (string, *http.Client, chan int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	cert, err := x509.ParseCertificate(link.Certificate())
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	views := make(chan int, 1)
+	go func() {
+		n, _ := link.Serve(context.Background(), ln)
+		views <- n
+	}()
+	return "https://" + ln.Addr().String(), client, views
+}
+
+func TestLink(t *testing.T) {
+	secret := Secret{Name: "work@github", Username: "detective", Password: "<Sup3r$ecret>"}
+	link, err := NewLink(secret, 1, time.Minute, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("share.NewLink: want: nil, have: %v", err)
+	}
+	base, client, views := serve(t, link)
+
+	tt := []struct {
+		method, path string
+		status       int
+		secret       bool
+	}{
+		{method: http.MethodGet, path: "/wrong-token", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/wrong-token", status: http.StatusNotFound},
+		// opening the link (e.g. by a link preview) does not reveal the secret
+		{method: http.MethodGet, path: link.Path(), status: http.StatusOK},
+		{method: http.MethodPut, path: link.Path(), status: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: link.Path(), status: http.StatusOK, secret: true},
+	}
+	for _, tc := range tt {
+		req, _ := http.NewRequest(tc.method, base+tc.path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: want: nil, have: %v", tc.method, tc.path, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s %s: want: %d, have: %d", tc.method, tc.path, tc.status, resp.StatusCode)
+		}
+		// the password is html escaped
+		if have := strings.Contains(string(body), "&lt;Sup3r$ecret&gt;"); have != tc.secret {
+			t.Fatalf("%s %s: secret revealed: want: %v, have: %v", tc.method, tc.path, tc.secret, have)
+		}
+		if resp.Header.Get("Cache-Control") != "no-store" {
+			t.Fatalf("%s %s: Cache-Control: want: no-store, have: %q", tc.method, tc.path, resp.Header.Get("Cache-Control"))
+		}
+	}
+	select {
+	case n := <-views:
+		if n != 1 {
+			t.Fatalf("Link.Serve: want: 1 view, have: %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Link.Serve: did not return after the last view")
+	}
+	// the server is gone after the last view; a request reaching it anyway is refused
+	rec := httptest.NewRecorder()
+	link.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, link.Path(), nil))
+	if rec.Code != http.StatusGone {
+		t.Fatalf("POST %s: want: %d, have: %d", link.Path(), http.StatusGone, rec.Code)
+	}
+}
+
+func TestLinkExpires(t *testing.T) {
+	link, err := NewLink(Secret{Name: "work@github", Password: "secret"}, 3, 200*time.Millisecond, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("share.NewLink: want: nil, have: %v", err)
+	}
+	_, _, views := serve(t, link)
+	select {
+	case n := <-views:
+		if n != 0 {
+			t.Fatalf("Link.Serve: want: 0 views, have: %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Link.Serve: did not return after the link expired")
+	}
+	if link.available() {
+		t.Fatalf("Link.available: want: false, have: true")
+	}
+	if _, err := NewLink(Secret{}, 0, time.Minute, nil); err == nil {
+		t.Fatalf("share.NewLink(0 views): want: %v, have: nil", ErrInvalidLink)
+	}
+}
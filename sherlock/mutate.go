@@ -0,0 +1,115 @@
+package sherlock
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+)
+
+// Detail sets an optional value of an account added with Add
+type Detail = internal.FieldUpdate
+
+// WithUsername sets the username of a new account
+func WithUsername(username string) Detail {
+	return internal.WithUsername(username)
+}
+
+// WithURL sets the url of a new account
+func WithURL(url string) Detail {
+	return internal.WithURL(url)
+}
+
+// WithNote sets the note of a new account
+func WithNote(note string) Detail {
+	return internal.WithNote(note)
+}
+
+// WithTag sets the tag of a new account
+func WithTag(tag string) Detail {
+	return func(a *Account) error {
+		a.Tag = tag
+		return nil
+	}
+}
+
+// GeneratePassword returns a random password conforming to the password policy
+func GeneratePassword(length int) (string, error) {
+	return internal.AutoGeneratePassword(length)
+}
+
+// Add adds an account to the group of the query. The password must be secure
+func (v *Vault) Add(ctx context.Context, query, groupKey, password string, details ...Detail) error {
+	account, err := internal.NewAccount(query, password, "", false, details...)
+	if err != nil {
+		return err
+	}
+	return v.sh.UpdateState(ctx, query, groupKey, internal.OptAddAccount(account))
+}
+
+// Delete deletes the account of the query
+func (v *Vault) Delete(ctx context.Context, query, groupKey string) error {
+	return v.sh.UpdateState(ctx, query, groupKey, internal.OptAccDelete())
+}
+
+// Mutation changes a single value of an account, see Update
+type Mutation struct {
+	apply internal.StateOption
+	// rename is the new name of the account if the mutation renames it
+	rename string
+}
+
+// SetPassword changes the password. Unless insecure the password must be secure
+func SetPassword(password string, insecure bool) Mutation {
+	return Mutation{apply: internal.OptAccPassword(password, insecure)}
+}
+
+// Rename changes the name of the account
+func Rename(name string) Mutation {
+	return Mutation{apply: internal.OptAccName(name), rename: strings.TrimSpace(name)}
+}
+
+// SetTag changes the tag
+func SetTag(tag string) Mutation {
+	return Mutation{apply: internal.OptsAccTag(tag)}
+}
+
+// SetUsername changes the username
+func SetUsername(username string) Mutation {
+	return Mutation{apply: internal.OptAccUsername(username)}
+}
+
+// SetURL changes the url
+func SetURL(url string) Mutation {
+	return Mutation{apply: internal.OptAccURL(url)}
+}
+
+// SetNote changes the note
+func SetNote(note string) Mutation {
+	return Mutation{apply: internal.OptAccNote(note)}
+}
+
+// SetFavorite pins or unpins the account
+func SetFavorite(favorite bool) Mutation {
+	return Mutation{apply: internal.OptAccFavorite(favorite)}
+}
+
+// Update applies the mutations in order to the account of the query and writes the
+// group once. If a mutation fails nothing is written. Mutations following a Rename
+// apply to the renamed account
+func (v *Vault) Update(ctx context.Context, query, groupKey string, mutations ...Mutation) error {
+	if len(mutations) == 0 {
+		return ErrNothingToApply
+	}
+	return v.sh.UpdateState(ctx, query, groupKey, func(g *internal.Group, acc string) error {
+		for _, m := range mutations {
+			if err := m.apply(g, acc); err != nil {
+				return err
+			}
+			if m.rename != "" {
+				acc = m.rename
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,159 @@
+// Package sherlock embeds sherlock vaults in Go programs. It reads and writes the
+// same vaults as the sherlock CLI, so accounts added by a program are available to
+// the CLI and the other way around:
+//
+//	vault, err := sherlock.Open()
+//	if err != nil {
+//		return err
+//	}
+//	account, err := vault.Get(ctx, "work@github", groupKey)
+//
+// Accounts are addressed by queries like group@account. Every group is encrypted
+// with its own group key which is passed to each call and never stored
+package sherlock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/KonstantinGasser/sherlock/storage"
+)
+
+// DefaultGroup is the group created by Setup
+const DefaultGroup = "default"
+
+// Account is an account of a group. Its exported fields are part of the vault
+// format and therefore stable
+type Account = internal.Account
+
+// Group is a decrypted group vault holding its accounts
+type Group = internal.Group
+
+// ChangeEvent describes a change written to a group. It never holds any secret
+type ChangeEvent = internal.ChangeEvent
+
+// FileSystem stores the vaults, see the storage package for the available backends
+type FileSystem = storage.FileSystem
+
+var (
+	ErrNotSetup       = internal.ErrNotSetup
+	ErrAlreadySetup   = internal.ErrAlreadySetup
+	ErrNoSuchGroup    = internal.ErrNoSuchGroup
+	ErrNoSuchAccount  = internal.ErrNoSuchAccount
+	ErrAccountExists  = internal.ErrAccountExists
+	ErrWrongKey       = internal.ErrWrongKey
+	ErrInvalidQuery   = internal.ErrInvalidQuery
+	ErrReadOnlyGroup  = internal.ErrReadOnlyGroup
+	ErrGroupExists    = fs.ErrGroupExists
+	ErrStopIteration  = fmt.Errorf("iteration stopped")
+	ErrNothingToApply = fmt.Errorf("no mutation to apply")
+)
+
+// Vault gives access to the groups of a storage backend. A Vault is safe for
+// concurrent use once its observers are registered: changes of the same group
+// are serialized within the process. Other processes writing the same vaults,
+// e.g. the CLI, are not synchronized with
+type Vault struct {
+	sh *internal.Sherlock
+}
+
+// Open opens the vaults of the backend configured for the CLI (~/.sherlock by
+// default, see storage.Open)
+func Open() (*Vault, error) {
+	fileSystem, err := storage.Open()
+	if err != nil {
+		return nil, err
+	}
+	return OpenWith(fileSystem), nil
+}
+
+// OpenWith opens the vaults stored in the FileSystem, e.g. an in-memory
+// backend created by storage.New(storage.Memory, nil)
+func OpenWith(fileSystem FileSystem) *Vault {
+	return &Vault{sh: internal.NewSherlock(fileSystem)}
+}
+
+// Observe registers a function called after every change written through the Vault
+func (v *Vault) Observe(observer func(ctx context.Context, events []ChangeEvent)) {
+	v.sh.Observe(observer)
+}
+
+// IsSetUp returns ErrNotSetup if the default group does not exist yet
+func (v *Vault) IsSetUp(ctx context.Context) error {
+	return v.sh.IsSetUp(ctx)
+}
+
+// Setup creates the default group encrypted with the group key
+func (v *Vault) Setup(ctx context.Context, groupKey string) error {
+	if err := v.sh.IsSetUp(ctx); err == nil {
+		return ErrAlreadySetup
+	}
+	return v.sh.Setup(ctx, groupKey)
+}
+
+// CreateGroup creates an empty group. The group key must be secure
+func (v *Vault) CreateGroup(ctx context.Context, gid, groupKey string) error {
+	return v.sh.SetupGroup(ctx, gid, groupKey, false)
+}
+
+// DeleteGroup irreversibly deletes a group and all of its accounts
+func (v *Vault) DeleteGroup(ctx context.Context, gid string) error {
+	return v.sh.DeleteGroup(ctx, gid)
+}
+
+// Groups returns the names of all groups
+func (v *Vault) Groups(ctx context.Context) ([]string, error) {
+	return v.sh.ReadRegisteredGroups(ctx)
+}
+
+// Group decrypts a group
+func (v *Vault) Group(ctx context.Context, gid, groupKey string) (*Group, error) {
+	return v.sh.LoadGroup(ctx, gid, groupKey)
+}
+
+// Get returns the account of a query like group@account
+func (v *Vault) Get(ctx context.Context, query, groupKey string) (*Account, error) {
+	return v.sh.GetAccount(ctx, query, groupKey)
+}
+
+// Each calls fn for every account of the group in the stored order. Returning
+// ErrStopIteration stops the iteration without an error; any other error stops
+// it and is returned
+func (v *Vault) Each(ctx context.Context, gid, groupKey string, fn func(*Account) error) error {
+	group, err := v.sh.LoadGroup(ctx, gid, groupKey)
+	if err != nil {
+		return err
+	}
+	for _, account := range group.Accounts {
+		if err := fn(account); err != nil {
+			if err == ErrStopIteration {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Find returns the accounts of the group matching every filter, e.g.
+// FilterByTag
+func (v *Vault) Find(ctx context.Context, gid, groupKey string, filters ...func(*Account) bool) ([]*Account, error) {
+	var found []*Account
+	err := v.Each(ctx, gid, groupKey, func(a *Account) error {
+		for _, match := range filters {
+			if !match(a) {
+				return nil
+			}
+		}
+		found = append(found, a)
+		return nil
+	})
+	return found, err
+}
+
+// FilterByTag matches accounts with the tag
+func FilterByTag(tag string) func(*Account) bool {
+	return internal.FilterByTag(tag)
+}
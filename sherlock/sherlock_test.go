@@ -0,0 +1,149 @@
+package sherlock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/storage"
+)
+
+const (
+	defaultKey = "default_group_key"
+	workKey    = "Tr0ub4dor&3-horse-staple"
+)
+
+func memVault(t *testing.T) *Vault {
+	fileSystem, err := storage.New(storage.Memory, nil)
+	if err != nil {
+		t.Fatalf("storage.New: want: nil, have: %v", err)
+	}
+	vault := OpenWith(fileSystem)
+	if err := vault.Setup(context.Background(), defaultKey); err != nil {
+		t.Fatalf("vault.Setup: want: nil, have: %v", err)
+	}
+	return vault
+}
+
+func TestVault(t *testing.T) {
+	ctx := context.Background()
+	vault := memVault(t)
+	if err := vault.Setup(ctx, defaultKey); err != ErrAlreadySetup {
+		t.Fatalf("vault.Setup: want: %v, have: %v", ErrAlreadySetup, err)
+	}
+	if err := vault.CreateGroup(ctx, "work", workKey); err != nil {
+		t.Fatalf("vault.CreateGroup: want: nil, have: %v", err)
+	}
+	if err := vault.CreateGroup(ctx, "work", workKey); !errors.Is(err, ErrGroupExists) {
+		t.Fatalf("vault.CreateGroup: want: %v, have: %v", ErrGroupExists, err)
+	}
+	var events []ChangeEvent
+	vault.Observe(func(ctx context.Context, changes []ChangeEvent) {
+		events = append(events, changes...)
+	})
+
+	for _, name := range []string{"github", "jira", "wiki"} {
+		tag := "dev"
+		if name == "wiki" {
+			tag = "docs"
+		}
+		if err := vault.Add(ctx, "work@"+name, workKey, "S3cure-"+name+"-horse-staple", WithUsername("watson"), WithTag(tag)); err != nil {
+			t.Fatalf("vault.Add: want: nil, have: %v", err)
+		}
+	}
+	if err := vault.Add(ctx, "work@weak", workKey, "1234"); err == nil {
+		t.Fatalf("vault.Add: want: insecure password error, have: nil")
+	}
+	if err := vault.Add(ctx, "work@github", "wrong-key", "S3cure-github-horse-staple"); err != ErrWrongKey {
+		t.Fatalf("vault.Add: want: %v, have: %v", ErrWrongKey, err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("vault.Observe: want: 3 events, have: %v", events)
+	}
+
+	account, err := vault.Get(ctx, "work@github", workKey)
+	if err != nil {
+		t.Fatalf("vault.Get: want: nil, have: %v", err)
+	}
+	if account.Username != "watson" || account.Tag != "dev" {
+		t.Fatalf("vault.Get: want: watson #dev, have: %s #%s", account.Username, account.Tag)
+	}
+	if _, err := vault.Get(ctx, "work@gitlab", workKey); !errors.Is(err, ErrNoSuchAccount) {
+		t.Fatalf("vault.Get: want: %v, have: %v", ErrNoSuchAccount, err)
+	}
+	if _, err := vault.Get(ctx, "private@github", workKey); !errors.Is(err, ErrNoSuchGroup) {
+		t.Fatalf("vault.Get: want: %v, have: %v", ErrNoSuchGroup, err)
+	}
+
+	found, err := vault.Find(ctx, "work", workKey, FilterByTag("dev"))
+	if err != nil || len(found) != 2 {
+		t.Fatalf("vault.Find: want: 2 accounts, have: %d (%v)", len(found), err)
+	}
+	var visited int
+	if err := vault.Each(ctx, "work", workKey, func(a *Account) error {
+		if visited++; a.Name == "jira" {
+			return ErrStopIteration
+		}
+		return nil
+	}); err != nil || visited != 2 {
+		t.Fatalf("vault.Each: want: 2 accounts visited, have: %d (%v)", visited, err)
+	}
+
+	groups, err := vault.Groups(ctx)
+	if err != nil || len(groups) != 2 {
+		t.Fatalf("vault.Groups: want: [default work], have: %v (%v)", groups, err)
+	}
+	if err := vault.Delete(ctx, "work@wiki", workKey); err != nil {
+		t.Fatalf("vault.Delete: want: nil, have: %v", err)
+	}
+	if err := vault.DeleteGroup(ctx, "work"); err != nil {
+		t.Fatalf("vault.DeleteGroup: want: nil, have: %v", err)
+	}
+	if _, err := vault.Group(ctx, "work", workKey); !errors.Is(err, ErrNoSuchGroup) {
+		t.Fatalf("vault.Group: want: %v, have: %v", ErrNoSuchGroup, err)
+	}
+}
+
+func TestVaultUpdate(t *testing.T) {
+	ctx := context.Background()
+	vault := memVault(t)
+	for _, name := range []string{"github", "jira"} {
+		if err := vault.Add(ctx, "default@"+name, defaultKey, "S3cure-"+name+"-horse-staple"); err != nil {
+			t.Fatalf("vault.Add: want: nil, have: %v", err)
+		}
+	}
+	if err := vault.Update(ctx, "default@github", defaultKey); err != ErrNothingToApply {
+		t.Fatalf("vault.Update: want: %v, have: %v", ErrNothingToApply, err)
+	}
+	// mutations following the rename apply to the renamed account
+	if err := vault.Update(ctx, "default@github", defaultKey, Rename("gitlab"), SetURL("https://gitlab.com"), SetFavorite(true)); err != nil {
+		t.Fatalf("vault.Update: want: nil, have: %v", err)
+	}
+	account, err := vault.Get(ctx, "default@gitlab", defaultKey)
+	if err != nil {
+		t.Fatalf("vault.Get: want: nil, have: %v", err)
+	}
+	if account.URL != "https://gitlab.com" || !account.Favorite {
+		t.Fatalf("vault.Update: want: url and favorite set, have: %+v", account)
+	}
+	// a failing mutation writes nothing
+	if err := vault.Update(ctx, "default@gitlab", defaultKey, SetNote("moved"), Rename("jira")); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("vault.Update: want: %v, have: %v", ErrAccountExists, err)
+	}
+	if account, _ := vault.Get(ctx, "default@gitlab", defaultKey); account.Note != "" {
+		t.Fatalf("vault.Update: want: no note, have: %q", account.Note)
+	}
+	if err := vault.Update(ctx, "default@gitlab", defaultKey, SetPassword("short", false)); err == nil {
+		t.Fatalf("vault.Update: want: insecure password error, have: nil")
+	}
+	password, err := GeneratePassword(24)
+	if err != nil {
+		t.Fatalf("sherlock.GeneratePassword: want: nil, have: %v", err)
+	}
+	if err := vault.Update(ctx, "default@gitlab", defaultKey, SetPassword(password, false), SetTag("scm")); err != nil {
+		t.Fatalf("vault.Update: want: nil, have: %v", err)
+	}
+	if account, _ := vault.Get(ctx, "default@gitlab", defaultKey); account.Password != password || account.Tag != "scm" {
+		t.Fatalf("vault.Update: want: new password and tag, have: %+v", account)
+	}
+}
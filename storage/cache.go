@@ -0,0 +1,382 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/spf13/afero"
+)
+
+// cacheRoot is the directory below ~/.sherlock holding a cache per remote
+const cacheRoot = "cache"
+
+// groupsListKey caches the list of registered groups
+const groupsListKey = "groups.json"
+
+// absentSuffix marks a file known not to exist on the remote so an offline
+// read of an optional file like config.json is not an error
+const absentSuffix = ".absent"
+
+// states of a cached vault reported by CacheStatus
+const (
+	CacheCurrent = "current"
+	CacheStale   = "stale"
+	CacheRemoved = "removed"
+	CacheOffline = "offline"
+)
+
+var ErrStaleCache = fmt.Errorf("the vault was changed on the remote since it was read (run the command again)")
+
+// cacheFS caches the files read from a remote FileSystem. Reads go to the remote and
+// refresh the cache; if the remote cannot be reached the cached copy is returned so
+// reads work offline. Writes always require the remote. Cached are the files a read
+// needs: the encrypted vaults, their signatures, the sealed keyring, the list of
+// groups, the icons and the config, which is plain text like on the remote. The
+// webhook key is never cached since nothing offline needs it, the device files
+// stay on the device (see WithDevice)
+type cacheFS struct {
+	remote FileSystem
+	cache  afero.Fs
+}
+
+// WithCache decorates the remote FileSystem caching the files read in the cache
+func WithCache(remote FileSystem, cache afero.Fs) FileSystem {
+	return cacheFS{remote: remote, cache: cache}
+}
+
+// Revision identifies the content of a cached or remote file
+func Revision(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// offline reports whether the remote failed in a way the cache can stand in for
+func offline(ctx context.Context, err error) bool {
+	return err != nil && !os.IsNotExist(err) && ctx.Err() == nil
+}
+
+// read reads the file from the remote keeping the cache in sync or from the
+// cache if the remote cannot be reached
+func (c cacheFS) read(ctx context.Context, key string, remote func() ([]byte, error)) ([]byte, error) {
+	b, err := remote()
+	if err == nil {
+		c.store(key, b)
+		return b, nil
+	}
+	if os.IsNotExist(err) {
+		_ = c.cache.Remove(key)
+		c.store(key+absentSuffix, nil)
+		return nil, err
+	}
+	if !offline(ctx, err) {
+		return nil, err
+	}
+	cached, cacheErr := afero.ReadFile(c.cache, key)
+	if cacheErr == nil {
+		return cached, nil
+	}
+	if c.cached(key + absentSuffix) {
+		return nil, &os.PathError{Op: "read", Path: key, Err: os.ErrNotExist}
+	}
+	return nil, err
+}
+
+// store caches the file. A failing cache never fails the operation on the remote
+func (c cacheFS) store(key string, b []byte) {
+	if err := c.cache.MkdirAll(path.Dir(key), 0700); err != nil {
+		return
+	}
+	if err := afero.WriteFile(c.cache, key, b, 0600); err == nil && path.Ext(key) != absentSuffix {
+		_ = c.cache.Remove(key + absentSuffix)
+	}
+}
+
+// cached reports whether the file is in the cache
+func (c cacheFS) cached(key string) bool {
+	_, err := c.cache.Stat(key)
+	return err == nil
+}
+
+func vaultKeyOf(gid string) string     { return path.Join(groupsKey, gid, vaultKey) }
+func signatureKeyOf(gid string) string { return path.Join(groupsKey, gid, signatureKey) }
+
+func (c cacheFS) InitFs(ctx context.Context, initVault []byte) error {
+	if err := c.remote.InitFs(ctx, initVault); err != nil {
+		return err
+	}
+	c.store(vaultKeyOf("default"), initVault)
+	return nil
+}
+
+func (c cacheFS) CreateGroup(ctx context.Context, name string, initVault []byte) error {
+	if err := c.remote.CreateGroup(ctx, name, initVault); err != nil {
+		return err
+	}
+	c.store(vaultKeyOf(name), initVault)
+	return nil
+}
+
+// GroupExists answers from the cache if the remote cannot be reached
+func (c cacheFS) GroupExists(ctx context.Context, name string) error {
+	err := c.remote.GroupExists(ctx, name)
+	if err == nil || err == fs.ErrGroupExists || !offline(ctx, err) {
+		return err
+	}
+	if c.cached(vaultKeyOf(name)) {
+		return fs.ErrGroupExists
+	}
+	return err
+}
+
+// VaultExists answers from the cache if the remote cannot be reached
+func (c cacheFS) VaultExists(ctx context.Context, group string) error {
+	err := c.remote.VaultExists(ctx, group)
+	if err == nil || err == fs.ErrNoSuchVault || !offline(ctx, err) {
+		return err
+	}
+	if c.cached(vaultKeyOf(group)) {
+		return fs.ErrNoSuchVault
+	}
+	return err
+}
+
+func (c cacheFS) ReadGroupVault(ctx context.Context, group string) ([]byte, error) {
+	return c.read(ctx, vaultKeyOf(group), func() ([]byte, error) { return c.remote.ReadGroupVault(ctx, group) })
+}
+
+func (c cacheFS) Delete(ctx context.Context, gid string) error {
+	if err := c.remote.Delete(ctx, gid); err != nil {
+		return err
+	}
+	_ = c.cache.RemoveAll(path.Join(groupsKey, gid))
+	return nil
+}
+
+// Write replaces the vault on the remote unless it was changed there since this
+// device read it. The vault read last is cached, so a different revision on the
+// remote means another device wrote the group in the meantime
+func (c cacheFS) Write(ctx context.Context, gid string, data []byte) error {
+	key := vaultKeyOf(gid)
+	current, err := c.remote.ReadGroupVault(ctx, gid)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if cached, cacheErr := afero.ReadFile(c.cache, key); err == nil && cacheErr == nil && Revision(cached) != Revision(current) {
+		c.store(key, current)
+		return ErrStaleCache
+	}
+	if err := c.remote.Write(ctx, gid, data); err != nil {
+		return err
+	}
+	c.store(key, data)
+	return nil
+}
+
+func (c cacheFS) LockGroup(ctx context.Context, gid string) (func(), error) {
+	return c.remote.LockGroup(ctx, gid)
+}
+
+func (c cacheFS) ReadRegisteredGroups(ctx context.Context) ([]string, error) {
+	b, err := c.read(ctx, groupsListKey, func() ([]byte, error) {
+		groups, err := c.remote.ReadRegisteredGroups(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(groups)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []string
+	if err := json.Unmarshal(b, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ReadDeviceKey is not cached since the device key never leaves the device,
+// see WithDevice. The remote only holds keys of earlier versions
+func (c cacheFS) ReadDeviceKey(ctx context.Context) ([]byte, error) {
+	return c.remote.ReadDeviceKey(ctx)
+}
+
+func (c cacheFS) WriteDeviceKey(ctx context.Context, key []byte) error {
+	return c.remote.WriteDeviceKey(ctx, key)
+}
+
+func (c cacheFS) ReadSignature(ctx context.Context, gid string) ([]byte, error) {
+	return c.read(ctx, signatureKeyOf(gid), func() ([]byte, error) { return c.remote.ReadSignature(ctx, gid) })
+}
+
+func (c cacheFS) WriteSignature(ctx context.Context, gid string, sig []byte) error {
+	if err := c.remote.WriteSignature(ctx, gid, sig); err != nil {
+		return err
+	}
+	c.store(signatureKeyOf(gid), sig)
+	return nil
+}
+
+func (c cacheFS) ReadIcon(ctx context.Context, name string) ([]byte, error) {
+	return c.read(ctx, path.Join(iconsKey, name), func() ([]byte, error) { return c.remote.ReadIcon(ctx, name) })
+}
+
+func (c cacheFS) WriteIcon(ctx context.Context, name string, icon []byte) error {
+	if err := c.remote.WriteIcon(ctx, name, icon); err != nil {
+		return err
+	}
+	c.store(path.Join(iconsKey, name), icon)
+	return nil
+}
+
+func (c cacheFS) ClearIcons(ctx context.Context) error {
+	if err := c.remote.ClearIcons(ctx); err != nil {
+		return err
+	}
+	_ = c.cache.RemoveAll(iconsKey)
+	return nil
+}
+
+func (c cacheFS) ReadConfig(ctx context.Context) ([]byte, error) {
+	return c.read(ctx, configKey, func() ([]byte, error) { return c.remote.ReadConfig(ctx) })
+}
+
+func (c cacheFS) WriteConfig(ctx context.Context, config []byte) error {
+	if err := c.remote.WriteConfig(ctx, config); err != nil {
+		return err
+	}
+	c.store(configKey, config)
+	return nil
+}
+
+// ReadWebhookKey is not cached since webhooks cannot be sent offline anyway
+func (c cacheFS) ReadWebhookKey(ctx context.Context) ([]byte, error) {
+	return c.remote.ReadWebhookKey(ctx)
+}
+
+func (c cacheFS) WriteWebhookKey(ctx context.Context, key []byte) error {
+	return c.remote.WriteWebhookKey(ctx, key)
+}
+
+func (c cacheFS) ReadKeyring(ctx context.Context) ([]byte, error) {
+	return c.read(ctx, keyringKey, func() ([]byte, error) { return c.remote.ReadKeyring(ctx) })
+}
+
+func (c cacheFS) WriteKeyring(ctx context.Context, keyring []byte) error {
+	if err := c.remote.WriteKeyring(ctx, keyring); err != nil {
+		return err
+	}
+	c.store(keyringKey, keyring)
+	return nil
+}
+
+// ReadUnlockLog is not cached since every device logs its own unlocks
+func (c cacheFS) ReadUnlockLog(ctx context.Context) ([]byte, error) {
+	return c.remote.ReadUnlockLog(ctx)
+}
+
+func (c cacheFS) WriteUnlockLog(ctx context.Context, log []byte) error {
+	return c.remote.WriteUnlockLog(ctx, log)
+}
+
+// ReadRevisions is not cached since the revisions stay on the device with the
+// device key
+func (c cacheFS) ReadRevisions(ctx context.Context) ([]byte, error) {
+	return c.remote.ReadRevisions(ctx)
+}
+
+func (c cacheFS) WriteRevisions(ctx context.Context, revisions []byte) error {
+	return c.remote.WriteRevisions(ctx, revisions)
+}
+
+// Cached reports whether the backend of the config is cached. Remote backends
+// are cached unless the config disables it
+func (config Config) Cached() bool {
+	return config.Remote() && (config.Cache == nil || *config.Cache)
+}
+
+// CachePath is the directory caching the remote of the config. Every remote
+// has its own cache so switching remotes never mixes their vaults
+func CachePath(config Config) string {
+	return filepath.Join(filepath.Dir(ConfigPath()), cacheRoot, configID(config))
+}
+
+// configID identifies the backend and options of the config
+func configID(config Config) string {
+	keys := make([]string, 0, len(config.Options))
+	for k := range config.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	id := config.Backend
+	for _, k := range keys {
+		id += "\x00" + k + "=" + config.Options[k]
+	}
+	return Revision([]byte(id))[:16]
+}
+
+// CacheEntry describes a cached vault
+type CacheEntry struct {
+	Group    string
+	Size     int64
+	Cached   time.Time
+	Revision string
+	// State compares the cached vault with the remote: CacheCurrent, CacheStale,
+	// CacheRemoved or CacheOffline if the remote cannot be reached
+	State string
+}
+
+// CacheStatus compares the vaults cached for the config with the remote
+func CacheStatus(ctx context.Context, config Config, remote FileSystem) ([]CacheEntry, error) {
+	cache := afero.NewBasePathFs(afero.NewOsFs(), CachePath(config))
+	gids, err := afero.ReadDir(cache, groupsKey)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	entries := make([]CacheEntry, 0, len(gids))
+	for _, gid := range gids {
+		key := vaultKeyOf(gid.Name())
+		info, err := cache.Stat(key)
+		if err != nil {
+			continue
+		}
+		cached, err := afero.ReadFile(cache, key)
+		if err != nil {
+			return nil, err
+		}
+		entry := CacheEntry{
+			Group:    gid.Name(),
+			Size:     info.Size(),
+			Cached:   info.ModTime(),
+			Revision: Revision(cached),
+		}
+		current, err := remote.ReadGroupVault(ctx, gid.Name())
+		switch {
+		case err == nil && Revision(current) == entry.Revision:
+			entry.State = CacheCurrent
+		case err == nil:
+			entry.State = CacheStale
+		case os.IsNotExist(err):
+			entry.State = CacheRemoved
+		case offline(ctx, err):
+			entry.State = CacheOffline
+		default:
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ClearCache removes the cache of the config
+func ClearCache(config Config) error {
+	return os.RemoveAll(CachePath(config))
+}
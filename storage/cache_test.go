@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/spf13/afero"
+)
+
+var errUnreachable = fmt.Errorf("remote unreachable")
+
+// flakyStore is a memStore which fails every operation while down
+type flakyStore struct {
+	*memStore
+	down bool
+}
+
+func (f *flakyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if f.down {
+		return nil, errUnreachable
+	}
+	return f.memStore.Get(ctx, key)
+}
+
+func (f *flakyStore) Put(ctx context.Context, key string, data []byte) error {
+	if f.down {
+		return errUnreachable
+	}
+	return f.memStore.Put(ctx, key, data)
+}
+
+func (f *flakyStore) Delete(ctx context.Context, key string) error {
+	if f.down {
+		return errUnreachable
+	}
+	return f.memStore.Delete(ctx, key)
+}
+
+func (f *flakyStore) List(ctx context.Context, prefix string) ([]string, error) {
+	if f.down {
+		return nil, errUnreachable
+	}
+	return f.memStore.List(ctx, prefix)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyStore{memStore: newMemStore()}
+	sh := internal.NewSherlock(WithCache(FromObjects(store), afero.NewMemMapFs()))
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	account, err := internal.NewAccount("default@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", internal.OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	if _, err := sh.ReadRegisteredGroups(ctx); err != nil {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: nil, have: %v", err)
+	}
+	if _, err := sh.Config(ctx); err != nil {
+		t.Fatalf("sherlock.Config: want: nil, have: %v", err)
+	}
+
+	// reads are served by the cache while the remote is down
+	store.down = true
+	if _, err := sh.GetAccount(ctx, "default@github", "default_group_key"); err != nil {
+		t.Fatalf("sherlock.GetAccount (offline): want: nil, have: %v", err)
+	}
+	if groups, err := sh.ReadRegisteredGroups(ctx); err != nil || len(groups) != 1 {
+		t.Fatalf("sherlock.ReadRegisteredGroups (offline): want: [default], have: %v, %v", groups, err)
+	}
+	if _, err := sh.Config(ctx); err != nil {
+		t.Fatalf("sherlock.Config (offline): want: nil, have: %v", err)
+	}
+	if _, err := sh.LoadGroup(ctx, "work", "key"); err == nil {
+		t.Fatalf("sherlock.LoadGroup (offline, never cached): want: error, have: nil")
+	}
+	// writes require the remote
+	if err := sh.UpdateState(ctx, "default@github", "default_group_key", internal.OptAccName("gitlab")); !errors.Is(err, errUnreachable) {
+		t.Fatalf("sherlock.UpdateState (offline): want: %v, have: %v", errUnreachable, err)
+	}
+	store.down = false
+
+	// another device changes the vault after this device read it
+	other := internal.NewSherlock(FromObjects(store.memStore))
+	if err := other.UpdateState(ctx, "default@github", "default_group_key", internal.OptAccName("gitlab")); err != nil {
+		t.Fatalf("sherlock.UpdateState (other device): want: nil, have: %v", err)
+	}
+	cached := WithCache(FromObjects(store), afero.NewMemMapFs())
+	vault, err := cached.ReadGroupVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("ReadGroupVault: want: nil, have: %v", err)
+	}
+	if err := other.UpdateState(ctx, "default@gitlab", "default_group_key", internal.OptAccName("github")); err != nil {
+		t.Fatalf("sherlock.UpdateState (other device): want: nil, have: %v", err)
+	}
+	if err := cached.Write(ctx, "default", vault); !errors.Is(err, ErrStaleCache) {
+		t.Fatalf("Write (stale): want: %v, have: %v", ErrStaleCache, err)
+	}
+	// the stale write refreshed the cache so the next write succeeds
+	if err := cached.Write(ctx, "default", vault); err != nil {
+		t.Fatalf("Write: want: nil, have: %v", err)
+	}
+}
+
+func TestCacheAbsent(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyStore{memStore: newMemStore()}
+	cached := WithCache(FromObjects(store), afero.NewMemMapFs())
+	if _, err := cached.ReadConfig(ctx); !os.IsNotExist(err) {
+		t.Fatalf("ReadConfig: want: not exist, have: %v", err)
+	}
+	store.down = true
+	// the remote reported the file missing before so it is still missing offline
+	if _, err := cached.ReadConfig(ctx); !os.IsNotExist(err) {
+		t.Fatalf("ReadConfig (offline): want: not exist, have: %v", err)
+	}
+	// a file never read cannot be answered offline
+	if _, err := cached.ReadDeviceKey(ctx); !errors.Is(err, errUnreachable) {
+		t.Fatalf("ReadDeviceKey (offline): want: %v, have: %v", errUnreachable, err)
+	}
+	store.down = false
+	if err := cached.WriteConfig(ctx, []byte("{}")); err != nil {
+		t.Fatalf("WriteConfig: want: nil, have: %v", err)
+	}
+	if err := cached.WriteWebhookKey(ctx, []byte("key")); err != nil {
+		t.Fatalf("WriteWebhookKey: want: nil, have: %v", err)
+	}
+	store.down = true
+	if b, err := cached.ReadConfig(ctx); err != nil || string(b) != "{}" {
+		t.Fatalf("ReadConfig (offline): want: {}, have: %q, %v", b, err)
+	}
+	// secrets nothing offline needs are never cached
+	if _, err := cached.ReadWebhookKey(ctx); !errors.Is(err, errUnreachable) {
+		t.Fatalf("ReadWebhookKey (offline): want: %v, have: %v", errUnreachable, err)
+	}
+}
+
+func TestConfigCached(t *testing.T) {
+	no := false
+	tt := []struct {
+		config Config
+		cached bool
+	}{
+		{config: Config{Backend: Local}, cached: false},
+		{config: Config{Backend: Memory}, cached: false},
+		{config: Config{Backend: Plugin, Options: map[string]string{"name": "s3"}}, cached: true},
+		{config: Config{Backend: Plugin, Options: map[string]string{"name": "s3"}, Cache: &no}, cached: false},
+	}
+	for _, tc := range tt {
+		if cached := tc.config.Cached(); cached != tc.cached {
+			t.Fatalf("Config.Cached(%+v): want: %v, have: %v", tc.config, tc.cached, cached)
+		}
+	}
+	a := CachePath(Config{Backend: Plugin, Options: map[string]string{"name": "s3", "url": "s3://a"}})
+	b := CachePath(Config{Backend: Plugin, Options: map[string]string{"name": "s3", "url": "s3://b"}})
+	if a == b {
+		t.Fatalf("CachePath: want: a cache per remote, have: %s for both", a)
+	}
+}
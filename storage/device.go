@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// deviceRoot is the directory below ~/.sherlock holding the device files of
+// every backend storing its vaults outside of ~/.sherlock
+const deviceRoot = "device"
+
+// deviceFS keeps the files which must never leave the device in a local
+// directory while everything else goes to the backend: the device key signing
+// the vaults, the last verified vault revisions and the unlock log. Anyone able
+// to write the backend could otherwise re-sign a modified vault or roll a vault
+// back together with its revision, and reads would need the backend to log
+// the unlock
+type deviceFS struct {
+	FileSystem
+	device afero.Fs
+}
+
+// WithDevice decorates the FileSystem keeping the device key, the vault
+// revisions and the unlock log in device
+func WithDevice(fsys FileSystem, device afero.Fs) FileSystem {
+	return deviceFS{FileSystem: fsys, device: device}
+}
+
+// DevicePath is the directory holding the device files of the config. Like the
+// cache every backend has its own so switching backends never mixes their keys
+func DevicePath(config Config) string {
+	return filepath.Join(filepath.Dir(ConfigPath()), deviceRoot, configID(config))
+}
+
+// hasDevice reports whether the vaults of the config are stored outside of
+// ~/.sherlock so its device files need a directory of their own
+func (config Config) hasDevice() bool {
+	return config.Remote() || (config.Backend == Local && config.Options["dir"] != "")
+}
+
+func (d deviceFS) read(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(d.device, key)
+}
+
+func (d deviceFS) write(ctx context.Context, key string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := d.device.MkdirAll(".", 0700); err != nil {
+		return err
+	}
+	return afero.WriteFile(d.device, key, data, 0600)
+}
+
+// ReadDeviceKey reads the device key from the device. A key which earlier
+// versions stored on the backend is moved to the device once so vaults signed
+// with it still verify
+func (d deviceFS) ReadDeviceKey(ctx context.Context) ([]byte, error) {
+	key, err := d.read(ctx, deviceKey)
+	if !os.IsNotExist(err) {
+		return key, err
+	}
+	legacy, legacyErr := d.FileSystem.ReadDeviceKey(ctx)
+	if legacyErr != nil || len(legacy) == 0 {
+		return nil, err
+	}
+	if err := d.write(ctx, deviceKey, legacy); err != nil {
+		return nil, err
+	}
+	return legacy, nil
+}
+
+func (d deviceFS) WriteDeviceKey(ctx context.Context, key []byte) error {
+	return d.write(ctx, deviceKey, key)
+}
+
+func (d deviceFS) ReadRevisions(ctx context.Context) ([]byte, error) {
+	return d.read(ctx, revisionsKey)
+}
+
+func (d deviceFS) WriteRevisions(ctx context.Context, revisions []byte) error {
+	return d.write(ctx, revisionsKey, revisions)
+}
+
+func (d deviceFS) ReadUnlockLog(ctx context.Context) ([]byte, error) {
+	return d.read(ctx, unlockLogKey)
+}
+
+func (d deviceFS) WriteUnlockLog(ctx context.Context, log []byte) error {
+	return d.write(ctx, unlockLogKey, log)
+}
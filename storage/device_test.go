@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/spf13/afero"
+)
+
+func TestDevice(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	device := afero.NewMemMapFs()
+	sh := internal.NewSherlock(WithDevice(FromObjects(store), device))
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.EnableSigning(ctx); err != nil {
+		t.Fatalf("sherlock.EnableSigning: want: nil, have: %v", err)
+	}
+	sh.Unlocking("default")
+	if _, err := sh.LoadGroup(ctx, "default", "default_group_key"); err != nil {
+		t.Fatalf("sherlock.LoadGroup: want: nil, have: %v", err)
+	}
+	// the device key, the revisions and the unlock log never reach the backend
+	for _, key := range []string{deviceKey, revisionsKey, unlockLogKey} {
+		if _, err := store.Get(ctx, key); !os.IsNotExist(err) {
+			t.Fatalf("store.Get(%s): want: not exist, have: %v", key, err)
+		}
+		if _, err := afero.ReadFile(device, key); err != nil {
+			t.Fatalf("device %s: want: nil, have: %v", key, err)
+		}
+	}
+	if _, err := store.Get(ctx, "groups/default/.vault.sig"); err != nil {
+		t.Fatalf("store.Get(signature): want: nil, have: %v", err)
+	}
+}
+
+func TestDeviceLegacyKey(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	if err := store.Put(ctx, deviceKey, []byte("legacy-key")); err != nil {
+		t.Fatalf("store.Put: want: nil, have: %v", err)
+	}
+	device := afero.NewMemMapFs()
+	fsys := WithDevice(FromObjects(store), device)
+	key, err := fsys.ReadDeviceKey(ctx)
+	if err != nil || string(key) != "legacy-key" {
+		t.Fatalf("ReadDeviceKey: want: legacy-key, have: %q, %v", key, err)
+	}
+	// once moved a key changed on the backend is ignored
+	if err := store.Put(ctx, deviceKey, []byte("planted-key")); err != nil {
+		t.Fatalf("store.Put: want: nil, have: %v", err)
+	}
+	if key, err := fsys.ReadDeviceKey(ctx); err != nil || !bytes.Equal(key, []byte("legacy-key")) {
+		t.Fatalf("ReadDeviceKey: want: legacy-key, have: %q, %v", key, err)
+	}
+	// without any key signing is not enabled
+	empty := WithDevice(FromObjects(newMemStore()), afero.NewMemMapFs())
+	if _, err := empty.ReadDeviceKey(ctx); !os.IsNotExist(err) {
+		t.Fatalf("ReadDeviceKey: want: not exist, have: %v", err)
+	}
+}
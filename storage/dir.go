@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/spf13/afero"
+)
+
+// dirStore is an ObjectStore keeping the objects as files below a directory.
+// It backs Local configs with a dir option so several local vaults (see
+// Workspaces) can live side by side
+type dirStore struct {
+	fs afero.Fs
+}
+
+// newDirStore stores the objects below dir
+func newDirStore(dir string) dirStore {
+	return dirStore{fs: afero.NewBasePathFs(afero.NewOsFs(), dir)}
+}
+
+func (d dirStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return afero.ReadFile(d.fs, key)
+}
+
+// Put writes the object to a temporary file first so a crash never leaves a
+// partial vault behind
+func (d dirStore) Put(ctx context.Context, key string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := d.fs.MkdirAll(path.Dir(key), 0700); err != nil {
+		return err
+	}
+	tmp := key + ".tmp"
+	if err := afero.WriteFile(d.fs, tmp, data, 0600); err != nil {
+		return err
+	}
+	return d.fs.Rename(tmp, key)
+}
+
+func (d dirStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.fs.RemoveAll(key)
+}
+
+// List returns no names for a missing prefix like the other object stores
+func (d dirStore) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	infos, err := afero.ReadDir(d.fs, prefix)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, nil
+}
+
+// Lock locks the key against other processes using the directory key.lock
+func (d dirStore) Lock(ctx context.Context, key string) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return fs.Lock(ctx, d.fs, key+".lock")
+}
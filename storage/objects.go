@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+)
+
+// keys of the objects sherlock stores, laid out like the local directory
+const (
+	groupsKey    = "groups"
+	vaultKey     = ".vault"
+	signatureKey = ".vault.sig"
+	deviceKey    = "device.key"
+	iconsKey     = "icons"
+	configKey    = "config.json"
+	webhookKey   = "webhook.key"
+	keyringKey   = "keyring"
+	unlockLogKey = "unlock.log"
+	revisionsKey = "revisions.json"
+)
+
+// ObjectStore is a flat store of objects addressed by slash separated keys like
+// groups/work/.vault. A remote or out-of-tree backend only has to implement an
+// ObjectStore; FromObjects maps it to the FileSystem sherlock requires
+type ObjectStore interface {
+	// Get returns the object or an error for which os.IsNotExist is true
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put creates or replaces the object
+	Put(ctx context.Context, key string, data []byte) error
+	// Delete removes the object and all objects below key/
+	Delete(ctx context.Context, key string) error
+	// List returns the names of the direct children of prefix/
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Locker is implemented by an ObjectStore which can lock an object against
+// other processes. Groups of stores which cannot are not locked
+type Locker interface {
+	// Lock waits until no other process holds the lock of the key and returns
+	// the function releasing it
+	Lock(ctx context.Context, key string) (func(), error)
+}
+
+// objectFS implements FileSystem on an ObjectStore
+type objectFS struct {
+	store ObjectStore
+}
+
+// FromObjects returns a FileSystem storing sherlock's files in the ObjectStore
+func FromObjects(store ObjectStore) FileSystem {
+	return objectFS{store: store}
+}
+
+func (o objectFS) InitFs(ctx context.Context, initVault []byte) error {
+	return o.store.Put(ctx, path.Join(groupsKey, "default", vaultKey), initVault)
+}
+
+func (o objectFS) CreateGroup(ctx context.Context, name string, initVault []byte) error {
+	return o.store.Put(ctx, path.Join(groupsKey, name, vaultKey), initVault)
+}
+
+// GroupExists follows fs.Fs and reports an existing group as fs.ErrGroupExists
+func (o objectFS) GroupExists(ctx context.Context, name string) error {
+	groups, err := o.store.List(ctx, groupsKey)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g == name {
+			return fs.ErrGroupExists
+		}
+	}
+	return nil
+}
+
+// VaultExists follows fs.Fs and reports an existing vault as fs.ErrNoSuchVault
+func (o objectFS) VaultExists(ctx context.Context, group string) error {
+	_, err := o.store.Get(ctx, path.Join(groupsKey, group, vaultKey))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fs.ErrNoSuchVault
+}
+
+func (o objectFS) ReadGroupVault(ctx context.Context, group string) ([]byte, error) {
+	return o.store.Get(ctx, path.Join(groupsKey, group, vaultKey))
+}
+
+func (o objectFS) Delete(ctx context.Context, gid string) error {
+	return o.store.Delete(ctx, path.Join(groupsKey, gid))
+}
+
+func (o objectFS) Write(ctx context.Context, gid string, data []byte) error {
+	return o.store.Put(ctx, path.Join(groupsKey, gid, vaultKey), data)
+}
+
+func (o objectFS) LockGroup(ctx context.Context, gid string) (func(), error) {
+	locker, ok := o.store.(Locker)
+	if !ok {
+		return func() {}, nil
+	}
+	return locker.Lock(ctx, path.Join(groupsKey, gid, vaultKey))
+}
+
+func (o objectFS) ReadRegisteredGroups(ctx context.Context) ([]string, error) {
+	return o.store.List(ctx, groupsKey)
+}
+
+func (o objectFS) ReadDeviceKey(ctx context.Context) ([]byte, error) {
+	return o.store.Get(ctx, deviceKey)
+}
+
+func (o objectFS) WriteDeviceKey(ctx context.Context, key []byte) error {
+	return o.store.Put(ctx, deviceKey, key)
+}
+
+func (o objectFS) ReadSignature(ctx context.Context, gid string) ([]byte, error) {
+	return o.store.Get(ctx, path.Join(groupsKey, gid, signatureKey))
+}
+
+func (o objectFS) WriteSignature(ctx context.Context, gid string, sig []byte) error {
+	return o.store.Put(ctx, path.Join(groupsKey, gid, signatureKey), sig)
+}
+
+func (o objectFS) ReadIcon(ctx context.Context, name string) ([]byte, error) {
+	return o.store.Get(ctx, path.Join(iconsKey, name))
+}
+
+func (o objectFS) WriteIcon(ctx context.Context, name string, icon []byte) error {
+	return o.store.Put(ctx, path.Join(iconsKey, name), icon)
+}
+
+func (o objectFS) ClearIcons(ctx context.Context) error {
+	return o.store.Delete(ctx, iconsKey)
+}
+
+func (o objectFS) ReadConfig(ctx context.Context) ([]byte, error) {
+	return o.store.Get(ctx, configKey)
+}
+
+func (o objectFS) WriteConfig(ctx context.Context, config []byte) error {
+	return o.store.Put(ctx, configKey, config)
+}
+
+func (o objectFS) ReadWebhookKey(ctx context.Context) ([]byte, error) {
+	return o.store.Get(ctx, webhookKey)
+}
+
+func (o objectFS) WriteWebhookKey(ctx context.Context, key []byte) error {
+	return o.store.Put(ctx, webhookKey, key)
+}
+
+func (o objectFS) ReadKeyring(ctx context.Context) ([]byte, error) {
+	return o.store.Get(ctx, keyringKey)
+}
+
+func (o objectFS) WriteKeyring(ctx context.Context, keyring []byte) error {
+	return o.store.Put(ctx, keyringKey, keyring)
+}
+
+func (o objectFS) ReadUnlockLog(ctx context.Context) ([]byte, error) {
+	return o.store.Get(ctx, unlockLogKey)
+}
+
+func (o objectFS) WriteUnlockLog(ctx context.Context, log []byte) error {
+	return o.store.Put(ctx, unlockLogKey, log)
+}
+
+func (o objectFS) ReadRevisions(ctx context.Context) ([]byte, error) {
+	return o.store.Get(ctx, revisionsKey)
+}
+
+func (o objectFS) WriteRevisions(ctx context.Context, revisions []byte) error {
+	return o.store.Put(ctx, revisionsKey, revisions)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/KonstantinGasser/sherlock/plugin"
+)
+
+// Plugin is the backend storing vaults with a sherlock-<name> storage plugin
+// found on the PATH. The plugin is named by the "name" option; all other options
+// are passed to the plugin as arguments like --url=s3://bucket/prefix
+const Plugin = "plugin"
+
+func init() {
+	Register(Plugin, func(options map[string]string) (FileSystem, error) {
+		name := options["name"]
+		if name == "" {
+			return nil, fmt.Errorf("%w: the plugin backend requires the option \"name\"", ErrInvalidConfig)
+		}
+		p, err := plugin.Lookup(os.Getenv("PATH"), name)
+		if err != nil {
+			return nil, err
+		}
+		return FromObjects(&plugin.Store{Plugin: p, Args: pluginArgs(options)}), nil
+	})
+}
+
+// pluginArgs returns the options except the name as arguments sorted by option
+func pluginArgs(options map[string]string) []string {
+	var args []string
+	for key, value := range options {
+		if key != "name" {
+			args = append(args, "--"+key+"="+value)
+		}
+	}
+	sort.Strings(args)
+	return args
+}
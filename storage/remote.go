@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// schemePlugins maps url schemes to the storage plugin serving them. Schemes not
+// listed are served by the plugin named like the scheme
+var schemePlugins = map[string]string{
+	"dav":     "webdav",
+	"davs":    "webdav",
+	"webdavs": "webdav",
+}
+
+// FromURL returns the config of a remote like s3://bucket/prefix, git+ssh://host/repo.git
+// or webdav://host/path. Remotes are stored by the storage plugin named by the scheme
+// (the part before a "+"), which receives the url as --url. sftp:// and ssh:// remotes
+// are stored by the SFTP backend
+func FromURL(raw string) (Config, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+		return Config{}, fmt.Errorf("%w: %q is no remote url like s3://bucket/prefix", ErrInvalidConfig, raw)
+	}
+	name := strings.ToLower(strings.SplitN(u.Scheme, "+", 2)[0])
+	if name == "sftp" || name == "ssh" {
+		return sftpConfig(u)
+	}
+	if mapped, ok := schemePlugins[name]; ok {
+		name = mapped
+	}
+	return Config{
+		Backend: Plugin,
+		Options: map[string]string{"name": name, "url": raw},
+	}, nil
+}
+
+// sftpConfig returns the config of a remote like sftp://user@host:2222/srv/sherlock.
+// A path starting with /~/ is relative to the home directory
+func sftpConfig(u *url.URL) (Config, error) {
+	if u.Hostname() == "" {
+		return Config{}, fmt.Errorf("%w: %q has no host", ErrInvalidConfig, u.String())
+	}
+	host := u.Hostname()
+	if u.User != nil {
+		host = u.User.Username() + "@" + host
+	}
+	if err := checkSFTPHost(host); err != nil {
+		return Config{}, err
+	}
+	options := map[string]string{"host": host}
+	if u.Port() != "" {
+		options["port"] = u.Port()
+	}
+	switch p := u.Path; {
+	case p == "" || p == "/" || p == "/~" || p == "/~/":
+	case strings.HasPrefix(p, "/~/"):
+		options["path"] = strings.TrimPrefix(p, "/~/")
+	default:
+		options["path"] = p
+	}
+	return Config{Backend: SFTP, Options: options}, nil
+}
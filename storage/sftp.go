@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// SFTP is the backend storing vaults on an SSH server. It runs the sftp client of
+// OpenSSH, so hosts, keys and agents configured in ~/.ssh are used as they are.
+// The "host" option names the server like user@vault.example.com or a Host of
+// ~/.ssh/config, "path" the directory of the vaults (default sherlock in the
+// home directory) and "port" overrides the port
+const SFTP = "sftp"
+
+// defaultSFTPPath is the directory of the vaults relative to the home directory
+const defaultSFTPPath = "sherlock"
+
+// sftpCommand is the sftp client run for every operation
+var sftpCommand = "sftp"
+
+func init() {
+	Register(SFTP, func(options map[string]string) (FileSystem, error) {
+		if options["host"] == "" {
+			return nil, fmt.Errorf("%w: the sftp backend requires the option \"host\"", ErrInvalidConfig)
+		}
+		if err := checkSFTPHost(options["host"]); err != nil {
+			return nil, err
+		}
+		root := options["path"]
+		if root == "" {
+			root = defaultSFTPPath
+		}
+		return FromObjects(&sftpStore{host: options["host"], port: options["port"], root: root}), nil
+	})
+}
+
+// checkSFTPHost refuses a host sftp would read as an option
+func checkSFTPHost(host string) error {
+	if strings.HasPrefix(host, "-") {
+		return fmt.Errorf("%w: sftp host %q must not start with -", ErrInvalidConfig, host)
+	}
+	return nil
+}
+
+// sftpStore is an ObjectStore running every operation as one sftp batch. Batch
+// mode never prompts, so the server must accept a key without passphrase or
+// one held by the ssh-agent
+type sftpStore struct {
+	host string
+	port string
+	root string
+}
+
+// sftpEntry is a file listed by ls -la
+type sftpEntry struct {
+	name string
+	dir  bool
+}
+
+// run runs the batch commands in a single sftp session and returns its output.
+// Commands are prefixed with @ so they are not echoed and with - if their
+// errors are ignored
+func (s *sftpStore) run(ctx context.Context, batch ...string) ([]byte, error) {
+	args := []string{"-q", "-b", "-"}
+	if s.port != "" {
+		args = append(args, "-P", s.port)
+	}
+	// the host is never taken for an option like -oProxyCommand
+	args = append(args, "--", s.host)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, sftpCommand, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(batch, "\n") + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "not found") || strings.Contains(msg, "No such file") {
+			return nil, &os.PathError{Op: "sftp", Path: s.host, Err: os.ErrNotExist}
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("sftp %s: %s", s.host, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// remote returns the quoted remote path of the key. Glob characters are escaped
+// since sftp expands remote paths
+func (s *sftpStore) remote(key string) string {
+	return quoteSFTP(path.Join(s.root, key))
+}
+
+func quoteSFTP(p string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(p) + `"`
+}
+
+func (s *sftpStore) Get(ctx context.Context, key string) ([]byte, error) {
+	tmp, err := ioutil.TempFile("", "sherlock-sftp-")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if _, err := s.run(ctx, "@get "+s.remote(key)+" "+quoteSFTP(tmp.Name())); err != nil {
+		if os.IsNotExist(err) {
+			return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+		}
+		return nil, err
+	}
+	return ioutil.ReadFile(tmp.Name())
+}
+
+// Put uploads the object next to its key and renames it over the key, so a
+// failed upload never leaves a truncated vault behind
+func (s *sftpStore) Put(ctx context.Context, key string, data []byte) error {
+	tmp, err := ioutil.TempFile("", "sherlock-sftp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	var batch []string
+	dir := ""
+	for _, part := range strings.Split(path.Dir(path.Join(s.root, key)), "/") {
+		dir = path.Join(dir, part)
+		if dir != "." && dir != "" {
+			batch = append(batch, "-@mkdir "+quoteSFTP(dir))
+		}
+	}
+	upload := s.remote(key + ".upload")
+	batch = append(batch,
+		"@put "+quoteSFTP(tmp.Name())+" "+upload,
+		"@rename "+upload+" "+s.remote(key),
+	)
+	_, err = s.run(ctx, batch...)
+	return err
+}
+
+// Delete removes the file or directory of the key
+func (s *sftpStore) Delete(ctx context.Context, key string) error {
+	batch, err := s.removals(ctx, key)
+	if err != nil {
+		return err
+	}
+	_, err = s.run(ctx, batch...)
+	return err
+}
+
+// removals returns the commands removing the key and everything below it. A
+// key which is no directory is removed as a file, ignoring that it is missing
+func (s *sftpStore) removals(ctx context.Context, key string) ([]string, error) {
+	entries, err := s.entries(ctx, key)
+	if os.IsNotExist(err) {
+		return []string{"-@rm " + s.remote(key)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var batch []string
+	for _, e := range entries {
+		child := path.Join(key, e.name)
+		if !e.dir {
+			batch = append(batch, "@rm "+s.remote(child))
+			continue
+		}
+		sub, err := s.removals(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, sub...)
+	}
+	return append(batch, "@rmdir "+s.remote(key)), nil
+}
+
+func (s *sftpStore) List(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := s.entries(ctx, prefix)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.name
+	}
+	return names, nil
+}
+
+// entries lists the directory of the key. The trailing slash makes sftp report
+// a key which is no directory as not found
+func (s *sftpStore) entries(ctx context.Context, key string) ([]sftpEntry, error) {
+	out, err := s.run(ctx, "@ls -la "+quoteSFTP(path.Join(s.root, key)+"/"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []sftpEntry
+	for _, line := range strings.Split(string(out), "\n") {
+		// drwx------  2 user group 4096 Oct 15 10:00 name
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+		name := path.Base(strings.Join(fields[8:], " "))
+		if name == "." || name == ".." {
+			continue
+		}
+		entries = append(entries, sftpEntry{name: name, dir: strings.HasPrefix(fields[0], "d")})
+	}
+	return entries, nil
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+)
+
+// fakeSFTP is a batch mode sftp client serving the directory it is run in. It
+// understands the commands and messages of OpenSSH's sftp used by sftpStore
+const fakeSFTP = `#!/bin/sh
+echo "$@" > "$0.args"
+cd "$(dirname "$0")/remote" || exit 1
+while IFS= read -r line; do
+	ignore=0
+	case "$line" in -*) ignore=1 ;; esac
+	line=$(printf '%s' "$line" | sed 's/^[-@]*//')
+	eval "set -- $line"
+	cmd=$1
+	shift
+	ok=1
+	case "$cmd" in
+	get) if [ -f "$1" ]; then cp "$1" "$2"; else echo "File \"$1\" not found." >&2; ok=0; fi ;;
+	put) cp "$1" "$2" || ok=0 ;;
+	rename) mv -f "$1" "$2" || ok=0 ;;
+	mkdir) mkdir "$1" 2>/dev/null || ok=0 ;;
+	rm) rm "$1" 2>/dev/null || { echo "Remove $1: No such file or directory" >&2; ok=0; } ;;
+	rmdir) rmdir "$1" || ok=0 ;;
+	ls) if [ -d "$2" ]; then ls -la "$2"; else echo "Can't ls: \"$2\" not found" >&2; ok=0; fi ;;
+	esac
+	if [ $ok = 0 ] && [ $ignore = 0 ]; then exit 1; fi
+done
+`
+
+func TestSFTP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherlock-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "remote"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	client := filepath.Join(dir, "sftp")
+	if err := ioutil.WriteFile(client, []byte(fakeSFTP), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(command string) { sftpCommand = command }(sftpCommand)
+	sftpCommand = client
+
+	ctx := context.Background()
+	if _, err := New(SFTP, nil); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("storage.New: want: %v, have: %v", ErrInvalidConfig, err)
+	}
+	if _, err := New(SFTP, map[string]string{"host": "-oProxyCommand=touch pwned"}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("storage.New(option as host): want: %v, have: %v", ErrInvalidConfig, err)
+	}
+	backend, err := New(SFTP, map[string]string{"host": "me@vaults", "port": "2222", "path": "vaults"})
+	if err != nil {
+		t.Fatalf("storage.New: want: nil, have: %v", err)
+	}
+	sh := internal.NewSherlock(backend)
+	if err := sh.IsSetUp(ctx); err == nil {
+		t.Fatalf("sherlock.IsSetUp: want: %v, have: nil", internal.ErrNotSetup)
+	}
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	account, err := internal.NewAccount("work@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "work@github", "work_group_key", internal.OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	if _, err := sh.GetAccount(ctx, "work@github", "work_group_key"); err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "remote", "vaults", "groups", "work", vaultKey)); err != nil {
+		t.Fatalf("vault not stored below the path option: %v", err)
+	}
+	if err := sh.DeleteGroup(ctx, "work"); err != nil {
+		t.Fatalf("sherlock.DeleteGroup: want: nil, have: %v", err)
+	}
+	groups, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: nil, have: %v", err)
+	}
+	if want := []string{"default"}; !reflect.DeepEqual(groups, want) {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: %v, have: %v", want, groups)
+	}
+	args, err := ioutil.ReadFile(client + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-q -b - -P 2222 -- me@vaults\n"; string(args) != want {
+		t.Fatalf("sftp arguments: want: %q, have: %q", want, args)
+	}
+}
+
+func TestSFTPFromURL(t *testing.T) {
+	tt := []struct {
+		url     string
+		options map[string]string
+	}{
+		{url: "sftp://vaults.example.com", options: map[string]string{"host": "vaults.example.com"}},
+		{url: "sftp://me@vaults.example.com:2222/srv/sherlock", options: map[string]string{"host": "me@vaults.example.com", "port": "2222", "path": "/srv/sherlock"}},
+		{url: "ssh://me@nas/~/sherlock", options: map[string]string{"host": "me@nas", "path": "sherlock"}},
+	}
+	for _, tc := range tt {
+		config, err := FromURL(tc.url)
+		if err != nil {
+			t.Fatalf("storage.FromURL(%s): want: nil, have: %v", tc.url, err)
+		}
+		if config.Backend != SFTP || !reflect.DeepEqual(config.Options, tc.options) {
+			t.Fatalf("storage.FromURL(%s): want: %v, have: %+v", tc.url, tc.options, config)
+		}
+	}
+}
+
+func TestSFTPFromURLOption(t *testing.T) {
+	for _, u := range []string{
+		"sftp://-oProxyCommand=sh/sherlock",
+		"ssh://-oProxyCommand=sh@nas/~/sherlock",
+	} {
+		if _, err := FromURL(u); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("storage.FromURL(%s): want: %v, have: %v", u, ErrInvalidConfig, err)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package storage selects the backend sherlock stores its vaults in. Backends
+// are registered by name with Register and chosen by the storage config
+// (~/.sherlock/storage.json) of the current workspace or the SHERLOCK_STORAGE
+// environment variable
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/fs"
+	"github.com/KonstantinGasser/sherlock/internal"
+	"github.com/spf13/afero"
+)
+
+const (
+	// Local is the default backend storing vaults in ~/.sherlock or in the
+	// directory of its dir option
+	Local = "local"
+	// Memory keeps the vaults in memory only; they are gone when sherlock exits
+	Memory = "memory"
+	// EnvBackend overrides the backend of the storage config
+	EnvBackend = "SHERLOCK_STORAGE"
+	// configFile is read from the local sherlock directory since it must be
+	// known before any backend can be opened
+	configFile = "storage.json"
+)
+
+var (
+	ErrUnknownBackend = fmt.Errorf("unknown storage backend")
+	ErrInvalidConfig  = fmt.Errorf("invalid storage config")
+)
+
+// FileSystem is the interface a backend implements. It is an alias so
+// backends outside of sherlock can implement it
+type FileSystem = internal.FileSystem
+
+// Factory creates a backend from the options of the storage config
+type Factory func(options map[string]string) (FileSystem, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+func init() {
+	Register(Local, func(options map[string]string) (FileSystem, error) {
+		if dir := options["dir"]; dir != "" {
+			if !filepath.IsAbs(dir) {
+				return nil, fmt.Errorf("%w: local dir %q must be absolute", ErrInvalidConfig, dir)
+			}
+			return FromObjects(newDirStore(dir)), nil
+		}
+		return fs.New(afero.NewOsFs()), nil
+	})
+	Register(Memory, func(options map[string]string) (FileSystem, error) {
+		return fs.New(afero.NewMemMapFs()), nil
+	})
+}
+
+// Register makes a backend available by name. Registering a name twice panics
+// like registering a database/sql driver twice
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if factory == nil {
+		panic("storage: Register factory is nil")
+	}
+	if _, ok := factories[name]; ok {
+		panic("storage: Register called twice for backend " + name)
+	}
+	factories[name] = factory
+}
+
+// Backends returns the names of the registered backends sorted
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the backend registered by name
+func New(name string, options map[string]string) (FileSystem, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (registered: %v)", ErrUnknownBackend, name, Backends())
+	}
+	return factory(options)
+}
+
+// Config selects and configures the backend
+type Config struct {
+	Backend string            `json:"backend"`
+	Options map[string]string `json:"options,omitempty"`
+	Retry   *RetryConfig      `json:"retry,omitempty"`
+	// Cache keeps a local copy of the vaults of a remote backend, see Cached
+	Cache *bool `json:"cache,omitempty"`
+}
+
+// Remote reports whether the backend of the config stores the vaults off
+// the device
+func (config Config) Remote() bool {
+	return config.Backend != Local && config.Backend != Memory
+}
+
+// LocalPaths returns the files and directories holding the vaults of a Local
+// config with a dir option. Other files in the directory are not part of it
+func LocalPaths(config Config) []string {
+	dir := config.Options["dir"]
+	if config.Backend != Local || dir == "" {
+		return nil
+	}
+	keys := []string{groupsKey, deviceKey, iconsKey, configKey, webhookKey, keyringKey, unlockLogKey, revisionsKey}
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = filepath.Join(dir, filepath.FromSlash(key))
+	}
+	return paths
+}
+
+// RetryConfig overrides the internal.DefaultRetryPolicy. Durations are
+// written like 250ms or 2s
+type RetryConfig struct {
+	Attempts int    `json:"attempts,omitempty"`
+	Delay    string `json:"delay,omitempty"`
+	MaxDelay string `json:"max_delay,omitempty"`
+}
+
+// policy returns the retry policy with the configured values applied
+func (rc *RetryConfig) policy() (internal.RetryPolicy, error) {
+	policy := internal.DefaultRetryPolicy
+	if rc == nil {
+		return policy, nil
+	}
+	if rc.Attempts < 0 {
+		return policy, fmt.Errorf("%w: retry attempts must not be negative", ErrInvalidConfig)
+	}
+	if rc.Attempts > 0 {
+		policy.Attempts = rc.Attempts
+	}
+	for _, d := range []struct {
+		value string
+		field *time.Duration
+	}{
+		{value: rc.Delay, field: &policy.Delay},
+		{value: rc.MaxDelay, field: &policy.MaxDelay},
+	} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return policy, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+		*d.field = parsed
+	}
+	return policy, nil
+}
+
+// ParseConfig parses a storage config. An empty backend selects Local
+func ParseConfig(b []byte) (Config, error) {
+	var config Config
+	if err := json.Unmarshal(b, &config); err != nil {
+		return Config{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if config.Backend == "" {
+		config.Backend = Local
+	}
+	return config, nil
+}
+
+// ConfigPath returns the location of the storage config
+func ConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".sherlock", configFile)
+}
+
+// WriteConfig writes the storage config used by Open
+func WriteConfig(config Config) error {
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ConfigPath()), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ConfigPath(), append(b, '\n'), 0600)
+}
+
+// Open opens the configured backend. Without a storage config the Local backend
+// is used; SHERLOCK_STORAGE overrides the backend
+func Open() (FileSystem, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return OpenConfig(config)
+}
+
+// LoadConfig reads the storage config of the active workspace applying
+// SHERLOCK_STORAGE. The default workspace uses the storage config
+func LoadConfig() (Config, error) {
+	config, ok, err := workspaceConfig()
+	if err != nil {
+		return Config{}, err
+	}
+	if !ok {
+		if config, err = LoadDefaultConfig(); err != nil {
+			return Config{}, err
+		}
+	}
+	if backend := os.Getenv(EnvBackend); backend != "" {
+		config.Backend = backend
+	}
+	return config, nil
+}
+
+// LoadDefaultConfig reads the storage config of the default workspace. Without
+// one the Local backend is used
+func LoadDefaultConfig() (Config, error) {
+	config := Config{Backend: Local}
+	b, err := ioutil.ReadFile(ConfigPath())
+	switch {
+	case err == nil:
+		if config, err = ParseConfig(b); err != nil {
+			return Config{}, err
+		}
+	case !os.IsNotExist(err):
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// OpenConfig opens the backend of the config wrapped to retry transient errors.
+// Remote backends are cached in CachePath. Backends storing the vaults outside
+// of ~/.sherlock keep the device key, vault revisions and unlock log in DevicePath
+func OpenConfig(config Config) (FileSystem, error) {
+	fsys, err := OpenRemote(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Cached() {
+		fsys = WithCache(fsys, afero.NewBasePathFs(afero.NewOsFs(), CachePath(config)))
+	}
+	if config.hasDevice() {
+		fsys = WithDevice(fsys, afero.NewBasePathFs(afero.NewOsFs(), DevicePath(config)))
+	}
+	return fsys, nil
+}
+
+// OpenRemote opens the backend of the config without the cache
+func OpenRemote(config Config) (FileSystem, error) {
+	policy, err := config.Retry.policy()
+	if err != nil {
+		return nil, err
+	}
+	backend, err := New(config.Backend, config.Options)
+	if err != nil {
+		return nil, err
+	}
+	return internal.WithRetry(backend, policy), nil
+}
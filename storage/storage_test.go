@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+)
+
+// memStore is an in-memory ObjectStore
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (m *memStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+	}
+	return data, nil
+}
+
+func (m *memStore) Put(ctx context.Context, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k := range m.objects {
+		if k == key || strings.HasPrefix(k, key+"/") {
+			delete(m.objects, k)
+		}
+	}
+	return nil
+}
+
+func (m *memStore) List(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[string]bool)
+	var names []string
+	for k := range m.objects {
+		if !strings.HasPrefix(k, prefix+"/") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(k, prefix+"/"), "/", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func TestRegister(t *testing.T) {
+	store := newMemStore()
+	Register("mem-test", func(options map[string]string) (FileSystem, error) {
+		if options["bucket"] != "vaults" {
+			return nil, ErrInvalidConfig
+		}
+		return FromObjects(store), nil
+	})
+	for _, name := range []string{Local, Memory, Plugin, "mem-test"} {
+		if i := sort.SearchStrings(Backends(), name); i == len(Backends()) || Backends()[i] != name {
+			t.Fatalf("storage.Backends: want: %s registered, have: %v", name, Backends())
+		}
+	}
+	if _, err := New("mem-test", nil); err != ErrInvalidConfig {
+		t.Fatalf("storage.New: want: %v, have: %v", ErrInvalidConfig, err)
+	}
+	if _, err := New("s3", nil); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("storage.New: want: %v, have: %v", ErrUnknownBackend, err)
+	}
+	if _, err := New(Plugin, nil); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("storage.New: want: %v, have: %v", ErrInvalidConfig, err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("storage.Register: want: panic for a duplicate backend, have: nil")
+			}
+		}()
+		Register(Local, func(options map[string]string) (FileSystem, error) { return nil, nil })
+	}()
+
+	config, err := ParseConfig([]byte(`{"backend":"mem-test","options":{"bucket":"vaults"},"retry":{"attempts":2,"delay":"10ms"},"cache":false}`))
+	if err != nil {
+		t.Fatalf("storage.ParseConfig: want: nil, have: %v", err)
+	}
+	fileSystem, err := OpenConfig(config)
+	if err != nil {
+		t.Fatalf("storage.OpenConfig: want: nil, have: %v", err)
+	}
+	// the backend selected by the config stores the vaults
+	ctx := context.Background()
+	sh := internal.NewSherlock(fileSystem)
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if _, ok := store.objects[path.Join(groupsKey, "default", vaultKey)]; !ok {
+		t.Fatalf("storage: want: default vault stored, have: %v", store.objects)
+	}
+}
+
+func TestRetryConfig(t *testing.T) {
+	tt := []struct {
+		config string
+		policy internal.RetryPolicy
+		err    error
+	}{
+		{config: `{}`, policy: internal.DefaultRetryPolicy},
+		{config: `{"retry":{"attempts":6,"max_delay":"5s"}}`, policy: internal.RetryPolicy{
+			Attempts: 6, Delay: internal.DefaultRetryPolicy.Delay, MaxDelay: 5 * time.Second, Jitter: internal.DefaultRetryPolicy.Jitter,
+		}},
+		{config: `{"retry":{"delay":"soon"}}`, err: ErrInvalidConfig},
+		{config: `{"retry":{"attempts":-1}}`, err: ErrInvalidConfig},
+		{config: `{"backend":`, err: ErrInvalidConfig},
+	}
+	for _, tc := range tt {
+		config, err := ParseConfig([]byte(tc.config))
+		if err == nil {
+			var policy internal.RetryPolicy
+			if policy, err = config.Retry.policy(); err == nil && !reflect.DeepEqual(policy, tc.policy) {
+				t.Fatalf("RetryConfig.policy(%s): want: %+v, have: %+v", tc.config, tc.policy, policy)
+			}
+			if config.Backend != Local {
+				t.Fatalf("storage.ParseConfig(%s): want backend: %s, have: %s", tc.config, Local, config.Backend)
+			}
+		}
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("storage.ParseConfig(%s): want: %v, have: %v", tc.config, tc.err, err)
+		}
+	}
+}
+
+func TestObjectFS(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	sh := internal.NewSherlock(FromObjects(store))
+	if err := sh.IsSetUp(ctx); err == nil {
+		t.Fatalf("sherlock.IsSetUp: want: %v, have: nil", internal.ErrNotSetup)
+	}
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.IsSetUp(ctx); err != nil {
+		t.Fatalf("sherlock.IsSetUp: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "work", "work_group_key", true); err == nil {
+		t.Fatalf("sherlock.SetupGroup: want: group exists error, have: nil")
+	}
+	account, err := internal.NewAccount("work@github", "Sup3r$ecret-pass", "", true)
+	if err != nil {
+		t.Fatalf("internal.NewAccount: want: nil, have: %v", err)
+	}
+	if err := sh.UpdateState(ctx, "work@github", "work_group_key", internal.OptAddAccount(account)); err != nil {
+		t.Fatalf("sherlock.UpdateState: want: nil, have: %v", err)
+	}
+	if _, err := sh.GetAccount(ctx, "work@github", "work_group_key"); err != nil {
+		t.Fatalf("sherlock.GetAccount: want: nil, have: %v", err)
+	}
+	groups, err := sh.ReadRegisteredGroups(ctx)
+	if err != nil {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: nil, have: %v", err)
+	}
+	if want := []string{"default", "work"}; !reflect.DeepEqual(groups, want) {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: %v, have: %v", want, groups)
+	}
+	if _, err := sh.LoadGroup(ctx, "private", "key"); !errors.Is(err, internal.ErrNoSuchGroup) {
+		t.Fatalf("sherlock.LoadGroup: want: %v, have: %v", internal.ErrNoSuchGroup, err)
+	}
+	if err := sh.DeleteGroup(ctx, "work"); err != nil {
+		t.Fatalf("sherlock.DeleteGroup: want: nil, have: %v", err)
+	}
+	if groups, _ := sh.ReadRegisteredGroups(ctx); !reflect.DeepEqual(groups, []string{"default"}) {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: [default], have: %v", groups)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	sh := internal.NewSherlock(FromObjects(newMemStore()))
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	memory, err := New(Memory, nil)
+	if err != nil {
+		t.Fatalf("storage.New: want: nil, have: %v", err)
+	}
+	// every memory backend starts empty
+	sh.UseFileSystem(memory)
+	if err := sh.IsSetUp(ctx); !errors.Is(err, internal.ErrNotSetup) {
+		t.Fatalf("sherlock.IsSetUp: want: %v, have: %v", internal.ErrNotSetup, err)
+	}
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	other, _ := New(Memory, nil)
+	if err := internal.NewSherlock(other).IsSetUp(ctx); !errors.Is(err, internal.ErrNotSetup) {
+		t.Fatalf("sherlock.IsSetUp: want: %v, have: %v", internal.ErrNotSetup, err)
+	}
+}
+
+func TestFromURL(t *testing.T) {
+	tt := []struct {
+		url    string
+		plugin string
+		err    error
+	}{
+		{url: "s3://bucket/prefix", plugin: "s3"},
+		{url: "git+ssh://git@example.com/vaults.git", plugin: "git"},
+		{url: "git+https://example.com/vaults.git", plugin: "git"},
+		{url: "davs://cloud.example.com/remote.php/dav/sherlock", plugin: "webdav"},
+		{url: "WebDAV://cloud.example.com/sherlock", plugin: "webdav"},
+		{url: "gs://bucket", plugin: "gs"},
+		{url: "bucket/prefix", err: ErrInvalidConfig},
+		{url: "s3://", err: ErrInvalidConfig},
+	}
+	for _, tc := range tt {
+		config, err := FromURL(tc.url)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("storage.FromURL(%s): want: %v, have: %v", tc.url, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if config.Backend != Plugin || config.Options["name"] != tc.plugin || config.Options["url"] != tc.url {
+			t.Fatalf("storage.FromURL(%s): want: plugin %s, have: %+v", tc.url, tc.plugin, config)
+		}
+		if args := pluginArgs(config.Options); !reflect.DeepEqual(args, []string{"--url=" + tc.url}) {
+			t.Fatalf("storage.pluginArgs: want: [--url=%s], have: %v", tc.url, args)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	// DefaultWorkspace uses the storage config (storage.json)
+	DefaultWorkspace = "default"
+	// EnvWorkspace overrides the current workspace
+	EnvWorkspace = "SHERLOCK_WORKSPACE"
+	// workspacesFile lists the workspaces next to the storage config
+	workspacesFile = "workspaces.json"
+	// workspacesDir holds the vaults of local workspaces without a dir
+	workspacesDir = "workspaces"
+)
+
+var (
+	ErrNoSuchWorkspace = fmt.Errorf("workspace not found")
+	ErrWorkspaceExists = fmt.Errorf("workspace already exists")
+)
+
+var workspaceName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Workspaces are named storage configs like kubectl contexts. Each workspace
+// has its own vaults; Current selects the one sherlock opens
+type Workspaces struct {
+	Current    string            `json:"current,omitempty"`
+	Workspaces map[string]Config `json:"workspaces,omitempty"`
+}
+
+// WorkspacesPath returns the location of the workspaces file
+func WorkspacesPath() string {
+	return filepath.Join(filepath.Dir(ConfigPath()), workspacesFile)
+}
+
+// WorkspaceDir returns the directory of the vaults of a local workspace
+// added without a directory
+func WorkspaceDir(name string) string {
+	return filepath.Join(filepath.Dir(ConfigPath()), workspacesDir, name)
+}
+
+// LoadWorkspaces reads the workspaces file. Without it only the default
+// workspace exists
+func LoadWorkspaces() (Workspaces, error) {
+	var w Workspaces
+	b, err := ioutil.ReadFile(WorkspacesPath())
+	if os.IsNotExist(err) {
+		return w, nil
+	}
+	if err != nil {
+		return w, err
+	}
+	if err := json.Unmarshal(b, &w); err != nil {
+		return w, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, WorkspacesPath(), err)
+	}
+	return w, nil
+}
+
+// WriteWorkspaces writes the workspaces file
+func WriteWorkspaces(w Workspaces) error {
+	b, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(WorkspacesPath()), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(WorkspacesPath(), append(b, '\n'), 0600)
+}
+
+// Active returns the workspace in use: SHERLOCK_WORKSPACE, the current
+// workspace or DefaultWorkspace
+func (w Workspaces) Active() string {
+	if name := os.Getenv(EnvWorkspace); name != "" {
+		return name
+	}
+	if w.Current != "" {
+		return w.Current
+	}
+	return DefaultWorkspace
+}
+
+// Names returns the names of all workspaces sorted, the default first
+func (w Workspaces) Names() []string {
+	names := make([]string, 0, len(w.Workspaces))
+	for name := range w.Workspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultWorkspace}, names...)
+}
+
+// Add adds a workspace
+func (w *Workspaces) Add(name string, config Config) error {
+	if !workspaceName.MatchString(name) {
+		return fmt.Errorf("%w: workspace name %q may only contain letters, digits, '.', '_' and '-'", ErrInvalidConfig, name)
+	}
+	if _, ok := w.Workspaces[name]; ok || name == DefaultWorkspace {
+		return fmt.Errorf("%w: %s", ErrWorkspaceExists, name)
+	}
+	if w.Workspaces == nil {
+		w.Workspaces = make(map[string]Config)
+	}
+	w.Workspaces[name] = config
+	return nil
+}
+
+// Use makes the workspace the current one
+func (w *Workspaces) Use(name string) error {
+	if name == DefaultWorkspace {
+		w.Current = ""
+		return nil
+	}
+	if _, ok := w.Workspaces[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNoSuchWorkspace, name)
+	}
+	w.Current = name
+	return nil
+}
+
+// workspaceConfig returns the storage config of the active workspace. ok is
+// false for the default workspace
+func workspaceConfig() (config Config, ok bool, err error) {
+	w, err := LoadWorkspaces()
+	if err != nil {
+		return Config{}, false, err
+	}
+	name := w.Active()
+	if name == DefaultWorkspace {
+		return Config{}, false, nil
+	}
+	config, ok = w.Workspaces[name]
+	if !ok {
+		if os.Getenv(EnvWorkspace) != "" {
+			return Config{}, false, fmt.Errorf("%w: %s (set by %s)", ErrNoSuchWorkspace, name, EnvWorkspace)
+		}
+		return Config{}, false, fmt.Errorf("%w: %s (see sherlock workspace list)", ErrNoSuchWorkspace, name)
+	}
+	if config.Backend == "" {
+		config.Backend = Local
+	}
+	return config, true, nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KonstantinGasser/sherlock/internal"
+)
+
+// tempHome points HOME to a new directory for the test
+func tempHome(t *testing.T) string {
+	t.Helper()
+	home, err := ioutil.TempDir("", "sherlock-home")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir: want: nil, have: %v", err)
+	}
+	previous := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	t.Cleanup(func() {
+		os.Setenv("HOME", previous)
+		os.RemoveAll(home)
+	})
+	return home
+}
+
+func TestWorkspaces(t *testing.T) {
+	home := tempHome(t)
+	os.Unsetenv(EnvWorkspace)
+
+	var w Workspaces
+	if w.Active() != DefaultWorkspace {
+		t.Fatalf("Workspaces.Active: want: %s, have: %s", DefaultWorkspace, w.Active())
+	}
+	acme := Config{Backend: Local, Options: map[string]string{"dir": WorkspaceDir("acme")}}
+	if err := w.Add("acme", acme); err != nil {
+		t.Fatalf("Workspaces.Add: want: nil, have: %v", err)
+	}
+	for _, name := range []string{"acme", DefaultWorkspace} {
+		if err := w.Add(name, acme); !errors.Is(err, ErrWorkspaceExists) {
+			t.Fatalf("Workspaces.Add(%s): want: %v, have: %v", name, ErrWorkspaceExists, err)
+		}
+	}
+	if err := w.Add("../acme", acme); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Workspaces.Add(../acme): want: %v, have: %v", ErrInvalidConfig, err)
+	}
+	if err := w.Add("globex", Config{Backend: Memory}); err != nil {
+		t.Fatalf("Workspaces.Add: want: nil, have: %v", err)
+	}
+	if want := []string{DefaultWorkspace, "acme", "globex"}; !reflect.DeepEqual(w.Names(), want) {
+		t.Fatalf("Workspaces.Names: want: %v, have: %v", want, w.Names())
+	}
+	if err := w.Use("initech"); !errors.Is(err, ErrNoSuchWorkspace) {
+		t.Fatalf("Workspaces.Use: want: %v, have: %v", ErrNoSuchWorkspace, err)
+	}
+	if err := w.Use("acme"); err != nil {
+		t.Fatalf("Workspaces.Use: want: nil, have: %v", err)
+	}
+	if err := WriteWorkspaces(w); err != nil {
+		t.Fatalf("storage.WriteWorkspaces: want: nil, have: %v", err)
+	}
+
+	// the current workspace selects the storage config
+	config, err := LoadConfig()
+	if err != nil || !reflect.DeepEqual(config, acme) {
+		t.Fatalf("storage.LoadConfig: want: %+v, have: %+v (%v)", acme, config, err)
+	}
+	os.Setenv(EnvWorkspace, "globex")
+	if config, _ := LoadConfig(); config.Backend != Memory {
+		t.Fatalf("storage.LoadConfig(%s=globex): want: %s, have: %s", EnvWorkspace, Memory, config.Backend)
+	}
+	os.Setenv(EnvWorkspace, "initech")
+	if _, err := LoadConfig(); !errors.Is(err, ErrNoSuchWorkspace) {
+		t.Fatalf("storage.LoadConfig(%s=initech): want: %v, have: %v", EnvWorkspace, ErrNoSuchWorkspace, err)
+	}
+	os.Setenv(EnvWorkspace, DefaultWorkspace)
+	if config, _ := LoadConfig(); !reflect.DeepEqual(config, Config{Backend: Local}) {
+		t.Fatalf("storage.LoadConfig(default): want: local, have: %+v", config)
+	}
+	os.Unsetenv(EnvWorkspace)
+
+	// a local workspace keeps its vaults in its own directory
+	fileSystem, err := Open()
+	if err != nil {
+		t.Fatalf("storage.Open: want: nil, have: %v", err)
+	}
+	ctx := context.Background()
+	sh := internal.NewSherlock(fileSystem)
+	if err := sh.Setup(ctx, "default_group_key"); err != nil {
+		t.Fatalf("sherlock.Setup: want: nil, have: %v", err)
+	}
+	if err := sh.SetupGroup(ctx, "client", "client_group_key", true); err != nil {
+		t.Fatalf("sherlock.SetupGroup: want: nil, have: %v", err)
+	}
+	if groups, _ := sh.ReadRegisteredGroups(ctx); !reflect.DeepEqual(groups, []string{"client", "default"}) {
+		t.Fatalf("sherlock.ReadRegisteredGroups: want: [client default], have: %v", groups)
+	}
+	if _, err := os.Stat(filepath.Join(WorkspaceDir("acme"), groupsKey, "client", vaultKey)); err != nil {
+		t.Fatalf("workspace vault: want: stored in the workspace dir, have: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".sherlock", groupsKey)); !os.IsNotExist(err) {
+		t.Fatalf("default vaults: want: untouched, have: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enescakir/emoji"
+	"github.com/fatih/color"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	// progressWidth is the number of cells of the progress bar
+	progressWidth = 24
+	// spinInterval is how often the spinner of an interactive progress is redrawn
+	spinInterval = 100 * time.Millisecond
+	// eventInterval is how often a json progress event is written at most
+	eventInterval = time.Second
+)
+
+var spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// ProgressEvent is a json progress event written by a Progress in json mode
+type ProgressEvent struct {
+	Operation string `json:"operation"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	Failed    int    `json:"failed"`
+	Item      string `json:"item,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Finished  bool   `json:"finished,omitempty"`
+}
+
+// Progress reports the progress of an operation over a number of items on stderr.
+// On a terminal a spinner, a progress bar and the current item are redrawn and
+// failed items are listed. In json mode a {"progress":...} event is written
+// at most once a second and once the operation finished. Otherwise nothing is written
+// so piped output stays clean
+type Progress struct {
+	mu        sync.Mutex
+	w         io.Writer
+	json      bool
+	tty       bool
+	operation string
+	total     int
+	done      int
+	failed    int
+	item      string
+	frame     int
+	lastEvent time.Time
+	stop      chan struct{}
+	stopped   sync.WaitGroup
+}
+
+// NewProgress starts reporting the progress of the operation over total items
+func NewProgress(operation string, total int, jsonOutput bool) *Progress {
+	p := &Progress{
+		w:         os.Stderr,
+		json:      jsonOutput,
+		tty:       !jsonOutput && terminal.IsTerminal(int(os.Stderr.Fd())),
+		operation: operation,
+		total:     total,
+		stop:      make(chan struct{}),
+	}
+	if p.tty {
+		p.stopped.Add(1)
+		go p.spin()
+	}
+	return p
+}
+
+// Start marks the item as the one currently worked on
+func (p *Progress) Start(item string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.item = item
+	p.draw()
+}
+
+// Done marks the item as finished. A failed item is listed on a terminal and
+// reported with the status "failed" in json mode
+func (p *Progress) Done(item string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	status := "ok"
+	if err != nil {
+		p.failed++
+		status = "failed"
+		if p.tty {
+			fmt.Fprint(p.w, clearLine)
+			_, _ = color.New(color.FgRed).Fprintf(p.w, "%v %s: %v\n", emoji.CrossMark, item, err)
+		}
+	}
+	if p.json && (err != nil || time.Since(p.lastEvent) >= eventInterval) {
+		p.event(item, status, false)
+	}
+	p.item = ""
+	p.draw()
+}
+
+// Finish stops the progress. On a terminal the progress line is cleared, in json
+// mode a final event is written
+func (p *Progress) Finish() {
+	if p.tty {
+		close(p.stop)
+		p.stopped.Wait()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty {
+		fmt.Fprint(p.w, clearLine)
+	}
+	if p.json {
+		p.event("", "", true)
+	}
+}
+
+// spin redraws the progress until it is stopped
+func (p *Progress) spin() {
+	defer p.stopped.Done()
+	ticker := time.NewTicker(spinInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// draw redraws the progress line on a terminal
+func (p *Progress) draw() {
+	if !p.tty {
+		return
+	}
+	filled := 0
+	if p.total > 0 {
+		filled = p.done * progressWidth / p.total
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressWidth-filled)
+	fmt.Fprint(p.w, clearLine)
+	_, _ = color.New(color.FgHiBlue).Fprintf(p.w, "%s %s %s %d/%d %s",
+		spinner[p.frame%len(spinner)], p.operation, bar, p.done, p.total, p.item)
+}
+
+// event writes a json progress event
+func (p *Progress) event(item, status string, finished bool) {
+	p.lastEvent = time.Now()
+	_ = json.NewEncoder(p.w).Encode(struct {
+		Progress ProgressEvent `json:"progress"`
+	}{
+		Progress: ProgressEvent{
+			Operation: p.operation,
+			Done:      p.done,
+			Total:     p.total,
+			Failed:    p.failed,
+			Item:      item,
+			Status:    status,
+			Finished:  finished,
+		},
+	})
+}
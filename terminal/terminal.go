@@ -2,13 +2,16 @@ package terminal
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/enescakir/emoji"
 	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
 	"github.com/olekukonko/tablewriter"
 	"golang.org/x/crypto/ssh/terminal"
 )
@@ -34,6 +37,18 @@ const banner = `
 ╚══════╝ ╚═════╝  ╚═════╝╚═╝  ╚═╝╚══════╝╚═════╝
 `
 
+const (
+	// clearLine moves the cursor to the start of the line and clears it
+	clearLine = "\r\033[K"
+	// clearPreviousLine moves the cursor up one row and clears it
+	clearPreviousLine = "\033[1A\r\033[K"
+	// ctrlC is the byte read for ctrl+c from a terminal in raw mode
+	ctrlC = 3
+	// maskLength is the number of characters shown for a masked secret so
+	// the mask does not reveal the length of the secret
+	maskLength = 12
+)
+
 func Success(format string, a ...interface{}) {
 	pretty(color.FgGreen, emoji.Emoji(emoji.RaisingHands.String()), format, a...)
 }
@@ -50,6 +65,12 @@ func Error(format string, a ...interface{}) {
 	pretty(color.FgRed, emoji.ExclamationMark, format, a...)
 }
 
+// MessagesToStderr writes all messages and prompts to stderr, e.g. for
+// commands whose stdout is read by another program
+func MessagesToStderr() {
+	color.Output = color.Error
+}
+
 func Banner() {
 	_, _ = color.New(color.FgHiGreen).Printf(fmt.Sprintf("%s\n", banner))
 }
@@ -63,7 +84,7 @@ func ReadPassword(format string, a ...interface{}) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	fmt.Print("\n")
+	fmt.Fprint(color.Output, "\n")
 	return string(b), nil
 }
 
@@ -74,6 +95,45 @@ func ReadLine(format string, a ...interface{}) (string, error) {
 
 }
 
+// ReadLines prompts the user for multiple lines of input. Reading stops at
+// the first empty line or the end of the input
+func ReadLines(format string, a ...interface{}) ([]string, error) {
+	pretty(color.FgHiBlue, emoji.Pencil, format, a...)
+	var lines []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// sparks are the block characters of a sparkline from low to high
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the values as a line of block characters scaled to
+// the largest value
+func Sparkline(values []int) string {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	line := make([]rune, len(values))
+	for i, v := range values {
+		if max == 0 || v <= 0 {
+			line[i] = sparks[0]
+			continue
+		}
+		line[i] = sparks[(v*(len(sparks)-1)+max-1)/max]
+	}
+	return string(line)
+}
+
 // YesNo prompts the user with a confirm dialog. in every case except for "y"
 // (lowercase y) the return will be false
 func YesNo(format string) bool {
@@ -144,3 +204,81 @@ func TableWithCellMerge(mergeByIndex int) func(*tablewriter.Table) {
 		t.SetRowLine(true)
 	}
 }
+
+// Reveal prints the secret masked and reveals it once a key is pressed. After the
+// duration has passed or the context is cancelled the secret is masked again and
+// every row of the line is cleared so it does not remain in the terminal's scrollback
+func Reveal(ctx context.Context, label, secret string, d time.Duration) error {
+	fd := int(syscall.Stdin)
+	if !terminal.IsTerminal(fd) {
+		return fmt.Errorf("revealing a secret requires an interactive terminal")
+	}
+	masked := strings.Repeat("*", maskLength)
+	prettyNoNewLine(color.FgHiBlue, emoji.Key, "(%s) %s press any key to reveal", label, masked)
+
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 1)
+	_, err = os.Stdin.Read(key)
+	if restoreErr := terminal.Restore(fd, state); err == nil {
+		err = restoreErr
+	}
+	if err != nil {
+		return err
+	}
+	// raw mode delivers ctrl+c as byte instead of an interrupt
+	if key[0] == ctrlC {
+		fmt.Println()
+		return context.Canceled
+	}
+
+	rows := 1
+	defer func() {
+		clearRows(rows)
+		pretty(color.FgHiBlue, emoji.Key, "(%s) %s", label, masked)
+	}()
+	for left := d; left > 0; left -= time.Second {
+		clearRows(rows)
+		line := fmt.Sprintf("%v (%s) %s (%ds)", emoji.Key, label, secret, int(left.Seconds()))
+		rows = lineRows(line)
+		_, _ = color.New(color.FgHiBlue).Print(line)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(minDuration(time.Second, left)):
+		}
+	}
+	return nil
+}
+
+// clearRows clears the current row and the rows above it which a wrapped
+// line spans and leaves the cursor at the start of the first row
+func clearRows(rows int) {
+	fmt.Print(clearLine)
+	for i := 1; i < rows; i++ {
+		fmt.Print(clearPreviousLine)
+	}
+}
+
+// lineRows returns the number of terminal rows the line occupies once it is
+// wrapped at the width of the terminal
+func lineRows(line string) int {
+	width, _, err := terminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		return 1
+	}
+	n := runewidth.StringWidth(line)
+	if n == 0 {
+		return 1
+	}
+	return (n + width - 1) / width
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,81 @@
+// Package webhook delivers signed notifications to an HTTPS endpoint
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the request body keyed
+	// with the webhook secret like so sha256={hex}
+	SignatureHeader = "X-Sherlock-Signature"
+	// Timeout limits how long a single delivery may take
+	Timeout = 5 * time.Second
+)
+
+var (
+	ErrInsecureURL = fmt.Errorf("webhook url must be an https:// url")
+)
+
+// CheckURL verifies the url can be used as webhook. Only https is accepted
+// since the events tell which accounts exist
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return ErrInsecureURL
+	}
+	return nil
+}
+
+// Sign returns the value of the SignatureHeader for the body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a body as received by the webhook endpoint
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Send posts the payload as json to the url signed with the secret. Any
+// response status other than 2xx is returned as error
+func Send(ctx context.Context, client *http.Client, target string, secret []byte, payload interface{}) error {
+	if err := CheckURL(target); err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	secret := []byte("webhook-secret")
+	var received map[string]string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !Verify(secret, body, r.Header.Get(SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &received)
+	}))
+	defer srv.Close()
+
+	payload := map[string]string{"group": "work", "operation": "added"}
+	if err := Send(context.Background(), srv.Client(), srv.URL, secret, payload); err != nil {
+		t.Fatalf("webhook.Send: want: nil, have: %v", err)
+	}
+	if received["group"] != "work" || received["operation"] != "added" {
+		t.Fatalf("webhook.Send: want: %v, have: %v", payload, received)
+	}
+	if err := Send(context.Background(), srv.Client(), srv.URL, []byte("wrong"), payload); err == nil {
+		t.Fatalf("webhook.Send: want: error for rejected signature, have: nil")
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tt := []struct {
+		url    string
+		expect error
+	}{
+		{url: "https://hooks.example.com/sherlock", expect: nil},
+		{url: "http://hooks.example.com/sherlock", expect: ErrInsecureURL},
+		{url: "https://", expect: ErrInsecureURL},
+		{url: "hooks.example.com", expect: ErrInsecureURL},
+	}
+	for _, tc := range tt {
+		if err := CheckURL(tc.url); err != tc.expect {
+			t.Fatalf("webhook.CheckURL(%s): want: %v, have: %v", tc.url, tc.expect, err)
+		}
+	}
+}